@@ -82,6 +82,31 @@ Username=_username, Discriminator=_discriminator, Avatar=_avatar, Email = _email
 DELIMITER ;
 
 
+-- Dumping structure for table sweetiebot.activity
+CREATE TABLE IF NOT EXISTS `activity` (
+  `Guild` bigint(20) unsigned NOT NULL,
+  `ID` bigint(20) unsigned NOT NULL,
+  `Day` date NOT NULL,
+  `Count` int(10) unsigned NOT NULL DEFAULT '0',
+  PRIMARY KEY (`Guild`,`ID`,`Day`),
+  KEY `INDEX_DAY` (`Day`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Number of messages each member sent per day, for the last 30 days.';
+
+-- Data exporting was unselected.
+
+
+-- Dumping structure for table sweetiebot.afk
+CREATE TABLE IF NOT EXISTS `afk` (
+  `Guild` bigint(20) unsigned NOT NULL,
+  `ID` bigint(20) unsigned NOT NULL,
+  `Message` varchar(256) NOT NULL DEFAULT '',
+  `Timestamp` datetime NOT NULL,
+  PRIMARY KEY (`Guild`,`ID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.aliases
 CREATE TABLE IF NOT EXISTS `aliases` (
   `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
@@ -97,6 +122,38 @@ CREATE TABLE IF NOT EXISTS `aliases` (
 -- Data exporting was unselected.
 
 
+-- Dumping structure for table sweetiebot.approvals
+CREATE TABLE IF NOT EXISTS `approvals` (
+  `Guild` bigint(20) unsigned NOT NULL,
+  `ID` bigint(20) unsigned NOT NULL,
+  `Channel` bigint(20) unsigned NOT NULL DEFAULT '0',
+  `Queued` bigint(20) unsigned NOT NULL DEFAULT '0',
+  `Content` text NOT NULL,
+  `Timestamp` datetime NOT NULL,
+  `Trusted` tinyint(1) NOT NULL DEFAULT '0',
+  PRIMARY KEY (`Guild`,`ID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Data exporting was unselected.
+
+
+-- Dumping structure for table sweetiebot.channelreminders
+CREATE TABLE IF NOT EXISTS `channelreminders` (
+  `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `Guild` bigint(20) unsigned NOT NULL,
+  `Channel` bigint(20) unsigned NOT NULL,
+  `Spec` varchar(128) NOT NULL,
+  `Message` text NOT NULL,
+  `NextRun` datetime NOT NULL,
+  `Paused` tinyint(1) NOT NULL DEFAULT '0',
+  PRIMARY KEY (`ID`),
+  KEY `INDEX_GUILD` (`Guild`),
+  KEY `INDEX_NEXTRUN` (`Paused`,`NextRun`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Recurring messages posted to a channel on a cron schedule.';
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.chatlog
 CREATE TABLE IF NOT EXISTS `chatlog` (
   `ID` bigint(20) unsigned NOT NULL,
@@ -132,6 +189,17 @@ END//
 DELIMITER ;
 
 
+-- Dumping structure for table sweetiebot.colorroles
+CREATE TABLE IF NOT EXISTS `colorroles` (
+  `Guild` bigint(20) unsigned NOT NULL,
+  `User` bigint(20) unsigned NOT NULL,
+  `Role` bigint(20) unsigned NOT NULL,
+  PRIMARY KEY (`Guild`,`User`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.debuglog
 CREATE TABLE IF NOT EXISTS `debuglog` (
   `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
@@ -369,6 +437,16 @@ END//
 DELIMITER ;
 
 
+-- Dumping structure for table sweetiebot.jobs
+CREATE TABLE IF NOT EXISTS `jobs` (
+  `Name` varchar(64) NOT NULL,
+  `NextRun` datetime NOT NULL,
+  PRIMARY KEY (`Name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='When each recurring job should run next, so restarts resume the schedule.';
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.markov_transcripts
 CREATE TABLE IF NOT EXISTS `markov_transcripts` (
   `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
@@ -427,6 +505,72 @@ CREATE TABLE IF NOT EXISTS `members` (
 -- Data exporting was unselected.
 
 
+-- Dumping structure for table sweetiebot.modlog
+CREATE TABLE IF NOT EXISTS `modlog` (
+  `ID` bigint(20) unsigned NOT NULL COMMENT 'ID of the discord audit log entry',
+  `Guild` bigint(20) unsigned NOT NULL,
+  `Type` tinyint(3) unsigned NOT NULL,
+  `User` bigint(20) unsigned NOT NULL,
+  `Moderator` bigint(20) unsigned NOT NULL,
+  `Reason` varchar(512) NOT NULL DEFAULT '',
+  `Timestamp` datetime NOT NULL,
+  PRIMARY KEY (`ID`),
+  KEY `INDEX_GUILD_USER` (`Guild`,`User`),
+  KEY `INDEX_GUILD_TIMESTAMP` (`Guild`,`Timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Moderation actions taken on each server, reconciled from the discord audit log, plus silences recorded by the bot.';
+
+-- Data exporting was unselected.
+
+
+-- Dumping structure for table sweetiebot.offenses
+CREATE TABLE IF NOT EXISTS `offenses` (
+  `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `Guild` bigint(20) unsigned NOT NULL,
+  `User` bigint(20) unsigned NOT NULL,
+  `Type` tinyint(3) unsigned NOT NULL,
+  `Moderator` bigint(20) unsigned NOT NULL,
+  `Reason` varchar(500) NOT NULL DEFAULT '',
+  `Timestamp` datetime NOT NULL,
+  PRIMARY KEY (`ID`),
+  KEY `INDEX_USER` (`Guild`,`User`,`Timestamp`),
+  KEY `INDEX_GUILD_TIMESTAMP` (`Guild`,`Timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Data exporting was unselected.
+
+
+-- Dumping structure for table sweetiebot.operations
+CREATE TABLE IF NOT EXISTS `operations` (
+  `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `Guild` bigint(20) unsigned NOT NULL,
+  `Kind` varchar(32) NOT NULL,
+  `Author` bigint(20) unsigned NOT NULL,
+  `Channel` bigint(20) unsigned NOT NULL,
+  `Description` varchar(255) NOT NULL,
+  `State` mediumtext NOT NULL,
+  `Total` int(11) NOT NULL,
+  `Done` int(11) NOT NULL DEFAULT '0',
+  `Failed` int(11) NOT NULL DEFAULT '0',
+  `Updated` datetime NOT NULL,
+  PRIMARY KEY (`ID`),
+  KEY `INDEX_GUILD` (`Guild`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Long running bulk operations and how far they got, so they can be resumed after a restart.';
+
+-- Data exporting was unselected.
+
+
+-- Dumping structure for table sweetiebot.pinboard
+CREATE TABLE IF NOT EXISTS `pinboard` (
+  `Message` bigint(20) unsigned NOT NULL,
+  `Guild` bigint(20) unsigned NOT NULL,
+  `Mirror` bigint(20) unsigned NOT NULL,
+  PRIMARY KEY (`Message`),
+  KEY `INDEX_GUILD` (`Guild`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.polloptions
 CREATE TABLE IF NOT EXISTS `polloptions` (
   `Poll` bigint(20) unsigned NOT NULL,
@@ -532,6 +676,52 @@ CREATE TABLE IF NOT EXISTS `schedule` (
 -- Data exporting was unselected.
 
 
+-- Dumping structure for table sweetiebot.stickies
+CREATE TABLE IF NOT EXISTS `stickies` (
+  `Channel` bigint(20) unsigned NOT NULL,
+  `Guild` bigint(20) unsigned NOT NULL,
+  `Message` text NOT NULL,
+  `Current` bigint(20) unsigned NOT NULL DEFAULT '0',
+  PRIMARY KEY (`Channel`),
+  KEY `INDEX_GUILD` (`Guild`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Data exporting was unselected.
+
+
+-- Dumping structure for table sweetiebot.suggestions
+CREATE TABLE IF NOT EXISTS `suggestions` (
+  `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `Guild` bigint(20) unsigned NOT NULL,
+  `Author` bigint(20) unsigned NOT NULL,
+  `Channel` bigint(20) unsigned NOT NULL DEFAULT '0',
+  `Message` bigint(20) unsigned NOT NULL DEFAULT '0',
+  `Content` text NOT NULL,
+  `Status` tinyint(3) unsigned NOT NULL DEFAULT '0',
+  `Up` int(11) NOT NULL DEFAULT '0',
+  `Down` int(11) NOT NULL DEFAULT '0',
+  `Moderator` bigint(20) unsigned NOT NULL DEFAULT '0',
+  `Reason` varchar(512) NOT NULL DEFAULT '',
+  `Timestamp` datetime NOT NULL,
+  PRIMARY KEY (`ID`),
+  KEY `INDEX_GUILD_STATUS` (`Guild`,`Status`),
+  KEY `INDEX_MESSAGE` (`Message`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Posts in the suggestion channel, their status and how they were voted on.';
+
+-- Data exporting was unselected.
+
+
+-- Dumping structure for table sweetiebot.tempchannels
+CREATE TABLE IF NOT EXISTS `tempchannels` (
+  `Channel` bigint(20) unsigned NOT NULL,
+  `Guild` bigint(20) unsigned NOT NULL,
+  PRIMARY KEY (`Channel`),
+  KEY `INDEX_GUILD` (`Guild`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.timezones
 CREATE TABLE IF NOT EXISTS `timezones` (
   `Location` varchar(40) NOT NULL,
@@ -577,6 +767,17 @@ CREATE TABLE IF NOT EXISTS `users` (
 -- Data exporting was unselected.
 
 
+-- Dumping structure for table sweetiebot.verified
+CREATE TABLE IF NOT EXISTS `verified` (
+  `Guild` bigint(20) unsigned NOT NULL,
+  `User` bigint(20) unsigned NOT NULL,
+  `Since` datetime NOT NULL,
+  PRIMARY KEY (`Guild`,`User`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.votes
 CREATE TABLE IF NOT EXISTS `votes` (
   `Poll` bigint(20) unsigned NOT NULL,
@@ -0,0 +1,147 @@
+package sweetiebot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type afkStatus struct {
+	message   string
+	since     time.Time
+	lastreply int64
+}
+
+// AFKModule lets users mark themselves as away. The state is kept in memory and mirrored to the database so it survives restarts.
+type AFKModule struct {
+	afk  map[uint64]*afkStatus
+	lock sync.Mutex
+}
+
+// AFK states older than this are cleared even if the user never comes back
+const afkExpiry = 7 * 24 * time.Hour
+
+// Name of the module
+func (w *AFKModule) Name() string {
+	return "AFK"
+}
+
+// Commands in the module
+func (w *AFKModule) Commands() []Command {
+	return []Command{
+		&afkCommand{w},
+	}
+}
+
+// Description of the module
+func (w *AFKModule) Description() string {
+	return "Lets users mark themselves as away with `afk`. When someone mentions an away user, Sweetie Bot replies with their away message, at most once a minute per user. The away state is cleared as soon as the user posts again, or after a week."
+}
+
+func (w *AFKModule) load(info *GuildInfo) {
+	if sb.db.status.get() {
+		w.afk = sb.db.GetAFK(SBatoi(info.ID))
+	} else {
+		w.afk = make(map[uint64]*afkStatus)
+	}
+}
+
+func (w *AFKModule) clear(info *GuildInfo, user uint64) bool {
+	w.lock.Lock()
+	_, ok := w.afk[user]
+	delete(w.afk, user)
+	w.lock.Unlock()
+	if ok && sb.db.CheckStatus() {
+		sb.db.RemoveAFK(user, SBatoi(info.ID))
+	}
+	return ok
+}
+
+// OnMessageCreate discord hook
+func (w *AFKModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	if w.clear(info, SBatoi(m.Author.ID)) && info.takeChatterNow("afk") {
+		info.SendMessage(m.ChannelID, "Welcome back, "+getUserName(SBatoi(m.Author.ID), info)+"! I've removed your AFK status.")
+	}
+
+	now := time.Now().UTC()
+	replies := []string{}
+	w.lock.Lock()
+	for _, u := range m.Mentions {
+		if s, ok := w.afk[SBatoi(u.ID)]; ok && u.ID != m.Author.ID && RateLimit(&s.lastreply, 60) {
+			reply := getUserName(SBatoi(u.ID), info) + " went AFK " + TimeDiff(now.Sub(s.since)) + " ago"
+			if len(s.message) > 0 {
+				reply += ": " + s.message
+			} else {
+				reply += "."
+			}
+			replies = append(replies, reply)
+		}
+	}
+	w.lock.Unlock()
+	if len(replies) > 0 && info.takeChatterNow("afk") {
+		info.SendMessage(m.ChannelID, strings.Join(replies, "\n"))
+	}
+}
+
+// OnCommand discord hook
+func (w *AFKModule) OnCommand(info *GuildInfo, m *discordgo.Message) bool {
+	if args, _ := ParseArguments(strings.TrimPrefix(m.Content, info.config.Basic.CommandPrefix)); len(args) > 0 && strings.ToLower(args[0]) != "afk" {
+		w.clear(info, SBatoi(m.Author.ID))
+	}
+	return false
+}
+
+// OnTick discord hook
+func (w *AFKModule) OnTick(info *GuildInfo) {
+	cutoff := time.Now().UTC().Add(-afkExpiry)
+	expired := []uint64{}
+	w.lock.Lock()
+	for k, v := range w.afk {
+		if v.since.Before(cutoff) {
+			expired = append(expired, k)
+		}
+	}
+	w.lock.Unlock()
+	for _, k := range expired {
+		w.clear(info, k)
+	}
+}
+
+type afkCommand struct {
+	w *AFKModule
+}
+
+func (c *afkCommand) Name() string {
+	return "AFK"
+}
+func (c *afkCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	message := ""
+	if len(args) > 0 {
+		message = strings.TrimSpace(msg.Content[indices[0]:])
+		if len(message) > 256 {
+			return "```Your AFK message can't be longer than 256 characters.```", false, nil
+		}
+		message = PartialSanitize(ReplaceAllMentions(message))
+		message = strings.Replace(message, "@everyone", "@\u200Beveryone", -1)
+		message = strings.Replace(message, "@here", "@\u200Bhere", -1)
+	}
+	s := &afkStatus{message: message, since: time.Now().UTC()}
+	c.w.lock.Lock()
+	c.w.afk[SBatoi(msg.Author.ID)] = s
+	c.w.lock.Unlock()
+	if sb.db.CheckStatus() {
+		sb.db.SetAFK(SBatoi(msg.Author.ID), SBatoi(info.ID), s.message, s.since)
+	}
+	return "```" + getUserName(SBatoi(msg.Author.ID), info) + " is now AFK. Your status will be cleared the next time you post.```", false, nil
+}
+func (c *afkCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Marks you as away. While you're away, anyone who mentions you gets a reply with your message and how long you've been gone. Posting anything clears your status.",
+		Params: []CommandUsageParam{
+			{Name: "message", Desc: "An optional message explaining where you went.", Optional: true},
+		},
+	}
+}
+func (c *afkCommand) UsageShort() string { return "Marks you as away." }
@@ -0,0 +1,283 @@
+package sweetiebot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type pendingApproval struct {
+	channel uint64
+	queued  string // ID of the message in the approval channel, empty while it's being posted
+	content string
+	since   time.Time
+}
+
+// ApprovalModule holds the first message of every new member until a moderator approves it. Members that have been approved
+// are remembered in the database, while members that have been on the server longer than approval.tenure are trusted automatically.
+type ApprovalModule struct {
+	trusted map[uint64]bool
+	pending map[uint64]*pendingApproval
+	lock    sync.Mutex
+}
+
+const approveEmoji = "✅"
+const rejectEmoji = "❌"
+
+// Name of the module
+func (w *ApprovalModule) Name() string {
+	return "Approval"
+}
+
+// Commands in the module
+func (w *ApprovalModule) Commands() []Command {
+	return []Command{
+		&approveCommand{w},
+	}
+}
+
+// Description of the module
+func (w *ApprovalModule) Description() string {
+	return "If `approval.enabled` is true, the first message a new member posts is deleted and sent to the approval channel, where a moderator can react with " + approveEmoji + " to repost it and let the member post freely, or " + rejectEmoji + " to throw it away. Members who have been on the server longer than `approval.tenure` are approved automatically."
+}
+
+func (w *ApprovalModule) load(info *GuildInfo) {
+	if sb.db.status.get() {
+		w.trusted, w.pending = sb.db.GetApprovals(SBatoi(info.ID))
+	} else {
+		w.trusted = make(map[uint64]bool)
+		w.pending = make(map[uint64]*pendingApproval)
+	}
+}
+
+func approvalChannel(info *GuildInfo) string {
+	if info.config.Approval.Channel != 0 {
+		return SBitoa(info.config.Approval.Channel)
+	}
+	if info.config.Basic.ModChannel != 0 {
+		return SBitoa(info.config.Basic.ModChannel)
+	}
+	return ""
+}
+
+// Returns true if the member has been on the server long enough to skip the approval queue
+func pastApprovalTenure(info *GuildInfo, user string) bool {
+	if info.config.Approval.Tenure <= 0 {
+		return false
+	}
+	m, err := info.GetMember(user)
+	if err != nil {
+		return false
+	}
+	return time.Now().UTC().Sub(m.JoinedAt) >= time.Duration(info.config.Approval.Tenure)*time.Second
+}
+
+// OnMessageCreate discord hook
+func (w *ApprovalModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	if !info.config.Approval.Enabled || m.Author.Bot {
+		return
+	}
+	queue := approvalChannel(info)
+	if len(queue) == 0 {
+		return // If there's nowhere to send messages for approval, holding them would just delete them
+	}
+	user := SBatoi(m.Author.ID)
+	w.lock.Lock()
+	trusted := w.trusted[user]
+	_, pending := w.pending[user]
+	w.lock.Unlock()
+	if trusted {
+		return
+	}
+	if !pending && (info.HasModRole(m.Author.ID) || pastApprovalTenure(info, m.Author.ID)) {
+		return
+	}
+
+	p := &pendingApproval{channel: SBatoi(m.ChannelID), content: m.Content, since: time.Now().UTC()}
+	for _, a := range m.Attachments {
+		p.content += "\n[attachment: " + a.Filename + "]" // Discord deletes attachments along with the message, so only the name survives
+	}
+	w.lock.Lock()
+	_, pending = w.pending[user]
+	if !pending {
+		w.pending[user] = p
+	}
+	w.lock.Unlock()
+	deleteMessage(m.ChannelID, m.ID)
+	if pending {
+		return // Only the first message is queued, anything else they post before being approved is dropped
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Type: "rich",
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    m.Author.Username + " (" + m.Author.ID + ")",
+			IconURL: m.Author.AvatarURL(""),
+		},
+		Description: p.content,
+		Fields:      []*discordgo.MessageEmbedField{{Name: "Channel", Value: "<#" + m.ChannelID + ">"}},
+		Footer:      &discordgo.MessageEmbedFooter{Text: "React with " + approveEmoji + " to post this and trust the member, or " + rejectEmoji + " to discard it."},
+	}
+	queued, err := sb.dg.ChannelMessageSendEmbed(queue, embed)
+	if err != nil {
+		info.Log("Failed to queue a message for approval: ", err.Error())
+		w.lock.Lock()
+		delete(w.pending, user)
+		w.lock.Unlock()
+		info.SendMessage(m.ChannelID, "<@"+m.Author.ID+">, your message couldn't be sent to the moderators for approval. Please try again later.")
+		return
+	}
+	w.lock.Lock()
+	p.queued = queued.ID
+	w.lock.Unlock()
+	sb.dg.MessageReactionAdd(queue, queued.ID, approveEmoji)
+	sb.dg.MessageReactionAdd(queue, queued.ID, rejectEmoji)
+	if sb.db.CheckStatus() {
+		sb.db.SetApprovalPending(user, SBatoi(info.ID), p.channel, SBatoi(queued.ID), p.content, p.since)
+	}
+	info.SendMessage(m.ChannelID, "<@"+m.Author.ID+">, the first message new members post has to be approved by a moderator. Yours will show up here once it has been approved.")
+}
+
+// OnMessageReactionAdd discord hook
+func (w *ApprovalModule) OnMessageReactionAdd(info *GuildInfo, r *discordgo.MessageReaction) {
+	if r.ChannelID != approvalChannel(info) || (r.Emoji.Name != approveEmoji && r.Emoji.Name != rejectEmoji) {
+		return
+	}
+	if !info.HasModRole(r.UserID) {
+		return
+	}
+	var user uint64
+	w.lock.Lock()
+	for k, v := range w.pending {
+		if v.queued == r.MessageID {
+			user = k
+		}
+	}
+	w.lock.Unlock()
+	if user == 0 {
+		return
+	}
+	if r.Emoji.Name == approveEmoji {
+		w.approve(info, user, getUserName(SBatoi(r.UserID), info))
+	} else {
+		w.reject(info, user, getUserName(SBatoi(r.UserID), info))
+	}
+}
+
+// OnGuildMemberRemove discord hook
+func (w *ApprovalModule) OnGuildMemberRemove(info *GuildInfo, m *discordgo.Member) {
+	w.lock.Lock()
+	_, ok := w.pending[SBatoi(m.User.ID)]
+	w.lock.Unlock()
+	if ok {
+		w.reject(info, SBatoi(m.User.ID), "") // Nobody needs to approve a message from someone who already left
+	}
+}
+
+// OnTick discord hook
+func (w *ApprovalModule) OnTick(info *GuildInfo) {
+	if info.config.Approval.Tenure <= 0 {
+		return
+	}
+	ready := []uint64{}
+	w.lock.Lock()
+	for k, v := range w.pending {
+		if len(v.queued) > 0 && pastApprovalTenure(info, SBitoa(k)) {
+			ready = append(ready, k)
+		}
+	}
+	w.lock.Unlock()
+	for _, k := range ready {
+		w.approve(info, k, "")
+	}
+}
+
+// Removes a pending approval, returning nil if someone else already handled it
+func (w *ApprovalModule) take(user uint64) *pendingApproval {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	p, ok := w.pending[user]
+	if !ok || len(p.queued) == 0 {
+		return nil
+	}
+	delete(w.pending, user)
+	return p
+}
+
+// Approves a pending message and trusts the member. If by is empty, the message was approved automatically.
+func (w *ApprovalModule) approve(info *GuildInfo, user uint64, by string) {
+	p := w.take(user)
+	w.lock.Lock()
+	w.trusted[user] = true
+	w.lock.Unlock()
+	if sb.db.CheckStatus() {
+		sb.db.SetApprovalTrusted(user, SBatoi(info.ID))
+	}
+	name := getUserName(user, info)
+	if p == nil {
+		if len(by) > 0 {
+			info.LogTo(LogModeration, by, " approved ", name, ".")
+		}
+		return
+	}
+	sb.dg.ChannelMessageDelete(approvalChannel(info), p.queued)
+	if len(by) == 0 {
+		info.LogTo(LogModeration, name, " was on the server long enough to be approved automatically.")
+	} else {
+		info.LogTo(LogModeration, by, " approved ", name, "'s first message.")
+	}
+	embed := &discordgo.MessageEmbed{
+		Type:        "rich",
+		Description: p.content,
+		Timestamp:   p.since.Format(time.RFC3339),
+	}
+	if u, err := sb.dg.User(SBitoa(user)); err == nil {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: name, IconURL: u.AvatarURL("")}
+	}
+	info.SendEmbed(SBitoa(p.channel), embed)
+}
+
+// Discards a pending message. The member stays untrusted, so their next message is held again.
+func (w *ApprovalModule) reject(info *GuildInfo, user uint64, by string) {
+	p := w.take(user)
+	if p == nil {
+		return
+	}
+	if sb.db.CheckStatus() {
+		sb.db.RemoveApproval(user, SBatoi(info.ID))
+	}
+	sb.dg.ChannelMessageDelete(approvalChannel(info), p.queued)
+	if len(by) > 0 {
+		info.LogTo(LogModeration, by, " rejected ", getUserName(user, info), "'s first message: ", strings.Replace(p.content, "\n", " ", -1))
+	}
+}
+
+type approveCommand struct {
+	w *ApprovalModule
+}
+
+func (c *approveCommand) Name() string {
+	return "Approve"
+}
+func (c *approveCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "user", Desc: "A ping of the user, or their name.", Type: ArgUser, Variadic: true},
+	}
+}
+func (c *approveCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *approveCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	user := args.User("user")
+	c.w.approve(info, user, getUserName(SBatoi(msg.Author.ID), info))
+	return "```" + getUserName(user, info) + " has been approved and can now post freely.```", false, nil
+}
+func (c *approveCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Approves a member, letting them skip the approval queue. If they have a message waiting for approval, it is posted.",
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *approveCommand) UsageShort() string { return "Approves a new member's messages." }
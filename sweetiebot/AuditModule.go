@@ -0,0 +1,487 @@
+package sweetiebot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// AuditModule logs moderation-relevant changes to the log channel, using the guild audit log to figure out who made them
+type AuditModule struct {
+	reconciling sync.Mutex // only one reconcile reads the audit log at a time
+	pending     AtomicFlag // set while a reconcile is waiting to start, so a burst of events only causes one
+}
+
+// How long to wait after an event before reconciling, so a mass kick or ban is picked up in one pass
+const auditReconcileDelay = 5 * time.Second
+
+// Name of the module
+func (w *AuditModule) Name() string {
+	return "Audit"
+}
+
+// Commands in the module
+func (w *AuditModule) Commands() []Command {
+	return []Command{
+		&modlogCommand{},
+	}
+}
+
+// Description of the module
+func (w *AuditModule) Description() string {
+	return "Logs role changes to the log channel, along with the moderator responsible for them, and keeps a history of bans and kicks that stays accurate even if they were done through discord while sweetiebot was offline. Requires the View Audit Log permission. Set `log.roles` to enable role change logging."
+}
+
+// Returns the roles in a that are not in b
+func roleDiff(a []string, b []string) []string {
+	r := []string{}
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if x == y {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r = append(r, x)
+		}
+	}
+	return r
+}
+
+// Checks if an audit log change of the given key lists all of the given roles
+func auditChangeHasRoles(entry *discordgo.AuditLogEntry, key discordgo.AuditLogChangeKey, roles []string) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	for _, c := range entry.Changes {
+		if c.Key == nil || *c.Key != key {
+			continue
+		}
+		values, ok := c.NewValue.([]interface{})
+		if !ok {
+			continue
+		}
+		count := 0
+		for _, r := range roles {
+			for _, v := range values {
+				if m, ok := v.(map[string]interface{}); ok && m["id"] == r {
+					count++
+					break
+				}
+			}
+		}
+		return count == len(roles)
+	}
+	return false
+}
+
+// Finds the audit log entry responsible for a member's role change. Returns nil if there isn't one, or we can't read the audit log.
+func findRoleUpdateEntry(info *GuildInfo, user string, added []string, removed []string) *discordgo.AuditLogEntry {
+	for i := 0; i < 3; i++ { // The audit log entry can show up a little after the gateway event does
+		log, err := sb.dg.GuildAuditLog(info.ID, "", "", int(discordgo.AuditLogActionMemberRoleUpdate), 10)
+		if err != nil {
+			return nil
+		}
+		for _, e := range log.AuditLogEntries {
+			if e.TargetID != user || time.Since(snowflakeTime(SBatoi(e.ID))) > 30*time.Second {
+				continue
+			}
+			if auditChangeHasRoles(e, discordgo.AuditLogChangeKeyRoleAdd, added) && auditChangeHasRoles(e, discordgo.AuditLogChangeKeyRoleRemove, removed) {
+				return e
+			}
+		}
+		time.Sleep(time.Second)
+	}
+	return nil
+}
+
+func roleNames(info *GuildInfo, roles []string) string {
+	names := make([]string, 0, len(roles))
+	for _, r := range roles {
+		if role, err := sb.dg.State.Role(info.ID, r); err == nil {
+			names = append(names, role.Name)
+		} else {
+			names = append(names, r)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// OnGuildMemberUpdate discord hook
+func (w *AuditModule) OnGuildMemberUpdate(info *GuildInfo, m *discordgo.GuildMemberUpdate) {
+	if !info.config.Log.Roles || m.BeforeUpdate == nil || m.User == nil {
+		return
+	}
+	added := roleDiff(m.Roles, m.BeforeUpdate.Roles)
+	removed := roleDiff(m.BeforeUpdate.Roles, m.Roles)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	go func() {
+		actor := "Someone"
+		reason := ""
+		entry := findRoleUpdateEntry(info, m.User.ID, added, removed)
+		if entry != nil {
+			if entry.UserID == sb.SelfID { // Automated changes (silencing, birthdays, etc.) go in the audit table instead of cluttering the log channel
+				if len(added) > 0 {
+					sb.db.Audit(AUDIT_TYPE_ACTION, m.User, "Added role(s) "+roleNames(info, added), SBatoi(info.ID))
+				}
+				if len(removed) > 0 {
+					sb.db.Audit(AUDIT_TYPE_ACTION, m.User, "Removed role(s) "+roleNames(info, removed), SBatoi(info.ID))
+				}
+				return
+			}
+			actor = getUserName(SBatoi(entry.UserID), info)
+			if len(entry.Reason) > 0 {
+				reason = " (" + entry.Reason + ")"
+			}
+		}
+		target := getUserName(SBatoi(m.User.ID), info)
+		if len(added) > 0 {
+			info.SendMessage(SBitoa(info.logChannel(LogModeration)), "```"+actor+" added "+Pluralize(int64(len(added)), " role")+" "+roleNames(info, added)+" to "+target+reason+"```")
+		}
+		if len(removed) > 0 {
+			info.SendMessage(SBitoa(info.logChannel(LogModeration)), "```"+actor+" removed "+Pluralize(int64(len(removed)), " role")+" "+roleNames(info, removed)+" from "+target+reason+"```")
+		}
+	}()
+}
+
+var modlogActions = map[discordgo.AuditLogAction]string{
+	discordgo.AuditLogActionMemberKick:      "Kicked",
+	discordgo.AuditLogActionMemberBanAdd:    "Banned",
+	discordgo.AuditLogActionMemberBanRemove: "Unbanned",
+}
+
+// Modlog types at or above modlogRecorded were recorded by the bot itself instead of the audit log, because discord
+// doesn't have an audit log entry for them
+const (
+	modlogRecorded  = 200
+	modlogSilence   = 200
+	modlogUnsilence = 201
+)
+
+var recordedModlogActions = map[uint8]string{
+	modlogSilence:   "Silenced",
+	modlogUnsilence: "Unsilenced",
+}
+
+// Records a moderation action that doesn't show up in the audit log. The ID is made to look like a snowflake from right
+// now, so it sorts with the audit log entries around it.
+func recordModlog(info *GuildInfo, ty uint8, user uint64, moderator uint64, reason string) {
+	if !sb.db.CheckStatus() {
+		return
+	}
+	now := time.Now().UTC()
+	id := ((uint64(now.UnixNano()/int64(time.Millisecond)) - DiscordEpoch) << 22) | uint64(rand.Int63n(1<<22))
+	sb.db.AddModlog(ModlogEntry{ID: id, Type: ty, User: user, Moderator: moderator, Reason: truncateRunes(reason, 512), Timestamp: now}, SBatoi(info.ID))
+}
+
+// Reconcile walks back through the guild audit log until it finds the newest moderation action we already know about,
+// and records everything that happened since then. This picks up any bans or kicks done through discord while we were offline.
+func (w *AuditModule) Reconcile(info *GuildInfo) {
+	if !sb.db.CheckStatus() {
+		return
+	}
+	w.reconciling.Lock()
+	defer w.reconciling.Unlock()
+	guild := SBatoi(info.ID)
+	newest := sb.db.GetNewestModlog(guild)
+	before := ""
+	count := 0
+	for page := 0; page < 10; page++ { // Never look back more than 1000 entries
+		log, err := sb.dg.GuildAuditLog(info.ID, "", before, 0, 100)
+		if err != nil || len(log.AuditLogEntries) == 0 {
+			break
+		}
+		for _, e := range log.AuditLogEntries {
+			id := SBatoi(e.ID)
+			if id <= newest {
+				page = 10
+				break
+			}
+			if e.ActionType == nil {
+				continue
+			}
+			if _, ok := modlogActions[*e.ActionType]; ok {
+				sb.db.AddModlog(ModlogEntry{ID: id, Type: uint8(*e.ActionType), User: SBatoi(e.TargetID), Moderator: SBatoi(e.UserID), Reason: e.Reason, Timestamp: snowflakeTime(id)}, guild)
+				count++
+			}
+		}
+		before = log.AuditLogEntries[len(log.AuditLogEntries)-1].ID
+	}
+	if count > 0 {
+		fmt.Println("Reconciled", count, "moderation actions from the audit log for", info.Name)
+	}
+}
+
+// Reconciles the audit log after auditReconcileDelay, unless a reconcile is already waiting to start. One that's already
+// running may have read the audit log before this event, so it doesn't count.
+func (w *AuditModule) scheduleReconcile(info *GuildInfo) {
+	if w.pending.test_and_set() {
+		return
+	}
+	go func() {
+		time.Sleep(auditReconcileDelay)
+		w.pending.clear()
+		w.Reconcile(info)
+	}()
+}
+
+// OnGuildCreate discord hook
+func (w *AuditModule) OnGuildCreate(info *GuildInfo, g *discordgo.Guild) {
+	w.scheduleReconcile(info)
+}
+
+// OnGuildBanAdd discord hook
+func (w *AuditModule) OnGuildBanAdd(info *GuildInfo, m *discordgo.GuildBanAdd) {
+	w.scheduleReconcile(info)
+}
+
+// OnGuildBanRemove discord hook
+func (w *AuditModule) OnGuildBanRemove(info *GuildInfo, m *discordgo.GuildBanRemove) {
+	w.scheduleReconcile(info)
+}
+
+// OnMessageDelete discord hook
+func (w *AuditModule) OnMessageDelete(info *GuildInfo, m *discordgo.Message) {
+	if len(info.config.Log.Channels[LogMessages]) == 0 {
+		return // Every deleted message would flood the main log channel, so this is only logged when asked for
+	}
+	cached := info.messagecache.Get(m.ID)
+	if cached == nil || cached.AuthorID == sb.SelfID {
+		return
+	}
+	s := getUserName(SBatoi(cached.AuthorID), info) + "'s message in #" + getChannelName(cached.ChannelID) + " was deleted: " + cached.Content
+	if len(cached.Attachments) > 0 {
+		s += "\nAttachments: " + strings.Join(cached.Attachments, " ")
+	}
+	info.LogTo(LogMessages, s)
+}
+
+// OnMessageUpdate discord hook
+func (w *AuditModule) OnMessageUpdate(info *GuildInfo, m *discordgo.Message) {
+	if len(info.config.Log.Channels[LogMessages]) == 0 {
+		return
+	}
+	cached := info.messagecache.Get(m.ID)
+	if cached == nil || !cached.Changed || cached.AuthorID == sb.SelfID {
+		return
+	}
+	info.LogTo(LogMessages, getUserName(SBatoi(cached.AuthorID), info), " edited a message in #", getChannelName(cached.ChannelID), ": ", messageLink(info, cached.ChannelID, cached.ID), "\nBefore: ", cached.Previous, "\nAfter: ", cached.Content)
+}
+
+// OnGuildMemberRemove discord hook
+func (w *AuditModule) OnGuildMemberRemove(info *GuildInfo, m *discordgo.Member) {
+	w.scheduleReconcile(info) // This might have been a kick
+}
+
+// A filter for !modlog, listing which modlog and offense types it matches
+type modlogFilter struct {
+	modlog  []uint8
+	offense []uint8
+}
+
+var modlogFilters = map[string]modlogFilter{
+	"warn":      {nil, []uint8{OFFENSE_WARNING}},
+	"silence":   {[]uint8{modlogSilence}, []uint8{OFFENSE_SPAM}},
+	"mute":      {[]uint8{modlogSilence}, []uint8{OFFENSE_SPAM}},
+	"unsilence": {[]uint8{modlogUnsilence}, nil},
+	"spam":      {nil, []uint8{OFFENSE_SPAM}},
+	"filter":    {nil, []uint8{OFFENSE_FILTER}},
+	"kick":      {[]uint8{uint8(discordgo.AuditLogActionMemberKick)}, nil},
+	"ban":       {[]uint8{uint8(discordgo.AuditLogActionMemberBanAdd)}, nil},
+	"unban":     {[]uint8{uint8(discordgo.AuditLogActionMemberBanRemove)}, nil},
+}
+
+// Everything except messages removed by the filter, which would drown out the real offenses
+var modlogDefaultFilter = modlogFilter{
+	[]uint8{uint8(discordgo.AuditLogActionMemberKick), uint8(discordgo.AuditLogActionMemberBanAdd), uint8(discordgo.AuditLogActionMemberBanRemove), modlogSilence, modlogUnsilence},
+	[]uint8{OFFENSE_WARNING, OFFENSE_SPAM},
+}
+
+func joinTypes(types []uint8) string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = strconv.Itoa(int(t))
+	}
+	return strings.Join(s, ",")
+}
+
+func (a ModerationAction) name() string {
+	if a.Offense {
+		return offenseNames[a.Type]
+	}
+	if s, ok := recordedModlogActions[a.Type]; ok {
+		return s
+	}
+	return modlogActions[discordgo.AuditLogAction(a.Type)]
+}
+
+// Results are shown this many at a time
+const modlogPageSize = 15
+
+// No more than this many results are exported
+const modlogExportLimit = 5000
+
+type modlogCommand struct {
+}
+
+func (c *modlogCommand) Name() string {
+	return "ModLog"
+}
+func (c *modlogCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	filter := modlogFilter{}
+	filtered := false
+	since := time.Unix(0, 0).UTC()
+	page := 1
+	export := false
+	names := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := strings.ToLower(args[i])
+		if f, ok := modlogFilters[arg]; ok {
+			filter.modlog = append(filter.modlog, f.modlog...)
+			filter.offense = append(filter.offense, f.offense...)
+			filtered = true
+		} else if arg == "since:" {
+			if i+2 >= len(args) {
+				return "```Error: since: should be followed by a duration like 'since: 30 days'.```", false, nil
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 {
+				return "```Error: since: should be followed by a duration like 'since: 30 days'.```", false, nil
+			}
+			now := time.Now().UTC()
+			switch parseRepeatInterval(args[i+2]) {
+			case 1:
+				since = now.Add(-time.Duration(n) * time.Second)
+			case 2:
+				since = now.Add(-time.Duration(n) * time.Minute)
+			case 3:
+				since = now.Add(-time.Duration(n) * time.Hour)
+			case 4:
+				since = now.AddDate(0, 0, -n)
+			case 5:
+				since = now.AddDate(0, 0, -n*7)
+			case 6:
+				since = now.AddDate(0, -n, 0)
+			case 7:
+				since = now.AddDate(0, -n*3, 0)
+			case 8:
+				since = now.AddDate(-n, 0, 0)
+			default:
+				return "```Error: unrecognized interval " + args[i+2] + ".```", false, nil
+			}
+			i += 2
+		} else if arg == "page:" && i+1 < len(args) {
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 1 {
+				return "```Error: page: should be followed by a page number.```", false, nil
+			}
+			page = n
+			i++
+		} else if arg == "export" {
+			export = true
+		} else {
+			names = append(names, args[i])
+		}
+	}
+	if !filtered {
+		filter = modlogDefaultFilter
+	}
+
+	var user uint64
+	who := "everyone"
+	if len(names) > 0 {
+		arg := strings.Join(names, " ")
+		IDs := FindUsername(arg, info)
+		if len(IDs) == 0 { // no matches!
+			return "```Error: Could not find any usernames or aliases matching " + arg + "!```", false, nil
+		}
+		if len(IDs) > 1 {
+			return "```Could be any of the following users or their aliases:\n" + strings.Join(IDsToUsernames(IDs, info, true), "\n") + "```", len(IDs) > 5, nil
+		}
+		user = IDs[0]
+		who = IDsToUsernames(IDs, info, false)[0]
+	}
+
+	gID := SBatoi(info.ID)
+	modlogTypes, offenseTypes := joinTypes(filter.modlog), joinTypes(filter.offense)
+	if export {
+		actions := sb.db.SearchModlog(gID, user, since, modlogTypes, offenseTypes, modlogExportLimit, 0)
+		if len(actions) == 0 {
+			return "```There are no matching moderation actions to export.```", false, nil
+		}
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"Timestamp", "Action", "User ID", "User", "Moderator ID", "Moderator", "Reason"})
+		for _, a := range actions {
+			w.Write([]string{a.Timestamp.Format(time.RFC3339), a.name(), SBitoa(a.User), getUserName(a.User, info), SBitoa(a.Moderator), getUserName(a.Moderator, info), a.Reason})
+		}
+		w.Flush()
+		_, err := sb.dg.ChannelMessageSendComplex(msg.ChannelID, &discordgo.MessageSend{
+			Content:         "Exported " + Pluralize(int64(len(actions)), " moderation action") + " for " + who + ".",
+			Files:           []*discordgo.File{{Name: "modlog.csv", ContentType: "text/csv", Reader: &buf}},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			return "```Error exporting the moderation history: " + apiErrorMessage(err) + "```", false, nil
+		}
+		return "", false, nil
+	}
+
+	// Ask for one extra, so we know whether there's another page
+	actions := sb.db.SearchModlog(gID, user, since, modlogTypes, offenseTypes, modlogPageSize+1, (page-1)*modlogPageSize)
+	if len(actions) == 0 {
+		if page > 1 {
+			return "```There are no results on that page.```", false, nil
+		}
+		return "```No moderation actions match that search.```", false, nil
+	}
+	more := len(actions) > modlogPageSize
+	if more {
+		actions = actions[:modlogPageSize]
+	}
+	lines := make([]string, 0, len(actions)+2)
+	lines = append(lines, fmt.Sprintf("Moderation history for %s (page %v):", who, page))
+	for _, a := range actions {
+		line := ApplyTimezone(a.Timestamp, info, msg.Author).Format("Jan 2, 2006 3:04pm") + ": "
+		if user == 0 {
+			line += getUserName(a.User, info) + " "
+		}
+		line += a.name() + " by " + getUserName(a.Moderator, info)
+		if len(a.Reason) > 0 {
+			line += " (" + a.Reason + ")"
+		}
+		lines = append(lines, line)
+	}
+	if more {
+		lines = append(lines, fmt.Sprintf("Add page: %v to see more, or export to get everything as a file.", page+1))
+	}
+	return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```", false, nil
+}
+func (c *modlogCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Searches the moderation history: bans, unbans and kicks (including any done through discord instead of sweetiebot), silences, and warnings. All filters are optional, and with none, lists the most recent actions on everyone. For example: `" + info.config.Basic.CommandPrefix + "modlog @user ban kick since: 30 days`.",
+		Params: []CommandUsageParam{
+			{Name: "user", Desc: "A ping of the user, or simply their name.", Optional: true},
+			{Name: "action", Desc: "Only show these kinds of actions: warn, silence, unsilence, spam, filter, kick, ban or unban. Can be given more than once.", Optional: true},
+			{Name: "since: duration", Desc: "Only show actions from the last `since: 2 weeks`.", Optional: true},
+			{Name: "page: number", Desc: "Which page of results to show.", Optional: true},
+			{Name: "export", Desc: "Sends every matching action, up to 5000, as a CSV file instead.", Optional: true},
+		},
+	}
+}
+func (c *modlogCommand) UsageShort() string { return "Searches the moderation history." }
@@ -0,0 +1,278 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// The name of the native AutoMod rule that !syncautomod keeps in sync with the filter
+const autoModSyncName = "Sweetie Bot filter"
+
+// Discord's limits on keyword rules
+const (
+	autoModMaxKeywords    = 1000
+	autoModMaxKeywordLen  = 60
+	autoModMaxAllowList   = 100
+	autoModMaxExemptRoles = 20
+)
+
+// Discord sends one event for every action a rule takes, so events for the same message within this many seconds are
+// only counted once
+const autoModDedupe = 10
+
+// What a blocked message can count as, set for each rule in automod.rules. The filter tiers use filter.actions, spam
+// is treated like the spam filter catching someone, and ignore does nothing.
+var autoModTiers = map[string]bool{"mild": true, "strong": true, "slur": true, "spam": true, "ignore": true}
+
+// AutoModModule folds the messages discord's own AutoMod blocks into Sweetie Bot's moderation history, so they count
+// towards the same warnings and silences as her filter
+type AutoModModule struct {
+	lock  sync.Mutex
+	seen  map[string]int64  // when each blocked message was last handled, to skip the extra events for its other actions
+	rules map[string]string // the name of each rule, so they don't have to be looked up every time one is triggered
+}
+
+// Name of the module
+func (w *AutoModModule) Name() string {
+	return "AutoMod"
+}
+
+// Commands in the module
+func (w *AutoModModule) Commands() []Command {
+	return []Command{
+		&syncAutoModCommand{w},
+	}
+}
+
+// Description of the module
+func (w *AutoModModule) Description() string {
+	return "Listens for messages blocked by the server's AutoMod rules, logs them, and counts them the same way as the word filter would, so AutoMod and the filter share one set of warnings and silences. `automod.rules` decides what each rule counts as. Can also copy the filter into a native AutoMod rule with `syncautomod`, so discord blocks those messages before anyone sees them. Sweetie Bot needs the Manage Server permission for all of this."
+}
+
+// Returns the tier a message blocked by an AutoMod rule counts as. Messages caught by the rule that !syncautomod made
+// count as whichever tier the filter entry they matched is in.
+func autoModTier(info *GuildInfo, e *discordgo.AutoModerationActionExecution) string {
+	if e.RuleID == SBitoa(info.config.AutoMod.SyncRule) && len(e.MatchedKeyword) > 0 {
+		return filterTierNames[filterTier(info, strings.Trim(e.MatchedKeyword, "*"))]
+	}
+	tier, ok := info.config.AutoMod.Rules[e.RuleID]
+	if !ok {
+		tier = info.config.AutoMod.Default
+	}
+	tier = strings.ToLower(tier)
+	if !autoModTiers[tier] {
+		return "ignore"
+	}
+	return tier
+}
+
+func (w *AutoModModule) ruleName(info *GuildInfo, id string) string {
+	w.lock.Lock()
+	name, ok := w.rules[id]
+	w.lock.Unlock()
+	if ok {
+		return name
+	}
+	var rule *discordgo.AutoModerationRule
+	err := CallAPI("AutoModerationRule", func() (err error) {
+		rule, err = sb.dg.AutoModerationRule(info.ID, id)
+		return
+	})
+	if err != nil {
+		return id
+	}
+	w.lock.Lock()
+	w.rules[id] = rule.Name
+	w.lock.Unlock()
+	return rule.Name
+}
+
+// Returns true if this blocked message was already handled by the event for another of its rule's actions
+func (w *AutoModModule) duplicate(e *discordgo.AutoModerationActionExecution) bool {
+	key := e.RuleID + "|" + e.UserID + "|" + e.MessageID + "|" + e.Content
+	now := time.Now().UTC().Unix()
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for k, t := range w.seen {
+		if now-t > autoModDedupe {
+			delete(w.seen, k)
+		}
+	}
+	if _, ok := w.seen[key]; ok {
+		return true
+	}
+	w.seen[key] = now
+	return false
+}
+
+// OnAutoModAction discord hook
+func (w *AutoModModule) OnAutoModAction(info *GuildInfo, e *discordgo.AutoModerationActionExecution) {
+	if len(e.UserID) == 0 || e.UserID == sb.SelfID || w.duplicate(e) {
+		return
+	}
+	tier := autoModTier(info, e)
+	if tier == "ignore" {
+		return
+	}
+	name := getUserName(SBatoi(e.UserID), info)
+	matched := e.MatchedContent
+	if len(matched) == 0 {
+		matched = e.MatchedKeyword
+	}
+	where := ""
+	if len(e.ChannelID) > 0 {
+		where = " in #" + getChannelName(e.ChannelID)
+	}
+	info.LogTo(LogModeration, "AutoMod rule ", w.ruleName(info, e.RuleID), " caught a message from ", name, where, " (", tier, "): \"", matched, "\"")
+	if info.HasModRole(e.UserID) {
+		return // Moderators are never punished by the filter, so they aren't by AutoMod either
+	}
+	if tier == "spam" {
+		if !sb.db.CheckStatus() {
+			return
+		}
+		info.AddOffense(Offense{Type: OFFENSE_SPAM, User: SBatoi(e.UserID), Moderator: SBatoi(sb.SelfID), Reason: "Blocked by AutoMod rule " + w.ruleName(info, e.RuleID), Timestamp: time.Now().UTC()})
+		if info.config.Spam.SilentRole != 0 && SilenceMemberSimple(e.UserID, info) == 0 {
+			info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+e.UserID+"> was silenced for spam caught by the AutoMod rule "+w.ruleName(info, e.RuleID)+". Please investigate.")
+		}
+		return
+	}
+	action := info.config.Filter.Actions[tier]
+	if !filterActions[action] {
+		action = "delete"
+	}
+	if action != "log" {
+		punishFilterTier(info, e.UserID, e.ChannelID, tier, action)
+	}
+}
+
+// Returns the filter entries in the given tier or above as AutoMod keywords. Entries that match anywhere get wildcards on
+// both ends, and anything longer than discord allows is skipped.
+func autoModKeywords(info *GuildInfo, min int) ([]string, int) {
+	keywords := make([]string, 0, len(info.config.Filter.Words))
+	skipped := 0
+	for k, wholeword := range info.config.Filter.Words {
+		if filterTier(info, k) < min {
+			continue
+		}
+		if !wholeword {
+			k = "*" + k + "*"
+		}
+		if len([]rune(k)) > autoModMaxKeywordLen || len(keywords) >= autoModMaxKeywords {
+			skipped++
+			continue
+		}
+		keywords = append(keywords, k)
+	}
+	return keywords, skipped
+}
+
+type syncAutoModCommand struct {
+	m *AutoModModule
+}
+
+func (c *syncAutoModCommand) Name() string {
+	return "SyncAutoMod"
+}
+func (c *syncAutoModCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	rule := SBitoa(info.config.AutoMod.SyncRule)
+	if len(args) > 0 && strings.ToLower(args[0]) == "remove" {
+		if info.config.AutoMod.SyncRule == 0 {
+			return "```There's no synced AutoMod rule to remove.```", false, nil
+		}
+		err := CallAPI("AutoModerationRuleDelete", func() error { return sb.dg.AutoModerationRuleDelete(info.ID, rule) })
+		if err != nil && ClassifyAPIError(err) != APIErrorNotFound {
+			return "```Couldn't remove the AutoMod rule: " + apiErrorMessage(err) + "```", false, nil
+		}
+		info.config.AutoMod.SyncRule = 0
+		info.SaveConfig()
+		return "```Removed the synced AutoMod rule. The filter will keep working as usual.```", false, nil
+	}
+
+	min := filterSlur
+	for i, name := range filterTierNames {
+		if strings.EqualFold(info.config.AutoMod.SyncTier, name) {
+			min = i
+		}
+	}
+	if info.config.Filter.SlursOnly && min < filterSlur {
+		return "```filter.slursonly is on, so only slurs are filtered. Set automod.synctier to slur first, or AutoMod would block more than the filter does.```", false, nil
+	}
+	if min < filterSlur && len(info.config.Filter.Channels) > 0 {
+		return "```Some channels only filter slurs, and AutoMod rules can't tell the difference. Set automod.synctier to slur, or remove those channels from filter.channels.```", false, nil
+	}
+	keywords, skipped := autoModKeywords(info, min)
+	if len(keywords) == 0 {
+		return "```The filter has no " + filterTierNames[min] + " entries or worse to sync. Change automod.synctier to sync more of it.```", false, nil
+	}
+	allow := MapToSlice(info.config.Filter.Allow)
+	if len(allow) > autoModMaxAllowList {
+		allow = allow[:autoModMaxAllowList]
+	}
+	// Moderators are never filtered, so they're exempt from the rule too
+	exempt := []string{}
+	if info.config.Basic.AlertRole != 0 {
+		exempt = append(exempt, SBitoa(info.config.Basic.AlertRole))
+	}
+	for r := range info.config.Basic.ModRoles {
+		if len(exempt) < autoModMaxExemptRoles {
+			exempt = append(exempt, r)
+		}
+	}
+	enabled := true
+	r := &discordgo.AutoModerationRule{
+		Name:        autoModSyncName,
+		EventType:   discordgo.AutoModerationEventMessageSend,
+		TriggerType: discordgo.AutoModerationEventTriggerKeyword,
+		TriggerMetadata: &discordgo.AutoModerationTriggerMetadata{
+			KeywordFilter: keywords,
+			AllowList:     &allow,
+		},
+		Actions:     []discordgo.AutoModerationAction{{Type: discordgo.AutoModerationRuleActionBlockMessage}},
+		Enabled:     &enabled,
+		ExemptRoles: &exempt,
+	}
+
+	var result *discordgo.AutoModerationRule
+	var err error
+	if info.config.AutoMod.SyncRule != 0 {
+		err = CallAPI("AutoModerationRuleEdit", func() (err error) {
+			result, err = sb.dg.AutoModerationRuleEdit(info.ID, rule, r)
+			return
+		})
+	}
+	verb := "Updated"
+	if info.config.AutoMod.SyncRule == 0 || ClassifyAPIError(err) == APIErrorNotFound { // It was never made, or someone deleted it
+		verb = "Created"
+		err = CallAPI("AutoModerationRuleCreate", func() (err error) {
+			result, err = sb.dg.AutoModerationRuleCreate(info.ID, r)
+			return
+		})
+	}
+	if err != nil {
+		return "```Couldn't sync the AutoMod rule: " + apiErrorMessage(err) + "```", false, nil
+	}
+	info.config.AutoMod.SyncRule = SBatoi(result.ID)
+	info.SaveConfig()
+	c.m.lock.Lock()
+	c.m.rules[result.ID] = result.Name
+	c.m.lock.Unlock()
+	s := verb + " the AutoMod rule \"" + autoModSyncName + "\" with " + Pluralize(int64(len(keywords)), " keyword") + " from the " + filterTierNames[min] + " tier and up."
+	if skipped > 0 {
+		s += fmt.Sprintf(" %v filter entries were skipped, because AutoMod only allows %v keywords of up to %v characters.", skipped, autoModMaxKeywords, autoModMaxKeywordLen)
+	}
+	return "```" + s + " Run this again after changing the filter to keep it in sync.```", false, nil
+}
+func (c *syncAutoModCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Copies the filter entries in `automod.synctier` and above into a native AutoMod rule, so discord blocks those messages before they're ever posted. Whole word entries stay whole words and the others match anywhere, and filter exceptions and moderator roles are carried over. Messages it blocks count as the tier of the entry they matched. AutoMod can't undo the leetspeak the filter catches, so the filter keeps running too. Run it again after changing the filter.",
+		Params: []CommandUsageParam{
+			{Name: "remove", Desc: "Deletes the synced rule instead.", Optional: true},
+		},
+	}
+}
+func (c *syncAutoModCommand) UsageShort() string { return "Copies the filter into AutoMod." }
@@ -0,0 +1,173 @@
+package sweetiebot
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type autoReaction struct {
+	channel string
+	message string
+	emoji   []string
+}
+
+// AutoReactModule reacts to every new message in the channels in autoreact.channels, such as adding voting arrows to
+// suggestions. Reactions are added one at a time by a single worker per server, so a busy channel can't flood discord.
+type AutoReactModule struct {
+	lock   sync.Mutex
+	queue  chan autoReaction
+	warned map[string]int64 // last time each channel's broken emoji were logged
+}
+
+// How many messages can be waiting for reactions before new ones are skipped
+const autoReactQueueSize = 100
+
+// Name of the module
+func (w *AutoReactModule) Name() string {
+	return "AutoReact"
+}
+
+// Commands in the module
+func (w *AutoReactModule) Commands() []Command {
+	return []Command{
+		&autoReactCommand{},
+	}
+}
+
+// Description of the module
+func (w *AutoReactModule) Description() string {
+	return "Reacts to every message posted in the channels set up with `!autoreact`, such as adding voting arrows in a suggestions channel. Bot messages are skipped."
+}
+
+// Turns an emoji as typed in a message into the form the reaction API expects
+func reactionAPIName(emoji string) string {
+	if m := customemojiregex.FindStringSubmatch(emoji); m != nil {
+		return m[1] + ":" + m[2]
+	}
+	return emoji
+}
+
+func (w *AutoReactModule) shouldWarn(channel string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.warned == nil {
+		w.warned = make(map[string]int64)
+	}
+	now := time.Now().UTC().Unix()
+	if now-w.warned[channel] < 300 {
+		return false
+	}
+	w.warned[channel] = now
+	return true
+}
+
+func (w *AutoReactModule) worker(info *GuildInfo) {
+	for r := range w.queue {
+		for _, e := range r.emoji {
+			err := CallAPI("MessageReactionAdd", func() error { return sb.dg.MessageReactionAdd(r.channel, r.message, reactionAPIName(e)) })
+			if err == nil {
+				continue
+			}
+			if discordErrorCode(err) == discordgo.ErrCodeUnknownMessage {
+				break // The message was deleted, so there's nothing left to react to
+			}
+			if !w.shouldWarn(r.channel) {
+				continue
+			}
+			if discordErrorCode(err) == discordgo.ErrCodeUnknownEmoji {
+				info.Log("Couldn't auto react with ", e, " in #", getChannelName(r.channel), ", because that emoji doesn't exist anymore. Use !autoreact to fix the channel's reactions.")
+			} else {
+				info.Log("Couldn't auto react in #", getChannelName(r.channel), ": ", err.Error())
+			}
+		}
+	}
+}
+
+// OnMessageCreate discord hook
+func (w *AutoReactModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	set := strings.Fields(info.config.AutoReact.Channels[m.ChannelID])
+	if len(set) == 0 || m.Author.Bot {
+		return
+	}
+	w.lock.Lock()
+	if w.queue == nil {
+		w.queue = make(chan autoReaction, autoReactQueueSize)
+		go w.worker(info)
+	}
+	w.lock.Unlock()
+	select {
+	case w.queue <- autoReaction{m.ChannelID, m.ID, set}:
+	default:
+		if w.shouldWarn(m.ChannelID) {
+			info.Log("Too many messages are waiting for auto reactions, so some messages in #", getChannelName(m.ChannelID), " were skipped.")
+		}
+	}
+}
+
+type autoReactCommand struct {
+}
+
+func (c *autoReactCommand) Name() string {
+	return "AutoReact"
+}
+func (c *autoReactCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "channel", Desc: "The channel to react in. If omitted, lists every channel with auto reactions.", Type: ArgChannel, Optional: true},
+		{Name: "emoji", Desc: "The emoji to react with, in order, separated by spaces. If omitted, auto reactions in the channel are turned off.", Optional: true, Variadic: true},
+	}
+}
+func (c *autoReactCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *autoReactCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !args.Has("channel") {
+		if len(info.config.AutoReact.Channels) == 0 {
+			return "```No channels have auto reactions.```", false, nil
+		}
+		lines := make([]string, 0, len(info.config.AutoReact.Channels))
+		for k, v := range info.config.AutoReact.Channels {
+			lines = append(lines, "<#"+k+">: "+v)
+		}
+		sort.Strings(lines)
+		return strings.Join(lines, "\n"), false, nil
+	}
+	channel := args.String("channel")
+	CheckMapNilString(&info.config.AutoReact.Channels)
+	if !args.Has("emoji") {
+		delete(info.config.AutoReact.Channels, channel)
+		info.SaveConfig()
+		return "```Turned off auto reactions in #" + getChannelName(channel) + ".```", false, nil
+	}
+	emoji := strings.Fields(args.String("emoji"))
+	if len(emoji) > 20 {
+		return "```Discord only allows 20 different reactions on a message.```", false, nil
+	}
+	// Discord is the only one who knows which emoji are valid, so try reacting to the command with each of them first
+	for _, e := range emoji {
+		if m := customemojiregex.FindStringSubmatch(e); m != nil {
+			if _, err := sb.dg.State.Emoji(info.ID, m[2]); err != nil {
+				return "```" + m[1] + " is an emoji from another server. Only this server's emoji can be used.```", false, nil
+			}
+		}
+		if err := sb.dg.MessageReactionAdd(msg.ChannelID, msg.ID, reactionAPIName(e)); err != nil {
+			if discordErrorCode(err) == discordgo.ErrCodeUnknownEmoji {
+				return "```" + e + " isn't an emoji discord recognizes.```", false, nil
+			}
+			return apiErrorMessage(err), false, nil
+		}
+	}
+	info.config.AutoReact.Channels[channel] = strings.Join(emoji, " ")
+	info.SaveConfig()
+	return "Every new message in <#" + channel + "> will be reacted to with " + strings.Join(emoji, " "), false, nil
+}
+func (c *autoReactCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Sets the emoji Sweetie Bot reacts to every new message in a channel with, such as `!autoreact #suggestions ⬆️ ⬇️`. Each emoji is checked by reacting to your command with it, so you'll see them show up as they're accepted.",
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *autoReactCommand) UsageShort() string { return "Sets up automatic reactions in a channel." }
@@ -0,0 +1,171 @@
+package sweetiebot
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// AutoThreadModule starts a thread on every message posted in the channels in autothread.channels, so support and suggestion
+// channels stay readable. Messages can be limited to ones matching autothread.match.
+type AutoThreadModule struct {
+	lock    sync.Mutex
+	buckets map[string]*TokenBucket // per-channel thread creation limit
+	warned  map[string]int64        // last time each channel's failure was logged
+	pattern string                  // source of the compiled match regex, so we only recompile it when the config changes
+	match   *regexp.Regexp
+}
+
+// Discord won't let a channel create threads much faster than this, so we stop trying before it starts rejecting them
+const autoThreadRate = 1.0 / 6.0
+const autoThreadBurst = 5
+
+// Discord only accepts these auto archive durations, in minutes
+var autoThreadArchiveDurations = []int{60, 1440, 4320, 10080}
+
+// Name of the module
+func (w *AutoThreadModule) Name() string {
+	return "AutoThread"
+}
+
+// Commands in the module
+func (w *AutoThreadModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *AutoThreadModule) Description() string {
+	return "Starts a thread on every message posted in one of the channels in `autothread.channels`, named after the message or `autothread.name`. If `autothread.match` is set, only messages matching that regex get a thread. Bot messages and messages shorter than `autothread.minlength` are skipped."
+}
+
+// Returns the compiled match regex, or nil if every message should get a thread
+func (w *AutoThreadModule) matcher(info *GuildInfo) (*regexp.Regexp, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if info.config.AutoThread.Match != w.pattern {
+		w.pattern = info.config.AutoThread.Match
+		w.match = nil
+		if len(w.pattern) > 0 {
+			r, err := regexp.Compile("(?i)" + w.pattern)
+			if err != nil {
+				w.pattern = "" // Keep recompiling until it's fixed, rather than matching everything
+				return nil, err
+			}
+			w.match = r
+		}
+	}
+	return w.match, nil
+}
+
+// Returns false if a failure in this channel was already logged recently
+func (w *AutoThreadModule) shouldWarn(channel string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.warned == nil {
+		w.warned = make(map[string]int64)
+	}
+	now := time.Now().UTC().Unix()
+	if now-w.warned[channel] < 300 {
+		return false
+	}
+	w.warned[channel] = now
+	return true
+}
+
+func (w *AutoThreadModule) take(channel string) bool {
+	w.lock.Lock()
+	if w.buckets == nil {
+		w.buckets = make(map[string]*TokenBucket)
+	}
+	b, ok := w.buckets[channel]
+	if !ok {
+		b = &TokenBucket{}
+		w.buckets[channel] = b
+	}
+	w.lock.Unlock()
+	return b.take(autoThreadRate, autoThreadBurst)
+}
+
+// Picks the closest auto archive duration discord allows, defaulting to a day
+func autoThreadArchive(minutes int) int {
+	if minutes <= 0 {
+		return 1440
+	}
+	best := autoThreadArchiveDurations[0]
+	for _, d := range autoThreadArchiveDurations {
+		if d <= minutes {
+			best = d
+		}
+	}
+	return best
+}
+
+// Builds a thread name from the first line of a message, with pings and custom emojis turned back into plain text
+func autoThreadName(info *GuildInfo, m *discordgo.Message) string {
+	content := strings.TrimSpace(m.Content)
+	if i := strings.IndexByte(content, '\n'); i >= 0 {
+		content = content[:i]
+	}
+	content = ReplaceAllMentions(content)
+	content = customemojiregex.ReplaceAllString(content, ":$1:")
+	user := getUserName(SBatoi(m.Author.ID), info)
+	if len(strings.TrimSpace(content)) == 0 {
+		content = user + "'s post"
+	}
+
+	name := info.config.AutoThread.Name
+	if len(name) == 0 {
+		name = "{message}"
+	}
+	name = strings.NewReplacer("{message}", strings.TrimSpace(content), "{user}", user, "{channel}", getChannelName(m.ChannelID)).Replace(name)
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > 100 {
+		name = string([]rune(name)[:99]) + "…"
+	}
+	return name
+}
+
+// OnMessageCreate discord hook
+func (w *AutoThreadModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	if !info.config.AutoThread.Channels[m.ChannelID] || m.Author.Bot || m.Thread != nil {
+		return
+	}
+	if m.Type != discordgo.MessageTypeDefault && m.Type != discordgo.MessageTypeReply {
+		return
+	}
+	if utf8.RuneCountInString(strings.TrimSpace(m.Content)) < info.config.AutoThread.MinLength {
+		return
+	}
+	match, err := w.matcher(info)
+	if err != nil {
+		if w.shouldWarn(m.ChannelID) {
+			info.Log("autothread.match is not a valid regex: ", err.Error())
+		}
+		return
+	}
+	if match != nil && !match.MatchString(m.Content) {
+		return
+	}
+	if !w.take(m.ChannelID) {
+		recordDropped("thread", info.ID, "thread creation limit", "#"+getChannelName(m.ChannelID)+" message "+m.ID)
+		if w.shouldWarn(m.ChannelID) {
+			info.Log("Too many messages were posted in #", getChannelName(m.ChannelID), " to start a thread on all of them, so some were skipped.")
+		}
+		return
+	}
+
+	name := autoThreadName(info, m)
+	err = CallAPI("MessageThreadStart", func() error {
+		_, err := sb.dg.MessageThreadStart(m.ChannelID, m.ID, name, autoThreadArchive(info.config.AutoThread.Archive))
+		return err
+	})
+	if err != nil && ClassifyAPIError(err) != APIErrorNotFound && w.shouldWarn(m.ChannelID) { // Not found means another module already deleted the message
+		if ClassifyAPIError(err) == APIErrorPermission {
+			info.Log("Couldn't start a thread in #", getChannelName(m.ChannelID), ", because I don't have the Create Public Threads permission there.")
+		} else {
+			info.Log("Failed to start a thread in #", getChannelName(m.ChannelID), ": ", err.Error())
+		}
+	}
+}
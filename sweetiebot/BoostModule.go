@@ -0,0 +1,182 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Schedule type used to take the perk role away from someone who stopped boosting, once the grace period is over
+const scheduleBoostPerk = 12
+
+const defaultBoostMessage = "Thank you {user} for boosting {server}! We're now at {count} boosts."
+
+// BoostModule thanks members who boost the server, optionally giving them a perk role for as long as they keep boosting,
+// and celebrates when the server reaches a new boost milestone.
+type BoostModule struct {
+	lock   sync.Mutex
+	count  int // the last boost count we saw, so we can tell when a milestone is crossed
+	tier   discordgo.PremiumTier
+	loaded bool
+}
+
+// Name of the module
+func (w *BoostModule) Name() string {
+	return "Boosts"
+}
+
+// Commands in the module
+func (w *BoostModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *BoostModule) Description() string {
+	return "Posts `boosts.message` in `boosts.channel` whenever someone starts boosting the server, and gives them `boosts.perkrole`. When the server reaches a multiple of `boosts.milestone` boosts or a new boost level, every current booster is thanked. If `boosts.revokeperk` is true, the perk role is taken away `boosts.grace` seconds after someone stops boosting."
+}
+
+// Returns the mentions of everyone currently boosting the server
+func currentBoosters(info *GuildInfo) []string {
+	guild, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return nil
+	}
+	sb.dg.State.RLock()
+	defer sb.dg.State.RUnlock()
+	r := []string{}
+	for _, m := range guild.Members {
+		if m.PremiumSince != nil && m.User != nil {
+			r = append(r, "<@"+m.User.ID+">")
+		}
+	}
+	sort.Strings(r)
+	return r
+}
+
+// OnGuildCreate discord hook
+func (w *BoostModule) OnGuildCreate(info *GuildInfo, g *discordgo.Guild) {
+	w.lock.Lock()
+	w.count = g.PremiumSubscriptionCount
+	w.tier = g.PremiumTier
+	w.loaded = true
+	w.lock.Unlock()
+}
+
+// OnGuildUpdate discord hook
+func (w *BoostModule) OnGuildUpdate(info *GuildInfo, g *discordgo.Guild) {
+	w.lock.Lock()
+	count, tier, loaded := w.count, w.tier, w.loaded
+	w.count = g.PremiumSubscriptionCount
+	w.tier = g.PremiumTier
+	w.loaded = true
+	w.lock.Unlock()
+	if !loaded || info.config.Boosts.Channel == 0 || g.PremiumSubscriptionCount <= count {
+		return
+	}
+	milestone := info.config.Boosts.Milestone
+	reached := g.PremiumTier > tier || (milestone > 0 && g.PremiumSubscriptionCount/milestone > count/milestone)
+	if !reached {
+		return
+	}
+	s := fmt.Sprintf("%s just reached %s", info.Name, Pluralize(int64(g.PremiumSubscriptionCount), " boost"))
+	if g.PremiumTier > tier {
+		s += fmt.Sprintf(" and boost level %v", int(g.PremiumTier))
+	}
+	s += "!"
+	if boosters := currentBoosters(info); len(boosters) > 0 {
+		if len(boosters) > 50 {
+			boosters = append(boosters[:50], fmt.Sprintf("and %v more", len(boosters)-50))
+		}
+		s += " Thank you to everyone boosting: " + strings.Join(boosters, ", ")
+	}
+	channel := SBitoa(info.config.Boosts.Channel)
+	info.sendChatter("boosts", func() { sendQuietMessage(channel, s) })
+}
+
+// Posts a message without pinging anyone it mentions, since thanking 30 boosters shouldn't send 30 notifications
+func sendQuietMessage(channel string, s string) {
+	CallAPI("ChannelMessageSendComplex", func() error {
+		_, err := sb.dg.ChannelMessageSendComplex(channel, &discordgo.MessageSend{Content: truncateRunes(s, 2000), AllowedMentions: &discordgo.MessageAllowedMentions{}})
+		return err
+	})
+}
+
+// OnGuildMemberUpdate discord hook
+func (w *BoostModule) OnGuildMemberUpdate(info *GuildInfo, m *discordgo.GuildMemberUpdate) {
+	if m.BeforeUpdate == nil || m.User == nil {
+		return
+	}
+	started := m.BeforeUpdate.PremiumSince == nil && m.PremiumSince != nil
+	stopped := m.BeforeUpdate.PremiumSince != nil && m.PremiumSince == nil
+	if started {
+		w.boostStarted(info, m.Member)
+	} else if stopped {
+		w.boostStopped(info, m.Member)
+	}
+}
+
+func (w *BoostModule) boostStarted(info *GuildInfo, m *discordgo.Member) {
+	info.LogTo(LogJoins, m.User.Username, " (", m.User.ID, ") started boosting the server.")
+	if role := info.config.Boosts.PerkRole; role != 0 {
+		if sb.db.CheckStatus() {
+			if id := sb.db.FindEvent(m.User.ID, SBatoi(info.ID), scheduleBoostPerk); id != nil {
+				sb.db.RemoveSchedule(*id) // They came back before the grace period ran out
+			}
+		}
+		if !info.UserHasRole(m.User.ID, SBitoa(role)) {
+			err := CallAPI("GuildMemberRoleAdd", func() error { return sb.dg.GuildMemberRoleAdd(info.ID, m.User.ID, SBitoa(role)) })
+			if err != nil {
+				info.LogError("Failed to give the booster perk role to "+m.User.Username+": ", err)
+			}
+		}
+	}
+	if info.config.Boosts.Channel == 0 {
+		return
+	}
+	msg := info.config.Boosts.Message
+	if len(msg) == 0 {
+		msg = defaultBoostMessage
+	}
+	count := 0
+	if guild, err := sb.dg.State.Guild(info.ID); err == nil {
+		count = guild.PremiumSubscriptionCount
+	}
+	msg = renderTemplate(info, msg, map[string]string{"user": "<@" + m.User.ID + ">", "username": m.User.Username, "count": fmt.Sprint(count)})
+	channel := SBitoa(info.config.Boosts.Channel)
+	info.sendChatter("boosts", func() { info.SendMessage(channel, msg) })
+}
+
+func (w *BoostModule) boostStopped(info *GuildInfo, m *discordgo.Member) {
+	info.LogTo(LogJoins, m.User.Username, " (", m.User.ID, ") stopped boosting the server.")
+	role := info.config.Boosts.PerkRole
+	if role == 0 || !info.config.Boosts.RevokePerk || !info.UserHasRole(m.User.ID, SBitoa(role)) {
+		return
+	}
+	if info.config.Boosts.Grace > 0 && sb.db.CheckStatus() {
+		if sb.db.FindEvent(m.User.ID, SBatoi(info.ID), scheduleBoostPerk) == nil {
+			sb.db.AddSchedule(SBatoi(info.ID), time.Now().UTC().Add(time.Duration(info.config.Boosts.Grace)*time.Second), scheduleBoostPerk, m.User.ID)
+		}
+		return
+	}
+	revokeBoostPerk(info, m.User.ID)
+}
+
+// Takes the perk role away from someone whose grace period is over, unless they started boosting again
+func revokeBoostPerk(info *GuildInfo, user string) {
+	role := info.config.Boosts.PerkRole
+	if role == 0 || !info.config.Boosts.RevokePerk {
+		return
+	}
+	m, err := info.GetMember(user)
+	if err != nil || m.PremiumSince != nil || !info.UserHasRole(user, SBitoa(role)) {
+		return
+	}
+	err = CallAPI("GuildMemberRoleRemove", func() error { return sb.dg.GuildMemberRoleRemove(info.ID, user, SBitoa(role)) })
+	if err != nil {
+		info.LogError("Failed to remove the booster perk role from "+m.User.Username+": ", err)
+		return
+	}
+	info.LogTo(LogJoins, "Removed the booster perk role from ", m.User.Username, " (", user, ") because they stopped boosting.")
+}
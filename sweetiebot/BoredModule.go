@@ -29,7 +29,7 @@ func (w *BoredModule) Description() string {
 func (w *BoredModule) OnIdle(info *GuildInfo, c *discordgo.Channel) {
 	id := c.ID
 
-	if RateLimit(&w.lastmessage, w.IdlePeriod(info)) && len(info.config.Bored.Commands) > 0 {
+	if RateLimit(&w.lastmessage, w.IdlePeriod(info)) && len(info.config.Bored.Commands) > 0 && info.takeChatterNow("bored") {
 		m := &discordgo.Message{ChannelID: id, Content: MapGetRandomItem(info.config.Bored.Commands),
 			Author: &discordgo.User{
 				ID:       sb.SelfID,
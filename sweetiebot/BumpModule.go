@@ -0,0 +1,110 @@
+package sweetiebot
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Schedule type used for bump reminders, so they survive a restart
+const scheduleBump = 10
+
+// BumpModule watches for a server listing bot like Disboard confirming a bump, and schedules a reminder to bump again
+// once the cooldown is over.
+type BumpModule struct {
+	lock     sync.Mutex
+	pattern  string // the bump.pattern the regex was compiled from
+	regex    *regexp.Regexp
+	lastwarn int64
+}
+
+// Name of the module
+func (w *BumpModule) Name() string {
+	return "Bump"
+}
+
+// Commands in the module
+func (w *BumpModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *BumpModule) Description() string {
+	return "When `bump.bot` posts a message matching `bump.pattern`, schedules a reminder to bump the server again after `bump.cooldown` seconds, pinging `bump.role`."
+}
+
+// Returns the compiled bump.pattern, recompiling it if the config changed
+func (w *BumpModule) getRegex(info *GuildInfo) *regexp.Regexp {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.regex != nil && w.pattern == info.config.Bump.Pattern {
+		return w.regex
+	}
+	r, err := regexp.Compile("(?i)" + info.config.Bump.Pattern)
+	if err != nil {
+		if RateLimit(&w.lastwarn, 3600) {
+			info.Log("bump.pattern isn't a valid regular expression, so bumps can't be detected: ", err.Error())
+		}
+		return nil
+	}
+	w.pattern = info.config.Bump.Pattern
+	w.regex = r
+	return r
+}
+
+// Returns true if a bump reminder is already waiting to be sent
+func pendingBumpReminder(info *GuildInfo) bool {
+	for _, e := range sb.db.GetEventsByType(SBatoi(info.ID), scheduleBump, 1) {
+		if e.Date.After(time.Now().UTC()) {
+			return true
+		}
+	}
+	return false
+}
+
+// OnMessageCreate discord hook
+func (w *BumpModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	if !info.config.Bump.Enabled || m.Author == nil || SBatoi(m.Author.ID) != info.config.Bump.Bot || len(info.config.Bump.Pattern) == 0 {
+		return
+	}
+	if info.config.Bump.Channel != 0 && SBatoi(m.ChannelID) != info.config.Bump.Channel {
+		return
+	}
+	r := w.getRegex(info)
+	if r == nil {
+		return
+	}
+	// Confirmations are usually in an embed, so check those too
+	matched := r.MatchString(m.Content)
+	for _, e := range m.Embeds {
+		matched = matched || r.MatchString(e.Title) || r.MatchString(e.Description)
+	}
+	if !matched || !sb.db.CheckStatus() {
+		return
+	}
+	// Bump bots sometimes send more than one confirmation, so only the first one schedules a reminder
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if pendingBumpReminder(info) {
+		return
+	}
+	cooldown := time.Duration(info.config.Bump.Cooldown) * time.Second
+	if cooldown < time.Minute {
+		cooldown = time.Minute
+	}
+	if sb.db.AddSchedule(SBatoi(info.ID), time.Now().UTC().Add(cooldown), scheduleBump, m.ChannelID) {
+		info.Log("Detected a bump in #", getChannelName(m.ChannelID), ", reminding everyone to bump again in ", TimeDiff(cooldown), ".")
+	}
+}
+
+// Posts a bump reminder once a scheduled one comes due
+func sendBumpReminder(info *GuildInfo, channel string) {
+	msg := renderTemplate(info, info.config.Bump.Message, map[string]string{"channel": "<#" + channel + ">"})
+	if len(msg) == 0 {
+		msg = "The server can be bumped again!"
+	}
+	if info.config.Bump.Role != 0 {
+		msg = "<@&" + SBitoa(info.config.Bump.Role) + "> " + msg
+	}
+	info.sendChatter("bump", func() { info.SendMessage(channel, msg) })
+}
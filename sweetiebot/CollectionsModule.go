@@ -65,6 +65,15 @@ func (c *addCommand) Process(args []string, msg *discordgo.Message, indices []in
 	add := ""
 	length := make([]string, len(collections), len(collections))
 	arg := msg.Content[indices[1]:]
+	added := 0
+	for _, v := range collections {
+		if !info.config.Basic.Collections[v][arg] {
+			added++
+		}
+	}
+	if limit := info.collectionItemLimit(); added > 0 && overLimit(countCollectionItems(info), added, limit) {
+		return limitReachedMessage(limit, "this server can only have %v items across all of its collections", "!remove"), false, nil
+	}
 	for k, v := range collections {
 		info.config.Basic.Collections[v][arg] = true
 		fn, ok := c.funcmap[v]
@@ -26,7 +26,18 @@ func (w *ConfigModule) Commands() []Command {
 	return []Command{
 		&setConfigCommand{},
 		&getConfigCommand{},
+		&configCommand{},
+		&bulkImportCommand{},
 		&setupCommand{},
+		&selfTestCommand{},
+		&preflightCommand{},
+		&staffRolesCommand{false},
+		&staffRolesCommand{true},
+		&logChannelCommand{},
+		&setVarCommand{},
+		&delVarCommand{},
+		&limitsCommand{},
+		&quietHoursCommand{},
 	}
 }
 
@@ -88,6 +99,11 @@ func (c *setConfigCommand) Process(args []string, msg *discordgo.Message, indice
 	n, ok := info.SetConfig(args[0], args[1], args[2:]...)
 	info.SaveConfig()
 	if ok {
+		if strings.HasPrefix(strings.ToLower(args[0]), "feedback.") {
+			if warning := checkFeedback(info); len(warning) > 0 {
+				return "```Successfully set " + args[0] + " to " + n + ", but:\n" + warning + "```", false, nil
+			}
+		}
 		return "```Successfully set " + args[0] + " to " + n + ".```", false, nil
 	}
 	return "```" + n + "```", false, nil
@@ -441,7 +457,7 @@ func (c *setupCommand) Process(args []string, msg *discordgo.Message, indices []
 	info.config.Basic.Aliases["calc"] = "roll"
 	info.config.Basic.Aliases["calculate"] = "roll"
 
-	sensitive := []string{"add", "addrole", "addwit", "ban", "disable", "dumptables", "echo", "enable", "getconfig", "deleterole", "removerole", "remove", "removewit", "setconfig", "setstatus", "update", "announce", "collections", "addevent", "addbirthday", "autosilence", "silence", "unsilence", "wipe", "new", "addquote", "removequote", "removealias", "delete", "createpoll", "deletepoll", "addoption", "echoembed", "getpressure", "getaudit", "getraid", "banraid", "bannewcomers"}
+	sensitive := []string{"add", "addrole", "addwit", "ban", "disable", "dumptables", "echo", "enable", "getconfig", "deleterole", "removerole", "remove", "removewit", "setconfig", "setstatus", "update", "announce", "collections", "addevent", "addbirthday", "autosilence", "silence", "unsilence", "wipe", "new", "addquote", "removequote", "removealias", "delete", "createpoll", "deletepoll", "addoption", "echoembed", "getpressure", "getaudit", "getraid", "banraid", "bannewcomers", "addemoji", "removeemoji", "renameemoji", "addsticker", "removesticker", "renamesticker", "modlog", "modules", "say", "editsay", "guildconfig", "leaveguild", "broadcastowners", "limiters", "dropped", "jobs", "addfilter", "removefilter", "listfilter", "addexception", "removeexception", "testfilter", "remindchannel", "pausereminder", "resumereminder", "removechannelreminder", "massrole", "setdmresponse", "exempt", "unexempt", "approve", "resyncmembers", "warn", "warnings", "autoreact", "stickymessage", "unstick", "rolemenu", "snipe", "editsnipe", "modroles", "adminroles", "moveall", "summon", "refreshphishing", "settopic", "fixmute", "logchannel", "rulesgate", "spamtest", "guildevent", "setvar", "delvar", "interrupted", "persona", "channeltemplate", "script", "quiethours", "suggestion", "temprole", "preflight", "digest", "migrateuser", "syncautomod", "tasks", "config", "bulkimport", "reactions"}
 	modint := SBitoa(info.config.Basic.AlertRole)
 
 	for _, v := range sensitive {
@@ -25,12 +25,22 @@ func (w *DebugModule) Commands() []Command {
 		&echoEmbedCommand{},
 		&disableCommand{},
 		&enableCommand{},
+		&modulesCommand{},
 		&updateCommand{},
 		&dumpTablesCommand{},
 		&listGuildsCommand{},
 		&announceCommand{},
+		&guildConfigCommand{},
+		&leaveGuildCommand{},
+		&broadcastOwnersCommand{},
+		&limitersCommand{},
+		&droppedCommand{},
+		&jobsCommand{},
+		&resyncMembersCommand{},
+		&setDMResponseCommand{},
 		&removeAliasCommand{},
 		&getAuditCommand{},
+		&interruptedCommand{map[string]operationResumer{"massrole": resumeMassRole}},
 	}
 }
 
@@ -230,6 +240,44 @@ func (c *enableCommand) UsageShort() string { return "Enables the given module/c
 func (c *enableCommand) Roles() []string    { return []string{"Princesses", "Royal Guard"} }
 func (c *enableCommand) Channels() []string { return []string{} }
 
+type modulesCommand struct {
+}
+
+func (c *modulesCommand) Name() string {
+	return "Modules"
+}
+func (c *modulesCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) > 1 {
+		switch strings.ToLower(args[len(args)-1]) {
+		case "on", "enable", "true":
+			return SetCommandEnable([]string{strings.Join(args[:len(args)-1], " ")}, true, " was enabled.", info, msg.ChannelID)
+		case "off", "disable", "false":
+			return SetCommandEnable([]string{strings.Join(args[:len(args)-1], " ")}, false, " was disabled.", info, msg.ChannelID)
+		}
+		return "```You must specify either on or off.```", false, nil
+	}
+
+	lines := make([]string, 0, len(info.modules))
+	for _, v := range info.modules {
+		state := "[enabled]"
+		if len(info.IsModuleDisabled(v.Name())) > 0 {
+			state = "[disabled]"
+		}
+		lines = append(lines, fmt.Sprintf("%-14s %s", v.Name(), state))
+	}
+	return "```" + strings.Join(lines, "\n") + "```", false, nil
+}
+func (c *modulesCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Lists all modules and whether they are enabled on this server, or turns a module on or off. A disabled module ignores all events and its commands are hidden from " + info.config.Basic.CommandPrefix + "help.",
+		Params: []CommandUsageParam{
+			{Name: "module", Desc: "The module to turn on or off.", Optional: true},
+			{Name: "on|off", Desc: "Whether the module should be enabled or disabled.", Optional: true},
+		},
+	}
+}
+func (c *modulesCommand) UsageShort() string { return "Lists or toggles modules." }
+
 type updateCommand struct {
 }
 
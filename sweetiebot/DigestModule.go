@@ -0,0 +1,259 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Every section a digest can have, in the order they're shown
+var digestSections = []string{"members", "messages", "channels", "emoji", "moderation", "quotes"}
+
+// No more than this many different emoji are counted between digests, so a flood of random emoji can't eat memory
+const digestEmojiLimit = 500
+
+var digestemojiregex = regexp.MustCompile("<a?:[A-Za-z0-9_]+:[0-9]+>")
+
+// DigestModule posts a regular summary of what happened on the server to digest.channel. Channel and emoji counts are
+// only kept in memory since the last digest, everything else comes from the database.
+type DigestModule struct {
+	lock     sync.Mutex
+	channels map[string]int
+	emoji    map[string]int
+	since    time.Time
+	quotes   int    // how many quotes there were at the last digest, or -1 if there hasn't been one yet
+	posted   string // the local date of the last digest, so it's never posted twice in one day
+}
+
+// Name of the module
+func (w *DigestModule) Name() string {
+	return "Digest"
+}
+
+// Commands in the module
+func (w *DigestModule) Commands() []Command {
+	return []Command{
+		&digestCommand{w},
+	}
+}
+
+// Description of the module
+func (w *DigestModule) Description() string {
+	return "Posts a daily or weekly summary of server activity to `digest.channel` at `digest.hour` in the server's timezone, with the sections listed in `digest.sections`."
+}
+
+func (w *DigestModule) reset(now time.Time, quotes int) {
+	w.channels = make(map[string]int)
+	w.emoji = make(map[string]int)
+	w.since = now
+	w.quotes = quotes
+}
+
+func (w *DigestModule) countEmoji(e string) {
+	if _, ok := w.emoji[e]; ok || len(w.emoji) < digestEmojiLimit {
+		w.emoji[e]++
+	}
+}
+
+// OnMessageCreate discord hook
+func (w *DigestModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	if info.config.Digest.Channel == 0 || m.Author == nil || m.Author.Bot {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.channels == nil {
+		w.reset(time.Now().UTC(), -1)
+	}
+	w.channels[m.ChannelID]++
+	for _, e := range digestemojiregex.FindAllString(m.Content, 10) {
+		w.countEmoji(strings.Replace(e, "<a:", "<:", 1))
+	}
+}
+
+// OnMessageReactionAdd discord hook
+func (w *DigestModule) OnMessageReactionAdd(info *GuildInfo, r *discordgo.MessageReaction) {
+	if info.config.Digest.Channel == 0 {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.channels == nil {
+		w.reset(time.Now().UTC(), -1)
+	}
+	e := r.Emoji
+	e.Animated = false // Animated and still versions of the same emoji count as one
+	w.countEmoji(e.MessageFormat())
+}
+
+// Returns the top n keys of a count map as lines like "name: count"
+func topCounts(counts map[string]int, n int, name func(string) string) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = fmt.Sprintf("%s: %v", name(k), counts[k])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns true if a digest section is turned on. An empty digest.sections means all of them.
+func digestHasSection(info *GuildInfo, section string) bool {
+	if len(info.config.Digest.Sections) == 0 {
+		return true
+	}
+	for _, v := range info.config.Digest.Sections {
+		if strings.ToLower(v) == section {
+			return true
+		}
+	}
+	return false
+}
+
+// Builds the digest embed. If reset is true, the in-memory counts start over, because this digest is being posted.
+func (w *DigestModule) digest(info *GuildInfo, reset bool) *discordgo.MessageEmbed {
+	days := 1
+	title := "Daily digest"
+	if info.config.Digest.Weekly {
+		days = 7
+		title = "Weekly digest"
+	}
+	now := time.Now().UTC()
+	period := time.Duration(days) * 24 * time.Hour
+	embed := &discordgo.MessageEmbed{
+		Type:      "rich",
+		Title:     title + " for " + info.Name,
+		Color:     0x3e92e5,
+		Timestamp: now.Format(time.RFC3339),
+		Fields:    []*discordgo.MessageEmbedField{},
+	}
+	field := func(name string, value string) {
+		if len(value) == 0 {
+			value = "Nothing"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: truncateRunes(value, 1024), Inline: false})
+	}
+	dbok := sb.db.CheckStatus()
+	guild := SBatoi(info.ID)
+	quotes := countQuotes(info)
+
+	w.lock.Lock()
+	if w.channels == nil {
+		w.reset(now, -1)
+	}
+	channels := w.channels
+	emoji := w.emoji
+	since := w.since
+	lastquotes := w.quotes
+	if reset {
+		w.reset(now, quotes)
+	}
+	w.lock.Unlock()
+
+	for _, section := range digestSections {
+		if !digestHasSection(info, section) {
+			continue
+		}
+		switch section {
+		case "members":
+			if dbok {
+				field("New members", Pluralize(int64(sb.db.CountNewUsers(int64(period/time.Second), guild)), " member")+" joined in the last "+TimeDiff(period)+".")
+			}
+		case "messages":
+			if dbok {
+				messages, members := sb.db.GetGuildActivity(guild, days)
+				field("Messages", Pluralize(int64(messages), " message")+" from "+Pluralize(int64(members), " member")+" over the last "+Pluralize(int64(days), " full day")+" (UTC).")
+			}
+		case "channels":
+			field("Top channels since "+ApplyTimezone(since, info, nil).Format("Jan 2 15:04"), topCounts(channels, 5, func(k string) string { return "<#" + k + ">" }))
+		case "emoji":
+			field("Top emoji since "+ApplyTimezone(since, info, nil).Format("Jan 2 15:04"), topCounts(emoji, 5, func(k string) string { return k }))
+		case "moderation":
+			if dbok {
+				counts := make(map[string]int)
+				for _, a := range sb.db.SearchModlog(guild, 0, now.Add(-period), joinTypes(modlogDefaultFilter.modlog), joinTypes(modlogDefaultFilter.offense), 1000, 0) {
+					counts[a.name()]++
+				}
+				field("Moderation actions", topCounts(counts, len(counts), func(k string) string { return k }))
+			}
+		case "quotes":
+			s := Pluralize(int64(quotes), " quote") + " saved."
+			if lastquotes >= 0 && quotes > lastquotes {
+				s += " " + Pluralize(int64(quotes-lastquotes), " new quote") + " since the last digest."
+			}
+			field("Quotes", s)
+		}
+	}
+	if !dbok {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "The database is down, so some sections are missing."}
+	}
+	return embed
+}
+
+// Posts the digest on every server where it's currently digest.hour on the right day, in the server's timezone
+func postDigests() {
+	sb.guildsLock.RLock()
+	guilds := make([]*GuildInfo, 0, len(sb.guilds))
+	for _, v := range sb.guilds {
+		guilds = append(guilds, v)
+	}
+	sb.guildsLock.RUnlock()
+	now := time.Now().UTC()
+	for _, info := range guilds {
+		d := info.config.Digest
+		if d.Channel == 0 {
+			continue
+		}
+		local := now.In(getTimezone(info, nil))
+		if local.Hour() != d.Hour || (d.Weekly && int(local.Weekday()) != d.Day) {
+			continue
+		}
+		for _, m := range info.modules {
+			w, ok := m.(*DigestModule)
+			if !ok || !info.ProcessModule("", w) {
+				continue
+			}
+			date := local.Format("2006-01-02")
+			w.lock.Lock()
+			done := w.posted == date
+			w.posted = date
+			w.lock.Unlock()
+			if !done {
+				info.SendEmbed(SBitoa(d.Channel), w.digest(info, true))
+			}
+		}
+	}
+}
+
+type digestCommand struct {
+	w *DigestModule
+}
+
+func (c *digestCommand) Name() string {
+	return "Digest"
+}
+func (c *digestCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return "", false, c.w.digest(info, false)
+}
+func (c *digestCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Shows what the next digest would look like right now, without posting it or resetting its counts.",
+	}
+}
+func (c *digestCommand) UsageShort() string { return "Previews the activity digest." }
@@ -19,16 +19,25 @@ func (w *EmoteModule) Name() string {
 }
 
 // Commands in the module
-func (w *EmoteModule) Commands() []Command { return []Command{} }
+func (w *EmoteModule) Commands() []Command {
+	return []Command{
+		&addEmojiCommand{},
+		&removeEmojiCommand{},
+		&renameEmojiCommand{},
+		&addStickerCommand{},
+		&removeStickerCommand{},
+		&renameStickerCommand{},
+	}
+}
 
 // Description of the module
 func (w *EmoteModule) Description() string {
-	return "Keeps a list of banned emotes that are either seizure-inducing or way too big, and deletes any messages that use them in any channels this module is active in."
+	return "Keeps a list of banned emotes that are either seizure-inducing or way too big, and deletes any messages that use them in any channels this module is active in. Also contains commands for managing the server's custom emojis and stickers."
 }
 
 func (w *EmoteModule) hasBigEmote(info *GuildInfo, m *discordgo.Message) bool {
 	if w.emoteban.MatchString(m.Content) {
-		sb.dg.ChannelMessageDelete(m.ChannelID, m.ID)
+		deleteMessage(m.ChannelID, m.ID)
 		if RateLimit(&w.lastmsg, 5) {
 			info.SendMessage(m.ChannelID, "`That emote isn't allowed here! Try to avoid using large or disturbing emotes, as they can be problematic.`")
 		}
@@ -49,7 +58,7 @@ func (w *EmoteModule) OnMessageUpdate(info *GuildInfo, m *discordgo.Message) {
 
 // OnCommand discord hook
 func (w *EmoteModule) OnCommand(info *GuildInfo, m *discordgo.Message) bool {
-	if info.UserHasRole(m.Author.ID, SBitoa(info.config.Basic.AlertRole)) {
+	if info.HasModRole(m.Author.ID) {
 		return false
 	}
 	return w.hasBigEmote(info, m)
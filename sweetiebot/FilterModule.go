@@ -0,0 +1,453 @@
+package sweetiebot
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// FilterModule deletes messages containing blocked words or phrases, even if they have been disguised with leet-speak or invisible characters
+type FilterModule struct {
+	lock    sync.RWMutex
+	tiers   [len(filterTierNames)]filterMatcher // matchers for each severity tier
+	allow   *regexp.Regexp                      // matches exceptions, which are removed before checking the tiers
+	lastmsg int64
+}
+
+type filterMatcher struct {
+	wholeword *regexp.Regexp // matches entries that only count as whole words
+	substring *regexp.Regexp // matches entries that count anywhere, including inside other words
+}
+
+// Filter severity tiers, from least to most severe
+const (
+	filterMild = iota
+	filterStrong
+	filterSlur
+)
+
+var filterTierNames = [...]string{"mild", "strong", "slur"}
+
+// What the filter can do to a message, set for each tier in filter.actions
+var filterActions = map[string]bool{"log": true, "delete": true, "warn": true, "silence": true}
+
+// Returns the tier of a blocked entry. Entries without a tier are strong, which is how every entry was treated before tiers existed.
+func filterTier(info *GuildInfo, entry string) int {
+	switch info.config.Filter.Severity[entry] {
+	case "mild":
+		return filterMild
+	case "slur":
+		return filterSlur
+	}
+	return filterStrong
+}
+
+// Name of the module
+func (w *FilterModule) Name() string {
+	return "Filter"
+}
+
+// Commands in the module
+func (w *FilterModule) Commands() []Command {
+	return []Command{
+		&addFilterCommand{w},
+		&removeFilterCommand{w},
+		&listFilterCommand{},
+		&addExceptionCommand{w},
+		&removeExceptionCommand{w},
+		&testFilterCommand{w},
+	}
+}
+
+// Description of the module
+func (w *FilterModule) Description() string {
+	return "Deletes any message containing a blocked word or phrase, even if it was disguised with common substitutions like `4` for `a` or `0` for `o`, or has invisible characters in it. Each entry can either match whole words only, or match anywhere, and is either mild, strong or a slur, with `filter.actions` deciding what happens for each. Exceptions can be added for innocent words that happen to contain a blocked one. If `filter.warn` is true, the user is also warned. Moderators are never filtered."
+}
+
+var filterLeetReplacer = strings.NewReplacer("4", "a", "@", "a", "3", "e", "1", "i", "!", "i", "0", "o", "5", "s", "$", "s", "7", "t", "+", "t", "8", "b", "9", "g", "|", "l")
+var filterNonAlnum = regexp.MustCompile("[^a-z0-9]+")
+
+func isInvisibleRune(r rune) bool {
+	switch r {
+	case '\u00AD', '\u034F', '\u061C', '\u180E', '\u200B', '\u200C', '\u200D', '\u200E', '\u200F', '\u2060', '\u2061', '\u2062', '\u2063', '\u2064', '\uFEFF':
+		return true
+	}
+	return false
+}
+
+// Lowercases the text, removes invisible characters and undoes common letter substitutions
+func normalizeFilterText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if isInvisibleRune(r) {
+			return -1
+		}
+		return r
+	}, strings.ToLower(s))
+	return filterLeetReplacer.Replace(s)
+}
+
+func compileFilterEntries(entries []string, bounded bool) (*regexp.Regexp, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	for i := range entries {
+		entries[i] = regexp.QuoteMeta(entries[i])
+	}
+	if bounded {
+		return regexp.Compile("\\b(?:" + strings.Join(entries, "|") + ")\\b")
+	}
+	return regexp.Compile("(?:" + strings.Join(entries, "|") + ")")
+}
+
+// UpdateRegex recompiles the filter from the config
+func (w *FilterModule) UpdateRegex(info *GuildInfo) bool {
+	var whole, sub [len(filterTierNames)][]string
+	for k, v := range info.config.Filter.Words {
+		tier := filterTier(info, k)
+		if v {
+			whole[tier] = append(whole[tier], k)
+		} else {
+			sub[tier] = append(sub[tier], k)
+		}
+	}
+	var tiers [len(filterTierNames)]filterMatcher
+	var err error
+	for i := range tiers {
+		if tiers[i].wholeword, err = compileFilterEntries(whole[i], true); err != nil {
+			return false
+		}
+		if tiers[i].substring, err = compileFilterEntries(sub[i], false); err != nil {
+			return false
+		}
+	}
+	allow, err := compileFilterEntries(MapToSlice(info.config.Filter.Allow), true)
+	if err != nil {
+		return false
+	}
+	w.lock.Lock()
+	w.tiers, w.allow = tiers, allow
+	w.lock.Unlock()
+	return true
+}
+
+// Returns the most severe blocked entry that the text contains and its tier, ignoring tiers below min. If the text is
+// clean, returns an empty string.
+func (w *FilterModule) match(text string, min int) (string, int) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	s := normalizeFilterText(text)
+	if w.allow != nil {
+		s = w.allow.ReplaceAllString(s, " ")
+	}
+	squashed := filterNonAlnum.ReplaceAllString(s, "") // catches things like "b.a.d" or "b a d"
+	for tier := len(w.tiers) - 1; tier >= min; tier-- {
+		t := w.tiers[tier]
+		if t.wholeword != nil {
+			if m := t.wholeword.FindString(s); len(m) > 0 {
+				return m, tier
+			}
+		}
+		if t.substring != nil {
+			if m := t.substring.FindString(s); len(m) > 0 {
+				return m, tier
+			}
+			if m := t.substring.FindString(squashed); len(m) > 0 {
+				return m, tier
+			}
+		}
+	}
+	return "", 0
+}
+
+// Returns true if only slurs are filtered in a channel, either because of filter.slursonly or because it (or the channel a
+// thread is in) is in filter.channels
+func slursOnly(info *GuildInfo, channel string) bool {
+	if info.config.Filter.SlursOnly || info.config.Filter.Channels[channel] {
+		return true
+	}
+	if len(info.config.Filter.Channels) == 0 {
+		return false
+	}
+	ch, err := sb.dg.State.Channel(channel)
+	return err == nil && len(ch.ParentID) > 0 && info.config.Filter.Channels[ch.ParentID]
+}
+
+func (w *FilterModule) check(info *GuildInfo, m *discordgo.Message) {
+	if m.Author == nil || m.Author.Bot || len(m.Content) == 0 || info.HasModRole(m.Author.ID) {
+		return
+	}
+	min := filterMild
+	if slursOnly(info, m.ChannelID) {
+		min = filterSlur
+	}
+	matched, tier := w.match(m.Content, min)
+	if len(matched) == 0 {
+		return
+	}
+	name := getUserName(SBatoi(m.Author.ID), info)
+	tiername := filterTierNames[tier]
+	action := info.config.Filter.Actions[tiername]
+	if !filterActions[action] {
+		action = "delete"
+	}
+	if action == "log" {
+		info.LogTo(LogModeration, name, " used ", tiername, " language in #", getChannelName(m.ChannelID), ": \"", matched, "\"")
+		return
+	}
+	deleteMessage(m.ChannelID, m.ID)
+	info.LogTo(LogModeration, "Deleted a message from ", name, " in #", getChannelName(m.ChannelID), " because it contained \"", matched, "\" (", tiername, ")")
+	if punishFilterTier(info, m.Author.ID, m.ChannelID, tiername, action) && info.config.Filter.Warn && RateLimit(&w.lastmsg, 5) {
+		info.SendMessage(m.ChannelID, "<@"+m.Author.ID+"> `Your message was removed because it contained a word that isn't allowed here.`")
+	}
+}
+
+// Records that a user's message was removed for language in one of the filter tiers, then warns or silences them if
+// the tier's action says to. Returns false if they were silenced, or nothing could be recorded because of a database
+// outage.
+func punishFilterTier(info *GuildInfo, user string, channel string, tiername string, action string) bool {
+	if !sb.db.CheckStatus() {
+		return false
+	}
+	now := time.Now().UTC()
+	info.AddOffense(Offense{Type: OFFENSE_FILTER, User: SBatoi(user), Moderator: SBatoi(sb.SelfID), Reason: tiername, Timestamp: now})
+	switch action {
+	case "warn":
+		info.AddOffense(Offense{Type: OFFENSE_WARNING, User: SBatoi(user), Moderator: SBatoi(sb.SelfID), Reason: "Used " + tiername + " language", Timestamp: now})
+		if channel, err := sb.dg.UserChannelCreate(user); err == nil {
+			sb.dg.ChannelMessageSend(channel.ID, "You have been warned on "+info.Name+" for using language that isn't allowed there.")
+		}
+	case "silence":
+		info.AddOffense(Offense{Type: OFFENSE_WARNING, User: SBatoi(user), Moderator: SBatoi(sb.SelfID), Reason: "Silenced for using " + tiername + " language", Timestamp: now})
+		if SilenceMemberSimple(user, info) == 0 {
+			info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+user+"> was silenced for using "+tiername+" language in <#"+channel+">. Please investigate.")
+		}
+		return false
+	}
+	return true
+}
+
+// OnMessageCreate discord hook
+func (w *FilterModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	w.check(info, m)
+}
+
+// OnMessageUpdate discord hook
+func (w *FilterModule) OnMessageUpdate(info *GuildInfo, m *discordgo.Message) {
+	w.check(info, m)
+}
+
+type addFilterCommand struct {
+	filter *FilterModule
+}
+
+func (c *addFilterCommand) Name() string {
+	return "AddFilter"
+}
+func (c *addFilterCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must provide a word or phrase to block.```", false, nil
+	}
+	entry := strings.TrimSpace(normalizeFilterText(args[0]))
+	if len(entry) == 0 {
+		return "```That word is empty once invisible characters are removed.```", false, nil
+	}
+	wholeword := true
+	tier := "strong"
+	for _, arg := range args[1:] {
+		switch arg = strings.ToLower(arg); arg {
+		case "anywhere":
+			wholeword = false
+		case "mild", "strong", "slur":
+			tier = arg
+		default:
+			return "```" + arg + " isn't anywhere, mild, strong or slur.```", false, nil
+		}
+	}
+	CheckMapNilBool(&info.config.Filter.Words)
+	CheckMapNilString(&info.config.Filter.Severity)
+	info.config.Filter.Words[entry] = wholeword
+	info.config.Filter.Severity[entry] = tier
+	info.SaveConfig()
+	if !c.filter.UpdateRegex(info) {
+		delete(info.config.Filter.Words, entry)
+		delete(info.config.Filter.Severity, entry)
+		c.filter.UpdateRegex(info)
+		return "```Failed to add " + entry + " because regex compilation failed.```", false, nil
+	}
+	if wholeword {
+		return "```Blocked " + entry + " as a whole word (" + tier + ").```", false, nil
+	}
+	return "```Blocked " + entry + " anywhere in a message (" + tier + ").```", false, nil
+}
+func (c *addFilterCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Adds a word or phrase to the filter, or changes an entry that is already there. The entry is normalized, so blocking `ass` also blocks `4ss` and `a$$`.",
+		Params: []CommandUsageParam{
+			{Name: "word", Desc: "The word or phrase to block, in quotes if it has spaces.", Optional: false},
+			{Name: "anywhere", Desc: "If specified, the entry will also match inside other words. Otherwise, it only matches whole words.", Optional: true},
+			{Name: "mild/strong/slur", Desc: "How severe the entry is, which decides what `filter.actions` does about it. Defaults to strong.", Optional: true},
+		},
+	}
+}
+func (c *addFilterCommand) UsageShort() string { return "Blocks a word or phrase." }
+
+type removeFilterCommand struct {
+	filter *FilterModule
+}
+
+func (c *removeFilterCommand) Name() string {
+	return "RemoveFilter"
+}
+func (c *removeFilterCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must provide a word or phrase to unblock.```", false, nil
+	}
+	entry := strings.TrimSpace(normalizeFilterText(msg.Content[indices[0]:]))
+	if _, ok := info.config.Filter.Words[entry]; !ok {
+		return "```" + entry + " isn't in the filter.```", false, nil
+	}
+	delete(info.config.Filter.Words, entry)
+	delete(info.config.Filter.Severity, entry)
+	info.SaveConfig()
+	c.filter.UpdateRegex(info)
+	return "```Unblocked " + entry + ".```", false, nil
+}
+func (c *removeFilterCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Removes a word or phrase from the filter.",
+		Params: []CommandUsageParam{
+			{Name: "word", Desc: "The word or phrase to unblock.", Optional: false},
+		},
+	}
+}
+func (c *removeFilterCommand) UsageShort() string { return "Unblocks a word or phrase." }
+
+type listFilterCommand struct {
+}
+
+func (c *listFilterCommand) Name() string {
+	return "ListFilter"
+}
+func (c *listFilterCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(info.config.Filter.Words) == 0 {
+		return "```The filter is empty.```", false, nil
+	}
+	lines := make([]string, 0, len(info.config.Filter.Words)+len(info.config.Filter.Allow)+2)
+	lines = append(lines, "Blocked words:")
+	for k, v := range info.config.Filter.Words {
+		line := k + " (" + filterTierNames[filterTier(info, k)]
+		if !v {
+			line += ", anywhere"
+		}
+		lines = append(lines, line+")")
+	}
+	if len(info.config.Filter.Allow) > 0 {
+		lines = append(lines, "", "Exceptions: "+strings.Join(MapToSlice(info.config.Filter.Allow), ", "))
+	}
+	return "```" + strings.Join(lines, "\n") + "```", true, nil
+}
+func (c *listFilterCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{Desc: "Sends you a private message listing every blocked word and exception."}
+}
+func (c *listFilterCommand) UsageShort() string { return "Lists blocked words." }
+
+type addExceptionCommand struct {
+	filter *FilterModule
+}
+
+func (c *addExceptionCommand) Name() string {
+	return "AddException"
+}
+func (c *addExceptionCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must provide a word to allow.```", false, nil
+	}
+	entry := strings.TrimSpace(normalizeFilterText(msg.Content[indices[0]:]))
+	CheckMapNilBool(&info.config.Filter.Allow)
+	info.config.Filter.Allow[entry] = true
+	info.SaveConfig()
+	if !c.filter.UpdateRegex(info) {
+		delete(info.config.Filter.Allow, entry)
+		c.filter.UpdateRegex(info)
+		return "```Failed to add " + entry + " because regex compilation failed.```", false, nil
+	}
+	return "```" + entry + " will no longer be filtered.```", false, nil
+}
+func (c *addExceptionCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Adds an exception to the filter, for innocent words that contain a blocked word, like `scunthorpe`.",
+		Params: []CommandUsageParam{
+			{Name: "word", Desc: "The whole word to allow.", Optional: false},
+		},
+	}
+}
+func (c *addExceptionCommand) UsageShort() string { return "Adds a filter exception." }
+
+type removeExceptionCommand struct {
+	filter *FilterModule
+}
+
+func (c *removeExceptionCommand) Name() string {
+	return "RemoveException"
+}
+func (c *removeExceptionCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must provide an exception to remove.```", false, nil
+	}
+	entry := strings.TrimSpace(normalizeFilterText(msg.Content[indices[0]:]))
+	if _, ok := info.config.Filter.Allow[entry]; !ok {
+		return "```" + entry + " isn't an exception.```", false, nil
+	}
+	delete(info.config.Filter.Allow, entry)
+	info.SaveConfig()
+	c.filter.UpdateRegex(info)
+	return "```Removed the exception for " + entry + ".```", false, nil
+}
+func (c *removeExceptionCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Removes an exception from the filter.",
+		Params: []CommandUsageParam{
+			{Name: "word", Desc: "The exception to remove.", Optional: false},
+		},
+	}
+}
+func (c *removeExceptionCommand) UsageShort() string { return "Removes a filter exception." }
+
+type testFilterCommand struct {
+	filter *FilterModule
+}
+
+func (c *testFilterCommand) Name() string {
+	return "TestFilter"
+}
+func (c *testFilterCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must provide some text to test.```", false, nil
+	}
+	arg := msg.Content[indices[0]:]
+	normalized := normalizeFilterText(arg)
+	min := filterMild
+	if slursOnly(info, msg.ChannelID) {
+		min = filterSlur
+	}
+	if matched, tier := c.filter.match(arg, min); len(matched) > 0 {
+		action := info.config.Filter.Actions[filterTierNames[tier]]
+		if !filterActions[action] {
+			action = "delete"
+		}
+		return "```Normalized: " + normalized + "\nThis contains \"" + matched + "\", which is " + filterTierNames[tier] + ", so the filter would " + action + " it.```", false, nil
+	}
+	return "```Normalized: " + normalized + "\nThis would not be filtered.```", false, nil
+}
+func (c *testFilterCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Shows what the filter would do with a message, without deleting anything. Useful for checking that the filter catches attempts to get around it.",
+		Params: []CommandUsageParam{
+			{Name: "arbitrary string", Desc: "The text to test.", Optional: false},
+		},
+	}
+}
+func (c *testFilterCommand) UsageShort() string { return "Tests the filter." }
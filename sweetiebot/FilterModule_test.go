@@ -0,0 +1,88 @@
+package sweetiebot
+
+import (
+	"testing"
+)
+
+func newTestFilter(t *testing.T) (*FilterModule, *GuildInfo) {
+	info := &GuildInfo{}
+	info.config.Filter.Words = map[string]bool{"darn": true, "heck": false, "bad": true, "slurword": false}
+	info.config.Filter.Severity = map[string]string{"darn": "mild", "slurword": "slur"}
+	info.config.Filter.Allow = map[string]bool{"badge": true, "hecking good": true}
+	w := &FilterModule{}
+	if !w.UpdateRegex(info) {
+		t.Fatal("UpdateRegex failed")
+	}
+	return w, info
+}
+
+func TestFilterTier(t *testing.T) {
+	_, info := newTestFilter(t)
+	cases := []struct {
+		entry string
+		want  int
+	}{
+		{"darn", filterMild},
+		{"heck", filterStrong},
+		{"bad", filterStrong},
+		{"slurword", filterSlur},
+		{"unknown", filterStrong},
+	}
+	for _, c := range cases {
+		if got := filterTier(info, c.entry); got != c.want {
+			t.Errorf("filterTier(%q) = %v, want %v", c.entry, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeFilterText(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"HELLO", "hello"},
+		{"h3ll0", "hello"},
+		{"$7r1ng", "string"},
+		{"@pple", "apple"},
+		{"b\u200Ba\u200Dd", "bad"},
+		{"soft\u00ADhyphen", "softhyphen"},
+		{"8i9", "big"},
+		{"|ol", "lol"},
+	}
+	for _, c := range cases {
+		if got := normalizeFilterText(c.in); got != c.want {
+			t.Errorf("normalizeFilterText(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	w, _ := newTestFilter(t)
+	cases := []struct {
+		name  string
+		text  string
+		min   int
+		match string
+		tier  int
+	}{
+		{"clean", "a perfectly nice message", filterMild, "", 0},
+		{"mild whole word", "oh darn it", filterMild, "darn", filterMild},
+		{"whole word inside another word", "darned socks", filterMild, "", 0},
+		{"substring inside another word", "what the hecking", filterMild, "heck", filterStrong},
+		{"leetspeak", "b4d", filterMild, "bad", filterStrong},
+		{"invisible characters", "b\u200Bad", filterMild, "bad", filterStrong},
+		{"spaced out substring", "h e c k", filterMild, "heck", filterStrong},
+		{"most severe wins", "darn slurword", filterMild, "slurword", filterSlur},
+		{"below the minimum tier", "oh darn", filterSlur, "", 0},
+		{"slur at the minimum tier", "slurword", filterSlur, "slurword", filterSlur},
+		{"allowed word", "nice badge", filterMild, "", 0},
+		{"allowed phrase", "a hecking good dog", filterMild, "", 0},
+		{"allowed word next to a blocked one", "bad badge", filterMild, "bad", filterStrong},
+	}
+	for _, c := range cases {
+		m, tier := w.match(c.text, c.min)
+		if m != c.match || (len(m) > 0 && tier != c.tier) {
+			t.Errorf("%s: match(%q) = %q, %v, want %q, %v", c.name, c.text, m, tier, c.match, c.tier)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package sweetiebot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const defaultGhostPingMessage = "Please don't ping people on {server} and then delete the message. The moderators have been told."
+
+// Breaks @everyone and @here in the messages we report, since the mod channel shouldn't get pinged by someone else's message
+var everyoneBreaker = strings.NewReplacer("@everyone", "@\u200Beveryone", "@here", "@\u200Bhere")
+
+// No more than this many recent pings are remembered per server, no matter how short the window is
+const ghostPingLimit = 1000
+
+// A recent message that pinged someone, kept until ghostping.window runs out
+type recentPing struct {
+	author   string
+	channel  string
+	mentions []string // pings of each user and role, like <@id> and <@&id>
+	posted   time.Time
+}
+
+// Messages Sweetie Bot deleted herself in the last few minutes. Discord merges repeated deletions by the same user into
+// one audit log entry, so the audit log can't be relied on to tell her own deletions apart from the author's.
+var selfDeleted = struct {
+	sync.Mutex
+	ids map[string]time.Time
+}{ids: make(map[string]time.Time)}
+
+// Remembers that Sweetie Bot is deleting these messages herself
+func markSelfDeleted(ids ...string) {
+	now := time.Now().UTC()
+	selfDeleted.Lock()
+	defer selfDeleted.Unlock()
+	for k, t := range selfDeleted.ids {
+		if now.Sub(t) > 5*time.Minute {
+			delete(selfDeleted.ids, k)
+		}
+	}
+	for _, id := range ids {
+		selfDeleted.ids[id] = now
+	}
+}
+
+func wasSelfDeleted(id string) bool {
+	selfDeleted.Lock()
+	defer selfDeleted.Unlock()
+	_, ok := selfDeleted.ids[id]
+	return ok
+}
+
+// deleteMessage deletes someone else's message, and remembers that it was Sweetie Bot who deleted it, so it's never
+// mistaken for a ghost ping
+func deleteMessage(channel string, id string) error {
+	markSelfDeleted(id)
+	return sb.dg.ChannelMessageDelete(channel, id)
+}
+
+// GhostPingModule catches people who ping someone and then quickly delete the message, which leaves the person they
+// pinged with a notification that leads nowhere.
+type GhostPingModule struct {
+	lock  sync.Mutex
+	pings map[string]*recentPing // by message ID
+}
+
+// Name of the module
+func (w *GhostPingModule) Name() string {
+	return "GhostPings"
+}
+
+// Commands in the module
+func (w *GhostPingModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *GhostPingModule) Description() string {
+	return "Alerts the moderators when someone pings a user or role and deletes the message within `ghostping.window` seconds. The original message is included if `privacy.storecontent` is on. Edits that remove a ping are never reported."
+}
+
+// Returns everyone a message pinged, leaving out the author and bots
+func pingedMentions(m *discordgo.Message) []string {
+	r := []string{}
+	if m.MentionEveryone {
+		r = append(r, "@everyone")
+	}
+	for _, u := range m.Mentions {
+		if u.ID != m.Author.ID && u.ID != sb.SelfID && !u.Bot {
+			r = append(r, "<@"+u.ID+">")
+		}
+	}
+	for _, role := range m.MentionRoles {
+		r = append(r, "<@&"+role+">")
+	}
+	return r
+}
+
+// Turns pings into names, so reporting a ghost ping doesn't ping everyone all over again
+func pingNames(info *GuildInfo, mentions []string) string {
+	names := make([]string, len(mentions))
+	for i, v := range mentions {
+		names[i] = v
+		if v == "@everyone" {
+			names[i] = everyoneBreaker.Replace(v)
+		} else if strings.HasPrefix(v, "<@&") {
+			if r, err := sb.dg.State.Role(info.ID, StripPing(v)); err == nil {
+				names[i] = "@" + r.Name
+			}
+		} else if strings.HasPrefix(v, "<@") {
+			names[i] = "@" + getUserName(SBatoi(StripPing(v)), info)
+		}
+	}
+	return SanitizeMentions(strings.Join(names, ", "))
+}
+
+// OnMessageCreate discord hook
+func (w *GhostPingModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	window := time.Duration(info.config.GhostPing.Window) * time.Second
+	if window <= 0 || m.Author == nil || m.Author.Bot {
+		return
+	}
+	mentions := pingedMentions(m)
+	if len(mentions) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.pings == nil {
+		w.pings = make(map[string]*recentPing)
+	}
+	for k, v := range w.pings {
+		if now.Sub(v.posted) > window {
+			delete(w.pings, k)
+		}
+	}
+	if len(w.pings) < ghostPingLimit {
+		w.pings[m.ID] = &recentPing{m.Author.ID, m.ChannelID, mentions, now}
+	}
+}
+
+// Returns true if a moderator, or a bot such as Sweetie Bot herself, deleted the author's message instead of the
+// author. Someone deleting their own message doesn't show up in the audit log.
+func deletedByOther(info *GuildInfo, p *recentPing) bool {
+	log, err := sb.dg.GuildAuditLog(info.ID, "", "", int(discordgo.AuditLogActionMessageDelete), 10)
+	if err != nil {
+		return false
+	}
+	for _, e := range log.AuditLogEntries {
+		if e.TargetID == p.author && e.Options != nil && e.Options.ChannelID == p.channel && snowflakeTime(SBatoi(e.ID)).After(p.posted) {
+			return true
+		}
+	}
+	return false
+}
+
+// OnMessageDelete discord hook
+func (w *GhostPingModule) OnMessageDelete(info *GuildInfo, m *discordgo.Message) {
+	w.lock.Lock()
+	p, ok := w.pings[m.ID]
+	delete(w.pings, m.ID)
+	w.lock.Unlock()
+	if !ok || wasSelfDeleted(m.ID) || time.Now().UTC().Sub(p.posted) > time.Duration(info.config.GhostPing.Window)*time.Second {
+		return
+	}
+	cached := info.messagecache.Get(m.ID)
+	go func() {
+		time.Sleep(2 * time.Second) // The audit log entry can show up a little after the gateway event does
+		if deletedByOther(info, p) {
+			return
+		}
+		name := getUserName(SBatoi(p.author), info)
+		pinged := pingNames(info, p.mentions)
+		after := TimeDiff(time.Now().UTC().Sub(p.posted))
+		s := "Ghost ping: <@" + p.author + "> pinged " + pinged + " in <#" + p.channel + "> and deleted the message " + after + " later."
+		if cached != nil && len(cached.Content) > 0 {
+			s += "\nThe message said: " + truncateRunes(everyoneBreaker.Replace(SanitizeMentions(PartialSanitize(cached.Content))), 1500)
+		}
+		info.SendMessage(SBitoa(info.config.Basic.ModChannel), s)
+		info.LogTo(LogModeration, name, " ghost pinged ", pinged, " in #", getChannelName(p.channel), ".")
+		if info.config.GhostPing.Warn {
+			msg := info.config.GhostPing.Message
+			if len(msg) == 0 {
+				msg = defaultGhostPingMessage
+			}
+			msg = renderTemplate(info, msg, map[string]string{"user": "<@" + p.author + ">", "username": name, "channel": "<#" + p.channel + ">"})
+			if ch, err := sb.dg.UserChannelCreate(p.author); err == nil {
+				sb.dg.ChannelMessageSend(ch.ID, msg)
+			}
+		}
+	}()
+}
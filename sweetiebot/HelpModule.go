@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -27,6 +28,8 @@ func (w *HelpModule) Commands() []Command {
 		&aboutCommand{},
 		&rulesCommand{},
 		&changelogCommand{},
+		&versionCommand{},
+		&serverInfoCommand{},
 	}
 }
 
@@ -47,14 +50,16 @@ func DumpCommandsModules(channelID string, info *GuildInfo, footer string, descr
 	fields := make([]*discordgo.MessageEmbedField, 0, len(info.modules))
 	for _, v := range info.modules {
 		cmds := v.Commands()
-		if len(cmds) > 0 {
+		if len(info.IsModuleDisabled(v.Name())) > 0 {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: v.Name() + " [disabled]", Value: "*[module disabled]*", Inline: true})
+		} else if len(cmds) > 0 {
 			s := make([]string, 0, len(cmds))
 			for _, c := range cmds {
 				s = append(s, c.Name()+info.IsCommandDisabled(c.Name()))
 			}
-			fields = append(fields, &discordgo.MessageEmbedField{Name: v.Name() + info.IsModuleDisabled(v.Name()), Value: strings.Join(s, "\n"), Inline: true})
+			fields = append(fields, &discordgo.MessageEmbedField{Name: v.Name(), Value: strings.Join(s, "\n"), Inline: true})
 		} else {
-			fields = append(fields, &discordgo.MessageEmbedField{Name: v.Name() + info.IsModuleDisabled(v.Name()), Value: "*[no commands]*", Inline: true})
+			fields = append(fields, &discordgo.MessageEmbedField{Name: v.Name(), Value: "*[no commands]*", Inline: true})
 		}
 	}
 	return &discordgo.MessageEmbed{
@@ -270,3 +275,98 @@ func (c *changelogCommand) Usage(info *GuildInfo) *CommandUsage {
 	}
 }
 func (c *changelogCommand) UsageShort() string { return "Retrieves the changelog for Sweetie Bot." }
+
+type serverInfoCommand struct {
+	lock     sync.Mutex
+	static   []*discordgo.MessageEmbedField
+	cachedAt int64
+}
+
+var verificationLevels = []string{"None", "Low", "Medium", "High", "Very High"}
+
+func (c *serverInfoCommand) Name() string {
+	return "ServerInfo"
+}
+
+// Fields that rarely change are cached for a few minutes, since building them requires looking up the owner
+func (c *serverInfoCommand) staticFields(info *GuildInfo, g *discordgo.Guild) []*discordgo.MessageEmbedField {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.static != nil && time.Now().UTC().Unix()-c.cachedAt < 300 {
+		return c.static
+	}
+	c.cachedAt = time.Now().UTC().Unix()
+	owner := "unknown"
+	if len(g.OwnerID) > 0 {
+		owner = getUserName(SBatoi(g.OwnerID), info) + " (" + g.OwnerID + ")"
+	}
+	verification := "unknown"
+	if int(g.VerificationLevel) < len(verificationLevels) {
+		verification = verificationLevels[g.VerificationLevel]
+	}
+	created := snowflakeTime(SBatoi(g.ID))
+	c.static = []*discordgo.MessageEmbedField{
+		{Name: "ID", Value: g.ID, Inline: true},
+		{Name: "Owner", Value: owner, Inline: true},
+		{Name: "Created", Value: created.Format("Jan 2, 2006") + " (" + TimeDiff(time.Now().UTC().Sub(created)) + " ago)", Inline: true},
+		{Name: "Verification Level", Value: verification, Inline: true},
+	}
+	return c.static
+}
+
+func (c *serverInfoCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	g, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return "```Guild not in state?!```", false, nil
+	}
+	fields := append([]*discordgo.MessageEmbedField{}, c.staticFields(info, g)...)
+
+	sb.dg.State.RLock()
+	members := strconv.Itoa(g.MemberCount)
+	if g.MemberCount == 0 {
+		members = "unknown" // Only sent if the bot was given the guild members intent
+	}
+	text, voice, categories := 0, 0, 0
+	for _, ch := range g.Channels {
+		switch ch.Type {
+		case discordgo.ChannelTypeGuildCategory:
+			categories++
+		case discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice:
+			voice++
+		default:
+			text++
+		}
+	}
+	roles := len(g.Roles)
+	emojis := len(g.Emojis)
+	stickers := len(g.Stickers)
+	name := g.Name
+	icon := g.IconURL("256")
+	tier := int(g.PremiumTier)
+	boosts := g.PremiumSubscriptionCount
+	sb.dg.State.RUnlock()
+
+	fields = append(fields,
+		&discordgo.MessageEmbedField{Name: "Members", Value: members, Inline: true},
+		&discordgo.MessageEmbedField{Name: "Channels", Value: fmt.Sprintf("%v text, %v voice, %v categories", text, voice, categories), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Roles", Value: strconv.Itoa(roles), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Emojis", Value: fmt.Sprintf("%v emojis, %v stickers", emojis, stickers), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Boosts", Value: fmt.Sprintf("Level %v (%s)", tier, Pluralize(int64(boosts), " boost")), Inline: true},
+	)
+	embed := &discordgo.MessageEmbed{
+		Type:   "rich",
+		Title:  name,
+		Color:  0x3e92e5,
+		Fields: fields,
+	}
+	if len(icon) > 0 {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: icon}
+	}
+	return "", false, embed
+}
+func (c *serverInfoCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Displays information about this server, such as its owner, when it was created, and how many members, channels, roles and emojis it has.",
+	}
+}
+func (c *serverInfoCommand) UsageShort() string { return "Displays information about this server." }
@@ -0,0 +1,211 @@
+package sweetiebot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type cachedInvite struct {
+	uses    int
+	maxuses int
+	inviter string
+	channel string
+	deleted time.Time // when discord told us the invite was deleted, which happens as soon as its last use is taken
+	usedup  bool      // true once a join has been attributed to the last use of a deleted invite
+}
+
+// InviteModule remembers how many times each invite has been used, so when someone joins it can work out which invite's
+// count went up and log who invited them.
+type InviteModule struct {
+	lock     sync.Mutex
+	invites  map[string]*cachedInvite // by code
+	vanity   int                      // uses of the vanity URL, or -1 if the server doesn't have one
+	loaded   bool
+	lastwarn int64
+}
+
+// Invites that were deleted are kept this long, in case a join that used up their last use is processed after the deletion
+const deletedInviteGrace = time.Minute
+
+// Name of the module
+func (w *InviteModule) Name() string {
+	return "Invites"
+}
+
+// Commands in the module
+func (w *InviteModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *InviteModule) Description() string {
+	return "If `log.invites` is true, logs which invite each new member joined with and who created it."
+}
+
+// Gets the current use count of every invite, and of the vanity URL if there is one
+func fetchInvites(info *GuildInfo) (map[string]*cachedInvite, int, error) {
+	var invites []*discordgo.Invite
+	err := CallAPI("GuildInvites", func() (err error) {
+		invites, err = sb.dg.GuildInvites(info.ID)
+		return
+	})
+	if err != nil {
+		return nil, -1, err
+	}
+	r := make(map[string]*cachedInvite, len(invites))
+	for _, inv := range invites {
+		c := &cachedInvite{uses: inv.Uses, maxuses: inv.MaxUses}
+		if inv.Inviter != nil {
+			c.inviter = inv.Inviter.ID
+		}
+		if inv.Channel != nil {
+			c.channel = inv.Channel.ID
+		}
+		r[inv.Code] = c
+	}
+	vanity := -1
+	if g, err := sb.dg.State.Guild(info.ID); err == nil && len(g.VanityURLCode) > 0 {
+		endpoint := discordgo.EndpointGuild(info.ID) + "/vanity-url"
+		if body, err := sb.dg.RequestWithBucketID("GET", endpoint, nil, endpoint); err == nil {
+			var v struct {
+				Uses int `json:"uses"`
+			}
+			if json.Unmarshal(body, &v) == nil {
+				vanity = v.Uses
+			}
+		}
+	}
+	return r, vanity, nil
+}
+
+// Replaces the cache with the current invites. Must be called with the lock held.
+func (w *InviteModule) refresh(info *GuildInfo) (map[string]*cachedInvite, int, bool) {
+	invites, vanity, err := fetchInvites(info)
+	if err != nil {
+		if ClassifyAPIError(err) == APIErrorPermission && RateLimit(&w.lastwarn, 3600) {
+			info.LogTo(LogJoins, "I need the Manage Server permission to see which invite new members used.")
+		}
+		return nil, -1, false
+	}
+	old, oldvanity := w.invites, w.vanity
+	now := time.Now()
+	for code, c := range old {
+		if _, ok := invites[code]; !ok && !c.deleted.IsZero() && now.Sub(c.deleted) < deletedInviteGrace {
+			invites[code] = c // Keep recently deleted invites around until the grace period is over
+		}
+	}
+	w.invites, w.vanity, w.loaded = invites, vanity, true
+	return old, oldvanity, true
+}
+
+// Works out which invites were used since the last refresh
+func (w *InviteModule) attribute(info *GuildInfo, m *discordgo.Member) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	wasloaded := w.loaded
+	old, oldvanity, ok := w.refresh(info)
+	if !ok {
+		return
+	}
+	name := m.User.Username + " (" + m.User.ID + ")"
+	if !wasloaded {
+		info.LogTo(LogJoins, name, " joined, but I hadn't loaded the invites yet, so I don't know which one they used.")
+		return
+	}
+	used := []string{}
+	for code, c := range w.invites {
+		prev, existed := old[code]
+		switch {
+		case !existed && c.uses > 0 && c.deleted.IsZero(): // created and used before we saw it being created
+			used = append(used, code)
+		case existed && c.deleted.IsZero() && c.uses > prev.uses:
+			used = append(used, code)
+		case existed && !c.deleted.IsZero() && !c.usedup && c.maxuses > 0 && c.uses+1 >= c.maxuses:
+			// Deleted right after its last use, which we never see counted
+			used = append(used, code)
+			c.uses++
+			c.usedup = true
+		}
+	}
+	if oldvanity >= 0 && w.vanity > oldvanity {
+		used = append(used, "vanity")
+	}
+	sort.Strings(used)
+	switch len(used) {
+	case 0:
+		info.LogTo(LogJoins, name, " joined, but I couldn't tell which invite they used. It may have been a temporary invite, server discovery, or a bot adding them.")
+	case 1:
+		info.LogTo(LogJoins, name, " joined using ", w.describe(info, used[0]), ".")
+	default:
+		// Several people joined at once, so any of these could be theirs
+		descs := make([]string, 0, len(used))
+		for _, code := range used {
+			descs = append(descs, w.describe(info, code))
+		}
+		info.LogTo(LogJoins, name, " joined using one of these invites: ", strings.Join(descs, ", "), ".")
+	}
+}
+
+// Describes an invite for the log. Must be called with the lock held.
+func (w *InviteModule) describe(info *GuildInfo, code string) string {
+	if code == "vanity" {
+		return fmt.Sprintf("the vanity URL (%v uses)", w.vanity)
+	}
+	c := w.invites[code]
+	s := "invite " + code
+	if len(c.inviter) > 0 {
+		s += " created by " + getUserName(SBatoi(c.inviter), info)
+	}
+	if len(c.channel) > 0 {
+		s += " for #" + getChannelName(c.channel)
+	}
+	return s + " (" + Pluralize(int64(c.uses), " use") + ")"
+}
+
+func (w *InviteModule) inviteCreated(i *discordgo.InviteCreate) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if !w.loaded {
+		return
+	}
+	c := &cachedInvite{uses: i.Uses, maxuses: i.MaxUses, channel: i.ChannelID}
+	if i.Inviter != nil {
+		c.inviter = i.Inviter.ID
+	}
+	w.invites[i.Code] = c
+}
+
+func (w *InviteModule) inviteDeleted(i *discordgo.InviteDelete) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if c, ok := w.invites[i.Code]; ok {
+		c.deleted = time.Now()
+	}
+}
+
+// OnGuildCreate discord hook
+func (w *InviteModule) OnGuildCreate(info *GuildInfo, g *discordgo.Guild) {
+	if !info.config.Log.Invites {
+		return
+	}
+	go func() {
+		w.lock.Lock()
+		w.refresh(info)
+		w.lock.Unlock()
+	}()
+}
+
+// OnGuildMemberAdd discord hook
+func (w *InviteModule) OnGuildMemberAdd(info *GuildInfo, m *discordgo.Member) {
+	if !info.config.Log.Invites {
+		w.lock.Lock()
+		w.loaded = false // Otherwise turning this back on would compare against invites from before it was turned off
+		w.lock.Unlock()
+		return
+	}
+	go w.attribute(info, m)
+}
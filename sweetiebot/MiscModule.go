@@ -24,6 +24,8 @@ func (w *MiscModule) Commands() []Command {
 		&searchCommand{emotes: w.emotes, statements: make(map[string][]*sql.Stmt)},
 		&rollCommand{},
 		&SnowflakeTimeCommand{},
+		&reactionsCommand{},
+		&cleanupCommand{},
 	}
 }
 
@@ -41,6 +41,24 @@ type ModuleOnMessageDelete interface {
 	OnMessageDelete(*GuildInfo, *discordgo.Message)
 }
 
+// ModuleOnMessageReactionAdd hook interface
+type ModuleOnMessageReactionAdd interface {
+	Module
+	OnMessageReactionAdd(*GuildInfo, *discordgo.MessageReaction)
+}
+
+// ModuleOnMessageReactionRemove hook interface
+type ModuleOnMessageReactionRemove interface {
+	Module
+	OnMessageReactionRemove(*GuildInfo, *discordgo.MessageReaction)
+}
+
+// ModuleOnInteractionCreate hook interface, which returns true if the module responded to the interaction
+type ModuleOnInteractionCreate interface {
+	Module
+	OnInteractionCreate(*GuildInfo, *discordgo.Interaction) bool
+}
+
 // ModuleOnPresenceUpdate hook interface
 type ModuleOnPresenceUpdate interface {
 	Module
@@ -50,7 +68,7 @@ type ModuleOnPresenceUpdate interface {
 // ModuleOnVoiceStateUpdate hook interface
 type ModuleOnVoiceStateUpdate interface {
 	Module
-	OnVoiceStateUpdate(*GuildInfo, *discordgo.VoiceState)
+	OnVoiceStateUpdate(*GuildInfo, *discordgo.VoiceStateUpdate)
 }
 
 // ModuleOnGuildUpdate hook interface
@@ -59,6 +77,12 @@ type ModuleOnGuildUpdate interface {
 	OnGuildUpdate(*GuildInfo, *discordgo.Guild)
 }
 
+// ModuleOnGuildCreate hook interface, called whenever discord sends us the guild, including after reconnecting
+type ModuleOnGuildCreate interface {
+	Module
+	OnGuildCreate(*GuildInfo, *discordgo.Guild)
+}
+
 // ModuleOnGuildMemberAdd hook interface
 type ModuleOnGuildMemberAdd interface {
 	Module
@@ -74,7 +98,7 @@ type ModuleOnGuildMemberRemove interface {
 // ModuleOnGuildMemberUpdate hook interface
 type ModuleOnGuildMemberUpdate interface {
 	Module
-	OnGuildMemberUpdate(*GuildInfo, *discordgo.Member)
+	OnGuildMemberUpdate(*GuildInfo, *discordgo.GuildMemberUpdate)
 }
 
 // ModuleOnGuildBanAdd hook interface
@@ -95,6 +119,13 @@ type ModuleOnGuildRoleDelete interface {
 	OnGuildRoleDelete(*GuildInfo, *discordgo.GuildRoleDelete)
 }
 
+// ModuleOnAutoModAction hook interface, called for every action discord's AutoMod takes, so a single blocked message can
+// arrive more than once
+type ModuleOnAutoModAction interface {
+	Module
+	OnAutoModAction(*GuildInfo, *discordgo.AutoModerationActionExecution)
+}
+
 // ModuleOnCommand hook interface
 type ModuleOnCommand interface {
 	Module
@@ -249,14 +280,20 @@ type moduleHooks struct {
 	OnMessageCreate     []ModuleOnMessageCreate
 	OnMessageUpdate     []ModuleOnMessageUpdate
 	OnMessageDelete     []ModuleOnMessageDelete
+	OnReactionAdd       []ModuleOnMessageReactionAdd
+	OnReactionRemove    []ModuleOnMessageReactionRemove
+	OnInteractionCreate []ModuleOnInteractionCreate
 	OnPresenceUpdate    []ModuleOnPresenceUpdate
+	OnVoiceStateUpdate  []ModuleOnVoiceStateUpdate
 	OnGuildUpdate       []ModuleOnGuildUpdate
+	OnGuildCreate       []ModuleOnGuildCreate
 	OnGuildMemberAdd    []ModuleOnGuildMemberAdd
 	OnGuildMemberRemove []ModuleOnGuildMemberRemove
 	OnGuildMemberUpdate []ModuleOnGuildMemberUpdate
 	OnGuildBanAdd       []ModuleOnGuildBanAdd
 	OnGuildBanRemove    []ModuleOnGuildBanRemove
 	OnGuildRoleDelete   []ModuleOnGuildRoleDelete
+	OnAutoModAction     []ModuleOnAutoModAction
 	OnCommand           []ModuleOnCommand
 	OnIdle              []ModuleOnIdle
 	OnTick              []ModuleOnTick
@@ -275,12 +312,27 @@ func (info *GuildInfo) RegisterModule(m Module) {
 	if h, ok := m.(ModuleOnMessageDelete); ok {
 		info.hooks.OnMessageDelete = append(info.hooks.OnMessageDelete, h)
 	}
+	if h, ok := m.(ModuleOnMessageReactionAdd); ok {
+		info.hooks.OnReactionAdd = append(info.hooks.OnReactionAdd, h)
+	}
+	if h, ok := m.(ModuleOnMessageReactionRemove); ok {
+		info.hooks.OnReactionRemove = append(info.hooks.OnReactionRemove, h)
+	}
+	if h, ok := m.(ModuleOnInteractionCreate); ok {
+		info.hooks.OnInteractionCreate = append(info.hooks.OnInteractionCreate, h)
+	}
 	if h, ok := m.(ModuleOnPresenceUpdate); ok {
 		info.hooks.OnPresenceUpdate = append(info.hooks.OnPresenceUpdate, h)
 	}
+	if h, ok := m.(ModuleOnVoiceStateUpdate); ok {
+		info.hooks.OnVoiceStateUpdate = append(info.hooks.OnVoiceStateUpdate, h)
+	}
 	if h, ok := m.(ModuleOnGuildUpdate); ok {
 		info.hooks.OnGuildUpdate = append(info.hooks.OnGuildUpdate, h)
 	}
+	if h, ok := m.(ModuleOnGuildCreate); ok {
+		info.hooks.OnGuildCreate = append(info.hooks.OnGuildCreate, h)
+	}
 	if h, ok := m.(ModuleOnGuildMemberAdd); ok {
 		info.hooks.OnGuildMemberAdd = append(info.hooks.OnGuildMemberAdd, h)
 	}
@@ -299,6 +351,9 @@ func (info *GuildInfo) RegisterModule(m Module) {
 	if h, ok := m.(ModuleOnGuildRoleDelete); ok {
 		info.hooks.OnGuildRoleDelete = append(info.hooks.OnGuildRoleDelete, h)
 	}
+	if h, ok := m.(ModuleOnAutoModAction); ok {
+		info.hooks.OnAutoModAction = append(info.hooks.OnAutoModAction, h)
+	}
 	if h, ok := m.(ModuleOnCommand); ok {
 		info.hooks.OnCommand = append(info.hooks.OnCommand, h)
 	}
@@ -0,0 +1,209 @@
+package sweetiebot
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// NewChannelModule announces channels as they're created, and gives new channels the permissions saved for their
+// category, so a big server's channels stay consistent without every moderator having to remember how they're set up.
+type NewChannelModule struct {
+}
+
+// Name of the module
+func (w *NewChannelModule) Name() string {
+	return "NewChannels"
+}
+
+// Commands in the module
+func (w *NewChannelModule) Commands() []Command {
+	return []Command{
+		&channelTemplateCommand{},
+	}
+}
+
+// Description of the module
+func (w *NewChannelModule) Description() string {
+	return "Posts new channels in `newchannels.announce`, and if `newchannels.applytemplates` is true, gives new channels the permissions saved for their category with `!channeltemplate`. Channels that @everyone can't see aren't announced, and neither are channels Sweetie Bot made herself."
+}
+
+// Finds who created a channel from the audit log. Returns an empty string if we can't tell.
+func findChannelCreator(info *GuildInfo, channel string) string {
+	for i := 0; i < 3; i++ { // The audit log entry can show up a little after the gateway event does
+		log, err := sb.dg.GuildAuditLog(info.ID, "", "", int(discordgo.AuditLogActionChannelCreate), 10)
+		if err != nil {
+			return ""
+		}
+		for _, e := range log.AuditLogEntries {
+			if e.TargetID == channel {
+				return e.UserID
+			}
+		}
+		time.Sleep(time.Second)
+	}
+	return ""
+}
+
+// Returns true if @everyone can see the channel, going by the server's default permissions and the channel's overwrites
+func everyoneCanView(info *GuildInfo, ch *discordgo.Channel) bool {
+	var perms int64
+	if role, err := sb.dg.State.Role(info.ID, info.ID); err == nil {
+		perms = role.Permissions
+	}
+	for _, v := range ch.PermissionOverwrites {
+		if v.Type == discordgo.PermissionOverwriteTypeRole && v.ID == info.ID {
+			perms = (perms &^ v.Deny) | v.Allow
+		}
+	}
+	return perms&(discordgo.PermissionViewChannel|discordgo.PermissionAdministrator) != 0
+}
+
+// Sets every permission overwrite saved for the channel's category. The silence role is left alone, since that's handled
+// by spam.silencenewchannels. Returns the channel with its new overwrites.
+func applyChannelTemplate(info *GuildInfo, ch *discordgo.Channel) (*discordgo.Channel, error) {
+	template := info.config.NewChannels.Templates[ch.ParentID]
+	if len(template) == 0 {
+		return ch, nil
+	}
+	changed := *ch
+	changed.PermissionOverwrites = make([]*discordgo.PermissionOverwrite, 0, len(ch.PermissionOverwrites)+len(template))
+	for _, v := range ch.PermissionOverwrites {
+		replaced := false
+		for _, t := range template {
+			replaced = replaced || (t.ID == v.ID && SBatoi(t.ID) != info.config.Spam.SilentRole)
+		}
+		if !replaced {
+			changed.PermissionOverwrites = append(changed.PermissionOverwrites, v)
+		}
+	}
+	for _, t := range template {
+		if SBatoi(t.ID) == info.config.Spam.SilentRole {
+			continue
+		}
+		err := CallAPI("ChannelPermissionSet", func() error {
+			return sb.dg.ChannelPermissionSet(ch.ID, t.ID, t.Type, t.Allow, t.Deny)
+		})
+		if err != nil {
+			return ch, err
+		}
+		changed.PermissionOverwrites = append(changed.PermissionOverwrites, t)
+	}
+	return &changed, nil
+}
+
+// Called when a channel is created
+func (w *NewChannelModule) channelCreated(info *GuildInfo, ch *discordgo.Channel) {
+	announce := info.config.NewChannels.Announce
+	if (announce == 0 && !info.config.NewChannels.ApplyTemplates) || ch.IsThread() || ch.Type == discordgo.ChannelTypeGuildCategory {
+		return
+	}
+	creator := findChannelCreator(info, ch.ID)
+	if creator == sb.SelfID {
+		return // Temporary voice channels and the like would just be noise
+	}
+	if info.config.NewChannels.ApplyTemplates {
+		templated, err := applyChannelTemplate(info, ch)
+		if err != nil {
+			info.LogTo(LogModeration, "Failed to apply the ", getChannelName(ch.ParentID), " template to #", ch.Name, ": ", apiErrorMessage(err))
+		} else if templated != ch {
+			info.LogTo(LogModeration, "Applied the ", getChannelName(ch.ParentID), " template to #", ch.Name, ".")
+		}
+		ch = templated
+	}
+	if announce == 0 || !everyoneCanView(info, ch) {
+		return
+	}
+	by := "someone"
+	if creator != "" {
+		by = getUserName(SBatoi(creator), info)
+	}
+	info.SendMessage(SBitoa(announce), "New channel <#"+ch.ID+"> created by "+by+".")
+}
+
+// Finds a category by its ID or name
+func findCategory(info *GuildInfo, arg string) *discordgo.Channel {
+	guild, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return nil
+	}
+	arg = strings.ToLower(strings.Trim(arg, "\" "))
+	for _, c := range guild.Channels {
+		if c.Type == discordgo.ChannelTypeGuildCategory && (c.ID == arg || strings.ToLower(c.Name) == arg) {
+			return c
+		}
+	}
+	return nil
+}
+
+type channelTemplateCommand struct {
+}
+
+func (c *channelTemplateCommand) Name() string {
+	return "ChannelTemplate"
+}
+func (c *channelTemplateCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		if len(info.config.NewChannels.Templates) == 0 {
+			return "```No channel templates have been saved.```", false, nil
+		}
+		lines := make([]string, 0, len(info.config.NewChannels.Templates))
+		for k, v := range info.config.NewChannels.Templates {
+			lines = append(lines, getChannelName(k)+": "+Pluralize(int64(len(v)), " permission overwrite"))
+		}
+		sort.Strings(lines)
+		if !info.config.NewChannels.ApplyTemplates {
+			lines = append(lines, "", "Templates aren't applied until newchannels.applytemplates is set to true.")
+		}
+		return "```\n" + strings.Join(lines, "\n") + "```", false, nil
+	}
+	if len(args) < 2 {
+		return "```You must give a channel to copy the permissions of, or remove.```", false, nil
+	}
+	last := len(args) - 1
+	category := findCategory(info, msg.Content[indices[0]:indices[last]])
+	if category == nil {
+		return "```There's no category called " + strings.TrimSpace(msg.Content[indices[0]:indices[last]]) + ".```", false, nil
+	}
+	if strings.ToLower(args[last]) == "remove" {
+		if _, ok := info.config.NewChannels.Templates[category.ID]; !ok {
+			return "```" + category.Name + " doesn't have a template.```", false, nil
+		}
+		delete(info.config.NewChannels.Templates, category.ID)
+		info.SaveConfig()
+		return "```Removed the template for " + category.Name + ".```", false, nil
+	}
+	if !channelregex.MatchString(args[last]) {
+		return "```The last argument has to be a #channel to copy the permissions of, or remove.```", false, nil
+	}
+	source, err := sb.dg.State.Channel(args[last][2 : len(args[last])-1])
+	if err != nil || source.GuildID != info.ID {
+		return "```That channel isn't on this server.```", false, nil
+	}
+	if info.config.NewChannels.Templates == nil {
+		info.config.NewChannels.Templates = make(map[string][]*discordgo.PermissionOverwrite)
+	}
+	template := make([]*discordgo.PermissionOverwrite, 0, len(source.PermissionOverwrites))
+	for _, v := range source.PermissionOverwrites {
+		o := *v
+		template = append(template, &o)
+	}
+	info.config.NewChannels.Templates[category.ID] = template
+	info.SaveConfig()
+	info.Log(getUserName(SBatoi(msg.Author.ID), info), " saved the permissions of #", source.Name, " as the template for ", category.Name)
+	return "```New channels in " + category.Name + " will get the same " + Pluralize(int64(len(template)), " permission overwrite") + " as #" + source.Name + ", as long as newchannels.applytemplates is true.```", false, nil
+}
+func (c *channelTemplateCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Saves the permissions of an existing channel as the template for a category. When `newchannels.applytemplates` is true, every channel created in that category is given the same permission overwrites. The silence role's permissions are never changed. With no arguments, lists the saved templates.",
+		Params: []CommandUsageParam{
+			{Name: "category", Desc: "The name or ID of the category.", Optional: true},
+			{Name: "#channel|remove", Desc: "The channel to copy the permissions of, or `remove` to delete the category's template.", Optional: true},
+		},
+	}
+}
+func (c *channelTemplateCommand) UsageShort() string {
+	return "Saves a category's permission template."
+}
@@ -0,0 +1,168 @@
+package sweetiebot
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// NicknameModule cleans up disruptive display names whenever a member joins or changes their name. Each rule is turned on
+// separately: nicknames.dehoist strips the punctuation people use to sort themselves to the top of the member list,
+// nicknames.decancer turns zalgo and fancy unicode letters back into plain ones, and nicknames.blank replaces names with
+// nothing readable left in them.
+type NicknameModule struct {
+	lastwarn int64
+}
+
+// Discord doesn't allow nicknames longer than this
+const maxNicknameLength = 32
+
+const defaultNicknameFallback = "Moderated Nickname"
+
+// Name of the module
+func (w *NicknameModule) Name() string {
+	return "Nicknames"
+}
+
+// Commands in the module
+func (w *NicknameModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *NicknameModule) Description() string {
+	return "Renames members whose names are hoisted with leading symbols, written in zalgo or fancy unicode, or unreadable, depending on which of `nicknames.dehoist`, `nicknames.decancer` and `nicknames.blank` are on."
+}
+
+// Maps the styled letters and digits people use to stand out, like 𝐛𝐨𝐥𝐝, ｆｕｌｌｗｉｄｔｈ or Ⓒⓘⓡⓒⓛⓔⓓ, back to ASCII
+func decancerRune(r rune) rune {
+	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E: // fullwidth forms
+		return r - 0xFEE0
+	case r >= 0x1D400 && r <= 0x1D6A3: // mathematical alphanumeric letters, 52 per style
+		return rune(letters[(r-0x1D400)%52])
+	case r >= 0x1D7CE && r <= 0x1D7FF: // mathematical digits, 10 per style
+		return '0' + (r-0x1D7CE)%10
+	case r >= 0x24B6 && r <= 0x24E9: // circled letters
+		return rune(letters[r-0x24B6])
+	case r >= 0x1F130 && r <= 0x1F149: // squared letters
+		return rune(letters[r-0x1F130])
+	case r >= 0x1F150 && r <= 0x1F169: // negative circled letters
+		return rune(letters[r-0x1F150])
+	case r >= 0x1F170 && r <= 0x1F189: // negative squared letters
+		return rune(letters[r-0x1F170])
+	}
+	return r
+}
+
+func genericCombiningMark(r rune) bool {
+	return (r >= 0x0300 && r <= 0x036F) || (r >= 0x1AB0 && r <= 0x1AFF) || (r >= 0x1DC0 && r <= 0x1DFF) || (r >= 0x20D0 && r <= 0x20FF) || (r >= 0xFE20 && r <= 0xFE2F)
+}
+
+// Returns true if at least one character in the name can actually be read out
+func pronounceable(name string) bool {
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Applies the enabled rules to a display name, returning the new name and which rules changed it
+func normalizeNickname(info *GuildInfo, name string) (string, []string) {
+	rules := []string{}
+	if info.config.Nicknames.Decancer {
+		marks := 0
+		s := strings.Map(func(r rune) rune {
+			if !unicode.In(r, unicode.Mn, unicode.Me) {
+				marks = 0
+				return decancerRune(r)
+			}
+			// Zalgo is made of stacked combining marks from the generic diacritic blocks, which real names almost never need
+			// more than one of, but plenty of scripts need a couple of their own marks on a letter
+			marks++
+			if marks > 2 || (marks > 1 && genericCombiningMark(r)) {
+				return -1
+			}
+			return r
+		}, name)
+		if s != name {
+			rules = append(rules, "decancer")
+			name = s
+		}
+	}
+	if info.config.Nicknames.Dehoist {
+		s := strings.TrimLeftFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) })
+		if s != name && len(s) > 0 {
+			rules = append(rules, "dehoist")
+			name = s
+		}
+	}
+	if info.config.Nicknames.Blank && !pronounceable(name) {
+		fallback := info.config.Nicknames.Fallback
+		if !pronounceable(fallback) {
+			fallback = defaultNicknameFallback
+		}
+		rules = append(rules, "blank")
+		name = fallback
+	}
+	return truncateRunes(strings.TrimSpace(name), maxNicknameLength), rules
+}
+
+func (w *NicknameModule) check(info *GuildInfo, m *discordgo.Member) {
+	if m == nil || m.User == nil || m.User.ID == sb.SelfID {
+		return
+	}
+	if !info.config.Nicknames.Dehoist && !info.config.Nicknames.Decancer && !info.config.Nicknames.Blank {
+		return
+	}
+	if info.config.Nicknames.BypassRole != 0 {
+		bypass := SBitoa(info.config.Nicknames.BypassRole)
+		for _, r := range m.Roles {
+			if r == bypass {
+				return
+			}
+		}
+	}
+	display := m.Nick
+	if len(display) == 0 {
+		display = m.User.GlobalName
+	}
+	if len(display) == 0 {
+		display = m.User.Username
+	}
+	name, rules := normalizeNickname(info, display)
+	if len(rules) == 0 || name == display {
+		return
+	}
+	// Discord won't let us rename the owner or anyone with a role at or above ours, so don't spam the log trying
+	if g, err := sb.dg.State.Guild(info.ID); err == nil && g.OwnerID == m.User.ID {
+		return
+	}
+	if highestRolePosition(info, m.User.ID) >= highestRolePosition(info, sb.SelfID) {
+		return
+	}
+	err := CallAPI("GuildMemberNickname", func() error { return sb.dg.GuildMemberNickname(info.ID, m.User.ID, name) })
+	if err != nil {
+		if ClassifyAPIError(err) == APIErrorPermission {
+			if RateLimit(&w.lastwarn, 3600) {
+				info.LogTo(LogModeration, "I need the Manage Nicknames permission to normalize nicknames.")
+			}
+		} else {
+			info.LogError("Failed to normalize a nickname: ", err)
+		}
+		return
+	}
+	info.LogTo(LogModeration, "Renamed ", m.User.Username, " (", m.User.ID, ") from ", display, " to ", name, " (", strings.Join(rules, ", "), ").")
+}
+
+// OnGuildMemberAdd discord hook
+func (w *NicknameModule) OnGuildMemberAdd(info *GuildInfo, m *discordgo.Member) {
+	w.check(info, m)
+}
+
+// OnGuildMemberUpdate discord hook
+func (w *NicknameModule) OnGuildMemberUpdate(info *GuildInfo, m *discordgo.GuildMemberUpdate) {
+	w.check(info, m.Member)
+}
@@ -0,0 +1,255 @@
+package sweetiebot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PhishingList is the bot-wide list of known phishing domains, set by the bot owner in the phishinglist file
+type PhishingList struct {
+	URL      string `json:"url"`      // a text file with one domain per line, or a JSON array of domains. If empty, defaultPhishingURL is used
+	Disabled bool   `json:"disabled"` // if true, the list is never downloaded, so only each server's phishing.block list is used
+}
+
+const defaultPhishingURL = "https://raw.githubusercontent.com/Discord-AntiScam/scam-links/main/list.txt"
+
+// The downloaded list is refreshed this often, but only once a server with phishing.enabled has needed it
+const phishingRefresh = "@every 6h"
+
+// Lists bigger than this are almost certainly not a domain list
+const phishingMaxSize = 16 * 1024 * 1024
+
+type phishingDomains struct {
+	lock     sync.RWMutex
+	domains  map[string]bool
+	updated  time.Time
+	fetching AtomicFlag
+	used     AtomicBool // set once a server has checked a message against the list
+	err      error      // the error from the last refresh, if it failed
+}
+
+var phishing phishingDomains
+
+// PhishingModule deletes messages that link to known phishing domains, such as fake discord nitro or steam giveaways,
+// and silences whoever sent them.
+type PhishingModule struct {
+}
+
+// Name of the module
+func (w *PhishingModule) Name() string {
+	return "Phishing"
+}
+
+// Commands in the module
+func (w *PhishingModule) Commands() []Command {
+	return []Command{
+		&refreshPhishingCommand{},
+	}
+}
+
+// Description of the module
+func (w *PhishingModule) Description() string {
+	return "If `phishing.enabled` is true, deletes messages linking to known phishing domains and silences the sender. The domains come from a public list that is downloaded every few hours, plus anything in `phishing.block`."
+}
+
+// Undoes the usual tricks for hiding a link from filters, like "discord[.]gift" or zero width spaces
+var phishingObfuscations = strings.NewReplacer(
+	"[.]", ".", "(.)", ".", "{.}", ".", "[dot]", ".", "(dot)", ".", "{dot}", ".", " dot ", ".",
+	"。", ".", "．", ".", "｡", ".", "\\", "/", "hxxp", "http",
+	"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "", "\u00ad", "",
+)
+
+var phishinghostregex = regexp.MustCompile(`(?:[\p{L}\p{N}](?:[\p{L}\p{N}-]*[\p{L}\p{N}])?\.)+\p{L}[\p{L}\p{N}-]*`)
+
+// Returns every domain mentioned in a message, after removing obfuscations
+func phishingHosts(text string) []string {
+	text = phishingObfuscations.Replace(strings.ToLower(text))
+	if unescaped, err := url.PathUnescape(text); err == nil {
+		text = strings.ToLower(unescaped)
+	}
+	return phishinghostregex.FindAllString(text, -1)
+}
+
+// Returns the entry in list matching host or one of its parent domains, so sub.example.com matches example.com
+func phishingListed(host string, list func(string) bool) string {
+	for {
+		if list(host) {
+			return host
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 || strings.IndexByte(host[i+1:], '.') < 0 {
+			return "" // Never match a bare top level domain
+		}
+		host = host[i+1:]
+	}
+}
+
+func configListContains(list map[string]bool) func(string) bool {
+	return func(host string) bool {
+		for k := range list {
+			if strings.EqualFold(strings.TrimPrefix(k, "www."), host) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Returns the phishing domain a message links to, or an empty string if it doesn't link to one
+func matchPhishing(info *GuildInfo, text string) string {
+	block := configListContains(info.config.Phishing.Block)
+	allow := configListContains(info.config.Phishing.Allow)
+	phishing.lock.RLock()
+	defer phishing.lock.RUnlock()
+	for _, host := range phishingHosts(text) {
+		if len(phishingListed(host, allow)) > 0 {
+			continue
+		}
+		if d := phishingListed(host, block); len(d) > 0 {
+			return d
+		}
+		if d := phishingListed(host, func(h string) bool { return phishing.domains[h] }); len(d) > 0 {
+			return d
+		}
+	}
+	return ""
+}
+
+// Parses a domain list, which is either a JSON array of domains or a text file with one domain per line
+func parsePhishingList(data []byte) (map[string]bool, error) {
+	domains := make(map[string]bool)
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var list []string
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		for _, d := range list {
+			domains[strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "www.")] = true
+		}
+		return domains, nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		d := strings.TrimSpace(scanner.Text())
+		if len(d) == 0 || d[0] == '#' || d[0] == '!' {
+			continue
+		}
+		if f := strings.Fields(d); len(f) > 1 {
+			d = f[len(f)-1] // hosts file format, like "0.0.0.0 example.com"
+		}
+		domains[strings.TrimPrefix(strings.ToLower(d), "www.")] = true
+	}
+	return domains, scanner.Err()
+}
+
+// Downloads the phishing domain list, returning how many domains it has
+func refreshPhishingList() (int, error) {
+	if sb.PhishingList.Disabled {
+		return 0, fmt.Errorf("the bot owner has turned off the phishing domain list")
+	}
+	if phishing.fetching.test_and_set() {
+		return 0, fmt.Errorf("the phishing domain list is already being downloaded")
+	}
+	defer phishing.fetching.clear()
+	source := sb.PhishingList.URL
+	if len(source) == 0 {
+		source = defaultPhishingURL
+	}
+	data, _, err := downloadUpload(source, phishingMaxSize)
+	var domains map[string]bool
+	if err == nil {
+		domains, err = parsePhishingList(data)
+	}
+	if err == nil && len(domains) == 0 {
+		err = fmt.Errorf("the list was empty")
+	}
+	phishing.lock.Lock()
+	defer phishing.lock.Unlock()
+	phishing.err = err
+	if err != nil {
+		fmt.Printf("[%s] Failed to download the phishing domain list: %s\n", time.Now().Format(time.Stamp), err.Error())
+		return len(phishing.domains), err // Keep using the old list
+	}
+	phishing.domains = domains
+	phishing.updated = time.Now().UTC()
+	return len(domains), nil
+}
+
+// Runs on a schedule, but only downloads the list if a server is actually using it
+func refreshPhishingJob() {
+	if phishing.used.get() {
+		refreshPhishingList()
+	}
+}
+
+func (w *PhishingModule) check(info *GuildInfo, m *discordgo.Message) {
+	if !info.config.Phishing.Enabled || m.Author == nil || m.Author.Bot || info.HasModRole(m.Author.ID) {
+		return
+	}
+	if !sb.PhishingList.Disabled && !phishing.used.get() {
+		phishing.used.set(true)
+		go refreshPhishingList() // The first server to need the list downloads it
+	}
+	text := m.Content
+	for _, e := range m.Embeds {
+		text += " " + e.URL
+	}
+	domain := matchPhishing(info, text)
+	if len(domain) == 0 {
+		return
+	}
+	deleteMessage(m.ChannelID, m.ID)
+	info.LogTo(LogModeration, "Deleted a phishing link from ", getUserName(SBatoi(m.Author.ID), info), " in #", getChannelName(m.ChannelID), " (matched ", domain, ")")
+	if sb.db.CheckStatus() {
+		info.AddOffense(Offense{Type: OFFENSE_SPAM, User: SBatoi(m.Author.ID), Moderator: SBatoi(sb.SelfID), Reason: "Posted a phishing link (" + domain + ")", Timestamp: time.Now().UTC()})
+	}
+	if info.config.Phishing.Silence && info.config.Spam.SilentRole != 0 && SilenceMemberSimple(m.Author.ID, info) == 0 {
+		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+m.Author.ID+"> was silenced for posting a phishing link to "+domain+" in <#"+m.ChannelID+">. Their account may have been compromised.")
+	}
+}
+
+// OnMessageCreate discord hook
+func (w *PhishingModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	w.check(info, m)
+}
+
+// OnMessageUpdate discord hook
+func (w *PhishingModule) OnMessageUpdate(info *GuildInfo, m *discordgo.Message) {
+	w.check(info, m)
+}
+
+var lastPhishingRefresh int64
+
+type refreshPhishingCommand struct {
+}
+
+func (c *refreshPhishingCommand) Name() string {
+	return "RefreshPhishing"
+}
+func (c *refreshPhishingCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !RateLimit(&lastPhishingRefresh, 300) {
+		phishing.lock.RLock()
+		defer phishing.lock.RUnlock()
+		return fmt.Sprintf("```The phishing domain list was refreshed less than 5 minutes ago, and has %v domains.```", len(phishing.domains)), false, nil
+	}
+	phishing.used.set(true)
+	n, err := refreshPhishingList()
+	if err != nil {
+		return "```Couldn't refresh the phishing domain list: " + err.Error() + "```", false, nil
+	}
+	return fmt.Sprintf("```Downloaded %v phishing domains.```", n), false, nil
+}
+func (c *refreshPhishingCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{Desc: "Downloads the phishing domain list right away, instead of waiting for the next scheduled refresh. The list is shared by every server, so this can only be done once every 5 minutes."}
+}
+func (c *refreshPhishingCommand) UsageShort() string { return "Refreshes the phishing domain list." }
@@ -0,0 +1,163 @@
+package sweetiebot
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PinboardModule lets members with a permitted role pin messages by reacting to them, either with discord's native pins or by
+// mirroring the message to a pinboard channel.
+type PinboardModule struct {
+	lock   sync.Mutex
+	warned map[string]int64 // last time each channel was warned about being out of pins
+}
+
+// Name of the module
+func (w *PinboardModule) Name() string {
+	return "Pinboard"
+}
+
+// Commands in the module
+func (w *PinboardModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *PinboardModule) Description() string {
+	return "When someone with one of the roles in `pinboard.roles` (or a moderator) reacts to a message with the `pinboard.emoji` reaction, Sweetie Bot pins it, and unpins it again once all of those reactions are removed. If `pinboard.channel` is set, the message is posted to that channel instead of being pinned, which avoids discord's limit of 50 pins per channel."
+}
+
+func (w *PinboardModule) isPinEmoji(info *GuildInfo, e discordgo.Emoji) bool {
+	pin := info.config.Pinboard.Emoji
+	if len(pin) == 0 {
+		return false
+	}
+	if id := customemojiregex.FindStringSubmatch(pin); id != nil {
+		return e.ID == id[2]
+	}
+	return len(e.ID) == 0 && strings.TrimSuffix(e.Name, "\uFE0F") == strings.TrimSuffix(pin, "\uFE0F")
+}
+
+func (w *PinboardModule) canPin(info *GuildInfo, user string) bool {
+	if info.HasModRole(user) {
+		return true
+	}
+	return len(info.config.Pinboard.Roles) > 0 && info.UserHasAnyRole(user, info.config.Pinboard.Roles)
+}
+
+func messageLink(info *GuildInfo, channel string, message string) string {
+	return "https://discord.com/channels/" + info.ID + "/" + channel + "/" + message
+}
+
+// OnMessageReactionAdd discord hook
+func (w *PinboardModule) OnMessageReactionAdd(info *GuildInfo, r *discordgo.MessageReaction) {
+	if !w.isPinEmoji(info, r.Emoji) || SBatoi(r.ChannelID) == info.config.Pinboard.Channel || !w.canPin(info, r.UserID) {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	msg, err := sb.dg.ChannelMessage(r.ChannelID, r.MessageID)
+	if err != nil {
+		return
+	}
+	user := getUserName(SBatoi(r.UserID), info)
+	link := messageLink(info, r.ChannelID, r.MessageID)
+
+	if info.config.Pinboard.Channel != 0 {
+		if !sb.db.CheckStatus() || sb.db.GetPinboardMirror(SBatoi(msg.ID), SBatoi(info.ID)) != 0 {
+			return
+		}
+		mirror, err := sb.dg.ChannelMessageSendEmbed(SBitoa(info.config.Pinboard.Channel), pinboardEmbed(info, msg, user, link))
+		if err != nil {
+			info.Log("Failed to post pinned message to the pinboard channel: ", err.Error())
+			return
+		}
+		sb.db.AddPinboardMirror(SBatoi(msg.ID), SBatoi(info.ID), SBatoi(mirror.ID))
+		info.Log(user, " pinned a message in #", getChannelName(r.ChannelID), " to the pinboard: ", link)
+		return
+	}
+
+	if msg.Pinned {
+		return
+	}
+	err = sb.dg.ChannelMessagePin(r.ChannelID, r.MessageID)
+	var rest *discordgo.RESTError
+	if errors.As(err, &rest) && rest.Message != nil && rest.Message.Code == discordgo.ErrCodeMaximumPinsReached {
+		if w.warned == nil {
+			w.warned = make(map[string]int64)
+		}
+		if now := time.Now().UTC().Unix(); now-w.warned[r.ChannelID] > 300 {
+			w.warned[r.ChannelID] = now
+			info.SendMessage(r.ChannelID, "This channel has reached discord's limit of 50 pins, so I can't pin any more messages here. Unpin some old messages, or ask a moderator to set `pinboard.channel` to use a pinboard channel instead.")
+		}
+		info.Log(user, " tried to pin a message in #", getChannelName(r.ChannelID), ", but the channel is out of pins.")
+	} else if err != nil {
+		info.Log("Failed to pin message in #", getChannelName(r.ChannelID), ": ", err.Error())
+	} else {
+		info.Log(user, " pinned a message in #", getChannelName(r.ChannelID), ": ", link)
+	}
+}
+
+// OnMessageReactionRemove discord hook
+func (w *PinboardModule) OnMessageReactionRemove(info *GuildInfo, r *discordgo.MessageReaction) {
+	if !w.isPinEmoji(info, r.Emoji) || SBatoi(r.ChannelID) == info.config.Pinboard.Channel || !w.canPin(info, r.UserID) {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	users, err := sb.dg.MessageReactions(r.ChannelID, r.MessageID, r.Emoji.APIName(), 100, "", "")
+	if err != nil {
+		return
+	}
+	for _, u := range users {
+		if w.canPin(info, u.ID) {
+			return // Someone else still wants this pinned
+		}
+	}
+	user := getUserName(SBatoi(r.UserID), info)
+	link := messageLink(info, r.ChannelID, r.MessageID)
+
+	if info.config.Pinboard.Channel != 0 {
+		if !sb.db.CheckStatus() {
+			return
+		}
+		mirror := sb.db.GetPinboardMirror(SBatoi(r.MessageID), SBatoi(info.ID))
+		if mirror == 0 {
+			return
+		}
+		sb.dg.ChannelMessageDelete(SBitoa(info.config.Pinboard.Channel), SBitoa(mirror))
+		sb.db.RemovePinboardMirror(SBatoi(r.MessageID), SBatoi(info.ID))
+		info.Log(user, " removed a message in #", getChannelName(r.ChannelID), " from the pinboard: ", link)
+		return
+	}
+
+	if err := sb.dg.ChannelMessageUnpin(r.ChannelID, r.MessageID); err != nil {
+		info.Log("Failed to unpin message in #", getChannelName(r.ChannelID), ": ", err.Error())
+	} else {
+		info.Log(user, " unpinned a message in #", getChannelName(r.ChannelID), ": ", link)
+	}
+}
+
+func pinboardEmbed(info *GuildInfo, msg *discordgo.Message, user string, link string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Type: "rich",
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    getUserName(SBatoi(msg.Author.ID), info),
+			IconURL: msg.Author.AvatarURL(""),
+		},
+		Description: msg.Content,
+		Color:       0xFFCC4D,
+		Fields:      []*discordgo.MessageEmbedField{{Name: "Source", Value: "[Jump to message in #" + getChannelName(msg.ChannelID) + "](" + link + ")"}},
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Pinned by " + user},
+		Timestamp:   msg.Timestamp.Format(time.RFC3339),
+	}
+	for _, a := range msg.Attachments {
+		if a.Width > 0 {
+			embed.Image = &discordgo.MessageEmbedImage{URL: a.URL}
+			break
+		}
+	}
+	return embed
+}
@@ -1,6 +1,7 @@
 package sweetiebot
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
 	"strings"
@@ -139,10 +140,16 @@ func (c *deletePollCommand) Process(args []string, msg *discordgo.Message, indic
 	}
 	arg := msg.Content[indices[0]:]
 	gID := SBatoi(info.ID)
-	id, _ := sb.db.GetPoll(arg, gID)
+	id, desc := sb.db.GetPoll(arg, gID)
 	if id == 0 {
 		return "```That poll doesn't exist!```", false, nil
 	}
+	if info.config.Polls.Chart {
+		options, counts := getPollResults(id)
+		if len(options) > 0 {
+			sendPollChart(msg.ChannelID, desc, options, counts, "**Final results:** "+pollResultsText(desc, options, counts))
+		}
+	}
 	err := sb.db.RemovePoll(arg, gID)
 	if err != nil {
 		return "```Error removing poll.```", false, nil
@@ -151,7 +158,7 @@ func (c *deletePollCommand) Process(args []string, msg *discordgo.Message, indic
 }
 func (c *deletePollCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Removes the poll with the given poll name.",
+		Desc: "Removes the poll with the given poll name. If `polls.chart` is on, the final results are posted as a chart first.",
 		Params: []CommandUsageParam{
 			{Name: "poll", Desc: "Name of the poll to delete.", Optional: false},
 		},
@@ -232,25 +239,56 @@ func (c *resultsCommand) Process(args []string, msg *discordgo.Message, indices
 	if id == 0 {
 		return "```That poll doesn't exist! Use \"" + info.config.Basic.CommandPrefix + "poll\" to list active polls.```", false, nil
 	}
+	options, counts := getPollResults(id)
+	text := pollResultsText(desc, options, counts)
+	if info.config.Polls.Chart {
+		if err := sendPollChart(msg.ChannelID, desc, options, counts, text); err == nil {
+			return "", false, nil
+		}
+	}
+	return text, len(options) > 10, nil
+}
+func (c *resultsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Displays the results of the given poll, if it exists. If `polls.chart` is on, the results are also drawn as a bar chart.",
+		Params: []CommandUsageParam{
+			{Name: "poll", Desc: "Name of the poll to view.", Optional: false},
+		},
+	}
+}
+func (c *resultsCommand) UsageShort() string { return "Displays results of a poll." }
+
+// Returns every option of a poll along with how many votes each one got
+func getPollResults(id uint64) ([]PollOptionStruct, []uint64) {
 	results := sb.db.GetResults(id)
 	options := sb.db.GetOptions(id)
+	counts := make([]uint64, len(options))
+	k := 0
+	for i, v := range options {
+		for k < len(results) && results[k].index < v.index {
+			k++
+		}
+		if k < len(results) && v.index == results[k].index {
+			counts[i] = results[k].count
+		}
+	}
+	return options, counts
+}
+
+// Writes out poll results with a small text graph, which is also sent alongside charts so the results can be read by
+// anyone who can't see images
+func pollResultsText(desc string, options []PollOptionStruct, counts []uint64) string {
 	max := uint64(0)
-	for _, v := range results {
-		if v.count > max {
-			max = v.count
+	for _, v := range counts {
+		if v > max {
+			max = v
 		}
 	}
 
-	str := make([]string, 0, len(results)+2)
+	str := make([]string, 0, len(options)+2)
 	str = append(str, desc)
-	k := 0
-	var count uint64
-	for _, v := range options {
-		count = 0
-		if k < len(results) && v.index == results[k].index {
-			count = results[k].count
-			k++
-		}
+	for i, v := range options {
+		count := counts[i]
 		normalized := count
 		if max > 10 {
 			normalized = uint64(float32(count) * (10.0 / float32(max)))
@@ -273,18 +311,23 @@ func (c *resultsCommand) Process(args []string, msg *discordgo.Message, indices
 		}
 		str = append(str, fmt.Sprintf("`%s%v. `%s %s (%v votes)", buf, v.index, graph, v.option, count))
 	}
-
-	return strings.Join(str, "\n"), len(str) > 11, nil
+	return strings.Join(str, "\n")
 }
-func (c *resultsCommand) Usage(info *GuildInfo) *CommandUsage {
-	return &CommandUsage{
-		Desc: "Displays the results of the given poll, if it exists.",
-		Params: []CommandUsageParam{
-			{Name: "poll", Desc: "Name of the poll to view.", Optional: false},
-		},
-	}
+
+// Sends a poll's results as a chart, with the text results as the message
+func sendPollChart(channel string, desc string, options []PollOptionStruct, counts []uint64, text string) error {
+	data, err := renderPollChart(desc, options, counts)
+	if err != nil {
+		return err
+	}
+	text = truncateRunes(text, 2000)
+	_, err = sb.dg.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+		Content:         text,
+		Files:           []*discordgo.File{{Name: "poll.png", ContentType: "image/png", Reader: bytes.NewReader(data)}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	return err
 }
-func (c *resultsCommand) UsageShort() string { return "Displays results of a poll." }
 
 type addOptionCommand struct {
 }
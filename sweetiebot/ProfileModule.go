@@ -0,0 +1,142 @@
+package sweetiebot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type profileChange struct {
+	user      *discordgo.User
+	oldNick   string
+	newNick   string
+	oldName   string
+	newName   string
+	oldAvatar string
+	avatarURL string
+	nick      bool
+	name      bool
+	avatar    bool
+	last      int64
+}
+
+// ProfileModule logs nickname, username and avatar changes to the mod channel. Discord only sends USER_UPDATE events
+// for the bot itself, so changes to other users are detected by diffing the member state in GUILD_MEMBER_UPDATE.
+type ProfileModule struct {
+	pending map[uint64]*profileChange
+	lock    sync.Mutex
+}
+
+// Name of the module
+func (w *ProfileModule) Name() string {
+	return "Profile"
+}
+
+// Commands in the module
+func (w *ProfileModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *ProfileModule) Description() string {
+	return "Logs nickname, username and avatar changes to the mod channel, so moderators can keep track of users trying to evade them by changing their name. Each type of change can be toggled with `profile.nicknames`, `profile.usernames` and `profile.avatars`. Rapid changes are collapsed into a single message."
+}
+
+func userIdentity(u *discordgo.User) string {
+	if len(u.Discriminator) > 0 && u.Discriminator != "0" {
+		return u.Username + "#" + u.Discriminator
+	}
+	return u.Username
+}
+
+// OnGuildMemberUpdate discord hook
+func (w *ProfileModule) OnGuildMemberUpdate(info *GuildInfo, m *discordgo.GuildMemberUpdate) {
+	if m.BeforeUpdate == nil || m.User == nil || m.BeforeUpdate.User == nil || m.User.Bot {
+		return
+	}
+	before := m.BeforeUpdate
+	nick := info.config.Profile.Nicknames && before.Nick != m.Nick
+	name := info.config.Profile.Usernames && userIdentity(before.User) != userIdentity(m.User)
+	avatar := info.config.Profile.Avatars && (before.Avatar != m.Avatar || before.User.Avatar != m.User.Avatar)
+	if !nick && !name && !avatar {
+		return
+	}
+
+	w.lock.Lock()
+	id := SBatoi(m.User.ID)
+	c, ok := w.pending[id]
+	if !ok {
+		c = &profileChange{oldNick: before.Nick, oldName: userIdentity(before.User), oldAvatar: before.AvatarURL("")}
+		w.pending[id] = c
+	}
+	c.user = m.User
+	c.last = time.Now().UTC().Unix()
+	if nick {
+		c.nick = true
+		c.newNick = m.Nick
+	}
+	if name {
+		c.name = true
+		c.newName = userIdentity(m.User)
+	}
+	if avatar {
+		c.avatar = true
+		c.avatarURL = m.AvatarURL("256")
+	}
+	w.lock.Unlock()
+
+	if info.config.Profile.Debounce <= 0 {
+		w.flush(info, 0)
+	}
+}
+
+// OnTick discord hook
+func (w *ProfileModule) OnTick(info *GuildInfo) {
+	w.flush(info, info.config.Profile.Debounce)
+}
+
+// Posts every pending change that hasn't been updated in the last debounce seconds
+func (w *ProfileModule) flush(info *GuildInfo, debounce int64) {
+	now := time.Now().UTC().Unix()
+	changes := []*profileChange{}
+	w.lock.Lock()
+	for k, v := range w.pending {
+		if now-v.last >= debounce {
+			changes = append(changes, v)
+			delete(w.pending, k)
+		}
+	}
+	w.lock.Unlock()
+
+	for _, c := range changes {
+		lines := []string{}
+		if c.name && c.oldName != c.newName {
+			lines = append(lines, "**Username:** "+PartialSanitize(c.oldName)+" → "+PartialSanitize(c.newName))
+		}
+		if c.nick && c.oldNick != c.newNick {
+			oldnick, newnick := c.oldNick, c.newNick
+			if len(oldnick) == 0 {
+				oldnick = "*(none)*"
+			}
+			if len(newnick) == 0 {
+				newnick = "*(none)*"
+			}
+			lines = append(lines, "**Nickname:** "+PartialSanitize(oldnick)+" → "+PartialSanitize(newnick))
+		}
+		embed := &discordgo.MessageEmbed{
+			Color:  0x3e92e5,
+			Author: &discordgo.MessageEmbedAuthor{Name: userIdentity(c.user), IconURL: c.user.AvatarURL("")},
+			Footer: &discordgo.MessageEmbedFooter{Text: "User ID: " + c.user.ID},
+		}
+		if c.avatar && c.oldAvatar != c.avatarURL {
+			lines = append(lines, "**Avatar changed**")
+			embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: c.oldAvatar}
+			embed.Image = &discordgo.MessageEmbedImage{URL: c.avatarURL}
+		}
+		if len(lines) == 0 {
+			continue // The user changed their profile back before the debounce period expired
+		}
+		embed.Description = "<@" + c.user.ID + "> updated their profile.\n" + strings.Join(lines, "\n")
+		info.SendEmbed(SBitoa(info.config.Basic.ModChannel), embed)
+	}
+}
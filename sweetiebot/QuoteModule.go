@@ -118,6 +118,9 @@ func (c *addquoteCommand) Process(args []string, msg *discordgo.Message, indices
 		return "```Could be any of the following users or their aliases:\n" + strings.Join(IDsToUsernames(IDs, info, true), "\n") + "```", len(IDs) > 5, nil
 	}
 
+	if limit := info.quoteLimit(); overLimit(countQuotes(info), 1, limit) {
+		return limitReachedMessage(limit, "this server can only have %v quotes", "!removequote"), false, nil
+	}
 	if len(info.config.Quote.Quotes) == 0 {
 		info.config.Quote.Quotes = make(map[uint64][]string)
 	}
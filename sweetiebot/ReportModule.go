@@ -0,0 +1,114 @@
+package sweetiebot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ReportModule handles the "Report Message" context menu command, which forwards a message to the mod channel
+type ReportModule struct {
+	lock sync.Mutex
+	last map[string]int64 // last time each user sent a report
+}
+
+const reportMessageCommand = "Report Message"
+
+// Members can only send one report every this many seconds
+const reportCooldown = 60
+
+// Name of the module
+func (w *ReportModule) Name() string {
+	return "Report"
+}
+
+// Commands in the module
+func (w *ReportModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *ReportModule) Description() string {
+	return "Adds a \"Report Message\" option to the Apps menu you get by right clicking (or long pressing) a message, which sends the message to the mod channel for review. Each member can send one report a minute."
+}
+
+// Returns false if the user already sent a report recently
+func (w *ReportModule) canReport(user string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.last == nil {
+		w.last = make(map[string]int64)
+	}
+	now := time.Now().UTC().Unix()
+	for k, v := range w.last {
+		if now-v > reportCooldown {
+			delete(w.last, k)
+		}
+	}
+	if _, ok := w.last[user]; ok {
+		return false
+	}
+	w.last[user] = now
+	return true
+}
+
+// OnInteractionCreate discord hook
+func (w *ReportModule) OnInteractionCreate(info *GuildInfo, i *discordgo.Interaction) bool {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return false
+	}
+	data := i.ApplicationCommandData()
+	if data.Name != reportMessageCommand || i.Member == nil {
+		return false
+	}
+	if info.config.Basic.ModChannel == 0 {
+		respondEphemeral(i, "This server doesn't have a mod channel, so there's nowhere to send reports. Please contact a moderator directly.")
+		return true
+	}
+	var msg *discordgo.Message
+	if data.Resolved != nil {
+		msg = data.Resolved.Messages[data.TargetID]
+	}
+	if msg == nil || msg.Author == nil {
+		respondEphemeral(i, "Discord didn't tell me which message you wanted to report. Please try again.")
+		return true
+	}
+	if msg.Author.ID == sb.SelfID || msg.Author.ID == i.Member.User.ID {
+		respondEphemeral(i, "You can't report that message.")
+		return true
+	}
+	if !w.canReport(i.Member.User.ID) {
+		respondEphemeral(i, "You can only send one report a minute. If something urgent is happening, ping a moderator.")
+		return true
+	}
+
+	content := msg.Content
+	if len(content) > 3000 {
+		content = content[:3000] + " [truncated]"
+	}
+	for _, a := range msg.Attachments {
+		content += "\n[attachment: " + a.Filename + "](" + a.URL + ")"
+	}
+	embed := &discordgo.MessageEmbed{
+		Type: "rich",
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    msg.Author.Username + " (" + msg.Author.ID + ")",
+			IconURL: msg.Author.AvatarURL(""),
+		},
+		Title:       "Reported message",
+		URL:         messageLink(info, i.ChannelID, msg.ID),
+		Description: content,
+		Color:       0xE74C3C,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Channel", Value: "<#" + i.ChannelID + ">", Inline: true},
+			{Name: "Reported by", Value: "<@" + i.Member.User.ID + ">", Inline: true},
+		},
+		Timestamp: msg.Timestamp.Format(time.RFC3339),
+	}
+	if _, err := sb.dg.ChannelMessageSendEmbed(SBitoa(info.config.Basic.ModChannel), embed); err != nil {
+		info.Log("Failed to send a message report to the mod channel: ", err.Error())
+		respondEphemeral(i, "Your report couldn't be delivered. Please contact a moderator directly.")
+		return true
+	}
+	respondEphemeral(i, "Thanks, the moderators have been sent your report.")
+	return true
+}
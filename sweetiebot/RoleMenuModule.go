@@ -0,0 +1,497 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// RoleMenuOption is a single role members can pick from a role menu
+type RoleMenuOption struct {
+	Role        uint64 `json:"role"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	Emoji       string `json:"emoji"`
+}
+
+// RoleMenu is a set of self-assignable roles posted as a message with buttons. Members click a button to get a private
+// dropdown of the roles on that page, with the roles they already have ticked.
+type RoleMenu struct {
+	Exclusive bool              `json:"exclusive"` // members can only have one role from an exclusive menu
+	Roles     []RoleMenuOption  `json:"roles"`
+	Posts     map[string]string `json:"posts"` // channel of every message the menu was posted as, by message ID
+}
+
+// Discord only allows 25 options in a dropdown, and 5 buttons in a row
+const roleMenuPageSize = 25
+const roleMenuMaxPages = 5
+
+// Roles that give these permissions can't be handed out by a role menu, so a typo can't give everyone moderator powers
+const roleMenuDangerousPerms = discordgo.PermissionAdministrator | discordgo.PermissionManageRoles | discordgo.PermissionManageServer |
+	discordgo.PermissionManageChannels | discordgo.PermissionManageMessages | discordgo.PermissionBanMembers |
+	discordgo.PermissionKickMembers | discordgo.PermissionModerateMembers | discordgo.PermissionManageWebhooks
+
+// RoleMenuModule handles the buttons and dropdowns of posted role menus. Everything a component needs is in its custom
+// ID and the server config, so menus posted before a restart keep working without re-registering anything.
+type RoleMenuModule struct {
+}
+
+// Name of the module
+func (w *RoleMenuModule) Name() string {
+	return "RoleMenu"
+}
+
+// Commands in the module
+func (w *RoleMenuModule) Commands() []Command {
+	return []Command{
+		&roleMenuCommand{},
+	}
+}
+
+// Description of the module
+func (w *RoleMenuModule) Description() string {
+	return "Lets members pick their own roles from menus posted with `!rolemenu`. Each menu is a message with buttons that open a private dropdown of roles."
+}
+
+func (m *RoleMenu) pages() int {
+	return (len(m.Roles) + roleMenuPageSize - 1) / roleMenuPageSize
+}
+
+func (m *RoleMenu) page(p int) []RoleMenuOption {
+	if p < 0 || p*roleMenuPageSize >= len(m.Roles) {
+		return nil
+	}
+	end := (p + 1) * roleMenuPageSize
+	if end > len(m.Roles) {
+		end = len(m.Roles)
+	}
+	return m.Roles[p*roleMenuPageSize : end]
+}
+
+func (m *RoleMenu) find(role uint64) int {
+	for i, v := range m.Roles {
+		if v.Role == role {
+			return i
+		}
+	}
+	return -1
+}
+
+func roleMenuEmoji(emoji string) *discordgo.ComponentEmoji {
+	if len(emoji) == 0 {
+		return nil
+	}
+	if m := customemojiregex.FindStringSubmatch(emoji); m != nil {
+		return &discordgo.ComponentEmoji{Name: m[1], ID: m[2], Animated: strings.HasPrefix(emoji, "<a:")}
+	}
+	return &discordgo.ComponentEmoji{Name: emoji}
+}
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// Builds the public message for a role menu, which lists its roles and has a button for each page of them
+func roleMenuMessage(name string, menu *RoleMenu) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	lines := make([]string, 0, len(menu.Roles))
+	length := 0
+	for i, v := range menu.Roles {
+		line := "**" + v.Label + "**"
+		if len(v.Emoji) > 0 {
+			line = v.Emoji + " " + line
+		}
+		if len(v.Description) > 0 {
+			line += ": " + v.Description
+		}
+		if length+len(line) > 3800 {
+			lines = append(lines, fmt.Sprintf("...and %v more", len(menu.Roles)-i))
+			break
+		}
+		length += len(line) + 1
+		lines = append(lines, line)
+	}
+	footer := "Pick as many roles as you like."
+	if menu.Exclusive {
+		footer = "You can only have one of these roles."
+	}
+	embed := &discordgo.MessageEmbed{
+		Type:        "rich",
+		Title:       name,
+		Description: strings.Join(lines, "\n"),
+		Color:       0x3498DB,
+		Footer:      &discordgo.MessageEmbedFooter{Text: footer},
+	}
+	buttons := make([]discordgo.MessageComponent, 0, menu.pages())
+	for p := 0; p < menu.pages(); p++ {
+		label := "Pick roles"
+		if menu.pages() > 1 {
+			label = fmt.Sprintf("Roles %v-%v", p*roleMenuPageSize+1, p*roleMenuPageSize+len(menu.page(p)))
+		}
+		buttons = append(buttons, discordgo.Button{Label: label, Style: discordgo.PrimaryButton, CustomID: fmt.Sprintf("rolemenu:open:%s:%v", name, p)})
+	}
+	if len(buttons) == 0 {
+		return embed, nil
+	}
+	return embed, []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// Builds the private dropdown for one page of a role menu, with the roles the member already has ticked
+func roleMenuPicker(name string, menu *RoleMenu, p int, member *discordgo.Member) []discordgo.MessageComponent {
+	has := make(map[string]bool)
+	for _, r := range member.Roles {
+		has[r] = true
+	}
+	page := menu.page(p)
+	options := make([]discordgo.SelectMenuOption, 0, len(page))
+	for _, v := range page {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       truncateRunes(v.Label, 100),
+			Value:       SBitoa(v.Role),
+			Description: truncateRunes(v.Description, 100),
+			Emoji:       roleMenuEmoji(v.Emoji),
+			Default:     has[SBitoa(v.Role)],
+		})
+	}
+	min := 0
+	max := len(options)
+	if menu.Exclusive {
+		max = 1
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.SelectMenu{
+			MenuType:    discordgo.StringSelectMenu,
+			CustomID:    fmt.Sprintf("rolemenu:pick:%s:%v", name, p),
+			Placeholder: "Pick your roles",
+			MinValues:   &min,
+			MaxValues:   max,
+			Options:     options,
+		},
+	}}}
+}
+
+// Gives a member exactly the roles they picked from one page of a menu, removing the ones they unticked. Picking a role
+// from an exclusive menu removes every other role in that menu.
+func applyRoleMenuPick(info *GuildInfo, menu *RoleMenu, p int, member *discordgo.Member, values []string) string {
+	picked := make(map[string]bool)
+	for _, v := range values {
+		picked[v] = true
+	}
+	has := make(map[string]bool)
+	for _, r := range member.Roles {
+		has[r] = true
+	}
+	options := menu.page(p)
+	if menu.Exclusive && len(picked) > 0 {
+		options = menu.Roles
+	}
+	added := []string{}
+	removed := []string{}
+	failed := []string{}
+	for _, v := range options {
+		role := SBitoa(v.Role)
+		if picked[role] == has[role] {
+			continue
+		}
+		var err error
+		if picked[role] {
+			err = CallAPI("GuildMemberRoleAdd", func() error { return sb.dg.GuildMemberRoleAdd(info.ID, member.User.ID, role) })
+		} else {
+			err = CallAPI("GuildMemberRoleRemove", func() error { return sb.dg.GuildMemberRoleRemove(info.ID, member.User.ID, role) })
+		}
+		switch {
+		case err != nil:
+			failed = append(failed, v.Label)
+		case picked[role]:
+			added = append(added, v.Label)
+		default:
+			removed = append(removed, v.Label)
+		}
+	}
+	s := []string{}
+	if len(added) > 0 {
+		s = append(s, "Gave you "+strings.Join(added, ", ")+".")
+	}
+	if len(removed) > 0 {
+		s = append(s, "Removed "+strings.Join(removed, ", ")+".")
+	}
+	if len(failed) > 0 {
+		s = append(s, "Couldn't change "+strings.Join(failed, ", ")+". Ask a moderator to check that I can still assign them.")
+		info.Log("A role menu failed to change ", strings.Join(failed, ", "), " for ", member.User.Username, ". Make sure those roles are below Sweetie Bot's highest role.")
+	}
+	if len(s) == 0 {
+		return "Your roles are unchanged."
+	}
+	return strings.Join(s, " ")
+}
+
+// OnInteractionCreate discord hook
+func (w *RoleMenuModule) OnInteractionCreate(info *GuildInfo, i *discordgo.Interaction) bool {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return false
+	}
+	data := i.MessageComponentData()
+	parts := strings.Split(data.CustomID, ":")
+	if len(parts) != 4 || parts[0] != "rolemenu" || i.Member == nil {
+		return false
+	}
+	menu, ok := info.config.RoleMenus.Menus[parts[2]]
+	p, err := strconv.Atoi(parts[3])
+	if !ok || err != nil || menu.page(p) == nil {
+		respondEphemeral(i, "This role menu doesn't exist anymore.")
+		return true
+	}
+	if isSilenced(i.Member, info) {
+		respondEphemeral(i, "You can't pick roles while you're silenced.")
+		return true
+	}
+	switch parts[1] {
+	case "open":
+		err = sb.dg.InteractionRespond(i, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:    "Pick the roles you want from " + parts[2] + ". Untick a role to remove it.",
+				Components: roleMenuPicker(parts[2], menu, p, i.Member),
+				Flags:      discordgo.MessageFlagsEphemeral,
+			},
+		})
+	case "pick":
+		// Changing roles can take longer than discord waits for a response, so acknowledge the pick first
+		err = sb.dg.InteractionRespond(i, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+		if err == nil {
+			result := applyRoleMenuPick(info, menu, p, i.Member, data.Values)
+			components := []discordgo.MessageComponent{}
+			_, err = sb.dg.InteractionResponseEdit(i, &discordgo.WebhookEdit{Content: &result, Components: &components})
+		}
+	default:
+		return false
+	}
+	if err != nil {
+		metricAPIErrors.Add(ClassifyAPIError(err).String(), 1)
+	}
+	return true
+}
+
+// Updates every posted copy of a menu after it changes, forgetting the ones that were deleted
+func refreshRoleMenu(info *GuildInfo, name string, menu *RoleMenu) {
+	embed, components := roleMenuMessage(name, menu)
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	for id, channel := range menu.Posts {
+		_, err := sb.dg.ChannelMessageEditComplex(&discordgo.MessageEdit{ID: id, Channel: channel, Embeds: &[]*discordgo.MessageEmbed{embed}, Components: &components})
+		if ClassifyAPIError(err) == APIErrorNotFound {
+			delete(menu.Posts, id)
+		}
+	}
+}
+
+// Returns true if name can be used for a role menu. Colons would break the custom IDs of its buttons.
+func validRoleMenuName(name string) bool {
+	return !strings.ContainsAny(name, ":") && len(name) <= 50
+}
+
+// Returns why user can't put role in a role menu, or an empty string if they can
+func roleMenuRoleError(info *GuildInfo, role *discordgo.Role, user string) string {
+	id := SBatoi(role.ID)
+	if id == info.config.Spam.SilentRole || id == info.config.Basic.AlertRole || role.Managed || role.ID == info.ID {
+		return role.Name + " can't be put in a role menu."
+	}
+	if role.Permissions&roleMenuDangerousPerms != 0 {
+		return role.Name + " has moderator permissions, so it can't be put in a role menu."
+	}
+	if highestRolePosition(info, sb.SelfID) <= role.Position {
+		return "I can't assign " + role.Name + " because it is above my highest role."
+	}
+	if user != info.OwnerID && highestRolePosition(info, user) <= role.Position {
+		return "You can't put " + role.Name + " in a role menu because it is above your highest role."
+	}
+	return ""
+}
+
+// Returns why emoji can't be shown in a role menu, or an empty string if it can
+func roleMenuEmojiError(info *GuildInfo, emoji string) string {
+	if m := customemojiregex.FindStringSubmatch(emoji); m != nil {
+		if _, err := sb.dg.State.Emoji(info.ID, m[2]); err != nil {
+			return m[1] + " is an emoji from another server. Only this server's emoji can be used."
+		}
+	}
+	return ""
+}
+
+// Returns why a menu that already has n roles can't have another, or an empty string if it can
+func roleMenuFullError(exclusive bool, n int) string {
+	if exclusive && n >= roleMenuPageSize {
+		return fmt.Sprintf("Exclusive role menus can only have %v roles.", roleMenuPageSize)
+	}
+	if n >= roleMenuPageSize*roleMenuMaxPages {
+		return fmt.Sprintf("Role menus can only have %v roles. Split them into more than one menu.", roleMenuPageSize*roleMenuMaxPages)
+	}
+	return ""
+}
+
+type roleMenuCommand struct {
+}
+
+func (c *roleMenuCommand) Name() string {
+	return "RoleMenu"
+}
+func (c *roleMenuCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	menus := info.config.RoleMenus.Menus
+	if len(args) < 1 || strings.ToLower(args[0]) == "list" {
+		if len(menus) == 0 {
+			return "```There are no role menus. Use " + info.config.Basic.CommandPrefix + "rolemenu add to make one.```", false, nil
+		}
+		lines := make([]string, 0, len(menus))
+		for k, v := range menus {
+			exclusive := ""
+			if v.Exclusive {
+				exclusive = ", exclusive"
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s%s, posted %s", k, Pluralize(int64(len(v.Roles)), " role"), exclusive, Pluralize(int64(len(v.Posts)), " time")))
+		}
+		sort.Strings(lines)
+		return "```" + strings.Join(lines, "\n") + "```", false, nil
+	}
+	action := strings.ToLower(args[0])
+	if len(args) < 2 {
+		return "```You have to say which role menu to change.```", false, nil
+	}
+	name := strings.ToLower(args[1])
+	if !validRoleMenuName(name) {
+		return "```Role menu names can't have colons in them, and can't be longer than 50 characters.```", false, nil
+	}
+	menu, ok := menus[name]
+	if !ok && action != "add" {
+		return "```There is no role menu named " + name + ".```", false, nil
+	}
+
+	switch action {
+	case "add":
+		if len(args) < 3 {
+			return "```You have to give the role to add, like `" + info.config.Basic.CommandPrefix + "rolemenu add games @Minecraft | Minecraft | For people who build things | ⛏️`.```", false, nil
+		}
+		fields := strings.Split(msg.Content[indices[2]:], "|")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		role, e := findRole(fields[0], info)
+		if role == nil {
+			return e, false, nil
+		}
+		if e := roleMenuRoleError(info, role, msg.Author.ID); len(e) > 0 {
+			return "```" + e + "```", false, nil
+		}
+		id := SBatoi(role.ID)
+		option := RoleMenuOption{Role: id, Label: role.Name}
+		if len(fields) > 1 && len(fields[1]) > 0 {
+			option.Label = fields[1]
+		}
+		if len(fields) > 2 {
+			option.Description = fields[2]
+		}
+		if len(fields) > 3 {
+			option.Emoji = fields[3]
+			if e := roleMenuEmojiError(info, option.Emoji); len(e) > 0 {
+				return "```" + e + "```", false, nil
+			}
+		}
+		if !ok {
+			if info.config.RoleMenus.Menus == nil {
+				info.config.RoleMenus.Menus = make(map[string]*RoleMenu)
+			}
+			menu = &RoleMenu{Posts: make(map[string]string)}
+			info.config.RoleMenus.Menus[name] = menu
+		}
+		if i := menu.find(id); i >= 0 {
+			menu.Roles[i] = option
+		} else if e := roleMenuFullError(menu.Exclusive, len(menu.Roles)); len(e) > 0 {
+			return "```" + e + "```", false, nil
+		} else {
+			menu.Roles = append(menu.Roles, option)
+		}
+		refreshRoleMenu(info, name, menu)
+		info.SaveConfig()
+		return "```Added " + role.Name + " to the " + name + " role menu.```", false, nil
+	case "remove":
+		if len(args) < 3 {
+			return "```You have to give the role to remove.```", false, nil
+		}
+		role, e := findRole(msg.Content[indices[2]:], info)
+		if role == nil {
+			return e, false, nil
+		}
+		i := menu.find(SBatoi(role.ID))
+		if i < 0 {
+			return "```" + role.Name + " isn't in the " + name + " role menu.```", false, nil
+		}
+		menu.Roles = append(menu.Roles[:i], menu.Roles[i+1:]...)
+		refreshRoleMenu(info, name, menu)
+		info.SaveConfig()
+		return "```Removed " + role.Name + " from the " + name + " role menu.```", false, nil
+	case "exclusive":
+		if len(args) < 3 {
+			return "```You have to say whether the menu should be exclusive (true or false).```", false, nil
+		}
+		exclusive, err := strconv.ParseBool(args[2])
+		if err != nil {
+			return "```" + args[2] + " isn't true or false.```", false, nil
+		}
+		if exclusive && len(menu.Roles) > roleMenuPageSize {
+			return fmt.Sprintf("```Exclusive role menus can only have %v roles.```", roleMenuPageSize), false, nil
+		}
+		menu.Exclusive = exclusive
+		refreshRoleMenu(info, name, menu)
+		info.SaveConfig()
+		if exclusive {
+			return "```Members can now only pick one role from " + name + ". Anyone who already has more than one keeps them until they next pick a role from it.```", false, nil
+		}
+		return "```Members can now pick as many roles as they like from " + name + ".```", false, nil
+	case "post":
+		if len(args) < 3 || !channelregex.MatchString(args[2]) {
+			return "```You have to give the channel to post the menu in.```", false, nil
+		}
+		if len(menu.Roles) == 0 {
+			return "```That role menu doesn't have any roles in it.```", false, nil
+		}
+		channel := StripPing(args[2])
+		if !info.HasChannel(channel) {
+			return "```That channel isn't on this server.```", false, nil
+		}
+		embed, components := roleMenuMessage(name, menu)
+		m, err := sb.dg.ChannelMessageSendComplex(channel, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Components: components})
+		if err != nil {
+			return apiErrorMessage(err), false, nil
+		}
+		if menu.Posts == nil {
+			menu.Posts = make(map[string]string)
+		}
+		menu.Posts[m.ID] = channel
+		info.SaveConfig()
+		return "```Posted the " + name + " role menu in #" + getChannelName(channel) + ".```", false, nil
+	case "delete":
+		for id, channel := range menu.Posts {
+			sb.dg.ChannelMessageDelete(channel, id)
+		}
+		delete(menus, name)
+		info.SaveConfig()
+		return "```Deleted the " + name + " role menu.```", false, nil
+	}
+	return "```You must specify add, remove, exclusive, post, delete or list.```", false, nil
+}
+func (c *roleMenuCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Manages role menus, which let members pick their own roles by clicking a button under a posted message. Menus are updated wherever they were posted whenever they change. Roles with moderator permissions can't be put in a menu.",
+		Params: []CommandUsageParam{
+			{Name: "add/remove/exclusive/post/delete/list", Desc: "`add` puts a role in a menu, making the menu if it doesn't exist. `remove` takes a role out of a menu. `exclusive` sets whether members can only have one role from the menu. `post` posts the menu in a channel. `delete` deletes the menu and every posted copy of it. `list` lists every menu.", Optional: false},
+			{Name: "menu", Desc: "The name of the menu, which is shown as its title.", Optional: true},
+			{Name: "role | label | description | emoji", Desc: "For `add`, the role to add, followed by the label, description and emoji members see, separated by `|`. Only the role is required. For `remove`, just the role. For `exclusive`, true or false. For `post`, the channel.", Optional: true},
+		},
+	}
+}
+func (c *roleMenuCommand) UsageShort() string { return "Manages self-assignable role menus." }
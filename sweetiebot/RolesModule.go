@@ -21,6 +21,13 @@ func (w *RolesModule) Commands() []Command {
 		&leaveRoleCommand{},
 		&removeRoleCommand{},
 		&deleteRoleCommand{},
+		&massRoleCommand{},
+		&tempRoleCommand{},
+		&colorCommand{},
+		&subscribeCommand{false},
+		&subscribeCommand{true},
+		&subscriptionsCommand{},
+		&setTopicCommand{},
 	}
 }
 
@@ -32,6 +39,11 @@ func (w *RolesModule) Description() string {
 // OnGuildRoleDelete keeps things tidy by making sure no deleted roles are user-assignable
 func (w *RolesModule) OnGuildRoleDelete(info *GuildInfo, r *discordgo.GuildRoleDelete) {
 	delete(info.config.Users.Roles, SBatoi(r.RoleID))
+	for k, v := range info.config.Subscriptions.Topics {
+		if v == r.RoleID {
+			delete(info.config.Subscriptions.Topics, k)
+		}
+	}
 	info.SaveConfig()
 }
 
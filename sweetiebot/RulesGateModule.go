@@ -0,0 +1,222 @@
+package sweetiebot
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Schedule type used to kick members who never accept the rules, so the deadline survives a restart
+const scheduleRulesKick = 11
+
+const rulesGateButton = "rulesgate:accept"
+const defaultRulesGateEmoji = "✅"
+
+// RulesGateModule gives new members rulesgate.role once they accept the rules, either by reacting to the rules message
+// or clicking its button, and kicks anyone who hasn't accepted within rulesgate.timeout.
+type RulesGateModule struct {
+}
+
+// Name of the module
+func (w *RulesGateModule) Name() string {
+	return "RulesGate"
+}
+
+// Commands in the module
+func (w *RulesGateModule) Commands() []Command {
+	return []Command{
+		&rulesGateCommand{},
+	}
+}
+
+// Description of the module
+func (w *RulesGateModule) Description() string {
+	return "If `rulesgate.enabled` is true, members are given `rulesgate.role` when they react to the rules message with `rulesgate.emoji` or click its I Accept button. If `rulesgate.timeout` is set, members who haven't accepted by then are kicked."
+}
+
+func rulesGateEmoji(info *GuildInfo) string {
+	if len(info.config.RulesGate.Emoji) == 0 {
+		return defaultRulesGateEmoji
+	}
+	return info.config.RulesGate.Emoji
+}
+
+// Returns true if a reaction used the configured emoji, which can be a unicode emoji, a custom emoji's ID or name:id
+func matchesRulesGateEmoji(info *GuildInfo, e discordgo.Emoji) bool {
+	emoji := rulesGateEmoji(info)
+	return e.Name == emoji || e.APIName() == emoji || (len(e.ID) > 0 && e.ID == emoji)
+}
+
+// Cancels a member's pending kick, if they have one
+func cancelRulesKick(info *GuildInfo, user string) {
+	if !sb.db.CheckStatus() {
+		return
+	}
+	if id := sb.db.FindEvent(user, SBatoi(info.ID), scheduleRulesKick); id != nil {
+		sb.db.RemoveSchedule(*id)
+	}
+}
+
+// Gives a member the rules role. Returns a message for the member and true if they hadn't already accepted.
+func acceptRules(info *GuildInfo, member *discordgo.Member) (string, bool) {
+	if info.config.RulesGate.Role == 0 {
+		return "This server hasn't set a role for accepting the rules. Please let a moderator know.", false
+	}
+	role := SBitoa(info.config.RulesGate.Role)
+	for _, r := range member.Roles {
+		if r == role {
+			return "You've already accepted the rules.", false
+		}
+	}
+	if isSilenced(member, info) {
+		return "You can't accept the rules while you're silenced.", false
+	}
+	err := CallAPI("GuildMemberRoleAdd", func() error { return sb.dg.GuildMemberRoleAdd(info.ID, member.User.ID, role) })
+	if err != nil {
+		info.LogError("Failed to give the rules role to "+member.User.Username+": ", err)
+		return "Something went wrong, please try again later or ask a moderator for help.", false
+	}
+	cancelRulesKick(info, member.User.ID)
+	info.LogTo(LogJoins, member.User.Username, " (", member.User.ID, ") accepted the rules ", TimeDiff(time.Now().UTC().Sub(member.JoinedAt)), " after joining.")
+	return "Thanks for accepting the rules, welcome to the server!", true
+}
+
+// Kicks a member whose deadline has passed, unless they accepted the rules some other way, like a moderator giving them the role
+func kickUnacceptedMember(info *GuildInfo, user string) {
+	if !info.config.RulesGate.Enabled || info.config.RulesGate.Timeout <= 0 || info.config.RulesGate.Role == 0 {
+		return
+	}
+	m, err := info.GetMember(user)
+	if err != nil {
+		return // They already left
+	}
+	role := SBitoa(info.config.RulesGate.Role)
+	for _, r := range m.Roles {
+		if r == role {
+			return
+		}
+	}
+	if m.User.Bot || info.HasModRole(user) {
+		return
+	}
+	reason := "Didn't accept the rules within " + TimeDiff(time.Duration(info.config.RulesGate.Timeout)*time.Second) + "."
+	err = CallAPI("GuildMemberDelete", func() error { return sb.dg.GuildMemberDeleteWithReason(info.ID, user, reason) })
+	if err != nil {
+		info.LogError("Failed to kick "+m.User.Username+" for not accepting the rules: ", err)
+		return
+	}
+	info.LogTo(LogJoins, "Kicked ", m.User.Username, " (", user, ") for not accepting the rules in time.")
+}
+
+// OnGuildMemberAdd discord hook
+func (w *RulesGateModule) OnGuildMemberAdd(info *GuildInfo, m *discordgo.Member) {
+	if !info.config.RulesGate.Enabled || info.config.RulesGate.Timeout <= 0 || m.User.Bot || !sb.db.CheckStatus() {
+		return
+	}
+	cancelRulesKick(info, m.User.ID) // Rejoining restarts the deadline
+	sb.db.AddSchedule(SBatoi(info.ID), time.Now().UTC().Add(time.Duration(info.config.RulesGate.Timeout)*time.Second), scheduleRulesKick, m.User.ID)
+}
+
+// OnGuildMemberRemove discord hook
+func (w *RulesGateModule) OnGuildMemberRemove(info *GuildInfo, m *discordgo.Member) {
+	if info.config.RulesGate.Enabled {
+		cancelRulesKick(info, m.User.ID)
+	}
+}
+
+// OnMessageReactionAdd discord hook
+func (w *RulesGateModule) OnMessageReactionAdd(info *GuildInfo, r *discordgo.MessageReaction) {
+	if !info.config.RulesGate.Enabled || r.MessageID != SBitoa(info.config.RulesGate.Message) || r.UserID == sb.SelfID || !matchesRulesGateEmoji(info, r.Emoji) {
+		return
+	}
+	m, err := info.GetMember(r.UserID)
+	if err != nil || m.User.Bot {
+		return
+	}
+	acceptRules(info, m)
+}
+
+// OnInteractionCreate discord hook
+func (w *RulesGateModule) OnInteractionCreate(info *GuildInfo, i *discordgo.Interaction) bool {
+	if i.Type != discordgo.InteractionMessageComponent || i.MessageComponentData().CustomID != rulesGateButton || i.Member == nil {
+		return false
+	}
+	if !info.config.RulesGate.Enabled {
+		respondEphemeral(i, "Accepting the rules has been disabled on this server.")
+		return true
+	}
+	msg, _ := acceptRules(info, i.Member)
+	respondEphemeral(i, msg)
+	return true
+}
+
+type rulesGateCommand struct {
+}
+
+func (c *rulesGateCommand) Name() string {
+	return "RulesGate"
+}
+func (c *rulesGateCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must provide a #channel to post an acceptance message in, or a link to an existing rules message.```", false, nil
+	}
+	if info.config.RulesGate.Role == 0 {
+		return "```Set rulesgate.role first, so there's something to give members who accept.```", false, nil
+	}
+	if r, err := sb.dg.State.Role(info.ID, SBitoa(info.config.RulesGate.Role)); err == nil && r.Permissions&roleMenuDangerousPerms != 0 {
+		return "```" + r.Name + " has moderator permissions, so it can't be handed out for accepting the rules.```", false, nil
+	}
+	emoji := rulesGateEmoji(info)
+	var channel, message string
+	if m := messagelinkregex.FindStringSubmatch(args[0]); m != nil {
+		if m[1] != info.ID || !info.HasChannel(m[2]) {
+			return "```That message isn't on this server.```", false, nil
+		}
+		channel, message = m[2], m[3]
+	} else if channelregex.MatchString(args[0]) {
+		channel = args[0][2 : len(args[0])-1]
+		if !info.HasChannel(channel) {
+			return "```That channel isn't on this server.```", false, nil
+		}
+		text := "Please read the rules, then click the button below or react with " + emoji + " to accept them and get access to the rest of the server."
+		if len(indices) > 1 {
+			text = msg.Content[indices[1]:]
+		}
+		var m *discordgo.Message
+		err := CallAPI("ChannelMessageSendComplex", func() (err error) {
+			m, err = sb.dg.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+				Content: text,
+				Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "I Accept", Style: discordgo.SuccessButton, CustomID: rulesGateButton},
+				}}},
+			})
+			return
+		})
+		if err != nil {
+			return "```Couldn't post the message: " + apiErrorMessage(err) + "```", false, nil
+		}
+		message = m.ID
+	} else {
+		return "```" + args[0] + " isn't a #channel or a message link.```", false, nil
+	}
+	if err := sb.dg.MessageReactionAdd(channel, message, emoji); err != nil {
+		info.LogError("Couldn't add the rules reaction: ", err)
+	}
+	info.config.RulesGate.Channel = SBatoi(channel)
+	info.config.RulesGate.Message = SBatoi(message)
+	info.config.RulesGate.Enabled = true
+	info.SaveConfig()
+	return "```Members who react to the rules message in #" + getChannelName(channel) + " with " + emoji + " will now be given " + roleNames(info, []string{SBitoa(info.config.RulesGate.Role)}) + ".```", false, nil
+}
+func (c *rulesGateCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Sets up the rules message. Given a channel, posts a message with an I Accept button there. Given a link to an existing message, like the one with your rules, uses that. Either way, Sweetie Bot reacts with `rulesgate.emoji`, and members who react with it too are given `rulesgate.role`. This also turns on `rulesgate.enabled`.",
+		Params: []CommandUsageParam{
+			{Name: "#channel|message link", Desc: "Where to post the acceptance message, or the message to use.", Optional: false},
+			{Name: "text", Desc: "The text of the posted message, if you don't want the default one.", Optional: true},
+		},
+	}
+}
+func (c *rulesGateCommand) UsageShort() string {
+	return "Sets up the rules acceptance message."
+}
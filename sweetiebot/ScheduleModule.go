@@ -1,6 +1,7 @@
 package sweetiebot
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -25,8 +26,18 @@ func (w *ScheduleModule) Commands() []Command {
 		&nextCommand{},
 		&addEventCommand{},
 		&removeEventCommand{},
+		&tasksCommand{},
+		&guildEventCommand{},
 		&remindMeCommand{},
 		&addBirthdayCommand{},
+		&sayCommand{},
+		&editSayCommand{},
+		&personaCommand{},
+		&remindChannelCommand{},
+		&channelRemindersCommand{},
+		&pauseReminderCommand{false},
+		&pauseReminderCommand{true},
+		&removeChannelReminderCommand{},
 	}
 }
 
@@ -66,7 +77,7 @@ func (w *ScheduleModule) OnTick(info *GuildInfo) {
 	for _, v := range events {
 		switch v.Type {
 		case 0:
-			err := sb.dg.GuildBanDelete(info.ID, v.Data)
+			err := CallAPI("GuildBanDelete", func() error { return sb.dg.GuildBanDelete(info.ID, v.Data) })
 			if err != nil {
 				info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Error unbanning <@"+v.Data+">: "+err.Error())
 			} else {
@@ -79,7 +90,7 @@ func (w *ScheduleModule) OnTick(info *GuildInfo) {
 				err := sb.dg.GuildMemberRoleAdd(info.ID, v.Data, SBitoa(info.config.Schedule.BirthdayRole))
 				info.LogError("Failed to set birthday role: ", err)
 			}
-			info.SendMessage(channel, "Happy Birthday <@"+v.Data+">!")
+			info.sendChatter("birthday", func() { info.SendMessage(channel, "Happy Birthday <@"+v.Data+">!") })
 		case 2:
 			info.SendMessage(channel, v.Data)
 		case 5, 3:
@@ -101,6 +112,15 @@ func (w *ScheduleModule) OnTick(info *GuildInfo) {
 		case 7:
 			dat := strings.SplitN(v.Data, "|", 2)
 			info.SendMessage(channel, dat[0]+" "+dat[1])
+		case 9:
+			a := &announcement{}
+			if err := json.Unmarshal([]byte(v.Data), a); err != nil {
+				info.Log("Failed to parse scheduled announcement #", v.ID, ": ", err.Error())
+			} else if _, err := postAnnouncement(info, a); err != nil {
+				info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Error posting announcement scheduled by "+getUserName(SBatoi(a.Author), info)+": "+err.Error())
+			} else {
+				info.Log("Posted announcement in #", getChannelName(a.Channel), " scheduled by ", getUserName(SBatoi(a.Author), info))
+			}
 		case 8:
 			err := UnsilenceMember(SBatoi(v.Data), info)
 			if err != nil {
@@ -108,12 +128,94 @@ func (w *ScheduleModule) OnTick(info *GuildInfo) {
 			} else {
 				info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Unsilenced <@"+v.Data+">")
 			}
+		case scheduleBump:
+			sendBumpReminder(info, v.Data)
+		case scheduleRulesKick:
+			kickUnacceptedMember(info, v.Data)
+		case scheduleBoostPerk:
+			revokeBoostPerk(info, v.Data)
+		case scheduleTempRole:
+			expireTempRole(info, v.Data)
 		}
 
 		sb.db.RemoveSchedule(v.ID)
 	}
 }
 
+// Returns the label for a scheduled event's type, and a description of what it will do. Episode titles are hidden
+// outside of the spoiler channels.
+func describeEvent(info *GuildInfo, v *ScheduleEvent, channel string) (string, string) {
+	data := v.Data
+	mt := "UNKNOWN"
+	switch v.Type {
+	case 0:
+		mt = "UNBAN"
+		data = "<@" + data + ">"
+	case 1:
+		mt = "BIRTHDAY"
+		data = "<@" + data + ">"
+	case 2:
+		mt = "MESSAGE"
+	case 3:
+		mt = "EPISODE"
+		if len(info.config.Spoiler.Channels) > 0 && !FindIntSlice(SBatoi(channel), info.config.Spoiler.Channels) {
+			data = "(title removed)"
+		}
+	case 4:
+		mt = "BIRTHDAY END"
+		data = "<@" + data + ">"
+	case 5:
+		mt = "EVENT"
+	case 6:
+		mt = "REMINDER"
+		if dat := strings.SplitN(data, "|", 2); len(dat) == 2 {
+			data = dat[1]
+		}
+	case 7:
+		if datas := strings.SplitN(data, "|", 2); len(datas) == 2 {
+			mt = "ROLE:" + ReplaceAllRolePings(datas[0], info)
+			data = datas[1]
+		}
+	case 8:
+		mt = "UNSILENCE"
+		data = "<@" + data + ">"
+	case 9:
+		a := &announcement{}
+		json.Unmarshal([]byte(data), a)
+		mt = "ANNOUNCE:#" + getChannelName(a.Channel)
+		data = a.Content + " (by " + getUserName(SBatoi(a.Author), info) + ")"
+	case scheduleBump:
+		mt = "BUMP"
+		data = "<#" + data + ">"
+	case scheduleRulesKick:
+		mt = "RULES KICK"
+		data = "<@" + data + ">"
+	case scheduleBoostPerk:
+		mt = "BOOST PERK"
+		data = "<@" + data + ">"
+	case scheduleTempRole:
+		mt = "TEMP ROLE"
+		if dat := strings.SplitN(data, "|", 2); len(dat) == 2 {
+			data = "<@" + dat[0] + "> loses <@&" + dat[1] + ">"
+		}
+	}
+	return mt, data
+}
+
+// Returns the ID of the user who scheduled an event, or 0 if it was scheduled by Sweetie Bot or wasn't recorded
+func eventOwner(v *ScheduleEvent) uint64 {
+	switch v.Type {
+	case 6:
+		return SBatoi(strings.SplitN(v.Data, "|", 2)[0])
+	case 9:
+		a := &announcement{}
+		if json.Unmarshal([]byte(v.Data), a) == nil {
+			return SBatoi(a.Author)
+		}
+	}
+	return 0
+}
+
 type scheduleCommand struct {
 }
 
@@ -150,7 +252,7 @@ func (c *scheduleCommand) Process(args []string, msg *discordgo.Message, indices
 	if maxresults < 1 {
 		maxresults = 1
 	}
-	if !info.UserHasRole(msg.Author.ID, SBitoa(info.config.Basic.AlertRole)) && (ty == 0 || ty == 4 || ty == 8) {
+	if !info.HasModRole(msg.Author.ID) && (ty == 0 || ty == 4 || ty == 8 || ty == 9) {
 		return "```You aren't allowed to view those events.```", false, nil
 	}
 	var events []ScheduleEvent
@@ -173,29 +275,7 @@ func (c *scheduleCommand) Process(args []string, msg *discordgo.Message, indices
 		} else {
 			t = ApplyTimezone(v.Date, info, msg.Author).Format("Jan 2 2006 3:04pm")
 		}
-		data := v.Data
-		mt := "UNKNOWN"
-		switch v.Type {
-		case 1:
-			mt = "BIRTHDAY"
-			data = "<@" + data + ">"
-		case 2:
-			mt = "MESSAGE"
-		case 3:
-			mt = "EPISODE"
-			if len(info.config.Spoiler.Channels) > 0 && !FindIntSlice(SBatoi(msg.ChannelID), info.config.Spoiler.Channels) {
-				data = "(title removed)"
-			}
-		case 5:
-			mt = "EVENT"
-		case 6:
-			mt = "REMINDER"
-			data = strings.SplitN(data, "|", 2)[1]
-		case 7:
-			datas := strings.SplitN(data, "|", 2)
-			mt = "ROLE:" + ReplaceAllRolePings(datas[0], info)
-			data = datas[1]
-		}
+		mt, data := describeEvent(info, &v, msg.ChannelID)
 		lines[k+1] = fmt.Sprintf("#%v **%s** [%s] %s", SBitoa(v.ID), t, mt, ReplaceAllMentions(data))
 	}
 
@@ -205,7 +285,7 @@ func (c *scheduleCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
 		Desc: "Lists up to `maxresults` upcoming events from the schedule. If the first argument is specified, lists only events of that type. Some event types can only be viewed by moderators. Max results: 20",
 		Params: []CommandUsageParam{
-			{Name: "type", Desc: "Can be one of: bans, birthdays, messages, episodes, events, roles, reminders.", Optional: true},
+			{Name: "type", Desc: "Can be one of: bans, birthdays, messages, episodes, events, roles, reminders, announcements, bumps, kicks, boosts, temproles.", Optional: true},
 			{Name: "maxresults", Desc: "Defaults to 5.", Optional: true},
 		},
 	}
@@ -230,6 +310,16 @@ func getScheduleType(s string) uint8 {
 		return 7
 	case "silences", "silence":
 		return 8
+	case "announcements", "announcement":
+		return 9
+	case "bumps", "bump":
+		return scheduleBump
+	case "kicks", "kick":
+		return scheduleRulesKick
+	case "boosts", "boost":
+		return scheduleBoostPerk
+	case "temproles", "temprole":
+		return scheduleTempRole
 	}
 	return 255
 }
@@ -324,6 +414,9 @@ func (c *addEventCommand) Process(args []string, msg *discordgo.Message, indices
 	if ty == 255 {
 		return "```Error: Invalid type specified.```", false, nil
 	}
+	if ty == 9 {
+		return "```Use " + info.config.Basic.CommandPrefix + "say to schedule announcements.```", false, nil
+	}
 	data := ""
 	if ty == 7 {
 		data = strings.ToLower(args[1])
@@ -426,7 +519,7 @@ func (c *removeEventCommand) Process(args []string, msg *discordgo.Message, indi
 		return "```Error: Event does not exist.```", false, nil
 	}
 	_, isOwner := sb.Owners[SBatoi(msg.Author.ID)]
-	if !isOwner && !info.UserHasRole(msg.Author.ID, SBitoa(info.config.Basic.AlertRole)) && !userOwnsEvent(e, msg.Author) {
+	if !isOwner && !info.HasModRole(msg.Author.ID) && !userOwnsEvent(e, msg.Author) {
 		return "```Error: You do not have permission to delete that event.```", false, nil
 	}
 
@@ -507,6 +600,9 @@ func (c *remindMeCommand) Process(args []string, msg *discordgo.Message, indices
 	if len(arg) == 0 {
 		return "```What am I reminding you about? I can't send you a blank message!```", false, nil
 	}
+	if limit := info.reminderLimit(); overLimit(sb.db.CountReminders(SBatoi(info.ID), msg.Author.ID), 1, limit) {
+		return limitReachedMessage(limit, "you can only have %v reminders waiting on this server", "!removeevent"), false, nil
+	}
 	if !sb.db.AddSchedule(SBatoi(info.ID), t, 6, msg.Author.ID+"|"+arg) {
 		return "```Error: servers can't have more than 5000 events!```", false, nil
 	}
@@ -0,0 +1,341 @@
+package sweetiebot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ScriptConfig is loaded from the scripts file. Only the bot owner can register script commands, and only programs in
+// Allow can be run. Allow can only be changed by editing the file, so a compromised discord account can't widen it.
+type ScriptConfig struct {
+	Allow      []string                  `json:"allow"`      // absolute paths of the only programs script commands can run
+	Timeout    int                       `json:"timeout"`    // seconds before a script is killed. If 0, scriptDefaultTimeout is used
+	MaxOutput  int                       `json:"maxoutput"`  // bytes of stdout that are kept. If 0, scriptDefaultOutput is used
+	MaxRunning int                       `json:"maxrunning"` // scripts that can run at the same time across the whole bot. If 0, scriptDefaultRunning is used
+	Commands   map[string]*ScriptCommand `json:"commands"`   // registered script commands, by lowercase name
+}
+
+// ScriptCommand runs Program with Args followed by whatever arguments the command was given
+type ScriptCommand struct {
+	Name    string   `json:"name"`
+	Program string   `json:"program"`
+	Args    []string `json:"args"`
+	Desc    string   `json:"desc"`
+}
+
+const scriptDefaultTimeout = 10
+const scriptDefaultOutput = 1900
+const scriptDefaultRunning = 2
+
+var scriptsLock sync.RWMutex
+var scriptsRunning int32
+
+func scriptLimit(v int, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// Returns true if the program is one of the executables the bot owner allowed in the scripts file
+func scriptAllowed(program string) bool {
+	if !filepath.IsAbs(program) {
+		return false
+	}
+	program = filepath.Clean(program)
+	for _, v := range sb.Scripts.Allow {
+		if filepath.Clean(v) == program {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the registered script command with this name, or nil
+func findScriptCommand(name string) Command {
+	scriptsLock.RLock()
+	defer scriptsLock.RUnlock()
+	if s, ok := sb.Scripts.Commands[strings.ToLower(name)]; ok {
+		return &scriptCommand{s.Name}
+	}
+	return nil
+}
+
+func saveScripts() error {
+	scriptsLock.RLock()
+	data, err := json.MarshalIndent(struct {
+		Scripts ScriptConfig `json:"scripts"`
+	}{sb.Scripts}, "", "  ")
+	scriptsLock.RUnlock()
+	if err == nil {
+		err = os.WriteFile("scripts", data, 0664)
+	}
+	return err
+}
+
+// cappedBuffer keeps the first max bytes written to it and silently throws away the rest, so a script that floods its
+// output can't use up memory, but still never blocks on a full pipe
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// Runs a script directly, without a shell, so none of the arguments are ever interpreted. The script gets an empty
+// environment apart from a few variables describing who ran it, so it can't read anything the bot was started with.
+// Returns stdout, the exit code, and an error if the script couldn't be started or was killed.
+func runScript(s *ScriptCommand, args []string, msg *discordgo.Message, info *GuildInfo) (string, bool, int, error) {
+	if !scriptAllowed(s.Program) {
+		return "", false, -1, fmt.Errorf("%s is not in the list of allowed programs", s.Program)
+	}
+	running := atomic.AddInt32(&scriptsRunning, 1)
+	defer atomic.AddInt32(&scriptsRunning, -1)
+	if int(running) > scriptLimit(sb.Scripts.MaxRunning, scriptDefaultRunning) {
+		return "", false, -1, fmt.Errorf("too many scripts are running right now")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(scriptLimit(sb.Scripts.Timeout, scriptDefaultTimeout))*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, s.Program, append(append([]string{}, s.Args...), args...)...)
+	cmd.Env = []string{
+		"SWEETIEBOT_USER=" + msg.Author.ID,
+		"SWEETIEBOT_USERNAME=" + msg.Author.Username,
+		"SWEETIEBOT_CHANNEL=" + msg.ChannelID,
+		"SWEETIEBOT_GUILD=" + info.ID,
+	}
+	cmd.Dir = filepath.Dir(s.Program)
+	cmd.WaitDelay = time.Second // Don't wait forever on a child process that kept our pipes open
+	stdout := &cappedBuffer{max: scriptLimit(sb.Scripts.MaxOutput, scriptDefaultOutput)}
+	cmd.Stdout = stdout
+	err := cmd.Run()
+	code := -1
+	if cmd.ProcessState != nil {
+		code = cmd.ProcessState.ExitCode()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", TimeDiff(time.Duration(scriptLimit(sb.Scripts.Timeout, scriptDefaultTimeout))*time.Second))
+	} else if _, exited := err.(*exec.ExitError); exited {
+		err = nil // A non-zero exit code is reported through the code, not as a failure to run
+	}
+	return stdout.buf.String(), stdout.truncated, code, err
+}
+
+// ScriptModule contains the script commands registered by the bot owner
+type ScriptModule struct {
+}
+
+// Name of the module
+func (w *ScriptModule) Name() string {
+	return "Scripts"
+}
+
+// Commands in the module
+func (w *ScriptModule) Commands() []Command {
+	r := []Command{&scriptAdminCommand{}}
+	scriptsLock.RLock()
+	names := make([]string, 0, len(sb.Scripts.Commands))
+	for _, v := range sb.Scripts.Commands {
+		names = append(names, v.Name)
+	}
+	scriptsLock.RUnlock()
+	sort.Strings(names)
+	for _, v := range names {
+		r = append(r, &scriptCommand{v})
+	}
+	return r
+}
+
+// Description of the module
+func (w *ScriptModule) Description() string {
+	return "Contains script commands, which run a program the bot owner allowed and post what it prints. Only the bot owner can add script commands."
+}
+
+type scriptCommand struct {
+	name string
+}
+
+func (c *scriptCommand) Name() string {
+	return c.name
+}
+func (c *scriptCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	scriptsLock.RLock()
+	s, ok := sb.Scripts.Commands[strings.ToLower(c.name)]
+	var script ScriptCommand
+	if ok {
+		script = *s
+	}
+	scriptsLock.RUnlock()
+	if !ok {
+		return "```That script command has been removed.```", false, nil
+	}
+	start := time.Now()
+	out, truncated, code, err := runScript(&script, args, msg, info)
+	fmt.Printf("[%s] SCRIPT %s in %s (%s): %s %q exited with %v after %s\n", time.Now().Format(time.Stamp), userIdentity(msg.Author), info.Name, info.ID, script.Program, args, code, time.Since(start).Round(time.Millisecond))
+	if sb.db.CheckStatus() {
+		sb.db.Audit(AUDIT_TYPE_COMMAND, msg.Author, fmt.Sprintf("[script] %s %q exited with %v", script.Program, args, code), SBatoi(info.ID))
+	}
+	if err != nil {
+		return "```Error running " + script.Name + ": " + err.Error() + "```", false, nil
+	}
+	out = strings.TrimSpace(out)
+	if truncated {
+		out += "\n[output truncated]"
+	}
+	if len(out) == 0 {
+		if code != 0 {
+			return fmt.Sprintf("```%s exited with code %v.```", script.Name, code), false, nil
+		}
+		return "```" + script.Name + " didn't print anything.```", false, nil
+	}
+	return "```\n" + PartialSanitize(out) + "```", false, nil
+}
+func (c *scriptCommand) Usage(info *GuildInfo) *CommandUsage {
+	scriptsLock.RLock()
+	defer scriptsLock.RUnlock()
+	desc := "Runs a script registered by the bot owner."
+	if s, ok := sb.Scripts.Commands[strings.ToLower(c.name)]; ok && len(s.Desc) > 0 {
+		desc = s.Desc
+	}
+	return &CommandUsage{
+		Desc: desc,
+		Params: []CommandUsageParam{
+			{Name: "arguments", Desc: "Passed to the script as they are.", Optional: true},
+		},
+	}
+}
+func (c *scriptCommand) UsageShort() string {
+	scriptsLock.RLock()
+	defer scriptsLock.RUnlock()
+	if s, ok := sb.Scripts.Commands[strings.ToLower(c.name)]; ok && len(s.Desc) > 0 {
+		return truncateRunes(s.Desc, 60)
+	}
+	return "Runs a script."
+}
+
+type scriptAdminCommand struct {
+}
+
+func (c *scriptAdminCommand) Name() string {
+	return "Script"
+}
+func (c *scriptAdminCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !isBotOwner(msg.Author.ID) {
+		return "```Only the owner of the bot itself can call this!```", false, nil
+	}
+	if len(args) < 1 {
+		scriptsLock.RLock()
+		lines := make([]string, 0, len(sb.Scripts.Commands))
+		for _, v := range sb.Scripts.Commands {
+			lines = append(lines, v.Name+": "+strings.Join(append([]string{v.Program}, v.Args...), " "))
+		}
+		scriptsLock.RUnlock()
+		sort.Strings(lines)
+		if len(lines) == 0 {
+			lines = append(lines, "No script commands have been registered.")
+		}
+		if len(sb.Scripts.Allow) == 0 {
+			lines = append(lines, "", "No programs are allowed. Add their absolute paths to \"allow\" in the scripts file and restart the bot.")
+		} else {
+			lines = append(lines, "", "Allowed programs: "+strings.Join(sb.Scripts.Allow, ", "))
+		}
+		return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```", false, nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "add":
+		if len(args) < 3 {
+			return "```You must give a name for the command and the program it runs.```", false, nil
+		}
+		name := strings.ToLower(args[1])
+		if _, ok := info.commands[name]; ok && findScriptCommand(name) == nil {
+			return "```" + name + " is already a command.```", false, nil
+		}
+		if !scriptAllowed(args[2]) {
+			return "```" + args[2] + " isn't in the list of allowed programs. Add its absolute path to \"allow\" in the scripts file and restart the bot.```", false, nil
+		}
+		s := &ScriptCommand{Name: args[1], Program: filepath.Clean(args[2]), Args: append([]string{}, args[3:]...)}
+		scriptsLock.Lock()
+		if old, ok := sb.Scripts.Commands[name]; ok {
+			s.Desc = old.Desc
+		}
+		if sb.Scripts.Commands == nil {
+			sb.Scripts.Commands = make(map[string]*ScriptCommand)
+		}
+		sb.Scripts.Commands[name] = s
+		scriptsLock.Unlock()
+		logAdminAction(msg.Author, "Registered script command "+name+": "+strings.Join(append([]string{s.Program}, s.Args...), " "))
+		if err := saveScripts(); err != nil {
+			return "```Registered " + name + ", but couldn't save it: " + err.Error() + "```", false, nil
+		}
+		return "```Registered " + name + ". It runs " + s.Program + " with any arguments it's given.```", false, nil
+	case "desc":
+		if len(args) < 3 {
+			return "```You must give a script command and its description.```", false, nil
+		}
+		name := strings.ToLower(args[1])
+		scriptsLock.Lock()
+		s, ok := sb.Scripts.Commands[name]
+		if ok {
+			s.Desc = msg.Content[indices[2]:]
+		}
+		scriptsLock.Unlock()
+		if !ok {
+			return "```There's no script command called " + name + ".```", false, nil
+		}
+		if err := saveScripts(); err != nil {
+			return "```Changed the description, but couldn't save it: " + err.Error() + "```", false, nil
+		}
+		return "```Changed the description of " + name + ".```", false, nil
+	case "remove":
+		if len(args) < 2 {
+			return "```You must give the script command to remove.```", false, nil
+		}
+		name := strings.ToLower(args[1])
+		scriptsLock.Lock()
+		_, ok := sb.Scripts.Commands[name]
+		delete(sb.Scripts.Commands, name)
+		scriptsLock.Unlock()
+		if !ok {
+			return "```There's no script command called " + name + ".```", false, nil
+		}
+		logAdminAction(msg.Author, "Removed script command "+name)
+		if err := saveScripts(); err != nil {
+			return "```Removed " + name + ", but couldn't save it: " + err.Error() + "```", false, nil
+		}
+		return "```Removed " + name + ".```", false, nil
+	}
+	return "```The first argument must be add, desc or remove.```", false, nil
+}
+func (c *scriptAdminCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Restricted command that registers a script command, which runs a program with the arguments it's given and posts what it prints. The program is run directly, never through a shell, with an empty environment, and is killed if it runs too long. Only programs listed in the `scripts` file can be used. With no arguments, lists the script commands.",
+		Params: []CommandUsageParam{
+			{Name: "add/desc/remove", Desc: "`add <name> <program> [arguments...]` registers a command, `desc <name> <description>` sets its help text, and `remove <name>` deletes it.", Optional: true},
+		},
+	}
+}
+func (c *scriptAdminCommand) UsageShort() string {
+	return "[RESTRICTED] Registers script commands."
+}
@@ -0,0 +1,140 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const defaultSecretsMessage = "Your message in {channel} on {server} was deleted because it looked like it contained {kind}. Anyone who saw it could have copied it, so revoke it and make a new one right away, even if you deleted it yourself."
+
+// A kind of secret and the pattern it's recognized by. These are the published formats of each service's keys, which
+// are distinctive enough that ordinary messages almost never match them.
+type secretPattern struct {
+	kind  string // the name used in secrets.ignore
+	desc  string // what it's called in messages, like "a discord bot token"
+	regex *regexp.Regexp
+}
+
+var secretPatterns = []secretPattern{
+	{"discord", "a discord bot token", regexp.MustCompile(`\b[MNO][A-Za-z\d_-]{23,27}\.[A-Za-z\d_-]{6}\.[A-Za-z\d_-]{27,40}\b`)},
+	{"webhook", "a discord webhook URL", regexp.MustCompile(`https?://(?:(?:ptb|canary)\.)?discord(?:app)?\.com/api(?:/v\d+)?/webhooks/\d+/[A-Za-z\d_-]{60,}`)},
+	{"github", "a GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z\d]{36}|github_pat_[A-Za-z\d_]{82})\b`)},
+	{"gitlab", "a GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z\d_-]{20}\b`)},
+	{"aws", "an AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z\d]{16}\b`)},
+	{"google", "a Google API key", regexp.MustCompile(`\bAIza[A-Za-z\d_-]{35}\b`)},
+	{"slack", "a Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z\d-]{10,}\b`)},
+	{"stripe", "a Stripe secret key", regexp.MustCompile(`\b[sr]k_live_[A-Za-z\d]{24,}\b`)},
+	{"openai", "an OpenAI API key", regexp.MustCompile(`\bsk-(?:proj-|svcacct-)?[A-Za-z\d_-]{20,}T3BlbkFJ[A-Za-z\d_-]{20,}\b`)},
+	{"npm", "an npm token", regexp.MustCompile(`\bnpm_[A-Za-z\d]{36}\b`)},
+	{"privatekey", "a private key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+}
+
+// A secret found in a message
+type secretMatch struct {
+	pattern *secretPattern
+	value   string
+}
+
+// Returns every secret in a message, except the kinds this server ignores
+func findSecrets(info *GuildInfo, text string) []secretMatch {
+	matches := []secretMatch{}
+	for i := range secretPatterns {
+		p := &secretPatterns[i]
+		if info.config.Secrets.Ignore[p.kind] {
+			continue
+		}
+		for _, s := range p.regex.FindAllString(text, -1) {
+			matches = append(matches, secretMatch{p, s})
+		}
+	}
+	return matches
+}
+
+// Hides all but the start of a secret, so the logs can show which one was leaked without leaking it again
+func redactSecret(s string) string {
+	r := []rune(s)
+	if len(r) <= 8 {
+		return fmt.Sprintf("[%v characters]", len(r))
+	}
+	return fmt.Sprintf("%s... [%v characters]", string(r[:4]), len(r))
+}
+
+// SecretsModule deletes messages that contain something that looks like a leaked token or key, and tells whoever posted
+// it to revoke it.
+type SecretsModule struct {
+}
+
+// Name of the module
+func (w *SecretsModule) Name() string {
+	return "Secrets"
+}
+
+// Commands in the module
+func (w *SecretsModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *SecretsModule) Description() string {
+	return "If `secrets.enabled` is true, deletes messages containing what looks like a leaked secret, like a discord bot token, a webhook URL, a GitHub, AWS or Google key, or a private key. The author is told to revoke it, and the moderators are alerted. The secret itself is never logged."
+}
+
+func (w *SecretsModule) check(info *GuildInfo, m *discordgo.Message) {
+	if !info.config.Secrets.Enabled || m.Author == nil || m.Author.ID == sb.SelfID {
+		return
+	}
+	matches := findSecrets(info, m.Content)
+	if len(matches) == 0 {
+		return
+	}
+	// Forget the message before it's deleted, so the deleted message log and !snipe never show it
+	info.messagecache.Remove(m.ID)
+	// The message was already logged before any module saw it. Overwriting it would copy the secret into the edit log,
+	// so delete it instead, which clears its edit history as well.
+	if sb.IsDBGuild(info) && sb.db.CheckStatus() {
+		sb.db.RemoveMessage(SBatoi(m.ID))
+	}
+	err := CallAPI("ChannelMessageDelete", func() error { return deleteMessage(m.ChannelID, m.ID) })
+
+	kinds := []string{}
+	found := []string{}
+	seen := make(map[string]bool)
+	for _, s := range matches {
+		if !seen[s.pattern.desc] {
+			seen[s.pattern.desc] = true
+			kinds = append(kinds, s.pattern.desc)
+		}
+		found = append(found, s.pattern.desc+" "+redactSecret(s.value))
+	}
+	kind := strings.Join(kinds, " and ")
+	name := getUserName(SBatoi(m.Author.ID), info)
+	if err != nil {
+		info.LogTo(LogModeration, "Couldn't delete a message from ", name, " in #", getChannelName(m.ChannelID), " that contains ", kind, ": ", apiErrorMessage(err))
+		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+m.Author.ID+"> posted what looks like "+kind+" in <#"+m.ChannelID+">, but I couldn't delete it. Please delete it right away: "+messageLink(info, m.ChannelID, m.ID))
+	} else {
+		info.LogTo(LogModeration, "Deleted a message from ", name, " in #", getChannelName(m.ChannelID), " that contains ", strings.Join(found, ", "))
+		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: deleted a message from <@"+m.Author.ID+"> in <#"+m.ChannelID+"> that contained what looks like "+kind+". They've been told to revoke it.")
+	}
+	if m.Author.Bot {
+		return
+	}
+	msg := info.config.Secrets.Message
+	if len(msg) == 0 {
+		msg = defaultSecretsMessage
+	}
+	msg = renderTemplate(info, msg, map[string]string{"user": "<@" + m.Author.ID + ">", "username": name, "channel": "#" + getChannelName(m.ChannelID), "kind": kind})
+	if ch, err := sb.dg.UserChannelCreate(m.Author.ID); err == nil {
+		sb.dg.ChannelMessageSend(ch.ID, msg)
+	}
+}
+
+// OnMessageCreate discord hook
+func (w *SecretsModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	w.check(info, m)
+}
+
+// OnMessageUpdate discord hook
+func (w *SecretsModule) OnMessageUpdate(info *GuildInfo, m *discordgo.Message) {
+	w.check(info, m)
+}
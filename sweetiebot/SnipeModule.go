@@ -0,0 +1,141 @@
+package sweetiebot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type snipedMessage struct {
+	msg  *CachedMessage
+	when time.Time // when it was deleted or edited
+}
+
+// SnipeModule remembers the last deleted and last edited message in each channel, so moderators can see what was said.
+// It only knows about messages in the message cache, so it does nothing unless privacy.storecontent is on.
+type SnipeModule struct {
+	lock    sync.Mutex
+	deleted map[string]snipedMessage // by channel
+	edited  map[string]snipedMessage // by channel
+}
+
+// Name of the module
+func (w *SnipeModule) Name() string {
+	return "Snipe"
+}
+
+// Commands in the module
+func (w *SnipeModule) Commands() []Command {
+	return []Command{
+		&snipeCommand{w, false},
+		&snipeCommand{w, true},
+	}
+}
+
+// Description of the module
+func (w *SnipeModule) Description() string {
+	return "Shows the last message deleted in a channel with `!snipe`, or what the last edited message said before it was edited with `!editsnipe`. Only works if `privacy.storecontent` is on."
+}
+
+func (w *SnipeModule) remember(list *map[string]snipedMessage, m *CachedMessage) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if *list == nil {
+		*list = make(map[string]snipedMessage)
+	}
+	(*list)[m.ChannelID] = snipedMessage{m, time.Now().UTC()}
+}
+
+// OnMessageDelete discord hook
+func (w *SnipeModule) OnMessageDelete(info *GuildInfo, m *discordgo.Message) {
+	if cached := info.messagecache.Get(m.ID); cached != nil {
+		w.remember(&w.deleted, cached)
+	}
+}
+
+// OnMessageUpdate discord hook
+func (w *SnipeModule) OnMessageUpdate(info *GuildInfo, m *discordgo.Message) {
+	if cached := info.messagecache.Get(m.ID); cached != nil && cached.Changed {
+		w.remember(&w.edited, cached)
+	}
+}
+
+// Returns the last sniped message in a channel, unless it is too old or content storage was turned off since
+func (w *SnipeModule) get(info *GuildInfo, channel string, edits bool) *snipedMessage {
+	if !info.StoresContent(channel) {
+		return nil
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	list := w.deleted
+	if edits {
+		list = w.edited
+	}
+	s, ok := list[channel]
+	if !ok || (info.config.Snipe.MaxAge > 0 && time.Now().UTC().Sub(s.when) > time.Duration(info.config.Snipe.MaxAge)*time.Second) {
+		return nil
+	}
+	return &s
+}
+
+type snipeCommand struct {
+	w     *SnipeModule
+	edits bool
+}
+
+func (c *snipeCommand) Name() string {
+	if c.edits {
+		return "EditSnipe"
+	}
+	return "Snipe"
+}
+func (c *snipeCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !info.config.Privacy.StoreContent {
+		return "```This server doesn't let me remember what anyone said, so there's nothing to snipe. Turn on privacy.storecontent to allow it.```", false, nil
+	}
+	s := c.w.get(info, msg.ChannelID, c.edits)
+	if s == nil {
+		if c.edits {
+			return "```No messages have been edited here recently.```", false, nil
+		}
+		return "```No messages have been deleted here recently.```", false, nil
+	}
+	author := &discordgo.MessageEmbedAuthor{Name: getUserName(SBatoi(s.msg.AuthorID), info)}
+	if u, err := sb.dg.User(s.msg.AuthorID); err == nil {
+		author.IconURL = u.AvatarURL("")
+	}
+	content := s.msg.Content
+	title := "Deleted " + TimeDiff(time.Now().UTC().Sub(s.when)) + " ago"
+	if c.edits {
+		content = s.msg.Previous
+		title = "Edited " + TimeDiff(time.Now().UTC().Sub(s.when)) + " ago"
+	}
+	if len(content) > 3500 {
+		content = content[:3500] + " [truncated]"
+	}
+	if !c.edits && len(s.msg.Attachments) > 0 {
+		content += "\n\nAttachments:\n" + strings.Join(s.msg.Attachments, "\n")
+	}
+	return "", false, &discordgo.MessageEmbed{
+		Type:        "rich",
+		Author:      author,
+		Title:       title,
+		Description: content,
+		Color:       0x95A5A6,
+		Timestamp:   time.Unix(s.msg.Timestamp, 0).UTC().Format(time.RFC3339),
+	}
+}
+func (c *snipeCommand) Usage(info *GuildInfo) *CommandUsage {
+	if c.edits {
+		return &CommandUsage{Desc: "Shows what the last edited message in this channel said before it was edited, if it was edited in the last `snipe.maxage` seconds."}
+	}
+	return &CommandUsage{Desc: "Shows the last message deleted in this channel, if it was deleted in the last `snipe.maxage` seconds. This includes messages removed by the filter or anti-spam."}
+}
+func (c *snipeCommand) UsageShort() string {
+	if c.edits {
+		return "Shows the last edited message."
+	}
+	return "Shows the last deleted message."
+}
@@ -2,10 +2,14 @@ package sweetiebot
 
 import (
 	"fmt"
+	"hash/fnv"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"math"
 
@@ -18,7 +22,21 @@ type userPressure struct {
 	sync.Mutex
 	pressure    float32
 	lastmessage int64
-	lastcache   string
+	lasthash    uint64                      // hash of the last message, so repeats can be detected without keeping the message content around
+	counted     map[string]float32          // pressure already counted for each recent message, so edits aren't counted twice
+	short       *SaturationLimit            // when recent short messages were posted, only allocated if the short message filter is on
+	rolepings   map[string]*SaturationLimit // when each role was recently pinged, only allocated once the user pings a role
+	roleblocks  map[string]int64            // when the user is allowed to ping each blocked role again
+	reactions   *SaturationLimit            // when recent reactions were added, only allocated once the user reacts to something
+	reacted     []reactionRef               // the reactions counted by reactions, so they can be removed again
+	forwards    *SaturationLimit            // when recent forwarded or sticker-only messages were posted, only allocated once the user posts one
+}
+
+// reactionRef identifies a single reaction someone added
+type reactionRef struct {
+	channel string
+	message string
+	emoji   string
 }
 
 // SpamModule detects banned emotes and deletes them
@@ -26,6 +44,8 @@ type SpamModule struct {
 	sync.Mutex
 	tracker  map[uint64]*userPressure
 	lastraid int64
+	joins    []recentJoin // everyone who joined within spam.fingerprinttime, for the raid fingerprint
+	cohort   *raidCohort  // the last cluster of similar accounts the fingerprint caught
 }
 
 // Name of the module
@@ -39,14 +59,18 @@ func (w *SpamModule) Commands() []Command {
 		&autoSilenceCommand{w},
 		&wipeCommand{},
 		&getPressureCommand{w},
+		&spamTestCommand{},
 		&getRaidCommand{w},
 		&banRaidCommand{w},
+		&exemptCommand{},
+		&unexemptCommand{},
+		&exemptionsCommand{},
 	}
 }
 
 // Description of the module
 func (w *SpamModule) Description() string {
-	return "Tracks all channels it is active on for spammers. Each message someone sends generates \"pressure\", which decays rapidly. Long messages, messages with links, or messages with pings will generate more pressure. If a user generates too much pressure, they will be silenced and the moderators notified. Also detects groups of people joining at the same time and alerts the moderators of a potential raid."
+	return "Tracks all channels it is active on for spammers. Each message someone sends generates \"pressure\", which decays rapidly. Long messages, messages with links, or messages with pings will generate more pressure. If a user generates too much pressure, they will be silenced and the moderators notified. Also detects groups of people joining at the same time, or groups of similar accounts joining over a few minutes if `spam.fingerprintsize` is set, and alerts the moderators of a potential raid."
 }
 
 func isSilenced(m *discordgo.Member, info *GuildInfo) bool {
@@ -60,7 +84,9 @@ func isSilenced(m *discordgo.Member, info *GuildInfo) bool {
 }
 
 func doDiscordSilence(userID string, info *GuildInfo) {
-	err := sb.dg.GuildMemberRoleAdd(info.ID, userID, SBitoa(info.config.Spam.SilentRole))
+	err := CallAPI("GuildMemberRoleAdd", func() error {
+		return sb.dg.GuildMemberRoleAdd(info.ID, userID, SBitoa(info.config.Spam.SilentRole))
+	})
 	info.LogError(fmt.Sprintf("GuildMemberRoleAdd(%s, %s, %v) return error: ", info.ID, userID, info.config.Spam.SilentRole), err)
 }
 func silenceMember(user *discordgo.User, info *GuildInfo) int8 {
@@ -92,10 +118,35 @@ func SilenceMemberSimple(userID string, info *GuildInfo) int8 {
 	return 0
 }
 
+// Posted in the channel a spammer was caught in when spam.actionmessage is empty
+const defaultSpamActionMessage = "{user} was {action} for {reason}. The moderators have been notified."
+
+// Fills in the placeholders of the spam.actionmessage template
+func renderSpamActionMessage(info *GuildInfo, u *discordgo.User, channel string, action string, reason string) string {
+	s := info.config.Spam.ActionMessage
+	if len(strings.TrimSpace(s)) == 0 {
+		s = defaultSpamActionMessage
+	}
+	return renderTemplate(info, s, map[string]string{
+		"user":     "<@" + u.ID + ">",
+		"username": u.Username,
+		"action":   action,
+		"reason":   reason,
+		"channel":  "<#" + channel + ">",
+	})
+}
+
+// Lets everyone in the channel know why the spammer's messages disappeared, unless the server turned this off
+func notifySpamAction(info *GuildInfo, u *discordgo.User, channel string, action string, reason string) {
+	if info.config.Spam.ActionNotify {
+		info.SendMessage(channel, renderSpamActionMessage(info, u, channel, action, reason))
+	}
+}
+
 func killSpammer(u *discordgo.User, info *GuildInfo, msg *discordgo.Message, reason string, oldpressure float32, newpressure float32) {
 	// Before anything else happens, we delete this message. This ensures that even if we get rate-limited, we can still delete any new messages
 	if info.config.Spam.MaxRemoveLookback >= 0 {
-		sb.dg.ChannelMessageDelete(msg.ChannelID, msg.ID)
+		deleteMessage(msg.ChannelID, msg.ID)
 	}
 
 	// Go 1.25 optimization: Use strings.Builder for efficient string concatenation
@@ -124,10 +175,14 @@ func killSpammer(u *discordgo.User, info *GuildInfo, msg *discordgo.Message, rea
 	if SBatoi(msg.ChannelID) == info.config.Users.WelcomeChannel {
 		sb.dg.GuildBanCreateWithReason(info.ID, u.ID, "Autobanned for "+reason+" in the welcome channel.", 1)
 		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+u.ID+"> was banned for "+reason+" in the welcome channel.")
-		info.Log(logmsg)
+		notifySpamAction(info, u, msg.ChannelID, "banned", reason)
+		info.LogTo(LogModeration, logmsg)
 		return
 	}
 	silenced := silenceMember(u, info) > 0
+	if !silenced && sb.db.CheckStatus() {
+		info.AddOffense(Offense{Type: OFFENSE_SPAM, User: SBatoi(u.ID), Moderator: SBatoi(sb.SelfID), Reason: reason, Timestamp: time.Now().UTC()})
+	}
 
 	if info.config.Spam.MaxRemoveLookback > 0 && !silenced {
 		IDs := []string{msg.ID}
@@ -158,37 +213,305 @@ func killSpammer(u *discordgo.User, info *GuildInfo, msg *discordgo.Message, rea
 
 	if !silenced { // Only send the alert if they weren't silenced already
 		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+u.ID+"> was silenced for "+reason+". Please investigate.") // Alert admins
-		info.Log(logmsg)
+		notifySpamAction(info, u, msg.ChannelID, "silenced", reason)
+		info.LogTo(LogModeration, logmsg)
 	} else {
-		info.Log("Killing spammer " + u.Username)
+		info.LogTo(LogModeration, "Killing spammer "+u.Username)
+	}
+}
+
+// Returns true if a message is too short to be anything but filler, which catches people flooding a channel with single
+// characters or with empty messages that only carry an embed or sticker.
+func isShortMessage(info *GuildInfo, m *discordgo.Message, exempt map[string]bool) bool {
+	if info.config.Spam.ShortLength <= 0 || info.config.Spam.ShortCount <= 0 || exempt["short"] {
+		return false
+	}
+	if info.config.Spam.ShortAllowReplies && m.MessageReference != nil {
+		return false
+	}
+	if info.config.Spam.ShortAllowFiles && len(m.Attachments) > 0 {
+		return false
+	}
+	return utf8.RuneCountInString(strings.TrimSpace(m.Content)) < info.config.Spam.ShortLength
+}
+
+var spacerCodeBlock = regexp.MustCompile("(?s)```.*?```")
+
+// Every kind of line break discord shows as a new line
+var spacerNewlines = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\v", "\n", "\f", "\n", "\u0085", "\n", "\u2028", "\n", "\u2029", "\n")
+
+// Returns true for characters that don't show up on their own: whitespace, invisible characters, blank fillers like the
+// Hangul filler and empty braille pattern, and markdown that renders as nothing on an otherwise empty line, like "_ _"
+func isSpacerRune(r rune) bool {
+	switch r {
+	case '_', '*', '~', '|', '>', '\u115f', '\u1160', '\u2800', '\u3164', '\uffa0':
+		return true
+	}
+	return unicode.IsSpace(r) || unicode.Is(unicode.Cf, r)
+}
+
+// Counts the lines of a message that would show up blank, not counting anything inside code blocks
+func countSpacerLines(content string) int {
+	content = spacerNewlines.Replace(spacerCodeBlock.ReplaceAllString(content, ""))
+	n := 0
+	for _, line := range strings.Split(content, "\n") {
+		if len(strings.TrimFunc(line, isSpacerRune)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Deletes a message with at least spam.spacerlines blank lines, which people use to push the rest of the chat off the
+// screen. Pressure only counts line breaks, so padding them out with invisible characters would otherwise get past it.
+// Returns true if the message was deleted.
+func checkSpacerFlood(info *GuildInfo, m *discordgo.Message, exempt map[string]bool) bool {
+	limit := info.config.Spam.SpacerLines
+	if limit <= 0 || exempt["spacers"] {
+		return false
+	}
+	n := countSpacerLines(m.Content)
+	if n < limit {
+		return false
+	}
+	deleteMessage(m.ChannelID, m.ID)
+	reason := fmt.Sprintf("posting a message with %v blank lines", n)
+	info.LogTo(LogModeration, m.Author.Username, " was caught ", reason, " in #", getChannelName(m.ChannelID), ", so it was deleted.")
+	if info.config.Spam.SpacerWarn && sb.db.CheckStatus() {
+		info.AddOffense(Offense{Type: OFFENSE_WARNING, User: SBatoi(m.Author.ID), Moderator: SBatoi(sb.SelfID), Reason: "Flooded #" + getChannelName(m.ChannelID) + " with blank lines", Timestamp: time.Now().UTC()})
+		if channel, err := sb.dg.UserChannelCreate(m.Author.ID); err == nil {
+			sb.dg.ChannelMessageSend(channel.ID, "You have been warned on "+info.Name+" for posting a message that was mostly blank lines.")
+		}
+	}
+	return true
+}
+
+// Tracks how often a user pings each role across messages, so someone repeatedly pinging a role gets stopped even if each
+// message alone is fine. Returns true if the message was deleted, either because it pushed the user over the limit or
+// because they are still blocked from pinging one of its roles.
+func checkRolePings(info *GuildInfo, m *discordgo.Message, track *userPressure, edited bool, exempt map[string]bool) bool {
+	count := info.config.Spam.RolePingCount
+	if len(m.MentionRoles) == 0 || count <= 0 || exempt["roleping"] {
+		return false
+	}
+	now := time.Now().UTC().Unix()
+	for _, role := range m.MentionRoles {
+		if until, ok := track.roleblocks[role]; ok {
+			if now < until {
+				deleteMessage(m.ChannelID, m.ID)
+				return true
+			}
+			delete(track.roleblocks, role)
+		}
+		if edited {
+			continue // Only new messages count towards the limit, but edits can't be used to sneak in a blocked role
+		}
+		if track.rolepings == nil {
+			track.rolepings = make(map[string]*SaturationLimit)
+		}
+		limit, ok := track.rolepings[role]
+		if !ok || len(limit.times) != count {
+			limit = &SaturationLimit{}
+			limit.resize(count)
+			track.rolepings[role] = limit
+		}
+		limit.append(now)
+		if !limit.checkafter(count-1, info.config.Spam.RolePingTime) {
+			continue
+		}
+		delete(track.rolepings, role)
+		name := role
+		if r, err := sb.dg.State.Role(info.ID, role); err == nil {
+			name = r.Name
+		}
+		reason := fmt.Sprintf("pinging the %s role %v times in %v seconds", name, count, info.config.Spam.RolePingTime)
+		if info.config.Spam.RolePingSilence {
+			killSpammer(m.Author, info, m, reason, track.pressure, track.pressure)
+			return true
+		}
+		deleteMessage(m.ChannelID, m.ID)
+		if track.roleblocks == nil {
+			track.roleblocks = make(map[string]int64)
+		}
+		track.roleblocks[role] = now + info.config.Spam.RolePingCooldown
+		cooldown := TimeDiff(time.Duration(info.config.Spam.RolePingCooldown) * time.Second)
+		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+m.Author.ID+"> was caught "+reason+", so any message they send pinging it in the next "+cooldown+" will be deleted.")
+		info.LogTo(LogModeration, m.Author.Username, " was blocked from pinging the ", name, " role for ", cooldown, " after ", reason, " in #", getChannelName(m.ChannelID), ".")
+		return true
+	}
+	return false
+}
+
+// Returns true if a message was forwarded from another channel. Our discordgo version drops the reference type and the
+// message snapshots, but a forward is the only kind of regular message that references another message without being a
+// reply, and its own content is always empty because everything lives in the snapshot.
+func isForwardedMessage(m *discordgo.Message) bool {
+	return m.MessageReference != nil && m.Type == discordgo.MessageTypeDefault && len(m.WebhookID) == 0 &&
+		m.Flags&discordgo.MessageFlagsIsCrossPosted == 0 && len(m.Content) == 0 && len(m.Attachments) == 0
+}
+
+// Returns true if a message is nothing but one or more stickers
+func isStickerMessage(m *discordgo.Message) bool {
+	return len(m.StickerItems) > 0 && len(strings.TrimSpace(m.Content)) == 0 && len(m.Attachments) == 0
+}
+
+// Tracks how often a user posts forwarded or sticker-only messages. Neither carries any text of its own, so a flood of
+// them slips past the message filters. Returns true if this message pushed the user over the limit.
+func trackForwardSpam(info *GuildInfo, m *discordgo.Message, track *userPressure, tm time.Time, exempt map[string]bool) bool {
+	count := info.config.Spam.ForwardCount
+	if count <= 0 || exempt["forwards"] || (!isForwardedMessage(m) && !isStickerMessage(m)) {
+		return false
+	}
+	if track.forwards == nil || len(track.forwards.times) != count {
+		track.forwards = &SaturationLimit{}
+		track.forwards.resize(count)
+	}
+	track.forwards.append(tm.Unix())
+	if !track.forwards.checkafter(count-1, info.config.Spam.ForwardTime) {
+		return false
+	}
+	track.forwards = nil // Start counting from scratch, so the next one doesn't immediately count as spam again
+	return true
+}
+
+// Returns true if the message was deleted because it pushed the user over the forwarded or sticker message limit
+func checkForwardSpam(info *GuildInfo, m *discordgo.Message, track *userPressure, tm time.Time, exempt map[string]bool) bool {
+	if !trackForwardSpam(info, m, track, tm, exempt) {
+		return false
+	}
+	count := info.config.Spam.ForwardCount
+	reason := fmt.Sprintf("posting %v forwarded or sticker messages in %v seconds", count, info.config.Spam.ForwardTime)
+	if info.config.Spam.ForwardSilence {
+		killSpammer(m.Author, info, m, reason, track.pressure, track.pressure)
+		return true
+	}
+	deleteMessage(m.ChannelID, m.ID)
+	info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+m.Author.ID+"> was caught "+reason+" in <#"+m.ChannelID+">, so their last one was deleted.")
+	info.LogTo(LogModeration, m.Author.Username, " was caught ", reason, " in #", getChannelName(m.ChannelID), ".")
+	return true
+}
+
+// Tracks how fast a user adds reactions, since raids that flood messages with reactions never trip the message filters.
+// Returns the reactions to remove if this one pushed the user over the limit. Must be called with the tracker locked.
+func checkReactionSpam(info *GuildInfo, r *discordgo.MessageReaction, track *userPressure) []reactionRef {
+	count := info.config.Spam.ReactionCount
+	if track.reactions == nil || len(track.reactions.times) != count {
+		track.reactions = &SaturationLimit{}
+		track.reactions.resize(count)
+		track.reacted = nil
+	}
+	now := time.Now().UTC().Unix()
+	track.reactions.append(now)
+	track.reacted = append(track.reacted, reactionRef{r.ChannelID, r.MessageID, r.Emoji.APIName()})
+	if len(track.reacted) > count {
+		track.reacted = track.reacted[len(track.reacted)-count:]
+	}
+	if !track.reactions.checkafter(count-1, info.config.Spam.ReactionTime) {
+		return nil
+	}
+	removed := track.reacted
+	track.reactions = nil // Start counting from scratch, so the next reaction doesn't immediately count as spam again
+	track.reacted = nil
+	return removed
+}
+
+// Removes reaction spam and tells the moderators which messages were targeted
+func punishReactionSpam(info *GuildInfo, m *discordgo.Member, reactions []reactionRef) {
+	targets := []string{}
+	seen := make(map[string]bool)
+	for _, v := range reactions {
+		if !seen[v.message] {
+			seen[v.message] = true
+			targets = append(targets, messageLink(info, v.channel, v.message))
+		}
+	}
+	reason := fmt.Sprintf("adding %v reactions in %v seconds", len(reactions), info.config.Spam.ReactionTime)
+	name := m.User.Username + " (" + m.User.ID + ")"
+	if info.config.Spam.ReactionSilence {
+		if silenceMember(m.User, info) == 0 {
+			if sb.db.CheckStatus() {
+				info.AddOffense(Offense{Type: OFFENSE_SPAM, User: SBatoi(m.User.ID), Moderator: SBatoi(sb.SelfID), Reason: reason, Timestamp: time.Now().UTC()})
+			}
+			info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+m.User.ID+"> was silenced for "+reason+". Please investigate.\n"+strings.Join(targets, "\n"))
+		}
+	} else {
+		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+m.User.ID+"> was caught "+reason+", so I removed their reactions.\n"+strings.Join(targets, "\n"))
+	}
+	info.LogTo(LogModeration, "Removing reaction spam from ", name, " after ", reason, " on ", strings.Join(targets, ", "))
+
+	failed := 0
+	for _, v := range reactions {
+		err := CallAPI("MessageReactionRemove", func() error { return sb.dg.MessageReactionRemove(v.channel, v.message, v.emoji, m.User.ID) })
+		if err != nil && ClassifyAPIError(err) != APIErrorNotFound {
+			failed++
+		}
+	}
+	if failed > 0 {
+		info.LogTo(LogModeration, "Couldn't remove ", Pluralize(int64(failed), " reaction"), " from ", name, ". I need the Manage Messages permission to remove other people's reactions.")
 	}
 }
 
-// Gets the pressure generated from an isolated message, ignoring the context.
-func getPressure(info *GuildInfo, m *discordgo.Message, edited bool) float32 {
-	p := info.config.Spam.ImagePressure * float32(len(m.Attachments))
-	p += info.config.Spam.PingPressure * float32(len(m.Mentions))
-	p += info.config.Spam.ImagePressure * float32(len(m.Embeds))
-	p += info.config.Spam.LengthPressure * float32(len(m.Content))
-	p += info.config.Spam.LinePressure * float32(strings.Count(m.Content, "\n"))
-	p += info.config.Spam.BasePressure
+// Gets the pressure generated from an isolated message, ignoring the context and any filters the message is exempt from.
+func getPressure(info *GuildInfo, m *discordgo.Message, edited bool, exempt map[string]bool) float32 {
+	p := info.config.Spam.BasePressure
+	if !exempt["images"] {
+		p += info.config.Spam.ImagePressure * float32(len(m.Attachments)+len(m.Embeds))
+	}
+	if !exempt["pings"] {
+		p += info.config.Spam.PingPressure * float32(len(m.Mentions))
+	}
+	if !exempt["length"] {
+		p += info.config.Spam.LengthPressure * float32(len(m.Content))
+	}
+	if !exempt["lines"] {
+		p += info.config.Spam.LinePressure * float32(strings.Count(m.Content, "\n"))
+	}
 	if edited { // Editing a message contributes only the square root of the total (so you can edit a post with lots of pictures and not get instabanned)
 		p = float32(math.Sqrt(float64(p)))
 	}
 	return p
 }
 
+// Hashes message content for repeat detection, ignoring case
+func contentHash(content string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(strings.ToLower(content)))
+	return hash.Sum64()
+}
+
+// Scales pressure up in channels with a lower spam.maxchannelpressure, which is the same as lowering the limit there
+func channelPressure(info *GuildInfo, channel string, p float32) float32 {
+	override, ok := info.config.Spam.MaxChannelPressure[SBatoi(channel)]
+	if ok && override > 0.0 {
+		p *= (info.config.Spam.MaxPressure / override)
+	}
+	return p
+}
+
+// Applies however much pressure decayed over the given number of milliseconds since a user's last message
+func decayPressure(info *GuildInfo, pressure float32, interval int64) float32 {
+	pressure -= info.config.Spam.BasePressure * (float32(interval) / (info.config.Spam.PressureDecay * 1000.0))
+	if pressure < 0 {
+		return 0
+	}
+	return pressure
+}
+
 func (w *SpamModule) checkSpam(info *GuildInfo, m *discordgo.Message, edited bool) bool {
 	if m.Author != nil {
 		if info.UserHasRole(m.Author.ID, SBitoa(info.config.Spam.SilentRole)) && SBatoi(m.ChannelID) != info.config.Users.WelcomeChannel {
-			sb.dg.ChannelMessageDelete(m.ChannelID, m.ID)
+			deleteMessage(m.ChannelID, m.ID)
 			return true
 		}
-		if (info.config.Basic.AlertRole != 0 && info.UserHasRole(m.Author.ID, SBitoa(info.config.Basic.AlertRole))) ||
+		if info.HasModRole(m.Author.ID) ||
 			(info.config.Spam.IgnoreRole != 0 && info.UserHasRole(m.Author.ID, SBitoa(info.config.Spam.IgnoreRole))) ||
 			m.Author.Bot {
 			return false
 		}
+		exempt := spamExemptions(info, m)
+		if exempt["all"] {
+			return false
+		}
 		id := SBatoi(m.Author.ID)
 		// In newer discordgo, Timestamp is already time.Time
 		tm := m.Timestamp
@@ -206,7 +529,8 @@ func (w *SpamModule) checkSpam(info *GuildInfo, m *discordgo.Message, edited boo
 			track = &userPressure{
 				pressure:    0,
 				lastmessage: tm.Unix()*1000 + int64(tm.Nanosecond()/1000000),
-				lastcache:   "",
+				lasthash:    0,
+				counted:     make(map[string]float32),
 			}
 			w.tracker[id] = track
 		}
@@ -217,34 +541,69 @@ func (w *SpamModule) checkSpam(info *GuildInfo, m *discordgo.Message, edited boo
 		track.Lock()
 		defer track.Unlock()
 
-		p := getPressure(info, m, edited)
-		if len(m.Content) > 0 && strings.ToLower(m.Content) == track.lastcache {
-			p += info.config.Spam.RepeatPressure
+		var p float32
+		if prior, ok := track.counted[m.ID]; edited && ok {
+			// We already counted this message when it was posted, so only count whatever the edit added to it
+			p = getPressure(info, m, false, exempt) - prior
+			if p <= 0 {
+				return false
+			}
+			track.counted[m.ID] = prior + p
+		} else {
+			p = getPressure(info, m, edited, exempt)
+			hash := contentHash(m.Content)
+			if !edited && len(m.Content) > 0 && hash == track.lasthash && !exempt["repeat"] {
+				p += info.config.Spam.RepeatPressure
+			}
+			if !edited {
+				track.lasthash = hash
+			}
+			if info.config.Spam.EditGrace > 0 {
+				now := time.Now().UTC()
+				for k := range track.counted {
+					if now.Sub(snowflakeTime(SBatoi(k))) > time.Duration(info.config.Spam.EditGrace)*time.Second {
+						delete(track.counted, k)
+					}
+				}
+				track.counted[m.ID] = p
+			}
+		}
+		if checkRolePings(info, m, track, edited, exempt) {
+			return true
+		}
+		if !edited && checkForwardSpam(info, m, track, tm, exempt) {
+			return true
+		}
+		if !edited && isShortMessage(info, m, exempt) {
+			if track.short == nil || len(track.short.times) != info.config.Spam.ShortCount {
+				track.short = &SaturationLimit{}
+				track.short.resize(info.config.Spam.ShortCount)
+			}
+			track.short.append(tm.Unix())
+			if track.short.checkafter(info.config.Spam.ShortCount-1, info.config.Spam.ShortTime) {
+				track.short = nil // Start counting from scratch, so the next short message doesn't immediately count as flooding again
+				killSpammer(m.Author, info, m, "flooding short or empty messages", track.pressure, track.pressure)
+				return true
+			}
 		}
-		track.lastcache = strings.ToLower(m.Content)
 		last := track.lastmessage
 		track.lastmessage = tm.Unix()*1000 + int64(tm.Nanosecond()/1000000)
 		if track.lastmessage < last { // This can happen because discord has a bad habit of re-sending timestamps if anything so much as touches a message
 			track.lastmessage = last
-			return false // An invalid timestamp is never spam
+			return checkSpacerFlood(info, m, exempt) // An invalid timestamp never adds pressure
 		}
 		interval := track.lastmessage - last
 
-		override, ok := info.config.Spam.MaxChannelPressure[SBatoi(m.ChannelID)]
-		if ok && override > 0.0 {
-			p *= (info.config.Spam.MaxPressure / override)
-		}
+		p = channelPressure(info, m.ChannelID, p)
 		oldpressure := track.pressure
-		track.pressure -= info.config.Spam.BasePressure * (float32(interval) / (info.config.Spam.PressureDecay * 1000.0))
-		if track.pressure < 0 {
-			track.pressure = 0
-		}
-		track.pressure += p
+		track.pressure = decayPressure(info, track.pressure, interval) + p
 		//fmt.Println("Current Pressure: ", track.pressure)
 		if track.pressure > info.config.Spam.MaxPressure {
 			killSpammer(m.Author, info, m, "spamming too many messages", oldpressure, track.pressure)
 			return true
 		}
+		// Blank lines still count towards the pressure first, so someone who keeps flooding them is silenced like any other spammer
+		return checkSpacerFlood(info, m, exempt)
 	}
 	return false
 }
@@ -254,6 +613,52 @@ func (w *SpamModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
 	w.checkSpam(info, m, false)
 }
 
+// OnMessageUpdate discord hook
+func (w *SpamModule) OnMessageUpdate(info *GuildInfo, m *discordgo.Message) {
+	if info.config.Spam.EditGrace <= 0 || time.Now().UTC().Sub(snowflakeTime(SBatoi(m.ID))) > time.Duration(info.config.Spam.EditGrace)*time.Second {
+		return // Only re-check edits made shortly after the message was posted
+	}
+	w.checkSpam(info, m, true)
+}
+
+// OnMessageReactionAdd discord hook
+func (w *SpamModule) OnMessageReactionAdd(info *GuildInfo, r *discordgo.MessageReaction) {
+	if info.config.Spam.ReactionCount <= 0 || r.UserID == sb.SelfID {
+		return
+	}
+	if info.HasModRole(r.UserID) || (info.config.Spam.IgnoreRole != 0 && info.UserHasRole(r.UserID, SBitoa(info.config.Spam.IgnoreRole))) {
+		return
+	}
+	m, err := info.GetMember(r.UserID)
+	if err != nil || m.User == nil || m.User.Bot {
+		return
+	}
+	exempt := spamExemptions(info, &discordgo.Message{ChannelID: r.ChannelID, Member: m})
+	if exempt["all"] || exempt["reactions"] {
+		return
+	}
+
+	id := SBatoi(r.UserID)
+	w.Lock()
+	track, ok := w.tracker[id]
+	if !ok {
+		now := time.Now().UTC()
+		track = &userPressure{
+			lastmessage: now.Unix()*1000 + int64(now.Nanosecond()/1000000),
+			counted:     make(map[string]float32),
+		}
+		w.tracker[id] = track
+	}
+	w.Unlock()
+
+	track.Lock()
+	reactions := checkReactionSpam(info, r, track)
+	track.Unlock()
+	if len(reactions) > 0 {
+		go punishReactionSpam(info, m, reactions)
+	}
+}
+
 // OnCommand discord hook
 func (w *SpamModule) OnCommand(info *GuildInfo, m *discordgo.Message) bool {
 	return w.checkSpam(info, m, false)
@@ -306,26 +711,32 @@ func (w *SpamModule) checkRaid(info *GuildInfo, m *discordgo.Member) {
 			ch, _ = sb.DebugChannels[info.ID]
 		}
 		info.SendMessage(ch, "<@&"+SBitoa(info.config.Basic.AlertRole)+"> Possible Raid Detected! Use `"+info.config.Basic.CommandPrefix+"autosilence all` to silence them!\n```"+strings.Join(s, "\n")+"```")
-		if info.config.Spam.LockdownDuration > 0 {
-			if info.lockdown == -1 { // Only engage lockdown if it wasn't already engaged
-				guild, err := sb.dg.State.Guild(info.ID)
-				if err != nil {
-					info.lockdown = discordgo.VerificationLevelHigh
-				} else {
-					info.lockdown = guild.VerificationLevel
-				}
-				level := discordgo.VerificationLevelHigh
-				g := &discordgo.GuildParams{VerificationLevel: &level}
-				_, err = sb.dg.GuildEdit(info.ID, g)
-				if err != nil {
-					info.SendMessage(ch, "Could not engage lockdown! Make sure you've given Sweetie Bot the Manage Server permission, or disable the lockdown entirely via `"+info.config.Basic.CommandPrefix+"setconfig spam.lockdownduration 0`.")
-				} else {
-					info.SendMessage(ch, fmt.Sprintf("Lockdown engaged! Server verification level will be reset in %v seconds. This lockdown can be manually ended via `"+info.config.Basic.CommandPrefix+"autosilence off/alert/log`.", info.config.Spam.LockdownDuration))
-				}
+		EngageLockdown(info, ch)
+	}
+}
+
+// EngageLockdown raises the server's verification level for spam.lockdownduration seconds, if lockdown is enabled.
+// If lockdown is already engaged, its timer is reset instead.
+func EngageLockdown(info *GuildInfo, ch string) {
+	if info.config.Spam.LockdownDuration > 0 {
+		if info.lockdown == -1 { // Only engage lockdown if it wasn't already engaged
+			guild, err := sb.dg.State.Guild(info.ID)
+			if err != nil {
+				info.lockdown = discordgo.VerificationLevelHigh
+			} else {
+				info.lockdown = guild.VerificationLevel
+			}
+			level := discordgo.VerificationLevelHigh
+			g := &discordgo.GuildParams{VerificationLevel: &level}
+			_, err = sb.dg.GuildEdit(info.ID, g)
+			if err != nil {
+				info.SendMessage(ch, "Could not engage lockdown! Make sure you've given Sweetie Bot the Manage Server permission, or disable the lockdown entirely via `"+info.config.Basic.CommandPrefix+"setconfig spam.lockdownduration 0`.")
+			} else {
+				info.SendMessage(ch, fmt.Sprintf("Lockdown engaged! Server verification level will be reset in %v seconds. This lockdown can be manually ended via `"+info.config.Basic.CommandPrefix+"autosilence off/alert/log`.", info.config.Spam.LockdownDuration))
 			}
-			// Otherwise just reset the timer
-			info.lastlockdown = time.Now().UTC()
 		}
+		// Otherwise just reset the timer
+		info.lastlockdown = time.Now().UTC()
 	}
 }
 
@@ -336,21 +747,22 @@ func (w *SpamModule) OnGuildMemberAdd(info *GuildInfo, m *discordgo.Member) {
 		silenceMember(m.User, info)
 		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "<@"+m.User.ID+"> "+created+" joined the server and was autosilenced. Please vet them before unsilencing them.")
 		if len(info.config.Users.WelcomeMessage) > 0 {
-			info.SendMessage(SBitoa(info.config.Users.WelcomeChannel), "<@"+m.User.ID+"> "+info.config.Users.WelcomeMessage)
+			sendWelcomeMessage(info, m.User)
 		}
 	}
 	if info.config.Spam.AutoSilence == -1 {
 		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "<@"+m.User.ID+"> "+created+" joined the server.")
 	}
 	if info.config.Spam.AutoSilence == -2 {
-		info.SendMessage(SBitoa(info.config.Log.Channel), "<@"+m.User.ID+"> "+created+" joined the server.")
+		info.SendMessage(SBitoa(info.logChannel(LogJoins)), "<@"+m.User.ID+"> "+created+" joined the server.")
 	}
 	w.checkRaid(info, m)
+	w.checkFingerprint(info, m)
 }
 
 // OnGuildMemberUpdate discord hook
-func (w *SpamModule) OnGuildMemberUpdate(info *GuildInfo, m *discordgo.Member) {
-	w.checkRaid(info, m)
+func (w *SpamModule) OnGuildMemberUpdate(info *GuildInfo, m *discordgo.GuildMemberUpdate) {
+	w.checkRaid(info, m.Member)
 }
 
 // OnGuildMemberRemove discord hook
@@ -360,7 +772,7 @@ func (w *SpamModule) OnGuildMemberRemove(info *GuildInfo, m *discordgo.Member) {
 		if info.config.Spam.AutoSilence == -1 || info.config.Spam.AutoSilence >= 2 {
 			info.SendMessage(SBitoa(info.config.Basic.ModChannel), text)
 		} else if info.config.Spam.AutoSilence == -2 {
-			info.SendMessage(SBitoa(info.config.Log.Channel), text)
+			info.SendMessage(SBitoa(info.logChannel(LogJoins)), text)
 		}
 	}
 }
@@ -432,6 +844,10 @@ func (c *autoSilenceCommand) Usage(info *GuildInfo) *CommandUsage {
 }
 func (c *autoSilenceCommand) UsageShort() string { return "Toggle auto silence." }
 
+// Wiping at least this many messages, or looking back at least this many seconds, has to be confirmed first
+const wipeConfirmMessages = 100
+const wipeConfirmSeconds = 3600
+
 type wipeCommand struct {
 }
 
@@ -466,6 +882,7 @@ func (c *wipeCommand) WipeMessages(ch string, num int, seconds int) (int, error)
 		if len(IDs) == 0 {
 			break
 		}
+		markSelfDeleted(IDs...)
 		sb.dg.ChannelMessagesBulkDelete(ch, IDs)
 		lastid = IDs[len(IDs)-1]
 	}
@@ -481,7 +898,17 @@ func (c *wipeCommand) Process(args []string, msg *discordgo.Message, indices []i
 	if err != nil || num <= 0 {
 		return "```There's no point deleting 0 messages!.```", false, nil
 	}
-	if len(args) > 2 && strings.ToLower(args[2]) == "messages" {
+	bymessages := len(args) > 2 && strings.ToLower(args[2]) == "messages"
+	if bymessages && num >= wipeConfirmMessages {
+		if !Confirm(info, msg.ChannelID, msg.Author.ID, fmt.Sprintf("**This will delete the last %v messages in <#%s>.** Are you sure?", num, ch)) {
+			return "```Cancelled the wipe.```", false, nil
+		}
+	} else if !bymessages && num >= wipeConfirmSeconds {
+		if !Confirm(info, msg.ChannelID, msg.Author.ID, fmt.Sprintf("**This will delete every message sent in <#%s> in the last %s.** Are you sure?", ch, TimeDiff(time.Duration(num)*time.Second))) {
+			return "```Cancelled the wipe.```", false, nil
+		}
+	}
+	if bymessages {
 		num, err = c.WipeMessages(ch, num, 0)
 	} else {
 		num, err = c.WipeMessages(ch, 9999, num)
@@ -493,7 +920,7 @@ func (c *wipeCommand) Process(args []string, msg *discordgo.Message, indices []i
 }
 func (c *wipeCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Removes all messages in a channel sent within the last N seconds, or simply removes the last N messages if \"messages\" is appended.",
+		Desc: "Removes all messages in a channel sent within the last N seconds, or simply removes the last N messages if \"messages\" is appended. Wiping " + strconv.Itoa(wipeConfirmMessages) + " or more messages, or looking back " + TimeDiff(wipeConfirmSeconds*time.Second) + " or more, has to be confirmed first.",
 		Params: []CommandUsageParam{
 			{Name: "channel", Desc: "The channel to delete from. You must use the #channel format so discord actually highlights the channel, otherwise it won't work.", Optional: false},
 			{Name: "seconds", Desc: "Specifies the number of seconds to look back. The command deletes all messages sent up to this many seconds ago.", Optional: false},
@@ -539,6 +966,47 @@ func (c *getPressureCommand) Process(args []string, msg *discordgo.Message, indi
 	u.Unlock()
 	return fmt.Sprint(pressure), false, nil
 }
+
+// Describes the state of a user's spam limiters as rows of the limiter table
+func (w *SpamModule) limiterRows(info *GuildInfo, user uint64) [][]string {
+	w.Lock()
+	track, ok := w.tracker[user]
+	w.Unlock()
+	name := getUserName(user, info)
+	if !ok {
+		return [][]string{{name, "not tracked", ""}}
+	}
+	spam := info.config.Spam
+	now := time.Now().UTC().Unix()
+	track.Lock()
+	defer track.Unlock()
+	rows := [][]string{{name + " pressure", fmt.Sprint(track.pressure), fmt.Sprint("silenced at ", spam.MaxPressure)}}
+	limit := func(label string, l *SaturationLimit, count int, period int64) {
+		if l != nil && count > 0 {
+			n, _ := l.Snapshot(now - period)
+			rows = append(rows, []string{name + " " + label, fmt.Sprintf("%v/%v", n, count), "per " + TimeDiff(time.Duration(period)*time.Second)})
+		}
+	}
+	limit("short messages", track.short, spam.ShortCount, spam.ShortTime)
+	limit("forwards", track.forwards, spam.ForwardCount, spam.ForwardTime)
+	limit("reactions", track.reactions, spam.ReactionCount, spam.ReactionTime)
+	rolename := func(role string) string {
+		if r, err := sb.dg.State.Role(info.ID, role); err == nil {
+			return r.Name
+		}
+		return role
+	}
+	for role, l := range track.rolepings {
+		limit("pings of "+rolename(role), l, spam.RolePingCount, spam.RolePingTime)
+	}
+	for role, until := range track.roleblocks {
+		if until > now {
+			rows = append(rows, []string{name + " blocked from " + rolename(role), TimeDiff(time.Duration(until-now)*time.Second) + " left", ""})
+		}
+	}
+	return rows
+}
+
 func (c *getPressureCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
 		Desc: "Restricted command that gets the current spam pressure of a user.",
@@ -584,12 +1052,15 @@ func (c *banRaidCommand) Process(args []string, msg *discordgo.Message, indices
 	}
 	reason := fmt.Sprintf("Banned by %s#%s via the !banraid command.", msg.Author.Username, msg.Author.Discriminator)
 	users := c.s.getRaidUsers(info)
+	if !Confirm(info, msg.ChannelID, msg.Author.ID, fmt.Sprintf("**This will ban %s from the most recent raid.** Are you sure?", Pluralize(int64(len(users)), " user"))) {
+		return "```Cancelled the raid ban.```", false, nil
+	}
 	for _, v := range users {
 		sb.dg.GuildBanCreateWithReason(info.ID, v.ID, reason, 1)
 	}
 	return fmt.Sprintf("```Banned %v users. The ban log will reflect who ran this command.```", len(users)), false, nil
 }
 func (c *banRaidCommand) Usage(info *GuildInfo) *CommandUsage {
-	return &CommandUsage{Desc: "Bans all users that are considered part of the most recent raid, if there was one. Use !getraid to check who will be banned before using this command."}
+	return &CommandUsage{Desc: "Bans all users that are considered part of the most recent raid, if there was one. Use !getraid to check who will be banned before using this command. You'll be asked to confirm before anyone is banned."}
 }
 func (c *banRaidCommand) UsageShort() string { return "Bans all users in most recent raid." }
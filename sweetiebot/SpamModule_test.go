@@ -0,0 +1,141 @@
+package sweetiebot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCountSpacerLines(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"plain text", "hello\nworld", 0},
+		{"empty lines", "a\n\n\nb", 2},
+		{"spaces and tabs", "a\n  \n\t\nb", 2},
+		{"zero width space", "a\n\u200B\nb", 1},
+		{"zero width joiner and word joiner", "a\n\u200D\u2060\nb", 1},
+		{"hangul filler", "a\n\u3164\nb", 1},
+		{"halfwidth hangul filler", "a\n\uFFA0\nb", 1},
+		{"hangul choseong filler", "a\n\u115F\u1160\nb", 1},
+		{"braille blank", "a\n\u2800\nb", 1},
+		{"underscore spacer", "a\n_ _\nb", 1},
+		{"markdown only", "a\n** **\n~~ ~~\n> \nb", 3},
+		{"crlf", "a\r\n\r\n\r\nb", 2},
+		{"lone carriage returns", "a\r\r\rb", 2},
+		{"line separator", "a\u2028\u2028\u2028b", 2},
+		{"paragraph separator", "a\u2029\u2029b", 1},
+		{"code block", "a\n```\n\n\n\n```\nb", 1},
+		{"code block with language", "```go\n\n\n\nfunc main() {}\n```", 1},
+		{"unclosed code block", "```\n\n\nb", 2},
+		{"text with visible characters", "a\n.\n-\nb", 0},
+	}
+	for _, c := range cases {
+		if got := countSpacerLines(c.content); got != c.want {
+			t.Errorf("%s: countSpacerLines(%q) = %v, want %v", c.name, c.content, got, c.want)
+		}
+	}
+}
+
+func TestSpacerLinesThreshold(t *testing.T) {
+	const limit = 30
+	cases := []struct {
+		name    string
+		content string
+		flood   bool
+	}{
+		{"just below", "a" + strings.Repeat("\n", limit) + "b", false},
+		{"at the limit", "a" + strings.Repeat("\n", limit+1) + "b", true},
+		{"just above with invisible lines", "a" + strings.Repeat("\n\u200B", limit+1) + "\nb", true},
+		{"just below with invisible lines", "a" + strings.Repeat("\n\u3164", limit-1) + "\nb", false},
+		{"above but inside a code block", "```" + strings.Repeat("\n", limit*2) + "```", false},
+		{"crlf just above", "a" + strings.Repeat("\r\n", limit+1) + "b", true},
+	}
+	for _, c := range cases {
+		if got := countSpacerLines(c.content) >= limit; got != c.flood {
+			t.Errorf("%s: %v blank lines, flood = %v, want %v", c.name, countSpacerLines(c.content), got, c.flood)
+		}
+	}
+}
+
+func TestIsSpacerRune(t *testing.T) {
+	for _, r := range []rune{' ', '\t', '_', '*', '~', '|', '>', '\u200B', '\u200D', '\u2060', '\uFEFF', '\u3164', '\uFFA0', '\u115F', '\u1160', '\u2800', '\u00A0'} {
+		if !isSpacerRune(r) {
+			t.Errorf("isSpacerRune(%U) = false, want true", r)
+		}
+	}
+	for _, r := range []rune{'a', '.', '-', '0', 'ㄱ', '⠁', '😀'} {
+		if isSpacerRune(r) {
+			t.Errorf("isSpacerRune(%U) = true, want false", r)
+		}
+	}
+}
+
+func TestForwardAndStickerMessages(t *testing.T) {
+	ref := &discordgo.MessageReference{MessageID: "1", ChannelID: "2"}
+	sticker := []*discordgo.StickerItem{{ID: "3", Name: "wave"}}
+	cases := []struct {
+		name    string
+		m       *discordgo.Message
+		forward bool
+		sticker bool
+	}{
+		{"plain message", &discordgo.Message{Content: "hi"}, false, false},
+		{"forward", &discordgo.Message{MessageReference: ref}, true, false},
+		{"reply", &discordgo.Message{MessageReference: ref, Content: "agreed"}, false, false},
+		{"crosspost", &discordgo.Message{MessageReference: ref, Flags: discordgo.MessageFlagsIsCrossPosted}, false, false},
+		{"webhook", &discordgo.Message{MessageReference: ref, WebhookID: "4"}, false, false},
+		{"pin notice", &discordgo.Message{MessageReference: ref, Type: discordgo.MessageTypeChannelPinnedMessage}, false, false},
+		{"sticker only", &discordgo.Message{StickerItems: sticker}, false, true},
+		{"sticker with whitespace", &discordgo.Message{StickerItems: sticker, Content: " \n"}, false, true},
+		{"sticker with text", &discordgo.Message{StickerItems: sticker, Content: "hello"}, false, false},
+		{"sticker with attachment", &discordgo.Message{StickerItems: sticker, Attachments: []*discordgo.MessageAttachment{{ID: "5"}}}, false, false},
+	}
+	for _, c := range cases {
+		if got := isForwardedMessage(c.m); got != c.forward {
+			t.Errorf("%s: isForwardedMessage = %v, want %v", c.name, got, c.forward)
+		}
+		if got := isStickerMessage(c.m); got != c.sticker {
+			t.Errorf("%s: isStickerMessage = %v, want %v", c.name, got, c.sticker)
+		}
+	}
+}
+
+func TestTrackForwardSpam(t *testing.T) {
+	forward := &discordgo.Message{MessageReference: &discordgo.MessageReference{MessageID: "1"}}
+	sticker := &discordgo.Message{StickerItems: []*discordgo.StickerItem{{ID: "2"}}}
+	text := &discordgo.Message{Content: "hello"}
+	cases := []struct {
+		name     string
+		messages []*discordgo.Message
+		gap      time.Duration // time between each message
+		exempt   bool
+		want     []bool // whether each message trips the limit
+	}{
+		{"forwards inside the window", []*discordgo.Message{forward, forward, forward, forward}, time.Second, false, []bool{false, false, false, true}},
+		{"stickers inside the window", []*discordgo.Message{sticker, sticker, sticker, sticker}, 2 * time.Second, false, []bool{false, false, false, true}},
+		{"mixed inside the window", []*discordgo.Message{forward, sticker, forward, sticker}, time.Second, false, []bool{false, false, false, true}},
+		{"forwards outside the window", []*discordgo.Message{forward, forward, forward, forward}, 4 * time.Second, false, []bool{false, false, false, false}},
+		{"stickers outside the window", []*discordgo.Message{sticker, sticker, sticker, sticker}, 4 * time.Second, false, []bool{false, false, false, false}},
+		{"text doesn't count", []*discordgo.Message{forward, text, forward, text, forward}, time.Second, false, []bool{false, false, false, false, false}},
+		{"exempt", []*discordgo.Message{forward, forward, forward, forward}, time.Second, true, []bool{false, false, false, false}},
+		{"counting restarts after a hit", []*discordgo.Message{forward, forward, forward, forward, forward}, time.Second, false, []bool{false, false, false, true, false}},
+	}
+	info := &GuildInfo{}
+	info.config.Spam.ForwardCount = 4
+	info.config.Spam.ForwardTime = 10
+	for _, c := range cases {
+		track := &userPressure{}
+		tm := time.Now().UTC()
+		for i, m := range c.messages {
+			if got := trackForwardSpam(info, m, track, tm, map[string]bool{"forwards": c.exempt}); got != c.want[i] {
+				t.Errorf("%s: message %v = %v, want %v", c.name, i, got, c.want[i])
+			}
+			tm = tm.Add(c.gap)
+		}
+	}
+}
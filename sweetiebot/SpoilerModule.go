@@ -34,7 +34,7 @@ func (w *SpoilerModule) hasSpoiler(info *GuildInfo, m *discordgo.Message) bool {
 		}
 	}
 	if w.spoilerban != nil && w.spoilerban.MatchString(strings.ToLower(m.Content)) {
-		sb.dg.ChannelMessageDelete(m.ChannelID, m.ID)
+		deleteMessage(m.ChannelID, m.ID)
 		if RateLimit(&w.lastmsg, info.config.Log.Cooldown) {
 			info.SendMessage(m.ChannelID, "[](/nospoilers) ```NO SPOILERS! Posting spoilers is a bannable offense. All discussion about new and future content MUST be in #mylittlespoilers.```")
 		}
@@ -55,7 +55,7 @@ func (w *SpoilerModule) OnMessageUpdate(info *GuildInfo, m *discordgo.Message) {
 
 // OnCommand discord hook
 func (w *SpoilerModule) OnCommand(info *GuildInfo, m *discordgo.Message) bool {
-	if info.UserHasRole(m.Author.ID, SBitoa(info.config.Basic.AlertRole)) {
+	if info.HasModRole(m.Author.ID) {
 		return false
 	} // If we are a mod, always allow us to run this command, otherwise we can't unspoil things
 	return w.hasSpoiler(info, m)
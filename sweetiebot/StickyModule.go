@@ -0,0 +1,229 @@
+package sweetiebot
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type stickyMessage struct {
+	sync.Mutex
+	content string
+	current string // ID of the posted copy, or empty if it hasn't been posted yet
+	count   int    // messages posted since the sticky was last reposted
+	last    time.Time
+	timer   *time.Timer
+}
+
+// StickyModule keeps a message at the bottom of a channel by reposting it once other messages have pushed it up. Reposts
+// wait for the channel to go quiet for sticky.delay seconds, or for sticky.messages new messages, whichever comes first.
+type StickyModule struct {
+	lock     sync.Mutex
+	stickies map[string]*stickyMessage // by channel
+}
+
+// Reposting more often than this in a busy channel just spams it
+const stickyMinInterval = 5 * time.Second
+
+// Name of the module
+func (w *StickyModule) Name() string {
+	return "Sticky"
+}
+
+// Commands in the module
+func (w *StickyModule) Commands() []Command {
+	return []Command{
+		&stickyMessageCommand{w},
+		&unstickCommand{w},
+	}
+}
+
+// Description of the module
+func (w *StickyModule) Description() string {
+	return "Keeps a message at the bottom of a channel, reposting it after new messages push it up. Use `!stickymessage` to set one up and `!unstick` to remove it."
+}
+
+func (w *StickyModule) load(info *GuildInfo) {
+	w.stickies = make(map[string]*stickyMessage)
+	if sb.db.status.get() {
+		for channel, s := range sb.db.GetStickies(SBatoi(info.ID)) {
+			w.stickies[SBitoa(channel)] = s
+		}
+	}
+}
+
+func (w *StickyModule) get(channel string) *stickyMessage {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.stickies[channel]
+}
+
+// Deletes the old copy of a sticky message and posts it again at the bottom of the channel
+func (w *StickyModule) repost(info *GuildInfo, channel string, s *stickyMessage) {
+	s.Lock()
+	defer s.Unlock()
+	if w.get(channel) != s {
+		return // It was unstuck or replaced while we were waiting
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.current) > 0 {
+		sb.dg.ChannelMessageDelete(channel, s.current)
+	}
+	msg, err := sb.dg.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+		Content:         "__**Stickied Message:**__\n" + s.content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	s.count = 0
+	s.last = time.Now().UTC()
+	if err != nil {
+		s.current = ""
+		info.Log("Failed to repost the sticky message in #", getChannelName(channel), ": ", err.Error())
+		return
+	}
+	s.current = msg.ID
+	if sb.db.CheckStatus() {
+		sb.db.SetStickyCurrent(SBatoi(channel), SBatoi(msg.ID))
+	}
+}
+
+// OnMessageCreate discord hook
+func (w *StickyModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	s := w.get(m.ChannelID)
+	if s == nil {
+		return
+	}
+	s.Lock()
+	s.count++
+	now := s.count >= info.config.Sticky.Messages && info.config.Sticky.Messages > 0 && time.Now().UTC().Sub(s.last) >= stickyMinInterval
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	delay := time.Duration(info.config.Sticky.Delay) * time.Second
+	if delay < stickyMinInterval {
+		delay = stickyMinInterval
+	}
+	s.timer = time.AfterFunc(delay, func() { w.repost(info, m.ChannelID, s) })
+	s.Unlock()
+	if now {
+		go w.repost(info, m.ChannelID, s)
+	}
+}
+
+// Removes a channel's sticky message, returning false if it didn't have one
+func (w *StickyModule) unstick(channel string) bool {
+	w.lock.Lock()
+	s, ok := w.stickies[channel]
+	delete(w.stickies, channel)
+	w.lock.Unlock()
+	if !ok {
+		return false
+	}
+	s.Lock()
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if len(s.current) > 0 {
+		sb.dg.ChannelMessageDelete(channel, s.current)
+	}
+	s.Unlock()
+	if sb.db.CheckStatus() {
+		sb.db.RemoveSticky(SBatoi(channel))
+	}
+	return true
+}
+
+type stickyMessageCommand struct {
+	w *StickyModule
+}
+
+func (c *stickyMessageCommand) Name() string {
+	return "StickyMessage"
+}
+func (c *stickyMessageCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "channel", Desc: "The channel to keep the message in. If omitted, lists every sticky message.", Type: ArgChannel, Optional: true},
+		{Name: "message", Desc: "The message to keep at the bottom of the channel.", Optional: true, Variadic: true},
+	}
+}
+func (c *stickyMessageCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *stickyMessageCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !args.Has("channel") {
+		c.w.lock.Lock()
+		lines := make([]string, 0, len(c.w.stickies))
+		for k, v := range c.w.stickies {
+			lines = append(lines, "#"+getChannelName(k)+": "+v.content)
+		}
+		c.w.lock.Unlock()
+		if len(lines) == 0 {
+			return "```No channels have a sticky message.```", false, nil
+		}
+		sort.Strings(lines)
+		return "```" + PartialSanitize(strings.Join(lines, "\n")) + "```", len(lines) > 5, nil
+	}
+	if !args.Has("message") {
+		return "```You have to give the message to keep in the channel.```", false, nil
+	}
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	channel := args.String("channel")
+	content := args.String("message")
+	if len(content) > 1900 {
+		return "```Sticky messages can't be longer than 1900 characters.```", false, nil
+	}
+	c.w.unstick(channel)
+	s := &stickyMessage{content: content}
+	c.w.lock.Lock()
+	c.w.stickies[channel] = s
+	c.w.lock.Unlock()
+	sb.db.SetSticky(SBatoi(channel), SBatoi(info.ID), content)
+	c.w.repost(info, channel, s)
+	return "```The message will now be kept at the bottom of #" + getChannelName(channel) + ". Use " + info.config.Basic.CommandPrefix + "unstick to remove it.```", false, nil
+}
+func (c *stickyMessageCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Keeps a message at the bottom of a channel by deleting and reposting it whenever other messages push it up. It's reposted once the channel has been quiet for `sticky.delay` seconds, or after `sticky.messages` new messages, whichever comes first. Setting a new sticky message replaces the old one.",
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *stickyMessageCommand) UsageShort() string {
+	return "Keeps a message at the bottom of a channel."
+}
+
+type unstickCommand struct {
+	w *StickyModule
+}
+
+func (c *unstickCommand) Name() string {
+	return "Unstick"
+}
+func (c *unstickCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "channel", Desc: "The channel whose sticky message should be removed.", Type: ArgChannel},
+	}
+}
+func (c *unstickCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *unstickCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	channel := args.String("channel")
+	if !c.w.unstick(channel) {
+		return "```#" + getChannelName(channel) + " doesn't have a sticky message.```", false, nil
+	}
+	return "```Removed the sticky message from #" + getChannelName(channel) + ".```", false, nil
+}
+func (c *unstickCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Removes a channel's sticky message and deletes the posted copy.",
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *unstickCommand) UsageShort() string { return "Removes a sticky message." }
@@ -0,0 +1,444 @@
+package sweetiebot
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	suggestionOpen = iota
+	suggestionApproved
+	suggestionDenied
+	suggestionImplemented
+)
+
+var suggestionStatuses = []string{"open", "approved", "denied", "implemented"}
+var suggestionColors = []int{0x3e92e5, 0x43b581, 0xf04747, 0x9b59b6}
+
+// The word a moderator uses to give a suggestion each status, and the label of its button
+var suggestionActions = []string{"reopen", "approve", "deny", "implement"}
+var suggestionLabels = []string{"Reopen", "Approve", "Deny", "Implemented"}
+
+const defaultSuggestionUpvote = "👍"
+const defaultSuggestionDownvote = "👎"
+const suggestionsPerPage = 10
+
+// Votes are recounted this long after a reaction, so a burst of votes only fetches the message once
+const suggestionRecountDelay = 3 * time.Second
+
+// Attachments larger than this can't be carried over to the suggestion, so the original post is left alone instead
+const suggestionMaxAttachment = 8 * 1024 * 1024
+
+// SuggestionModule turns posts in the suggestion channel into suggestions that can be voted on, and that moderators
+// can approve, deny or mark as implemented.
+type SuggestionModule struct {
+	lock     sync.Mutex
+	recounts map[uint64]bool // suggestion messages waiting to be recounted
+}
+
+// Name of the module
+func (w *SuggestionModule) Name() string {
+	return "SuggestionBoard"
+}
+
+// Commands in the module
+func (w *SuggestionModule) Commands() []Command {
+	return []Command{
+		&suggestionCommand{},
+		&suggestionsCommand{},
+	}
+}
+
+// Description of the module
+func (w *SuggestionModule) Description() string {
+	return "Turns every post in `suggestions.channel` into a suggestion that can be voted on with `suggestions.upvote` and `suggestions.downvote`. Moderators can approve, deny or mark suggestions as implemented with the buttons on them or with `!suggestion`. Implemented suggestions are moved to `suggestions.implemented`, if it's set."
+}
+
+func suggestionUpvote(info *GuildInfo) string {
+	if len(info.config.Suggestions.Upvote) == 0 {
+		return defaultSuggestionUpvote
+	}
+	return info.config.Suggestions.Upvote
+}
+
+func suggestionDownvote(info *GuildInfo) string {
+	if len(info.config.Suggestions.Downvote) == 0 {
+		return defaultSuggestionDownvote
+	}
+	return info.config.Suggestions.Downvote
+}
+
+// Returns true if a reaction used the given emoji, which can be a unicode emoji, a custom emoji's ID or name:id
+func matchesEmoji(e discordgo.Emoji, emoji string) bool {
+	return e.Name == emoji || e.APIName() == emoji || (len(e.ID) > 0 && e.ID == emoji)
+}
+
+// Parses a suggestion status, either by name or by the word used to set it
+func parseSuggestionStatus(s string) (uint8, bool) {
+	s = strings.ToLower(s)
+	for i := range suggestionStatuses {
+		if s == suggestionStatuses[i] || s == suggestionActions[i] {
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
+
+func suggestionEmbed(info *GuildInfo, s *Suggestion) *discordgo.MessageEmbed {
+	status := strings.ToUpper(suggestionStatuses[s.Status][:1]) + suggestionStatuses[s.Status][1:]
+	if s.Status != suggestionOpen && s.Moderator != 0 {
+		status += " by " + getUserName(s.Moderator, info)
+		if len(s.Reason) > 0 {
+			status += ": " + s.Reason
+		}
+	}
+	embed := &discordgo.MessageEmbed{
+		Type:        "rich",
+		Title:       fmt.Sprintf("Suggestion #%v", s.ID),
+		Description: truncateRunes(s.Content, 4000),
+		Color:       suggestionColors[s.Status],
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Status", Value: truncateRunes(status, 1024), Inline: true},
+			{Name: "Votes", Value: fmt.Sprintf("%s %v · %s %v", suggestionUpvote(info), s.Up, suggestionDownvote(info), s.Down), Inline: true},
+		},
+		Timestamp: s.Timestamp.Format(time.RFC3339),
+	}
+	if s.Status == suggestionImplemented {
+		embed.Title = "[Implemented] " + embed.Title
+	}
+	if m, err := info.GetMember(SBitoa(s.Author)); err == nil {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: m.User.Username, IconURL: m.User.AvatarURL("")}
+	} else {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: getUserName(s.Author, info)}
+	}
+	return embed
+}
+
+// Implemented suggestions are finished, so they don't get any buttons. Everything else gets a button for every other status.
+func suggestionComponents(s *Suggestion) []discordgo.MessageComponent {
+	if s.Status == suggestionImplemented {
+		return []discordgo.MessageComponent{}
+	}
+	buttons := []discordgo.MessageComponent{}
+	styles := []discordgo.ButtonStyle{discordgo.SecondaryButton, discordgo.SuccessButton, discordgo.DangerButton, discordgo.PrimaryButton}
+	for i := range suggestionStatuses {
+		if uint8(i) != s.Status {
+			buttons = append(buttons, discordgo.Button{Label: suggestionLabels[i], Style: styles[i], CustomID: fmt.Sprintf("suggestion:%s:%v", suggestionActions[i], s.ID)})
+		}
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// A downloaded copy of an attachment. Deleting a message deletes its attachments too, so a post that gets replaced has to
+// upload them again.
+type copiedAttachment struct {
+	name string
+	ty   string
+	data []byte
+}
+
+func copyAttachments(attachments []*discordgo.MessageAttachment) ([]copiedAttachment, error) {
+	copies := make([]copiedAttachment, 0, len(attachments))
+	for _, a := range attachments {
+		data, ty, err := downloadUpload(a.URL, suggestionMaxAttachment)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't copy %s: %s", a.Filename, err.Error())
+		}
+		copies = append(copies, copiedAttachment{a.Filename, ty, data})
+	}
+	return copies, nil
+}
+
+// Readers can only be read once, so this has to be called again every time a message is retried
+func attachmentFiles(copies []copiedAttachment) []*discordgo.File {
+	files := make([]*discordgo.File, 0, len(copies))
+	for _, a := range copies {
+		files = append(files, &discordgo.File{Name: a.name, ContentType: a.ty, Reader: bytes.NewReader(a.data)})
+	}
+	return files
+}
+
+// OnMessageCreate discord hook
+func (w *SuggestionModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
+	channel := info.config.Suggestions.Channel
+	if channel == 0 || SBatoi(m.ChannelID) != channel || m.Author == nil || m.Author.Bot || (len(strings.TrimSpace(m.Content)) == 0 && len(m.Attachments) == 0) || !sb.db.CheckStatus() {
+		return
+	}
+	attachments, err := copyAttachments(m.Attachments)
+	if err != nil {
+		info.LogTo(LogModeration, "Couldn't turn ", m.Author.Username, "'s post into a suggestion: ", err.Error())
+		return // Leave their message alone so the attachment isn't lost
+	}
+	s := &Suggestion{Author: SBatoi(m.Author.ID), Content: m.Content, Timestamp: time.Now().UTC()}
+	if s.ID = sb.db.AddSuggestion(SBatoi(info.ID), s.Author, s.Content); s.ID == 0 {
+		return // Leave their message alone so the suggestion isn't lost
+	}
+	var posted *discordgo.Message
+	err = CallAPI("ChannelMessageSendComplex", func() (e error) {
+		posted, e = sb.dg.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{suggestionEmbed(info, s)}, Components: suggestionComponents(s), Files: attachmentFiles(attachments)})
+		return e
+	})
+	if err != nil {
+		info.LogTo(LogModeration, "Couldn't post suggestion #", s.ID, ": ", apiErrorMessage(err))
+		return
+	}
+	sb.db.SetSuggestionMessage(s.ID, SBatoi(posted.ChannelID), SBatoi(posted.ID))
+	CallAPI("ChannelMessageDelete", func() error { return deleteMessage(m.ChannelID, m.ID) })
+	for _, emoji := range []string{suggestionUpvote(info), suggestionDownvote(info)} {
+		if err := sb.dg.MessageReactionAdd(posted.ChannelID, posted.ID, emoji); err != nil {
+			info.LogError("Couldn't add a suggestion vote reaction: ", err)
+		}
+	}
+}
+
+// Counts the votes on a suggestion's message, not including our own reactions, and updates the embed if they changed
+func recountSuggestion(info *GuildInfo, s *Suggestion) {
+	msg, err := sb.dg.ChannelMessage(SBitoa(s.Channel), SBitoa(s.Message))
+	if err != nil {
+		return
+	}
+	up, down := 0, 0
+	for _, r := range msg.Reactions {
+		count := r.Count
+		if r.Me {
+			count--
+		}
+		if matchesEmoji(*r.Emoji, suggestionUpvote(info)) {
+			up = count
+		} else if matchesEmoji(*r.Emoji, suggestionDownvote(info)) {
+			down = count
+		}
+	}
+	if up == s.Up && down == s.Down {
+		return
+	}
+	s.Up, s.Down = up, down
+	sb.db.SetSuggestionVotes(s.ID, up, down)
+	updateSuggestion(info, s)
+}
+
+func (w *SuggestionModule) voted(info *GuildInfo, r *discordgo.MessageReaction) {
+	if r.UserID == sb.SelfID || (!matchesEmoji(r.Emoji, suggestionUpvote(info)) && !matchesEmoji(r.Emoji, suggestionDownvote(info))) {
+		return
+	}
+	if ch := SBatoi(r.ChannelID); ch != info.config.Suggestions.Channel && ch != info.config.Suggestions.Implemented {
+		return
+	}
+	message := SBatoi(r.MessageID)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.recounts == nil {
+		w.recounts = make(map[uint64]bool)
+	}
+	if w.recounts[message] {
+		return // The pending recount will see this vote too
+	}
+	w.recounts[message] = true
+	go func() {
+		time.Sleep(suggestionRecountDelay)
+		w.lock.Lock()
+		delete(w.recounts, message)
+		w.lock.Unlock()
+		if !sb.db.CheckStatus() {
+			return
+		}
+		if s := sb.db.GetSuggestionByMessage(SBatoi(info.ID), message); s != nil {
+			recountSuggestion(info, s)
+		}
+	}()
+}
+
+// OnMessageReactionAdd discord hook
+func (w *SuggestionModule) OnMessageReactionAdd(info *GuildInfo, r *discordgo.MessageReaction) {
+	w.voted(info, r)
+}
+
+// OnMessageReactionRemove discord hook
+func (w *SuggestionModule) OnMessageReactionRemove(info *GuildInfo, r *discordgo.MessageReaction) {
+	w.voted(info, r)
+}
+
+// Edits a suggestion's message to match what's stored
+func updateSuggestion(info *GuildInfo, s *Suggestion) error {
+	embeds := []*discordgo.MessageEmbed{suggestionEmbed(info, s)}
+	components := suggestionComponents(s)
+	return CallAPI("ChannelMessageEditComplex", func() error {
+		_, err := sb.dg.ChannelMessageEditComplex(&discordgo.MessageEdit{ID: SBitoa(s.Message), Channel: SBitoa(s.Channel), Embeds: &embeds, Components: &components})
+		return err
+	})
+}
+
+// Gives a suggestion a new status and updates its message. Implemented suggestions are moved to suggestions.implemented.
+func setSuggestionStatus(info *GuildInfo, s *Suggestion, status uint8, moderator string, reason string) error {
+	if !sb.db.SetSuggestionStatus(s.ID, status, SBatoi(moderator), reason) {
+		return fmt.Errorf("the database couldn't be updated")
+	}
+	s.Status, s.Moderator, s.Reason = status, SBatoi(moderator), reason
+	info.LogTo(LogModeration, getUserName(s.Moderator, info), " marked suggestion #", s.ID, " as ", suggestionStatuses[status], ".")
+	target := info.config.Suggestions.Implemented
+	if status != suggestionImplemented || target == 0 || target == s.Channel {
+		return updateSuggestion(info, s)
+	}
+	var attachments []copiedAttachment
+	err := CallAPI("ChannelMessage", func() error {
+		old, e := sb.dg.ChannelMessage(SBitoa(s.Channel), SBitoa(s.Message))
+		if e == nil {
+			attachments, e = copyAttachments(old.Attachments)
+		}
+		return e
+	})
+	var posted *discordgo.Message
+	if err == nil {
+		err = CallAPI("ChannelMessageSendComplex", func() (e error) {
+			posted, e = sb.dg.ChannelMessageSendComplex(SBitoa(target), &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{suggestionEmbed(info, s)}, Components: suggestionComponents(s), Files: attachmentFiles(attachments)})
+			return e
+		})
+	}
+	if err != nil {
+		updateSuggestion(info, s) // At least relabel it where it is
+		return err
+	}
+	CallAPI("ChannelMessageDelete", func() error { return sb.dg.ChannelMessageDelete(SBitoa(s.Channel), SBitoa(s.Message)) })
+	s.Channel, s.Message = SBatoi(posted.ChannelID), SBatoi(posted.ID)
+	sb.db.SetSuggestionMessage(s.ID, s.Channel, s.Message)
+	return nil
+}
+
+// OnInteractionCreate discord hook
+func (w *SuggestionModule) OnInteractionCreate(info *GuildInfo, i *discordgo.Interaction) bool {
+	if i.Type != discordgo.InteractionMessageComponent {
+		return false
+	}
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 3 || parts[0] != "suggestion" || i.Member == nil {
+		return false
+	}
+	if !info.HasModRole(i.Member.User.ID) {
+		respondEphemeral(i, "Only moderators can change the status of a suggestion. Vote on it by reacting instead!")
+		return true
+	}
+	status, ok := parseSuggestionStatus(parts[1])
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if !ok || err != nil || !sb.db.CheckStatus() {
+		respondEphemeral(i, "That suggestion can't be changed right now.")
+		return true
+	}
+	s := sb.db.GetSuggestion(SBatoi(info.ID), id)
+	if s == nil {
+		respondEphemeral(i, "That suggestion doesn't exist anymore.")
+		return true
+	}
+	// Moving a suggestion can take longer than discord waits for a response, so acknowledge the click first
+	if err = sb.dg.InteractionRespond(i, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate}); err != nil {
+		metricAPIErrors.Add(ClassifyAPIError(err).String(), 1)
+		return true
+	}
+	if err = setSuggestionStatus(info, s, status, i.Member.User.ID, ""); err != nil {
+		info.LogTo(LogModeration, "Couldn't update suggestion #", s.ID, ": ", err.Error())
+	}
+	return true
+}
+
+type suggestionCommand struct {
+}
+
+func (c *suggestionCommand) Name() string {
+	return "Suggestion"
+}
+func (c *suggestionCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 2 {
+		return "```You must give a suggestion ID and what to do with it: " + strings.Join(suggestionActions, ", ") + ".```", false, nil
+	}
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(args[0], "#"), 10, 64)
+	if err != nil {
+		return "```" + args[0] + " isn't a suggestion ID.```", false, nil
+	}
+	s := sb.db.GetSuggestion(SBatoi(info.ID), id)
+	if s == nil {
+		return fmt.Sprintf("```There's no suggestion #%v.```", id), false, nil
+	}
+	status, ok := parseSuggestionStatus(args[1])
+	if !ok {
+		return "```" + args[1] + " isn't one of " + strings.Join(suggestionActions, ", ") + ".```", false, nil
+	}
+	reason := ""
+	if len(indices) > 2 {
+		reason = truncateRunes(msg.Content[indices[2]:], 500)
+	}
+	if err := setSuggestionStatus(info, s, status, msg.Author.ID, reason); err != nil {
+		return fmt.Sprintf("```Marked suggestion #%v as %s, but couldn't update its message: %s```", id, suggestionStatuses[status], apiErrorMessage(err)), false, nil
+	}
+	return fmt.Sprintf("```Marked suggestion #%v as %s.```", id, suggestionStatuses[status]), false, nil
+}
+func (c *suggestionCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Changes the status of a suggestion and updates its message. Implemented suggestions are relabeled and moved to `suggestions.implemented`, if it's set. Moderators can also use the buttons on the suggestion.",
+		Params: []CommandUsageParam{
+			{Name: "ID", Desc: "The number of the suggestion, like `12`.", Optional: false},
+			{Name: strings.Join(suggestionActions, "/"), Desc: "What to do with the suggestion.", Optional: false},
+			{Name: "reason", Desc: "Shown on the suggestion along with its status.", Optional: true},
+		},
+	}
+}
+func (c *suggestionCommand) UsageShort() string {
+	return "Approves, denies or implements a suggestion."
+}
+
+type suggestionsCommand struct {
+}
+
+func (c *suggestionsCommand) Name() string {
+	return "Suggestions"
+}
+func (c *suggestionsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	statuses := []string{}
+	page := 1
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			page = n
+		} else if status, ok := parseSuggestionStatus(arg); ok {
+			statuses = append(statuses, strconv.Itoa(int(status)))
+		} else if strings.ToLower(arg) != "all" {
+			return "```" + arg + " isn't a page number, all, or one of " + strings.Join(suggestionStatuses, ", ") + ".```", false, nil
+		}
+	}
+	if len(statuses) == 0 {
+		for i := range suggestionStatuses {
+			statuses = append(statuses, strconv.Itoa(i))
+		}
+	}
+	list := sb.db.GetSuggestions(SBatoi(info.ID), strings.Join(statuses, ","), suggestionsPerPage, (page-1)*suggestionsPerPage)
+	if len(list) == 0 {
+		return "```No suggestions found.```", false, nil
+	}
+	lines := make([]string, 0, len(list)+1)
+	lines = append(lines, fmt.Sprintf("Page %v, newest first:", page))
+	for _, s := range list {
+		lines = append(lines, fmt.Sprintf("#%v [%s] +%v/-%v %s (by %s)", s.ID, suggestionStatuses[s.Status], s.Up, s.Down, truncateRunes(strings.Replace(s.Content, "\n", " ", -1), 80), getUserName(s.Author, info)))
+	}
+	return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```", false, nil
+}
+func (c *suggestionsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: fmt.Sprintf("Lists suggestions, %v at a time, newest first, along with their votes.", suggestionsPerPage),
+		Params: []CommandUsageParam{
+			{Name: strings.Join(suggestionStatuses, "/"), Desc: "Only list suggestions with these statuses. If omitted, lists all of them.", Optional: true},
+			{Name: "page", Desc: "Which page of results to show.", Optional: true},
+		},
+	}
+}
+func (c *suggestionsCommand) UsageShort() string {
+	return "Lists suggestions by status."
+}
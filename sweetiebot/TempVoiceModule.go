@@ -0,0 +1,188 @@
+package sweetiebot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TempVoiceModule creates a voice channel for anyone who joins one of the hub channels in tempvoice.hubs, moves them into
+// it, and deletes it once everyone has left. The channels it manages are stored so any left behind by a restart are
+// cleaned up when the bot reconnects.
+type TempVoiceModule struct {
+	lock     sync.Mutex
+	channels map[string]time.Time // when each managed channel was created
+	creating map[string]bool      // users we are currently making a channel for
+	bucket   TokenBucket
+	lastwarn int64
+}
+
+// New channels can't be deleted for this long, because the owner hasn't been moved into them yet
+const tempVoiceGrace = 15 * time.Second
+
+// At most this many channels are created per second, with bursts of tempVoiceBurst, to stay clear of discord's channel limits
+const tempVoiceRate = 0.2
+const tempVoiceBurst = 5
+
+// Name of the module
+func (w *TempVoiceModule) Name() string {
+	return "TempVoice"
+}
+
+// Commands in the module
+func (w *TempVoiceModule) Commands() []Command { return []Command{} }
+
+// Description of the module
+func (w *TempVoiceModule) Description() string {
+	return "Creates a temporary voice channel for anyone who joins a hub channel set in `tempvoice.hubs`, and deletes it once it's empty."
+}
+
+func (w *TempVoiceModule) load(info *GuildInfo) {
+	w.channels = make(map[string]time.Time)
+	w.creating = make(map[string]bool)
+	if sb.db.status.get() {
+		for _, ch := range sb.db.GetTempChannels(SBatoi(info.ID)) {
+			w.channels[SBitoa(ch)] = time.Time{}
+		}
+	}
+}
+
+// Returns the number of members in each voice channel on the server
+func voiceChannelCounts(info *GuildInfo) map[string]int {
+	counts := make(map[string]int)
+	g, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return counts
+	}
+	sb.dg.State.RLock()
+	for _, v := range g.VoiceStates {
+		counts[v.ChannelID]++
+	}
+	sb.dg.State.RUnlock()
+	return counts
+}
+
+func (w *TempVoiceModule) forget(channel string) {
+	w.lock.Lock()
+	delete(w.channels, channel)
+	w.lock.Unlock()
+	if sb.db.CheckStatus() {
+		sb.db.RemoveTempChannel(SBatoi(channel))
+	}
+}
+
+// Deletes every managed channel that is empty and old enough to have had its owner moved in
+func (w *TempVoiceModule) cleanup(info *GuildInfo) {
+	counts := voiceChannelCounts(info)
+	now := time.Now()
+	empty := []string{}
+	w.lock.Lock()
+	for ch, created := range w.channels {
+		if counts[ch] == 0 && now.Sub(created) >= tempVoiceGrace {
+			empty = append(empty, ch)
+		}
+	}
+	w.lock.Unlock()
+	for _, ch := range empty {
+		err := CallAPI("ChannelDelete", func() error {
+			_, err := sb.dg.ChannelDelete(ch)
+			return err
+		})
+		if err == nil || ClassifyAPIError(err) == APIErrorNotFound {
+			w.forget(ch)
+		} else {
+			info.LogError("Failed to delete an empty temporary voice channel: ", err)
+		}
+	}
+}
+
+func (w *TempVoiceModule) create(info *GuildInfo, hub *discordgo.Channel, member *discordgo.Member) {
+	defer func() {
+		w.lock.Lock()
+		delete(w.creating, member.User.ID)
+		w.lock.Unlock()
+	}()
+	name := info.config.TempVoice.Name
+	if len(strings.TrimSpace(name)) == 0 {
+		name = "{user}'s channel"
+	}
+	display := member.Nick
+	if len(display) == 0 {
+		display = member.User.Username
+	}
+	name = truncateRunes(strings.Replace(name, "{user}", display, -1), 100)
+	overwrites := make([]*discordgo.PermissionOverwrite, 0, len(hub.PermissionOverwrites)+1)
+	overwrites = append(overwrites, hub.PermissionOverwrites...) // Copy the hub's permissions, so private hubs make private channels
+	overwrites = append(overwrites, &discordgo.PermissionOverwrite{
+		ID:    member.User.ID,
+		Type:  discordgo.PermissionOverwriteTypeMember,
+		Allow: discordgo.PermissionManageChannels | discordgo.PermissionVoiceMoveMembers,
+	})
+	var ch *discordgo.Channel
+	err := CallAPI("GuildChannelCreateComplex", func() (err error) {
+		ch, err = sb.dg.GuildChannelCreateComplex(info.ID, discordgo.GuildChannelCreateData{
+			Name:                 name,
+			Type:                 discordgo.ChannelTypeGuildVoice,
+			ParentID:             hub.ParentID,
+			Bitrate:              hub.Bitrate,
+			UserLimit:            info.config.TempVoice.UserLimit,
+			PermissionOverwrites: overwrites,
+		})
+		return
+	})
+	if err != nil {
+		info.LogError("Failed to create a temporary voice channel: ", err)
+		return
+	}
+	w.lock.Lock()
+	w.channels[ch.ID] = time.Now()
+	w.lock.Unlock()
+	if sb.db.CheckStatus() {
+		sb.db.AddTempChannel(SBatoi(ch.ID), SBatoi(info.ID))
+	}
+	// If the member already left the hub, the move fails and the channel is deleted once the grace period is over
+	sb.dg.GuildMemberMove(info.ID, member.User.ID, &ch.ID)
+	time.AfterFunc(tempVoiceGrace, func() { w.cleanup(info) })
+}
+
+// OnVoiceStateUpdate discord hook
+func (w *TempVoiceModule) OnVoiceStateUpdate(info *GuildInfo, v *discordgo.VoiceStateUpdate) {
+	if v.BeforeUpdate != nil && len(v.BeforeUpdate.ChannelID) > 0 && v.BeforeUpdate.ChannelID != v.ChannelID {
+		w.lock.Lock()
+		_, managed := w.channels[v.BeforeUpdate.ChannelID]
+		w.lock.Unlock()
+		if managed {
+			go w.cleanup(info)
+		}
+	}
+	if !info.config.TempVoice.Hubs[v.ChannelID] || v.Member == nil || v.Member.User == nil || v.Member.User.Bot {
+		return
+	}
+	hub, err := sb.dg.State.Channel(v.ChannelID)
+	if err != nil {
+		return
+	}
+	w.lock.Lock()
+	if w.creating[v.UserID] {
+		w.lock.Unlock()
+		return
+	}
+	if !w.bucket.take(tempVoiceRate, tempVoiceBurst) {
+		w.lock.Unlock()
+		recordDropped("voice", info.ID, "voice channel creation limit", getUserName(SBatoi(v.UserID), info))
+		if RateLimit(&w.lastwarn, 60) {
+			info.LogTo(LogVoice, "Too many temporary voice channels are being created, so some members in ", hub.Name, " weren't given one.")
+		}
+		return
+	}
+	w.creating[v.UserID] = true
+	w.lock.Unlock()
+	go w.create(info, hub, v.Member)
+}
+
+// OnGuildCreate discord hook
+func (w *TempVoiceModule) OnGuildCreate(info *GuildInfo, g *discordgo.Guild) {
+	go w.cleanup(info) // Delete any channels that emptied out while we were disconnected
+}
@@ -28,9 +28,18 @@ func (w *UsersModule) Commands() []Command {
 		&timeCommand{},
 		&setTimeZoneCommand{},
 		&userInfoCommand{},
+		&activityCommand{},
 		&defaultServerCommand{},
 		&silenceCommand{},
 		&unsilenceCommand{},
+		&fixMuteCommand{},
+		&migrateUserCommand{},
+		&warnCommand{},
+		&warningsCommand{},
+		&myRecordCommand{},
+		&welcomeCardCommand{},
+		&moveAllCommand{},
+		&summonCommand{},
 	}
 }
 
@@ -214,9 +223,9 @@ func (c *banCommand) Process(args []string, msg *discordgo.Message, indices []in
 	}
 
 	fmt.Printf("Banned %s because: %s\n", u.Username, reason)
-	err := sb.dg.GuildBanCreate(info.ID, uID, 1) // Note that this will probably generate a SawBan event
+	err := CallAPI("GuildBanCreate", func() error { return sb.dg.GuildBanCreate(info.ID, uID, 1) }) // Note that this will probably generate a SawBan event
 	if err != nil {
-		return "```Error: " + err.Error() + "```", false, nil
+		return apiErrorMessage(err), false, nil
 	}
 	return "```Banned " + u.Username + " from the server. Harmony restored.```", false, nil
 }
@@ -257,9 +266,12 @@ func (c *banNewcomersCommand) Process(args []string, msg *discordgo.Message, ind
 	if len(IDs) == 0 {
 		return fmt.Sprintf("```No one has sent their first message in the past %v seconds!```", duration), false, nil
 	}
+	if !Confirm(info, msg.ChannelID, msg.Author.ID, fmt.Sprintf("**This will ban %s who sent their first message in the past %v seconds.** Are you sure?", Pluralize(int64(len(IDs)), " member"), duration)) {
+		return "```Cancelled the ban.```", false, nil
+	}
 	for _, id := range IDs {
 		//var err error = nil
-		err := sb.dg.GuildBanCreate(info.ID, SBitoa(id), 1)
+		err := CallAPI("GuildBanCreate", func() error { return sb.dg.GuildBanCreate(info.ID, SBitoa(id), 1) })
 		//sb.dg.ChannelMessageSend(msg.ChannelID, fmt.Sprintf("Pretending to ban <@%v>", id))
 		info.LogError("Error banning user: ", err)
 	}
@@ -268,7 +280,7 @@ func (c *banNewcomersCommand) Process(args []string, msg *discordgo.Message, ind
 }
 func (c *banNewcomersCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Bans all users who have sent their first message in the past `duration` seconds.",
+		Desc: "Bans all users who have sent their first message in the past `duration` seconds. You'll be asked to confirm before anyone is banned.",
 		Params: []CommandUsageParam{
 			{Name: "duration", Desc: "The number of seconds to look back, defaults to 120 seconds (so anyone who sent their first message in the past 2 minutes would be banned).", Optional: true},
 		},
@@ -586,6 +598,7 @@ func (c *silenceCommand) Process(args []string, msg *discordgo.Message, indices
 	if len(info.config.Spam.SilenceMessage) > 0 {
 		sb.dg.ChannelMessageSend(SBitoa(info.config.Users.WelcomeChannel), "<@"+SBitoa(IDs[0])+"> "+info.config.Spam.SilenceMessage)
 	}
+	recordModlog(info, modlogSilence, IDs[0], SBatoi(msg.Author.ID), reason)
 	if len(reason) > 0 {
 		reason = " because " + reason
 	}
@@ -623,8 +636,9 @@ func (c *unsilenceCommand) Process(args []string, msg *discordgo.Message, indice
 
 	err := UnsilenceMember(IDs[0], info)
 	if err != nil {
-		return "```Error unsilencing member: " + err.Error() + "```", false, nil
+		return apiErrorMessage(err), false, nil
 	}
+	recordModlog(info, modlogUnsilence, IDs[0], SBatoi(msg.Author.ID), "")
 	return "```Unsilenced " + IDsToUsernames(IDs, info, false)[0] + ".```", false, nil
 }
 func (c *unsilenceCommand) Usage(info *GuildInfo) *CommandUsage {
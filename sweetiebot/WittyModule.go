@@ -67,8 +67,16 @@ func (w *WittyModule) UpdateRegex(info *GuildInfo) bool {
 	return err == nil
 }
 
-func (w *WittyModule) sendWittyComment(channel string, comment string, info *GuildInfo) {
-	if RateLimit(&w.lastcomment, info.config.Witty.Cooldown) {
+func (w *WittyModule) sendWittyComment(channel string, comment string, user *discordgo.User, info *GuildInfo) {
+	// A witty response posted hours later wouldn't make any sense, so these are never held back
+	if RateLimit(&w.lastcomment, info.config.Witty.Cooldown) && info.takeChatterNow("witty") {
+		comment = renderTemplate(info, comment, map[string]string{"user": "<@" + user.ID + ">", "username": user.Username, "channel": "<#" + channel + ">"})
+		if p, ok := info.persona(info.config.Witty.Persona); ok {
+			// If the webhook can't be used, say it ourselves instead of staying silent
+			if _, err := sendAsPersona(info, channel, p, &discordgo.MessageSend{Content: info.sanitizeOutput(comment)}); err == nil {
+				return
+			}
+		}
 		info.SendMessage(channel, comment)
 	}
 }
@@ -80,7 +88,7 @@ func (w *WittyModule) OnMessageCreate(info *GuildInfo, m *discordgo.Message) {
 		if w.wittyregex != nil && w.wittyregex.MatchString(str) {
 			for i := 0; i < len(w.triggerregex); i++ {
 				if w.triggerregex[i].MatchString(str) {
-					w.sendWittyComment(m.ChannelID, w.remarks[i][rand.Intn(len(w.remarks[i]))], info)
+					w.sendWittyComment(m.ChannelID, w.remarks[i][rand.Intn(len(w.remarks[i]))], m.Author, info)
 					break
 				}
 			}
@@ -111,6 +119,11 @@ func (c *addWitCommand) Process(args []string, msg *discordgo.Message, indices [
 
 	trigger := strings.ToLower(args[0])
 	remark := args[1]
+	if _, ok := info.config.Witty.Responses[trigger]; !ok {
+		if limit := info.triggerLimit(); overLimit(len(info.config.Witty.Responses), 1, limit) {
+			return limitReachedMessage(limit, "this server can only have %v witty triggers", "!removewit"), false, nil
+		}
+	}
 
 	CheckMapNilString(&info.config.Witty.Responses)
 	info.config.Witty.Responses[trigger] = remark
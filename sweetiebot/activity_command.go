@@ -0,0 +1,94 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Message counts are stored in one row per user per day, and only the last 30 days are kept
+const activityDays = 30
+
+func pruneActivity() {
+	if sb.db.CheckStatus() {
+		sb.db.PruneActivity(activityDays)
+	}
+}
+
+// Draws one bar per day, scaled so the busiest day fills the chart
+func activityChart(counts map[string]int, days int, now time.Time) (string, int) {
+	max := 0
+	total := 0
+	for _, v := range counts {
+		total += v
+		if v > max {
+			max = v
+		}
+	}
+	width := 30
+	lines := make([]string, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		day := now.AddDate(0, 0, -i)
+		n := counts[day.Format("2006-01-02")]
+		bar := 0
+		if max > 0 {
+			bar = (n*width + max - 1) / max // round up so any activity at all shows at least one block
+		}
+		lines = append(lines, fmt.Sprintf("%s | %-*s %v", day.Format("Mon Jan 02"), width, strings.Repeat("#", bar), n))
+	}
+	return strings.Join(lines, "\n"), total
+}
+
+type activityCommand struct {
+}
+
+func (c *activityCommand) Name() string {
+	return "Activity"
+}
+func (c *activityCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	days := 7
+	if len(args) > 0 {
+		if n, err := strconv.Atoi(args[len(args)-1]); err == nil {
+			if n != 7 && n != 30 {
+				return "```You can only see activity for the last 7 or 30 days.```", false, nil
+			}
+			days = n
+			args = args[:len(args)-1]
+		}
+	}
+	user := SBatoi(msg.Author.ID)
+	if len(args) > 0 {
+		arg := strings.Join(args, " ")
+		IDs := FindUsername(arg, info)
+		if len(IDs) == 0 { // no matches!
+			return "```Error: Could not find any usernames or aliases matching " + arg + "!```", false, nil
+		}
+		if len(IDs) > 1 {
+			return "```Could be any of the following users or their aliases:\n" + strings.Join(IDsToUsernames(IDs, info, true), "\n") + "```", len(IDs) > 5, nil
+		}
+		user = IDs[0]
+	}
+
+	name := getUserName(user, info)
+	chart, total := activityChart(sb.db.GetActivity(user, SBatoi(info.ID), days), days, time.Now().UTC())
+	if total == 0 {
+		return "```" + name + " hasn't sent any messages in the last " + strconv.Itoa(days) + " days.```", false, nil
+	}
+	return "```" + name + " sent " + Pluralize(int64(total), " message") + " in the last " + strconv.Itoa(days) + " days (UTC):\n" + chart + "```", days > 7, nil
+}
+func (c *activityCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Shows how many messages a user sent each day, as a bar chart. Only message counts are stored, never the messages themselves. If you don't want members looking up each other's activity, restrict this command to moderators with `" + info.config.Basic.CommandPrefix + "setconfig modules.commandroles activity <role>`.",
+		Params: []CommandUsageParam{
+			{Name: "user", Desc: "The user to look up. Defaults to yourself.", Optional: true},
+			{Name: "days", Desc: "Either 7 or 30. Defaults to 7.", Optional: true},
+		},
+	}
+}
+func (c *activityCommand) UsageShort() string { return "Shows a user's recent message activity." }
@@ -0,0 +1,447 @@
+package sweetiebot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Admin commands are only available to the owners of the bot and are meant for operating it across many servers.
+
+func isBotOwner(user string) bool {
+	_, ok := sb.Owners[SBatoi(user)]
+	return ok
+}
+
+// Records an admin action in the console and in the main guild's audit log
+func logAdminAction(user *discordgo.User, action string) {
+	fmt.Printf("[%s] ADMIN %s: %s\n", time.Now().Format(time.Stamp), userIdentity(user), action)
+	if sb.db != nil && sb.db.status.get() {
+		sb.db.Audit(AUDIT_TYPE_ACTION, user, "[admin] "+action, sb.MainGuildID)
+	}
+}
+
+// Finds a guild that the bot is on by ID or by name. Returns an error message if there isn't exactly one match.
+func findAnyGuild(arg string) (*GuildInfo, string) {
+	sb.guildsLock.RLock()
+	guild, ok := sb.guilds[SBatoi(arg)]
+	ids := make([]uint64, 0, len(sb.guilds))
+	for k := range sb.guilds {
+		ids = append(ids, k)
+	}
+	sb.guildsLock.RUnlock()
+	if ok {
+		return guild, ""
+	}
+	guilds := findServers(arg, ids)
+	if len(guilds) == 0 {
+		return nil, "```No server matches that string.```"
+	}
+	if len(guilds) > 1 {
+		names := make([]string, len(guilds), len(guilds))
+		for k, v := range guilds {
+			names[k] = v.Name + " (" + v.ID + ")"
+		}
+		return nil, "```Could be any of the following servers:\n" + strings.Join(names, "\n") + "```"
+	}
+	return guilds[0], ""
+}
+
+type guildConfigCommand struct {
+}
+
+func (c *guildConfigCommand) Name() string {
+	return "GuildConfig"
+}
+func (c *guildConfigCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !isBotOwner(msg.Author.ID) {
+		return "```Only the owner of the bot itself can call this!```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must specify a server name or ID.```", false, nil
+	}
+	guild, err := findAnyGuild(msg.Content[indices[0]:])
+	if guild == nil {
+		return err, false, nil
+	}
+	data, e := json.MarshalIndent(guild.config, "", "  ")
+	if e != nil {
+		return "```Error serializing config: " + e.Error() + "```", false, nil
+	}
+	logAdminAction(msg.Author, "Inspected the config of "+guild.Name+" ("+guild.ID+")")
+	return "```json\n" + string(data) + "```", true, nil
+}
+func (c *guildConfigCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Restricted command that sends you the live configuration of any server the bot is on.",
+		Params: []CommandUsageParam{
+			{Name: "server", Desc: "The name or ID of the server.", Optional: false},
+		},
+	}
+}
+func (c *guildConfigCommand) UsageShort() string { return "[RESTRICTED] Shows a server's config." }
+
+type leaveGuildCommand struct {
+}
+
+func (c *leaveGuildCommand) Name() string {
+	return "LeaveGuild"
+}
+func (c *leaveGuildCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !isBotOwner(msg.Author.ID) {
+		return "```Only the owner of the bot itself can call this!```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must specify a server name or ID.```", false, nil
+	}
+	guild, e := findAnyGuild(msg.Content[indices[0]:])
+	if guild == nil {
+		return e, false, nil
+	}
+	if SBatoi(guild.ID) == sb.MainGuildID {
+		return "```I can't leave the main server!```", false, nil
+	}
+	if err := sb.dg.GuildLeave(guild.ID); err != nil {
+		return "```Error leaving " + guild.Name + ": " + err.Error() + "```", false, nil
+	}
+
+	// Clean up everything we were tracking for that guild instead of waiting for the GUILD_DELETE event
+	sb.guildsLock.Lock()
+	delete(sb.guilds, SBatoi(guild.ID))
+	sb.guildsLock.Unlock()
+	if g, err := sb.dg.State.Guild(guild.ID); err == nil {
+		for _, ch := range g.Channels {
+			sb.LastMessages.Delete(ch.ID)
+		}
+	}
+	metricCommandsDroppedByGuild.Delete(guild.ID)
+
+	logAdminAction(msg.Author, "Forced the bot to leave "+guild.Name+" ("+guild.ID+")")
+	return "```Left " + guild.Name + ".```", false, nil
+}
+func (c *leaveGuildCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Restricted command that makes the bot leave a server and discard everything it has cached for it.",
+		Params: []CommandUsageParam{
+			{Name: "server", Desc: "The name or ID of the server.", Optional: false},
+		},
+	}
+}
+func (c *leaveGuildCommand) UsageShort() string { return "[RESTRICTED] Leaves a server." }
+
+type broadcastOwnersCommand struct {
+}
+
+func (c *broadcastOwnersCommand) Name() string {
+	return "BroadcastOwners"
+}
+func (c *broadcastOwnersCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !isBotOwner(msg.Author.ID) {
+		return "```Only the owner of the bot itself can call this!```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You have to tell me to say something, silly!```", false, nil
+	}
+	arg := msg.Content[indices[0]:]
+	owners := make(map[string]bool)
+	sb.guildsLock.RLock()
+	for _, v := range sb.guilds {
+		owners[v.OwnerID] = true
+	}
+	sb.guildsLock.RUnlock()
+
+	logAdminAction(msg.Author, fmt.Sprintf("Broadcast a message to %v server owners: %s", len(owners), arg))
+	go func() {
+		for k := range owners {
+			ch, err := sb.dg.UserChannelCreate(k)
+			if err == nil {
+				sb.dg.ChannelMessageSend(ch.ID, arg)
+			}
+			time.Sleep(time.Second) // Don't trip the global rate limit
+		}
+	}()
+	return fmt.Sprintf("```Sending message to %v server owners.```", len(owners)), false, nil
+}
+func (c *broadcastOwnersCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Restricted command that sends a private message to the owner of every server the bot is on.",
+		Params: []CommandUsageParam{
+			{Name: "arbitrary string", Desc: "The message to send.", Optional: false},
+		},
+	}
+}
+func (c *broadcastOwnersCommand) UsageShort() string {
+	return "[RESTRICTED] Messages all server owners."
+}
+
+type limitersCommand struct {
+}
+
+func (c *limitersCommand) Name() string {
+	return "Limiters"
+}
+func (c *limitersCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !isBotOwner(msg.Author.ID) {
+		return "```Only the owner of the bot itself can call this!```", false, nil
+	}
+	if len(args) > 0 && strings.ToLower(args[0]) == "dump" {
+		return c.dump(args[1:], msg, info)
+	}
+	limits := sb.CommandLimits
+	lines := []string{fmt.Sprintf("Global command bucket: %.1f/%v tokens (%v/sec)", sb.commandbucket.level(limits.GlobalRate, limits.GlobalBurst), limits.GlobalBurst, limits.GlobalRate)}
+	lines = append(lines, "Dropped commands: "+metricCommandsDropped.String())
+	lines = append(lines, "Rate limited by discord: "+metricRateLimited.String())
+	lines = append(lines, "Deferred sends: "+metricSendsDeferred.String())
+	if len(args) > 0 {
+		guild, e := findAnyGuild(msg.Content[indices[0]:])
+		if guild == nil {
+			return e, false, nil
+		}
+		recent, _ := guild.commandlimit.Snapshot(time.Now().UTC().Unix() - guild.config.Modules.CommandMaxDuration)
+		guild.commandLock.RLock()
+		cooldowns := len(guild.commandLast)
+		guild.commandLock.RUnlock()
+		lines = append(lines, "", guild.Name+" ("+guild.ID+"):")
+		lines = append(lines, fmt.Sprintf("Command bucket: %.1f/%v tokens (%v/sec)", guild.commandbucket.level(limits.GuildRate, limits.GuildBurst), limits.GuildBurst, limits.GuildRate))
+		lines = append(lines, fmt.Sprintf("Commands in the last %s: %v (limit %v)", TimeDiff(time.Duration(guild.config.Modules.CommandMaxDuration)*time.Second), recent, guild.config.Modules.CommandPerDuration))
+		lines = append(lines, fmt.Sprintf("Channels with command cooldowns: %v", cooldowns))
+		if v := metricCommandsDroppedByGuild.Get(guild.ID); v != nil {
+			lines = append(lines, "Dropped commands: "+v.String())
+		}
+	}
+	logAdminAction(msg.Author, "Dumped limiter state")
+	return "```" + strings.Join(lines, "\n") + "```", false, nil
+}
+
+// Formats rows of text as a table with aligned columns
+func limiterTable(rows [][]string) string {
+	widths := []int{}
+	for _, row := range rows {
+		for i, v := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(v); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	lines := make([]string, len(rows))
+	for k, row := range rows {
+		for i, v := range row {
+			if i < len(row)-1 {
+				v += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(v)+2)
+			}
+			lines[k] += v
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Formats a token bucket as a row of the limiter table
+func bucketRow(name string, b *TokenBucket, rate float64, burst float64) []string {
+	tokens, full := b.Snapshot(rate, burst)
+	state := "full"
+	if full > 0 {
+		state = "full in " + TimeDiff(full)
+	}
+	return []string{name, fmt.Sprintf("%.1f/%v tokens", tokens, burst), fmt.Sprintf("%v/sec, %s", rate, state)}
+}
+
+// Shows every limiter for a server as a table, along with the spam limiters of a user if one is given
+func (c *limitersCommand) dump(args []string, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	guild := info
+	if len(args) > 0 && !strings.HasPrefix(args[0], "<@") {
+		var e string
+		if guild, e = findAnyGuild(args[0]); guild == nil {
+			return e, false, nil
+		}
+		args = args[1:]
+	}
+	if guild == nil {
+		return "```You have to give a server ID when using this in a private message.```", false, nil
+	}
+	limits := sb.CommandLimits
+	now := time.Now().UTC().Unix()
+	rows := [][]string{{"LIMITER", "LEVEL", "LIMIT"}}
+	rows = append(rows, bucketRow("Global commands", &sb.commandbucket, limits.GlobalRate, limits.GlobalBurst))
+	rows = append(rows, bucketRow("Private messages", &sb.dmbucket, dmRate, dmBurst))
+	if n, size := sb.ratelimits.Snapshot(now - 60); size > 0 {
+		rows = append(rows, []string{"Discord 429s", fmt.Sprintf("%v in the last minute", n), fmt.Sprintf("alert at %v", sb.RateLimitAlert.Threshold)})
+	}
+	rows = append(rows, bucketRow("Server commands", &guild.commandbucket, limits.GuildRate, limits.GuildBurst))
+	if guild.config.Modules.CommandPerDuration > 0 {
+		n, _ := guild.commandlimit.Snapshot(now - guild.config.Modules.CommandMaxDuration)
+		rows = append(rows, []string{"Server command limit", fmt.Sprintf("%v/%v commands", n, guild.config.Modules.CommandPerDuration), "per " + TimeDiff(time.Duration(guild.config.Modules.CommandMaxDuration)*time.Second)})
+	}
+	if rate, burst, ok := chatterBudget(guild); ok {
+		rows = append(rows, bucketRow("Chatter budget", &guild.chatterbucket, rate, burst))
+	}
+	cooldowns := [][]string{}
+	guild.commandLock.RLock()
+	for channel, cmds := range guild.commandLast {
+		for cmd, last := range cmds {
+			if limit := guild.config.Modules.CommandLimits[cmd]; now-last <= limit {
+				cooldowns = append(cooldowns, []string{"Cooldown " + cmd + " in #" + getChannelName(channel), TimeDiff(time.Duration(limit-(now-last))*time.Second) + " left", "once every " + TimeDiff(time.Duration(limit)*time.Second)})
+			}
+		}
+	}
+	guild.commandLock.RUnlock()
+	sort.Slice(cooldowns, func(i, j int) bool { return cooldowns[i][0] < cooldowns[j][0] })
+	rows = append(rows, cooldowns...)
+	if len(args) > 0 {
+		user := SBatoi(StripPing(args[0]))
+		for _, m := range guild.modules {
+			if w, ok := m.(*SpamModule); ok {
+				rows = append(rows, w.limiterRows(guild, user)...)
+			}
+		}
+	}
+	logAdminAction(msg.Author, "Dumped limiter state for "+guild.Name)
+	return "```\n" + guild.Name + " (" + guild.ID + ")\n" + limiterTable(rows) + "```", len(rows) > 30, nil
+}
+func (c *limitersCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Restricted command that shows the state of the bot's rate limiters, for debugging. `limiters dump [server ID] [@user]` shows a table of every limiter for the server, including how full each token bucket is, how many recent commands count towards the server's command limit, and every command cooldown still in effect. If a user is given, their spam pressure and spam limiters are included too.",
+		Params: []CommandUsageParam{
+			{Name: "server", Desc: "The name or ID of a server to also show the limiters of.", Optional: true},
+		},
+	}
+}
+func (c *limitersCommand) UsageShort() string { return "[RESTRICTED] Shows rate limiter state." }
+
+type jobsCommand struct {
+}
+
+func (c *jobsCommand) Name() string {
+	return "Jobs"
+}
+func (c *jobsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !isBotOwner(msg.Author.ID) {
+		return "```Only the owner of the bot itself can call this!```", false, nil
+	}
+	jobs := sb.cron.Jobs()
+	if len(jobs) == 0 {
+		return "```There are no recurring jobs.```", false, nil
+	}
+	lines := make([]string, 0, len(jobs)+1)
+	lines = append(lines, "Recurring jobs:")
+	now := time.Now().UTC()
+	for _, j := range jobs {
+		line := fmt.Sprintf("%s [%s] next run in %s", j.Name, j.Spec, TimeDiff(j.Next.Sub(now)))
+		if !j.Last.IsZero() {
+			line += ", last ran " + TimeDiff(now.Sub(j.Last)) + " ago"
+		}
+		lines = append(lines, line)
+	}
+	return "```" + strings.Join(lines, "\n") + "```", false, nil
+}
+func (c *jobsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{Desc: "Restricted command that lists the bot's recurring jobs and when they will run next."}
+}
+func (c *jobsCommand) UsageShort() string { return "[RESTRICTED] Lists recurring jobs." }
+
+type droppedCommand struct {
+}
+
+func (c *droppedCommand) Name() string {
+	return "Dropped"
+}
+func (c *droppedCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !isBotOwner(msg.Author.ID) {
+		return "```Only the owner of the bot itself can call this!```", false, nil
+	}
+	kind := ""
+	if len(args) > 0 {
+		for _, k := range droppedKinds {
+			if strings.ToLower(args[0]) == k {
+				kind = k
+			}
+		}
+		if kind != "" {
+			args = args[1:]
+			indices = indices[1:]
+		}
+	}
+	guild := ""
+	if len(args) > 0 {
+		g, e := findAnyGuild(msg.Content[indices[0]:])
+		if g == nil {
+			return e, false, nil
+		}
+		guild = g.ID
+	}
+	events := droppedEvents.recent(20, kind, guild)
+	if len(events) == 0 {
+		return "```Nothing has been dropped since the bot started.```", false, nil
+	}
+	lines := make([]string, 0, len(events)+1)
+	lines = append(lines, "Most recent first:")
+	for _, e := range events {
+		lines = append(lines, e.String())
+	}
+	logAdminAction(msg.Author, "Dumped dropped events")
+	return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```", true, nil
+}
+func (c *droppedCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Restricted command that shows the last 20 things the bot threw away to protect itself under load, such as commands over the rate limit, messages that couldn't be sent, and persona messages over the webhook rate limit. Only the last " + fmt.Sprint(deadLetterSize) + " are remembered, and they are forgotten when the bot restarts.",
+		Params: []CommandUsageParam{
+			{Name: strings.Join(droppedKinds, "|"), Desc: "Only show one kind of dropped event.", Optional: true},
+			{Name: "server", Desc: "The name or ID of a server to only show its dropped events.", Optional: true},
+		},
+	}
+}
+func (c *droppedCommand) UsageShort() string {
+	return "[RESTRICTED] Shows what was dropped under load."
+}
+
+type setDMResponseCommand struct {
+}
+
+func (c *setDMResponseCommand) Name() string {
+	return "SetDMResponse"
+}
+func (c *setDMResponseCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !isBotOwner(msg.Author.ID) {
+		return "```Only the owner of the bot itself can call this!```", false, nil
+	}
+	if len(args) < 1 {
+		return "```Private messages that aren't commands are currently handled with: " + sb.DMResponse.Mode + "\nReply: " + sb.DMResponse.Message + "```", false, nil
+	}
+	mode := strings.ToLower(args[0])
+	if mode != "ignore" && mode != "reply" && mode != "forward" {
+		return "```The mode must be ignore, reply or forward.```", false, nil
+	}
+	sb.DMResponse.Mode = mode
+	if len(args) > 1 {
+		sb.DMResponse.Message = msg.Content[indices[1]:]
+	}
+	data, err := json.Marshal(struct {
+		DMResponse DMResponse `json:"dmresponse"`
+	}{sb.DMResponse})
+	if err == nil {
+		err = os.WriteFile("dmresponse", data, 0664)
+	}
+	if err != nil {
+		return "```Changed the DM response, but couldn't save it: " + err.Error() + "```", false, nil
+	}
+	logAdminAction(msg.Author, "Set the DM response to "+mode)
+	return "```Private messages that aren't commands will now be handled with: " + mode + "```", false, nil
+}
+func (c *setDMResponseCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Restricted command that sets what the bot does with private messages that aren't commands. `ignore` does nothing, `reply` responds with a canned message, and `forward` passes the message on to the mod channel of the user's default server. Responses are rate limited across the whole bot. The setting is saved in the `dmresponse` file.",
+		Params: []CommandUsageParam{
+			{Name: "ignore/reply/forward", Desc: "How to handle private messages. If omitted, shows the current setting.", Optional: true},
+			{Name: "message", Desc: "The canned reply, also used when a forwarded message has nowhere to go.", Optional: true},
+		},
+	}
+}
+func (c *setDMResponseCommand) UsageShort() string { return "[RESTRICTED] Sets how DMs are handled." }
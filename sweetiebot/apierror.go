@@ -0,0 +1,124 @@
+package sweetiebot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// APIErrorType classifies why a discord API call failed
+type APIErrorType int
+
+// Classes of discord API errors
+const (
+	APIErrorNone APIErrorType = iota
+	APIErrorPermission
+	APIErrorNotFound
+	APIErrorRateLimited
+	APIErrorServer
+	APIErrorOther
+)
+
+func (t APIErrorType) String() string {
+	switch t {
+	case APIErrorNone:
+		return "none"
+	case APIErrorPermission:
+		return "permission"
+	case APIErrorNotFound:
+		return "notfound"
+	case APIErrorRateLimited:
+		return "ratelimited"
+	case APIErrorServer:
+		return "server"
+	}
+	return "other"
+}
+
+// discordErrorCode extracts the JSON error code from a discord REST error, or returns 0
+func discordErrorCode(err error) int {
+	var rest *discordgo.RESTError
+	if errors.As(err, &rest) && rest.Message != nil {
+		return rest.Message.Code
+	}
+	return 0
+}
+
+// ClassifyAPIError figures out what kind of error a discord API call returned
+func ClassifyAPIError(err error) APIErrorType {
+	if err == nil {
+		return APIErrorNone
+	}
+	var limit *discordgo.RateLimitError
+	if errors.As(err, &limit) {
+		return APIErrorRateLimited
+	}
+	var rest *discordgo.RESTError
+	if !errors.As(err, &rest) || rest.Response == nil {
+		return APIErrorOther
+	}
+	switch code := rest.Response.StatusCode; {
+	case code == http.StatusForbidden || code == http.StatusUnauthorized:
+		return APIErrorPermission
+	case code == http.StatusNotFound:
+		return APIErrorNotFound
+	case code == http.StatusTooManyRequests:
+		return APIErrorRateLimited
+	case code >= 500:
+		return APIErrorServer
+	}
+	return APIErrorOther
+}
+
+// Turns a discord API error into something a user can understand
+func apiErrorMessage(err error) string {
+	switch ClassifyAPIError(err) {
+	case APIErrorPermission:
+		return "```I don't have permission to do that! Make sure my role has the right permissions and is above the roles of the user I'm acting on.```"
+	case APIErrorNotFound:
+		return "```Discord says that doesn't exist anymore. It may have already been deleted, or the user may have left the server.```"
+	case APIErrorRateLimited:
+		return "```Discord is rate limiting me right now. Try again in a little while.```"
+	case APIErrorServer:
+		return "```Discord is having problems right now. Try again in a little while.```"
+	}
+	return "```Error: " + err.Error() + "```"
+}
+
+// Calls that create something new each time they succeed. Discord can return a server error after it already handled
+// the request, so retrying one of these after a server error could post the same message or create the same channel twice.
+var nonIdempotentCalls = map[string]bool{
+	"ChannelMessageSendComplex": true,
+	"ChannelMessageSendEmbed":   true,
+	"GuildChannelCreateComplex": true,
+	"GuildRoleCreate":           true,
+	"GuildScheduledEventCreate": true,
+	"AutoModerationRuleCreate":  true,
+	"MessageThreadStart":        true,
+	"WebhookCreate":             true,
+	"WebhookExecute":            true,
+}
+
+// CallAPI runs a discord API call, retrying it with exponential backoff if it was rate limited or discord had a server error.
+// Calls in nonIdempotentCalls are only retried if they were rate limited, since discord never handled those requests.
+// Every failure is counted in the api_errors metric under its error type. Returns the last error, if any.
+func CallAPI(name string, call func() error) error {
+	backoff := time.Second
+	for i := 0; ; i++ {
+		err := call()
+		ty := ClassifyAPIError(err)
+		if ty == APIErrorNone {
+			return nil
+		}
+		metricAPIErrors.Add(ty.String(), 1)
+		if (ty != APIErrorRateLimited && (ty != APIErrorServer || nonIdempotentCalls[name])) || i >= 2 {
+			fmt.Printf("[%s] %s failed (%s): %s\n", time.Now().Format(time.Stamp), name, ty, err.Error())
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
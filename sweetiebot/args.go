@@ -0,0 +1,278 @@
+package sweetiebot
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ArgType determines how a declared command argument is parsed and validated
+type ArgType int
+
+// Argument types
+const (
+	ArgString   ArgType = iota
+	ArgInt              // A whole number
+	ArgBool             // Only useful for flags, which are then set by just giving --flag
+	ArgUser             // A ping, ID or name of a user. If several users match, the caller is asked which one they meant
+	ArgChannel          // A #channel, ID or name of a channel on this server
+	ArgDuration         // Something like 90s, 30m, 2h30m, 3d or 2w
+	ArgRole             // A ping, ID or name of a role on this server
+)
+
+// CommandArg declares a single argument of a command. Positional arguments are matched in order, while flags can be given
+// anywhere before a variadic argument as --name value or --name=value. A variadic positional argument must come last, and
+// takes the rest of the message as it is, so text like --this inside it is never mistaken for a flag.
+type CommandArg struct {
+	Name     string
+	Desc     string
+	Type     ArgType
+	Flag     bool
+	Optional bool
+	Variadic bool
+}
+
+// CommandWithArgs is a command that declares its arguments, so the dispatcher can parse and validate them before running it
+type CommandWithArgs interface {
+	Command
+	Args() []CommandArg
+	Run(*ParsedArgs, *discordgo.Message, *GuildInfo) (string, bool, *discordgo.MessageEmbed)
+}
+
+// ParsedArgs holds the validated values of a command's arguments, by name
+type ParsedArgs struct {
+	values map[string]interface{}
+}
+
+// Has returns true if the argument was given
+func (a *ParsedArgs) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+// String returns a string argument, or the ID of a channel or role argument
+func (a *ParsedArgs) String(name string) string {
+	s, _ := a.values[name].(string)
+	return s
+}
+
+// Int returns an integer argument
+func (a *ParsedArgs) Int(name string) int64 {
+	i, _ := a.values[name].(int64)
+	return i
+}
+
+// Bool returns a boolean argument
+func (a *ParsedArgs) Bool(name string) bool {
+	b, _ := a.values[name].(bool)
+	return b
+}
+
+// User returns the ID of a user argument
+func (a *ParsedArgs) User(name string) uint64 {
+	u, _ := a.values[name].(uint64)
+	return u
+}
+
+// Duration returns a duration argument
+func (a *ParsedArgs) Duration(name string) time.Duration {
+	d, _ := a.values[name].(time.Duration)
+	return d
+}
+
+func isArgFlag(s string) bool {
+	return len(s) > 2 && strings.HasPrefix(s, "--")
+}
+
+// Parses durations like time.ParseDuration does, but also accepts days and weeks
+func parseArgDuration(s string) (time.Duration, error) {
+	s = strings.ToLower(s)
+	if n, err := strconv.Atoi(strings.TrimRight(s, "dw")); err == nil && len(s) > 1 {
+		switch s[len(s)-1] {
+		case 'd':
+			return time.Duration(n) * 24 * time.Hour, nil
+		case 'w':
+			return time.Duration(n) * 7 * 24 * time.Hour, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+func parseArgValue(arg CommandArg, value string, msg *discordgo.Message, info *GuildInfo) (interface{}, string) {
+	switch arg.Type {
+	case ArgInt:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, arg.Name + " must be a whole number, not " + value + "."
+		}
+		return i, ""
+	case ArgBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, arg.Name + " must be true or false, not " + value + "."
+		}
+		return b, ""
+	case ArgUser:
+		id, e := Resolve(info, msg, EntityMember, value)
+		if len(id) == 0 {
+			return nil, e
+		}
+		return SBatoi(id), ""
+	case ArgChannel:
+		id, e := Resolve(info, msg, EntityChannel, value)
+		if len(id) == 0 {
+			return nil, e
+		}
+		return id, ""
+	case ArgRole:
+		id, e := Resolve(info, msg, EntityRole, value)
+		if len(id) == 0 {
+			return nil, e
+		}
+		return id, ""
+	case ArgDuration:
+		d, err := parseArgDuration(value)
+		if err != nil || d <= 0 {
+			return nil, arg.Name + " must be a duration like 30m, 12h or 3d, not " + value + "."
+		}
+		return d, ""
+	}
+	return value, ""
+}
+
+// ParseCommandArgs matches the arguments given to a command against the ones it declares. The indices and the message's
+// content are used to preserve the original formatting of variadic arguments, and whoever sent the message is asked to pick
+// if a name matches several users, channels or roles. Returns a description of the problem if the arguments are invalid.
+func ParseCommandArgs(spec []CommandArg, args []string, indices []int, msg *discordgo.Message, info *GuildInfo) (*ParsedArgs, string) {
+	content := msg.Content
+	parsed := &ParsedArgs{values: make(map[string]interface{})}
+	positional := []CommandArg{}
+	flags := make(map[string]CommandArg)
+	for _, a := range spec {
+		if a.Flag {
+			flags[strings.ToLower(a.Name)] = a
+		} else {
+			positional = append(positional, a)
+		}
+	}
+
+	pos := 0
+	for i := 0; i < len(args); i++ {
+		if isArgFlag(args[i]) {
+			name := args[i][2:]
+			value := ""
+			hasvalue := false
+			if j := strings.IndexByte(name, '='); j >= 0 {
+				name, value, hasvalue = name[:j], name[j+1:], true
+			}
+			f, ok := flags[strings.ToLower(name)]
+			if !ok {
+				return nil, "Unknown flag --" + name + "."
+			}
+			if !hasvalue && f.Type == ArgBool {
+				parsed.values[f.Name] = true
+				continue
+			}
+			if !hasvalue {
+				if i+1 >= len(args) {
+					return nil, "--" + f.Name + " needs a value."
+				}
+				i++
+				value = args[i]
+			}
+			v, e := parseArgValue(f, value, msg, info)
+			if v == nil {
+				return nil, e
+			}
+			parsed.values[f.Name] = v
+			continue
+		}
+
+		if pos >= len(positional) {
+			return nil, "Too many arguments, starting at " + args[i] + ". Put arguments with spaces in quotes."
+		}
+		p := positional[pos]
+		pos++
+		value := args[i]
+		if p.Variadic {
+			if i+1 < len(args) {
+				if len(indices) == len(args) && len(content) > 0 {
+					value = strings.TrimSpace(content[indices[i]:])
+				} else {
+					value = strings.Join(args[i:], " ")
+				}
+			}
+			i = len(args)
+		}
+		v, e := parseArgValue(p, value, msg, info)
+		if v == nil {
+			return nil, e
+		}
+		parsed.values[p.Name] = v
+	}
+
+	for _, p := range positional[pos:] {
+		if !p.Optional {
+			return nil, "Missing " + p.Name + "."
+		}
+	}
+	missing := []string{}
+	for _, f := range flags {
+		if !f.Optional && !parsed.Has(f.Name) {
+			missing = append(missing, "--"+f.Name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, "Missing " + strings.Join(missing, ", ") + "."
+	}
+	return parsed, ""
+}
+
+// ArgsUsageLine builds a one line summary of how to call a command, like "!remindchannel <channel> <schedule> <message...>"
+func ArgsUsageLine(c CommandWithArgs, info *GuildInfo) string {
+	s := info.config.Basic.CommandPrefix + strings.ToLower(c.Name())
+	for _, a := range c.Args() {
+		name := a.Name
+		if a.Flag {
+			name = "--" + name
+			if a.Type != ArgBool {
+				name += " " + a.Name
+			}
+		}
+		if a.Variadic {
+			name += "..."
+		}
+		if a.Optional {
+			s += " [" + name + "]"
+		} else {
+			s += " <" + name + ">"
+		}
+	}
+	return s
+}
+
+// ArgsToParams builds the help parameters for a command from its declared arguments
+func ArgsToParams(args []CommandArg) []CommandUsageParam {
+	params := make([]CommandUsageParam, 0, len(args))
+	for _, a := range args {
+		name := a.Name
+		if a.Flag {
+			name = "--" + name
+		}
+		params = append(params, CommandUsageParam{Name: name, Desc: a.Desc, Optional: a.Optional, Variadic: a.Variadic})
+	}
+	return params
+}
+
+// RunWithArgs parses a command's arguments and runs it, or explains how to use it if the arguments are invalid
+func RunWithArgs(c CommandWithArgs, args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	parsed, e := ParseCommandArgs(c.Args(), args, indices, msg, info)
+	if parsed == nil {
+		return "```" + e + "\nUsage: " + ArgsUsageLine(c, info) + "```", false, nil
+	}
+	return c.Run(parsed, msg, info)
+}
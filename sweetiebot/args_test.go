@@ -0,0 +1,76 @@
+package sweetiebot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Parses a command line the same way the dispatcher does, leaving out the command name
+func parseTestArgs(spec []CommandArg, content string) (*ParsedArgs, string) {
+	args, indices := ParseArguments(content[1:])
+	return ParseCommandArgs(spec, args[1:], indices[1:], &discordgo.Message{Content: content}, &GuildInfo{})
+}
+
+func TestParseCommandArgs(t *testing.T) {
+	spec := []CommandArg{
+		{Name: "count", Type: ArgInt},
+		{Name: "every", Type: ArgDuration, Optional: true},
+		{Name: "quiet", Type: ArgBool, Flag: true, Optional: true},
+		{Name: "label", Type: ArgString, Flag: true, Optional: true},
+		{Name: "message", Type: ArgString, Optional: true, Variadic: true},
+	}
+	cases := []struct {
+		name    string
+		content string
+		err     string
+		want    map[string]interface{}
+	}{
+		{"positional only", "!cmd 3", "", map[string]interface{}{"count": int64(3)}},
+		{"duration with days", "!cmd 3 2d", "", map[string]interface{}{"count": int64(3), "every": 48 * time.Hour}},
+		{"bool flag", "!cmd --quiet 3", "", map[string]interface{}{"count": int64(3), "quiet": true}},
+		{"flag with value", "!cmd --label hello 3", "", map[string]interface{}{"count": int64(3), "label": "hello"}},
+		{"flag with equals", "!cmd 3 --label=hi", "", map[string]interface{}{"count": int64(3), "label": "hi"}},
+		{"variadic keeps formatting", "!cmd 3 1h hello   there,  world", "", map[string]interface{}{"message": "hello   there,  world"}},
+		{"flags inside variadic are text", "!cmd 3 1h use --quiet to hide it", "", map[string]interface{}{"message": "use --quiet to hide it"}},
+		{"flag before variadic", "!cmd --quiet 3 1h some text", "", map[string]interface{}{"quiet": true, "message": "some text"}},
+		{"missing positional", "!cmd", "Missing count.", nil},
+		{"bad int", "!cmd three", "count must be a whole number, not three.", nil},
+		{"bad duration", "!cmd 3 soon", "every must be a duration like 30m, 12h or 3d, not soon.", nil},
+		{"unknown flag", "!cmd 3 --loud", "Unknown flag --loud.", nil},
+		{"flag without value", "!cmd 3 --label", "--label needs a value.", nil},
+	}
+	for _, c := range cases {
+		parsed, e := parseTestArgs(spec, c.content)
+		if e != c.err {
+			t.Errorf("%s: error = %q, want %q", c.name, e, c.err)
+			continue
+		}
+		for k, v := range c.want {
+			if parsed.values[k] != v {
+				t.Errorf("%s: %s = %#v, want %#v", c.name, k, parsed.values[k], v)
+			}
+		}
+	}
+}
+
+func TestParseCommandArgsTooMany(t *testing.T) {
+	spec := []CommandArg{{Name: "a", Type: ArgString}}
+	if _, e := parseTestArgs(spec, "!cmd one two"); e != "Too many arguments, starting at two. Put arguments with spaces in quotes." {
+		t.Errorf("error = %q", e)
+	}
+}
+
+func TestParseCommandArgsMissingFlags(t *testing.T) {
+	spec := []CommandArg{
+		{Name: "zeta", Type: ArgString, Flag: true},
+		{Name: "alpha", Type: ArgString, Flag: true},
+		{Name: "mid", Type: ArgString, Flag: true},
+	}
+	for i := 0; i < 20; i++ { // Map order changes from run to run, so make sure the message doesn't
+		if _, e := parseTestArgs(spec, "!cmd --mid x"); e != "Missing --alpha, --zeta." {
+			t.Fatalf("error = %q, want %q", e, "Missing --alpha, --zeta.")
+		}
+	}
+}
@@ -0,0 +1,686 @@
+package sweetiebot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Import files larger than this are refused, since everything in them ends up in the config
+const importMaxFileSize = 512 * 1024
+
+// No more than this many rows are read from one file
+const importMaxRows = 5000
+
+// Only this many validation errors are listed in a preview
+const importMaxErrors = 10
+
+// A row of an import file, with its fields renamed to the names the importers use
+type importRow struct {
+	n      int // the position of the row in the file, for error messages
+	fields map[string]string
+}
+
+// importSource maps the field names another bot uses in its exports to the names the importers use. Each field can
+// have several names, which are tried in order.
+type importSource struct {
+	name   string
+	fields map[string][]string
+}
+
+var importSources = []*importSource{
+	{"sweetiebot", map[string][]string{
+		"user": {"user"}, "quote": {"quote"},
+		"name": {"name"}, "content": {"content"},
+		"menu": {"menu"}, "role": {"role"}, "label": {"label"}, "description": {"description"}, "emoji": {"emoji"}, "exclusive": {"exclusive"},
+	}},
+	{"carlbot", map[string][]string{
+		"user": {"author_id", "user_id", "author"}, "quote": {"content", "text", "quote"},
+		"name": {"name", "tag"}, "content": {"content", "text"},
+		"menu": {"message_id", "message"}, "role": {"role_id", "role"}, "label": {"role_name"}, "emoji": {"emoji", "reaction"}, "exclusive": {"unique"},
+	}},
+	{"yagpdb", map[string][]string{
+		"user": {"author_id", "user_id", "author"}, "quote": {"message", "content"},
+		"name": {"name", "key"}, "content": {"response", "value", "body"},
+		"menu": {"group_name", "group"}, "role": {"role_id", "role"}, "label": {"name", "role_name"}, "description": {"description"}, "emoji": {"emoji"}, "exclusive": {"single"},
+	}},
+}
+
+func findImportSource(name string) *importSource {
+	for _, s := range importSources {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Returns the source whose field names match the most columns of the file, preferring our own names if it's a tie
+func detectImportSource(kind *importKind, columns map[string]string) *importSource {
+	best, score := importSources[0], -1
+	for _, s := range importSources {
+		n := 0
+		for _, f := range kind.fields {
+			for _, name := range s.fields[f] {
+				if _, ok := columns[name]; ok {
+					n++
+					break
+				}
+			}
+		}
+		if n > score {
+			best, score = s, n
+		}
+	}
+	return best
+}
+
+// What an import file creates. The first required fields of each row must be there, the rest are optional. native reads
+// the same map the config stores this in, so anything exported with !config can be imported again.
+type importKind struct {
+	name     string
+	fields   []string
+	required int
+	native   func(data []byte) ([]importRow, error)
+	plan     func(info *GuildInfo, user string, rows []importRow) *importPlan
+}
+
+var roleMenuImport = &importKind{"rolemenus", []string{"menu", "role", "label", "description", "emoji", "exclusive"}, 2, nativeRoleMenuRows, planRoleMenuImport}
+
+var importKinds = map[string]*importKind{
+	"quote":        {"quotes", []string{"user", "quote"}, 2, nativeQuoteRows, planQuoteImport},
+	"tag":          {"tags", []string{"name", "content"}, 2, nativeTagRows, planTagImport},
+	"rolemenu":     roleMenuImport,
+	"reactionrole": roleMenuImport, // what other bots call role menus
+}
+
+// What an import would do, worked out before anything is changed so it can be previewed. apply makes the changes, undo
+// reverts them if the config turns out to be too large, and done runs once they've been saved.
+type importPlan struct {
+	what    string // what's being imported, like " quote"
+	added   int
+	changed int // entries already on the server that will be overwritten
+	skipped int // rows that are already on the server exactly as they are
+	errors  []string
+	apply   func()
+	undo    func()
+	done    func()
+}
+
+func (p *importPlan) fail(row importRow, format string, a ...interface{}) {
+	p.errors = append(p.errors, fmt.Sprintf("Row %v: ", row.n)+truncateRunes(fmt.Sprintf(format, a...), 150))
+}
+
+func (p *importPlan) summary(rows int) string {
+	s := fmt.Sprintf("Read %s from the file.\nNew: %s\nReplaced: %v\nAlready here: %v\nErrors: %v", Pluralize(int64(rows), " row"), Pluralize(int64(p.added), p.what), p.changed, p.skipped, len(p.errors))
+	for i, e := range p.errors {
+		if i >= importMaxErrors {
+			s += fmt.Sprintf("\n  ...and %v more.", len(p.errors)-importMaxErrors)
+			break
+		}
+		s += "\n  " + e
+	}
+	return s
+}
+
+// Converts a JSON value from an import file into the text the importers expect
+func importValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Reads a list of JSON objects, keyed by their lowercased field names
+func parseImportJSON(data []byte) ([]map[string]string, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var list []map[string]interface{}
+	if err := d.Decode(&list); err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]string, 0, len(list))
+	for _, obj := range list {
+		row := make(map[string]string, len(obj))
+		for k, v := range obj {
+			row[strings.ToLower(k)] = importValue(v)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Reads a CSV file whose first line names the columns, skipping blank lines
+func parseImportCSV(data []byte) ([]map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		if len(rec) == 1 && len(strings.TrimSpace(rec[0])) == 0 {
+			continue
+		}
+		row := make(map[string]string, len(header))
+		for i, v := range rec {
+			if i < len(header) {
+				row[header[i]] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Reads an import file, which can be a CSV file with a header line, a JSON list of objects, a JSON object holding a
+// single such list, or the map the config itself stores this kind of thing in. Columns are renamed using source, or
+// whichever source matches best if it's nil, and overrides, which give the column to use for a field directly.
+func readImportRows(kind *importKind, data []byte, source *importSource, overrides map[string]string) ([]importRow, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if data[0] == '{' {
+		var wrapper map[string]json.RawMessage
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, err
+		}
+		if len(wrapper) == 1 {
+			for _, v := range wrapper {
+				var probe []map[string]json.RawMessage
+				if json.Unmarshal(v, &probe) == nil {
+					data = bytes.TrimSpace(v)
+				}
+			}
+		}
+		if data[0] == '{' {
+			return kind.native(data)
+		}
+	}
+	var raw []map[string]string
+	var err error
+	if data[0] == '[' {
+		raw, err = parseImportJSON(data)
+	} else {
+		raw, err = parseImportCSV(data)
+	}
+	if err != nil || len(raw) == 0 {
+		return nil, err
+	}
+	if source == nil {
+		source = detectImportSource(kind, raw[0])
+	}
+	names := make(map[string][]string, len(kind.fields))
+	for _, f := range kind.fields {
+		names[f] = source.fields[f]
+		if o, ok := overrides[f]; ok {
+			names[f] = []string{o}
+		}
+	}
+	for _, f := range kind.fields[:kind.required] {
+		found := false
+		for _, n := range names[f] {
+			if _, ok := raw[0][n]; ok {
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.New("the file has no column for " + f + ". Say which column to use with " + f + "=<column>")
+		}
+	}
+	rows := make([]importRow, 0, len(raw))
+	for i, r := range raw {
+		row := importRow{i + 1, make(map[string]string, len(kind.fields))}
+		for _, f := range kind.fields {
+			for _, n := range names[f] {
+				if v, ok := r[n]; ok {
+					row.fields[f] = strings.TrimSpace(v)
+					break
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func nativeQuoteRows(data []byte) ([]importRow, error) {
+	var quotes map[string][]string
+	if err := json.Unmarshal(data, &quotes); err != nil {
+		return nil, err
+	}
+	users := make([]string, 0, len(quotes))
+	for k := range quotes {
+		users = append(users, k)
+	}
+	sort.Strings(users)
+	rows := []importRow{}
+	for _, u := range users {
+		for _, q := range quotes[u] {
+			rows = append(rows, importRow{len(rows) + 1, map[string]string{"user": u, "quote": q}})
+		}
+	}
+	return rows, nil
+}
+
+func nativeTagRows(data []byte) ([]importRow, error) {
+	var tags map[string]string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, err
+	}
+	names := MapStringToSlice(tags)
+	sort.Strings(names)
+	rows := make([]importRow, 0, len(names))
+	for _, k := range names {
+		rows = append(rows, importRow{len(rows) + 1, map[string]string{"name": k, "content": tags[k]}})
+	}
+	return rows, nil
+}
+
+func nativeRoleMenuRows(data []byte) ([]importRow, error) {
+	var menus map[string]*RoleMenu
+	if err := json.Unmarshal(data, &menus); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(menus))
+	for k := range menus {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	rows := []importRow{}
+	for _, k := range names {
+		if menus[k] == nil {
+			continue
+		}
+		for _, o := range menus[k].Roles {
+			rows = append(rows, importRow{len(rows) + 1, map[string]string{"menu": k, "role": SBitoa(o.Role), "label": o.Label, "description": o.Description, "emoji": o.Emoji, "exclusive": strconv.FormatBool(menus[k].Exclusive)}})
+		}
+	}
+	return rows, nil
+}
+
+// Finds the user a row of an import file refers to, by ping, ID or a name that only one user has. Members who have
+// left can only be given by ID.
+func importUser(info *GuildInfo, s string) (uint64, string) {
+	if len(s) == 0 {
+		return 0, "no user was given"
+	}
+	if id := SBatoi(StripPing(s)); id != 0 && len(StripPing(s)) > 15 {
+		return id, ""
+	}
+	IDs := FindUsername(strings.ToLower(s), info)
+	if len(IDs) == 0 {
+		return 0, "there's no user called " + s
+	}
+	if len(IDs) > 1 {
+		return 0, s + " could be " + Pluralize(int64(len(IDs)), " different user") + ", so use their ID instead"
+	}
+	return IDs[0], ""
+}
+
+func planQuoteImport(info *GuildInfo, user string, rows []importRow) *importPlan {
+	p := &importPlan{what: " quote"}
+	limit := info.quoteLimit()
+	used := countQuotes(info)
+	seen := make(map[uint64]map[string]bool)
+	added := make(map[uint64][]string)
+	over := 0
+	for _, row := range rows {
+		text := row.fields["quote"]
+		if len(text) == 0 {
+			p.fail(row, "the quote is empty")
+			continue
+		}
+		id, e := importUser(info, row.fields["user"])
+		if id == 0 {
+			p.fail(row, "%s", e)
+			continue
+		}
+		if seen[id] == nil {
+			seen[id] = make(map[string]bool)
+			for _, q := range info.config.Quote.Quotes[id] {
+				seen[id][q] = true
+			}
+		}
+		if seen[id][text] {
+			p.skipped++
+			continue
+		}
+		if overLimit(used, p.added+1, limit) {
+			over++
+			continue
+		}
+		seen[id][text] = true
+		added[id] = append(added[id], text)
+		p.added++
+	}
+	if over > 0 {
+		p.errors = append([]string{fmt.Sprintf("%s would go over this server's limit of %v quotes.", Pluralize(int64(over), " more quote"), limit)}, p.errors...)
+	}
+	old := make(map[uint64]int)
+	p.apply = func() {
+		if len(info.config.Quote.Quotes) == 0 {
+			info.config.Quote.Quotes = make(map[uint64][]string)
+		}
+		for id, q := range added {
+			old[id] = len(info.config.Quote.Quotes[id])
+			info.config.Quote.Quotes[id] = append(info.config.Quote.Quotes[id], q...)
+		}
+	}
+	p.undo = func() {
+		for id, n := range old {
+			if n == 0 {
+				delete(info.config.Quote.Quotes, id)
+			} else {
+				info.config.Quote.Quotes[id] = info.config.Quote.Quotes[id][:n]
+			}
+		}
+	}
+	return p
+}
+
+// Other bots allow dashes and spaces in tag names, which variables can't have
+var importTagName = strings.NewReplacer("-", "_", " ", "_")
+
+// Tags become server variables, so {name} anywhere in a configured message is replaced with the tag's content
+func planTagImport(info *GuildInfo, user string, rows []importRow) *importPlan {
+	p := &importPlan{what: " tag"}
+	vars := make(map[string]string, len(info.config.Basic.Variables))
+	for k, v := range info.config.Basic.Variables {
+		vars[k] = v
+	}
+	imported := make(map[string]importRow)
+	order := []string{}
+	over := 0
+	for _, row := range rows {
+		name := importTagName.Replace(strings.ToLower(strings.Trim(row.fields["name"], "{}")))
+		content := row.fields["content"]
+		if !varnameregex.MatchString(name) {
+			p.fail(row, "%s can't be a tag name, because names can only have letters, numbers and underscores, and can't be longer than 32 characters", row.fields["name"])
+			continue
+		}
+		if templateBuiltins[name] {
+			p.fail(row, "{%s} is filled in automatically, so it can't be a tag", name)
+			continue
+		}
+		if len(content) == 0 {
+			p.fail(row, "{%s} is empty", name)
+			continue
+		}
+		if len([]rune(content)) > maxVariableLength {
+			p.fail(row, "{%s} is longer than %v characters", name, maxVariableLength)
+			continue
+		}
+		cur, exists := vars[name]
+		_, fromFile := imported[name]
+		switch {
+		case fromFile:
+		case exists && cur == content:
+			p.skipped++
+			continue
+		case exists:
+			p.changed++
+		case len(vars) >= maxGuildVariables:
+			over++
+			continue
+		default:
+			p.added++
+		}
+		if !fromFile {
+			order = append(order, name)
+		}
+		vars[name] = content
+		imported[name] = row
+	}
+	if over > 0 {
+		p.errors = append([]string{fmt.Sprintf("%s would go over the limit of %v variables.", Pluralize(int64(over), " more tag"), maxGuildVariables)}, p.errors...)
+	}
+
+	// templateCycle looks at the server's variables, so swap in the imported ones while checking them, the same way
+	// !setvar does
+	prev := info.config.Basic.Variables
+	info.config.Basic.Variables = vars
+	for _, name := range order {
+		if cycle := templateCycle(info, name); cycle != nil {
+			p.fail(imported[name], "that would make {%s} refer to itself: {%s}", name, strings.Join(cycle, "} -> {"))
+			if v, ok := prev[name]; ok {
+				vars[name] = v
+				p.changed--
+			} else {
+				delete(vars, name)
+				p.added--
+			}
+		}
+	}
+	info.config.Basic.Variables = prev
+
+	p.apply = func() { info.config.Basic.Variables = vars }
+	p.undo = func() { info.config.Basic.Variables = prev }
+	return p
+}
+
+func planRoleMenuImport(info *GuildInfo, user string, rows []importRow) *importPlan {
+	p := &importPlan{what: " role"}
+	menus := make(map[string]*RoleMenu)
+	imported := make(map[string]bool)
+	order := []string{}
+	for _, row := range rows {
+		name := strings.ToLower(row.fields["menu"])
+		if len(name) == 0 {
+			p.fail(row, "no menu was given")
+			continue
+		}
+		if !validRoleMenuName(name) {
+			p.fail(row, "%s can't be a role menu name, because names can't have colons in them or be longer than 50 characters", name)
+			continue
+		}
+		role, e := findRole(row.fields["role"], info)
+		if role == nil {
+			p.fail(row, "%s", strings.Replace(strings.Trim(e, "`"), "\n", " ", -1))
+			continue
+		}
+		if e := roleMenuRoleError(info, role, user); len(e) > 0 {
+			p.fail(row, "%s", e)
+			continue
+		}
+		option := RoleMenuOption{Role: SBatoi(role.ID), Label: role.Name, Description: row.fields["description"], Emoji: row.fields["emoji"]}
+		if len(row.fields["label"]) > 0 {
+			option.Label = row.fields["label"]
+		}
+		if e := roleMenuEmojiError(info, option.Emoji); len(e) > 0 {
+			p.fail(row, "%s", e)
+			continue
+		}
+		menu, ok := menus[name]
+		if !ok {
+			menu = &RoleMenu{Posts: make(map[string]string)}
+			if cur, ok := info.config.RoleMenus.Menus[name]; ok && cur != nil {
+				menu = &RoleMenu{Exclusive: cur.Exclusive, Roles: append([]RoleMenuOption{}, cur.Roles...), Posts: cur.Posts}
+			}
+		}
+		exclusive := menu.Exclusive
+		if v := row.fields["exclusive"]; len(v) > 0 {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				p.fail(row, "%s isn't true or false", v)
+				continue
+			}
+			exclusive = b
+		}
+		key := name + "|" + role.ID
+		i := menu.find(option.Role)
+		if i < 0 {
+			if e := roleMenuFullError(exclusive, len(menu.Roles)); len(e) > 0 {
+				p.fail(row, "%s", e)
+				continue
+			}
+		} else if exclusive && len(menu.Roles) > roleMenuPageSize {
+			p.fail(row, "exclusive role menus can only have %v roles", roleMenuPageSize)
+			continue
+		}
+		switch {
+		case i >= 0 && menu.Roles[i] == option && menu.Exclusive == exclusive:
+			p.skipped++
+			continue
+		case i >= 0:
+			menu.Roles[i] = option
+			if !imported[key] {
+				p.changed++
+			}
+		default:
+			menu.Roles = append(menu.Roles, option)
+			p.added++
+		}
+		menu.Exclusive = exclusive
+		imported[key] = true
+		if !ok {
+			menus[name] = menu
+			order = append(order, name)
+		}
+	}
+
+	var prev map[string]*RoleMenu
+	p.apply = func() {
+		prev = info.config.RoleMenus.Menus
+		next := make(map[string]*RoleMenu, len(prev)+len(menus))
+		for k, v := range prev {
+			next[k] = v
+		}
+		for k, v := range menus {
+			next[k] = v
+		}
+		info.config.RoleMenus.Menus = next
+	}
+	p.undo = func() { info.config.RoleMenus.Menus = prev }
+	p.done = func() {
+		for _, name := range order {
+			refreshRoleMenu(info, name, menus[name])
+		}
+	}
+	return p
+}
+
+type bulkImportCommand struct {
+}
+
+func (c *bulkImportCommand) Name() string {
+	return "BulkImport"
+}
+func (c *bulkImportCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must say what to import: quotes, tags or rolemenus.```", false, nil
+	}
+	kind, ok := importKinds[strings.TrimSuffix(strings.ToLower(args[0]), "s")]
+	if !ok {
+		return "```" + args[0] + " can't be imported. You can import quotes, tags or rolemenus.```", false, nil
+	}
+	var source *importSource
+	overrides := make(map[string]string)
+	preview := false
+	url := ""
+	for _, arg := range args[1:] {
+		a := strings.ToLower(arg)
+		switch {
+		case a == "preview" || a == "dryrun":
+			preview = true
+		case strings.HasPrefix(a, "http://") || strings.HasPrefix(a, "https://") || strings.HasPrefix(a, "<http"):
+			url = strings.Trim(arg, "<>")
+		case strings.Contains(a, "="):
+			f := strings.SplitN(a, "=", 2)
+			known := false
+			for _, v := range kind.fields {
+				known = known || v == f[0]
+			}
+			if !known {
+				return "```" + f[0] + " isn't a field of " + kind.name + ". The fields are: " + strings.Join(kind.fields, ", ") + "```", false, nil
+			}
+			overrides[f[0]] = strings.TrimSpace(f[1])
+		default:
+			if source = findImportSource(a); source == nil {
+				names := make([]string, 0, len(importSources))
+				for _, s := range importSources {
+					names = append(names, s.name)
+				}
+				return "```" + arg + " isn't a format I know. Leave it out to guess from the file's columns, or use one of: " + strings.Join(names, ", ") + "```", false, nil
+			}
+		}
+	}
+	if len(msg.Attachments) > 0 {
+		url = msg.Attachments[0].URL
+	}
+	if len(url) == 0 {
+		return "```Attach the CSV or JSON file to import, or give a link to it.```", false, nil
+	}
+	data, _, err := downloadUpload(url, importMaxFileSize)
+	if err != nil {
+		return "```Couldn't download the file: " + err.Error() + "```", false, nil
+	}
+	rows, err := readImportRows(kind, data, source, overrides)
+	if err != nil {
+		return "```Couldn't read the file: " + err.Error() + "```", false, nil
+	}
+	if len(rows) == 0 {
+		return "```There's nothing in that file to import.```", false, nil
+	}
+	if len(rows) > importMaxRows {
+		return fmt.Sprintf("```That file has %v rows, but only %v can be imported at once. Split it into smaller files.```", len(rows), importMaxRows), false, nil
+	}
+
+	p := kind.plan(info, msg.Author.ID, rows)
+	summary := p.summary(len(rows))
+	if preview || p.added+p.changed == 0 {
+		return "```\n" + SanitizeMentions(summary) + "```", false, nil
+	}
+	if !Confirm(info, msg.ChannelID, msg.Author.ID, "```\n"+SanitizeMentions(summary)+"```Import the rows without errors?") {
+		return "```Cancelled the import.```", false, nil
+	}
+	p = kind.plan(info, msg.Author.ID, rows) // In case anything changed while waiting for an answer
+	p.apply()
+	if data, err := json.Marshal(info.config); err != nil || len(data) > sb.MaxConfigSize {
+		p.undo()
+		return "```That would make the config too large to save. Config files can't be larger than " + strconv.Itoa(sb.MaxConfigSize) + " bytes.```", false, nil
+	}
+	info.SaveConfig()
+	if p.done != nil {
+		p.done()
+	}
+	info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " imported ", Pluralize(int64(p.added), p.what), " and replaced ", p.changed, " from a file of ", kind.name, " with ", len(rows), " rows. ", len(p.errors), " rows had errors.")
+	return "```Imported " + Pluralize(int64(p.added), p.what) + " and replaced " + strconv.Itoa(p.changed) + ". " + Pluralize(int64(len(p.errors)), " row") + " had errors and " + strconv.Itoa(p.skipped) + " were already here.```", false, nil
+}
+func (c *bulkImportCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Imports quotes, tags or role menu roles in bulk from an attached CSV or JSON file, such as an export from another bot. Shows how many rows would be created and any rows with errors, and asks for confirmation before importing the rest. CSV files need a header line naming the columns. JSON files can be a list of objects, or the same maps `" + info.config.Basic.CommandPrefix + "config quote.quotes`, `basic.variables` or `rolemenus.menus` show. Tags become server variables, used as `{name}` in configured messages. The server's limits on quotes and variables still apply, and quotes that are already there are skipped.",
+		Params: []CommandUsageParam{
+			{Name: "quotes/tags/rolemenus", Desc: "What the file contains. Quotes need `user` and `quote` columns, tags need `name` and `content`, and role menus need `menu` and `role`, with optional `label`, `description`, `emoji` and `exclusive` columns.", Optional: false},
+			{Name: "format", Desc: "Whose column names the file uses: `sweetiebot`, `carlbot` or `yagpdb`. If left out, this is guessed from the columns.", Optional: true},
+			{Name: "field=column", Desc: "Uses a specific column for a field, like `quote=text`, for files with other column names.", Optional: true},
+			{Name: "preview", Desc: "Only shows what would be imported.", Optional: true},
+		},
+	}
+}
+func (c *bulkImportCommand) UsageShort() string { return "Imports quotes, tags or role menus." }
@@ -0,0 +1,241 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Channel reminders are recurring messages posted on a cron schedule, evaluated in the server's timezone. They are checked
+// once a minute by the channelreminders job, so a reminder that was missed while the bot was offline is posted once on startup.
+
+func runChannelReminders() {
+	if !sb.db.CheckStatus() {
+		return
+	}
+	now := time.Now().UTC()
+	for _, r := range sb.db.GetDueChannelReminders(now) {
+		sb.guildsLock.RLock()
+		info, ok := sb.guilds[r.Guild]
+		sb.guildsLock.RUnlock()
+		if !ok {
+			continue
+		}
+		if _, err := sb.dg.State.Guild(info.ID); err != nil {
+			continue // The guild hasn't loaded yet, so we can't tell if the channel still exists
+		}
+		channel := SBitoa(r.Channel)
+		reason := ""
+		spec, err := ParseCronSpec(r.Spec)
+		if err != nil {
+			reason = "its schedule `" + r.Spec + "` isn't valid: " + err.Error()
+		} else if !info.HasChannel(channel) {
+			reason = "its channel no longer exists"
+		}
+		if len(reason) > 0 {
+			sb.db.PauseChannelReminder(r.ID, r.Guild, true, r.NextRun)
+			info.SendMessage(SBitoa(info.config.Basic.ModChannel), fmt.Sprintf("Channel reminder #%v was paused because %s. Use `%sremovechannelreminder %v` to delete it.", r.ID, reason, info.config.Basic.CommandPrefix, r.ID))
+			continue
+		}
+		sb.db.SetChannelReminderNext(r.ID, spec.NextIn(now, getTimezone(info, nil)))
+		info.SendMessage(channel, r.Message)
+	}
+}
+
+// Finds a reminder by ID on this server
+func getChannelReminder(info *GuildInfo, arg string) (*ChannelReminder, string) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil {
+		return nil, "```" + arg + " is not a valid reminder ID.```"
+	}
+	for _, r := range sb.db.GetChannelReminders(SBatoi(info.ID)) {
+		if r.ID == id {
+			return &r, ""
+		}
+	}
+	return nil, "```There is no channel reminder with that ID on this server.```"
+}
+
+type remindChannelCommand struct {
+}
+
+func (c *remindChannelCommand) Name() string {
+	return "RemindChannel"
+}
+func (c *remindChannelCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "channel", Desc: "The channel to post the reminder in.", Type: ArgChannel},
+		{Name: "schedule", Desc: "When to post the reminder, in quotes."},
+		{Name: "message", Desc: "The message to post.", Variadic: true},
+	}
+}
+func (c *remindChannelCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *remindChannelCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	channel := args.String("channel")
+	schedule := args.String("schedule")
+	spec, err := ParseCronSpec(schedule)
+	if err != nil {
+		return "```Invalid schedule: " + err.Error() + ". Remember to put the schedule in quotes, like \"0 18 * * *\".```", false, nil
+	}
+	if spec.every > 0 && spec.every < time.Hour {
+		return "```Channel reminders can't repeat more than once an hour.```", false, nil
+	}
+	message := sanitizeEveryone(args.String("message"), msg.Author.ID, channel)
+	loc := getTimezone(info, nil)
+	next := spec.NextIn(time.Now().UTC(), loc)
+	if limit := info.channelReminderLimit(); overLimit(sb.db.CountChannelReminders(SBatoi(info.ID)), 1, limit) {
+		return limitReachedMessage(limit, "this server can only have %v channel reminders", "!removechannelreminder"), false, nil
+	}
+	if !sb.db.AddChannelReminder(SBatoi(info.ID), SBatoi(channel), schedule, message, next) {
+		return "```A database error prevented the channel reminder from being saved.```", false, nil
+	}
+	info.Log(getUserName(SBatoi(msg.Author.ID), info), " added a channel reminder in #", getChannelName(channel), " [", schedule, "]")
+	return "```Added channel reminder. It will first be posted on " + next.In(loc).Format("Jan 2 3:04pm MST") + ".```", false, nil
+}
+func (c *remindChannelCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Posts a message to a channel on a recurring schedule, in the server's timezone. The schedule is a cron expression (minute hour day-of-month month day-of-week) in quotes, or one of `@hourly`, `@daily`, `@weekly`, `@monthly` or `\"@every 12h\"`. For example, `" + info.config.Basic.CommandPrefix + "remindchannel #general \"0 18 * * *\" Time for the daily thread!` posts every day at 6pm.",
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *remindChannelCommand) UsageShort() string { return "Adds a recurring channel reminder." }
+
+type channelRemindersCommand struct {
+}
+
+func (c *channelRemindersCommand) Name() string {
+	return "ChannelReminders"
+}
+func (c *channelRemindersCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	reminders := sb.db.GetChannelReminders(SBatoi(info.ID))
+	if len(reminders) == 0 {
+		return "```There are no channel reminders on this server.```", false, nil
+	}
+	lines := make([]string, 0, len(reminders)+1)
+	lines = append(lines, "Channel reminders:")
+	for _, r := range reminders {
+		status := "next " + ApplyTimezone(r.NextRun, info, msg.Author).Format("Jan 2 3:04pm")
+		if r.Paused {
+			status = "PAUSED"
+		}
+		message := r.Message
+		if m := []rune(message); len(m) > 50 {
+			message = string(m[:50]) + "..."
+		}
+		lines = append(lines, fmt.Sprintf("#%v #%s [%s] %s: %s", r.ID, getChannelName(SBitoa(r.Channel)), r.Spec, status, message))
+	}
+	return "```" + PartialSanitize(strings.Join(lines, "\n")) + "```", len(reminders) > 5, nil
+}
+func (c *channelRemindersCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{Desc: "Lists all the recurring channel reminders on this server, and when they will be posted next."}
+}
+func (c *channelRemindersCommand) UsageShort() string { return "Lists channel reminders." }
+
+type pauseReminderCommand struct {
+	resume bool
+}
+
+func (c *pauseReminderCommand) Name() string {
+	if c.resume {
+		return "ResumeReminder"
+	}
+	return "PauseReminder"
+}
+func (c *pauseReminderCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must provide the ID of a channel reminder.```", false, nil
+	}
+	r, e := getChannelReminder(info, args[0])
+	if r == nil {
+		return e, false, nil
+	}
+	if r.Paused != c.resume {
+		if c.resume {
+			return "```That reminder isn't paused.```", false, nil
+		}
+		return "```That reminder is already paused.```", false, nil
+	}
+	next := r.NextRun
+	if c.resume {
+		if !info.HasChannel(SBitoa(r.Channel)) {
+			return "```That reminder's channel no longer exists.```", false, nil
+		}
+		spec, err := ParseCronSpec(r.Spec)
+		if err != nil {
+			return "```That reminder has an invalid schedule: " + err.Error() + "```", false, nil
+		}
+		next = spec.NextIn(time.Now().UTC(), getTimezone(info, nil)) // Don't post everything that was skipped while it was paused
+	}
+	if !sb.db.PauseChannelReminder(r.ID, r.Guild, !c.resume, next) {
+		return "```Error updating reminder.```", false, nil
+	}
+	if c.resume {
+		return "```Resumed reminder. It will next be posted on " + ApplyTimezone(next, info, msg.Author).Format("Jan 2 3:04pm") + ".```", false, nil
+	}
+	return "```Paused reminder.```", false, nil
+}
+func (c *pauseReminderCommand) Usage(info *GuildInfo) *CommandUsage {
+	desc := "Pauses a channel reminder until it is resumed."
+	if c.resume {
+		desc = "Resumes a paused channel reminder. Any posts that were skipped while it was paused are not made up."
+	}
+	return &CommandUsage{
+		Desc: desc,
+		Params: []CommandUsageParam{
+			{Name: "ID", Desc: "The ID of the reminder, from `" + info.config.Basic.CommandPrefix + "channelreminders`.", Optional: false},
+		},
+	}
+}
+func (c *pauseReminderCommand) UsageShort() string {
+	if c.resume {
+		return "Resumes a channel reminder."
+	}
+	return "Pauses a channel reminder."
+}
+
+type removeChannelReminderCommand struct {
+}
+
+func (c *removeChannelReminderCommand) Name() string {
+	return "RemoveChannelReminder"
+}
+func (c *removeChannelReminderCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must provide the ID of a channel reminder.```", false, nil
+	}
+	r, e := getChannelReminder(info, args[0])
+	if r == nil {
+		return e, false, nil
+	}
+	if !sb.db.RemoveChannelReminder(r.ID, r.Guild) {
+		return "```Error removing reminder.```", false, nil
+	}
+	info.Log(getUserName(SBatoi(msg.Author.ID), info), " removed channel reminder ", r.ID, " in #", getChannelName(SBitoa(r.Channel)))
+	return "```Removed reminder.```", false, nil
+}
+func (c *removeChannelReminderCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Deletes a channel reminder.",
+		Params: []CommandUsageParam{
+			{Name: "ID", Desc: "The ID of the reminder, from `" + info.config.Basic.CommandPrefix + "channelreminders`.", Optional: false},
+		},
+	}
+}
+func (c *removeChannelReminderCommand) UsageShort() string { return "Removes a channel reminder." }
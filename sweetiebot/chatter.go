@@ -0,0 +1,23 @@
+package sweetiebot
+
+// Returns the chatter budget's refill rate in messages per second and its size, or false if there's no budget
+func chatterBudget(info *GuildInfo) (float64, float64, bool) {
+	c := info.config.Chatter
+	if c.Messages <= 0 || c.Period <= 0 {
+		return 0, 0, false
+	}
+	return float64(c.Messages) / float64(c.Period), float64(c.Messages), true
+}
+
+// Takes one message out of the server's chatter budget, which every message Sweetie Bot posts on her own draws from,
+// like witty responses, AFK replies and birthday wishes. Commands and moderation never do. Returns false if the budget
+// is used up, in which case the message should be skipped, and it's counted under the feature that wanted to post it.
+func (info *GuildInfo) takeChatter(feature string) bool {
+	rate, burst, ok := chatterBudget(info)
+	if !ok || info.chatterbucket.take(rate, burst) {
+		return true
+	}
+	metricChatterSkipped.Add(feature, 1)
+	recordDropped("chatter", info.ID, "chatter budget", feature)
+	return false
+}
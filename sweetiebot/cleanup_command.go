@@ -0,0 +1,132 @@
+package sweetiebot
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Cleanup only looks at this many of the most recent messages in the channel, and never at anything older than
+// cleanupMaxAge, so nobody can use it to dig through a channel's history
+const cleanupLookback = 100
+const cleanupMaxAge = time.Hour
+
+// Most commands a single cleanup can remove
+const cleanupMaxCommands = 25
+
+// A command someone used, along with everything the bot said in response
+type cleanupGroup struct {
+	command   string
+	responses []string
+}
+
+type cleanupCommand struct {
+}
+
+func (c *cleanupCommand) Name() string {
+	return "Cleanup"
+}
+
+// Returns true if a message looks like a command, either with the prefix or by pinging the bot
+func isCommandMessage(info *GuildInfo, m *discordgo.Message) bool {
+	if len(info.config.Basic.CommandPrefix) > 0 && len(m.Content) > len(info.config.Basic.CommandPrefix) && strings.HasPrefix(m.Content, info.config.Basic.CommandPrefix) {
+		return true
+	}
+	_, ok := stripSelfMention(m.Content)
+	return ok && info.config.Basic.MentionCommands
+}
+
+// Finds the user's recent commands in a channel and the bot's responses to them. A bot message counts as a response if
+// it replies to the command, or if nobody else spoke between the command and the bot's message. Returns the newest
+// groups last.
+func findCommandGroups(info *GuildInfo, channel string, user string) ([]cleanupGroup, error) {
+	var list []*discordgo.Message
+	err := CallAPI("ChannelMessages", func() (err error) {
+		list, err = sb.dg.ChannelMessages(channel, cleanupLookback, "", "", "")
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().UTC().Add(-cleanupMaxAge)
+	groups := []cleanupGroup{}
+	index := make(map[string]int)
+	current := -1
+	for i := len(list) - 1; i >= 0; i-- { // Discord returns the newest messages first
+		m := list[i]
+		if m.Author == nil || m.Timestamp.Before(cutoff) {
+			current = -1
+			continue
+		}
+		switch {
+		case m.Author.ID == user && isCommandMessage(info, m):
+			index[m.ID] = len(groups)
+			current = len(groups)
+			groups = append(groups, cleanupGroup{command: m.ID})
+		case m.Author.ID == sb.SelfID:
+			g := current
+			if m.MessageReference != nil {
+				if j, ok := index[m.MessageReference.MessageID]; ok {
+					g = j
+				}
+			}
+			if g >= 0 {
+				groups[g].responses = append(groups[g].responses, m.ID)
+			}
+		default:
+			current = -1
+		}
+	}
+	return groups, nil
+}
+
+func (c *cleanupCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	num := 10
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return "```The number of commands to clean up must be a positive number.```", false, nil
+		}
+		num = n
+	}
+	if num > cleanupMaxCommands {
+		num = cleanupMaxCommands
+	}
+	if perms, err := sb.dg.State.UserChannelPermissions(sb.SelfID, msg.ChannelID); err != nil || perms&discordgo.PermissionManageMessages == 0 {
+		return "```I need the Manage Messages permission in this channel to delete your commands.```", false, nil
+	}
+	groups, err := findCommandGroups(info, msg.ChannelID, msg.Author.ID)
+	if err != nil {
+		return "```Couldn't get the recent messages in this channel: " + apiErrorMessage(err) + "```", false, nil
+	}
+	if len(groups) > num+1 {
+		groups = groups[len(groups)-num-1:] // This cleanup command is always the newest one, so it doesn't count
+	}
+	IDs := []string{}
+	for _, g := range groups {
+		IDs = append(IDs, g.command)
+		IDs = append(IDs, g.responses...)
+	}
+	if len(IDs) == 0 {
+		return "", false, nil
+	} else if len(IDs) == 1 {
+		err = CallAPI("ChannelMessageDelete", func() error { return deleteMessage(msg.ChannelID, IDs[0]) })
+	} else {
+		err = CallAPI("ChannelMessagesBulkDelete", func() error { return sb.BulkDelete(msg.ChannelID, IDs) })
+	}
+	if err != nil {
+		return "```Couldn't delete your commands: " + apiErrorMessage(err) + "```", false, nil
+	}
+	return "", false, nil
+}
+func (c *cleanupCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Deletes your own recent commands in this channel, along with Sweetie Bot's responses to them, to tidy up after testing something. Only the last " + strconv.Itoa(cleanupLookback) + " messages in the channel are checked, and only if they're less than " + TimeDiff(cleanupMaxAge) + " old. Any message of Sweetie Bot's that replies to one of your commands, or came right after one before anyone else spoke, counts as a response. Sweetie Bot needs the Manage Messages permission in the channel.",
+		Params: []CommandUsageParam{
+			{Name: "count", Desc: "How many of your commands to delete, up to " + strconv.Itoa(cleanupMaxCommands) + ". Defaults to 10.", Optional: true},
+		},
+	}
+}
+func (c *cleanupCommand) UsageShort() string { return "Deletes your recent commands." }
@@ -0,0 +1,213 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var hexcolorregex = regexp.MustCompile("^#?([0-9a-fA-F]{6})$")
+
+// Discord refuses to create roles past this point
+const maxGuildRoles = 250
+
+// How close to the role limit we get before warning the moderators
+const guildRolesWarning = 240
+
+// Parses a color name from the palette or a hex code, returning -1 and an error message if it isn't allowed on this server
+func parseColor(s string, info *GuildInfo) (int, string) {
+	palette := info.config.Colors.Palette
+	if hex, ok := palette[strings.ToLower(s)]; ok {
+		s = hex
+	} else if len(palette) > 0 {
+		allowed := false
+		for _, v := range palette {
+			if strings.EqualFold(strings.TrimPrefix(v, "#"), strings.TrimPrefix(s, "#")) {
+				allowed = true
+			}
+		}
+		if !allowed {
+			names := MapStringToSlice(palette)
+			sort.Strings(names)
+			return -1, "You can only pick one of these colors: " + strings.Join(names, ", ")
+		}
+	}
+	m := hexcolorregex.FindStringSubmatch(s)
+	if m == nil {
+		return -1, s + " isn't a color. Colors must be given as a hex code, like #FF8800."
+	}
+	color, _ := strconv.ParseInt(m[1], 16, 32)
+	if color == 0 {
+		return -1, "Discord treats #000000 as having no color at all. Use #010101 if you want black."
+	}
+	return int(color), ""
+}
+
+// Finds a role in the state, returning nil if it doesn't exist anymore
+func colorRoleOf(info *GuildInfo, role uint64) *discordgo.Role {
+	if role == 0 {
+		return nil
+	}
+	r, err := sb.dg.State.Role(info.ID, SBitoa(role))
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// Moves a newly created role from the bottom of the role list to just beneath the anchor role. Only the roles in between need
+// to shift down, and they are all below the anchor, so the bot is always allowed to move them.
+func moveBelowAnchor(info *GuildInfo, role *discordgo.Role, anchor *discordgo.Role) error {
+	guild, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return err
+	}
+	moved := []*discordgo.Role{{ID: role.ID, Position: anchor.Position - 1}}
+	sb.dg.State.RLock()
+	for _, r := range guild.Roles {
+		if r.ID != role.ID && r.Position > role.Position && r.Position < anchor.Position {
+			moved = append(moved, &discordgo.Role{ID: r.ID, Position: r.Position - 1})
+		}
+	}
+	sb.dg.State.RUnlock()
+	return CallAPI("GuildRoleReorder", func() error {
+		_, err := sb.dg.GuildRoleReorder(info.ID, moved)
+		return err
+	})
+}
+
+// Deletes color roles whose owner has left the server or taken the role off, and forgets any that were deleted by hand
+func pruneColorRoles() {
+	if !sb.db.CheckStatus() {
+		return
+	}
+	sb.guildsLock.RLock()
+	guilds := make([]*GuildInfo, 0, len(sb.guilds))
+	for _, v := range sb.guilds {
+		guilds = append(guilds, v)
+	}
+	sb.guildsLock.RUnlock()
+	for _, info := range guilds {
+		if _, err := sb.dg.State.Guild(info.ID); err != nil {
+			continue // The guild hasn't loaded yet, so we can't tell which roles still exist
+		}
+		guild := SBatoi(info.ID)
+		removed := 0
+		for user, role := range sb.db.GetColorRoles(guild) {
+			if colorRoleOf(info, role) == nil {
+				sb.db.RemoveColorRole(user, guild)
+				continue
+			}
+			m, err := info.GetMember(SBitoa(user))
+			if err != nil && ClassifyAPIError(err) != APIErrorNotFound {
+				continue // Don't delete a role just because discord had a hiccup
+			}
+			if m != nil && info.UserHasRole(m.User.ID, SBitoa(role)) {
+				continue
+			}
+			if CallAPI("GuildRoleDelete", func() error { return sb.dg.GuildRoleDelete(info.ID, SBitoa(role)) }) == nil {
+				sb.db.RemoveColorRole(user, guild)
+				removed++
+			}
+			time.Sleep(time.Second)
+		}
+		if removed > 0 {
+			info.Log("Deleted ", Pluralize(int64(removed), " unused color role"), ".")
+		}
+	}
+}
+
+type colorCommand struct {
+}
+
+func (c *colorCommand) Name() string {
+	return "Color"
+}
+func (c *colorCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "color", Desc: "A hex code like #FF8800, the name of a color in the server's palette, or \"none\" to remove your color."},
+	}
+}
+func (c *colorCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *colorCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	anchor := colorRoleOf(info, info.config.Colors.Anchor)
+	if anchor == nil {
+		return "```Color roles aren't enabled on this server. A moderator has to set colors.anchor to a role first.```", false, nil
+	}
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	user := SBatoi(msg.Author.ID)
+	guild := SBatoi(info.ID)
+	existing := colorRoleOf(info, sb.db.GetColorRole(user, guild))
+
+	switch strings.ToLower(args.String("color")) {
+	case "none", "off", "remove":
+		if existing == nil {
+			return "```You don't have a color role.```", false, nil
+		}
+		if err := CallAPI("GuildRoleDelete", func() error { return sb.dg.GuildRoleDelete(info.ID, existing.ID) }); err != nil {
+			return apiErrorMessage(err), false, nil
+		}
+		sb.db.RemoveColorRole(user, guild)
+		return "```Your color role has been removed.```", false, nil
+	}
+
+	color, e := parseColor(args.String("color"), info)
+	if color < 0 {
+		return "```" + e + "```", false, nil
+	}
+	if existing != nil {
+		_, err := sb.dg.GuildRoleEdit(info.ID, existing.ID, &discordgo.RoleParams{Color: &color})
+		if err != nil {
+			return apiErrorMessage(err), false, nil
+		}
+		if !info.UserHasRole(msg.Author.ID, existing.ID) {
+			sb.dg.GuildMemberRoleAdd(info.ID, msg.Author.ID, existing.ID)
+		}
+		return fmt.Sprintf("```Your color is now #%06X.```", color), false, nil
+	}
+
+	roles, err := sb.dg.GuildRoles(info.ID)
+	if err != nil {
+		return apiErrorMessage(err), false, nil
+	}
+	if len(roles) >= maxGuildRoles {
+		return "```This server has reached discord's limit of 250 roles, so no more color roles can be created.```", false, nil
+	}
+	r, err := sb.dg.GuildRoleCreate(info.ID, &discordgo.RoleParams{Name: msg.Author.Username, Color: &color})
+	if err != nil {
+		return apiErrorMessage(err), false, nil
+	}
+	sb.db.SetColorRole(user, guild, SBatoi(r.ID))
+	if err := moveBelowAnchor(info, r, anchor); err != nil {
+		info.Log("Couldn't move ", msg.Author.Username, "'s color role beneath the ", anchor.Name, " role: ", err.Error())
+	}
+	if err := sb.dg.GuildMemberRoleAdd(info.ID, msg.Author.ID, r.ID); err != nil {
+		return apiErrorMessage(err), false, nil
+	}
+	if len(roles)+1 >= guildRolesWarning {
+		info.Log("This server has ", len(roles)+1, " roles, which is close to discord's limit of 250. Once it's reached, no more roles can be created by anyone, including color roles.")
+	}
+	return fmt.Sprintf("```Your color is now #%06X.```", color), false, nil
+}
+func (c *colorCommand) Usage(info *GuildInfo) *CommandUsage {
+	desc := "Gives you a personal role with the color of your choice, placed just beneath the role in colors.anchor. Using the command again changes the color of the same role. Roles of members who leave or drop their color role are cleaned up once a day."
+	if len(info.config.Colors.Palette) > 0 {
+		names := MapStringToSlice(info.config.Colors.Palette)
+		sort.Strings(names)
+		desc += " This server only allows these colors: " + strings.Join(names, ", ")
+	}
+	return &CommandUsage{
+		Desc:   desc,
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *colorCommand) UsageShort() string { return "Sets the color of your name." }
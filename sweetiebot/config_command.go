@@ -0,0 +1,259 @@
+package sweetiebot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Anything longer than this is sent as a file instead of a message
+const configInlineLimit = 1900
+
+// A config option found by its dotted path. key is only valid if the path named one key of a map option.
+type configPath struct {
+	name  string // the canonical path, like Spam.MaxPressure or Basic.Variables.rules
+	field reflect.Value
+	key   reflect.Value
+}
+
+// Returns true if this struct field is the named part of a config path, by its field name or its json name
+func configFieldMatches(f reflect.StructField, name string) bool {
+	return strings.ToLower(f.Name) == name || strings.Split(f.Tag.Get("json"), ",")[0] == name
+}
+
+// Converts a map key from a config path into the map's key type
+func configMapKey(t reflect.Type, key string) (reflect.Value, error) {
+	k := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		k.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return k, errors.New(key + " isn't an integer.")
+		}
+		k.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i := PingAtoi(key)
+		if i == 0 {
+			return k, errors.New(key + " isn't an ID.")
+		}
+		k.SetUint(i)
+	default:
+		return k, errors.New("that map can't be edited one key at a time.")
+	}
+	return k, nil
+}
+
+// Finds the config option at the given path, which is Category.Option, or Category.Option.Key for one key of a map. The
+// category can be left out if only one category has that option, the same as for setconfig.
+func findConfigPath(info *GuildInfo, path string) (configPath, error) {
+	t := reflect.ValueOf(&info.config).Elem()
+	path, err := fixRequest(path, t)
+	if err != nil {
+		return configPath{}, errors.New(strings.Trim(err.Error(), "`"))
+	}
+	parts := strings.SplitN(path, ".", 3)
+	names := strings.SplitN(strings.ToLower(path), ".", 3)
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Kind() != reflect.Struct || !configFieldMatches(t.Type().Field(i), names[0]) {
+			continue
+		}
+		section := t.Field(i)
+		if len(names) < 2 {
+			return configPath{name: t.Type().Field(i).Name, field: section}, nil
+		}
+		for j := 0; j < section.NumField(); j++ {
+			if !configFieldMatches(section.Type().Field(j), names[1]) {
+				continue
+			}
+			p := configPath{name: t.Type().Field(i).Name + "." + section.Type().Field(j).Name, field: section.Field(j)}
+			if len(parts) > 2 {
+				if p.field.Kind() != reflect.Map {
+					return configPath{}, errors.New(p.name + " isn't a map, so it doesn't have keys.")
+				}
+				if p.key, err = configMapKey(p.field.Type().Key(), parts[2]); err != nil {
+					return configPath{}, errors.New("Can't use " + parts[2] + " as a key of " + p.name + ": " + err.Error())
+				}
+				p.name += "." + parts[2]
+			}
+			return p, nil
+		}
+		return configPath{}, errors.New(t.Type().Field(i).Name + " has no option called " + parts[1] + ".")
+	}
+	return configPath{}, errors.New("There's no config category called " + parts[0] + ".")
+}
+
+// Returns the value at a config path, serialized the same way as the config file, and indented if it's going to be
+// shown on its own
+func (p configPath) marshal(indent bool) ([]byte, error) {
+	v := p.field
+	if p.key.IsValid() {
+		if v = v.MapIndex(p.key); !v.IsValid() {
+			return []byte("null"), nil
+		}
+	}
+	if indent {
+		return json.MarshalIndent(v.Interface(), "", "  ")
+	}
+	return json.Marshal(v.Interface())
+}
+
+// Parses a new value for a config path from JSON, rejecting anything that doesn't fit the option's type. A string
+// option can also be given as plain text without quotes.
+func (p configPath) parse(raw string) (reflect.Value, error) {
+	t := p.field.Type()
+	if p.key.IsValid() {
+		t = t.Elem()
+	}
+	v := reflect.New(t)
+	d := json.NewDecoder(strings.NewReader(raw))
+	d.DisallowUnknownFields()
+	err := d.Decode(v.Interface())
+	if err == nil && d.More() {
+		err = errors.New("there's more after the value")
+	}
+	if err != nil {
+		if t.Kind() == reflect.String && !strings.HasPrefix(raw, "\"") {
+			v.Elem().SetString(raw)
+			return v.Elem(), nil
+		}
+		return v, errors.New(p.name + " must be " + describeConfigType(t) + ": " + err.Error())
+	}
+	return v.Elem(), nil
+}
+
+// Describes a config option's type in plain words for error messages
+func describeConfigType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "a whole number"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a positive whole number or ID"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "a JSON list of " + describeConfigType(t.Elem()) + "s"
+	case reflect.Map:
+		return "a JSON object of " + describeConfigType(t.Elem()) + "s"
+	}
+	return "a JSON " + t.Kind().String()
+}
+
+// Sends JSON as a code block, or as a file if it's too long for one message
+func sendConfigJSON(channel string, name string, data []byte) (string, bool, *discordgo.MessageEmbed) {
+	if len(data) <= configInlineLimit {
+		return "```json\n" + ExtraSanitize(string(data)) + "```", false, nil
+	}
+	_, err := sb.dg.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+		Content:         name + " is too long to show here, so here it is as a file.",
+		Files:           []*discordgo.File{{Name: strings.ToLower(name) + ".json", ContentType: "application/json", Reader: bytes.NewReader(data)}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		return "```Error sending the config: " + apiErrorMessage(err) + "```", false, nil
+	}
+	return "", false, nil
+}
+
+type configCommand struct {
+}
+
+func (c *configCommand) Name() string {
+	return "Config"
+}
+func (c *configCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !info.HasAdminRole(msg.Author.ID) && !isBotOwner(msg.Author.ID) {
+		return "```Only admins can view or edit the raw config.```", false, nil
+	}
+	if len(args) > 0 && strings.ToLower(args[0]) == "set" {
+		if len(args) < 3 {
+			return "```You must give the option to set and its new value, like: " + info.config.Basic.CommandPrefix + "config set spam.maxpressure 60```", false, nil
+		}
+		return c.set(info, msg, args[1], strings.TrimSpace(msg.Content[indices[2]:])), false, nil
+	}
+	if len(args) < 1 {
+		data, err := json.MarshalIndent(info.config, "", "  ")
+		if err != nil {
+			return "```Error serializing config: " + err.Error() + "```", false, nil
+		}
+		return sendConfigJSON(msg.ChannelID, "Config", data)
+	}
+	p, err := findConfigPath(info, args[0])
+	if err != nil {
+		return "```" + err.Error() + "```", false, nil
+	}
+	data, err := p.marshal(true)
+	if err != nil {
+		return "```Error serializing " + p.name + ": " + err.Error() + "```", false, nil
+	}
+	return sendConfigJSON(msg.ChannelID, p.name, data)
+}
+
+// Replaces the value at a config path, or one key of a map. Setting a map key to null deletes it. The change is undone
+// if it would make the config too large to save.
+func (c *configCommand) set(info *GuildInfo, msg *discordgo.Message, path string, raw string) string {
+	p, err := findConfigPath(info, path)
+	if err != nil {
+		return "```" + err.Error() + "```"
+	}
+	if p.field.Kind() == reflect.Struct {
+		return "```Can't replace a whole config category. Set its options one at a time, like " + p.name + ".Option.```"
+	}
+	if strings.HasPrefix(raw, "```") && strings.HasSuffix(raw, "```") && len(raw) >= 6 { // Let people paste a code block
+		raw = strings.TrimSpace(strings.TrimPrefix(raw[3:len(raw)-3], "json"))
+	}
+	v, err := p.parse(raw)
+	if err != nil {
+		return "```" + err.Error() + "```"
+	}
+	old, _ := p.marshal(false)
+	prev := reflect.ValueOf(p.field.Interface())
+	if p.key.IsValid() {
+		m := reflect.MakeMap(p.field.Type())
+		for _, k := range p.field.MapKeys() {
+			m.SetMapIndex(k, p.field.MapIndex(k))
+		}
+		if raw == "null" {
+			m.SetMapIndex(p.key, reflect.Value{})
+		} else {
+			m.SetMapIndex(p.key, v)
+		}
+		v = m
+	}
+	p.field.Set(v)
+	if data, err := json.Marshal(info.config); err != nil || len(data) > sb.MaxConfigSize {
+		p.field.Set(prev)
+		return "```That would make the config too large to save. Config files can't be larger than " + strconv.Itoa(sb.MaxConfigSize) + " bytes.```"
+	}
+	info.SaveConfig()
+	cur, _ := p.marshal(false)
+	info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " changed ", p.name, " from ", string(old), " to ", string(cur))
+	s := "Set " + p.name + " to " + string(cur) + "."
+	if strings.HasPrefix(p.name, "Feedback.") {
+		if warning := checkFeedback(info); len(warning) > 0 {
+			s += " But:\n" + warning
+		}
+	}
+	return "```\n" + ExtraSanitize(s) + "```"
+}
+func (c *configCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Shows the server's configuration as JSON, the same way it's saved, either all of it or one category or option. `" + info.config.Basic.CommandPrefix + "config set <option> <value>` changes any option, with the new value written as JSON, so lists and maps can be replaced in one go: `" + info.config.Basic.CommandPrefix + "config set spam.verifiedrelax {\"length\": true, \"lines\": true}`. Give a map key after the option to change just that key, or set it to `null` to remove it. Values that don't fit the option's type are rejected, and every change is logged. Only admins can use this.",
+		Params: []CommandUsageParam{
+			{Name: "set", Desc: "Changes an option instead of showing it.", Optional: true},
+			{Name: "option", Desc: "A category like `Spam`, an option like `Spam.MaxPressure`, or a map key like `Basic.Variables.rules`. The category can be left out if the option name is unique.", Optional: true},
+			{Name: "value", Desc: "The new value as JSON. Strings don't need quotes.", Optional: true},
+		},
+	}
+}
+func (c *configCommand) UsageShort() string { return "Shows or edits the raw config as JSON." }
@@ -0,0 +1,90 @@
+package sweetiebot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long a confirmation prompt waits for an answer before giving up, which counts as cancelling
+const confirmTimeout = 30 * time.Second
+
+// A confirmation prompt waiting for an answer from the user who ran the command
+type pendingConfirm struct {
+	user   string
+	answer chan bool
+}
+
+var confirmLock sync.Mutex
+var confirmations = make(map[string]*pendingConfirm) // by the ID of the prompt message
+
+// Confirm posts prompt with confirm and cancel buttons, and waits for user to press one. Only that user's answer
+// counts. Returns true if they confirmed before confirmTimeout ran out. The prompt is deleted either way.
+func Confirm(info *GuildInfo, channel string, user string, prompt string) bool {
+	var msg *discordgo.Message
+	err := CallAPI("ChannelMessageSendComplex", func() (err error) {
+		msg, err = sb.dg.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+			Content: prompt + "\n<@" + user + ">, you have " + TimeDiff(confirmTimeout) + " to decide.",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Confirm", Style: discordgo.DangerButton, CustomID: "confirm:yes", Emoji: &discordgo.ComponentEmoji{Name: "✅"}},
+					discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "confirm:no", Emoji: &discordgo.ComponentEmoji{Name: "❌"}},
+				}},
+			},
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{user}},
+		})
+		return
+	})
+	if err != nil {
+		info.LogError("Couldn't post a confirmation prompt: ", err)
+		return false
+	}
+	p := &pendingConfirm{user, make(chan bool, 1)}
+	confirmLock.Lock()
+	confirmations[msg.ID] = p
+	confirmLock.Unlock()
+	defer func() {
+		confirmLock.Lock()
+		delete(confirmations, msg.ID)
+		confirmLock.Unlock()
+		sb.dg.ChannelMessageDelete(channel, msg.ID)
+	}()
+	select {
+	case ok := <-p.answer:
+		return ok
+	case <-time.After(confirmTimeout):
+		return false
+	}
+}
+
+// Handles a press of one of the buttons under a confirmation prompt. Returns false if the interaction wasn't one.
+func handleConfirmButton(i *discordgo.Interaction) bool {
+	if i.Type != discordgo.InteractionMessageComponent || i.Message == nil {
+		return false
+	}
+	id := i.MessageComponentData().CustomID
+	if !strings.HasPrefix(id, "confirm:") {
+		return false
+	}
+	confirmLock.Lock()
+	p, ok := confirmations[i.Message.ID]
+	confirmLock.Unlock()
+	if !ok {
+		respondEphemeral(i, "This confirmation has already expired.")
+		return true
+	}
+	if i.Member == nil || i.Member.User.ID != p.user {
+		respondEphemeral(i, "Only the person who ran the command can answer this.")
+		return true
+	}
+	if err := sb.dg.InteractionRespond(i, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate}); err != nil {
+		metricAPIErrors.Add(ClassifyAPIError(err).String(), 1)
+	}
+	select {
+	case p.answer <- id == "confirm:yes":
+	default: // They already answered
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ContentLimits bound how much user-generated content a single server can store, set by the bot owner in the limits
+// file. Servers can lower these for themselves with the limits.* options, but never raise them. 0 means unlimited.
+type ContentLimits struct {
+	Quotes           int `json:"quotes"`           // quotes stored across all users
+	Reminders        int `json:"reminders"`        // pending !remindme reminders a single user can have
+	ChannelReminders int `json:"channelreminders"` // repeating !remindchannel reminders
+	Triggers         int `json:"triggers"`         // witty response triggers
+	CollectionItems  int `json:"collectionitems"`  // items across all collections
+}
+
+// Returns the tighter of a server's own limit and the bot owner's, where 0 means there is no limit
+func effectiveLimit(guild int, ceiling int) int {
+	if ceiling > 0 && (guild <= 0 || guild > ceiling) {
+		return ceiling
+	}
+	if guild < 0 {
+		return 0
+	}
+	return guild
+}
+
+func (info *GuildInfo) quoteLimit() int {
+	return effectiveLimit(info.config.Limits.Quotes, sb.ContentLimits.Quotes)
+}
+func (info *GuildInfo) reminderLimit() int {
+	return effectiveLimit(info.config.Limits.Reminders, sb.ContentLimits.Reminders)
+}
+func (info *GuildInfo) channelReminderLimit() int {
+	return effectiveLimit(info.config.Limits.ChannelReminders, sb.ContentLimits.ChannelReminders)
+}
+func (info *GuildInfo) triggerLimit() int {
+	return effectiveLimit(info.config.Limits.Triggers, sb.ContentLimits.Triggers)
+}
+func (info *GuildInfo) collectionItemLimit() int {
+	return effectiveLimit(info.config.Limits.CollectionItems, sb.ContentLimits.CollectionItems)
+}
+
+func countQuotes(info *GuildInfo) int {
+	n := 0
+	for _, v := range info.config.Quote.Quotes {
+		n += len(v)
+	}
+	return n
+}
+
+func countCollectionItems(info *GuildInfo) int {
+	n := 0
+	for _, v := range info.config.Basic.Collections {
+		n += len(v)
+	}
+	return n
+}
+
+// Returns true if adding count more things would go over the limit
+func overLimit(used int, count int, limit int) bool {
+	return limit > 0 && used+count > limit
+}
+
+func limitReachedMessage(limit int, what string, remove string) string {
+	return fmt.Sprintf("```Limit reached: %s. Remove some with %s first.```", fmt.Sprintf(what, limit), remove)
+}
+
+type limitsCommand struct {
+}
+
+func (c *limitsCommand) Name() string {
+	return "Limits"
+}
+func (c *limitsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	describe := func(name string, used int, limit int) string {
+		if limit <= 0 {
+			return fmt.Sprintf("%-18s %v (no limit)", name, used)
+		}
+		return fmt.Sprintf("%-18s %v/%v", name, used, limit)
+	}
+	lines := []string{
+		describe("Quotes", countQuotes(info), info.quoteLimit()),
+		describe("Collection items", countCollectionItems(info), info.collectionItemLimit()),
+		describe("Witty triggers", len(info.config.Witty.Responses), info.triggerLimit()),
+	}
+	if sb.db.CheckStatus() {
+		gID := SBatoi(info.ID)
+		lines = append(lines, describe("Channel reminders", sb.db.CountChannelReminders(gID), info.channelReminderLimit()))
+		lines = append(lines, describe("Your reminders", sb.db.CountReminders(gID, msg.Author.ID), info.reminderLimit()))
+	} else {
+		lines = append(lines, "A temporary database outage means reminders can't be counted right now.")
+	}
+	return "```\n" + strings.Join(lines, "\n") + "```", false, nil
+}
+func (c *limitsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Shows how many quotes, collection items, witty triggers and reminders this server has stored, and how many it's allowed. Reminders are limited for each user, so your own count is shown. Admins can lower the limits with the `limits.*` options, but can't raise them above what the bot owner allows.",
+	}
+}
+func (c *limitsCommand) UsageShort() string {
+	return "Shows how much this server has stored and its limits."
+}
@@ -0,0 +1,271 @@
+package sweetiebot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CronSpec describes when a recurring job runs. It is either a fixed interval, or a standard 5 field cron expression
+// (minute hour day-of-month month day-of-week) that is evaluated in UTC.
+type CronSpec struct {
+	every  time.Duration
+	minute uint64 // bitsets of the allowed values for each field
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+}
+
+var cronFieldRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+func parseCronField(s string, min int, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, errors.New("invalid step in " + part)
+			}
+			step = n
+			part = part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, errors.New("invalid value " + bounds[0])
+			}
+			hi = lo
+			if len(bounds) > 1 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, errors.New("invalid value " + bounds[1])
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%s is out of range (%v-%v)", part, min, max)
+		}
+		for i := lo; i <= hi; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// ParseCronSpec parses a cron expression, or one of the shortcuts @hourly, @daily, @weekly, @monthly and @every <duration>
+func ParseCronSpec(s string) (*CronSpec, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	switch s {
+	case "@hourly":
+		s = "0 * * * *"
+	case "@daily", "@midnight":
+		s = "0 0 * * *"
+	case "@weekly":
+		s = "0 0 * * 0"
+	case "@monthly":
+		s = "0 0 1 * *"
+	}
+	if strings.HasPrefix(s, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(s[7:]))
+		if err != nil {
+			return nil, err
+		}
+		if d < time.Minute {
+			return nil, errors.New("interval must be at least one minute")
+		}
+		return &CronSpec{every: d}, nil
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return nil, errors.New("cron expressions must have exactly 5 fields")
+	}
+	var bits [5]uint64
+	for i, f := range fields {
+		var err error
+		if bits[i], err = parseCronField(f, cronFieldRanges[i][0], cronFieldRanges[i][1]); err != nil {
+			return nil, err
+		}
+	}
+	return &CronSpec{minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4], anyDom: fields[2] == "*", anyDow: fields[4] == "*"}, nil
+}
+
+func (c *CronSpec) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDom || c.anyDow { // Like standard cron, if both fields are restricted, matching either one is enough
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time the job should run strictly after t
+func (c *CronSpec) Next(t time.Time) time.Time {
+	return c.NextIn(t, time.UTC)
+}
+
+// NextIn is like Next, but evaluates the expression in the given timezone. The result is always in UTC.
+func (c *CronSpec) NextIn(t time.Time, loc *time.Location) time.Time {
+	if c.every > 0 {
+		return t.UTC().Add(c.every)
+	}
+	t = t.In(loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0) // An expression like "0 0 31 2 *" can never match
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		} else if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		} else if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc) // Truncating to the hour would break timezones with half hour offsets
+		} else if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+		} else {
+			return t.UTC()
+		}
+	}
+	return limit.UTC()
+}
+
+// CronJob is a bot-wide recurring task
+type CronJob struct {
+	Name    string
+	Spec    string
+	Run     func()
+	spec    *CronSpec
+	next    time.Time
+	last    time.Time
+	running AtomicFlag
+}
+
+// CronJobStatus is a copy of a job's schedule at one point in time, which is safe to read while the scheduler runs
+type CronJobStatus struct {
+	Name    string
+	Spec    string
+	Next    time.Time
+	Last    time.Time
+	Running bool
+}
+
+// CronScheduler runs recurring jobs and persists when each job should run next, so that restarting the bot doesn't reset them.
+type CronScheduler struct {
+	jobs []*CronJob
+	lock sync.Mutex
+}
+
+// Register adds a job to the scheduler. If the job missed its last run while the bot was offline, it will run once as soon as the scheduler starts.
+func (s *CronScheduler) Register(name string, spec string, run func()) error {
+	c, err := ParseCronSpec(spec)
+	if err != nil {
+		return err
+	}
+	job := &CronJob{Name: name, Spec: spec, Run: run, spec: c}
+	now := time.Now().UTC()
+	job.next = c.Next(now)
+	if sb.db != nil && sb.db.status.get() {
+		if next, ok := sb.db.GetJobNextRun(name); ok {
+			job.next = next // If this is in the past, the job runs on the next check, and its next run is computed from then
+		} else {
+			sb.db.SetJobNextRun(name, job.next)
+		}
+	}
+	s.lock.Lock()
+	s.jobs = append(s.jobs, job)
+	s.lock.Unlock()
+	return nil
+}
+
+// Jobs returns the status of every registered job, sorted by when they run next
+func (s *CronScheduler) Jobs() []CronJobStatus {
+	s.lock.Lock()
+	jobs := make([]CronJobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, CronJobStatus{j.Name, j.Spec, j.next, j.last, j.running.get()})
+	}
+	s.lock.Unlock()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Next.Before(jobs[j].Next) })
+	return jobs
+}
+
+func (s *CronScheduler) tick() {
+	now := time.Now().UTC()
+	started := []CronJobStatus{}
+	s.lock.Lock()
+	for _, job := range s.jobs {
+		if job.next.After(now) || job.running.test_and_set() {
+			continue
+		}
+		job.last = now
+		job.next = job.spec.Next(now) // Computing this from now instead of the missed run time means downtime only results in one run
+		started = append(started, CronJobStatus{Name: job.Name, Next: job.next})
+		go func(j *CronJob) {
+			defer j.running.clear()
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Println("Job", j.Name, "panicked:", r)
+				}
+			}()
+			j.Run()
+		}(job)
+	}
+	s.lock.Unlock()
+	if sb.db.status.get() { // Saved after unlocking, so a slow database doesn't hold up everything reading the scheduler
+		for _, j := range started {
+			sb.db.SetJobNextRun(j.Name, j.Next)
+		}
+	}
+}
+
+func cronLoop() {
+	for !sb.quit.get() {
+		time.Sleep(30 * time.Second) // Sleep first so guilds have a chance to load before any missed jobs run
+		sb.cron.tick()
+	}
+}
+
+// Copies every guild's config into the backups folder, keeping the last week of backups
+func backupConfigs() {
+	dir := "backups"
+	if err := os.MkdirAll(dir, 0775); err != nil {
+		fmt.Println("Failed to create backup folder: ", err.Error())
+		return
+	}
+	date := time.Now().UTC().Format("2006-01-02")
+	sb.guildsLock.RLock()
+	ids := make([]string, 0, len(sb.guilds))
+	for _, v := range sb.guilds {
+		ids = append(ids, v.ID)
+	}
+	sb.guildsLock.RUnlock()
+	for _, id := range ids {
+		data, err := os.ReadFile(id + ".json")
+		if err != nil {
+			continue
+		}
+		if err = os.WriteFile(filepath.Join(dir, id+"."+date+".json"), data, 0664); err != nil {
+			fmt.Println("Failed to back up config for ", id, ": ", err.Error())
+		}
+	}
+
+	old, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	cutoff := time.Now().UTC().AddDate(0, 0, -7).Format("2006-01-02")
+	for _, f := range old {
+		parts := strings.Split(filepath.Base(f), ".")
+		if len(parts) == 3 && parts[1] < cutoff {
+			os.Remove(f)
+		}
+	}
+}
@@ -87,6 +87,7 @@ type BotDB struct {
 	sqlGetEventsByType        *sql.Stmt
 	sqlGetNextEvent           *sql.Stmt
 	sqlGetReminders           *sql.Stmt
+	sqlCountReminders         *sql.Stmt
 	sqlGetUnsilenceDate       *sql.Stmt
 	sqlGetTimeZone            *sql.Stmt
 	sqlFindTimeZone           *sql.Stmt
@@ -109,6 +110,73 @@ type BotDB struct {
 	sqlCheckOption            *sql.Stmt
 	sqlSentMessage            *sql.Stmt
 	sqlGetNewcomers           *sql.Stmt
+	sqlAddModlog              *sql.Stmt
+	sqlGetNewestModlog        *sql.Stmt
+	sqlSearchModlog           *sql.Stmt
+	sqlSearchModlogUser       *sql.Stmt
+	sqlGetJobNextRun          *sql.Stmt
+	sqlSetJobNextRun          *sql.Stmt
+	sqlAddActivity            *sql.Stmt
+	sqlGetActivity            *sql.Stmt
+	sqlGetGuildActivity       *sql.Stmt
+	sqlPruneActivity          *sql.Stmt
+	sqlSetAFK                 *sql.Stmt
+	sqlRemoveAFK              *sql.Stmt
+	sqlGetAFK                 *sql.Stmt
+	sqlAddChannelReminder     *sql.Stmt
+	sqlCountChannelReminders  *sql.Stmt
+	sqlGetChannelReminders    *sql.Stmt
+	sqlGetDueChannelReminders *sql.Stmt
+	sqlSetChannelReminderNext *sql.Stmt
+	sqlPauseChannelReminder   *sql.Stmt
+	sqlRemoveChannelReminder  *sql.Stmt
+	sqlAddPinboardMirror      *sql.Stmt
+	sqlGetPinboardMirror      *sql.Stmt
+	sqlRemovePinboardMirror   *sql.Stmt
+	sqlSetApprovalPending     *sql.Stmt
+	sqlSetApprovalTrusted     *sql.Stmt
+	sqlRemoveApproval         *sql.Stmt
+	sqlGetApprovals           *sql.Stmt
+	sqlAddOffense             *sql.Stmt
+	sqlGetOffenses            *sql.Stmt
+	sqlSetColorRole           *sql.Stmt
+	sqlGetColorRole           *sql.Stmt
+	sqlRemoveColorRole        *sql.Stmt
+	sqlGetColorRoles          *sql.Stmt
+	sqlSetSticky              *sql.Stmt
+	sqlSetStickyCurrent       *sql.Stmt
+	sqlRemoveSticky           *sql.Stmt
+	sqlGetStickies            *sql.Stmt
+	sqlAddTempChannel         *sql.Stmt
+	sqlRemoveTempChannel      *sql.Stmt
+	sqlGetTempChannels        *sql.Stmt
+	sqlAddOperation           *sql.Stmt
+	sqlUpdateOperation        *sql.Stmt
+	sqlRemoveOperation        *sql.Stmt
+	sqlGetOperations          *sql.Stmt
+	sqlAddSuggestion          *sql.Stmt
+	sqlSetSuggestionMessage   *sql.Stmt
+	sqlSetSuggestionStatus    *sql.Stmt
+	sqlSetSuggestionVotes     *sql.Stmt
+	sqlGetSuggestion          *sql.Stmt
+	sqlGetSuggestionByMessage *sql.Stmt
+	sqlGetSuggestions         *sql.Stmt
+	sqlGetInactiveMembers     *sql.Stmt
+	sqlGetUserDataSummary     *sql.Stmt
+	sqlMoveOffenses           *sql.Stmt
+	sqlMoveModlog             *sql.Stmt
+	sqlMergeActivity          *sql.Stmt
+	sqlRemoveUserActivity     *sql.Stmt
+	sqlMoveBirthday           *sql.Stmt
+	sqlRemoveBirthday         *sql.Stmt
+	sqlMergeFirstSeen         *sql.Stmt
+	sqlGetAllEvents           *sql.Stmt
+	sqlGetGuildEvent          *sql.Stmt
+	sqlGetVerified            *sql.Stmt
+	sqlAddVerified            *sql.Stmt
+	sqlRemoveVerified         *sql.Stmt
+	sqlGetVerifyCandidates    *sql.Stmt
+	sqlRemoveMessage          *sql.Stmt
 }
 
 func DB_Load(log logger, driver string, conn string) (*BotDB, error) {
@@ -234,6 +302,7 @@ func (db *BotDB) LoadStatements() error {
 	db.sqlGetEventsByType, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ? AND Type = ? ORDER BY Date ASC LIMIT ?")
 	db.sqlGetNextEvent, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ? AND Type = ? ORDER BY Date ASC LIMIT 1")
 	db.sqlGetReminders, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ? AND Type = 6 AND Data LIKE ? ORDER BY Date ASC LIMIT ?")
+	db.sqlCountReminders, err = db.Prepare("SELECT COUNT(*) FROM schedule WHERE Guild = ? AND Type = 6 AND Data LIKE ?")
 	db.sqlGetUnsilenceDate, err = db.Prepare("SELECT Date FROM schedule WHERE Guild = ? AND Type = 8 AND Data = ?")
 	db.sqlGetTimeZone, err = db.Prepare("SELECT Location FROM users WHERE ID = ?")
 	db.sqlFindTimeZone, err = db.Prepare("SELECT Location FROM timezones WHERE Location LIKE ?")
@@ -256,6 +325,73 @@ func (db *BotDB) LoadStatements() error {
 	db.sqlCheckOption, err = db.Prepare("SELECT `Option` FROM polloptions WHERE poll = ? AND `Index` = ?")
 	db.sqlSentMessage, err = db.Prepare("UPDATE `members` SET `FirstMessage` = UTC_TIMESTAMP() WHERE ID = ? AND Guild = ? AND `FirstMessage` IS NULL")
 	db.sqlGetNewcomers, err = db.Prepare("SELECT ID FROM `members` WHERE `Guild` = ? AND `FirstMessage` > DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? SECOND)")
+	db.sqlAddModlog, err = db.Prepare("INSERT IGNORE INTO modlog (ID, Guild, Type, User, Moderator, Reason, Timestamp) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	db.sqlGetNewestModlog, err = db.Prepare("SELECT COALESCE(MAX(ID), 0) FROM modlog WHERE Guild = ? AND Type < ?")
+	db.sqlSearchModlog, err = db.Prepare("SELECT Source, Type, User, Moderator, Reason, Timestamp FROM (SELECT 0 AS Source, Type, User, Moderator, Reason, Timestamp FROM modlog WHERE Guild = ? AND Timestamp >= ? AND FIND_IN_SET(Type, ?) UNION ALL SELECT 1, Type, User, Moderator, Reason, Timestamp FROM offenses WHERE Guild = ? AND Timestamp >= ? AND FIND_IN_SET(Type, ?)) M ORDER BY Timestamp DESC LIMIT ? OFFSET ?")
+	db.sqlSearchModlogUser, err = db.Prepare("SELECT Source, Type, User, Moderator, Reason, Timestamp FROM (SELECT 0 AS Source, Type, User, Moderator, Reason, Timestamp FROM modlog WHERE Guild = ? AND User = ? AND Timestamp >= ? AND FIND_IN_SET(Type, ?) UNION ALL SELECT 1, Type, User, Moderator, Reason, Timestamp FROM offenses WHERE Guild = ? AND User = ? AND Timestamp >= ? AND FIND_IN_SET(Type, ?)) M ORDER BY Timestamp DESC LIMIT ? OFFSET ?")
+	db.sqlGetJobNextRun, err = db.Prepare("SELECT NextRun FROM jobs WHERE Name = ?")
+	db.sqlSetJobNextRun, err = db.Prepare("INSERT INTO jobs (Name, NextRun) VALUES (?, ?) ON DUPLICATE KEY UPDATE NextRun = ?")
+	db.sqlAddActivity, err = db.Prepare("INSERT INTO activity (Guild, ID, Day, Count) VALUES (?, ?, UTC_DATE(), 1) ON DUPLICATE KEY UPDATE Count = Count + 1")
+	db.sqlGetActivity, err = db.Prepare("SELECT Day, Count FROM activity WHERE Guild = ? AND ID = ? AND Day > DATE_SUB(UTC_DATE(), INTERVAL ? DAY)")
+	db.sqlGetGuildActivity, err = db.Prepare("SELECT COALESCE(SUM(Count), 0), COUNT(DISTINCT ID) FROM activity WHERE Guild = ? AND Day >= DATE_SUB(UTC_DATE(), INTERVAL ? DAY) AND Day < UTC_DATE()")
+	db.sqlPruneActivity, err = db.Prepare("DELETE FROM activity WHERE Day <= DATE_SUB(UTC_DATE(), INTERVAL ? DAY)")
+	db.sqlSetAFK, err = db.Prepare("INSERT INTO afk (Guild, ID, Message, Timestamp) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE Message = ?, Timestamp = ?")
+	db.sqlRemoveAFK, err = db.Prepare("DELETE FROM afk WHERE Guild = ? AND ID = ?")
+	db.sqlGetAFK, err = db.Prepare("SELECT ID, Message, Timestamp FROM afk WHERE Guild = ?")
+	db.sqlAddChannelReminder, err = db.Prepare("INSERT INTO channelreminders (Guild, Channel, Spec, Message, NextRun) VALUES (?, ?, ?, ?, ?)")
+	db.sqlCountChannelReminders, err = db.Prepare("SELECT COUNT(*) FROM channelreminders WHERE Guild = ?")
+	db.sqlGetChannelReminders, err = db.Prepare("SELECT ID, Guild, Channel, Spec, Message, NextRun, Paused FROM channelreminders WHERE Guild = ? ORDER BY ID ASC")
+	db.sqlGetDueChannelReminders, err = db.Prepare("SELECT ID, Guild, Channel, Spec, Message, NextRun, Paused FROM channelreminders WHERE Paused = 0 AND NextRun <= ?")
+	db.sqlSetChannelReminderNext, err = db.Prepare("UPDATE channelreminders SET NextRun = ? WHERE ID = ?")
+	db.sqlPauseChannelReminder, err = db.Prepare("UPDATE channelreminders SET Paused = ?, NextRun = ? WHERE ID = ? AND Guild = ?")
+	db.sqlRemoveChannelReminder, err = db.Prepare("DELETE FROM channelreminders WHERE ID = ? AND Guild = ?")
+	db.sqlAddPinboardMirror, err = db.Prepare("INSERT IGNORE INTO pinboard (Message, Guild, Mirror) VALUES (?, ?, ?)")
+	db.sqlGetPinboardMirror, err = db.Prepare("SELECT Mirror FROM pinboard WHERE Message = ? AND Guild = ?")
+	db.sqlRemovePinboardMirror, err = db.Prepare("DELETE FROM pinboard WHERE Message = ? AND Guild = ?")
+	db.sqlSetApprovalPending, err = db.Prepare("INSERT INTO approvals (Guild, ID, Channel, Queued, Content, Timestamp, Trusted) VALUES (?, ?, ?, ?, ?, ?, 0) ON DUPLICATE KEY UPDATE Channel = ?, Queued = ?, Content = ?, Timestamp = ?, Trusted = 0")
+	db.sqlSetApprovalTrusted, err = db.Prepare("INSERT INTO approvals (Guild, ID, Content, Timestamp, Trusted) VALUES (?, ?, '', UTC_TIMESTAMP(), 1) ON DUPLICATE KEY UPDATE Channel = 0, Queued = 0, Content = '', Timestamp = UTC_TIMESTAMP(), Trusted = 1")
+	db.sqlRemoveApproval, err = db.Prepare("DELETE FROM approvals WHERE Guild = ? AND ID = ?")
+	db.sqlGetApprovals, err = db.Prepare("SELECT ID, Channel, Queued, Content, Timestamp, Trusted FROM approvals WHERE Guild = ?")
+	db.sqlAddOffense, err = db.Prepare("INSERT INTO offenses (Guild, User, Type, Moderator, Reason, Timestamp) VALUES (?, ?, ?, ?, ?, ?)")
+	db.sqlGetOffenses, err = db.Prepare("SELECT * FROM (SELECT ID, Type, User, Moderator, Reason, Timestamp FROM offenses WHERE Guild = ? AND User = ? ORDER BY Timestamp DESC LIMIT 1000) O ORDER BY Timestamp ASC")
+	db.sqlSetColorRole, err = db.Prepare("INSERT INTO colorroles (Guild, User, Role) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE Role = ?")
+	db.sqlGetColorRole, err = db.Prepare("SELECT Role FROM colorroles WHERE Guild = ? AND User = ?")
+	db.sqlRemoveColorRole, err = db.Prepare("DELETE FROM colorroles WHERE Guild = ? AND User = ?")
+	db.sqlGetColorRoles, err = db.Prepare("SELECT User, Role FROM colorroles WHERE Guild = ?")
+	db.sqlSetSticky, err = db.Prepare("INSERT INTO stickies (Channel, Guild, Message, Current) VALUES (?, ?, ?, 0) ON DUPLICATE KEY UPDATE Message = ?, Current = 0")
+	db.sqlSetStickyCurrent, err = db.Prepare("UPDATE stickies SET Current = ? WHERE Channel = ?")
+	db.sqlRemoveSticky, err = db.Prepare("DELETE FROM stickies WHERE Channel = ?")
+	db.sqlGetStickies, err = db.Prepare("SELECT Channel, Message, Current FROM stickies WHERE Guild = ?")
+	db.sqlAddTempChannel, err = db.Prepare("INSERT IGNORE INTO tempchannels (Channel, Guild) VALUES (?, ?)")
+	db.sqlRemoveTempChannel, err = db.Prepare("DELETE FROM tempchannels WHERE Channel = ?")
+	db.sqlGetTempChannels, err = db.Prepare("SELECT Channel FROM tempchannels WHERE Guild = ?")
+	db.sqlAddOperation, err = db.Prepare("INSERT INTO operations (Guild, Kind, Author, Channel, Description, State, Total, Updated) VALUES (?, ?, ?, ?, ?, ?, ?, UTC_TIMESTAMP())")
+	db.sqlUpdateOperation, err = db.Prepare("UPDATE operations SET Done = ?, Failed = ?, Updated = UTC_TIMESTAMP() WHERE ID = ?")
+	db.sqlRemoveOperation, err = db.Prepare("DELETE FROM operations WHERE ID = ?")
+	db.sqlGetOperations, err = db.Prepare("SELECT ID, Kind, Author, Channel, Description, State, Total, Done, Failed, Updated FROM operations WHERE Guild = ? ORDER BY ID ASC")
+	db.sqlAddSuggestion, err = db.Prepare("INSERT INTO suggestions (Guild, Author, Content, Timestamp) VALUES (?, ?, ?, UTC_TIMESTAMP())")
+	db.sqlSetSuggestionMessage, err = db.Prepare("UPDATE suggestions SET Channel = ?, Message = ? WHERE ID = ?")
+	db.sqlSetSuggestionStatus, err = db.Prepare("UPDATE suggestions SET Status = ?, Moderator = ?, Reason = ? WHERE ID = ?")
+	db.sqlSetSuggestionVotes, err = db.Prepare("UPDATE suggestions SET Up = ?, Down = ? WHERE ID = ?")
+	db.sqlGetSuggestion, err = db.Prepare("SELECT ID, Author, Channel, Message, Content, Status, Up, Down, Moderator, Reason, Timestamp FROM suggestions WHERE ID = ? AND Guild = ?")
+	db.sqlGetSuggestionByMessage, err = db.Prepare("SELECT ID, Author, Channel, Message, Content, Status, Up, Down, Moderator, Reason, Timestamp FROM suggestions WHERE Message = ? AND Guild = ?")
+	db.sqlGetSuggestions, err = db.Prepare("SELECT ID, Author, Channel, Message, Content, Status, Up, Down, Moderator, Reason, Timestamp FROM suggestions WHERE Guild = ? AND FIND_IN_SET(Status, ?) ORDER BY ID DESC LIMIT ? OFFSET ?")
+	db.sqlGetInactiveMembers, err = db.Prepare("SELECT M.ID FROM members M INNER JOIN users U ON U.ID = M.ID WHERE M.Guild = ? AND U.LastSeen < ?")
+	db.sqlGetUserDataSummary, err = db.Prepare("SELECT (SELECT COUNT(*) FROM offenses WHERE Guild = ? AND User = ?), (SELECT COUNT(*) FROM modlog WHERE Guild = ? AND User = ?), (SELECT COUNT(*) FROM activity WHERE Guild = ? AND ID = ?), (SELECT COALESCE(SUM(Count), 0) FROM activity WHERE Guild = ? AND ID = ?), (SELECT COUNT(*) FROM schedule WHERE Guild = ? AND Type = 1 AND Data = ?), COALESCE((SELECT Role FROM colorroles WHERE Guild = ? AND User = ?), 0), (SELECT FirstSeen FROM members WHERE Guild = ? AND ID = ?)")
+	db.sqlMoveOffenses, err = db.Prepare("UPDATE offenses SET User = ? WHERE Guild = ? AND User = ?")
+	db.sqlMoveModlog, err = db.Prepare("UPDATE modlog SET User = ? WHERE Guild = ? AND User = ?")
+	db.sqlMergeActivity, err = db.Prepare("INSERT INTO activity (Guild, ID, Day, Count) SELECT Guild, ?, Day, Count FROM activity WHERE Guild = ? AND ID = ? ON DUPLICATE KEY UPDATE Count = CASE ? WHEN 'merge' THEN activity.Count + VALUES(Count) WHEN 'replace' THEN VALUES(Count) ELSE activity.Count END")
+	db.sqlRemoveUserActivity, err = db.Prepare("DELETE FROM activity WHERE Guild = ? AND ID = ?")
+	db.sqlMoveBirthday, err = db.Prepare("UPDATE schedule SET Data = ? WHERE Guild = ? AND Data = ? AND (Type = 1 OR Type = 4)")
+	db.sqlRemoveBirthday, err = db.Prepare("DELETE FROM schedule WHERE Guild = ? AND Data = ? AND (Type = 1 OR Type = 4)")
+	db.sqlMergeFirstSeen, err = db.Prepare("UPDATE members N INNER JOIN members O ON O.Guild = N.Guild AND O.ID = ? SET N.FirstSeen = LEAST(N.FirstSeen, O.FirstSeen), N.FirstMessage = COALESCE(LEAST(N.FirstMessage, O.FirstMessage), N.FirstMessage, O.FirstMessage) WHERE N.Guild = ? AND N.ID = ?")
+	db.sqlGetAllEvents, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ? ORDER BY Date ASC LIMIT ?")
+	db.sqlGetGuildEvent, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE ID = ? AND Guild = ?")
+	db.sqlGetVerified, err = db.Prepare("SELECT User FROM verified WHERE Guild = ?")
+	db.sqlAddVerified, err = db.Prepare("INSERT IGNORE INTO verified (Guild, User, Since) VALUES (?, ?, UTC_TIMESTAMP())")
+	db.sqlRemoveVerified, err = db.Prepare("DELETE FROM verified WHERE Guild = ? AND User = ?")
+	db.sqlGetVerifyCandidates, err = db.Prepare("SELECT M.ID FROM members M WHERE M.Guild = ? AND M.FirstSeen <= ? AND (SELECT COALESCE(SUM(A.Count), 0) FROM activity A WHERE A.Guild = M.Guild AND A.ID = M.ID) >= ? AND NOT EXISTS (SELECT 1 FROM offenses O WHERE O.Guild = M.Guild AND O.User = M.ID AND O.Type != 2 AND O.Timestamp >= ?)")
+	db.sqlRemoveMessage, err = db.Prepare("DELETE FROM chatlog WHERE ID = ?")
 	return err
 }
 
@@ -297,6 +433,12 @@ func (db *BotDB) AddMessage(id uint64, author uint64, message string, channel ui
 	db.CheckError("AddMessage", err)
 }
 
+// RemoveMessage deletes a message from the chat log. The chatlog_before_delete trigger removes its edit history too.
+func (db *BotDB) RemoveMessage(id uint64) {
+	_, err := db.sqlRemoveMessage.Exec(id)
+	db.CheckError("RemoveMessage", err)
+}
+
 func (db *BotDB) GetMessage(id uint64) (uint64, string, time.Time, uint64) {
 	var author uint64
 	var message string
@@ -784,6 +926,33 @@ func (db *BotDB) GetEventsByType(guild uint64, ty uint8, maxnum int) []ScheduleE
 	return r
 }
 
+// GetAllEvents returns every kind of scheduled event on a server, including the ones only moderators can see, soonest first
+func (db *BotDB) GetAllEvents(guild uint64, maxnum int) []ScheduleEvent {
+	q, err := db.sqlGetAllEvents.Query(guild, maxnum)
+	if db.CheckError("GetAllEvents", err) {
+		return []ScheduleEvent{}
+	}
+	defer q.Close()
+	r := make([]ScheduleEvent, 0, 8)
+	for q.Next() {
+		p := ScheduleEvent{}
+		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetGuildEvent is like GetEvent, but returns nil if the event belongs to a different server
+func (db *BotDB) GetGuildEvent(id uint64, guild uint64) *ScheduleEvent {
+	e := &ScheduleEvent{}
+	err := db.sqlGetGuildEvent.QueryRow(id, guild).Scan(&e.ID, &e.Date, &e.Type, &e.Data)
+	if err == sql.ErrNoRows || db.CheckError("GetGuildEvent", err) {
+		return nil
+	}
+	return e
+}
+
 func (db *BotDB) GetNextEvent(guild uint64, ty uint8) ScheduleEvent {
 	p := ScheduleEvent{}
 	err := db.sqlGetNextEvent.QueryRow(guild, ty).Scan(&p.ID, &p.Date, &p.Type, &p.Data)
@@ -793,6 +962,14 @@ func (db *BotDB) GetNextEvent(guild uint64, ty uint8) ScheduleEvent {
 	return p
 }
 
+// CountReminders returns how many pending reminders a user has on a server
+func (db *BotDB) CountReminders(guild uint64, id string) int {
+	var i int
+	err := db.sqlCountReminders.QueryRow(guild, id+"|%").Scan(&i)
+	db.CheckError("CountReminders", err)
+	return i
+}
+
 func (db *BotDB) GetReminders(guild uint64, id string, maxnum int) []ScheduleEvent {
 	q, err := db.sqlGetReminders.Query(guild, id+"|%", maxnum)
 	if db.CheckError("GetReminders", err) {
@@ -1060,3 +1237,654 @@ func (db *BotDB) GetNewcomers(lookback int, guild uint64) []uint64 {
 	}
 	return r
 }
+
+type ModlogEntry struct {
+	ID        uint64
+	Type      uint8
+	User      uint64
+	Moderator uint64
+	Reason    string
+	Timestamp time.Time
+}
+
+func (db *BotDB) AddModlog(e ModlogEntry, guild uint64) {
+	_, err := db.sqlAddModlog.Exec(e.ID, guild, e.Type, e.User, e.Moderator, e.Reason, e.Timestamp)
+	db.CheckError("AddModlog", err)
+}
+
+// GetNewestModlog returns the ID of the newest entry reconciled from the audit log, ignoring the ones the bot recorded itself
+func (db *BotDB) GetNewestModlog(guild uint64) uint64 {
+	var id uint64
+	err := db.sqlGetNewestModlog.QueryRow(guild, modlogRecorded).Scan(&id)
+	db.CheckError("GetNewestModlog", err)
+	return id
+}
+
+// ModerationAction is an entry from either the modlog or the offenses table. If Offense is true, Type is an offense type,
+// otherwise it's a modlog type.
+type ModerationAction struct {
+	Offense   bool
+	Type      uint8
+	User      uint64
+	Moderator uint64
+	Reason    string
+	Timestamp time.Time
+}
+
+// SearchModlog returns the newest moderation actions since the given time, newest first. modlogTypes and offenseTypes are
+// comma separated lists of the types to include. If user is 0, actions on every user are returned.
+func (db *BotDB) SearchModlog(guild uint64, user uint64, since time.Time, modlogTypes string, offenseTypes string, maxnum int, offset int) []ModerationAction {
+	var q *sql.Rows
+	var err error
+	if user == 0 {
+		q, err = db.sqlSearchModlog.Query(guild, since, modlogTypes, guild, since, offenseTypes, maxnum, offset)
+	} else {
+		q, err = db.sqlSearchModlogUser.Query(guild, user, since, modlogTypes, guild, user, since, offenseTypes, maxnum, offset)
+	}
+	if db.CheckError("SearchModlog", err) {
+		return []ModerationAction{}
+	}
+	defer q.Close()
+	r := make([]ModerationAction, 0, maxnum)
+	for q.Next() {
+		p := ModerationAction{}
+		if err := q.Scan(&p.Offense, &p.Type, &p.User, &p.Moderator, &p.Reason, &p.Timestamp); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+func (db *BotDB) GetJobNextRun(name string) (time.Time, bool) {
+	var t time.Time
+	err := db.sqlGetJobNextRun.QueryRow(name).Scan(&t)
+	if err == sql.ErrNoRows || db.CheckError("GetJobNextRun", err) {
+		return t, false
+	}
+	return t.UTC(), true
+}
+
+func (db *BotDB) SetJobNextRun(name string, next time.Time) {
+	_, err := db.sqlSetJobNextRun.Exec(name, next, next)
+	db.CheckError("SetJobNextRun", err)
+}
+
+func (db *BotDB) AddActivity(user uint64, guild uint64) {
+	_, err := db.sqlAddActivity.Exec(guild, user)
+	db.CheckError("AddActivity", err)
+}
+
+// GetActivity returns the number of messages a user sent on each of the last N days, keyed by the UTC date in YYYY-MM-DD format
+func (db *BotDB) GetActivity(user uint64, guild uint64, days int) map[string]int {
+	r := make(map[string]int)
+	q, err := db.sqlGetActivity.Query(guild, user, days)
+	if db.CheckError("GetActivity", err) {
+		return r
+	}
+	defer q.Close()
+	for q.Next() {
+		var day time.Time
+		var count int
+		if err := q.Scan(&day, &count); err == nil {
+			r[day.Format("2006-01-02")] = count
+		}
+	}
+	return r
+}
+
+// GetGuildActivity returns how many messages were sent on a server over the last N full days in UTC, not counting
+// today, and by how many members
+func (db *BotDB) GetGuildActivity(guild uint64, days int) (int, int) {
+	var messages, members int
+	err := db.sqlGetGuildActivity.QueryRow(guild, days).Scan(&messages, &members)
+	db.CheckError("GetGuildActivity", err)
+	return messages, members
+}
+
+func (db *BotDB) PruneActivity(days int) {
+	_, err := db.sqlPruneActivity.Exec(days)
+	db.CheckError("PruneActivity", err)
+}
+
+func (db *BotDB) SetAFK(user uint64, guild uint64, message string, timestamp time.Time) {
+	_, err := db.sqlSetAFK.Exec(guild, user, message, timestamp, message, timestamp)
+	db.CheckError("SetAFK", err)
+}
+
+func (db *BotDB) RemoveAFK(user uint64, guild uint64) {
+	_, err := db.sqlRemoveAFK.Exec(guild, user)
+	db.CheckError("RemoveAFK", err)
+}
+
+func (db *BotDB) GetAFK(guild uint64) map[uint64]*afkStatus {
+	r := make(map[uint64]*afkStatus)
+	q, err := db.sqlGetAFK.Query(guild)
+	if db.CheckError("GetAFK", err) {
+		return r
+	}
+	defer q.Close()
+	for q.Next() {
+		var id uint64
+		p := &afkStatus{}
+		if err := q.Scan(&id, &p.message, &p.since); err == nil {
+			r[id] = p
+		}
+	}
+	return r
+}
+
+type ChannelReminder struct {
+	ID      uint64
+	Guild   uint64
+	Channel uint64
+	Spec    string
+	Message string
+	NextRun time.Time
+	Paused  bool
+}
+
+// CountChannelReminders returns how many channel reminders a server has
+func (db *BotDB) CountChannelReminders(guild uint64) int {
+	var i int
+	err := db.sqlCountChannelReminders.QueryRow(guild).Scan(&i)
+	db.CheckError("CountChannelReminders", err)
+	return i
+}
+
+// AddChannelReminder stores a new channel reminder. How many a server can have is up to limits.channelreminders, which
+// the caller checks.
+func (db *BotDB) AddChannelReminder(guild uint64, channel uint64, spec string, message string, next time.Time) bool {
+	_, err := db.sqlAddChannelReminder.Exec(guild, channel, spec, message, next)
+	return !db.CheckError("AddChannelReminder", err)
+}
+
+func (db *BotDB) scanChannelReminders(fn string, q *sql.Rows, err error) []ChannelReminder {
+	if db.CheckError(fn, err) {
+		return []ChannelReminder{}
+	}
+	defer q.Close()
+	r := make([]ChannelReminder, 0, 2)
+	for q.Next() {
+		p := ChannelReminder{}
+		if err := q.Scan(&p.ID, &p.Guild, &p.Channel, &p.Spec, &p.Message, &p.NextRun, &p.Paused); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+func (db *BotDB) GetChannelReminders(guild uint64) []ChannelReminder {
+	q, err := db.sqlGetChannelReminders.Query(guild)
+	return db.scanChannelReminders("GetChannelReminders", q, err)
+}
+
+func (db *BotDB) GetDueChannelReminders(now time.Time) []ChannelReminder {
+	q, err := db.sqlGetDueChannelReminders.Query(now)
+	return db.scanChannelReminders("GetDueChannelReminders", q, err)
+}
+
+func (db *BotDB) SetChannelReminderNext(id uint64, next time.Time) {
+	_, err := db.sqlSetChannelReminderNext.Exec(next, id)
+	db.CheckError("SetChannelReminderNext", err)
+}
+
+// PauseChannelReminder pauses or resumes a reminder. Returns false if the reminder doesn't exist on this guild.
+func (db *BotDB) PauseChannelReminder(id uint64, guild uint64, paused bool, next time.Time) bool {
+	r, err := db.sqlPauseChannelReminder.Exec(paused, next, id, guild)
+	if db.CheckError("PauseChannelReminder", err) {
+		return false
+	}
+	n, _ := r.RowsAffected()
+	return n > 0
+}
+
+func (db *BotDB) RemoveChannelReminder(id uint64, guild uint64) bool {
+	r, err := db.sqlRemoveChannelReminder.Exec(id, guild)
+	if db.CheckError("RemoveChannelReminder", err) {
+		return false
+	}
+	n, _ := r.RowsAffected()
+	return n > 0
+}
+
+func (db *BotDB) AddPinboardMirror(message uint64, guild uint64, mirror uint64) {
+	_, err := db.sqlAddPinboardMirror.Exec(message, guild, mirror)
+	db.CheckError("AddPinboardMirror", err)
+}
+
+// GetPinboardMirror returns the ID of the message mirroring this one in the pinboard channel, or 0 if there isn't one
+func (db *BotDB) GetPinboardMirror(message uint64, guild uint64) uint64 {
+	var mirror uint64
+	err := db.sqlGetPinboardMirror.QueryRow(message, guild).Scan(&mirror)
+	if err == sql.ErrNoRows || db.CheckError("GetPinboardMirror", err) {
+		return 0
+	}
+	return mirror
+}
+
+func (db *BotDB) RemovePinboardMirror(message uint64, guild uint64) {
+	_, err := db.sqlRemovePinboardMirror.Exec(message, guild)
+	db.CheckError("RemovePinboardMirror", err)
+}
+
+func (db *BotDB) SetApprovalPending(user uint64, guild uint64, channel uint64, queued uint64, content string, timestamp time.Time) {
+	_, err := db.sqlSetApprovalPending.Exec(guild, user, channel, queued, content, timestamp, channel, queued, content, timestamp)
+	db.CheckError("SetApprovalPending", err)
+}
+
+func (db *BotDB) SetApprovalTrusted(user uint64, guild uint64) {
+	_, err := db.sqlSetApprovalTrusted.Exec(guild, user)
+	db.CheckError("SetApprovalTrusted", err)
+}
+
+func (db *BotDB) RemoveApproval(user uint64, guild uint64) {
+	_, err := db.sqlRemoveApproval.Exec(guild, user)
+	db.CheckError("RemoveApproval", err)
+}
+
+// GetApprovals returns the set of members that have been approved, and the messages still waiting for approval
+func (db *BotDB) GetApprovals(guild uint64) (map[uint64]bool, map[uint64]*pendingApproval) {
+	trusted := make(map[uint64]bool)
+	pending := make(map[uint64]*pendingApproval)
+	q, err := db.sqlGetApprovals.Query(guild)
+	if db.CheckError("GetApprovals", err) {
+		return trusted, pending
+	}
+	defer q.Close()
+	for q.Next() {
+		var id, queued uint64
+		var istrusted bool
+		p := &pendingApproval{}
+		if err := q.Scan(&id, &p.channel, &queued, &p.content, &p.since, &istrusted); err == nil {
+			if istrusted {
+				trusted[id] = true
+			} else {
+				p.queued = SBitoa(queued)
+				pending[id] = p
+			}
+		}
+	}
+	return trusted, pending
+}
+
+type Offense struct {
+	ID        uint64
+	Type      uint8
+	User      uint64
+	Moderator uint64
+	Reason    string
+	Timestamp time.Time
+}
+
+func (db *BotDB) AddOffense(o Offense, guild uint64) {
+	_, err := db.sqlAddOffense.Exec(guild, o.User, o.Type, o.Moderator, o.Reason, o.Timestamp)
+	db.CheckError("AddOffense", err)
+}
+
+func (db *BotDB) scanUserSet(fn string, q *sql.Rows, err error) map[uint64]bool {
+	r := make(map[uint64]bool)
+	if db.CheckError(fn, err) {
+		return r
+	}
+	defer q.Close()
+	for q.Next() {
+		var id uint64
+		if err := q.Scan(&id); err == nil {
+			r[id] = true
+		}
+	}
+	return r
+}
+
+// GetVerified returns the members of a server who are currently verified
+func (db *BotDB) GetVerified(guild uint64) map[uint64]bool {
+	q, err := db.sqlGetVerified.Query(guild)
+	return db.scanUserSet("GetVerified", q, err)
+}
+
+func (db *BotDB) AddVerified(guild uint64, user uint64) {
+	_, err := db.sqlAddVerified.Exec(guild, user)
+	db.CheckError("AddVerified", err)
+}
+
+// RemoveVerified takes away a member's verification, and returns false if they weren't verified
+func (db *BotDB) RemoveVerified(guild uint64, user uint64) bool {
+	r, err := db.sqlRemoveVerified.Exec(guild, user)
+	if db.CheckError("RemoveVerified", err) {
+		return false
+	}
+	n, _ := r.RowsAffected()
+	return n > 0
+}
+
+// GetVerifyCandidates returns the members first seen before joined, who have sent at least the given number of
+// messages in the activity that's kept, and have had no warnings or spam offenses since clean
+func (db *BotDB) GetVerifyCandidates(guild uint64, joined time.Time, messages int, clean time.Time) map[uint64]bool {
+	q, err := db.sqlGetVerifyCandidates.Query(guild, joined, messages, clean)
+	return db.scanUserSet("GetVerifyCandidates", q, err)
+}
+
+// GetOffenses returns a user's most recent 1000 offenses, oldest first
+func (db *BotDB) GetOffenses(user uint64, guild uint64) []Offense {
+	q, err := db.sqlGetOffenses.Query(guild, user)
+	if db.CheckError("GetOffenses", err) {
+		return []Offense{}
+	}
+	defer q.Close()
+	r := make([]Offense, 0, 2)
+	for q.Next() {
+		p := Offense{}
+		if err := q.Scan(&p.ID, &p.Type, &p.User, &p.Moderator, &p.Reason, &p.Timestamp); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+func (db *BotDB) SetColorRole(user uint64, guild uint64, role uint64) {
+	_, err := db.sqlSetColorRole.Exec(guild, user, role, role)
+	db.CheckError("SetColorRole", err)
+}
+
+// GetColorRole returns the ID of a user's color role, or 0 if they don't have one
+func (db *BotDB) GetColorRole(user uint64, guild uint64) uint64 {
+	var role uint64
+	err := db.sqlGetColorRole.QueryRow(guild, user).Scan(&role)
+	if err == sql.ErrNoRows || db.CheckError("GetColorRole", err) {
+		return 0
+	}
+	return role
+}
+
+func (db *BotDB) RemoveColorRole(user uint64, guild uint64) {
+	_, err := db.sqlRemoveColorRole.Exec(guild, user)
+	db.CheckError("RemoveColorRole", err)
+}
+
+// GetColorRoles returns every color role on a server, by the user it belongs to
+func (db *BotDB) GetColorRoles(guild uint64) map[uint64]uint64 {
+	r := make(map[uint64]uint64)
+	q, err := db.sqlGetColorRoles.Query(guild)
+	if db.CheckError("GetColorRoles", err) {
+		return r
+	}
+	defer q.Close()
+	for q.Next() {
+		var user, role uint64
+		if err := q.Scan(&user, &role); err == nil {
+			r[user] = role
+		}
+	}
+	return r
+}
+
+func (db *BotDB) SetSticky(channel uint64, guild uint64, message string) {
+	_, err := db.sqlSetSticky.Exec(channel, guild, message, message)
+	db.CheckError("SetSticky", err)
+}
+
+func (db *BotDB) SetStickyCurrent(channel uint64, current uint64) {
+	_, err := db.sqlSetStickyCurrent.Exec(current, channel)
+	db.CheckError("SetStickyCurrent", err)
+}
+
+func (db *BotDB) RemoveSticky(channel uint64) {
+	_, err := db.sqlRemoveSticky.Exec(channel)
+	db.CheckError("RemoveSticky", err)
+}
+
+// GetStickies returns every sticky message on a server, by channel
+func (db *BotDB) GetStickies(guild uint64) map[uint64]*stickyMessage {
+	r := make(map[uint64]*stickyMessage)
+	q, err := db.sqlGetStickies.Query(guild)
+	if db.CheckError("GetStickies", err) {
+		return r
+	}
+	defer q.Close()
+	for q.Next() {
+		var channel, current uint64
+		s := &stickyMessage{}
+		if err := q.Scan(&channel, &s.content, &current); err == nil {
+			if current != 0 {
+				s.current = SBitoa(current)
+			}
+			r[channel] = s
+		}
+	}
+	return r
+}
+
+func (db *BotDB) AddTempChannel(channel uint64, guild uint64) {
+	_, err := db.sqlAddTempChannel.Exec(channel, guild)
+	db.CheckError("AddTempChannel", err)
+}
+
+func (db *BotDB) RemoveTempChannel(channel uint64) {
+	_, err := db.sqlRemoveTempChannel.Exec(channel)
+	db.CheckError("RemoveTempChannel", err)
+}
+
+func (db *BotDB) GetTempChannels(guild uint64) []uint64 {
+	q, err := db.sqlGetTempChannels.Query(guild)
+	if db.CheckError("GetTempChannels", err) {
+		return []uint64{}
+	}
+	defer q.Close()
+	r := make([]uint64, 0, 4)
+	for q.Next() {
+		var p uint64
+		if err := q.Scan(&p); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// AddOperation persists a bulk operation that is starting and returns its ID, or 0 if it couldn't be saved
+func (db *BotDB) AddOperation(guild uint64, op *operationProgress) uint64 {
+	res, err := db.sqlAddOperation.Exec(guild, op.Kind, SBatoi(op.Author), SBatoi(op.Channel), op.Desc, op.State, op.Total)
+	if db.CheckError("AddOperation", err) {
+		return 0
+	}
+	id, err := res.LastInsertId()
+	if db.CheckError("AddOperation", err) {
+		return 0
+	}
+	return uint64(id)
+}
+
+func (db *BotDB) UpdateOperation(id uint64, done int64, failed int64) {
+	_, err := db.sqlUpdateOperation.Exec(done, failed, id)
+	db.CheckError("UpdateOperation", err)
+}
+
+func (db *BotDB) RemoveOperation(id uint64) {
+	_, err := db.sqlRemoveOperation.Exec(id)
+	db.CheckError("RemoveOperation", err)
+}
+
+// GetOperations returns every bulk operation saved for a server, including ones that are still running
+func (db *BotDB) GetOperations(guild uint64) []*operationProgress {
+	q, err := db.sqlGetOperations.Query(guild)
+	if db.CheckError("GetOperations", err) {
+		return []*operationProgress{}
+	}
+	defer q.Close()
+	r := make([]*operationProgress, 0, 2)
+	for q.Next() {
+		var author, channel uint64
+		p := &operationProgress{}
+		if err := q.Scan(&p.ID, &p.Kind, &author, &channel, &p.Desc, &p.State, &p.Total, &p.Done, &p.Failed, &p.Updated); err == nil {
+			p.Author = SBitoa(author)
+			p.Channel = SBitoa(channel)
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// Suggestion is a post in the suggestion channel
+type Suggestion struct {
+	ID        uint64
+	Author    uint64
+	Channel   uint64
+	Message   uint64
+	Content   string
+	Status    uint8
+	Up        int
+	Down      int
+	Moderator uint64
+	Reason    string
+	Timestamp time.Time
+}
+
+// AddSuggestion stores a new open suggestion and returns its ID, or 0 if it couldn't be stored
+func (db *BotDB) AddSuggestion(guild uint64, author uint64, content string) uint64 {
+	res, err := db.sqlAddSuggestion.Exec(guild, author, content)
+	if db.CheckError("AddSuggestion", err) {
+		return 0
+	}
+	id, err := res.LastInsertId()
+	if db.CheckError("AddSuggestion", err) {
+		return 0
+	}
+	return uint64(id)
+}
+
+func (db *BotDB) SetSuggestionMessage(id uint64, channel uint64, message uint64) {
+	_, err := db.sqlSetSuggestionMessage.Exec(channel, message, id)
+	db.CheckError("SetSuggestionMessage", err)
+}
+
+func (db *BotDB) SetSuggestionStatus(id uint64, status uint8, moderator uint64, reason string) bool {
+	_, err := db.sqlSetSuggestionStatus.Exec(status, moderator, reason, id)
+	return !db.CheckError("SetSuggestionStatus", err)
+}
+
+func (db *BotDB) SetSuggestionVotes(id uint64, up int, down int) {
+	_, err := db.sqlSetSuggestionVotes.Exec(up, down, id)
+	db.CheckError("SetSuggestionVotes", err)
+}
+
+func scanSuggestion(row interface{ Scan(...interface{}) error }) (*Suggestion, error) {
+	p := &Suggestion{}
+	err := row.Scan(&p.ID, &p.Author, &p.Channel, &p.Message, &p.Content, &p.Status, &p.Up, &p.Down, &p.Moderator, &p.Reason, &p.Timestamp)
+	return p, err
+}
+
+// GetSuggestion returns the suggestion with this ID on this server, or nil if there isn't one
+func (db *BotDB) GetSuggestion(guild uint64, id uint64) *Suggestion {
+	p, err := scanSuggestion(db.sqlGetSuggestion.QueryRow(id, guild))
+	if err == sql.ErrNoRows || db.CheckError("GetSuggestion", err) {
+		return nil
+	}
+	return p
+}
+
+// GetSuggestionByMessage returns the suggestion posted as this message, or nil if it isn't one
+func (db *BotDB) GetSuggestionByMessage(guild uint64, message uint64) *Suggestion {
+	p, err := scanSuggestion(db.sqlGetSuggestionByMessage.QueryRow(message, guild))
+	if err == sql.ErrNoRows || db.CheckError("GetSuggestionByMessage", err) {
+		return nil
+	}
+	return p
+}
+
+// GetSuggestions returns a page of suggestions with any of the given statuses, which is a comma separated list, newest first
+func (db *BotDB) GetSuggestions(guild uint64, statuses string, maxnum int, offset int) []*Suggestion {
+	q, err := db.sqlGetSuggestions.Query(guild, statuses, maxnum, offset)
+	if db.CheckError("GetSuggestions", err) {
+		return []*Suggestion{}
+	}
+	defer q.Close()
+	r := make([]*Suggestion, 0, maxnum)
+	for q.Next() {
+		if p, err := scanSuggestion(q); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetInactiveMembers returns the members of a server who haven't been seen anywhere since the cutoff
+func (db *BotDB) GetInactiveMembers(guild uint64, cutoff time.Time) map[uint64]bool {
+	q, err := db.sqlGetInactiveMembers.Query(guild, cutoff)
+	if db.CheckError("GetInactiveMembers", err) {
+		return map[uint64]bool{}
+	}
+	defer q.Close()
+	r := make(map[uint64]bool)
+	for q.Next() {
+		var id uint64
+		if err := q.Scan(&id); err == nil {
+			r[id] = true
+		}
+	}
+	return r
+}
+
+// UserData is everything a server keeps about one of its members that migrating to a new account can move
+type UserData struct {
+	Offenses     int
+	Modlog       int // moderator actions recorded against them, along with the reasons given
+	ActivityDays int
+	Messages     int
+	Birthday     bool
+	ColorRole    uint64
+	FirstSeen    *time.Time
+}
+
+// GetUserData returns what a server has stored about a user, for previewing a migration
+func (db *BotDB) GetUserData(user uint64, guild uint64) UserData {
+	r := UserData{}
+	var birthdays int
+	err := db.sqlGetUserDataSummary.QueryRow(guild, user, guild, user, guild, user, guild, user, guild, SBitoa(user), guild, user, guild, user).Scan(&r.Offenses, &r.Modlog, &r.ActivityDays, &r.Messages, &birthdays, &r.ColorRole, &r.FirstSeen)
+	db.CheckError("GetUserData", err)
+	r.Birthday = birthdays > 0
+	return r
+}
+
+// MigrateUserData moves a user's data on a server over to another account, all at once, so a failure leaves both
+// accounts as they were. Offenses and moderation log entries are always moved, since they can't conflict. Where both
+// accounts have data, strategy decides what happens: "merge" adds up the message counts of days they both have and
+// keeps the earliest join date, "replace" uses the old account's counts, birthday and color role instead of the new
+// account's and also keeps the earliest join date, and "keep" leaves all of the new account's data alone and only
+// fills in what it's missing.
+func (db *BotDB) MigrateUserData(from uint64, to uint64, guild uint64, strategy string) error {
+	old := db.GetUserData(from, guild)
+	cur := db.GetUserData(to, guild)
+	tx, err := db.db.Begin()
+	if db.CheckError("MigrateUserData", err) {
+		return err
+	}
+	defer tx.Rollback() // Does nothing once the transaction is committed
+	exec := func(stmt *sql.Stmt, args ...interface{}) bool {
+		_, err = tx.Stmt(stmt).Exec(args...)
+		return db.CheckError("MigrateUserData", err)
+	}
+	if exec(db.sqlMoveOffenses, to, guild, from) || exec(db.sqlMoveModlog, to, guild, from) {
+		return err
+	}
+	if exec(db.sqlMergeActivity, to, guild, from, strategy) || exec(db.sqlRemoveUserActivity, guild, from) {
+		return err
+	}
+	if old.Birthday && (!cur.Birthday || strategy == "replace") {
+		if cur.Birthday && exec(db.sqlRemoveBirthday, guild, SBitoa(to)) {
+			return err
+		}
+		if exec(db.sqlMoveBirthday, SBitoa(to), guild, SBitoa(from)) {
+			return err
+		}
+	}
+	if old.ColorRole != 0 && (cur.ColorRole == 0 || strategy == "replace") {
+		if exec(db.sqlSetColorRole, guild, to, old.ColorRole, old.ColorRole) || exec(db.sqlRemoveColorRole, guild, from) {
+			return err
+		}
+	}
+	if strategy != "keep" && exec(db.sqlMergeFirstSeen, from, guild, to) {
+		return err
+	}
+	err = tx.Commit()
+	db.CheckError("MigrateUserData", err)
+	return err
+}
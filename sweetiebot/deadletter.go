@@ -0,0 +1,77 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// droppedEvent is something the bot deliberately threw away to protect itself while under load, like a command that
+// hit the global rate limit, or a message that couldn't be sent
+type droppedEvent struct {
+	Time   time.Time
+	Kind   string // what was dropped, one of droppedKinds
+	Guild  string
+	Reason string
+	Detail string
+}
+
+var droppedKinds = []string{"command", "send", "webhook", "thread", "voice", "dm"}
+
+// Only the most recent drops are kept, in memory, so a long incident can't use up more than a fixed amount of it
+const deadLetterSize = 500
+
+// Details are cut down to this many characters, since they're only there to recognize what was lost
+const deadLetterDetail = 100
+
+// deadLetters is a fixed size ring buffer of dropped events
+type deadLetters struct {
+	lock   sync.Mutex
+	events [deadLetterSize]droppedEvent
+	next   int
+	count  int
+}
+
+var droppedEvents deadLetters
+
+// Records something that was dropped. guild can be empty if it didn't belong to a server.
+func recordDropped(kind string, guild string, reason string, detail string) {
+	droppedEvents.lock.Lock()
+	droppedEvents.events[droppedEvents.next] = droppedEvent{time.Now().UTC(), kind, guild, reason, truncateRunes(detail, deadLetterDetail)}
+	droppedEvents.next = (droppedEvents.next + 1) % deadLetterSize
+	if droppedEvents.count < deadLetterSize {
+		droppedEvents.count++
+	}
+	droppedEvents.lock.Unlock()
+}
+
+// Returns up to max of the most recent dropped events, newest first, optionally only of one kind or from one server
+func (d *deadLetters) recent(max int, kind string, guild string) []droppedEvent {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	r := make([]droppedEvent, 0, max)
+	for i := 1; i <= d.count && len(r) < max; i++ {
+		e := d.events[(d.next-i+deadLetterSize)%deadLetterSize]
+		if (kind == "" || e.Kind == kind) && (guild == "" || e.Guild == guild) {
+			r = append(r, e)
+		}
+	}
+	return r
+}
+
+func (e droppedEvent) String() string {
+	s := fmt.Sprintf("%s [%s] %s", e.Time.Format("Jan 02 15:04:05"), e.Kind, e.Reason)
+	if e.Guild != "" {
+		sb.guildsLock.RLock()
+		if info, ok := sb.guilds[SBatoi(e.Guild)]; ok {
+			s += " in " + info.Name
+		} else {
+			s += " in " + e.Guild
+		}
+		sb.guildsLock.RUnlock()
+	}
+	if e.Detail != "" {
+		s += ": " + e.Detail
+	}
+	return s
+}
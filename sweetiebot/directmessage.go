@@ -0,0 +1,59 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DM responses share one bot-wide bucket, so spamming the bot with private messages can't make it flood a mod channel or get it rate limited
+const dmRate = 0.2
+const dmBurst = 5
+
+// Handles a private message that isn't a command, according to the owner's dmresponse setting
+func handleDirectMessage(m *discordgo.Message) {
+	if m.Author.Bot {
+		return
+	}
+	if sb.Debug {
+		fmt.Printf("[%s] DM from %s (%s): %s\n", time.Now().Format(time.Stamp), userIdentity(m.Author), m.Author.ID, m.Content)
+	}
+	switch sb.DMResponse.Mode {
+	case "reply":
+		if sb.dmbucket.take(dmRate, dmBurst) {
+			sb.dg.ChannelMessageSend(m.ChannelID, sb.DMResponse.Message)
+		} else {
+			recordDropped("dm", "", "DM response limit", "reply to "+userIdentity(m.Author))
+		}
+	case "forward":
+		if !sb.dmbucket.take(dmRate, dmBurst) {
+			recordDropped("dm", "", "DM response limit", "forward from "+userIdentity(m.Author))
+			return
+		}
+		var info *GuildInfo
+		if sb.db.status.get() {
+			info = getDefaultServer(SBatoi(m.Author.ID))
+			if gIDs := sb.db.GetUserGuilds(SBatoi(m.Author.ID)); info == nil && len(gIDs) == 1 {
+				sb.guildsLock.RLock()
+				info = sb.guilds[gIDs[0]]
+				sb.guildsLock.RUnlock()
+			}
+		}
+		if info == nil || info.config.Basic.ModChannel == 0 {
+			sb.dg.ChannelMessageSend(m.ChannelID, sb.DMResponse.Message)
+			return
+		}
+		embed := &discordgo.MessageEmbed{
+			Color:       0x3e92e5,
+			Author:      &discordgo.MessageEmbedAuthor{Name: userIdentity(m.Author), IconURL: m.Author.AvatarURL("")},
+			Description: m.Content,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Private message from user ID " + m.Author.ID},
+		}
+		for _, a := range m.Attachments {
+			embed.Description += "\n" + a.URL
+		}
+		info.SendEmbed(SBitoa(info.config.Basic.ModChannel), embed)
+		sb.dg.ChannelMessageSend(m.ChannelID, "Your message has been passed on to the moderators of "+info.Name+".")
+	}
+}
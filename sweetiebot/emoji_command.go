@@ -0,0 +1,480 @@
+package sweetiebot
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord's documented limits for custom emoji and stickers
+const (
+	maxEmojiSize   = 256 * 1024
+	maxStickerSize = 512 * 1024
+)
+
+var emojinameregex = regexp.MustCompile("^[A-Za-z0-9_]{2,32}$")
+var customemojiregex = regexp.MustCompile("^<a?:([A-Za-z0-9_]+):([0-9]+)>$")
+
+// Number of static (and separately, animated) emoji slots for each boost tier
+func emojiSlots(tier discordgo.PremiumTier) int {
+	switch tier {
+	case discordgo.PremiumTier1:
+		return 100
+	case discordgo.PremiumTier2:
+		return 150
+	case discordgo.PremiumTier3:
+		return 250
+	}
+	return 50
+}
+
+// Number of sticker slots for each boost tier
+func stickerSlots(tier discordgo.PremiumTier) int {
+	switch tier {
+	case discordgo.PremiumTier1:
+		return 15
+	case discordgo.PremiumTier2:
+		return 30
+	case discordgo.PremiumTier3:
+		return 60
+	}
+	return 5
+}
+
+func hasManageEmojis(info *GuildInfo) bool {
+	perms, err := getAllPerms(info, sb.SelfID)
+	if err != nil {
+		return true // If we can't figure out our permissions, let discord tell us instead
+	}
+	return perms&(discordgo.PermissionManageEmojis|discordgo.PermissionAdministrator) != 0
+}
+
+// Gets the URL of the image to upload, preferring an attachment over an explicit URL
+func getUploadSource(args []string, msg *discordgo.Message) string {
+	if len(msg.Attachments) > 0 {
+		return msg.Attachments[0].URL
+	}
+	if len(args) > 0 {
+		return strings.Trim(args[0], "<>")
+	}
+	return ""
+}
+
+// Refuses to connect to anything that isn't on the public internet. This runs after DNS resolution, on every connection
+// including redirects, so a hostname that points at 127.0.0.1 or the cloud metadata address can't get around it.
+func refusePrivateAddress(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%s isn't a public address", host)
+	}
+	return nil
+}
+
+// Users can make the bot download any URL they like, so this never goes through a proxy and only connects to public
+// addresses
+var downloadClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second, Control: refusePrivateAddress}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// Downloads the file at url, refusing anything larger than maxsize or anything that isn't on the public internet.
+// Returns the data and detected content type.
+func downloadUpload(url string, maxsize int) ([]byte, string, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, "", errors.New("that isn't a valid URL")
+	}
+	resp, err := downloadClient.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("server responded with %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxsize)+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxsize {
+		return nil, "", fmt.Errorf("file is larger than %vKB", maxsize/1024)
+	}
+	ty := http.DetectContentType(data)
+	if ty == "text/plain; charset=utf-8" && json.Valid(data) {
+		ty = "application/json" // Lottie stickers are just JSON
+	}
+	return data, ty, nil
+}
+
+func getGuildEmoji(name string, info *GuildInfo) (*discordgo.Emoji, string) {
+	id := ""
+	if m := customemojiregex.FindStringSubmatch(name); m != nil {
+		name = m[1]
+		id = m[2]
+	}
+	name = strings.ToLower(strings.Trim(name, ":"))
+	emojis, err := sb.dg.GuildEmojis(info.ID)
+	if err != nil {
+		return nil, "```Error getting emojis: " + err.Error() + "```"
+	}
+	for _, v := range emojis {
+		if v.ID == id || (id == "" && strings.ToLower(v.Name) == name) {
+			return v, ""
+		}
+	}
+	return nil, "```There is no emoji named " + name + " on this server.```"
+}
+
+func getGuildStickers(info *GuildInfo) ([]*discordgo.Sticker, error) {
+	endpoint := discordgo.EndpointGuildStickers(info.ID)
+	body, err := sb.dg.RequestWithBucketID("GET", endpoint, nil, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var stickers []*discordgo.Sticker
+	err = json.Unmarshal(body, &stickers)
+	return stickers, err
+}
+
+func getGuildSticker(name string, info *GuildInfo) (*discordgo.Sticker, string) {
+	stickers, err := getGuildStickers(info)
+	if err != nil {
+		return nil, "```Error getting stickers: " + err.Error() + "```"
+	}
+	name = strings.ToLower(name)
+	for _, v := range stickers {
+		if v.ID == name || strings.ToLower(v.Name) == name {
+			return v, ""
+		}
+	}
+	return nil, "```There is no sticker named " + name + " on this server.```"
+}
+
+func describeUploadError(err error, what string) string {
+	switch discordErrorCode(err) {
+	case discordgo.ErrCodeMaximumNumberOfEmojisReached:
+		return "```This server is out of emoji slots! Remove an emoji or boost the server to get more.```"
+	case discordgo.ErrCodeMaximumNumberOfStickersReached:
+		return "```This server is out of sticker slots! Remove a sticker or boost the server to get more.```"
+	case discordgo.ErrCodeMissingPermissions:
+		return "```I don't have permission to manage emojis and stickers on this server!```"
+	case discordgo.ErrCodeFileUploadedExceedsTheMaximumSize, discordgo.ErrCodeRequestEntityTooLarge:
+		return "```That file is too large to be used as " + what + ".```"
+	}
+	return apiErrorMessage(err)
+}
+
+type addEmojiCommand struct {
+}
+
+func (c *addEmojiCommand) Name() string {
+	return "AddEmoji"
+}
+func (c *addEmojiCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must provide a name for the emoji!```", false, nil
+	}
+	name := strings.Trim(args[0], ":")
+	if !emojinameregex.MatchString(name) {
+		return "```Emoji names must be between 2 and 32 characters long and can only contain letters, numbers and underscores.```", false, nil
+	}
+	url := getUploadSource(args[1:], msg)
+	if len(url) == 0 {
+		return "```You must attach an image or provide a URL to one!```", false, nil
+	}
+	if !hasManageEmojis(info) {
+		return "```I don't have permission to manage emojis and stickers on this server!```", false, nil
+	}
+	data, ty, err := downloadUpload(url, maxEmojiSize)
+	if err != nil {
+		return "```Couldn't use that image: " + err.Error() + ". Emojis must be 256KB or smaller.```", false, nil
+	}
+	animated := false
+	switch ty {
+	case "image/png", "image/jpeg", "image/webp":
+	case "image/gif":
+		animated = true
+	default:
+		return "```Emojis must be a PNG, JPEG, WEBP or GIF image, but that file is " + ty + ".```", false, nil
+	}
+
+	if guild, err := sb.dg.State.Guild(info.ID); err == nil {
+		count := 0
+		for _, v := range guild.Emojis {
+			if v.Animated == animated {
+				count++
+			}
+			if strings.EqualFold(v.Name, name) {
+				return "```An emoji named " + name + " already exists!```", false, nil
+			}
+		}
+		if count >= emojiSlots(guild.PremiumTier) {
+			kind := "static"
+			if animated {
+				kind = "animated"
+			}
+			return fmt.Sprintf("```This server is out of %s emoji slots (%v/%v)! Remove an emoji or boost the server to get more.```", kind, count, emojiSlots(guild.PremiumTier)), false, nil
+		}
+	}
+
+	emoji, err := sb.dg.GuildEmojiCreate(info.ID, &discordgo.EmojiParams{Name: name, Image: "data:" + ty + ";base64," + base64.StdEncoding.EncodeToString(data)})
+	if err != nil {
+		return describeUploadError(err, "an emoji"), false, nil
+	}
+	return "Added " + emoji.MessageFormat() + " as `:" + emoji.Name + ":`", false, nil
+}
+func (c *addEmojiCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Uploads a new custom emoji to the server from an attached image or a URL. Emojis must be a PNG, JPEG, WEBP or GIF image no larger than 256KB.",
+		Params: []CommandUsageParam{
+			{Name: ":name:", Desc: "Name of the new emoji. Must be 2-32 characters of letters, numbers or underscores.", Optional: false},
+			{Name: "url", Desc: "URL of the image to upload. Not needed if you attach the image to the message instead.", Optional: true},
+		},
+	}
+}
+func (c *addEmojiCommand) UsageShort() string { return "Uploads a new custom emoji." }
+
+type removeEmojiCommand struct {
+}
+
+func (c *removeEmojiCommand) Name() string {
+	return "RemoveEmoji"
+}
+func (c *removeEmojiCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must specify which emoji to remove!```", false, nil
+	}
+	emoji, e := getGuildEmoji(args[0], info)
+	if emoji == nil {
+		return e, false, nil
+	}
+	if err := sb.dg.GuildEmojiDelete(info.ID, emoji.ID); err != nil {
+		return describeUploadError(err, "an emoji"), false, nil
+	}
+	return "```Removed :" + emoji.Name + ":```", false, nil
+}
+func (c *removeEmojiCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Deletes a custom emoji from the server.",
+		Params: []CommandUsageParam{
+			{Name: "emoji", Desc: "Either the emoji itself, or its name.", Optional: false},
+		},
+	}
+}
+func (c *removeEmojiCommand) UsageShort() string { return "Deletes a custom emoji." }
+
+type renameEmojiCommand struct {
+}
+
+func (c *renameEmojiCommand) Name() string {
+	return "RenameEmoji"
+}
+func (c *renameEmojiCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 2 {
+		return "```You must specify the emoji to rename and its new name!```", false, nil
+	}
+	name := strings.Trim(args[1], ":")
+	if !emojinameregex.MatchString(name) {
+		return "```Emoji names must be between 2 and 32 characters long and can only contain letters, numbers and underscores.```", false, nil
+	}
+	emoji, e := getGuildEmoji(args[0], info)
+	if emoji == nil {
+		return e, false, nil
+	}
+	old := emoji.Name
+	emoji, err := sb.dg.GuildEmojiEdit(info.ID, emoji.ID, &discordgo.EmojiParams{Name: name, Roles: emoji.Roles})
+	if err != nil {
+		return describeUploadError(err, "an emoji"), false, nil
+	}
+	return "Renamed `:" + old + ":` to " + emoji.MessageFormat() + " `:" + emoji.Name + ":`", false, nil
+}
+func (c *renameEmojiCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Renames a custom emoji.",
+		Params: []CommandUsageParam{
+			{Name: "emoji", Desc: "Either the emoji itself, or its current name.", Optional: false},
+			{Name: "name", Desc: "The new name of the emoji.", Optional: false},
+		},
+	}
+}
+func (c *renameEmojiCommand) UsageShort() string { return "Renames a custom emoji." }
+
+type addStickerCommand struct {
+}
+
+func (c *addStickerCommand) Name() string {
+	return "AddSticker"
+}
+func (c *addStickerCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 2 {
+		return "```You must provide a name for the sticker and an emoji that represents it!```", false, nil
+	}
+	name := args[0]
+	if len(name) < 2 || len(name) > 30 {
+		return "```Sticker names must be between 2 and 30 characters long.```", false, nil
+	}
+	tag := strings.Trim(args[1], ":")
+	if m := customemojiregex.FindStringSubmatch(args[1]); m != nil {
+		tag = m[1]
+	}
+	url := getUploadSource(args[2:], msg)
+	if len(url) == 0 {
+		return "```You must attach an image or provide a URL to one!```", false, nil
+	}
+	if !hasManageEmojis(info) {
+		return "```I don't have permission to manage emojis and stickers on this server!```", false, nil
+	}
+	data, ty, err := downloadUpload(url, maxStickerSize)
+	if err != nil {
+		return "```Couldn't use that image: " + err.Error() + ". Stickers must be 512KB or smaller.```", false, nil
+	}
+	ext := ""
+	switch ty {
+	case "image/png":
+		ext = ".png"
+	case "image/gif":
+		ext = ".gif"
+	case "application/json":
+		ext = ".json"
+	default:
+		return "```Stickers must be a PNG, APNG, GIF or Lottie JSON file, but that file is " + ty + ".```", false, nil
+	}
+
+	if guild, err := sb.dg.State.Guild(info.ID); err == nil {
+		if stickers, err := getGuildStickers(info); err == nil {
+			if len(stickers) >= stickerSlots(guild.PremiumTier) {
+				return fmt.Sprintf("```This server is out of sticker slots (%v/%v)! Remove a sticker or boost the server to get more.```", len(stickers), stickerSlots(guild.PremiumTier)), false, nil
+			}
+			for _, v := range stickers {
+				if strings.EqualFold(v.Name, name) {
+					return "```A sticker named " + name + " already exists!```", false, nil
+				}
+			}
+		}
+	}
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	w.WriteField("name", name)
+	w.WriteField("tags", tag)
+	w.WriteField("description", "")
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="file"; filename="`+name+ext+`"`)
+	h.Set("Content-Type", ty)
+	p, err := w.CreatePart(h)
+	if err == nil {
+		_, err = p.Write(data)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return "```Error: " + err.Error() + "```", false, nil
+	}
+
+	endpoint := discordgo.EndpointGuildStickers(info.ID)
+	resp, err := sb.dg.RequestWithLockedBucket("POST", endpoint, w.FormDataContentType(), body.Bytes(), sb.dg.Ratelimiter.LockBucket(endpoint), 0)
+	if err != nil {
+		return describeUploadError(err, "a sticker"), false, nil
+	}
+	sticker := discordgo.Sticker{}
+	json.Unmarshal(resp, &sticker)
+	return "```Added the " + sticker.Name + " sticker.```", false, nil
+}
+func (c *addStickerCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Uploads a new sticker to the server from an attached file or a URL. Stickers must be a PNG, APNG, GIF or Lottie JSON file no larger than 512KB.",
+		Params: []CommandUsageParam{
+			{Name: "name", Desc: "Name of the new sticker. Must be 2-30 characters long.", Optional: false},
+			{Name: "emoji", Desc: "The emoji that best represents the sticker, used for suggestions.", Optional: false},
+			{Name: "url", Desc: "URL of the file to upload. Not needed if you attach the file to the message instead.", Optional: true},
+		},
+	}
+}
+func (c *addStickerCommand) UsageShort() string { return "Uploads a new sticker." }
+
+type removeStickerCommand struct {
+}
+
+func (c *removeStickerCommand) Name() string {
+	return "RemoveSticker"
+}
+func (c *removeStickerCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must specify which sticker to remove!```", false, nil
+	}
+	sticker, e := getGuildSticker(msg.Content[indices[0]:], info)
+	if sticker == nil {
+		return e, false, nil
+	}
+	endpoint := discordgo.EndpointGuildSticker(info.ID, sticker.ID)
+	if _, err := sb.dg.RequestWithBucketID("DELETE", endpoint, nil, discordgo.EndpointGuildStickers(info.ID)); err != nil {
+		return describeUploadError(err, "a sticker"), false, nil
+	}
+	return "```Removed the " + sticker.Name + " sticker.```", false, nil
+}
+func (c *removeStickerCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Deletes a sticker from the server.",
+		Params: []CommandUsageParam{
+			{Name: "name", Desc: "Name of the sticker to delete.", Optional: false},
+		},
+	}
+}
+func (c *removeStickerCommand) UsageShort() string { return "Deletes a sticker." }
+
+type renameStickerCommand struct {
+}
+
+func (c *renameStickerCommand) Name() string {
+	return "RenameSticker"
+}
+func (c *renameStickerCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 2 {
+		return "```You must specify the sticker to rename and its new name!```", false, nil
+	}
+	if len(args[1]) < 2 || len(args[1]) > 30 {
+		return "```Sticker names must be between 2 and 30 characters long.```", false, nil
+	}
+	sticker, e := getGuildSticker(args[0], info)
+	if sticker == nil {
+		return e, false, nil
+	}
+	endpoint := discordgo.EndpointGuildSticker(info.ID, sticker.ID)
+	if _, err := sb.dg.RequestWithBucketID("PATCH", endpoint, map[string]string{"name": args[1]}, discordgo.EndpointGuildStickers(info.ID)); err != nil {
+		return describeUploadError(err, "a sticker"), false, nil
+	}
+	return "```Renamed the " + sticker.Name + " sticker to " + args[1] + ".```", false, nil
+}
+func (c *renameStickerCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Renames a sticker. Use quotes if either name has spaces in it.",
+		Params: []CommandUsageParam{
+			{Name: "sticker", Desc: "Current name of the sticker.", Optional: false},
+			{Name: "name", Desc: "The new name of the sticker.", Optional: false},
+		},
+	}
+}
+func (c *renameStickerCommand) UsageShort() string { return "Renames a sticker." }
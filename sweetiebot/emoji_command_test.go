@@ -0,0 +1,30 @@
+package sweetiebot
+
+import "testing"
+
+func TestRefusePrivateAddress(t *testing.T) {
+	cases := []struct {
+		address string
+		ok      bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:4700:4700::1111]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.0.0.5:80", false},
+		{"172.16.3.4:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false}, // Cloud metadata
+		{"[fe80::1]:80", false},
+		{"[fd00::1]:80", false},
+		{"0.0.0.0:80", false},
+		{"224.0.0.1:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"localhost:80", false}, // Only ever called with an IP, so anything else is refused
+	}
+	for _, c := range cases {
+		if err := refusePrivateAddress("tcp", c.address, nil); (err == nil) != c.ok {
+			t.Errorf("refusePrivateAddress(%q) = %v, want ok = %v", c.address, err, c.ok)
+		}
+	}
+}
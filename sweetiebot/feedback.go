@@ -0,0 +1,213 @@
+package sweetiebot
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const defaultFeedbackSuccess = "✅"
+const defaultFeedbackFailure = "❌"
+const defaultFeedbackWorking = "⏳"
+
+// Commands that take longer than this get the working reaction until they finish
+const feedbackWorkingDelay = time.Second
+
+// Returns how command feedback is given on this server: "text" (the default), "react" or "both"
+func feedbackMode(info *GuildInfo) string {
+	switch strings.ToLower(info.config.Feedback.Mode) {
+	case "react":
+		return "react"
+	case "both":
+		return "both"
+	}
+	return "text"
+}
+
+func feedbackEmoji(emoji string, def string) string {
+	if len(emoji) == 0 {
+		return def
+	}
+	return emoji
+}
+
+// Reacts to a command with one of the feedback emoji. If a custom emoji can't be used, the default one is used instead.
+func addFeedback(m *discordgo.Message, emoji string, def string) {
+	err := CallAPI("MessageReactionAdd", func() error { return sb.dg.MessageReactionAdd(m.ChannelID, m.ID, reactionAPIName(emoji)) })
+	if err != nil && emoji != def && ClassifyAPIError(err) != APIErrorPermission {
+		sb.dg.MessageReactionAdd(m.ChannelID, m.ID, def)
+	}
+}
+
+// Returns true if feedback reactions can go on this message. Commands that Sweetie Bot runs herself, like the ones the
+// bored module picks, aren't real messages.
+func wantsFeedback(info *GuildInfo, m *discordgo.Message) bool {
+	return info != nil && len(m.ID) > 0 && m.Author.ID != sb.SelfID && feedbackMode(info) != "text"
+}
+
+// Tells someone their command couldn't be run, with an error message, a reaction, or both, depending on feedback.mode
+func (info *GuildInfo) commandFailed(m *discordgo.Message, message string) {
+	if wantsFeedback(info, m) {
+		addFeedback(m, feedbackEmoji(info.config.Feedback.Failure, defaultFeedbackFailure), defaultFeedbackFailure)
+		if feedbackMode(info) == "react" {
+			return
+		}
+	}
+	info.Error(m.ChannelID, message)
+}
+
+// Someone is only told they can't run a command once in this many seconds
+const commandDeniedCooldown = 60
+
+// Returns what happens when someone runs a command they aren't allowed to: "public" (the default), "dm" or "silent"
+func deniedResponse(info *GuildInfo) string {
+	switch strings.ToLower(info.config.Modules.DeniedResponse) {
+	case "dm":
+		return "dm"
+	case "silent":
+		return "silent"
+	}
+	return "public"
+}
+
+// Tells someone they aren't allowed to run a command, the way modules.deniedresponse says to. Each user is only told
+// once every commandDeniedCooldown seconds, so running a restricted command over and over can't flood the channel.
+func (info *GuildInfo) commandDenied(m *discordgo.Message, message string) {
+	mode := deniedResponse(info)
+	if mode == "silent" {
+		return
+	}
+	now := time.Now().UTC().Unix()
+	info.commandLock.Lock()
+	if info.deniedLast == nil {
+		info.deniedLast = make(map[string]int64)
+	}
+	for u, t := range info.deniedLast {
+		if now-t >= commandDeniedCooldown {
+			delete(info.deniedLast, u)
+		}
+	}
+	_, recent := info.deniedLast[m.Author.ID]
+	if !recent {
+		info.deniedLast[m.Author.ID] = now
+	}
+	info.commandLock.Unlock()
+	if recent {
+		return
+	}
+	if mode == "public" {
+		info.commandFailed(m, message)
+		return
+	}
+	if wantsFeedback(info, m) {
+		addFeedback(m, feedbackEmoji(info.config.Feedback.Failure, defaultFeedbackFailure), defaultFeedbackFailure)
+		if feedbackMode(info) == "react" {
+			return
+		}
+	}
+	if ch, err := sb.dg.UserChannelCreate(m.Author.ID); err == nil {
+		sb.dg.ChannelMessageSend(ch.ID, "```\n"+message+"```")
+	}
+}
+
+// Starts the feedback for a command that's about to run. The working reaction is added if the command is still running
+// after feedbackWorkingDelay. The returned function must be called once it's done, with whether it posted anything,
+// to take the working reaction back off and react with the success emoji. In react mode, only commands with no other
+// response get the success reaction, so every command still gets exactly one answer.
+func startFeedback(info *GuildInfo, m *discordgo.Message) func(responded bool) {
+	if !wantsFeedback(info, m) {
+		return func(bool) {}
+	}
+	working := feedbackEmoji(info.config.Feedback.Working, defaultFeedbackWorking)
+	var lock sync.Mutex
+	added := false
+	timer := time.AfterFunc(feedbackWorkingDelay, func() {
+		lock.Lock()
+		defer lock.Unlock()
+		addFeedback(m, working, defaultFeedbackWorking)
+		added = true
+	})
+	return func(responded bool) {
+		timer.Stop()
+		lock.Lock()
+		if added {
+			sb.dg.MessageReactionRemove(m.ChannelID, m.ID, reactionAPIName(working), sb.SelfID)
+			sb.dg.MessageReactionRemove(m.ChannelID, m.ID, defaultFeedbackWorking, sb.SelfID) // In case the fallback was used
+		}
+		lock.Unlock()
+		if !responded || feedbackMode(info) == "both" {
+			addFeedback(m, feedbackEmoji(info.config.Feedback.Success, defaultFeedbackSuccess), defaultFeedbackSuccess)
+		}
+	}
+}
+
+// Returns why Sweetie Bot can't react with a custom emoji, or an empty string if she can. Unicode emoji always work.
+// Custom emoji have to come from a server she's on, and if the emoji is limited to certain roles, she needs one of them.
+func checkReactionEmoji(emoji string) string {
+	match := customemojiregex.FindStringSubmatch(emoji)
+	if match == nil {
+		if strings.ContainsAny(emoji, "<>:") {
+			return emoji + " isn't a valid emoji. Custom emoji have to be typed normally, so they show up as the emoji."
+		}
+		return ""
+	}
+	sb.dg.State.RLock()
+	defer sb.dg.State.RUnlock()
+	for _, g := range sb.dg.State.Guilds {
+		for _, e := range g.Emojis {
+			if e.ID != match[2] {
+				continue
+			}
+			if !e.Available {
+				return match[1] + " is from " + g.Name + ", which lost the boosts it needed for that emoji, so it can't be used right now."
+			}
+			if len(e.Roles) == 0 {
+				return ""
+			}
+			var self *discordgo.Member
+			for _, m := range g.Members {
+				if m.User.ID == sb.SelfID {
+					self = m
+				}
+			}
+			if self != nil {
+				for _, r := range self.Roles {
+					for _, allowed := range e.Roles {
+						if r == allowed {
+							return ""
+						}
+					}
+				}
+			}
+			return match[1] + " can only be used by certain roles on " + g.Name + ", and Sweetie Bot doesn't have any of them."
+		}
+	}
+	return "Sweetie Bot isn't on the server " + match[1] + " is from, so she can't use it."
+}
+
+// Returns a warning about anything that will keep the feedback reactions from working, or an empty string
+func checkFeedback(info *GuildInfo) string {
+	if mode := strings.ToLower(info.config.Feedback.Mode); len(mode) > 0 && mode != feedbackMode(info) {
+		return "feedback.mode must be text, react or both. Sweetie Bot will reply with text until it's fixed."
+	}
+	if feedbackMode(info) == "text" {
+		return ""
+	}
+	problems := []string{}
+	for _, e := range []string{info.config.Feedback.Success, info.config.Feedback.Failure, info.config.Feedback.Working} {
+		if len(e) > 0 {
+			if s := checkReactionEmoji(e); len(s) > 0 {
+				problems = append(problems, s)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		problems = append(problems, "The default emoji will be used instead of any that can't be.")
+	}
+	if perms, err := getAllPerms(info, sb.SelfID); err == nil && perms&(discordgo.PermissionAdministrator|discordgo.PermissionAddReactions) == 0 {
+		problems = append(problems, "Sweetie Bot doesn't have the Add Reactions permission, so she can't react to commands.")
+	}
+	return strings.Join(problems, "\n")
+}
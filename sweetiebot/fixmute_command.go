@@ -0,0 +1,130 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Overwrite changes are paced so repairing a server with hundreds of channels doesn't get the bot rate limited
+const fixMuteRate = 2
+const fixMuteBurst = 5
+
+type fixMuteCommand struct {
+}
+
+func (c *fixMuteCommand) Name() string {
+	return "FixMute"
+}
+func (c *fixMuteCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !info.HasAdminRole(msg.Author.ID) {
+		if _, isOwner := sb.Owners[SBatoi(msg.Author.ID)]; !isOwner {
+			return "```Only admins can repair the silence role.```", false, nil
+		}
+	}
+	if info.fixmute.test_and_set() {
+		return "```The silence role is already being repaired.```", false, nil
+	}
+	report := []string{}
+	role, err := sb.dg.State.Role(info.ID, SBitoa(info.config.Spam.SilentRole))
+	if info.config.Spam.SilentRole == 0 || err != nil {
+		hoist := false
+		mentionable := false
+		permissions := int64(discordgo.PermissionViewChannel)
+		err = CallAPI("GuildRoleCreate", func() (err error) {
+			role, err = sb.dg.GuildRoleCreate(info.ID, &discordgo.RoleParams{
+				Name:        "Silence",
+				Hoist:       &hoist,
+				Permissions: &permissions,
+				Mentionable: &mentionable,
+			})
+			return
+		})
+		if err != nil {
+			info.fixmute.clear()
+			return "```The silence role doesn't exist, and I couldn't create one: " + err.Error() + "```", false, nil
+		}
+		info.config.Spam.SilentRole = SBatoi(role.ID)
+		info.SaveConfig()
+		report = append(report, "Created a new Silence role, because spam.silentrole didn't point at a role.")
+	}
+	if highestRolePosition(info, sb.SelfID) <= role.Position {
+		report = append(report, "PROBLEM: "+role.Name+" is above my highest role, so I can't silence anyone. Move my role above it in the server settings.")
+	}
+	if role.Permissions&int64(silenceDeny) != 0 {
+		report = append(report, "PROBLEM: "+role.Name+" itself has permission to send messages or make threads. Turn those permissions off for the role.")
+	}
+	if role.Managed || role.ID == info.ID {
+		report = append(report, "PROBLEM: spam.silentrole points at a role that can't be assigned.")
+	}
+
+	guild, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		info.fixmute.clear()
+		return "```Guild not in state?!```", false, nil
+	}
+	sb.dg.State.RLock()
+	channels := make([]*discordgo.Channel, len(guild.Channels))
+	copy(channels, guild.Channels)
+	sb.dg.State.RUnlock()
+	// Another role's overwrite that allows sending beats the silence role's deny, and there's no safe way to fix that for them
+	leaks := []string{}
+	for _, ch := range channels {
+		if SBatoi(ch.ID) == info.config.Users.WelcomeChannel {
+			continue
+		}
+		for _, v := range ch.PermissionOverwrites {
+			if v.Type == discordgo.PermissionOverwriteTypeRole && v.ID != role.ID && v.ID != info.ID && v.Allow&discordgo.PermissionSendMessages != 0 {
+				leaks = append(leaks, "#"+ch.Name)
+				break
+			}
+		}
+	}
+	if len(leaks) > 0 {
+		if len(leaks) > 10 {
+			leaks = append(leaks[:10], fmt.Sprintf("and %v more", len(leaks)-10))
+		}
+		report = append(report, "WARNING: another role is allowed to send messages in "+strings.Join(leaks, ", ")+", which overrides the silence role for anyone with that role.")
+	}
+	go runFixMute(info, channels, msg.ChannelID, msg.Author)
+	report = append(report, "Checking the silence role's permissions in "+Pluralize(int64(len(channels)), " channel")+". This will take about "+TimeDiff(time.Duration(len(channels)/fixMuteRate)*time.Second)+" if they all need fixing.")
+	return "```" + strings.Join(report, "\n") + "```", false, nil
+}
+func (c *fixMuteCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Checks that the silence role exists, that Sweetie Bot can assign it, and that it can't send messages or make threads in any channel except the welcome channel. A missing role is created and missing overwrites are added, slowly, to avoid discord's rate limits. Reports every change it made, plus anything it can't fix on its own. Only admins can use this.",
+	}
+}
+func (c *fixMuteCommand) UsageShort() string { return "Tests and repairs the silence role." }
+
+func runFixMute(info *GuildInfo, channels []*discordgo.Channel, channel string, author *discordgo.User) {
+	defer info.fixmute.clear()
+	var bucket TokenBucket
+	fixed := []string{}
+	failed := []string{}
+	for _, ch := range channels {
+		if sb.quit.get() {
+			break
+		}
+		for !bucket.take(fixMuteRate, fixMuteBurst) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		changed, err := applySilenceOverwrite(info, ch)
+		if err != nil {
+			failed = append(failed, "#"+ch.Name)
+		} else if changed {
+			fixed = append(fixed, "#"+ch.Name)
+		}
+	}
+	tally := "The silence role's permissions were already correct in every channel."
+	if len(fixed) > 0 {
+		tally = "Fixed the silence role's permissions in " + strings.Join(fixed, ", ") + "."
+	}
+	if len(failed) > 0 {
+		tally += "\nCouldn't fix " + strings.Join(failed, ", ") + ". Make sure I have the Manage Roles permission in those channels."
+	}
+	info.LogTo(LogModeration, getUserName(SBatoi(author.ID), info), " repaired the silence role: ", Pluralize(int64(len(fixed)), " channel"), " fixed, ", len(failed), " failed.")
+	info.SendMessage(channel, "```"+tally+"```")
+}
@@ -0,0 +1,272 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// External events have to end at some point, so ones without an end time are given this long
+const defaultGuildEventLength = 2 * time.Hour
+
+const guildEventTimeFormat = "Mon Jan 2, 3:04pm MST"
+
+type guildEventCommand struct {
+}
+
+// Returns true if s could be a discord ID. Anything else would be put straight into the API path, so it has to be checked first.
+func isSnowflake(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil && len(s) >= 15 && len(s) <= 20
+}
+
+func (c *guildEventCommand) Name() string {
+	return "GuildEvent"
+}
+
+func guildEventLink(info *GuildInfo, id string) string {
+	return "https://discord.com/events/" + info.ID + "/" + id
+}
+
+func guildEventError(err error) string {
+	if ClassifyAPIError(err) == APIErrorPermission {
+		return "```I need the Manage Events permission to do that.```"
+	}
+	if ClassifyAPIError(err) == APIErrorNotFound {
+		return "```That event doesn't exist.```"
+	}
+	return "```Discord refused: " + apiErrorMessage(err) + "```"
+}
+
+// Sets where an event happens. A voice or stage channel hosts the event there, anything else is treated as an external location.
+func setGuildEventLocation(info *GuildInfo, params *discordgo.GuildScheduledEventParams, location string) string {
+	if channelregex.MatchString(location) {
+		ch, err := sb.dg.State.Channel(location[2 : len(location)-1])
+		if err != nil || ch.GuildID != info.ID {
+			return "```That channel isn't on this server.```"
+		}
+		switch ch.Type {
+		case discordgo.ChannelTypeGuildVoice:
+			params.EntityType = discordgo.GuildScheduledEventEntityTypeVoice
+		case discordgo.ChannelTypeGuildStageVoice:
+			params.EntityType = discordgo.GuildScheduledEventEntityTypeStageInstance
+		default:
+			return "```Events can only be hosted in voice or stage channels. For anything else, write where it is instead.```"
+		}
+		params.ChannelID = ch.ID
+		params.EntityMetadata = nil
+		return ""
+	}
+	if len(location) > 100 {
+		return "```The location can't be longer than 100 characters.```"
+	}
+	params.EntityType = discordgo.GuildScheduledEventEntityTypeExternal
+	params.ChannelID = ""
+	params.EntityMetadata = &discordgo.GuildScheduledEventEntityMetadata{Location: location}
+	return ""
+}
+
+func describeGuildEvent(info *GuildInfo, e *discordgo.GuildScheduledEvent, tz *time.Location) string {
+	where := e.EntityMetadata.Location
+	if len(e.ChannelID) > 0 {
+		where = "#" + getChannelName(e.ChannelID)
+	}
+	status := ""
+	if e.Status == discordgo.GuildScheduledEventStatusActive {
+		status = " [happening now]"
+	}
+	return fmt.Sprintf("%s: %s%s\n  %s, %s, %v interested", e.ID, e.Name, status, e.ScheduledStartTime.In(tz).Format(guildEventTimeFormat), where, e.UserCount)
+}
+
+func (c *guildEventCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	tz := getTimezone(info, msg.Author)
+	if len(args) < 1 || strings.ToLower(args[0]) == "list" {
+		var events []*discordgo.GuildScheduledEvent
+		err := CallAPI("GuildScheduledEvents", func() (err error) {
+			events, err = sb.dg.GuildScheduledEvents(info.ID, true)
+			return
+		})
+		if err != nil {
+			return guildEventError(err), false, nil
+		}
+		if len(events) == 0 {
+			return "```There are no upcoming events.```", false, nil
+		}
+		lines := make([]string, 0, len(events))
+		for _, e := range events {
+			lines = append(lines, describeGuildEvent(info, e, tz))
+		}
+		return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```", len(lines) > 6, nil
+	}
+
+	if action := strings.ToLower(args[0]); action != "create" && len(args) > 1 && !isSnowflake(args[1]) {
+		return "```" + args[1] + " isn't an event ID. Event IDs are long numbers, like the ones `" + info.config.Basic.CommandPrefix + "guildevent list` shows.```", false, nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "create":
+		if len(args) < 4 {
+			return "```You must give a time, a name and a location. Put the time and name in quotes if they have spaces.```", false, nil
+		}
+		start, err := parseCommonTime(args[1], info, msg.Author)
+		if err != nil {
+			return "```Couldn't understand that time. Try something like \"Jan 2 3:04pm\".```", false, nil
+		}
+		start = start.UTC()
+		if !start.After(time.Now().UTC()) {
+			return "```Events have to start in the future.```", false, nil
+		}
+		if len(args[2]) > 100 {
+			return "```Event names can't be longer than 100 characters.```", false, nil
+		}
+		params := &discordgo.GuildScheduledEventParams{
+			Name:               args[2],
+			ScheduledStartTime: &start,
+			PrivacyLevel:       discordgo.GuildScheduledEventPrivacyLevelGuildOnly,
+		}
+		if e := setGuildEventLocation(info, params, args[3]); len(e) > 0 {
+			return e, false, nil
+		}
+		if params.EntityType == discordgo.GuildScheduledEventEntityTypeExternal {
+			end := start.Add(defaultGuildEventLength)
+			params.ScheduledEndTime = &end
+		}
+		if len(args) == 5 {
+			params.Description = args[4]
+		} else if len(args) > 5 {
+			params.Description = truncateRunes(msg.Content[indices[4]:], 1000)
+		}
+		var e *discordgo.GuildScheduledEvent
+		err = CallAPI("GuildScheduledEventCreate", func() (err error) {
+			e, err = sb.dg.GuildScheduledEventCreate(info.ID, params)
+			return
+		})
+		if err != nil {
+			return guildEventError(err), false, nil
+		}
+		return "```Created event " + e.ID + " for " + start.In(tz).Format(guildEventTimeFormat) + ".```\n" + guildEventLink(info, e.ID), false, nil
+	case "edit":
+		if len(args) < 4 {
+			return "```You must give an event ID, what to change (name, time, end, location or description) and the new value.```", false, nil
+		}
+		params := &discordgo.GuildScheduledEventParams{}
+		value := args[3] // A single value may have been quoted, but several words without quotes are taken as they were typed
+		if len(args) > 4 {
+			value = msg.Content[indices[3]:]
+		}
+		switch strings.ToLower(args[2]) {
+		case "name":
+			if len(value) > 100 {
+				return "```Event names can't be longer than 100 characters.```", false, nil
+			}
+			params.Name = value
+		case "time", "start", "end":
+			t, err := parseCommonTime(value, info, msg.Author)
+			if err != nil {
+				return "```Couldn't understand that time. Try something like \"Jan 2 3:04pm\".```", false, nil
+			}
+			t = t.UTC()
+			if strings.ToLower(args[2]) == "end" {
+				params.ScheduledEndTime = &t
+			} else {
+				params.ScheduledStartTime = &t
+			}
+		case "location":
+			if e := setGuildEventLocation(info, params, value); len(e) > 0 {
+				return e, false, nil
+			}
+			if params.EntityType == discordgo.GuildScheduledEventEntityTypeExternal {
+				// Moving an event out of a channel needs an end time, so keep the one it has or make one up
+				if old, err := sb.dg.GuildScheduledEvent(info.ID, args[1], false); err == nil {
+					end := old.ScheduledStartTime.Add(defaultGuildEventLength)
+					if old.ScheduledEndTime != nil {
+						end = *old.ScheduledEndTime
+					}
+					params.ScheduledEndTime = &end
+				}
+			}
+		case "description":
+			params.Description = truncateRunes(value, 1000)
+		default:
+			return "```You can change an event's name, time, end, location or description.```", false, nil
+		}
+		var e *discordgo.GuildScheduledEvent
+		err := CallAPI("GuildScheduledEventEdit", func() (err error) {
+			e, err = sb.dg.GuildScheduledEventEdit(info.ID, args[1], params)
+			return
+		})
+		if err != nil {
+			return guildEventError(err), false, nil
+		}
+		return "```Updated " + e.Name + ".```", false, nil
+	case "cancel":
+		if len(args) < 2 {
+			return "```You must give the ID of the event to cancel.```", false, nil
+		}
+		e, err := sb.dg.GuildScheduledEvent(info.ID, args[1], false)
+		if err != nil {
+			return guildEventError(err), false, nil
+		}
+		// Discord only lets events that haven't started be cancelled, running ones have to be ended instead
+		status := discordgo.GuildScheduledEventStatusCanceled
+		if e.Status == discordgo.GuildScheduledEventStatusActive {
+			status = discordgo.GuildScheduledEventStatusCompleted
+		}
+		err = CallAPI("GuildScheduledEventEdit", func() (err error) {
+			_, err = sb.dg.GuildScheduledEventEdit(info.ID, args[1], &discordgo.GuildScheduledEventParams{Status: status})
+			return
+		})
+		if err != nil {
+			return guildEventError(err), false, nil
+		}
+		if status == discordgo.GuildScheduledEventStatusCompleted {
+			return "```Ended " + e.Name + ".```", false, nil
+		}
+		return "```Cancelled " + e.Name + ".```", false, nil
+	case "announce":
+		if len(args) < 3 || !channelregex.MatchString(args[2]) {
+			return "```You must give the ID of the event and a #channel to announce it in, and optionally a topic to ping.```", false, nil
+		}
+		channel := args[2][2 : len(args[2])-1]
+		if !info.HasChannel(channel) {
+			return "```That channel isn't on this server.```", false, nil
+		}
+		e, err := sb.dg.GuildScheduledEvent(info.ID, args[1], false)
+		if err != nil {
+			return guildEventError(err), false, nil
+		}
+		send := &discordgo.MessageSend{AllowedMentions: &discordgo.MessageAllowedMentions{}}
+		if len(args) > 3 {
+			role, errmsg := getTopicRole(args[3], info)
+			if role == nil {
+				return errmsg, false, nil
+			}
+			send.Content = "<@&" + role.ID + "> "
+			send.AllowedMentions.Roles = []string{role.ID}
+		}
+		send.Content += "**" + e.Name + "** starts <t:" + fmt.Sprint(e.ScheduledStartTime.Unix()) + ":R>!\n" + guildEventLink(info, e.ID)
+		err = CallAPI("ChannelMessageSendComplex", func() (err error) {
+			_, err = sb.dg.ChannelMessageSendComplex(channel, send)
+			return
+		})
+		if err != nil {
+			return "```Couldn't post the announcement: " + apiErrorMessage(err) + "```", false, nil
+		}
+		return "```Announced " + e.Name + " in #" + getChannelName(channel) + ".```", false, nil
+	}
+	return "```Unknown subcommand. Use list, create, edit, cancel or announce.```", false, nil
+}
+func (c *guildEventCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Manages the server's native Discord events. Times are in your timezone, or the server's if you haven't set one.\n`list` shows upcoming events and their IDs.\n`create <time> <name> <#channel|location> [description]` creates an event in a voice or stage channel, or somewhere else if the location isn't a channel. Events somewhere else end " + TimeDiff(defaultGuildEventLength) + " after they start unless you change it.\n`edit <id> <name|time|end|location|description> <value>` changes an event.\n`cancel <id>` cancels an event, or ends it if it already started.\n`announce <id> <#channel> [topic]` posts a link to an event, pinging the role for a `!subscribe` topic if one is given.",
+		Params: []CommandUsageParam{
+			{Name: "list|create|edit|cancel|announce", Desc: "What to do. Defaults to list.", Optional: true},
+			{Name: "arguments", Desc: "Depend on what you're doing, see above.", Optional: true},
+		},
+	}
+}
+func (c *guildEventCommand) UsageShort() string {
+	return "Creates and manages Discord events."
+}
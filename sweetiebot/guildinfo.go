@@ -10,6 +10,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -21,20 +22,33 @@ type logger interface {
 
 // GuildInfo Stores state information about a guild
 type GuildInfo struct {
-	ID           string // Cache the ID because it doesn't change
-	Name         string // Cache the name to reduce locking
-	OwnerID      string
-	lastlogerr   int64
-	commandLock  sync.RWMutex
-	commandLast  map[string]map[string]int64
-	commandlimit *SaturationLimit
-	config       BotConfig
-	emotemodule  *EmoteModule
-	hooks        moduleHooks
-	modules      []Module
-	commands     map[string]Command
-	lockdown     discordgo.VerificationLevel // if -1 no lockdown was initiated, otherwise remembers the previous lockdown setting
-	lastlockdown time.Time
+	ID            string // Cache the ID because it doesn't change
+	Name          string // Cache the name to reduce locking
+	OwnerID       string
+	lastlogerr    int64
+	commandLock   sync.RWMutex
+	commandLast   map[string]map[string]int64
+	deniedLast    map[string]int64 // when each user was last told they can't run a command, guarded by commandLock
+	commandlimit  *SaturationLimit
+	commandbucket TokenBucket // per-guild share of the bot-wide command processing limit
+	chatterbucket TokenBucket // shared by everything the bot posts on her own, see chatter.messages
+	messagecache  MessageCache
+	massrole      massRoleOperation
+	voicemove     AtomicFlag // set while moveall is running
+	fixmute       AtomicFlag // set while fixmute is repairing channel overwrites
+	resync        AtomicFlag // set while the member list is being reloaded
+	lastresync    int64
+	config        BotConfig
+	emotemodule   *EmoteModule
+	hooks         moduleHooks
+	modules       []Module
+	commands      map[string]Command
+	lockdown      discordgo.VerificationLevel // if -1 no lockdown was initiated, otherwise remembers the previous lockdown setting
+	lastlockdown  time.Time
+	quietLock     sync.Mutex
+	quietqueue    []func() // messages held back until quiet hours end
+	verifiedLock  sync.RWMutex
+	verified      map[uint64]bool // members trusted because of spam.verifieddays, or nil if they haven't been loaded yet
 }
 
 // AddCommand adds a command to the guild
@@ -244,7 +258,7 @@ func (info *GuildInfo) sanitizeOutput(message string) string {
 func (info *GuildInfo) SendEmbed(channelID string, embed *discordgo.MessageEmbed) bool {
 	ch, private := channelIsPrivate(channelID)
 	if !private && ch.GuildID != info.ID {
-		if SBatoi(channelID) != info.config.Log.Channel {
+		if !info.isLogChannel(channelID) {
 			info.Log("Attempted to send message to ", channelID, ", which isn't on this server.")
 		}
 		return false
@@ -259,7 +273,10 @@ func (info *GuildInfo) SendEmbed(channelID string, embed *discordgo.MessageEmbed
 			sb.dg.ChannelMessageSendEmbed(channelID, embed)
 		}
 		embed.Fields = fields
-		sb.dg.ChannelMessageSendEmbed(channelID, embed)
+		CallAPI("ChannelMessageSendEmbed", func() error {
+			_, err := sb.dg.ChannelMessageSendEmbed(channelID, embed)
+			return err
+		})
 	}
 	return true
 }
@@ -302,6 +319,7 @@ func (b *sbRequestBuffer) Process() (*discordgo.MessageSend, int) {
 	}
 
 	b.count -= count
+	metricSendsDeferred.Add("combined", int64(i-1))
 	if i >= len(b.buffer) {
 		b.buffer = nil
 	} else {
@@ -327,6 +345,7 @@ func (info *GuildInfo) RequestPostWithBuffer(urlStr string, data *discordgo.Mess
 	softwait := sb.dg.Ratelimiter.GetWaitTime(b, minRemaining)
 
 	if remain == 0 && softwait > 0 {
+		metricSendsDeferred.Add("softwait", 1)
 		b.Release(nil)
 		time.Sleep(softwait)
 		b.Lock()
@@ -337,6 +356,7 @@ func (info *GuildInfo) RequestPostWithBuffer(urlStr string, data *discordgo.Mess
 
 		if data != nil {
 			if wait := sb.dg.Ratelimiter.GetWaitTime(b, 1); wait > 0 {
+				metricSendsDeferred.Add("hardwait", 1)
 				fmt.Printf("Hit rate limit in buffered request, sleeping for %v (%v remaining)\n", wait, remain)
 				time.Sleep(wait)
 			}
@@ -363,6 +383,7 @@ func (info *GuildInfo) RequestPostWithBuffer(urlStr string, data *discordgo.Mess
 
 		// If we ran out of breathing room on our bucket, sleep until the end of the soft limit
 		if softwait > 0 {
+			metricSendsDeferred.Add("softwait", 1)
 			time.Sleep(softwait)
 		}
 		b.Lock() // Re-lock the bucket
@@ -376,38 +397,108 @@ func (info *GuildInfo) sendContent(channelID string, message string, minRequest
 		Content: info.sanitizeOutput(message),
 	}, minRequest)
 	if err != nil {
+		metricAPIErrors.Add(ClassifyAPIError(err).String(), 1)
+		recordDropped("send", info.ID, ClassifyAPIError(err).String(), "#"+getChannelName(channelID)+": "+message)
 		fmt.Println("Failed to send message: ", err.Error())
 	}
 }
 
-// SendMessage sends a message to the given channel, splitting it into multiple messages if necessary, and combining smaller messages if a rate limit is about to be hit
+// Returns true if the text after a code block's opening fence names a language for syntax highlighting, like go or c++
+func isLanguageTag(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '+' && c != '#' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the code block that is still open at the end of s, as the fence that opened it, such as "```" or "```go".
+// Returns an empty string if every code block in s was closed.
+func openCodeBlock(s string) string {
+	open := ""
+	for {
+		i := strings.Index(s, "```")
+		if i < 0 {
+			return open
+		}
+		s = s[i+3:]
+		if len(open) > 0 {
+			open = ""
+			continue
+		}
+		open = "```"
+		if nl := strings.IndexByte(s, '\n'); nl > 0 && nl <= 20 && isLanguageTag(s[:nl]) {
+			open += s[:nl]
+		}
+	}
+}
+
+// Splits a message into parts that each fit in discord's 2000 character limit. Parts are split at the last newline
+// that fits, or the last space if there isn't a newline in the second half of the part. A code block that gets split
+// is closed at the end of one part and opened again, with the same language, at the start of the next.
+func splitMessage(message string, limit int) []string {
+	parts := []string{}
+	for len(message) > limit {
+		budget := limit - 4 // Always leave room to close a code block
+		index := strings.LastIndexByte(message[:budget], '\n')
+		if index < budget/2 {
+			index = strings.LastIndexByte(message[:budget], ' ')
+		}
+		skip := 1 // Don't start the next part with the newline or space we split at
+		if index < budget/2 {
+			index, skip = budget, 0
+			for index > 0 && !utf8.RuneStart(message[index]) { // Never split a character in half
+				index--
+			}
+		}
+		part := message[:index]
+		message = message[index+skip:]
+		if fence := openCodeBlock(part); len(fence) > 0 {
+			part += "\n```"
+			message = fence + "\n" + message
+		}
+		parts = append(parts, part)
+	}
+	return append(parts, message)
+}
+
+// Keeps the first max parts of a split message, replacing the rest with a note saying how many were cut off
+func limitMessageParts(parts []string, max int) []string {
+	if max <= 0 || len(parts) <= max {
+		return parts
+	}
+	cut := len(parts) - max
+	return append(parts[:max:max], "```The rest of this message was cut off, because it would have taken "+Pluralize(int64(cut), " more message")+".```")
+}
+
+// SendMessage sends a message to the given channel, splitting it into multiple messages if necessary, and combining smaller messages if a rate limit is about to be hit.
+// At most basic.maxmessageparts messages are sent, if it is set.
 func (info *GuildInfo) SendMessage(channelID string, message string) bool {
+	return info.sendParts(channelID, message, info.config.Basic.MaxMessageParts)
+}
+
+// SendLong sends a message to the given channel, split into as many messages as it takes, in order. Unlike SendMessage,
+// it ignores basic.maxmessageparts, so it should only be used for output someone explicitly asked for, like a private help message.
+func (info *GuildInfo) SendLong(channelID string, message string) bool {
+	return info.sendParts(channelID, message, 0)
+}
+
+// Sends a message split into parts, cutting it off after max parts if max is greater than 0
+func (info *GuildInfo) sendParts(channelID string, message string, max int) bool {
 	ch, private := channelIsPrivate(channelID)
 	if !private && ch.GuildID != info.ID {
-		if SBatoi(channelID) != info.config.Log.Channel {
+		if !info.isLogChannel(channelID) {
 			info.Log("Attempted to send message to ", channelID, ", which isn't on this server.")
 		}
 		return false
 	}
 
-	for len(message) > 1999 { // discord has a 2000 character limit
-		if message[0:3] == "```" && message[len(message)-3:] == "```" {
-			index := strings.LastIndex(message[:1995], "\n")
-			if index < 10 { // Ensure we process at least 10 characters to prevent an infinite loop
-				index = 1995
-			}
-			info.sendContent(channelID, message[:index]+"```", 1)
-			message = "```\n" + message[index:]
-		} else {
-			index := strings.LastIndex(message[:1999], "\n")
-			if index < 10 {
-				index = 1999
-			}
-			info.sendContent(channelID, message[:index], 1)
-			message = message[index:]
-		}
+	parts := limitMessageParts(splitMessage(message, 1999), max) // discord has a 2000 character limit
+	for _, part := range parts[:len(parts)-1] {
+		info.sendContent(channelID, part, 1)
 	}
-	go info.sendContent(channelID, message, 2)
+	go info.sendContent(channelID, parts[len(parts)-1], 2)
 
 	//sb.dg.ChannelMessageSend(channelID, info.sanitizeOutput(message))
 	return true
@@ -543,6 +634,15 @@ func (info *GuildInfo) FindChannelID(name string) string {
 	return ""
 }
 
+// Returns the name of a channel, or its ID if it isn't in the state cache
+func getChannelName(id string) string {
+	c, err := sb.dg.State.Channel(id)
+	if err != nil {
+		return id
+	}
+	return c.Name
+}
+
 // HasChannel returns true if this guild has a channel with the given ID
 func (info *GuildInfo) HasChannel(id string) bool {
 	c, err := sb.dg.State.Channel(id)
@@ -553,13 +653,52 @@ func (info *GuildInfo) HasChannel(id string) bool {
 }
 
 func (info *GuildInfo) Log(args ...interface{}) {
+	info.LogTo(LogGeneral, args...)
+}
+
+// Log categories that can be sent to their own channel with log.channels. Anything without a channel goes to log.channel.
+const (
+	LogGeneral    = ""
+	LogMessages   = "messages"
+	LogJoins      = "joins"
+	LogModeration = "moderation"
+	LogVoice      = "voice"
+)
+
+var logCategories = []string{LogMessages, LogJoins, LogModeration, LogVoice}
+
+// Returns the channel a log category is sent to
+func (info *GuildInfo) logChannel(category string) uint64 {
+	if ch := SBatoi(info.config.Log.Channels[category]); ch != 0 && len(category) > 0 {
+		return ch
+	}
+	return info.config.Log.Channel
+}
+
+// Returns true if the channel is the log channel, or the channel of any log category
+func (info *GuildInfo) isLogChannel(channel string) bool {
+	if SBatoi(channel) == info.config.Log.Channel {
+		return true
+	}
+	for _, v := range info.config.Log.Channels {
+		if v == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// LogTo works like Log, but sends the message to the channel set for the category in log.channels, if there is one
+func (info *GuildInfo) LogTo(category string, args ...interface{}) {
 	s := fmt.Sprint(args...)
 	fmt.Printf("[%s] %s\n", time.Now().Format(time.Stamp), s)
 	if sb.db != nil && info != nil && sb.IsMainGuild(info) && sb.db.status.get() {
 		sb.db.Audit(AUDIT_TYPE_LOG, nil, s, SBatoi(info.ID))
 	}
-	if info != nil && info.config.Log.Channel > 0 {
-		info.SendMessage(SBitoa(info.config.Log.Channel), "```\n"+s+"```")
+	if info != nil {
+		if ch := info.logChannel(category); ch > 0 {
+			info.SendMessage(SBitoa(ch), "```\n"+s+"```")
+		}
 	}
 }
 
@@ -0,0 +1,103 @@
+package sweetiebot
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestOpenCodeBlock(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"no code here", ""},
+		{"```closed```", ""},
+		{"```\nopen", "```"},
+		{"```go\nfunc main() {", "```go"},
+		{"```c++\nint x;", "```c++"},
+		{"```not a language!\nstill open", "```"},
+		{"```go\nclosed\n```\n```py\nopen", "```py"},
+		{"```go\nclosed\n```", ""},
+		{"``` unterminated on one line", "```"},
+	}
+	for _, c := range cases {
+		if got := openCodeBlock(c.in); got != c.want {
+			t.Errorf("openCodeBlock(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	const limit = 40
+	cases := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{"fits", "short message", []string{"short message"}},
+		{"exactly the limit", strings.Repeat("a", limit), []string{strings.Repeat("a", limit)}},
+		{"splits at a newline", "first line is here\nsecond line is here too", []string{"first line is here", "second line is here too"}},
+		{"splits at a space", "words words words words words words words", []string{"words words words words words words", "words"}},
+		{"no newline or space", strings.Repeat("x", 50), []string{strings.Repeat("x", limit-4), strings.Repeat("x", 50-(limit-4))}},
+		{"code block with a language", "```go\nfunc a() {}\nfunc b() {}\nfunc c() {}\n```", []string{"```go\nfunc a() {}\nfunc b() {}\n```", "```go\nfunc c() {}\n```"}},
+		{"code block without a language", "```\nline one\nline two\nline three\nline four\n```", []string{"```\nline one\nline two\nline three\n```", "```\nline four\n```"}},
+	}
+	for _, c := range cases {
+		got := splitMessage(c.message, limit)
+		if strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("%s: splitMessage(%q) = %q, want %q", c.name, c.message, got, c.want)
+		}
+	}
+}
+
+func TestSplitMessageLimits(t *testing.T) {
+	const limit = 40
+	cases := []struct {
+		name    string
+		message string
+	}{
+		{"multi-byte runes", strings.Repeat("é", 100)},
+		{"multi-byte runes one byte over", "a" + strings.Repeat("日", 13)},
+		{"emoji", strings.Repeat("😀", 30)},
+		{"long code block", "```go\n" + strings.Repeat("x := 1\n", 40) + "```"},
+		{"fence right at the split", strings.Repeat("a", 30) + "\n```\n" + strings.Repeat("b ", 20) + "```"},
+	}
+	for _, c := range cases {
+		parts := splitMessage(c.message, limit)
+		for i, p := range parts {
+			if len(p) > limit {
+				t.Errorf("%s: part %v is %v bytes, limit is %v", c.name, i, len(p), limit)
+			}
+			if !utf8.ValidString(p) {
+				t.Errorf("%s: part %v splits a character: %q", c.name, i, p)
+			}
+			if len(openCodeBlock(p)) > 0 {
+				t.Errorf("%s: part %v leaves a code block open: %q", c.name, i, p)
+			}
+		}
+	}
+	if parts := splitMessage(strings.Repeat("é", 100), limit); strings.Join(parts, "") != strings.Repeat("é", 100) {
+		t.Errorf("hard splits lost text: %q", parts)
+	}
+}
+
+func TestLimitMessageParts(t *testing.T) {
+	parts := []string{"a", "b", "c", "d"}
+	cases := []struct {
+		max  int
+		want []string
+	}{
+		{0, parts},
+		{4, parts},
+		{10, parts},
+		{3, []string{"a", "b", "c", "```The rest of this message was cut off, because it would have taken 1 more message.```"}},
+		{1, []string{"a", "```The rest of this message was cut off, because it would have taken 3 more messages.```"}},
+	}
+	for _, c := range cases {
+		got := limitMessageParts(append([]string{}, parts...), c.max)
+		if strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("limitMessageParts(%v) = %q, want %q", c.max, got, c.want)
+		}
+	}
+}
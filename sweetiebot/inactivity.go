@@ -0,0 +1,116 @@
+package sweetiebot
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const defaultInactivityMessage = "You haven't been around on {server} in a while, so your {role} role was removed. You can get it back whenever you like!"
+
+// Removes inactivity.role from everyone on every server who hasn't been seen for inactivity.days
+func removeInactiveRoles() {
+	if !sb.db.CheckStatus() {
+		return
+	}
+	sb.guildsLock.RLock()
+	guilds := make([]*GuildInfo, 0, len(sb.guilds))
+	for _, v := range sb.guilds {
+		guilds = append(guilds, v)
+	}
+	sb.guildsLock.RUnlock()
+	for _, info := range guilds {
+		if sb.quit.get() {
+			return
+		}
+		if info.config.Inactivity.Role != 0 && info.config.Inactivity.Days > 0 {
+			removeInactiveRole(info)
+		}
+	}
+}
+
+// Returns the members who have the inactivity role and should lose it. Members who joined less than inactivity.days
+// ago, moderators, and anyone with one of inactivity.exempt are never included.
+func findInactiveMembers(info *GuildInfo, now time.Time) []*discordgo.Member {
+	cutoff := now.Add(-time.Duration(info.config.Inactivity.Days) * 24 * time.Hour)
+	inactive := sb.db.GetInactiveMembers(SBatoi(info.ID), cutoff)
+	if len(inactive) == 0 {
+		return nil
+	}
+	guild, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return nil
+	}
+	role := SBitoa(info.config.Inactivity.Role)
+	exempt := make(map[string]bool, len(info.config.Inactivity.Exempt))
+	for _, v := range info.config.Inactivity.Exempt {
+		exempt[SBitoa(v)] = true
+	}
+	candidates := []*discordgo.Member{}
+	sb.dg.State.RLock()
+	for _, m := range guild.Members {
+		if m.User == nil || m.User.Bot || !inactive[SBatoi(m.User.ID)] || m.JoinedAt.After(cutoff) {
+			continue
+		}
+		has := false
+		for _, r := range m.Roles {
+			has = has || r == role
+		}
+		if has {
+			candidates = append(candidates, m)
+		}
+	}
+	sb.dg.State.RUnlock()
+	r := make([]*discordgo.Member, 0, len(candidates))
+	for _, m := range candidates {
+		if (len(exempt) > 0 && info.UserHasAnyRole(m.User.ID, exempt)) || info.HasModRole(m.User.ID) {
+			continue
+		}
+		r = append(r, m)
+	}
+	return r
+}
+
+func removeInactiveRole(info *GuildInfo) {
+	members := findInactiveMembers(info, time.Now().UTC())
+	if len(members) == 0 {
+		return
+	}
+	role := SBitoa(info.config.Inactivity.Role)
+	rolename := role
+	if r, err := sb.dg.State.Role(info.ID, role); err == nil {
+		rolename = r.Name
+	}
+	removed := []string{}
+	failed := 0
+	for _, m := range members {
+		err := CallAPI("GuildMemberRoleRemove", func() error { return sb.dg.GuildMemberRoleRemove(info.ID, m.User.ID, role) })
+		if err != nil {
+			failed++
+			if ClassifyAPIError(err) == APIErrorPermission {
+				break // We can't manage this role anymore, so every other attempt would fail too
+			}
+			continue
+		}
+		removed = append(removed, m.User.Username)
+		if info.config.Inactivity.DM {
+			msg := info.config.Inactivity.Message
+			if len(msg) == 0 {
+				msg = defaultInactivityMessage
+			}
+			msg = renderTemplate(info, msg, map[string]string{"user": "<@" + m.User.ID + ">", "username": m.User.Username, "role": rolename})
+			if ch, err := sb.dg.UserChannelCreate(m.User.ID); err == nil {
+				sb.dg.ChannelMessageSend(ch.ID, msg)
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	if len(removed) > 0 {
+		names := strings.Join(removed, ", ")
+		info.LogTo(LogModeration, "Removed ", rolename, " from ", Pluralize(int64(len(removed)), " member"), " who haven't been seen in ", Pluralize(info.config.Inactivity.Days, " day"), ": ", truncateRunes(names, 1500))
+	}
+	if failed > 0 {
+		info.LogTo(LogModeration, "Couldn't remove ", rolename, " from ", Pluralize(int64(failed), " inactive member"), ". Make sure it's below Sweetie Bot's highest role.")
+	}
+}
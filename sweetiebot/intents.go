@@ -0,0 +1,51 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// GatewayIntents selects which privileged gateway intents the bot asks for, set by the bot owner in the intents file. Privileged
+// intents must also be enabled for the bot in the discord developer portal, or discord will refuse the connection.
+type GatewayIntents struct {
+	Members        bool `json:"members"`        // member joins, leaves and updates, and the full member list
+	Presences      bool `json:"presences"`      // online status and activities
+	MessageContent bool `json:"messagecontent"` // the content of messages that don't mention the bot
+}
+
+func (i GatewayIntents) mask() discordgo.Intent {
+	intents := discordgo.IntentsAllWithoutPrivileged
+	if i.Members {
+		intents |= discordgo.IntentGuildMembers
+	}
+	if i.Presences {
+		intents |= discordgo.IntentGuildPresences
+	}
+	if i.MessageContent {
+		intents |= discordgo.IntentMessageContent
+	}
+	return intents
+}
+
+// Prints a warning for every feature that won't work with the configured intents
+func (i GatewayIntents) warn() {
+	if !i.MessageContent {
+		fmt.Println("WARNING: The message content intent is disabled, so Sweetie Bot can only see the content of messages that mention her or are sent to her privately. Commands in servers, anti-spam, the word filter, witty responses, markov and the chat log will not work.")
+	}
+	if !i.Members {
+		fmt.Println("WARNING: The server members intent is disabled, so joins, leaves and member updates won't be seen. Raid detection, auto-silence, welcome messages, profile change logging and role logging will not work, and member lists will be incomplete.")
+	}
+	if !i.Presences {
+		fmt.Println("WARNING: The presence intent is disabled, so Sweetie Bot won't know when users were last online.")
+	}
+}
+
+// Explains the error discord sends when the bot asks for a privileged intent it isn't allowed to use
+func intentError(err error) string {
+	if strings.Contains(err.Error(), "4014") {
+		return " (Discord rejected one of the privileged intents. Either enable it for the bot in the developer portal, or disable it in the intents file.)"
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package sweetiebot
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Application commands are registered globally when the bot connects, so they are available on every server. Modules
+// respond to them through the OnInteractionCreate hook, which means disabling a module also disables its commands.
+var applicationCommands = []*discordgo.ApplicationCommand{
+	{Name: reportMessageCommand, Type: discordgo.MessageApplicationCommand},
+}
+
+func registerApplicationCommands(appID string) {
+	if _, err := sb.dg.ApplicationCommandBulkOverwrite(appID, "", applicationCommands); err != nil {
+		fmt.Println("Failed to register application commands: ", err.Error())
+	}
+}
+
+// Sends a response to an interaction that only the user who triggered it can see
+func respondEphemeral(i *discordgo.Interaction, content string) {
+	err := sb.dg.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		metricAPIErrors.Add(ClassifyAPIError(err).String(), 1)
+	}
+}
+
+func sbInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand && i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	info := getGuildFromID(i.GuildID)
+	if info == nil {
+		respondEphemeral(i.Interaction, "That can only be used on a server.")
+		return
+	}
+	if boolXOR(sb.Debug, info.IsDebug(i.ChannelID)) {
+		return
+	}
+	if handleConfirmButton(i.Interaction) || handlePickInteraction(i.Interaction) {
+		return
+	}
+	for _, h := range info.hooks.OnInteractionCreate {
+		if info.ProcessModule(i.ChannelID, h) && h.OnInteractionCreate(info, i.Interaction) {
+			return
+		}
+	}
+	respondEphemeral(i.Interaction, "That has been disabled on this server.")
+}
@@ -1,6 +1,7 @@
 package sweetiebot
 
 import (
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +21,53 @@ type SaturationLimit struct {
 	lock  sync.Mutex
 }
 
+// TokenBucket refills with tokens at a constant rate up to a maximum burst size. Each event consumes one token.
+type TokenBucket struct {
+	tokens float64
+	last   int64 // unix nanoseconds of the last refill
+	lock   sync.Mutex
+}
+
+// Attempts to take a token from the bucket, given a refill rate (tokens per second) and a burst size. Returns false if the bucket is empty.
+func (b *TokenBucket) take(rate float64, burst float64) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := time.Now().UnixNano()
+	if b.last == 0 {
+		b.tokens = burst
+	} else {
+		b.tokens += rate * float64(now-b.last) / float64(time.Second)
+	}
+	b.last = now
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Returns how many tokens are currently in the bucket without consuming any
+func (b *TokenBucket) level(rate float64, burst float64) float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.last == 0 {
+		return burst
+	}
+	return math.Min(burst, b.tokens+rate*float64(time.Now().UnixNano()-b.last)/float64(time.Second))
+}
+
+// Snapshot returns how many tokens are in the bucket and how long it will take to fill back up, without consuming any
+func (b *TokenBucket) Snapshot(rate float64, burst float64) (float64, time.Duration) {
+	tokens := b.level(rate, burst)
+	if rate <= 0 || tokens >= burst {
+		return tokens, 0
+	}
+	return tokens, time.Duration((burst - tokens) / rate * float64(time.Second))
+}
+
 func realmod(x int, m int) int {
 	x %= m
 	if x < 0 {
@@ -36,6 +84,10 @@ func (f *AtomicFlag) clear() {
 	atomic.SwapUint32(&f.flag, 0)
 }
 
+func (f *AtomicFlag) get() bool {
+	return atomic.LoadUint32(&f.flag) != 0
+}
+
 func (s *SaturationLimit) append(t int64) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -59,6 +111,19 @@ func (s *SaturationLimit) checkafter(num int, period int64) bool {
 	return (s.times[s.index] - s.times[i]) <= period
 }
 
+// Snapshot returns how many of the recorded events happened after since, and how many events the limit can hold
+func (s *SaturationLimit) Snapshot(since int64) (int, int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	n := 0
+	for _, t := range s.times {
+		if t > since {
+			n++
+		}
+	}
+	return n, len(s.times)
+}
+
 func (s *SaturationLimit) resize(size int) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
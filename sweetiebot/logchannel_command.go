@@ -0,0 +1,73 @@
+package sweetiebot
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// logChannelCommand edits log.channels, which sends a category of log messages somewhere other than log.channel
+type logChannelCommand struct {
+}
+
+func (c *logChannelCommand) Name() string {
+	return "LogChannel"
+}
+func (c *logChannelCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		s := "```Default: "
+		if info.config.Log.Channel != 0 {
+			s += "#" + getChannelName(SBitoa(info.config.Log.Channel))
+		} else {
+			s += "[not set]"
+		}
+		for _, category := range logCategories {
+			s += "\n" + category + ": "
+			if ch, ok := info.config.Log.Channels[category]; ok && len(ch) > 0 {
+				s += "#" + getChannelName(ch)
+			} else {
+				s += "[default]"
+			}
+		}
+		return s + "```", false, nil
+	}
+	category := strings.ToLower(args[0])
+	valid := false
+	for _, v := range logCategories {
+		valid = valid || v == category
+	}
+	if !valid {
+		return "```" + args[0] + " isn't a log category. Try one of these: " + strings.Join(logCategories, ", ") + "```", false, nil
+	}
+	if len(args) < 2 {
+		return "```You must provide a #channel, or \"default\" to send " + category + " logs to the main log channel.```", false, nil
+	}
+	CheckMapNilString(&info.config.Log.Channels)
+	if strings.ToLower(args[1]) == "default" {
+		delete(info.config.Log.Channels, category)
+		info.SaveConfig()
+		return "```" + category + " logs will now go to the main log channel.```", false, nil
+	}
+	if !channelregex.MatchString(args[1]) {
+		return "```" + args[1] + " isn't a #channel.```", false, nil
+	}
+	ch := args[1][2 : len(args[1])-1]
+	if !info.HasChannel(ch) {
+		return "```That channel isn't on this server.```", false, nil
+	}
+	info.config.Log.Channels[category] = ch
+	info.SaveConfig()
+	return "```" + category + " logs will now go to #" + getChannelName(ch) + ".```", false, nil
+}
+func (c *logChannelCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Sends one category of log messages to its own channel instead of `log.channel`. The categories are " + strings.Join(logCategories, ", ") + ". Message edits and deletions are only logged once the messages category has a channel. With no arguments, lists where each category currently goes.",
+		Params: []CommandUsageParam{
+			{Name: "category", Desc: "The log category to change.", Optional: true},
+			{Name: "#channel|default", Desc: "The channel to send it to, or \"default\" to send it to `log.channel` again.", Optional: true},
+		},
+	}
+}
+func (c *logChannelCommand) UsageShort() string {
+	return "Sets which channel a category of logs goes to."
+}
@@ -0,0 +1,319 @@
+package sweetiebot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// massRoleOperation tracks the bulk role change running on a guild. Only one can run at a time.
+type massRoleOperation struct {
+	running AtomicFlag
+	cancel  AtomicBool
+	bucket  TokenBucket
+	desc    string
+	total   int64
+	done    int64
+	failed  int64
+	saved   *operationProgress
+}
+
+// What a massrole needs to be resumed after a restart
+type massRoleState struct {
+	Role    string   `json:"role"`
+	Add     bool     `json:"add"`
+	Targets []string `json:"targets"`
+}
+
+// Role changes are paced well below discord's limits so a massrole doesn't starve the rest of the bot
+const massRoleRate = 2
+const massRoleBurst = 5
+
+// Finds any role on the server by ping, ID or name. Names don't have to be exact, as long as only one role matches.
+func findRole(arg string, info *GuildInfo) (*discordgo.Role, string) {
+	matches, _ := ResolveCandidates(info, EntityRole, arg)
+	if len(matches) == 0 {
+		if mentionregex.MatchString(arg) {
+			return nil, "```That's not a role in this server! Are you sure you pinged a role, and not a user?```"
+		}
+		return nil, "```" + arg + " is not a role name!```"
+	}
+	if len(matches) > 1 {
+		names := make([]string, 0, len(matches))
+		for _, v := range matches {
+			names = append(names, v.Name)
+		}
+		return nil, "```" + arg + " could be any of these roles:\n" + strings.Join(names, "\n") + "```"
+	}
+	r, err := sb.dg.State.Role(info.ID, matches[0].ID)
+	if err != nil {
+		return nil, "```Error: Couldn't get roles!```"
+	}
+	return r, ""
+}
+
+// Returns the position of the highest role a member has, which determines what roles they are allowed to manage
+func highestRolePosition(info *GuildInfo, user string) int {
+	m, err := info.GetMember(user)
+	if err != nil {
+		return -1
+	}
+	pos := 0
+	for _, id := range m.Roles {
+		if r, err := sb.dg.State.Role(info.ID, id); err == nil && r.Position > pos {
+			pos = r.Position
+		}
+	}
+	return pos
+}
+
+type massRoleFilter func(m *discordgo.Member) bool
+
+func parseMassRoleFilter(arg string, info *GuildInfo, user *discordgo.User) (massRoleFilter, string) {
+	lower := strings.ToLower(arg)
+	switch {
+	case lower == "bots":
+		return func(m *discordgo.Member) bool { return m.User.Bot }, ""
+	case lower == "humans":
+		return func(m *discordgo.Member) bool { return !m.User.Bot }, ""
+	case strings.HasPrefix(lower, "has:"), strings.HasPrefix(lower, "lacks:"):
+		i := strings.Index(arg, ":")
+		r, e := findRole(arg[i+1:], info)
+		if r == nil {
+			return nil, e
+		}
+		has := lower[:i] == "has"
+		return func(m *discordgo.Member) bool {
+			for _, id := range m.Roles {
+				if id == r.ID {
+					return has
+				}
+			}
+			return !has
+		}, ""
+	case strings.HasPrefix(lower, "before:"), strings.HasPrefix(lower, "after:"):
+		i := strings.Index(arg, ":")
+		t, err := parseCommonTime(arg[i+1:], info, user)
+		if err != nil {
+			return nil, "```Could not parse " + arg[i+1:] + " as a date: " + err.Error() + "```"
+		}
+		before := lower[:i] == "before"
+		return func(m *discordgo.Member) bool { return m.JoinedAt.Before(t) == before }, ""
+	}
+	return nil, "```" + arg + " is not a valid filter. Use has:role, lacks:role, before:date, after:date, bots or humans.```"
+}
+
+type massRoleCommand struct {
+}
+
+func (c *massRoleCommand) Name() string {
+	return "MassRole"
+}
+func (c *massRoleCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	op := &info.massrole
+	if len(args) < 1 {
+		return "```You must specify add, remove, status or cancel.```", false, nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "status":
+		if !op.running.get() {
+			return "```No mass role change is running.```", false, nil
+		}
+		return fmt.Sprintf("```%s: %v/%v done, %v failed.```", op.desc, atomic.LoadInt64(&op.done), atomic.LoadInt64(&op.total), atomic.LoadInt64(&op.failed)), false, nil
+	case "cancel":
+		if !op.running.get() {
+			return "```No mass role change is running.```", false, nil
+		}
+		op.cancel.set(true)
+		return "```Cancelling the mass role change.```", false, nil
+	case "add", "remove":
+	default:
+		return "```You must specify add, remove, status or cancel.```", false, nil
+	}
+	if !sb.Intents.Members {
+		return "```This command needs the server members intent, which the bot owner has disabled, so I don't know who is on the server.```", false, nil
+	}
+	add := strings.ToLower(args[0]) == "add"
+	if len(args) < 3 {
+		return "```You must specify a role and at least one filter. Use `all` to change everyone.```", false, nil
+	}
+	role, e := findRole(args[1], info)
+	if role == nil {
+		return e, false, nil
+	}
+	if e := checkMassRole(info, role, msg.Author); len(e) > 0 {
+		return e, false, nil
+	}
+
+	filters := []massRoleFilter{}
+	for _, arg := range args[2:] {
+		if strings.ToLower(arg) == "all" {
+			continue
+		}
+		f, e := parseMassRoleFilter(arg, info, msg.Author)
+		if f == nil {
+			return e, false, nil
+		}
+		filters = append(filters, f)
+	}
+
+	guild, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return "```Guild not in state?!```", false, nil
+	}
+	targets := []string{}
+	sb.dg.State.RLock()
+	for _, m := range guild.Members {
+		hasrole := false
+		for _, id := range m.Roles {
+			hasrole = hasrole || id == role.ID
+		}
+		if hasrole == add {
+			continue
+		}
+		match := true
+		for _, f := range filters {
+			match = match && f(m)
+		}
+		if match {
+			targets = append(targets, m.User.ID)
+		}
+	}
+	sb.dg.State.RUnlock()
+	if len(targets) == 0 {
+		return "```No members match those filters.```", false, nil
+	}
+	action := "Removing " + role.Name + " from"
+	if add {
+		action = "Adding " + role.Name + " to"
+	}
+	if !Confirm(info, msg.ChannelID, msg.Author.ID, "**"+SanitizeMentions(action)+" "+Pluralize(int64(len(targets)), " member")+".** Are you sure?") {
+		return "```Cancelled the mass role change.```", false, nil
+	}
+	if op.running.test_and_set() {
+		return "```A mass role change is already running. Use `" + info.config.Basic.CommandPrefix + "massrole cancel` to stop it.```", false, nil
+	}
+
+	op.desc = action + " " + Pluralize(int64(len(targets)), " member")
+	op.cancel.set(false)
+	atomic.StoreInt64(&op.total, int64(len(targets)))
+	atomic.StoreInt64(&op.done, 0)
+	atomic.StoreInt64(&op.failed, 0)
+	op.saved = startOperation(info, "massrole", msg.Author.ID, msg.ChannelID, op.desc, massRoleState{role.ID, add, targets}, len(targets))
+	info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " started a mass role change: ", op.desc)
+	go runMassRole(info, op, role, add, targets, 0, msg.ChannelID, msg.Author)
+
+	estimate := time.Duration(len(targets)/massRoleRate) * time.Second
+	return "```" + op.desc + ". This will take about " + TimeDiff(estimate) + ". Use `" + info.config.Basic.CommandPrefix + "massrole cancel` to stop it.```", false, nil
+}
+func (c *massRoleCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Adds a role to, or removes a role from, every member matching all of the given filters. Changes are made slowly to avoid hitting discord's rate limits, with progress reported every minute. Only one mass role change can run at a time. Both you and Sweetie Bot must have a higher role than the one being changed. Example: `" + info.config.Basic.CommandPrefix + "massrole add Members humans \"before:1 Jan 2020\" lacks:Muted`",
+		Params: []CommandUsageParam{
+			{Name: "add/remove/status/cancel", Desc: "Whether to add or remove the role, or check on or cancel the change that is currently running.", Optional: false},
+			{Name: "role", Desc: "The name of the role, or a ping of it.", Optional: false},
+			{Name: "filters", Desc: "`has:role` or `lacks:role` to check for another role, `before:date` or `after:date` to check when the member joined, `bots` or `humans`, or `all` to match everyone. Put filters with spaces in quotes.", Optional: false, Variadic: true},
+		},
+	}
+}
+func (c *massRoleCommand) UsageShort() string { return "Adds or removes a role from many members." }
+
+// Makes sure a role can be changed in bulk, both by us and by whoever asked for it
+func checkMassRole(info *GuildInfo, role *discordgo.Role, author *discordgo.User) string {
+	if SBatoi(role.ID) == info.config.Spam.SilentRole {
+		return "```Use the silence command to silence people, not this.```"
+	}
+	if role.Managed || role.ID == info.ID {
+		return "```That role is managed by discord and can't be assigned.```"
+	}
+	if highestRolePosition(info, sb.SelfID) <= role.Position {
+		return "```I can't change " + role.Name + " because it is above my highest role.```"
+	}
+	if author.ID != info.OwnerID && highestRolePosition(info, author.ID) <= role.Position {
+		return "```You can't change " + role.Name + " because it is above your highest role.```"
+	}
+	return ""
+}
+
+// Continues a massrole that was interrupted by a restart, starting from the first member it hadn't gotten to
+func resumeMassRole(info *GuildInfo, saved *operationProgress, msg *discordgo.Message) string {
+	state := massRoleState{}
+	if err := json.Unmarshal([]byte(saved.State), &state); err != nil {
+		return "```That massrole's saved progress is corrupt, so it can't be resumed: " + err.Error() + "```"
+	}
+	role, err := sb.dg.State.Role(info.ID, state.Role)
+	if err != nil {
+		return "```The role that massrole was changing doesn't exist anymore.```"
+	}
+	if e := checkMassRole(info, role, msg.Author); len(e) > 0 {
+		return e
+	}
+	start := int(saved.Done)
+	if start >= len(state.Targets) {
+		saved.finish()
+		return "```That massrole had already finished.```"
+	}
+	op := &info.massrole
+	if op.running.test_and_set() {
+		return "```A mass role change is already running. Wait for it to finish, or use `" + info.config.Basic.CommandPrefix + "massrole cancel` to stop it.```"
+	}
+	op.desc = saved.Desc
+	op.cancel.set(false)
+	op.saved = saved
+	atomic.StoreInt64(&op.total, int64(len(state.Targets)))
+	atomic.StoreInt64(&op.done, saved.Done)
+	atomic.StoreInt64(&op.failed, saved.Failed)
+	saved.resumed()
+	info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " resumed a mass role change at ", start, "/", len(state.Targets), ": ", op.desc)
+	go runMassRole(info, op, role, state.Add, state.Targets, start, msg.ChannelID, msg.Author)
+
+	estimate := time.Duration((len(state.Targets)-start)/massRoleRate) * time.Second
+	return "```Resumed: " + op.desc + ", starting from " + strconv.Itoa(start) + "/" + strconv.Itoa(len(state.Targets)) + ". This will take about " + TimeDiff(estimate) + ".```"
+}
+
+func runMassRole(info *GuildInfo, op *massRoleOperation, role *discordgo.Role, add bool, targets []string, start int, channel string, author *discordgo.User) {
+	defer op.running.clear()
+	defer op.saved.finish()
+	lastreport := time.Now().UTC()
+	for i := start; i < len(targets); i++ {
+		user := targets[i]
+		if op.cancel.get() || sb.quit.get() {
+			break
+		}
+		for !op.bucket.take(massRoleRate, massRoleBurst) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		err := CallAPI("massrole", func() error {
+			if add {
+				return sb.dg.GuildMemberRoleAdd(info.ID, user, role.ID)
+			}
+			return sb.dg.GuildMemberRoleRemove(info.ID, user, role.ID)
+		})
+		if err != nil {
+			atomic.AddInt64(&op.failed, 1)
+			if ClassifyAPIError(err) == APIErrorPermission {
+				op.cancel.set(true) // If we lost permissions, every other change will fail too
+				info.SendMessage(channel, "Stopping mass role change: "+apiErrorMessage(err))
+			}
+		}
+		atomic.StoreInt64(&op.done, int64(i+1))
+		op.saved.progress(int64(i+1), atomic.LoadInt64(&op.failed))
+		if time.Now().UTC().Sub(lastreport) >= time.Minute {
+			lastreport = time.Now().UTC()
+			info.SendMessage(channel, fmt.Sprintf("%s: %v/%v done.", op.desc, i+1, len(targets)))
+		}
+	}
+
+	result := "Finished"
+	if op.cancel.get() || sb.quit.get() {
+		result = "Stopped"
+	}
+	tally := fmt.Sprintf("%s mass role change (%s): %v/%v processed, %v failed.", result, op.desc, atomic.LoadInt64(&op.done), len(targets), atomic.LoadInt64(&op.failed))
+	info.LogTo(LogModeration, getUserName(SBatoi(author.ID), info), ": ", tally)
+	info.SendMessage(channel, tally)
+}
@@ -0,0 +1,155 @@
+package sweetiebot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// memberResync tracks a request for a guild's full member list. Discord answers over the gateway in chunks of up to 1000
+// members, which discordgo adds to the state as they arrive, so all we have to do is keep track of who was in them.
+type memberResync struct {
+	seen   map[string]bool
+	chunks int
+	done   chan struct{}
+}
+
+var memberResyncs = struct {
+	sync.Mutex
+	pending map[string]*memberResync // by nonce
+}{pending: make(map[string]*memberResync)}
+
+// How long to wait for discord to send every chunk before giving up
+const memberResyncTimeout = 5 * time.Minute
+
+func sbGuildMembersChunk(s *discordgo.Session, m *discordgo.GuildMembersChunk) {
+	memberResyncs.Lock()
+	defer memberResyncs.Unlock()
+	r, ok := memberResyncs.pending[m.Nonce]
+	if !ok {
+		return
+	}
+	for _, v := range m.Members {
+		r.seen[v.User.ID] = true
+	}
+	r.chunks++
+	if r.chunks >= m.ChunkCount {
+		delete(memberResyncs.pending, m.Nonce)
+		close(r.done)
+	}
+}
+
+// Requests the full member list of a guild from discord and brings the member cache in line with it. Returns the number of
+// members discord sent, how many were cached beforehand, and how many cached members had actually left.
+func resyncMembers(info *GuildInfo) (int, int, int, error) {
+	if !sb.Intents.Members {
+		return 0, 0, 0, errors.New("listing members requires the server members intent, which the bot owner has disabled")
+	}
+	if info.resync.test_and_set() {
+		return 0, 0, 0, errors.New("the member list is already being reloaded")
+	}
+	defer info.resync.clear()
+	guild, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	sb.dg.State.RLock()
+	before := len(guild.Members)
+	sb.dg.State.RUnlock()
+
+	nonce := "resync" + strconv.FormatInt(time.Now().UTC().UnixNano(), 36)
+	r := &memberResync{seen: make(map[string]bool), done: make(chan struct{})}
+	memberResyncs.Lock()
+	memberResyncs.pending[nonce] = r
+	memberResyncs.Unlock()
+	requested := time.Now().UTC()
+	err = sb.dg.RequestGuildMembers(info.ID, "", 0, nonce, false)
+	if err == nil {
+		select {
+		case <-r.done:
+		case <-time.After(memberResyncTimeout):
+			err = errors.New("discord didn't send the whole member list in time")
+		}
+	}
+	if err != nil {
+		memberResyncs.Lock()
+		delete(memberResyncs.pending, nonce)
+		memberResyncs.Unlock()
+		return 0, before, 0, err
+	}
+
+	// Anyone still cached that discord didn't send us left while we weren't looking, unless they joined after we asked,
+	// since the chunks discord already sent wouldn't have them
+	stale := []*discordgo.Member{}
+	sb.dg.State.RLock()
+	for _, m := range guild.Members {
+		if !r.seen[m.User.ID] && !m.JoinedAt.After(requested) {
+			stale = append(stale, m)
+		}
+	}
+	sb.dg.State.RUnlock()
+	for _, m := range stale {
+		sb.dg.State.MemberRemove(m)
+	}
+	return len(r.seen), before, len(stale), nil
+}
+
+// Resyncs every guild whose member cache doesn't match the member count discord gave us, one at a time so we don't
+// flood the gateway with member requests.
+func resyncAllMembers() {
+	sb.guildsLock.RLock()
+	guilds := make([]*GuildInfo, 0, len(sb.guilds))
+	for _, v := range sb.guilds {
+		guilds = append(guilds, v)
+	}
+	sb.guildsLock.RUnlock()
+	for _, info := range guilds {
+		if sb.quit.get() {
+			return
+		}
+		guild, err := sb.dg.State.Guild(info.ID)
+		if err != nil {
+			continue
+		}
+		sb.dg.State.RLock()
+		drifted := guild.MemberCount != len(guild.Members)
+		sb.dg.State.RUnlock()
+		if drifted {
+			if _, _, _, err := resyncMembers(info); err != nil {
+				fmt.Println("Failed to resync members of ", info.Name, ": ", err.Error())
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}
+}
+
+type resyncMembersCommand struct {
+}
+
+func (c *resyncMembersCommand) Name() string {
+	return "ResyncMembers"
+}
+func (c *resyncMembersCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !RateLimit(&info.lastresync, 600) {
+		return "```The member list can only be reloaded once every 10 minutes.```", false, nil
+	}
+	go func() {
+		loaded, before, removed, err := resyncMembers(info)
+		if err != nil {
+			info.SendMessage(msg.ChannelID, "```Couldn't reload the member list: "+err.Error()+".```")
+			return
+		}
+		info.SendMessage(msg.ChannelID, fmt.Sprintf("```Loaded %s from discord. %v were cached before, and %s who had left the server were removed.```", Pluralize(int64(loaded), " member"), before, Pluralize(int64(removed), " member")))
+	}()
+	return "```Requesting the member list from discord. On large servers this can take a minute.```", false, nil
+}
+func (c *resyncMembersCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Reloads the entire member list from discord, which fixes member counts, mass role changes and anything else that relies on knowing who is on the server when the bot's cache has drifted. This also happens automatically once a day for servers where the cache doesn't match discord's member count. Can only be run once every 10 minutes.",
+	}
+}
+func (c *resyncMembersCommand) UsageShort() string { return "Reloads the member list from discord." }
@@ -0,0 +1,152 @@
+package sweetiebot
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// mentionRule maps the first words of a phrase like "@Sweetie mute @user for 10 minutes" to the command it means
+type mentionRule struct {
+	words     []string // the phrase has to start with one of these
+	command   string
+	durations bool // if true, "for 10 minutes" becomes the "for: 10 minutes" the command expects
+}
+
+var mentionRules = []mentionRule{
+	{[]string{"mute", "silence", "shush", "timeout"}, "silence", true},
+	{[]string{"unmute", "unsilence"}, "unsilence", false},
+	{[]string{"ban"}, "ban", true},
+	{[]string{"warn"}, "warn", false},
+	{[]string{"remind me", "remindme"}, "remindme", false},
+	{[]string{"help me with", "help with", "help me", "help"}, "help", false},
+	{[]string{"roll"}, "roll", false},
+}
+
+// Whole phrases that mean a command with no arguments
+var mentionPhrases = map[string]string{
+	"what can you do":        "help",
+	"what are your commands": "help",
+	"commands":               "help",
+	"what time is it":        "time",
+}
+
+// Polite filler that can come before or after the actual request
+var mentionFillerStart = []string{"please", "can you", "could you", "would you", "hey"}
+var mentionFillerEnd = []string{"please", "thanks", "thank you"}
+
+const defaultMentionResponse = "Hi {user}! My command prefix here is `{prefix}`. Use `{prefix}help` to see everything I can do."
+
+// What Sweetie Bot says when she's pinged with a request she can't make sense of
+const mentionConfusedResponse = "```I didn't understand that. Try something like \"@{botname} mute @user for 10 minutes\", or use {prefix}help to see every command.```"
+
+// Sweetie Bot only answers a ping that doesn't run a command once every this many seconds in each channel
+const mentionResponseCooldown = 60
+
+// Answers someone who pinged the bot without running a command, unless she already answered a ping in this channel
+// recently. msg is a template, so {user}, {username}, {channel}, {botname} and {prefix} are filled in.
+func respondToMention(info *GuildInfo, m *discordgo.Message, msg string) {
+	if info.config.Basic.IgnoreInvalidCommands {
+		return
+	}
+	if !mentionCooldown(info, m.ChannelID) || !info.takeChatter("mention") {
+		return
+	}
+	info.SendMessage(m.ChannelID, renderTemplate(info, msg, map[string]string{"user": "<@" + m.Author.ID + ">", "username": m.Author.Username, "channel": "<#" + m.ChannelID + ">", "botname": getUserName(SBatoi(sb.SelfID), info), "prefix": info.config.Basic.CommandPrefix}))
+}
+
+// Returns true if the bot hasn't answered a ping in this channel for mentionResponseCooldown seconds, and starts the
+// cooldown again if so. Every kind of answer to a ping shares this, so someone can't get around it by alternating them.
+func mentionCooldown(info *GuildInfo, channel string) bool {
+	info.commandLock.Lock()
+	defer info.commandLock.Unlock()
+	if len(info.commandLast[channel]) == 0 {
+		info.commandLast[channel] = make(map[string]int64)
+	}
+	last := info.commandLast[channel]["@mention"] // Can't collide with a command, because command names can't start with @
+	ok := RateLimit(&last, mentionResponseCooldown)
+	info.commandLast[channel]["@mention"] = last
+	return ok
+}
+
+// Strips a leading ping of the bot from a message. Returns false if the message doesn't start with one.
+func stripSelfMention(content string) (string, bool) {
+	for _, m := range []string{"<@" + sb.SelfID + ">", "<@!" + sb.SelfID + ">"} {
+		if strings.HasPrefix(content, m) {
+			return strings.TrimSpace(content[len(m):]), true
+		}
+	}
+	return "", false
+}
+
+// Removes any of the given lowercase phrases from the start or end of the words, as long as they're whole words
+func trimMentionFiller(words []string, filler []string, fromEnd bool) []string {
+	for changed := true; changed; {
+		changed = false
+		for _, f := range filler {
+			fw := strings.Fields(f)
+			if len(fw) >= len(words) {
+				continue
+			}
+			part := words[:len(fw)]
+			if fromEnd {
+				part = words[len(words)-len(fw):]
+			}
+			if strings.ToLower(strings.Join(part, " ")) == f {
+				if fromEnd {
+					words = words[:len(words)-len(fw)]
+				} else {
+					words = words[len(fw):]
+				}
+				changed = true
+			}
+		}
+	}
+	return words
+}
+
+// Turns "for 10 minutes" into "for: 10 minutes", as long as it really is a number followed by a unit
+func rewriteMentionDuration(words []string) []string {
+	for i := 0; i+2 < len(words); i++ {
+		if strings.ToLower(words[i]) != "for" {
+			continue
+		}
+		if _, err := strconv.Atoi(words[i+1]); err == nil && parseRepeatInterval(words[i+2]) != 255 {
+			words[i] = "for:"
+			break
+		}
+	}
+	return words
+}
+
+// Parses a phrase someone said to the bot into a command, without the prefix. known reports whether a word is already a
+// command or alias, so "@Sweetie roll 1d6" works the same as "!roll 1d6". Returns false if the phrase means nothing.
+func parseMentionCommand(phrase string, known func(string) bool) (string, bool) {
+	words := strings.Fields(strings.TrimRight(phrase, "?!. "))
+	words = trimMentionFiller(words, mentionFillerStart, false)
+	words = trimMentionFiller(words, mentionFillerEnd, true)
+	if len(words) == 0 {
+		return "", false
+	}
+	if cmd, ok := mentionPhrases[strings.ToLower(strings.Join(words, " "))]; ok {
+		return cmd, true
+	}
+	for _, rule := range mentionRules {
+		for _, w := range rule.words {
+			n := len(strings.Fields(w))
+			if n > len(words) || strings.ToLower(strings.Join(words[:n], " ")) != w {
+				continue
+			}
+			rest := words[n:]
+			if rule.durations {
+				rest = rewriteMentionDuration(rest)
+			}
+			return strings.Join(append([]string{rule.command}, rest...), " "), true
+		}
+	}
+	if known(strings.ToLower(words[0])) {
+		return strings.Join(words, " "), true
+	}
+	return "", false
+}
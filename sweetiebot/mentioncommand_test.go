@@ -0,0 +1,158 @@
+package sweetiebot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestMentionCooldown(t *testing.T) {
+	info := &GuildInfo{commandLast: make(map[string]map[string]int64)}
+	cases := []struct {
+		name    string
+		channel string
+		want    bool
+	}{
+		{"first ping", "1", true},
+		{"second ping in the same channel", "1", false},
+		{"another channel", "2", true},
+		{"back in the first channel", "1", false},
+	}
+	for _, c := range cases {
+		if got := mentionCooldown(info, c.channel); got != c.want {
+			t.Errorf("%s: mentionCooldown(%q) = %v, want %v", c.name, c.channel, got, c.want)
+		}
+	}
+	info.commandLast["1"]["@mention"] = time.Now().UTC().Unix() - mentionResponseCooldown - 1
+	if !mentionCooldown(info, "1") {
+		t.Error("the cooldown should be over once mentionResponseCooldown has passed")
+	}
+	if _, ok := info.commandLast["1"]["mention"]; ok {
+		t.Error("the cooldown shouldn't use a key a command could have")
+	}
+}
+
+func TestRespondToMentionIgnoresInvalidCommands(t *testing.T) {
+	info := &GuildInfo{commandLast: make(map[string]map[string]int64)}
+	info.config.Basic.IgnoreInvalidCommands = true
+	m := &discordgo.Message{ChannelID: "1", Author: &discordgo.User{ID: "2"}}
+	for _, msg := range []string{defaultMentionResponse, mentionConfusedResponse} {
+		respondToMention(info, m, msg) // Returns before sending anything, so this doesn't need a connection
+	}
+	if !mentionCooldown(info, "1") {
+		t.Error("ignored pings shouldn't start the cooldown")
+	}
+}
+
+func TestParseMentionCommand(t *testing.T) {
+	known := func(s string) bool { return s == "roll" || s == "time" || s == "8ball" }
+	cases := []struct {
+		phrase string
+		want   string
+		ok     bool
+	}{
+		{"mute @user for 10 minutes", "silence @user for: 10 minutes", true},
+		{"please mute @user for 10 minutes thanks", "silence @user for: 10 minutes", true},
+		{"can you shush @user?", "silence @user", true},
+		{"Timeout @user for 1 hour!", "silence @user for: 1 hour", true},
+		{"unmute @user", "unsilence @user", true},
+		{"ban @user for 3 days for spamming", "ban @user for: 3 days for spamming", true},
+		{"ban @user for spamming", "ban @user for spamming", true},
+		{"warn @user for 5 minutes of spam", "warn @user for 5 minutes of spam", true}, // warn doesn't take a duration
+		{"remind me in 5 minutes to stretch", "remindme in 5 minutes to stretch", true},
+		{"help me with ban", "help ban", true},
+		{"help", "help", true},
+		{"What can you do?", "help", true},
+		{"hey what time is it?", "time", true},
+		{"commands please", "help", true},
+		{"ROLL 1d6", "roll 1d6", true},
+		{"8ball will it rain", "8ball will it rain", true},
+		{"could you roll 2d20 thank you", "roll 2d20", true},
+		{"", "", false},
+		{"???", "", false},
+		{"please", "", false},
+		{"thank you", "", false},
+		{"how are you", "", false},
+		{"banana bread", "", false}, // starts with "ban", but not as a whole word
+		{"mutely watching", "", false},
+	}
+	for _, c := range cases {
+		got, ok := parseMentionCommand(c.phrase, known)
+		if got != c.want || ok != c.ok {
+			t.Errorf("parseMentionCommand(%q) = %q, %v, want %q, %v", c.phrase, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestMentionGrammarTables(t *testing.T) {
+	seen := []string{}
+	for _, rule := range mentionRules {
+		for _, w := range rule.words {
+			if w != strings.ToLower(w) || w != strings.Join(strings.Fields(w), " ") {
+				t.Errorf("%q has to be lowercase with single spaces, or it can never match", w)
+			}
+			for _, prev := range seen {
+				if w == prev || strings.HasPrefix(w, prev+" ") {
+					t.Errorf("%q can never match, because %q comes first", w, prev)
+				}
+			}
+			seen = append(seen, w)
+		}
+	}
+	for k := range mentionPhrases {
+		if k != strings.ToLower(strings.TrimRight(k, "?!. ")) {
+			t.Errorf("phrase %q has to be lowercase without trailing punctuation, or it can never match", k)
+		}
+	}
+}
+
+func TestTrimMentionFiller(t *testing.T) {
+	cases := []struct {
+		words   string
+		fromEnd bool
+		want    string
+	}{
+		{"please mute @user", false, "mute @user"},
+		{"Can you please mute @user", false, "mute @user"},
+		{"hey could you roll", false, "roll"},
+		{"mute @user thank you", true, "mute @user"},
+		{"mute @user please thanks", true, "mute @user"},
+		{"please", false, "please"}, // Never trims away everything
+		{"pleased to meet you", false, "pleased to meet you"},
+		{"mute @user please", false, "mute @user please"},
+		{"please mute @user", true, "please mute @user"},
+	}
+	for _, c := range cases {
+		filler := mentionFillerStart
+		if c.fromEnd {
+			filler = mentionFillerEnd
+		}
+		if got := strings.Join(trimMentionFiller(strings.Fields(c.words), filler, c.fromEnd), " "); got != c.want {
+			t.Errorf("trimMentionFiller(%q, %v) = %q, want %q", c.words, c.fromEnd, got, c.want)
+		}
+	}
+}
+
+func TestRewriteMentionDuration(t *testing.T) {
+	cases := []struct {
+		words string
+		want  string
+	}{
+		{"@user for 10 minutes", "@user for: 10 minutes"},
+		{"@user For 1 HOUR", "@user for: 1 HOUR"},
+		{"@user for 5 years for 2 days", "@user for: 5 years for 2 days"},
+		{"@user being rude for 2 days", "@user being rude for: 2 days"},
+		{"@user for spam", "@user for spam"},
+		{"@user for ten minutes", "@user for ten minutes"},
+		{"@user for 10 lightyears", "@user for 10 lightyears"},
+		{"@user for 10", "@user for 10"},
+		{"@user", "@user"},
+	}
+	for _, c := range cases {
+		if got := strings.Join(rewriteMentionDuration(strings.Fields(c.words)), " "); got != c.want {
+			t.Errorf("rewriteMentionDuration(%q) = %q, want %q", c.words, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package sweetiebot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CachedMessage is a recently posted message whose content is kept in memory
+type CachedMessage struct {
+	ID          string
+	ChannelID   string
+	AuthorID    string
+	Content     string
+	Previous    string // what the message said before it was last edited, if it was
+	Changed     bool   // true if the most recent update changed the content, rather than just adding an embed
+	Attachments []string
+	Timestamp   int64
+}
+
+// MessageCache keeps the content of recent messages in memory, so features like edit and delete logging can see what a message
+// used to say. It is shared by every module in a guild, is only filled if the guild has opted in with privacy.storecontent,
+// and is bounded by both privacy.cachesize and privacy.cachettl.
+type MessageCache struct {
+	messages map[string]*CachedMessage
+	order    []string // message IDs from oldest to newest. May contain IDs that were already removed.
+	lock     sync.Mutex
+}
+
+// StoresContent returns true if message content from this channel is allowed to be cached or logged
+func (info *GuildInfo) StoresContent(channelID string) bool {
+	if !info.config.Privacy.StoreContent || info.isLogChannel(channelID) {
+		return false
+	}
+	_, excluded := info.config.Privacy.ExcludeChannels[channelID]
+	return !excluded
+}
+
+// Add caches a message, or updates the content of a message that is already cached
+func (c *MessageCache) Add(info *GuildInfo, m *discordgo.Message) {
+	if m.Author == nil || !info.StoresContent(m.ChannelID) || info.config.Privacy.CacheSize <= 0 {
+		return
+	}
+	attachments := make([]string, 0, len(m.Attachments))
+	for _, a := range m.Attachments {
+		attachments = append(attachments, a.URL)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.messages == nil {
+		c.messages = make(map[string]*CachedMessage)
+	}
+	if old, ok := c.messages[m.ID]; ok {
+		old.Changed = old.Content != m.Content
+		if old.Changed {
+			old.Previous = old.Content
+		}
+		old.Content = m.Content
+		if len(attachments) > 0 {
+			old.Attachments = attachments
+		}
+		return
+	}
+	c.messages[m.ID] = &CachedMessage{ID: m.ID, ChannelID: m.ChannelID, AuthorID: m.Author.ID, Content: m.Content, Attachments: attachments, Timestamp: snowflakeTime(SBatoi(m.ID)).Unix()}
+	c.order = append(c.order, m.ID)
+	c.evict(info.config.Privacy.CacheSize, info.config.Privacy.CacheTTL)
+}
+
+// Get returns a cached message, or nil if it isn't in the cache
+func (c *MessageCache) Get(id string) *CachedMessage {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	m, ok := c.messages[id]
+	if !ok {
+		return nil
+	}
+	cp := *m
+	return &cp
+}
+
+// Remove deletes a message from the cache
+func (c *MessageCache) Remove(id string) {
+	c.lock.Lock()
+	delete(c.messages, id)
+	c.lock.Unlock()
+}
+
+// Clear empties the cache, which happens whenever content storage is turned off
+func (c *MessageCache) Clear() {
+	c.lock.Lock()
+	c.messages = nil
+	c.order = nil
+	c.lock.Unlock()
+}
+
+// Drops messages until we're under the size limit and nothing is older than the TTL. Must be called with the lock held.
+func (c *MessageCache) evict(size int, ttl int64) {
+	cutoff := time.Now().UTC().Unix() - ttl
+	for len(c.order) > 0 {
+		id := c.order[0]
+		m, ok := c.messages[id]
+		if ok && len(c.messages) <= size && (ttl <= 0 || m.Timestamp >= cutoff) {
+			break
+		}
+		delete(c.messages, id)
+		c.order = c.order[1:]
+	}
+	if len(c.order) > 2*size { // Only happens if lots of messages were removed, so reclaim the stale IDs
+		order := make([]string, 0, len(c.messages))
+		for _, id := range c.order {
+			if _, ok := c.messages[id]; ok {
+				order = append(order, id)
+			}
+		}
+		c.order = order
+	}
+}
+
+// Expires old messages even if nobody is talking, and empties the cache if content storage was turned off
+func (c *MessageCache) expire(info *GuildInfo) {
+	if !info.config.Privacy.StoreContent {
+		c.Clear()
+		return
+	}
+	c.lock.Lock()
+	c.evict(info.config.Privacy.CacheSize, info.config.Privacy.CacheTTL)
+	c.lock.Unlock()
+}
@@ -0,0 +1,17 @@
+package sweetiebot
+
+import "expvar"
+
+// Metrics are published through expvar, which serves them as JSON on /debug/vars alongside the pprof endpoints
+var (
+	metricCommandsDropped        = expvar.NewMap("commands_dropped")
+	metricCommandsDroppedByGuild = expvar.NewMap("commands_dropped_by_guild")
+	metricAPIErrors              = expvar.NewMap("api_errors")
+	metricRateLimited            = expvar.NewMap("rate_limited")    // 429 responses from discord, by route
+	metricSendsDeferred          = expvar.NewMap("sends_deferred")  // messages held back or combined by the outbound message buffer
+	metricChatterSkipped         = expvar.NewMap("chatter_skipped") // messages skipped because a server's chatter budget ran out, by feature
+)
+
+func init() {
+	expvar.Publish("token_buckets", expvar.Func(tokenBucketLevels))
+}
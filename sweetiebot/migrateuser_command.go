@@ -0,0 +1,105 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Returns users.migratestrategy, or merge if it isn't one of the strategies MigrateUserData knows
+func migrateStrategy(info *GuildInfo) string {
+	switch s := strings.ToLower(info.config.Users.MigrateStrategy); s {
+	case "merge", "replace", "keep":
+		return s
+	}
+	return "merge"
+}
+
+// Describes what a server has stored about a user, one line per kind of data
+func describeUserData(info *GuildInfo, d UserData) []string {
+	s := []string{
+		"Offenses: " + Pluralize(int64(d.Offenses), " offense"),
+		"Moderation log: " + Pluralize(int64(d.Modlog), " action") + ", along with the notes moderators left",
+		fmt.Sprintf("Activity: %s over %s", Pluralize(int64(d.Messages), " message"), Pluralize(int64(d.ActivityDays), " day")),
+	}
+	if d.Birthday {
+		s = append(s, "Birthday: yes")
+	} else {
+		s = append(s, "Birthday: none")
+	}
+	if d.ColorRole != 0 {
+		s = append(s, "Color role: "+SBitoa(d.ColorRole))
+	} else {
+		s = append(s, "Color role: none")
+	}
+	if d.FirstSeen != nil {
+		s = append(s, "First seen: "+ApplyTimezone(*d.FirstSeen, info, nil).Format("Jan 2, 2006"))
+	}
+	return s
+}
+
+type migrateUserCommand struct {
+}
+
+func (c *migrateUserCommand) Name() string {
+	return "MigrateUser"
+}
+func (c *migrateUserCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !info.HasAdminRole(msg.Author.ID) {
+		if _, isOwner := sb.Owners[SBatoi(msg.Author.ID)]; !isOwner {
+			return "```Only admins can migrate someone's data.```", false, nil
+		}
+	}
+	if len(args) < 2 {
+		return "```You must give the old account and the new account, as pings or user IDs.```", false, nil
+	}
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	from := PingAtoi(args[0])
+	to := PingAtoi(args[1])
+	if from == 0 || to == 0 {
+		return "```Both accounts have to be pings or user IDs, since the old account probably isn't on the server anymore.```", false, nil
+	}
+	if from == to {
+		return "```Those are the same account.```", false, nil
+	}
+	guild := SBatoi(info.ID)
+	old := sb.db.GetUserData(from, guild)
+	cur := sb.db.GetUserData(to, guild)
+	strategy := migrateStrategy(info)
+	oldname := getUserName(from, info)
+	newname := getUserName(to, info)
+	preview := "Moving data from " + oldname + " (" + SBitoa(from) + ") to " + newname + " (" + SBitoa(to) + ") using the " + strategy + " strategy.\n\nOld account:\n  " + strings.Join(describeUserData(info, old), "\n  ") + "\n\nNew account:\n  " + strings.Join(describeUserData(info, cur), "\n  ")
+	if old.Offenses == 0 && old.Modlog == 0 && old.ActivityDays == 0 && !old.Birthday && old.ColorRole == 0 {
+		return "```" + SanitizeMentions(oldname) + " has no data on this server to move.```", false, nil
+	}
+	if len(args) > 2 && strings.ToLower(args[2]) == "preview" {
+		return "```\n" + SanitizeMentions(preview) + "```", false, nil
+	}
+	if !Confirm(info, msg.ChannelID, msg.Author.ID, "```\n"+SanitizeMentions(preview)+"```**This can't be undone.** Are you sure?") {
+		return "```Cancelled the migration.```", false, nil
+	}
+	if err := sb.db.MigrateUserData(from, to, guild, strategy); err != nil {
+		return "```Couldn't migrate the data, so nothing was moved: " + err.Error() + "```", false, nil
+	}
+	if old.ColorRole != 0 && (cur.ColorRole == 0 || strategy == "replace") {
+		if _, err := sb.dg.State.Member(info.ID, SBitoa(to)); err == nil {
+			CallAPI("GuildMemberRoleAdd", func() error { return sb.dg.GuildMemberRoleAdd(info.ID, SBitoa(to), SBitoa(old.ColorRole)) })
+		}
+	}
+	info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " migrated the data of ", oldname, " (", SBitoa(from), ") to ", newname, " (", SBitoa(to), ") with the ", strategy, " strategy. Moved ", Pluralize(int64(old.Offenses), " offense"), ", ", Pluralize(int64(old.Modlog), " logged moderator action"), ", ", Pluralize(int64(old.Messages), " message"), " of activity, birthday: ", old.Birthday, ", color role: ", old.ColorRole, ". The new account had ", Pluralize(int64(cur.Offenses), " offense"), ", ", Pluralize(int64(cur.Modlog), " logged moderator action"), " and ", Pluralize(int64(cur.Messages), " message"), " of activity.")
+	return "```Moved " + SanitizeMentions(oldname) + "'s data to " + SanitizeMentions(newname) + ".```", false, nil
+}
+func (c *migrateUserCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Moves everything this server has stored about someone's old account to their new one: warnings and other offenses, moderation log entries and the notes moderators left on them, message activity, their birthday, their color role, and when they were first seen. Shows what will be moved and asks for confirmation first. Where both accounts have data, `users.migratestrategy` decides which wins. Everything moved is logged. Only admins can use this.",
+		Params: []CommandUsageParam{
+			{Name: "old", Desc: "A ping or user ID of the old account.", Optional: false},
+			{Name: "new", Desc: "A ping or user ID of the new account.", Optional: false},
+			{Name: "preview", Desc: "Only shows what would be moved, without moving anything.", Optional: true},
+		},
+	}
+}
+func (c *migrateUserCommand) UsageShort() string { return "Moves someone's data to a new account." }
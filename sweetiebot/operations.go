@@ -0,0 +1,202 @@
+package sweetiebot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// operationProgress is a long running bulk operation, like a massrole, whose progress is saved to the database as it
+// goes. If the bot restarts before it finishes, the saved progress lets the moderator who started it pick it back up
+// where it stopped instead of starting over.
+type operationProgress struct {
+	ID       uint64
+	Kind     string // which command started it, which is also what knows how to resume it
+	Author   string
+	Channel  string
+	Desc     string
+	State    string // whatever the operation needs to resume, as JSON
+	Total    int64
+	Done     int64
+	Failed   int64
+	Updated  time.Time
+	lastsave int64
+	running  bool // set once a resumed operation has actually started again
+}
+
+// Progress is only written to the database this often, so a fast operation doesn't hammer it
+const operationSaveInterval = 15
+
+// Operations running in this process, by ID. Anything in the database that isn't in here was interrupted.
+var runningOperations sync.Map
+
+// Interrupted operations that have already been reported, so reconnecting doesn't report them again
+var reportedOperations sync.Map
+
+// Resumes an interrupted operation of a particular kind, returning the response to the moderator
+type operationResumer func(info *GuildInfo, op *operationProgress, msg *discordgo.Message) string
+
+// Starts tracking an operation. If the database is down, the operation still runs, it just can't be resumed.
+func startOperation(info *GuildInfo, kind string, author string, channel string, desc string, state interface{}, total int) *operationProgress {
+	op := &operationProgress{Kind: kind, Author: author, Channel: channel, Desc: desc, Total: int64(total), Updated: time.Now().UTC()}
+	if data, err := json.Marshal(state); err == nil {
+		op.State = string(data)
+	}
+	if sb.db.CheckStatus() {
+		op.ID = sb.db.AddOperation(SBatoi(info.ID), op)
+	}
+	op.lastsave = time.Now().UTC().Unix()
+	if op.ID != 0 {
+		runningOperations.Store(op.ID, op)
+	}
+	return op
+}
+
+// Claims an interrupted operation before it's resumed or discarded, so two moderators can't both pick it back up.
+// Returns false if it's already running, or someone else is already resuming it.
+func (op *operationProgress) claim() bool {
+	_, running := runningOperations.LoadOrStore(op.ID, op)
+	return !running
+}
+
+// Gives up a claim on an operation that didn't end up running, so it can be resumed again later
+func (op *operationProgress) release() {
+	if !op.running {
+		runningOperations.CompareAndDelete(op.ID, op)
+	}
+}
+
+// Starts tracking an interrupted operation again once it has been resumed
+func (op *operationProgress) resumed() {
+	op.lastsave = time.Now().UTC().Unix()
+	op.running = true
+	runningOperations.Store(op.ID, op)
+}
+
+// Records how far the operation got, saving it if it hasn't been saved in a while
+func (op *operationProgress) progress(done int64, failed int64) {
+	atomic.StoreInt64(&op.Done, done)
+	atomic.StoreInt64(&op.Failed, failed)
+	if op.ID != 0 && RateLimit(&op.lastsave, operationSaveInterval) && sb.db.CheckStatus() {
+		sb.db.UpdateOperation(op.ID, done, failed)
+	}
+}
+
+// Stops tracking the operation. If the bot is shutting down, the progress is saved instead so it can be resumed later.
+func (op *operationProgress) finish() {
+	if op.ID == 0 {
+		return
+	}
+	runningOperations.Delete(op.ID)
+	if !sb.db.CheckStatus() {
+		return
+	}
+	if sb.quit.get() {
+		sb.db.UpdateOperation(op.ID, atomic.LoadInt64(&op.Done), atomic.LoadInt64(&op.Failed))
+	} else {
+		sb.db.RemoveOperation(op.ID)
+	}
+}
+
+func (op *operationProgress) describe() string {
+	return fmt.Sprintf("#%v %s: %s, stopped at %v/%v with %v failed, %s ago", op.ID, op.Kind, op.Desc, op.Done, op.Total, op.Failed, TimeDiff(time.Now().UTC().Sub(op.Updated)))
+}
+
+// Returns the saved operations for a server that aren't running anymore
+func interruptedOperations(info *GuildInfo) []*operationProgress {
+	all := sb.db.GetOperations(SBatoi(info.ID))
+	r := make([]*operationProgress, 0, len(all))
+	for _, op := range all {
+		if _, running := runningOperations.Load(op.ID); !running {
+			r = append(r, op)
+		}
+	}
+	return r
+}
+
+// Tells whoever started each interrupted operation that it can be resumed
+func reportInterruptedOperations(info *GuildInfo) {
+	if !sb.db.CheckStatus() {
+		return
+	}
+	for _, op := range interruptedOperations(info) {
+		if _, reported := reportedOperations.LoadOrStore(op.ID, true); reported {
+			continue
+		}
+		prefix := info.config.Basic.CommandPrefix
+		info.SendMessage(op.Channel, fmt.Sprintf("<@%s>, a %s (%s) was interrupted at %v/%v when I restarted. Use `%sinterrupted resume %v` to pick it back up, or `%sinterrupted discard %v` to forget about it.", op.Author, op.Kind, op.Desc, op.Done, op.Total, prefix, op.ID, prefix, op.ID))
+	}
+}
+
+type interruptedCommand struct {
+	resumers map[string]operationResumer
+}
+
+func (c *interruptedCommand) Name() string {
+	return "Interrupted"
+}
+func (c *interruptedCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	ops := interruptedOperations(info)
+	if len(args) < 1 {
+		if len(ops) == 0 {
+			return "```There are no interrupted operations.```", false, nil
+		}
+		lines := make([]string, 0, len(ops))
+		for _, op := range ops {
+			lines = append(lines, op.describe())
+		}
+		return "```\n" + strings.Join(lines, "\n") + "```", false, nil
+	}
+	action := strings.ToLower(args[0])
+	if action != "resume" && action != "discard" {
+		return "```You must specify resume or discard, or nothing to list interrupted operations.```", false, nil
+	}
+	if len(args) < 2 {
+		return "```You must give the ID of the operation to " + action + ".```", false, nil
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(args[1], "#"), 10, 64)
+	if err != nil {
+		return "```" + args[1] + " isn't an operation ID.```", false, nil
+	}
+	var op *operationProgress
+	for _, v := range ops {
+		if v.ID == id {
+			op = v
+		}
+	}
+	if op == nil || !op.claim() {
+		return "```There's no interrupted operation with that ID. It may still be running, or already have been resumed.```", false, nil
+	}
+	defer op.release()
+	if action == "discard" {
+		sb.db.RemoveOperation(op.ID)
+		info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " discarded an interrupted ", op.Kind, ": ", op.Desc)
+		return "```Discarded " + op.describe() + ".```", false, nil
+	}
+	resume, ok := c.resumers[op.Kind]
+	if !ok {
+		return "```I don't know how to resume a " + op.Kind + ". Discard it and start over instead.```", false, nil
+	}
+	return resume(info, op, msg), false, nil
+}
+func (c *interruptedCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Long bulk operations, like a `!massrole`, save their progress as they go. If the bot restarts before one finishes, whoever started it is told, and it can be continued from where it stopped. With no arguments, lists the interrupted operations.",
+		Params: []CommandUsageParam{
+			{Name: "resume|discard", Desc: "Whether to continue the operation or forget about it.", Optional: true},
+			{Name: "id", Desc: "The ID of the operation, from the list.", Optional: true},
+		},
+	}
+}
+func (c *interruptedCommand) UsageShort() string {
+	return "Resumes bulk operations interrupted by a restart."
+}
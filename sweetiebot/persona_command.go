@@ -0,0 +1,112 @@
+package sweetiebot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const maxGuildPersonas = 25
+
+// Finds a persona by name. Returns false if there isn't one, in which case the message should come from the bot itself.
+func (info *GuildInfo) persona(name string) (Persona, bool) {
+	if name == "" {
+		return Persona{}, false
+	}
+	p, ok := info.config.Basic.Personas[strings.ToLower(name)]
+	return p, ok
+}
+
+func (p Persona) describe(name string) string {
+	if p.Avatar == "" {
+		return name + ": " + p.Name
+	}
+	return name + ": " + p.Name + " (" + p.Avatar + ")"
+}
+
+type personaCommand struct {
+}
+
+func (c *personaCommand) Name() string {
+	return "Persona"
+}
+func (c *personaCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		if len(info.config.Basic.Personas) == 0 {
+			return "```No personas have been added. Use !persona add <name> \"<display name>\" [avatar URL] to add one.```", false, nil
+		}
+		names := make([]string, 0, len(info.config.Basic.Personas))
+		for k := range info.config.Basic.Personas {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		lines := make([]string, 0, len(names))
+		for _, k := range names {
+			lines = append(lines, info.config.Basic.Personas[k].describe(k))
+		}
+		return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```", len(lines) > 6, nil
+	}
+	action := strings.ToLower(args[0])
+	if action != "add" && action != "remove" {
+		return "```You must specify add or remove, or nothing to list the personas.```", false, nil
+	}
+	if len(args) < 2 {
+		return "```You must give the name of the persona to " + action + ".```", false, nil
+	}
+	name := strings.ToLower(args[1])
+	if action == "remove" {
+		if _, ok := info.config.Basic.Personas[name]; !ok {
+			return "```There's no persona called " + name + ".```", false, nil
+		}
+		delete(info.config.Basic.Personas, name)
+		if info.config.Witty.Persona == name {
+			info.config.Witty.Persona = ""
+		}
+		info.SaveConfig()
+		return "```Removed " + name + ". Scheduled announcements that used it will be posted by me instead.```", false, nil
+	}
+	if !varnameregex.MatchString(name) {
+		return "```Persona names can only have letters, numbers and underscores, and can't be longer than 32 characters.```", false, nil
+	}
+	if len(args) < 3 {
+		return "```You must give the name the persona's messages will be posted under.```", false, nil
+	}
+	p := Persona{Name: strings.TrimSpace(args[2])}
+	if n := len([]rune(p.Name)); n < 1 || n > 80 {
+		return "```Display names must be between 1 and 80 characters.```", false, nil
+	}
+	if lower := strings.ToLower(p.Name); strings.Contains(lower, "discord") || strings.Contains(lower, "clyde") {
+		return "```Discord doesn't allow display names that contain \"discord\" or \"clyde\".```", false, nil
+	}
+	if len(args) > 3 {
+		p.Avatar = strings.Trim(args[3], "<>")
+		if !strings.HasPrefix(p.Avatar, "https://") && !strings.HasPrefix(p.Avatar, "http://") {
+			return "```The avatar has to be a link to an image.```", false, nil
+		}
+	}
+	if _, ok := info.config.Basic.Personas[name]; !ok && len(info.config.Basic.Personas) >= maxGuildPersonas {
+		return "```This server already has 25 personas. Remove one with !persona remove first.```", false, nil
+	}
+	if info.config.Basic.Personas == nil {
+		info.config.Basic.Personas = make(map[string]Persona)
+	}
+	info.config.Basic.Personas[name] = p
+	info.SaveConfig()
+	info.Log(getUserName(SBatoi(msg.Author.ID), info), " set the ", name, " persona to ", p.Name)
+	return "```Set " + PartialSanitize(p.describe(name)) + ". Use it with " + info.config.Basic.CommandPrefix + "say #channel as:" + name + " <message>.```", false, nil
+}
+func (c *personaCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Adds or removes a persona, a name and avatar that announcements from `" + info.config.Basic.CommandPrefix + "say` and witty responses can be posted under instead of Sweetie Bot's own. Messages are posted through a webhook that Sweetie Bot creates in the channel, so it needs the Manage Webhooks permission. With no arguments, lists the personas.",
+		Params: []CommandUsageParam{
+			{Name: "add|remove", Desc: "Whether to add (or replace) a persona, or remove one.", Optional: true},
+			{Name: "name", Desc: "The short name used to pick the persona, like `news`.", Optional: true},
+			{Name: "display name", Desc: "The name messages are posted under, in quotes if it has spaces. Only used with `add`.", Optional: true},
+			{Name: "avatar URL", Desc: "A link to the avatar image. If omitted, the webhook's default avatar is used.", Optional: true},
+		},
+	}
+}
+func (c *personaCommand) UsageShort() string {
+	return "Manages personas for announcements."
+}
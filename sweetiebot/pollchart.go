@@ -0,0 +1,98 @@
+package sweetiebot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+// Layout of a poll chart, in pixels
+const pollChartWidth = 640
+const pollChartMargin = 16
+const pollChartBarHeight = 22
+const pollChartRowHeight = 58 // label, bar and the gap before the next option
+
+// Charts with more options than this would be taller than discord will preview
+const pollChartMaxOptions = 30
+
+var pollChartBackground = color.RGBA{0x2C, 0x2F, 0x33, 0xFF}
+var pollChartTrack = color.RGBA{0x40, 0x44, 0x4B, 0xFF}
+var pollChartBar = color.RGBA{0x72, 0x89, 0xDA, 0xFF}
+var pollChartLeader = color.RGBA{0x43, 0xB5, 0x81, 0xFF}
+var pollChartText = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+var pollChartSubtext = color.RGBA{0xB9, 0xBB, 0xBE, 0xFF}
+
+// Cuts text down to the number of characters that fit in width pixels when drawn at scale
+func fitText(text string, width int, scale int) string {
+	fit := width / (fontAdvance * scale)
+	if r := []rune(text); len(r) > fit {
+		if fit < 4 {
+			return string(r[:max(fit, 0)])
+		}
+		return string(r[:fit-3]) + "..."
+	}
+	return text
+}
+
+// Draws a poll's results as a bar chart. Every option tied for the most votes is drawn in the leader color, and options
+// with no votes still get an empty bar so they don't look like they're missing.
+func renderPollChart(desc string, options []PollOptionStruct, counts []uint64) ([]byte, error) {
+	if len(options) > pollChartMaxOptions {
+		return nil, fmt.Errorf("polls with more than %v options can't be drawn as a chart", pollChartMaxOptions)
+	}
+	var most, total uint64
+	for _, c := range counts {
+		total += c
+		if c > most {
+			most = c
+		}
+	}
+	header := 44
+	height := header + len(options)*pollChartRowHeight + pollChartMargin
+	img := image.NewRGBA(image.Rect(0, 0, pollChartWidth, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(pollChartBackground), image.Point{}, draw.Src)
+	inner := pollChartWidth - pollChartMargin*2
+	drawText(img, pollChartMargin, pollChartMargin, 2, pollChartText, fitText(desc, inner, 2))
+
+	for i, option := range options {
+		y := header + i*pollChartRowHeight
+		count := counts[i]
+		summary := fmt.Sprintf("%v votes", count)
+		if count == 1 {
+			summary = "1 vote"
+		}
+		if total > 0 {
+			summary += fmt.Sprintf(" (%v%%)", (count*100+total/2)/total)
+		}
+		summaryWidth := len(summary) * fontAdvance * 2
+		drawText(img, pollChartWidth-pollChartMargin-summaryWidth, y+4, 2, pollChartSubtext, summary)
+		label := fmt.Sprintf("%v. %s", option.index, option.option)
+		drawText(img, pollChartMargin, y+4, 2, pollChartText, fitText(label, inner-summaryWidth-fontAdvance*2, 2))
+
+		bar := image.Rect(pollChartMargin, y+24, pollChartMargin+inner, y+24+pollChartBarHeight)
+		draw.Draw(img, bar, image.NewUniform(pollChartTrack), image.Point{}, draw.Src)
+		if count == 0 {
+			continue
+		}
+		fill := bar
+		fill.Max.X = bar.Min.X + max(int(uint64(inner)*count/most), 4) // Keep tiny shares visible
+		c := pollChartBar
+		if count == most {
+			c = pollChartLeader
+		}
+		draw.Draw(img, fill, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	if total == 0 {
+		msg := "No votes yet"
+		drawText(img, pollChartWidth-pollChartMargin-len(msg)*fontAdvance*2, pollChartMargin, 2, pollChartSubtext, msg)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
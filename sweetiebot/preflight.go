@@ -0,0 +1,144 @@
+package sweetiebot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A server permission a module can't work without, and what breaks if it's missing
+type modulePermission struct {
+	perm int64
+	name string
+	why  string
+}
+
+var (
+	permManageRoles    = modulePermission{discordgo.PermissionManageRoles, "Manage Roles", "silence members and assign roles"}
+	permManageMessages = modulePermission{discordgo.PermissionManageMessages, "Manage Messages", "delete messages"}
+	permBanMembers     = modulePermission{discordgo.PermissionBanMembers, "Ban Members", "ban spammers and raiders"}
+	permKickMembers    = modulePermission{discordgo.PermissionKickMembers, "Kick Members", "kick members"}
+	permManageServer   = modulePermission{discordgo.PermissionManageServer, "Manage Server", "engage lockdown mode, see invites and use AutoMod"}
+	permAuditLog       = modulePermission{discordgo.PermissionViewAuditLogs, "View Audit Log", "tell who made moderation changes"}
+	permManageChannels = modulePermission{discordgo.PermissionManageChannels, "Manage Channels", "create and delete channels"}
+	permMoveMembers    = modulePermission{discordgo.PermissionVoiceMoveMembers, "Move Members", "move members between voice channels"}
+	permNicknames      = modulePermission{discordgo.PermissionManageNicknames, "Manage Nicknames", "change nicknames"}
+	permReactions      = modulePermission{discordgo.PermissionAddReactions, "Add Reactions", "add reactions"}
+	permThreads        = modulePermission{discordgo.PermissionCreatePublicThreads, "Create Public Threads", "start threads"}
+)
+
+// The permissions each module needs, by the lowercase module name. Modules that only post messages aren't listed.
+var modulePermissions = map[string][]modulePermission{
+	"anti-spam":       {permManageRoles, permManageMessages, permBanMembers, permManageServer},
+	"users":           {permManageRoles, permBanMembers},
+	"roles":           {permManageRoles},
+	"scheduler":       {permManageRoles},
+	"audit":           {permAuditLog},
+	"filter":          {permManageMessages},
+	"automod":         {permManageServer},
+	"phishing":        {permManageMessages},
+	"secrets":         {permManageMessages},
+	"emote":           {permManageMessages},
+	"spoiler":         {permManageMessages},
+	"pinboard":        {permManageMessages},
+	"sticky":          {permManageMessages},
+	"suggestionboard": {permManageMessages, permReactions},
+	"autoreact":       {permReactions},
+	"autothread":      {permThreads},
+	"rolemenu":        {permManageRoles},
+	"rulesgate":       {permManageRoles, permKickMembers, permReactions},
+	"boosts":          {permManageRoles},
+	"invites":         {permManageServer},
+	"nicknames":       {permNicknames},
+	"newchannels":     {permManageRoles, permAuditLog},
+	"tempvoice":       {permManageChannels, permMoveMembers},
+}
+
+// Returns a line for each permission that an enabled module needs but Sweetie Bot doesn't have, naming the modules
+// that need it. If all is true, every module is checked, even disabled ones, which is used before the server is set up.
+func missingPermissions(info *GuildInfo, all bool) ([]string, error) {
+	perms, err := getAllPerms(info, sb.SelfID)
+	if err != nil {
+		return nil, err
+	}
+	if perms&discordgo.PermissionAdministrator != 0 {
+		return nil, nil
+	}
+	needed := make(map[string][]string)
+	reasons := make(map[string]string)
+	for _, m := range info.modules {
+		name := strings.ToLower(m.Name())
+		if _, disabled := info.config.Modules.Disabled[name]; disabled && !all {
+			continue
+		}
+		for _, p := range modulePermissions[name] {
+			if perms&p.perm == 0 {
+				needed[p.name] = append(needed[p.name], m.Name())
+				reasons[p.name] = p.why
+			}
+		}
+	}
+	lines := make([]string, 0, len(needed))
+	for k, v := range needed {
+		lines = append(lines, k+" (to "+reasons[k]+"): needed by "+strings.Join(v, ", "))
+	}
+	sort.Strings(lines)
+	return lines, nil
+}
+
+// Builds the preflight summary, or an empty string if nothing is missing
+func preflightReport(info *GuildInfo, all bool) string {
+	lines, err := missingPermissions(info, all)
+	if err != nil {
+		return "Discord hasn't sent Sweetie Bot's member information yet, so her permissions can't be checked. Try " + info.config.Basic.CommandPrefix + "preflight again in a minute."
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Sweetie Bot is missing permissions that some of her modules need on " + info.Name + ":\n```\n" + strings.Join(lines, "\n") + "```\nTo fix this, go to Server Settings -> Roles, select Sweetie Bot's role, and turn these permissions on. You can also disable the modules you don't use with `" + info.config.Basic.CommandPrefix + "disable <module>`. Run `" + info.config.Basic.CommandPrefix + "preflight` to check again."
+}
+
+// Runs the preflight after joining a new server, and sends the result to the server owner, or to the system channel if
+// they can't be messaged. Every module is checked, since they're all disabled until setup is run.
+func sendPreflight(info *GuildInfo) {
+	report := preflightReport(info, true)
+	if len(report) == 0 {
+		return
+	}
+	if ch, err := sb.dg.UserChannelCreate(info.OwnerID); err == nil {
+		if _, err = sb.dg.ChannelMessageSend(ch.ID, report); err == nil {
+			return
+		}
+	}
+	if g, err := sb.dg.State.Guild(info.ID); err == nil && len(g.SystemChannelID) > 0 {
+		info.SendMessage(g.SystemChannelID, report)
+	}
+}
+
+type preflightCommand struct {
+}
+
+func (c *preflightCommand) Name() string {
+	return "Preflight"
+}
+func (c *preflightCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	all := len(args) > 0 && strings.ToLower(args[0]) == "all"
+	report := preflightReport(info, all)
+	if len(report) == 0 {
+		if all {
+			return "```Sweetie Bot has every permission her modules need.```", false, nil
+		}
+		return "```Sweetie Bot has every permission her enabled modules need.```", false, nil
+	}
+	return report, false, nil
+}
+func (c *preflightCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Checks that Sweetie Bot has the server permissions her enabled modules need, and lists any that are missing along with the modules that need them. This also runs when she joins a new server.",
+		Params: []CommandUsageParam{
+			{Name: "all", Desc: "Checks every module, including disabled ones.", Optional: true},
+		},
+	}
+}
+func (c *preflightCommand) UsageShort() string { return "Checks for missing permissions." }
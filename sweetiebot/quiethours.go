@@ -0,0 +1,157 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// No more than this many messages are held back for the end of quiet hours. Once it's full the oldest are dropped.
+const quietQueueSize = 50
+
+// Returns true if it's currently quiet hours on this server, in the server's timezone
+func (info *GuildInfo) isQuietHours(t time.Time) bool {
+	q := info.config.QuietHours
+	if !q.Enabled || q.Start == q.End {
+		return false
+	}
+	hour := t.In(getTimezone(info, nil)).Hour()
+	if q.Start < q.End {
+		return hour >= q.Start && hour < q.End
+	}
+	return hour >= q.Start || hour < q.End // Quiet hours that wrap around midnight
+}
+
+// Posts non-urgent chatter, like bump reminders, boost celebrations and birthday wishes, unless it's quiet hours. During quiet hours it's
+// either held back until they end or thrown away, depending on quiethours.queue. Moderation never goes through here.
+// Either way it has to fit in the chatter budget when it's actually posted.
+func (info *GuildInfo) sendChatter(feature string, send func()) {
+	if !info.isQuietHours(time.Now().UTC()) {
+		if info.takeChatter(feature) {
+			send()
+		}
+		return
+	}
+	if !info.config.QuietHours.Queue {
+		return
+	}
+	info.quietLock.Lock()
+	if len(info.quietqueue) >= quietQueueSize {
+		info.quietqueue = info.quietqueue[1:]
+	}
+	info.quietqueue = append(info.quietqueue, func() {
+		if info.takeChatter(feature) {
+			send()
+		}
+	})
+	info.quietLock.Unlock()
+}
+
+// Like sendChatter, but for chatter that only makes sense right away, like replies to a message. During quiet hours it's
+// always dropped, even if quiethours.queue is on.
+func (info *GuildInfo) takeChatterNow(feature string) bool {
+	return !info.isQuietHours(time.Now().UTC()) && info.takeChatter(feature)
+}
+
+// Posts everything that was held back on servers whose quiet hours are over
+func flushQuietHours() {
+	sb.guildsLock.RLock()
+	guilds := make([]*GuildInfo, 0, len(sb.guilds))
+	for _, v := range sb.guilds {
+		guilds = append(guilds, v)
+	}
+	sb.guildsLock.RUnlock()
+	now := time.Now().UTC()
+	for _, info := range guilds {
+		if info.isQuietHours(now) {
+			continue
+		}
+		info.quietLock.Lock()
+		queue := info.quietqueue
+		info.quietqueue = nil
+		info.quietLock.Unlock()
+		for i, send := range queue {
+			if i > 0 {
+				time.Sleep(time.Second) // Don't dump everything at once
+			}
+			send()
+		}
+	}
+}
+
+func describeQuietHours(info *GuildInfo) string {
+	q := info.config.QuietHours
+	what := "dropped"
+	if q.Queue {
+		what = "held back until they end"
+	}
+	state := "off"
+	if q.Enabled {
+		state = "on"
+	}
+	s := fmt.Sprintf("Quiet hours are %s, from %02d:00 to %02d:00 (%s). Non-urgent messages are %s.", state, q.Start, q.End, getTimezone(info, nil).String(), what)
+	if q.Enabled && q.Start == q.End {
+		s += " The start and end are the same, so there are no quiet hours."
+	}
+	info.quietLock.Lock()
+	if n := len(info.quietqueue); n > 0 {
+		s += " " + Pluralize(int64(n), " message") + " waiting."
+	}
+	info.quietLock.Unlock()
+	return s
+}
+
+type quietHoursCommand struct {
+}
+
+func (c *quietHoursCommand) Name() string {
+	return "QuietHours"
+}
+func (c *quietHoursCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```" + describeQuietHours(info) + "```", false, nil
+	}
+	for _, arg := range args {
+		switch strings.ToLower(arg) {
+		case "on":
+			info.config.QuietHours.Enabled = true
+		case "off":
+			info.config.QuietHours.Enabled = false
+		case "queue":
+			info.config.QuietHours.Queue = true
+		case "drop":
+			info.config.QuietHours.Queue = false
+		default:
+			hours := strings.SplitN(arg, "-", 2)
+			if len(hours) != 2 {
+				return "```" + arg + " isn't on, off, queue, drop, or a range of hours like 23-7.```", false, nil
+			}
+			start, err := strconv.Atoi(strings.TrimSuffix(hours[0], ":00"))
+			end, err2 := strconv.Atoi(strings.TrimSuffix(hours[1], ":00"))
+			if err != nil || err2 != nil || start < 0 || start > 23 || end < 0 || end > 23 {
+				return "```Quiet hours must be whole hours from 0 to 23, like 23-7.```", false, nil
+			}
+			info.config.QuietHours.Start = start
+			info.config.QuietHours.End = end
+			info.config.QuietHours.Enabled = true
+		}
+	}
+	info.SaveConfig()
+	return "```" + describeQuietHours(info) + "```", false, nil
+}
+func (c *quietHoursCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Sets the hours when Sweetie Bot keeps non-urgent chatter to herself, like witty responses, birthday wishes, bump reminders and boost celebrations. Moderation carries on as usual. Hours are in the server's timezone, set with `users.timezonelocation`. During quiet hours, messages are either dropped or held back and posted once they end. Witty responses, AFK replies and bored actions are always dropped. With no arguments, shows the current setting.",
+		Params: []CommandUsageParam{
+			{Name: "on/off", Desc: "Turns quiet hours on or off.", Optional: true},
+			{Name: "start-end", Desc: "The hours quiet hours start and end, like `23-7`. Also turns them on.", Optional: true},
+			{Name: "queue/drop", Desc: "Whether messages are held back until quiet hours end, or dropped.", Optional: true},
+		},
+	}
+}
+func (c *quietHoursCommand) UsageShort() string {
+	return "Sets when the bot stays quiet."
+}
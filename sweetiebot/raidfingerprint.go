@@ -0,0 +1,243 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// No more than this many recent joins are fingerprinted at once, so a huge raid can't make every join check slower
+const fingerprintJoinLimit = 200
+
+// Someone who joined recently, with everything the raid fingerprint compares
+type recentJoin struct {
+	user          *discordgo.User
+	name          string // lowercase username
+	defaultAvatar bool
+	created       time.Time
+	joined        time.Time
+}
+
+// A cluster of similar accounts that joined together, which the moderators can ban with one click
+type raidCohort struct {
+	users    []*discordgo.User
+	detected time.Time
+	message  string // the ID of the alert in the mod channel
+	channel  string
+	banned   bool
+}
+
+// Returns the number of single character insertions, deletions or substitutions it takes to turn a into b
+func editDistance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(t)]
+}
+
+// Returns true if two joiners look like they came from the same raid. Similar usernames, both having the default
+// avatar, and accounts created around the same time each count as a sign, and it takes two of them, since real members
+// often share one by chance.
+func similarJoins(info *GuildInfo, a *recentJoin, b *recentJoin) bool {
+	signs := 0
+	if editDistance(a.name, b.name) <= info.config.Spam.FingerprintEdits {
+		signs++
+	}
+	if a.defaultAvatar && b.defaultAvatar {
+		signs++
+	}
+	created := a.created.Sub(b.created)
+	if created < 0 {
+		created = -created
+	}
+	if created <= time.Duration(info.config.Spam.FingerprintCreated)*time.Second {
+		signs++
+	}
+	return signs >= 2
+}
+
+// Returns the recent joins that are connected to joins[start] through a chain of similar joins
+func findCluster(info *GuildInfo, joins []recentJoin, start int) []int {
+	seen := make([]bool, len(joins))
+	seen[start] = true
+	cluster := []int{start}
+	for i := 0; i < len(cluster); i++ {
+		for j := range joins {
+			if !seen[j] && similarJoins(info, &joins[cluster[i]], &joins[j]) {
+				seen[j] = true
+				cluster = append(cluster, j)
+			}
+		}
+	}
+	return cluster
+}
+
+// Fingerprints a new member against everyone else who joined within spam.fingerprinttime. If they belong to a cluster of
+// at least spam.fingerprintsize similar accounts, the moderators are alerted, lockdown is engaged, and the cluster
+// becomes the cohort that can be banned from the alert. Anyone matching the cohort after that is quietly added to it.
+func (w *SpamModule) checkFingerprint(info *GuildInfo, m *discordgo.Member) {
+	if info.config.Spam.FingerprintSize <= 1 || m.User == nil || m.User.Bot {
+		return
+	}
+	now := time.Now().UTC()
+	window := time.Duration(info.config.Spam.FingerprintTime) * time.Second
+	join := recentJoin{
+		user:          m.User,
+		name:          strings.ToLower(m.User.Username),
+		defaultAvatar: len(m.User.Avatar) == 0,
+		created:       snowflakeTime(SBatoi(m.User.ID)),
+		joined:        now,
+	}
+
+	w.Lock()
+	joins := w.joins[:0]
+	for _, v := range w.joins {
+		if now.Sub(v.joined) <= window && v.user.ID != m.User.ID {
+			joins = append(joins, v)
+		}
+	}
+	if len(joins) >= fingerprintJoinLimit {
+		joins = joins[1:]
+	}
+	joins = append(joins, join)
+	w.joins = joins
+
+	if c := w.cohort; c != nil && !c.banned && now.Sub(c.detected) <= window {
+		for _, v := range joins {
+			if inCohort(c, v.user.ID) && similarJoins(info, &v, &join) {
+				c.users = append(c.users, m.User)
+				w.Unlock()
+				info.LogTo(LogModeration, "Added ", m.User.Username, " to the suspected raid cohort.")
+				return
+			}
+		}
+	}
+	cluster := findCluster(info, joins, len(joins)-1)
+	fresh := 0 // Only members that aren't already in the current cohort count towards a new one
+	for _, k := range cluster {
+		if c := w.cohort; c == nil || c.banned || now.Sub(c.detected) > window || !inCohort(c, joins[k].user.ID) {
+			fresh++
+		}
+	}
+	if fresh < info.config.Spam.FingerprintSize {
+		w.Unlock()
+		return
+	}
+	users := make([]*discordgo.User, len(cluster))
+	for i, k := range cluster {
+		users[i] = joins[k].user
+	}
+	c := &raidCohort{users: users, detected: now, channel: SBitoa(info.config.Basic.ModChannel)}
+	if sb.Debug {
+		c.channel, _ = sb.DebugChannels[info.ID]
+	}
+	w.cohort = c
+	w.Unlock()
+
+	s := make([]string, len(cluster))
+	names := make([]string, len(cluster))
+	for i, k := range cluster {
+		v := joins[k]
+		avatar := ""
+		if v.defaultAvatar {
+			avatar = ", default avatar"
+		}
+		s[i] = fmt.Sprintf("%s (%s, created %s ago%s)", v.user.Username, v.user.ID, TimeDiff(now.Sub(v.created)), avatar)
+		names[i] = v.user.Username
+	}
+	text := "<@&" + SBitoa(info.config.Basic.AlertRole) + "> Possible raid detected! " + strconv.Itoa(len(cluster)) + " similar accounts joined in the last " + TimeDiff(window) + ". Anyone else matching them who joins soon will be added to the cohort.\n```\n" + SanitizeMentions(strings.Join(s, "\n")) + "```"
+	var msg *discordgo.Message
+	err := CallAPI("ChannelMessageSendComplex", func() (err error) {
+		msg, err = sb.dg.ChannelMessageSendComplex(c.channel, &discordgo.MessageSend{
+			Content: truncateRunes(text, 2000),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: "Ban the cohort", Style: discordgo.DangerButton, CustomID: "raidcohort:ban"},
+				}},
+			},
+			AllowedMentions: &discordgo.MessageAllowedMentions{Roles: []string{SBitoa(info.config.Basic.AlertRole)}},
+		})
+		return
+	})
+	if err == nil {
+		w.Lock()
+		c.message = msg.ID
+		w.Unlock()
+	}
+	info.LogTo(LogModeration, "Raid fingerprint caught ", len(cluster), " similar accounts: ", strings.Join(names, ", "))
+	if info.config.Spam.AutoSilence >= 1 {
+		for _, u := range users {
+			silenceMember(u, info)
+		}
+	}
+	EngageLockdown(info, c.channel)
+}
+
+func inCohort(c *raidCohort, user string) bool {
+	for _, u := range c.users {
+		if u.ID == user {
+			return true
+		}
+	}
+	return false
+}
+
+// OnInteractionCreate discord hook
+func (w *SpamModule) OnInteractionCreate(info *GuildInfo, i *discordgo.Interaction) bool {
+	if i.Type != discordgo.InteractionMessageComponent || i.MessageComponentData().CustomID != "raidcohort:ban" || i.Member == nil {
+		return false
+	}
+	if !info.HasModRole(i.Member.User.ID) {
+		respondEphemeral(i, "Only moderators can ban a raid cohort.")
+		return true
+	}
+	w.Lock()
+	c := w.cohort
+	if c == nil || c.banned || c.message != i.Message.ID {
+		w.Unlock()
+		respondEphemeral(i, "This cohort has already been banned, or a newer raid has been detected since.")
+		return true
+	}
+	c.banned = true
+	users := append([]*discordgo.User{}, c.users...)
+	w.Unlock()
+
+	// Banning everyone can take longer than discord waits for a response, so acknowledge the click first
+	if err := sb.dg.InteractionRespond(i, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate}); err != nil {
+		metricAPIErrors.Add(ClassifyAPIError(err).String(), 1)
+	}
+	reason := "Banned by " + i.Member.User.Username + " as part of a raid cohort."
+	banned := 0
+	for _, u := range users {
+		if err := CallAPI("GuildBanCreateWithReason", func() error { return sb.dg.GuildBanCreateWithReason(info.ID, u.ID, reason, 1) }); err == nil {
+			banned++
+		} else {
+			info.LogError("Error banning user: ", err)
+		}
+	}
+	content := i.Message.Content + "\n**" + SanitizeMentions(i.Member.User.Username) + " banned " + Pluralize(int64(banned), " member") + " from this cohort.**"
+	components := []discordgo.MessageComponent{}
+	CallAPI("ChannelMessageEditComplex", func() error {
+		_, err := sb.dg.ChannelMessageEditComplex(&discordgo.MessageEdit{ID: i.Message.ID, Channel: i.ChannelID, Content: &content, Components: &components})
+		return err
+	})
+	info.LogTo(LogModeration, i.Member.User.Username, " banned ", banned, " members in a raid cohort.")
+	return true
+}
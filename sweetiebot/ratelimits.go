@@ -0,0 +1,68 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// RateLimitAlert warns about the bot being throttled by discord, set by the bot owner in the limits file
+type RateLimitAlert struct {
+	Threshold int  `json:"threshold"` // number of 429 responses in a minute that triggers a warning, or 0 to never warn
+	PingOwner bool `json:"pingowner"` // if true, the bot owners are also sent a private message
+}
+
+var routeidregex = regexp.MustCompile("[0-9]{5,}")
+
+// Turns a request URL into its route, like channels/:id/messages, so the rate limit metrics don't get a new key for every channel
+func rateLimitRoute(url string) string {
+	route := strings.TrimPrefix(url, discordgo.EndpointAPI)
+	if i := strings.IndexByte(route, '?'); i >= 0 {
+		route = route[:i]
+	}
+	if i := strings.Index(route, "/reactions/"); i >= 0 {
+		route = route[:i+len("/reactions")] // Reaction routes have the emoji in them
+	}
+	return routeidregex.ReplaceAllString(route, ":id")
+}
+
+// Reports the fill level of every token bucket the bot uses to limit itself
+func tokenBucketLevels() interface{} {
+	limits := sb.CommandLimits
+	levels := map[string]interface{}{
+		"commands": sb.commandbucket.level(limits.GlobalRate, limits.GlobalBurst),
+		"dm":       sb.dmbucket.level(dmRate, dmBurst),
+	}
+	guilds := make(map[string]float64)
+	sb.guildsLock.RLock()
+	for _, v := range sb.guilds {
+		guilds[v.ID] = v.commandbucket.level(limits.GuildRate, limits.GuildBurst)
+	}
+	sb.guildsLock.RUnlock()
+	levels["commands_by_guild"] = guilds
+	return levels
+}
+
+func sbRateLimit(s *discordgo.Session, r *discordgo.RateLimit) {
+	metricRateLimited.Add(rateLimitRoute(r.URL), 1)
+	if sb.RateLimitAlert.Threshold <= 0 {
+		return
+	}
+	now := time.Now().UTC().Unix()
+	sb.ratelimits.append(now)
+	if !sb.ratelimits.checkafter(sb.RateLimitAlert.Threshold-1, 60) || !RateLimit(&sb.lastratelimitalert, 600) {
+		return
+	}
+	warning := fmt.Sprintf("Discord rate limited the bot %v times in the last minute, most recently on %s. Something is sending far too many requests.", sb.RateLimitAlert.Threshold, rateLimitRoute(r.URL))
+	fmt.Printf("[%s] %s\n", time.Now().Format(time.Stamp), warning)
+	if sb.RateLimitAlert.PingOwner {
+		for owner := range sb.Owners {
+			if ch, err := sb.dg.UserChannelCreate(SBitoa(owner)); err == nil {
+				sb.dg.ChannelMessageSend(ch.ID, warning+" Check `rate_limited` in the metrics to see where.")
+			}
+		}
+	}
+}
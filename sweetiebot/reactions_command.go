@@ -0,0 +1,158 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var messagelinkregex = regexp.MustCompile(`^<?https://(?:(?:ptb|canary)\.)?discord(?:app)?\.com/channels/([0-9]+)/([0-9]+)/([0-9]+)>?$`)
+
+// At most this many reactors are fetched for each emoji, so a giveaway with thousands of entries can't tie up the bot
+const reactionsFetchLimit = 1000
+
+// Number of names shown on each page of results
+const reactionsPageSize = 100
+
+type reactionsCommand struct {
+}
+
+type reactionList struct {
+	emoji string
+	count int
+	users []string
+}
+
+func (c *reactionsCommand) Name() string {
+	return "Reactions"
+}
+
+// Gets everyone who reacted with an emoji, up to reactionsFetchLimit, 100 at a time
+func fetchReactors(channel string, message string, emoji string) ([]*discordgo.User, error) {
+	reactors := []*discordgo.User{}
+	after := ""
+	for len(reactors) < reactionsFetchLimit {
+		var users []*discordgo.User
+		err := CallAPI("MessageReactions", func() (err error) {
+			users, err = sb.dg.MessageReactions(channel, message, emoji, 100, "", after)
+			return
+		})
+		if err != nil {
+			return reactors, err
+		}
+		reactors = append(reactors, users...)
+		if len(users) < 100 {
+			break
+		}
+		after = users[len(users)-1].ID
+		time.Sleep(250 * time.Millisecond) // Reaction lookups share a tight bucket, so don't hammer it
+	}
+	return reactors, nil
+}
+
+func (c *reactionsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must provide a link to a message.```", false, nil
+	}
+	m := messagelinkregex.FindStringSubmatch(args[0])
+	if m == nil {
+		return "```That isn't a message link. Right click a message and choose Copy Message Link.```", false, nil
+	}
+	if m[1] != info.ID || !info.HasChannel(m[2]) {
+		return "```That message isn't on this server.```", false, nil
+	}
+	// Otherwise anyone could read the reactions in a channel they can't see
+	if perms, err := sb.dg.State.UserChannelPermissions(msg.Author.ID, m[2]); err != nil || perms&discordgo.PermissionViewChannel == 0 || perms&discordgo.PermissionReadMessageHistory == 0 {
+		return "```You can't read that channel.```", false, nil
+	}
+	page := 1
+	if len(args) > 1 {
+		p, err := strconv.Atoi(args[1])
+		if err != nil || p < 1 {
+			return "```The page must be a positive number.```", false, nil
+		}
+		page = p
+	}
+	var target *discordgo.Message
+	err := CallAPI("ChannelMessage", func() (err error) {
+		target, err = sb.dg.ChannelMessage(m[2], m[3])
+		return
+	})
+	if err != nil {
+		if ClassifyAPIError(err) == APIErrorNotFound {
+			return "```That message doesn't exist anymore.```", false, nil
+		}
+		return "```Couldn't get that message: " + apiErrorMessage(err) + "```", false, nil
+	}
+	if len(target.Reactions) == 0 {
+		return "```Nobody has reacted to that message.```", false, nil
+	}
+
+	lists := make([]reactionList, 0, len(target.Reactions))
+	people := make(map[string]bool)
+	total := 0
+	for _, r := range target.Reactions {
+		users, err := fetchReactors(m[2], m[3], r.Emoji.APIName())
+		if err != nil && ClassifyAPIError(err) == APIErrorPermission {
+			return "```I don't have permission to see who reacted to that message.```", false, nil
+		}
+		name := r.Emoji.Name
+		if len(r.Emoji.ID) > 0 {
+			name = ":" + r.Emoji.Name + ":"
+		}
+		names := make([]string, 0, len(users))
+		for _, u := range users {
+			names = append(names, u.Username)
+			people[u.ID] = true
+		}
+		lists = append(lists, reactionList{name, r.Count, names})
+		total += len(names)
+	}
+
+	pages := (total + reactionsPageSize - 1) / reactionsPageSize
+	if page > pages {
+		return "```That message only has " + Pluralize(int64(pages), " page") + " of reactions.```", false, nil
+	}
+	s := Pluralize(int64(len(people)), " user") + " reacted:\n"
+	for _, l := range lists {
+		s += fmt.Sprintf("  %s %v\n", l.emoji, l.count)
+	}
+	start := (page - 1) * reactionsPageSize
+	end := start + reactionsPageSize
+	i := 0
+	for _, l := range lists {
+		shown := []string{}
+		for _, n := range l.users {
+			if i >= start && i < end {
+				shown = append(shown, n)
+			}
+			i++
+		}
+		if len(shown) > 0 {
+			s += "\n" + l.emoji + ": " + strings.Join(shown, ", ")
+		}
+		if len(l.users) < l.count && i > start && i <= end {
+			s += fmt.Sprintf(" (and %v more not shown)", l.count-len(l.users))
+		}
+	}
+	if pages > 1 {
+		s += fmt.Sprintf("\n\nPage %v of %v.", page, pages)
+	}
+	return "```\n" + PartialSanitize(s) + "```", false, nil
+}
+func (c *reactionsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: fmt.Sprintf("Lists everyone who reacted to a message, grouped by emoji, %v names per page. Useful for checking who entered a giveaway or said they'd come to an event. At most %v people are looked up for each emoji. Each use can take a lot of requests to discord, so only moderators can use it by default. To let everyone use it, remove it from `modules.commandroles`.", reactionsPageSize, reactionsFetchLimit),
+		Params: []CommandUsageParam{
+			{Name: "message link", Desc: "A link to the message, from Copy Message Link.", Optional: false},
+			{Name: "page", Desc: "Which page of reactors to show. Defaults to 1.", Optional: true},
+		},
+	}
+}
+func (c *reactionsCommand) UsageShort() string {
+	return "Lists who reacted to a message."
+}
@@ -0,0 +1,375 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// EntityKind is the kind of thing a command argument names
+type EntityKind int
+
+// Entity kinds
+const (
+	EntityMember EntityKind = iota
+	EntityRole
+	EntityChannel
+)
+
+func (k EntityKind) String() string {
+	switch k {
+	case EntityRole:
+		return "role"
+	case EntityChannel:
+		return "channel"
+	}
+	return "member"
+}
+
+// No more than this many fuzzy matches are offered, since anything past that means the name was too vague
+const resolveMaxMatches = 50
+
+// The picker shows this many matches at a time
+const resolvePageSize = 10
+
+// How long the picker waits for an answer before giving up
+const resolveTimeout = 60 * time.Second
+
+// A member, role or channel that an argument might refer to
+type ResolvedEntity struct {
+	ID   string
+	Name string // how it's shown when asking which one was meant
+}
+
+// How closely a name matches what was typed. Lower is better, and only the best matches of any name are kept.
+const (
+	matchExact = iota
+	matchPrefix
+	matchContains
+	matchFuzzy
+	matchNone
+)
+
+// Returns how closely name matches arg, which must already be lowercase. Names within a few typos of arg count as fuzzy
+// matches, as long as arg is long enough that it wouldn't match nearly everything.
+func matchName(name string, arg string) int {
+	name = strings.ToLower(name)
+	switch {
+	case len(name) == 0:
+		return matchNone
+	case name == arg:
+		return matchExact
+	case strings.HasPrefix(name, arg):
+		return matchPrefix
+	case strings.Contains(name, arg):
+		return matchContains
+	case len([]rune(arg)) >= 4 && editDistance(name, arg) <= len([]rune(arg))/4:
+		return matchFuzzy
+	}
+	return matchNone
+}
+
+// Collects entities by how well they match, keeping only the best tier
+type entityMatcher struct {
+	arg     string
+	best    int
+	matches []ResolvedEntity
+}
+
+func (e *entityMatcher) add(id string, display string, names ...string) {
+	score := matchNone
+	for _, n := range names {
+		score = min(score, matchName(n, e.arg))
+	}
+	if score == matchNone || score > e.best {
+		return
+	}
+	if score < e.best {
+		e.best = score
+		e.matches = e.matches[:0]
+	}
+	e.matches = append(e.matches, ResolvedEntity{id, display})
+}
+
+func (e *entityMatcher) result() []ResolvedEntity {
+	sort.SliceStable(e.matches, func(i, j int) bool { return strings.ToLower(e.matches[i].Name) < strings.ToLower(e.matches[j].Name) })
+	if len(e.matches) > resolveMaxMatches {
+		return e.matches[:resolveMaxMatches]
+	}
+	return e.matches
+}
+
+// ResolveCandidates returns everything on the server that arg could refer to. A mention or ID only ever matches that
+// entity. Otherwise names are compared without caring about case, and only the closest matches are returned: exact
+// names first, then names starting with arg, then names containing it, then names a few typos away. Also returns
+// true if the matches are certain, because they came from a mention, an ID or an exact name.
+func ResolveCandidates(info *GuildInfo, kind EntityKind, arg string) ([]ResolvedEntity, bool) {
+	arg = strings.TrimSpace(arg)
+	if len(arg) == 0 {
+		return nil, false
+	}
+	guild, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return nil, false
+	}
+	mentioned := ""
+	switch {
+	case kind == EntityMember && userregex.MatchString(arg):
+		mentioned = StripPing(arg)
+	case kind == EntityRole && mentionregex.MatchString(arg) && strings.HasPrefix(arg, "<@&"):
+		mentioned = arg[3 : len(arg)-1]
+	case kind == EntityChannel && channelregex.MatchString(arg):
+		mentioned = arg[2 : len(arg)-1]
+	case len(arg) > 15:
+		if _, err := strconv.ParseUint(arg, 10, 64); err == nil {
+			mentioned = arg
+		}
+	}
+
+	m := &entityMatcher{arg: strings.ToLower(strings.TrimLeft(arg, "#@")), best: matchNone}
+	sb.dg.State.RLock()
+	switch kind {
+	case EntityMember:
+		for _, v := range guild.Members {
+			if v.User == nil {
+				continue
+			}
+			display := v.User.Username
+			if len(v.Nick) > 0 {
+				display = v.Nick + " (" + v.User.Username + ")"
+			}
+			if v.User.ID == mentioned {
+				sb.dg.State.RUnlock()
+				return []ResolvedEntity{{v.User.ID, display}}, true
+			}
+			m.add(v.User.ID, display, v.Nick, v.User.GlobalName, v.User.Username)
+		}
+	case EntityRole:
+		for _, v := range guild.Roles {
+			if v.ID == guild.ID {
+				continue // @everyone
+			}
+			if v.ID == mentioned {
+				sb.dg.State.RUnlock()
+				return []ResolvedEntity{{v.ID, "@" + v.Name}}, true
+			}
+			m.add(v.ID, "@"+v.Name, v.Name)
+		}
+	case EntityChannel:
+		for _, list := range [][]*discordgo.Channel{guild.Channels, guild.Threads} {
+			for _, v := range list {
+				if v.Type == discordgo.ChannelTypeGuildCategory {
+					continue
+				}
+				if v.ID == mentioned {
+					sb.dg.State.RUnlock()
+					return []ResolvedEntity{{v.ID, "#" + v.Name}}, true
+				}
+				m.add(v.ID, "#"+v.Name, v.Name)
+			}
+		}
+	}
+	sb.dg.State.RUnlock()
+
+	if len(mentioned) > 0 {
+		if kind == EntityMember { // Someone who left can still be pinged, so they can still be warned or looked up
+			return []ResolvedEntity{{mentioned, getUserName(SBatoi(mentioned), info)}}, true
+		}
+		return nil, false
+	}
+	if kind == EntityMember && len(m.matches) == 0 {
+		// They may have left the server, or not be in the state yet, so fall back on the names the database remembers
+		for _, id := range FindUsername(arg, info) {
+			m.matches = append(m.matches, ResolvedEntity{SBitoa(id), getUserName(id, info)})
+		}
+	}
+	return m.result(), m.best == matchExact
+}
+
+// Resolve finds the one member, role or channel that arg refers to, returning its ID. If arg matches several, the user
+// who ran the command is asked to pick one, and if it only loosely matches one, they're asked if that's the one they
+// meant. Returns an error message if nothing matches, or nothing was picked.
+func Resolve(info *GuildInfo, msg *discordgo.Message, kind EntityKind, arg string) (string, string) {
+	matches, certain := ResolveCandidates(info, kind, arg)
+	if len(matches) == 0 {
+		return "", "There's no " + kind.String() + " called " + arg + "."
+	}
+	if len(matches) == 1 && certain {
+		return matches[0].ID, ""
+	}
+	answerable := msg != nil && len(msg.ID) > 0 && msg.Author.ID != sb.SelfID // Nobody can answer a prompt for a command the bot ran herself
+	if len(matches) == 1 {
+		if !answerable {
+			return "", "There's no " + kind.String() + " called " + arg + ". Did you mean " + matches[0].Name + " (" + matches[0].ID + ")?"
+		}
+		if !Confirm(info, msg.ChannelID, msg.Author.ID, "There's no "+kind.String()+" called "+SanitizeMentions(arg)+". Did you mean "+SanitizeMentions(matches[0].Name)+"?") {
+			return "", "No " + kind.String() + " was picked."
+		}
+		return matches[0].ID, ""
+	}
+	if !answerable {
+		names := make([]string, 0, len(matches))
+		for _, v := range matches {
+			names = append(names, v.Name+" ("+v.ID+")")
+		}
+		return "", arg + " could be any of these:\n" + strings.Join(names, "\n")
+	}
+	i := Pick(info, msg.ChannelID, msg.Author.ID, "More than one "+kind.String()+" matches "+arg+". Which one did you mean?", matches)
+	if i < 0 {
+		return "", "No " + kind.String() + " was picked."
+	}
+	return matches[i].ID, ""
+}
+
+// A picker waiting for the user who ran the command to choose an option
+type pendingPick struct {
+	user    string
+	prompt  string
+	options []ResolvedEntity
+	page    int
+	answer  chan int
+}
+
+var pickLock sync.Mutex
+var picks = make(map[string]*pendingPick) // by the ID of the picker message
+
+func (p *pendingPick) pages() int {
+	return (len(p.options) + resolvePageSize - 1) / resolvePageSize
+}
+
+func (p *pendingPick) content() string {
+	s := SanitizeMentions(p.prompt)
+	if p.pages() > 1 {
+		s += fmt.Sprintf(" (page %v of %v)", p.page+1, p.pages())
+	}
+	return s + "\n<@" + p.user + ">, you have " + TimeDiff(resolveTimeout) + " to pick."
+}
+
+func (p *pendingPick) components() []discordgo.MessageComponent {
+	start := p.page * resolvePageSize
+	options := make([]discordgo.SelectMenuOption, 0, resolvePageSize)
+	for i, v := range p.options[start:min(start+resolvePageSize, len(p.options))] {
+		options = append(options, discordgo.SelectMenuOption{Label: truncateRunes(v.Name, 100), Value: strconv.Itoa(start + i), Description: v.ID})
+	}
+	buttons := []discordgo.MessageComponent{}
+	if p.pages() > 1 {
+		buttons = append(buttons,
+			discordgo.Button{Label: "Previous", Style: discordgo.SecondaryButton, CustomID: "pick:prev", Disabled: p.page == 0},
+			discordgo.Button{Label: "Next", Style: discordgo.SecondaryButton, CustomID: "pick:next", Disabled: p.page+1 >= p.pages()})
+	}
+	buttons = append(buttons, discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "pick:cancel"})
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{MenuType: discordgo.StringSelectMenu, CustomID: "pick:choose", Placeholder: "Pick one", Options: options},
+		}},
+		discordgo.ActionsRow{Components: buttons},
+	}
+}
+
+// Pick posts a menu of options, a page at a time, and waits for user to choose one. Only that user's answer counts.
+// Returns the index of the option they chose, or -1 if they cancelled or didn't answer within resolveTimeout. The
+// menu is deleted either way.
+func Pick(info *GuildInfo, channel string, user string, prompt string, options []ResolvedEntity) int {
+	p := &pendingPick{user: user, prompt: prompt, options: options, answer: make(chan int, 1)}
+	var msg *discordgo.Message
+	err := CallAPI("ChannelMessageSendComplex", func() (err error) {
+		msg, err = sb.dg.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+			Content:         p.content(),
+			Components:      p.components(),
+			AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{user}},
+		})
+		return
+	})
+	if err != nil {
+		info.LogError("Couldn't post a picker: ", err)
+		return -1
+	}
+	pickLock.Lock()
+	picks[msg.ID] = p
+	pickLock.Unlock()
+	defer func() {
+		pickLock.Lock()
+		delete(picks, msg.ID)
+		pickLock.Unlock()
+		sb.dg.ChannelMessageDelete(channel, msg.ID)
+	}()
+	select {
+	case i := <-p.answer:
+		return i
+	case <-time.After(resolveTimeout):
+		return -1
+	}
+}
+
+// Handles a choice or a page change on a picker. Returns false if the interaction wasn't for one.
+func handlePickInteraction(i *discordgo.Interaction) bool {
+	if i.Type != discordgo.InteractionMessageComponent || i.Message == nil {
+		return false
+	}
+	data := i.MessageComponentData()
+	if !strings.HasPrefix(data.CustomID, "pick:") {
+		return false
+	}
+	p, answer, response, e := takePick(i, data)
+	if len(e) > 0 {
+		respondEphemeral(i, e)
+		return true
+	}
+	// The lock is already released, so a slow response can't hold up every other picker
+	if err := sb.dg.InteractionRespond(i, response); err != nil {
+		metricAPIErrors.Add(ClassifyAPIError(err).String(), 1)
+	}
+	if answer > -2 {
+		select {
+		case p.answer <- answer:
+		default: // They already answered
+		}
+	}
+	return true
+}
+
+// Finds the picker a button press belongs to and works out what it does, while holding pickLock. Returns the picker,
+// the option that was chosen (-1 to cancel, -2 if it just changed pages) and how to respond, or an error message for
+// the user. Once an answer is chosen, the picker is taken out of picks, so nobody else can answer it.
+func takePick(i *discordgo.Interaction, data discordgo.MessageComponentInteractionData) (*pendingPick, int, *discordgo.InteractionResponse, string) {
+	pickLock.Lock()
+	defer pickLock.Unlock()
+	p, ok := picks[i.Message.ID]
+	if !ok {
+		return nil, -2, nil, "This picker has already expired."
+	}
+	if i.Member == nil || i.Member.User.ID != p.user {
+		return nil, -2, nil, "Only the person who ran the command can pick."
+	}
+	answer := -2
+	response := &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate}
+	switch data.CustomID {
+	case "pick:prev", "pick:next":
+		if data.CustomID == "pick:prev" && p.page > 0 {
+			p.page--
+		} else if data.CustomID == "pick:next" && p.page+1 < p.pages() {
+			p.page++
+		}
+		response = &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: p.content(), Components: p.components()},
+		}
+	case "pick:cancel":
+		answer = -1
+	case "pick:choose":
+		if len(data.Values) > 0 {
+			if n, err := strconv.Atoi(data.Values[0]); err == nil && n >= 0 && n < len(p.options) {
+				answer = n
+			}
+		}
+	}
+	if answer > -2 {
+		delete(picks, i.Message.ID)
+	}
+	return p, answer, response, ""
+}
@@ -0,0 +1,166 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestMatchName(t *testing.T) {
+	cases := []struct {
+		name string
+		arg  string
+		want int
+	}{
+		{"Rarity", "rarity", matchExact},
+		{"Rarity", "rar", matchPrefix},
+		{"Pineapple", "apple", matchContains},
+		{"Rarity", "raritty", matchFuzzy},
+		{"Rarity", "rxy", matchNone},
+		{"Fluttershy", "flutershy", matchFuzzy},
+		{"Fluttershy", "twilight", matchNone},
+		{"Sun", "sin", matchNone}, // Too short to count typos
+		{"", "anything", matchNone},
+	}
+	for _, c := range cases {
+		if got := matchName(c.name, c.arg); got != c.want {
+			t.Errorf("matchName(%q, %q) = %v, want %v", c.name, c.arg, got, c.want)
+		}
+	}
+}
+
+func TestEntityMatcher(t *testing.T) {
+	m := &entityMatcher{arg: "apple", best: matchNone}
+	m.add("1", "Pineapple", "Pineapple")
+	m.add("2", "Apple Bloom", "Apple Bloom")
+	m.add("3", "Rarity", "Rarity")
+	m.add("4", "AJ (Applejack)", "AJ", "Applejack")
+	got := m.result()
+	if m.best != matchPrefix || len(got) != 2 || got[0].ID != "4" || got[1].ID != "2" {
+		t.Errorf("prefix matches should replace contains matches and be sorted by name, got %v (tier %v)", got, m.best)
+	}
+	m.add("5", "apple", "apple")
+	if got := m.result(); m.best != matchExact || len(got) != 1 || got[0].ID != "5" {
+		t.Errorf("an exact match should replace prefix matches, got %v (tier %v)", got, m.best)
+	}
+	m.add("6", "Applejack", "Applejack")
+	if got := m.result(); len(got) != 1 {
+		t.Errorf("worse matches shouldn't be added once there's an exact match, got %v", got)
+	}
+
+	m = &entityMatcher{arg: "filler", best: matchNone}
+	for i := 0; i < resolveMaxMatches+10; i++ {
+		m.add(fmt.Sprint(i), fmt.Sprintf("filler%02d", i), fmt.Sprintf("filler%02d", i))
+	}
+	if got := m.result(); len(got) != resolveMaxMatches || got[0].Name != "filler00" {
+		t.Errorf("matches should be capped at %v, got %v", resolveMaxMatches, len(got))
+	}
+}
+
+// Sets up a fake server in the state, so things can be resolved without connecting to discord
+func newTestResolver(t *testing.T) *GuildInfo {
+	old := sb
+	t.Cleanup(func() { sb = old })
+	sb = &SweetieBot{dg: &discordgo.Session{State: discordgo.NewState()}, db: &BotDB{}}
+	guild := &discordgo.Guild{ID: "100000000000000000"}
+	member := func(id string, username string, nick string) *discordgo.Member {
+		return &discordgo.Member{GuildID: guild.ID, Nick: nick, User: &discordgo.User{ID: id, Username: username}}
+	}
+	guild.Members = []*discordgo.Member{
+		member("100000000000000001", "Applejack", "AJ"),
+		member("100000000000000002", "Apple Bloom", ""),
+		member("100000000000000003", "Pineapple", ""),
+		member("100000000000000004", "Rarity", ""),
+	}
+	for i := 0; i < resolveMaxMatches+10; i++ {
+		guild.Members = append(guild.Members, member(fmt.Sprint(100000000000000100+i), fmt.Sprintf("filler%02d", i), ""))
+	}
+	guild.Roles = []*discordgo.Role{
+		{ID: guild.ID, Name: "@everyone"},
+		{ID: "100000000000000200", Name: "Moderator"},
+		{ID: "100000000000000201", Name: "Mod"},
+	}
+	guild.Channels = []*discordgo.Channel{
+		{ID: "100000000000000300", GuildID: guild.ID, Name: "general", Type: discordgo.ChannelTypeGuildText},
+		{ID: "100000000000000301", GuildID: guild.ID, Name: "general-2", Type: discordgo.ChannelTypeGuildText},
+		{ID: "100000000000000302", GuildID: guild.ID, Name: "general", Type: discordgo.ChannelTypeGuildCategory},
+	}
+	if err := sb.dg.State.GuildAdd(guild); err != nil {
+		t.Fatal(err)
+	}
+	return &GuildInfo{ID: guild.ID}
+}
+
+func TestResolveCandidates(t *testing.T) {
+	info := newTestResolver(t)
+	cases := []struct {
+		name    string
+		kind    EntityKind
+		arg     string
+		want    []string
+		certain bool
+	}{
+		{"exact username", EntityMember, "applejack", []string{"100000000000000001"}, true},
+		{"exact nickname", EntityMember, "aj", []string{"100000000000000001"}, true},
+		{"prefix beats contains", EntityMember, "Apple", []string{"100000000000000001", "100000000000000002"}, false},
+		{"contains", EntityMember, "neapple", []string{"100000000000000003"}, false},
+		{"typo", EntityMember, "raritty", []string{"100000000000000004"}, false},
+		{"mention", EntityMember, "<@100000000000000004>", []string{"100000000000000004"}, true},
+		{"nickname mention", EntityMember, "<@!100000000000000001>", []string{"100000000000000001"}, true},
+		{"id", EntityMember, "100000000000000002", []string{"100000000000000002"}, true},
+		{"mention of someone who left", EntityMember, "<@100000000000009999>", []string{"100000000000009999"}, true},
+		{"id of someone who left", EntityMember, "100000000000009999", []string{"100000000000009999"}, true},
+		{"nothing matches", EntityMember, "zzzz", nil, false},
+		{"whitespace", EntityMember, "  ", nil, false},
+		{"role mention", EntityRole, "<@&100000000000000201>", []string{"100000000000000201"}, true},
+		{"exact role beats prefix", EntityRole, "@mod", []string{"100000000000000201"}, true},
+		{"role prefix", EntityRole, "moder", []string{"100000000000000200"}, false},
+		{"everyone isn't a role", EntityRole, "everyone", nil, false},
+		{"unknown role id", EntityRole, "100000000000009999", nil, false},
+		{"channel mention", EntityChannel, "<#100000000000000301>", []string{"100000000000000301"}, true},
+		{"exact channel skips categories", EntityChannel, "#general", []string{"100000000000000300"}, true},
+		{"channel prefix", EntityChannel, "gen", []string{"100000000000000300", "100000000000000301"}, false},
+		{"user mention isn't a channel", EntityChannel, "<@100000000000000001>", nil, false},
+	}
+	for _, c := range cases {
+		got, certain := ResolveCandidates(info, c.kind, c.arg)
+		ids := []string{}
+		for _, v := range got {
+			ids = append(ids, v.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(append([]string{}, c.want...)) || certain != c.certain {
+			t.Errorf("%s: ResolveCandidates(%v, %q) = %v, %v, want %v, %v", c.name, c.kind, c.arg, ids, certain, c.want, c.certain)
+		}
+	}
+
+	if got, certain := ResolveCandidates(info, EntityMember, "filler"); len(got) != resolveMaxMatches || certain {
+		t.Errorf("fuzzy matches should be capped at %v, got %v", resolveMaxMatches, len(got))
+	}
+}
+
+func TestResolveWithoutPrompting(t *testing.T) {
+	info := newTestResolver(t)
+	sb.SelfID = "100000000000000999"
+	msg := &discordgo.Message{ID: "1", Author: &discordgo.User{ID: sb.SelfID}} // The bot can't answer its own prompts
+	cases := []struct {
+		name string
+		kind EntityKind
+		arg  string
+		id   string
+		err  bool
+	}{
+		{"exact", EntityMember, "rarity", "100000000000000004", false},
+		{"mention", EntityRole, "<@&100000000000000200>", "100000000000000200", false},
+		{"single prefix match isn't picked automatically", EntityRole, "moder", "", true},
+		{"single typo isn't picked automatically", EntityMember, "raritty", "", true},
+		{"ambiguous", EntityChannel, "gen", "", true},
+		{"nothing", EntityChannel, "zzzz", "", true},
+	}
+	for _, c := range cases {
+		id, e := Resolve(info, msg, c.kind, c.arg)
+		if id != c.id || (len(e) > 0) != c.err {
+			t.Errorf("%s: Resolve(%q) = %q, %q", c.name, c.arg, id, e)
+		}
+	}
+}
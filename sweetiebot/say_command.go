@@ -0,0 +1,222 @@
+package sweetiebot
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// announcement is a message or embed posted on behalf of a moderator. Scheduled announcements are stored as JSON in the schedule table.
+type announcement struct {
+	Channel string `json:"channel"`
+	Author  string `json:"author"`
+	Embed   bool   `json:"embed"`
+	Title   string `json:"title"`
+	Color   int    `json:"color"`
+	Content string `json:"content"`
+	Persona string `json:"persona"`
+}
+
+func (a *announcement) message() *discordgo.MessageSend {
+	if !a.Embed {
+		return &discordgo.MessageSend{Content: a.Content}
+	}
+	return &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{a.embed()}}
+}
+
+func (a *announcement) embed() *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{Type: "rich", Title: a.Title, Description: a.Content, Color: a.Color}
+}
+
+// Breaks @everyone and @here pings unless the user is allowed to use them in the target channel
+func sanitizeEveryone(s string, user string, channel string) string {
+	perms, err := sb.dg.State.UserChannelPermissions(user, channel)
+	if err == nil && (perms&discordgo.PermissionMentionEveryone) != 0 {
+		return s
+	}
+	s = strings.Replace(s, "@everyone", "@\u200Beveryone", -1)
+	return strings.Replace(s, "@here", "@\u200Bhere", -1)
+}
+
+// Parses the optional [as:persona] [embed [0xCOLOR] "title"] prefix of an announcement, followed by the content. Returns false if there was no content.
+func parseAnnouncement(a *announcement, args []string, indices []int, msg *discordgo.Message) bool {
+	i := 0
+	if i < len(args) && strings.HasPrefix(strings.ToLower(args[i]), "as:") {
+		a.Persona = strings.ToLower(args[i][3:])
+		i++
+	}
+	if i < len(args) && strings.ToLower(args[i]) == "embed" {
+		a.Embed = true
+		a.Color = 0x3e92e5
+		i++
+		if i < len(args) && colorregex.MatchString(args[i]) {
+			color, _ := strconv.ParseUint(args[i][2:], 16, 32)
+			a.Color = int(color)
+			i++
+		}
+		if i+1 < len(args) {
+			a.Title = args[i]
+			i++
+		}
+	}
+	if i >= len(args) {
+		return false
+	}
+	a.Content = sanitizeEveryone(msg.Content[indices[i]:], msg.Author.ID, a.Channel)
+	a.Title = sanitizeEveryone(a.Title, msg.Author.ID, a.Channel)
+	return len(strings.TrimSpace(a.Content)) > 0
+}
+
+// Posts an announcement and returns the ID of the message
+func postAnnouncement(info *GuildInfo, a *announcement) (string, error) {
+	// Variables are filled in when the announcement is posted, so a scheduled one picks up any changes made in the meantime
+	rendered := *a
+	rendered.Content = sanitizeEveryone(renderTemplate(info, a.Content, map[string]string{"channel": "<#" + a.Channel + ">"}), a.Author, a.Channel)
+	rendered.Title = sanitizeEveryone(renderTemplate(info, a.Title, nil), a.Author, a.Channel)
+	m := rendered.message()
+	m.Content = info.sanitizeOutput(m.Content)
+	var posted *discordgo.Message
+	var err error
+	if p, ok := info.persona(a.Persona); ok {
+		posted, err = sendAsPersona(info, a.Channel, p, m)
+	} else {
+		posted, err = sb.dg.ChannelMessageSendComplex(a.Channel, m)
+	}
+	if err != nil {
+		return "", err
+	}
+	return posted.ID, nil
+}
+
+type sayCommand struct {
+}
+
+func (c *sayCommand) Name() string {
+	return "Say"
+}
+func (c *sayCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 2 || !channelregex.MatchString(args[0]) {
+		return "```You must provide a channel and a message.```", false, nil
+	}
+	a := &announcement{Channel: args[0][2 : len(args[0])-1], Author: msg.Author.ID}
+	if !info.HasChannel(a.Channel) {
+		return "```That channel isn't on this server.```", false, nil
+	}
+
+	var t time.Time
+	scheduled := false
+	if len(args) > 2 {
+		if parsed, err := parseCommonTime(args[1], info, msg.Author); err == nil {
+			t = parsed.UTC()
+			scheduled = true
+			if t.Before(time.Now().UTC()) {
+				return "```Error: Cannot schedule an announcement in the past!```", false, nil
+			}
+		}
+	}
+	start := 1
+	if scheduled {
+		start = 2
+	}
+	if !parseAnnouncement(a, args[start:], indices[start:], msg) {
+		return "```You have to tell me to say something, silly!```", false, nil
+	}
+	if _, ok := info.persona(a.Persona); a.Persona != "" && !ok {
+		return "```There's no persona called " + a.Persona + ". Add one with " + info.config.Basic.CommandPrefix + "persona add first.```", false, nil
+	}
+
+	if scheduled {
+		if !sb.db.CheckStatus() {
+			return "```A temporary database outage is preventing this command from being executed.```", false, nil
+		}
+		data, _ := json.Marshal(a)
+		if !sb.db.AddSchedule(SBatoi(info.ID), t, 9, string(data)) {
+			return "```Error: servers can't have more than 5000 events!```", false, nil
+		}
+		info.Log(getUserName(SBatoi(msg.Author.ID), info), " scheduled an announcement in #", getChannelName(a.Channel), " for ", t.Format(time.RFC822))
+		return "```Announcement scheduled for " + TimeDiff(t.Sub(time.Now().UTC())) + " from now.```", false, nil
+	}
+
+	id, err := postAnnouncement(info, a)
+	if err != nil {
+		return "```Error posting announcement: " + err.Error() + "```", false, nil
+	}
+	return "```Posted announcement. Use " + info.config.Basic.CommandPrefix + "editsay " + args[0] + " " + id + " to edit it.```", false, nil
+}
+func (c *sayCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Makes Sweetie Bot post a message or an embed in the given channel, either immediately or at a scheduled time. For example: `" + info.config.Basic.CommandPrefix + "say #announcements \"25 Dec 9:00am\" embed \"Merry Christmas!\" Happy holidays everypony!`. @everyone and @here pings are broken unless you are allowed to use them in that channel.",
+		Params: []CommandUsageParam{
+			{Name: "#channel", Desc: "The channel to post the announcement in.", Optional: false},
+			{Name: "date", Desc: "A date in the format 12 Jun 16 2:10pm, in quotes. If omitted, the announcement is posted immediately.", Optional: true},
+			{Name: "as:persona", Desc: "Posts the announcement under the name and avatar of a persona added with `" + info.config.Basic.CommandPrefix + "persona`, instead of Sweetie Bot's.", Optional: true},
+			{Name: "embed", Desc: "Posts the announcement as an embed instead of a normal message.", Optional: true},
+			{Name: "0xC0L0R", Desc: "Color of the embed box. Only valid after `embed`.", Optional: true},
+			{Name: "title", Desc: "Title of the embed, in quotes. Only valid after `embed`.", Optional: true},
+			{Name: "arbitrary string", Desc: "The message to post.", Optional: false},
+		},
+	}
+}
+func (c *sayCommand) UsageShort() string { return "Posts or schedules an announcement." }
+
+type editSayCommand struct {
+}
+
+func (c *editSayCommand) Name() string {
+	return "EditSay"
+}
+func (c *editSayCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 3 || !channelregex.MatchString(args[0]) {
+		return "```You must provide a channel, a message ID, and the new message.```", false, nil
+	}
+	a := &announcement{Channel: args[0][2 : len(args[0])-1], Author: msg.Author.ID}
+	if !info.HasChannel(a.Channel) {
+		return "```That channel isn't on this server.```", false, nil
+	}
+	old, err := sb.dg.ChannelMessage(a.Channel, args[1])
+	if err != nil {
+		return "```Couldn't find that message: " + err.Error() + "```", false, nil
+	}
+	if !parseAnnouncement(a, args[2:], indices[2:], msg) {
+		return "```You have to tell me to say something, silly!```", false, nil
+	}
+	// Messages posted under a persona come from one of our webhooks, so they have to be edited through it
+	webhook := &discordgo.WebhookEdit{Embeds: &[]*discordgo.MessageEmbed{}}
+	if a.Embed {
+		content := ""
+		webhook.Content = &content
+		webhook.Embeds = &[]*discordgo.MessageEmbed{a.embed()}
+	} else {
+		content := info.sanitizeOutput(a.Content)
+		webhook.Content = &content
+	}
+	persona, err := editAsPersona(old, webhook)
+	if !persona && err == nil {
+		if old.Author == nil || old.Author.ID != sb.SelfID {
+			return "```I can only edit my own messages.```", false, nil
+		}
+		edit := discordgo.NewMessageEdit(a.Channel, old.ID)
+		edit.Content = webhook.Content
+		edit.Embeds = webhook.Embeds
+		_, err = sb.dg.ChannelMessageEditComplex(edit)
+	}
+	if err != nil {
+		return "```Error editing announcement: " + err.Error() + "```", false, nil
+	}
+	info.Log(getUserName(SBatoi(msg.Author.ID), info), " edited announcement ", old.ID, " in #", getChannelName(a.Channel))
+	return "```Edited announcement.```", false, nil
+}
+func (c *editSayCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Replaces the contents of a message that Sweetie Bot posted, such as an announcement made with `" + info.config.Basic.CommandPrefix + "say`. Accepts the same `embed` options as `" + info.config.Basic.CommandPrefix + "say`.",
+		Params: []CommandUsageParam{
+			{Name: "#channel", Desc: "The channel the message is in.", Optional: false},
+			{Name: "message ID", Desc: "The ID of the message to edit.", Optional: false},
+			{Name: "arbitrary string", Desc: "The new message.", Optional: false},
+		},
+	}
+}
+func (c *editSayCommand) UsageShort() string { return "Edits a previous announcement." }
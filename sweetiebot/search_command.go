@@ -30,6 +30,9 @@ func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices [
 	if !sb.db.CheckStatus() {
 		return "```A temporary database outage is preventing this command from being executed.```", false, nil
 	}
+	if !info.config.Privacy.StoreContent {
+		return "```This server has turned off message content storage (privacy.storecontent), so there is no chat log to search.```", false, nil
+	}
 	if c.lock.test_and_set() {
 		return "```Sorry, I'm busy processing another request right now. Please try again later!```", false, nil
 	}
@@ -0,0 +1,122 @@
+package sweetiebot
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// selfTest collects the results of each diagnostic check
+type selfTest struct {
+	lines  []string
+	failed int
+}
+
+func (t *selfTest) check(ok bool, desc string, hint string) bool {
+	if ok {
+		t.lines = append(t.lines, "[PASS] "+desc)
+	} else {
+		t.lines = append(t.lines, "[FAIL] "+desc+"\n       -> "+hint)
+		t.failed++
+	}
+	return ok
+}
+
+func (t *selfTest) warn(desc string) {
+	t.lines = append(t.lines, "[WARN] "+desc)
+}
+
+// Checks that a configured channel exists and that the bot can post in it
+func (t *selfTest) channel(info *GuildInfo, name string, key string, id uint64) {
+	if id == 0 {
+		t.warn(name + " is not set. Use " + info.config.Basic.CommandPrefix + "setconfig " + key + " #channel to set it.")
+		return
+	}
+	channel := SBitoa(id)
+	if !t.check(info.HasChannel(channel), name+" exists", "The channel set in "+key+" was deleted or isn't on this server. Set it to a different channel.") {
+		return
+	}
+	perms, err := sb.dg.State.UserChannelPermissions(sb.SelfID, channel)
+	need := int64(discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks)
+	t.check(err == nil && perms&need == need, name+" is writable", "Give Sweetie Bot the View Channel, Send Messages and Embed Links permissions in #"+getChannelName(channel)+".")
+}
+
+// Checks that a configured role exists and is below the bot's highest role, so the bot can assign it
+func (t *selfTest) role(info *GuildInfo, name string, key string, id uint64, assign bool) {
+	if id == 0 {
+		t.warn(name + " is not set. Use " + info.config.Basic.CommandPrefix + "setconfig " + key + " <role> to set it.")
+		return
+	}
+	r, err := sb.dg.State.Role(info.ID, SBitoa(id))
+	if !t.check(err == nil, name+" exists", "The role set in "+key+" was deleted. Set it to a different role.") || !assign {
+		return
+	}
+	t.check(highestRolePosition(info, sb.SelfID) > r.Position, name+" is below Sweetie Bot's highest role", "Drag Sweetie Bot's role above "+r.Name+" in Server Settings -> Roles, or she won't be able to assign it.")
+}
+
+type selfTestCommand struct {
+}
+
+func (c *selfTestCommand) Name() string {
+	return "SelfTest"
+}
+func (c *selfTestCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if msg.Author.ID != info.OwnerID && !isBotOwner(msg.Author.ID) {
+		return "```Only the owner of this server can run the self test.```", false, nil
+	}
+	t := &selfTest{}
+
+	dbok := sb.db.status.get() && sb.db.db.Ping() == nil
+	t.check(dbok, "Database is connected", "The database is down. Commands that need it will be unavailable until it reconnects, which is attempted every 30 seconds. The bot owner should check the database server.")
+	t.check(sb.Intents.MessageContent, "Message content intent is enabled", "The bot owner has disabled the message content intent, so most features can't see messages. See the intents section of INSTALLATION.md.")
+	t.check(sb.Intents.Members, "Server members intent is enabled", "The bot owner has disabled the server members intent, so joins, leaves and raids can't be detected. See the intents section of INSTALLATION.md.")
+
+	perms, err := getAllPerms(info, sb.SelfID)
+	if t.check(err == nil, "Sweetie Bot's permissions can be read", "Discord hasn't sent the bot's member information yet. Try again in a minute.") {
+		if perms&discordgo.PermissionAdministrator != 0 {
+			t.warn("Sweetie Bot has the Administrator permission, which is more than she needs.")
+		} else {
+			missing, _ := missingPermissions(info, false)
+			for _, v := range missing {
+				t.check(false, "Missing "+v, "Give Sweetie Bot's role this permission, or disable the modules that need it.")
+			}
+			if len(missing) == 0 {
+				t.check(true, "Enabled modules have the permissions they need", "")
+			}
+		}
+		if perms&discordgo.PermissionMentionEveryone != 0 {
+			t.warn("Sweetie Bot has the Mention Everyone permission, so she might be tricked into pinging everyone.")
+		}
+	}
+
+	t.channel(info, "Mod channel", "basic.modchannel", info.config.Basic.ModChannel)
+	t.channel(info, "Log channel", "log.channel", info.config.Log.Channel)
+	if info.config.Basic.BotChannel != 0 {
+		t.channel(info, "Bot channel", "basic.botchannel", info.config.Basic.BotChannel)
+	}
+	if info.config.Users.WelcomeChannel != 0 {
+		t.channel(info, "Welcome channel", "users.welcomechannel", info.config.Users.WelcomeChannel)
+	}
+	t.role(info, "Mod role", "basic.alertrole", info.config.Basic.AlertRole, false)
+	t.role(info, "Silence role", "spam.silentrole", info.config.Spam.SilentRole, true)
+	if info.config.Schedule.BirthdayRole != 0 {
+		t.role(info, "Birthday role", "schedule.birthdayrole", info.config.Schedule.BirthdayRole, true)
+	}
+	if warning := checkFeedback(info); len(warning) > 0 {
+		for _, v := range strings.Split(warning, "\n") {
+			t.warn(v)
+		}
+	}
+
+	summary := "All checks passed!"
+	if t.failed > 0 {
+		summary = Pluralize(int64(t.failed), " check") + " failed."
+	}
+	return "```" + strings.Join(t.lines, "\n") + "\n\n" + summary + "```", len(t.lines) > 15, nil
+}
+func (c *selfTestCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Checks that Sweetie Bot is set up correctly on this server: that the database is connected, she has the permissions she needs, the configured channels exist and she can post in them, and the configured roles exist and are below her own role. Every failed check comes with a hint on how to fix it. Only the server owner can run this.",
+	}
+}
+func (c *selfTestCommand) UsageShort() string { return "Checks for setup problems." }
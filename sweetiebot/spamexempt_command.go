@@ -0,0 +1,218 @@
+package sweetiebot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// The spam filters a channel or role can be exempted from. "all" skips spam detection entirely.
+var spamFilters = map[string]bool{"images": true, "pings": true, "length": true, "lines": true, "repeat": true, "short": true, "roleping": true, "reactions": true, "forwards": true, "spacers": true, "all": true}
+
+// Returns the set of spam filters that don't apply to this message, based on its channel, the author's roles, and whether
+// the author is verified
+func spamExemptions(info *GuildInfo, m *discordgo.Message) map[string]bool {
+	var exempt map[string]bool
+	if len(info.config.Spam.VerifiedRelax) > 0 {
+		user := ""
+		if m.Author != nil {
+			user = m.Author.ID
+		} else if m.Member != nil && m.Member.User != nil {
+			user = m.Member.User.ID
+		}
+		if len(user) > 0 && info.isVerified(SBatoi(user)) {
+			exempt = make(map[string]bool, len(info.config.Spam.VerifiedRelax))
+			for f, relaxed := range info.config.Spam.VerifiedRelax {
+				exempt[f] = relaxed
+			}
+		}
+	}
+	if len(info.config.Spam.Exempt) == 0 {
+		return exempt // Most servers have no exemptions, so don't bother looking anything up
+	}
+	ids := []string{m.ChannelID}
+	if ch, err := sb.dg.State.Channel(m.ChannelID); err == nil && len(ch.ParentID) > 0 {
+		ids = append(ids, ch.ParentID) // Threads and channels inherit the exemptions of their parent
+	}
+	if m.Member != nil {
+		ids = append(ids, m.Member.Roles...)
+	} else if member, err := info.GetMember(m.Author.ID); err == nil {
+		ids = append(ids, member.Roles...)
+	}
+	for _, id := range ids {
+		for f := range info.config.Spam.Exempt[id] {
+			if exempt == nil {
+				exempt = make(map[string]bool)
+			}
+			exempt[f] = true
+		}
+	}
+	return exempt
+}
+
+// Parses a channel, role ping or role name into an ID and a human readable name
+func parseExemptTarget(arg string, info *GuildInfo) (string, string, string) {
+	if channelregex.MatchString(arg) {
+		id := arg[2 : len(arg)-1]
+		if !info.HasChannel(id) {
+			return "", "", "```That channel isn't on this server.```"
+		}
+		return id, "#" + getChannelName(id), ""
+	}
+	r, e := findRole(arg, info)
+	if r == nil {
+		return "", "", e
+	}
+	return r.ID, r.Name, ""
+}
+
+func exemptTargetName(info *GuildInfo, id string) string {
+	if info.HasChannel(id) {
+		return "#" + getChannelName(id)
+	}
+	if r, err := sb.dg.State.Role(info.ID, id); err == nil {
+		return r.Name
+	}
+	return id + " (deleted)"
+}
+
+func sortedFilters(m map[string]bool) string {
+	f := MapToSlice(m)
+	sort.Strings(f)
+	return strings.Join(f, ", ")
+}
+
+func parseSpamFilters(args []string) ([]string, string) {
+	if len(args) == 0 {
+		return []string{"all"}, ""
+	}
+	filters := make([]string, 0, len(args))
+	for _, v := range args {
+		v = strings.ToLower(v)
+		if !spamFilters[v] {
+			return nil, "```" + v + " is not a spam filter. Use images, pings, length, lines, repeat, short, roleping, reactions, forwards, spacers or all.```"
+		}
+		filters = append(filters, v)
+	}
+	return filters, ""
+}
+
+type exemptCommand struct {
+}
+
+func (c *exemptCommand) Name() string {
+	return "Exempt"
+}
+func (c *exemptCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must specify a channel or role to exempt.```", false, nil
+	}
+	id, name, e := parseExemptTarget(args[0], info)
+	if len(id) == 0 {
+		return e, false, nil
+	}
+	filters, e := parseSpamFilters(args[1:])
+	if filters == nil {
+		return e, false, nil
+	}
+	if info.config.Spam.Exempt == nil {
+		info.config.Spam.Exempt = make(map[string]map[string]bool)
+	}
+	if info.config.Spam.Exempt[id] == nil {
+		info.config.Spam.Exempt[id] = make(map[string]bool)
+	}
+	for _, f := range filters {
+		info.config.Spam.Exempt[id][f] = true
+	}
+	info.SaveConfig()
+	info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " exempted ", name, " from spam filters: ", strings.Join(filters, ", "))
+	return "```" + name + " is now exempt from these spam filters: " + sortedFilters(info.config.Spam.Exempt[id]) + "```", false, nil
+}
+func (c *exemptCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Exempts a channel, or anyone with a role, from some or all of the spam filters. For example, `" + info.config.Basic.CommandPrefix + "exempt #bot-commands lines length` stops long messages in #bot-commands from counting as spam, but still catches people pinging or posting images too fast.",
+		Params: []CommandUsageParam{
+			{Name: "#channel/role", Desc: "The channel or role to exempt.", Optional: false},
+			{Name: "filters", Desc: "Any of `images`, `pings`, `length`, `lines`, `repeat`, `short`, `roleping`, `reactions`, `forwards`, `spacers`, or `all`. Defaults to `all`.", Optional: true, Variadic: true},
+		},
+	}
+}
+func (c *exemptCommand) UsageShort() string { return "Exempts a channel or role from spam filters." }
+
+type unexemptCommand struct {
+}
+
+func (c *unexemptCommand) Name() string {
+	return "Unexempt"
+}
+func (c *unexemptCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must specify a channel or role.```", false, nil
+	}
+	id, name, e := parseExemptTarget(args[0], info)
+	if len(id) == 0 {
+		if _, ok := info.config.Spam.Exempt[StripPing(args[0])]; !ok {
+			return e, false, nil
+		}
+		id, name = StripPing(args[0]), args[0] // Allow cleaning up exemptions for deleted channels and roles by ID
+	}
+	if _, ok := info.config.Spam.Exempt[id]; !ok {
+		return "```" + name + " isn't exempt from any spam filters.```", false, nil
+	}
+	if len(args) < 2 {
+		delete(info.config.Spam.Exempt, id)
+	} else {
+		filters, e := parseSpamFilters(args[1:])
+		if filters == nil {
+			return e, false, nil
+		}
+		for _, f := range filters {
+			delete(info.config.Spam.Exempt[id], f)
+		}
+		if len(info.config.Spam.Exempt[id]) == 0 {
+			delete(info.config.Spam.Exempt, id)
+		}
+	}
+	info.SaveConfig()
+	info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " removed spam filter exemptions from ", name)
+	if remaining, ok := info.config.Spam.Exempt[id]; ok {
+		return "```" + name + " is still exempt from: " + sortedFilters(remaining) + "```", false, nil
+	}
+	return "```" + name + " is no longer exempt from any spam filters.```", false, nil
+}
+func (c *unexemptCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Removes spam filter exemptions from a channel or role.",
+		Params: []CommandUsageParam{
+			{Name: "#channel/role", Desc: "The channel or role.", Optional: false},
+			{Name: "filters", Desc: "The filters to stop exempting it from. If omitted, removes all of its exemptions.", Optional: true, Variadic: true},
+		},
+	}
+}
+func (c *unexemptCommand) UsageShort() string { return "Removes spam filter exemptions." }
+
+type exemptionsCommand struct {
+}
+
+func (c *exemptionsCommand) Name() string {
+	return "Exemptions"
+}
+func (c *exemptionsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	lines := []string{}
+	if info.config.Spam.IgnoreRole != 0 {
+		lines = append(lines, exemptTargetName(info, SBitoa(info.config.Spam.IgnoreRole))+": all (spam.ignorerole)")
+	}
+	for id, filters := range info.config.Spam.Exempt {
+		lines = append(lines, exemptTargetName(info, id)+": "+sortedFilters(filters))
+	}
+	if len(lines) == 0 {
+		return "```Nothing is exempt from the spam filters. Moderators and bots are always exempt.```", false, nil
+	}
+	sort.Strings(lines)
+	return "```Spam filter exemptions (moderators and bots are always exempt):\n" + strings.Join(lines, "\n") + "```", len(lines) > 10, nil
+}
+func (c *exemptionsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{Desc: "Lists the channels and roles that are exempt from spam filters, and which filters they are exempt from."}
+}
+func (c *exemptionsCommand) UsageShort() string { return "Lists spam filter exemptions." }
@@ -0,0 +1,157 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// No simulated burst is longer than this, since anything that takes more messages than this to trigger is effectively off
+const spamTestMessages = 20
+
+type spamTestResult struct {
+	filter    string
+	triggered int // which message would have triggered it, or 0 if none did
+	detail    string
+}
+
+type spamTestCommand struct {
+}
+
+func (c *spamTestCommand) Name() string {
+	return "SpamTest"
+}
+
+// Runs a burst of messages through the pressure calculation used by checkSpam, without touching anyone's real pressure.
+// Returns which message pushed the pressure over the limit, or 0, and the highest pressure reached.
+func simulatePressure(info *GuildInfo, channel string, interval int64, msgs []*discordgo.Message) (int, float32) {
+	var pressure, peak float32
+	var lasthash uint64
+	for i, m := range msgs {
+		p := getPressure(info, m, false, nil)
+		hash := contentHash(m.Content)
+		if len(m.Content) > 0 && hash == lasthash {
+			p += info.config.Spam.RepeatPressure
+		}
+		lasthash = hash
+		if i > 0 {
+			pressure = decayPressure(info, pressure, interval)
+		}
+		pressure += channelPressure(info, channel, p)
+		peak = max(peak, pressure)
+		if pressure > info.config.Spam.MaxPressure {
+			return i + 1, peak
+		}
+	}
+	return 0, peak
+}
+
+// Runs a burst of events through a fresh SaturationLimit the same way the short message and role ping filters do
+func simulateSaturation(count int, period int64, interval int64) int {
+	if count <= 0 {
+		return 0
+	}
+	limit := &SaturationLimit{}
+	limit.resize(count)
+	now := time.Now().UTC().Unix()
+	for i := 0; i < spamTestMessages; i++ {
+		limit.append(now + int64(i)*interval/1000)
+		if limit.checkafter(count-1, period) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func spamTestBurst(n int, content func(i int) string, mentions int, attachments int) []*discordgo.Message {
+	msgs := make([]*discordgo.Message, 0, n)
+	for i := 0; i < n; i++ {
+		m := &discordgo.Message{Content: content(i)}
+		for j := 0; j < mentions; j++ {
+			m.Mentions = append(m.Mentions, &discordgo.User{ID: strconv.Itoa(j)})
+		}
+		for j := 0; j < attachments; j++ {
+			m.Attachments = append(m.Attachments, &discordgo.MessageAttachment{})
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+func (c *spamTestCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	channel := msg.ChannelID
+	interval := int64(1000)
+	for _, arg := range args {
+		if channelregex.MatchString(arg) {
+			channel = arg[2 : len(arg)-1]
+		} else if f, err := strconv.ParseFloat(arg, 64); err == nil && f > 0 {
+			interval = int64(f * 1000)
+		} else {
+			return "```" + arg + " isn't a #channel or a number of seconds.```", false, nil
+		}
+	}
+	if !info.HasChannel(channel) {
+		return "```That channel isn't on this server.```", false, nil
+	}
+	spam := &info.config.Spam
+	limit := spam.MaxPressure
+	if override, ok := spam.MaxChannelPressure[SBatoi(channel)]; ok && override > 0 {
+		limit = override
+	}
+	results := []spamTestResult{}
+	pressure := func(filter string, msgs []*discordgo.Message) {
+		n, peak := simulatePressure(info, channel, interval, msgs)
+		// Report pressure against the channel's own limit, which is what channelPressure scaled it by
+		results = append(results, spamTestResult{filter, n, fmt.Sprintf("peak %.1f of %.1f", peak*limit/spam.MaxPressure, limit)})
+	}
+	pressure("burst", spamTestBurst(spamTestMessages, func(i int) string { return "hey, what's everyone up to? " + strconv.Itoa(i) }, 0, 0))
+	pressure("duplicates", spamTestBurst(spamTestMessages, func(i int) string { return "hey, what's everyone up to?" }, 0, 0))
+	pressure("mentions", spamTestBurst(spamTestMessages, func(i int) string { return "look at this " + strconv.Itoa(i) }, 4, 0))
+	pressure("images", spamTestBurst(spamTestMessages, func(i int) string { return "" }, 0, 2))
+	pressure("wall of text", spamTestBurst(5, func(i int) string {
+		return strings.Repeat(strings.Repeat("a", 59)+"\n", 30) + strconv.Itoa(i)
+	}, 0, 0))
+
+	if spam.ShortLength > 0 && spam.ShortCount > 0 {
+		results = append(results, spamTestResult{"short flood", simulateSaturation(spam.ShortCount, spam.ShortTime, interval), fmt.Sprintf("%v messages under %v characters in %vs", spam.ShortCount, spam.ShortLength, spam.ShortTime)})
+	} else {
+		results = append(results, spamTestResult{"short flood", 0, "off"})
+	}
+	if spam.RolePingCount > 0 {
+		detail := fmt.Sprintf("same role %v times in %vs", spam.RolePingCount, spam.RolePingTime)
+		results = append(results, spamTestResult{"role pings", simulateSaturation(spam.RolePingCount, spam.RolePingTime, interval), detail})
+	} else {
+		results = append(results, spamTestResult{"role pings", 0, "off"})
+	}
+	results = append(results, spamTestResult{"caps", 0, "no caps filter, caps only count as length"})
+
+	lines := []string{fmt.Sprintf("Simulated messages %v apart in #%s. Nothing was actually posted.", TimeDiff(time.Duration(interval)*time.Millisecond), getChannelName(channel)), ""}
+	lines = append(lines, fmt.Sprintf("%-13s %-16s %s", "Filter", "Triggered?", "Threshold"))
+	for _, r := range results {
+		triggered := "no"
+		if r.triggered > 0 {
+			triggered = "yes, message " + strconv.Itoa(r.triggered)
+		}
+		lines = append(lines, fmt.Sprintf("%-13s %-16s %s", r.filter, triggered, r.detail))
+	}
+	exempt := spamExemptions(info, &discordgo.Message{ChannelID: channel, Member: &discordgo.Member{}})
+	if len(exempt) > 0 {
+		lines = append(lines, "", "This channel is exempt from: "+strings.Join(MapToSlice(exempt), ", ")+". The results above ignore exemptions.")
+	}
+	return "```\n" + strings.Join(lines, "\n") + "```", false, nil
+}
+func (c *spamTestCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: fmt.Sprintf("Simulates bursts of up to %v messages against the current anti-spam settings and reports which filters would have silenced the sender, and after how many messages. Nothing is posted and nobody's real pressure changes, so this is safe to run while tuning `spam.*` options. Exemptions are ignored.", spamTestMessages),
+		Params: []CommandUsageParam{
+			{Name: "#channel", Desc: "Simulates the messages in this channel, which matters if it has its own `spam.maxchannelpressure`. Defaults to the current channel.", Optional: true},
+			{Name: "seconds", Desc: "How far apart the simulated messages are. Defaults to 1.", Optional: true},
+		},
+	}
+}
+func (c *spamTestCommand) UsageShort() string {
+	return "Tests the anti-spam settings with simulated messages."
+}
@@ -0,0 +1,114 @@
+package sweetiebot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// staffRolesCommand edits basic.modroles, or basic.adminroles if admin is true
+type staffRolesCommand struct {
+	admin bool
+}
+
+func (c *staffRolesCommand) Name() string {
+	if c.admin {
+		return "AdminRoles"
+	}
+	return "ModRoles"
+}
+func (c *staffRolesCommand) roles(info *GuildInfo) *map[string]bool {
+	if c.admin {
+		return &info.config.Basic.AdminRoles
+	}
+	return &info.config.Basic.ModRoles
+}
+func (c *staffRolesCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	what := "moderator"
+	if c.admin {
+		what = "admin"
+	}
+	roles := c.roles(info)
+	if len(args) < 1 || strings.ToLower(args[0]) == "list" {
+		names := make([]string, 0, len(*roles))
+		for id := range *roles {
+			if r, err := sb.dg.State.Role(info.ID, id); err == nil {
+				names = append(names, r.Name)
+			} else {
+				names = append(names, id)
+			}
+		}
+		sort.Strings(names)
+		s := "```"
+		if !c.admin && info.config.Basic.AlertRole != 0 {
+			s += "Alert role: " + roleNames(info, []string{SBitoa(info.config.Basic.AlertRole)}) + "\n"
+		}
+		if len(names) == 0 {
+			s += "No extra " + what + " roles have been set."
+		} else {
+			s += "Extra " + what + " roles: " + strings.Join(names, ", ")
+		}
+		if c.admin {
+			return PartialSanitize(s + "\nThe server owner and anyone with the Administrator permission are always admins.```"), false, nil
+		}
+		return PartialSanitize(s + "\nAnyone with the Manage Messages permission, and every admin, is also a moderator.```"), false, nil
+	}
+	// Otherwise any moderator could make themselves an admin
+	if c.admin && !info.HasAdminRole(msg.Author.ID) {
+		_, isOwner := sb.Owners[SBatoi(msg.Author.ID)]
+		if !isOwner {
+			return "```Only admins can change the admin roles.```", false, nil
+		}
+	}
+	op := strings.ToLower(args[0])
+	if op != "add" && op != "remove" {
+		return "```You must specify add, remove or list.```", false, nil
+	}
+	if len(args) < 2 {
+		return "```You must specify a role.```", false, nil
+	}
+	r, e := findRole(msg.Content[indices[1]:], info)
+	if r == nil {
+		return e, false, nil
+	}
+	CheckMapNilBool(roles)
+	if op == "add" {
+		if r.ID == info.ID {
+			return "```You can't make everyone a " + what + ".```", false, nil
+		}
+		(*roles)[r.ID] = true
+		info.SaveConfig()
+		return "```" + r.Name + " now counts as a " + what + " role.```", false, nil
+	}
+	if !(*roles)[r.ID] {
+		return "```" + r.Name + " isn't one of the " + what + " roles.```", false, nil
+	}
+	delete(*roles, r.ID)
+	info.SaveConfig()
+	return "```" + r.Name + " no longer counts as a " + what + " role.```", false, nil
+}
+func (c *staffRolesCommand) Usage(info *GuildInfo) *CommandUsage {
+	if c.admin {
+		return &CommandUsage{
+			Desc: "Lists, adds or removes the roles that count as admins. Admins can run every command, regardless of `modules.commandroles`. The server owner and anyone with the Administrator permission are always admins. Only admins can change this list.",
+			Params: []CommandUsageParam{
+				{Name: "add|remove|list", Desc: "What to do. If omitted, lists the admin roles.", Optional: true},
+				{Name: "role", Desc: "A role ping or the name of a role.", Optional: true},
+			},
+		}
+	}
+	return &CommandUsage{
+		Desc: "Lists, adds or removes the roles that count as moderators, in addition to `basic.alertrole`. Moderators can run any command restricted to the alert role, and are exempt from the spam filter, word filter and command limits. Anyone with the Manage Messages permission is always a moderator.",
+		Params: []CommandUsageParam{
+			{Name: "add|remove|list", Desc: "What to do. If omitted, lists the moderator roles.", Optional: true},
+			{Name: "role", Desc: "A role ping or the name of a role.", Optional: true},
+		},
+	}
+}
+func (c *staffRolesCommand) UsageShort() string {
+	if c.admin {
+		return "Sets which roles count as admins."
+	}
+	return "Sets which roles count as moderators."
+}
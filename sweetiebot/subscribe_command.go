@@ -0,0 +1,181 @@
+package sweetiebot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Returns the role for a subscription topic, or an error message listing the topics there are
+func getTopicRole(topic string, info *GuildInfo) (*discordgo.Role, string) {
+	topic = strings.ToLower(strings.TrimSpace(topic))
+	id, ok := info.config.Subscriptions.Topics[topic]
+	if !ok {
+		if len(info.config.Subscriptions.Topics) == 0 {
+			return nil, "```This server doesn't have any topics to subscribe to.```"
+		}
+		return nil, "```" + topic + " isn't a topic. You can subscribe to: " + strings.Join(topicNames(info), ", ") + "```"
+	}
+	role, err := sb.dg.State.Role(info.ID, id)
+	if err != nil {
+		return nil, "```The role for " + topic + " doesn't exist anymore. Ask a moderator to fix it.```"
+	}
+	if highestRolePosition(info, sb.SelfID) <= role.Position {
+		return nil, "```I can't assign " + role.Name + " because it is above my highest role. Ask a moderator to fix it.```"
+	}
+	return role, ""
+}
+
+func topicNames(info *GuildInfo) []string {
+	names := make([]string, 0, len(info.config.Subscriptions.Topics))
+	for k := range info.config.Subscriptions.Topics {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type subscribeCommand struct {
+	unsubscribe bool
+}
+
+func (c *subscribeCommand) Name() string {
+	if c.unsubscribe {
+		return "Unsubscribe"
+	}
+	return "Subscribe"
+}
+func (c *subscribeCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You have to give a topic. Use " + info.config.Basic.CommandPrefix + "subscriptions to see them all.```", false, nil
+	}
+	topic := strings.ToLower(msg.Content[indices[0]:])
+	role, e := getTopicRole(topic, info)
+	if role == nil {
+		return e, false, nil
+	}
+	has := info.UserHasRole(msg.Author.ID, role.ID)
+	if c.unsubscribe {
+		if !has {
+			return "```You aren't subscribed to " + topic + ".```", false, nil
+		}
+		if err := CallAPI("GuildMemberRoleRemove", func() error { return sb.dg.GuildMemberRoleRemove(info.ID, msg.Author.ID, role.ID) }); err != nil {
+			return apiErrorMessage(err), false, nil
+		}
+		return "```You won't be pinged for " + topic + " anymore.```", false, nil
+	}
+	if has {
+		return "```You're already subscribed to " + topic + ".```", false, nil
+	}
+	if err := CallAPI("GuildMemberRoleAdd", func() error { return sb.dg.GuildMemberRoleAdd(info.ID, msg.Author.ID, role.ID) }); err != nil {
+		return apiErrorMessage(err), false, nil
+	}
+	return "```You'll now be pinged for " + topic + ". Use " + info.config.Basic.CommandPrefix + "unsubscribe " + topic + " to stop.```", false, nil
+}
+func (c *subscribeCommand) Usage(info *GuildInfo) *CommandUsage {
+	if c.unsubscribe {
+		return &CommandUsage{
+			Desc:   "Stops you from being pinged for announcements about a topic.",
+			Params: []CommandUsageParam{{Name: "topic", Desc: "The topic to unsubscribe from.", Optional: false}},
+		}
+	}
+	return &CommandUsage{
+		Desc:   "Gives you the ping role for a topic, so you get pinged when there's an announcement about it. Use `" + info.config.Basic.CommandPrefix + "subscriptions` to see every topic.",
+		Params: []CommandUsageParam{{Name: "topic", Desc: "The topic to subscribe to.", Optional: false}},
+	}
+}
+func (c *subscribeCommand) UsageShort() string {
+	if c.unsubscribe {
+		return "Stops pinging you for a topic."
+	}
+	return "Pings you for announcements about a topic."
+}
+
+type subscriptionsCommand struct {
+}
+
+func (c *subscriptionsCommand) Name() string {
+	return "Subscriptions"
+}
+func (c *subscriptionsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	names := topicNames(info)
+	if len(names) == 0 {
+		return "```This server doesn't have any topics to subscribe to.```", false, nil
+	}
+	subscribed := []string{}
+	available := []string{}
+	for _, k := range names {
+		if info.UserHasRole(msg.Author.ID, info.config.Subscriptions.Topics[k]) {
+			subscribed = append(subscribed, k)
+		} else {
+			available = append(available, k)
+		}
+	}
+	s := "```"
+	if len(subscribed) == 0 {
+		s += "You aren't subscribed to anything."
+	} else {
+		s += "You're subscribed to: " + strings.Join(subscribed, ", ")
+	}
+	if len(available) > 0 {
+		s += "\nYou can also subscribe to: " + strings.Join(available, ", ")
+	}
+	return s + "```", false, nil
+}
+func (c *subscriptionsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{Desc: "Lists the topics you are subscribed to, and the ones you can subscribe to with `" + info.config.Basic.CommandPrefix + "subscribe`."}
+}
+func (c *subscriptionsCommand) UsageShort() string { return "Lists your subscriptions." }
+
+type setTopicCommand struct {
+}
+
+func (c *setTopicCommand) Name() string {
+	return "SetTopic"
+}
+func (c *setTopicCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You have to give a topic name, like `" + info.config.Basic.CommandPrefix + "settopic events @Event Pings`.```", false, nil
+	}
+	topic := strings.ToLower(args[0])
+	CheckMapNilString(&info.config.Subscriptions.Topics)
+	if len(args) < 2 {
+		if _, ok := info.config.Subscriptions.Topics[topic]; !ok {
+			return "```" + topic + " isn't a topic.```", false, nil
+		}
+		delete(info.config.Subscriptions.Topics, topic)
+		info.SaveConfig()
+		return "```Removed the " + topic + " topic. Its role wasn't deleted, and anyone subscribed still has it.```", false, nil
+	}
+	role, e := findRole(msg.Content[indices[1]:], info)
+	if role == nil {
+		return e, false, nil
+	}
+	id := SBatoi(role.ID)
+	if id == info.config.Spam.SilentRole || id == info.config.Basic.AlertRole || role.Managed || role.ID == info.ID {
+		return "```" + role.Name + " can't be used as a ping role.```", false, nil
+	}
+	if role.Permissions&roleMenuDangerousPerms != 0 {
+		return "```" + role.Name + " has moderator permissions, so anyone could give themselves moderator powers by subscribing.```", false, nil
+	}
+	if highestRolePosition(info, sb.SelfID) <= role.Position {
+		return "```I can't assign " + role.Name + " because it is above my highest role.```", false, nil
+	}
+	if msg.Author.ID != info.OwnerID && highestRolePosition(info, msg.Author.ID) <= role.Position {
+		return "```You can't use " + role.Name + " because it is above your highest role.```", false, nil
+	}
+	info.config.Subscriptions.Topics[topic] = role.ID
+	info.SaveConfig()
+	return "```Members can now use " + info.config.Basic.CommandPrefix + "subscribe " + topic + " to get " + role.Name + ".```", false, nil
+}
+func (c *setTopicCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Adds a topic members can subscribe to, which gives them a role to ping for announcements about that topic. Roles with moderator permissions can't be used. If no role is given, removes the topic.",
+		Params: []CommandUsageParam{
+			{Name: "topic", Desc: "A one word name for the topic, like `events` or `giveaways`.", Optional: false},
+			{Name: "role", Desc: "The name of the ping role, or a ping of it.", Optional: true},
+		},
+	}
+}
+func (c *setTopicCommand) UsageShort() string { return "Sets up a subscription topic." }
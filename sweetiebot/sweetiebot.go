@@ -25,14 +25,21 @@ type BotConfig struct {
 		IgnoreInvalidCommands bool                       `json:"ignoreinvalidcommands"`
 		Importable            bool                       `json:"importable"`
 		AlertRole             uint64                     `json:"alertrole"`
+		ModRoles              map[string]bool            `json:"modroles"`
+		AdminRoles            map[string]bool            `json:"adminroles"`
 		ModChannel            uint64                     `json:"modchannel"`
 		FreeChannels          map[string]bool            `json:"freechannels"`
 		BotChannel            uint64                     `json:"botchannel"`
 		Aliases               map[string]string          `json:"aliases"`
 		Collections           map[string]map[string]bool `json:"collections"`
+		Variables             map[string]string          `json:"variables"`
+		Personas              map[string]Persona         `json:"personas"`
 		ListenToBots          bool                       `json:"listentobots"`
 		CommandPrefix         string                     `json:"commandprefix"`
 		TrackUserLeft         bool                       `json:"trackuserleft"`
+		MentionCommands       bool                       `json:"mentioncommands"`
+		MentionResponse       string                     `json:"mentionresponse"`
+		MaxMessageParts       int                        `json:"maxmessageparts"`
 	} `json:"basic"`
 	Modules struct {
 		Channels           map[string]map[string]bool `json:"modulechannels"`
@@ -43,25 +50,55 @@ type BotConfig struct {
 		CommandDisabled    map[string]bool            `json:"commanddisabled"`
 		CommandPerDuration int                        `json:"commandperduration"`
 		CommandMaxDuration int64                      `json:"commandmaxduration"`
+		DeniedResponse     string                     `json:"deniedresponse"`
 	} `json:"modules"`
 	Spam struct {
-		ImagePressure      float32            `json:"imagepressure"`
-		PingPressure       float32            `json:"pingpressure"`
-		LengthPressure     float32            `json:"lengthpressure"`
-		RepeatPressure     float32            `json:"repeatpressure"`
-		LinePressure       float32            `json:"linepressure"`
-		BasePressure       float32            `json:"basepressure"`
-		PressureDecay      float32            `json:"pressuredecay"`
-		MaxPressure        float32            `json:"maxpressure"`
-		MaxChannelPressure map[uint64]float32 `json:"maxchannelpressure"`
-		MaxRemoveLookback  int                `json:"MaxSpamRemoveLookback"`
-		SilentRole         uint64             `json:"silentrole"`
-		IgnoreRole         uint64             `json:"ignorerole"`
-		RaidTime           int64              `json:"maxraidtime"`
-		RaidSize           int                `json:"raidsize"`
-		SilenceMessage     string             `json:"silencemessage"`
-		AutoSilence        int                `json:"autosilence"`
-		LockdownDuration   int                `json:"lockdownduration"`
+		ImagePressure      float32                    `json:"imagepressure"`
+		PingPressure       float32                    `json:"pingpressure"`
+		LengthPressure     float32                    `json:"lengthpressure"`
+		RepeatPressure     float32                    `json:"repeatpressure"`
+		LinePressure       float32                    `json:"linepressure"`
+		BasePressure       float32                    `json:"basepressure"`
+		PressureDecay      float32                    `json:"pressuredecay"`
+		MaxPressure        float32                    `json:"maxpressure"`
+		MaxChannelPressure map[uint64]float32         `json:"maxchannelpressure"`
+		MaxRemoveLookback  int                        `json:"MaxSpamRemoveLookback"`
+		SilentRole         uint64                     `json:"silentrole"`
+		IgnoreRole         uint64                     `json:"ignorerole"`
+		RaidTime           int64                      `json:"maxraidtime"`
+		RaidSize           int                        `json:"raidsize"`
+		SilenceMessage     string                     `json:"silencemessage"`
+		AutoSilence        int                        `json:"autosilence"`
+		LockdownDuration   int                        `json:"lockdownduration"`
+		EditGrace          int64                      `json:"editgrace"`
+		Exempt             map[string]map[string]bool `json:"exempt"`
+		ShortLength        int                        `json:"shortlength"`
+		ShortCount         int                        `json:"shortcount"`
+		ShortTime          int64                      `json:"shorttime"`
+		ShortAllowReplies  bool                       `json:"shortallowreplies"`
+		ShortAllowFiles    bool                       `json:"shortallowfiles"`
+		RolePingCount      int                        `json:"rolepingcount"`
+		RolePingTime       int64                      `json:"rolepingtime"`
+		RolePingCooldown   int64                      `json:"rolepingcooldown"`
+		RolePingSilence    bool                       `json:"rolepingsilence"`
+		ReactionCount      int                        `json:"reactioncount"`
+		ReactionTime       int64                      `json:"reactiontime"`
+		ReactionSilence    bool                       `json:"reactionsilence"`
+		ForwardCount       int                        `json:"forwardcount"`
+		ForwardTime        int64                      `json:"forwardtime"`
+		ForwardSilence     bool                       `json:"forwardsilence"`
+		SpacerLines        int                        `json:"spacerlines"`
+		SpacerWarn         bool                       `json:"spacerwarn"`
+		FingerprintSize    int                        `json:"fingerprintsize"`
+		FingerprintTime    int64                      `json:"fingerprinttime"`
+		FingerprintEdits   int                        `json:"fingerprintedits"`
+		FingerprintCreated int64                      `json:"fingerprintcreated"`
+		ActionNotify       bool                       `json:"actionnotify"`
+		ActionMessage      string                     `json:"actionmessage"`
+		VerifiedDays       int64                      `json:"verifieddays"`
+		VerifiedMessages   int                        `json:"verifiedmessages"`
+		VerifiedRelax      map[string]bool            `json:"verifiedrelax"`
+		SilenceNewChannels bool                       `json:"silencenewchannels"`
 	} `json:"spam"`
 	Bucket struct {
 		MaxItems       int `json:"maxbucket"`
@@ -80,7 +117,20 @@ type BotConfig struct {
 		WelcomeChannel   uint64          `json:"welcomechannel"`
 		WelcomeMessage   string          `json:"welcomemessage"`
 		Roles            map[uint64]bool `json:"userroles"`
+		TempRoleRejoin   bool            `json:"temprolerejoin"`
+		MigrateStrategy  string          `json:"migratestrategy"`
 	} `json:"users"`
+	WelcomeCard struct {
+		Enabled    bool   `json:"enabled"`
+		Background string `json:"background"`
+		AvatarX    int    `json:"avatarx"`
+		AvatarY    int    `json:"avatary"`
+		AvatarSize int    `json:"avatarsize"`
+		TextX      int    `json:"textx"`
+		TextY      int    `json:"texty"`
+		TextScale  int    `json:"textscale"`
+		TextColor  string `json:"textcolor"`
+	} `json:"welcomecard"`
 	Bored struct {
 		Cooldown int64           `json:"maxbored"`
 		Commands map[string]bool `json:"boredcommands"`
@@ -90,12 +140,16 @@ type BotConfig struct {
 		HideNegativeRules bool           `json:"hidenegativerules"`
 	} `json:"help"`
 	Log struct {
-		Cooldown int64  `json:"maxerror"`
-		Channel  uint64 `json:"logchannel"`
+		Cooldown int64             `json:"maxerror"`
+		Channel  uint64            `json:"logchannel"`
+		Roles    bool              `json:"logroles"`
+		Channels map[string]string `json:"channels"`
+		Invites  bool              `json:"invites"`
 	} `json:"log"`
 	Witty struct {
 		Responses map[string]string `json:"witty"`
 		Cooldown  int64             `json:"maxwit"`
+		Persona   string            `json:"persona"`
 	} `json:"Wit"`
 	Schedule struct {
 		BirthdayRole uint64 `json:"birthdayrole"`
@@ -112,6 +166,180 @@ type BotConfig struct {
 	Quote struct {
 		Quotes map[uint64][]string `json:"quotes"`
 	} `json:"quote"`
+	Profile struct {
+		Nicknames bool  `json:"lognicknames"`
+		Usernames bool  `json:"logusernames"`
+		Avatars   bool  `json:"logavatars"`
+		Debounce  int64 `json:"debounce"`
+	} `json:"profile"`
+	Privacy struct {
+		StoreContent    bool            `json:"storecontent"`
+		CacheSize       int             `json:"cachesize"`
+		CacheTTL        int64           `json:"cachettl"`
+		ExcludeChannels map[string]bool `json:"excludechannels"`
+	} `json:"privacy"`
+	Filter struct {
+		Words     map[string]bool   `json:"words"` // maps each blocked entry to true if it only matches whole words
+		Allow     map[string]bool   `json:"allow"`
+		Warn      bool              `json:"warn"`
+		Severity  map[string]string `json:"severity"` // maps each blocked entry to mild, strong or slur
+		Actions   map[string]string `json:"actions"`
+		Channels  map[string]bool   `json:"channels"`
+		SlursOnly bool              `json:"slursonly"`
+	} `json:"filter"`
+	Pinboard struct {
+		Emoji   string          `json:"emoji"`
+		Roles   map[string]bool `json:"roles"`
+		Channel uint64          `json:"channel"`
+	} `json:"pinboard"`
+	Approval struct {
+		Enabled bool   `json:"enabled"`
+		Channel uint64 `json:"channel"`
+		Tenure  int64  `json:"tenure"`
+	} `json:"approval"`
+	Warnings struct {
+		DecayDays   int64  `json:"decaydays"`
+		DecayCurve  string `json:"decaycurve"`
+		SelfService bool   `json:"selfservice"`
+		Appeal      string `json:"appeal"`
+	} `json:"warnings"`
+	AutoThread struct {
+		Channels  map[string]bool `json:"channels"`
+		Match     string          `json:"match"`
+		Name      string          `json:"name"`
+		MinLength int             `json:"minlength"`
+		Archive   int             `json:"archive"`
+	} `json:"autothread"`
+	Colors struct {
+		Anchor  uint64            `json:"anchor"`
+		Palette map[string]string `json:"palette"`
+	} `json:"colors"`
+	AutoReact struct {
+		Channels map[string]string `json:"channels"`
+	} `json:"autoreact"`
+	Sticky struct {
+		Messages int   `json:"messages"`
+		Delay    int64 `json:"delay"`
+	} `json:"sticky"`
+	RoleMenus struct {
+		Menus map[string]*RoleMenu `json:"menus"`
+	} `json:"rolemenus"`
+	TempVoice struct {
+		Hubs      map[string]bool `json:"hubs"`
+		Name      string          `json:"name"`
+		UserLimit int             `json:"userlimit"`
+	} `json:"tempvoice"`
+	Snipe struct {
+		MaxAge int64 `json:"maxage"`
+	} `json:"snipe"`
+	Phishing struct {
+		Enabled bool            `json:"enabled"`
+		Silence bool            `json:"silence"`
+		Block   map[string]bool `json:"block"`
+		Allow   map[string]bool `json:"allow"`
+	} `json:"phishing"`
+	Secrets struct {
+		Enabled bool            `json:"enabled"`
+		Ignore  map[string]bool `json:"ignore"`
+		Message string          `json:"message"`
+	} `json:"secrets"`
+	Polls struct {
+		Chart bool `json:"chart"`
+	} `json:"polls"`
+	Bump struct {
+		Enabled  bool   `json:"enabled"`
+		Bot      uint64 `json:"bot"`
+		Channel  uint64 `json:"channel"`
+		Pattern  string `json:"pattern"`
+		Cooldown int64  `json:"cooldown"`
+		Role     uint64 `json:"role"`
+		Message  string `json:"message"`
+	} `json:"bump"`
+	Subscriptions struct {
+		Topics map[string]string `json:"topics"`
+	} `json:"subscriptions"`
+	RulesGate struct {
+		Enabled bool   `json:"enabled"`
+		Channel uint64 `json:"channel"`
+		Message uint64 `json:"message"`
+		Emoji   string `json:"emoji"`
+		Role    uint64 `json:"role"`
+		Timeout int64  `json:"timeout"`
+	} `json:"rulesgate"`
+	Nicknames struct {
+		Dehoist    bool   `json:"dehoist"`
+		Decancer   bool   `json:"decancer"`
+		Blank      bool   `json:"blank"`
+		Fallback   string `json:"fallback"`
+		BypassRole uint64 `json:"bypassrole"`
+	} `json:"nicknames"`
+	Limits struct {
+		Quotes           int `json:"quotes"`
+		Reminders        int `json:"reminders"`
+		ChannelReminders int `json:"channelreminders"`
+		Triggers         int `json:"triggers"`
+		CollectionItems  int `json:"collectionitems"`
+	} `json:"limits"`
+	NewChannels struct {
+		Announce       uint64                                      `json:"announce"`
+		ApplyTemplates bool                                        `json:"applytemplates"`
+		Templates      map[string][]*discordgo.PermissionOverwrite `json:"templates"`
+	} `json:"newchannels"`
+	Boosts struct {
+		Channel    uint64 `json:"channel"`
+		Message    string `json:"message"`
+		PerkRole   uint64 `json:"perkrole"`
+		RevokePerk bool   `json:"revokeperk"`
+		Grace      int64  `json:"grace"`
+		Milestone  int    `json:"milestone"`
+	} `json:"boosts"`
+	QuietHours struct {
+		Enabled bool `json:"enabled"`
+		Start   int  `json:"start"`
+		End     int  `json:"end"`
+		Queue   bool `json:"queue"`
+	} `json:"quiethours"`
+	Suggestions struct {
+		Channel     uint64 `json:"channel"`
+		Implemented uint64 `json:"implemented"`
+		Upvote      string `json:"upvote"`
+		Downvote    string `json:"downvote"`
+	} `json:"suggestions"`
+	Inactivity struct {
+		Role    uint64   `json:"role"`
+		Days    int64    `json:"days"`
+		DM      bool     `json:"dm"`
+		Message string   `json:"message"`
+		Exempt  []uint64 `json:"exempt"`
+	} `json:"inactivity"`
+	GhostPing struct {
+		Window  int64  `json:"window"`
+		Warn    bool   `json:"warn"`
+		Message string `json:"message"`
+	} `json:"ghostping"`
+	Digest struct {
+		Channel  uint64   `json:"channel"`
+		Weekly   bool     `json:"weekly"`
+		Day      int      `json:"day"`
+		Hour     int      `json:"hour"`
+		Sections []string `json:"sections"`
+	} `json:"digest"`
+	Chatter struct {
+		Messages int   `json:"messages"`
+		Period   int64 `json:"period"`
+	} `json:"chatter"`
+	Feedback struct {
+		Mode    string `json:"mode"`
+		Success string `json:"success"`
+		Failure string `json:"failure"`
+		Working string `json:"working"`
+	} `json:"feedback"`
+	AutoMod struct {
+		Rules    map[string]string `json:"rules"` // maps the ID of each AutoMod rule to the tier its blocked messages count as
+		Default  string            `json:"default"`
+		SyncTier string            `json:"synctier"`
+		SyncRule uint64            `json:"syncrule"` // the AutoMod rule made by !syncautomod
+	} `json:"automod"`
 }
 
 // ConfigHelp is a map of help strings for the configuration options above
@@ -119,11 +347,18 @@ var ConfigHelp map[string]string = map[string]string{
 	"basic.ignoreinvalidcommands": "If true, Sweetie Bot won't display an error if a nonsensical command is used. This helps her co-exist with other bots that also use the `!` prefix.",
 	"basic.importable":            "If true, the collections on this server will be importable into another server where sweetie is.",
 	"basic.alertrole":             "This is intended to point at a moderator role shared by all admins and moderators of the server for notification purposes.",
+	"basic.modroles":              "Every role that counts as a moderator, in addition to `basic.alertrole`. Anyone with the Manage Messages permission also counts as a moderator. Moderators can run any command restricted to `basic.alertrole`, and are exempt from the same things `basic.alertrole` is. Use `!modroles` to change this.",
+	"basic.adminroles":            "Every role that counts as an admin. The server owner and anyone with the Administrator permission are always admins. Admins can run every command, regardless of `modules.commandroles`, and are also moderators. Use `!adminroles` to change this.",
 	"basic.modchannel":            "This should point at the hidden moderator channel, or whatever channel moderates want to be notified on.",
 	"basic.freechannels":          "This is a list of all channels that are exempt from rate limiting. Usually set to the dedicated `#botabuse` channel in a server.",
 	"basic.botchannel":            "This allows you to designate a particular channel for sweetie bot to point users to if they are trying to run too many commands at once. Usually this channel will also be included in `basic.freechannels`",
 	"basic.aliases":               "Can be used to redirect commands, such as making `!listgroup` call the `!listgroups` command. Useful for making shortcuts.\n\nExample: `!setconfig basic.aliases kawaii \"pick cute\"` sets an alias mapping `!kawaii arg1...` to `!pick cute arg1...`, preserving all arguments that are passed to the alias.",
 	"basic.collections":           "All the collections used by sweetiebot. Manipulate it via `!add` and `!remove`",
+	"basic.variables":             "Values that replace `{name}` in the welcome message, witty responses, announcements, bump reminders and the anti-spam action message. Should be configured using `!setvar` and `!delvar`.",
+	"basic.personas":              "Names and avatars that `!say` announcements and witty responses can be posted under, through a webhook. Should be configured using `!persona`.",
+	"basic.maxmessageparts":       "Responses longer than discord's 2000 character limit are split into several messages, but only this many are posted. Anything past that is cut off with a note saying so. If 0, long responses are never cut off. Command replies sent in private messages are never cut off. Default: 0",
+	"basic.mentionresponse":       "What Sweetie Bot says when someone pings her without asking for anything, at most once a minute in each channel. {user} is replaced with a ping of them, {username} with their name, {channel} with the channel, and {prefix} with the command prefix. Nothing is said if `basic.ignoreinvalidcommands` is true. Default: Hi {user}! My command prefix here is `{prefix}`. Use `{prefix}help` to see everything I can do.",
+	"basic.mentioncommands":       "If true, pinging the bot followed by a request like `@Sweetie mute @user for 10 minutes` or `@Sweetie roll 1d6` runs the matching command, as if it had been typed with the command prefix. Requests that can't be understood get a hint, at most once a minute in each channel, unless `basic.ignoreinvalidcommands` is true. Default: true",
 	"basic.listentobots":          "If true, sweetiebot will process bot messages and allow them to run commands. Bots can never trigger anti-spam. Defaults to false.",
 	"basic.commandprefix":         "Determines the SINGLE ASCII CHARACTER prefix used to denote sweetiebot commands. You can't set it to an emoji or any weird foreign character. The default is `!`. If this is set to an invalid value, Sweetiebot will default to using `!`.",
 	"basic.trackuserleft":         "If true, sweetiebot will also track users that leave the server if autosilence is set to alert or log. Defaults to false.",
@@ -133,6 +368,7 @@ var ConfigHelp map[string]string = map[string]string{
 	"modules.commanddisabled":     "A list of disabled commands.",
 	"modules.commandperduration":  "Maximum number of commands that can be run within `commandmaxduration` seconds. Default: 3",
 	"modules.commandmaxduration":  "Default: 20. This means that by default, at most 3 commands can be run every 20 seconds.",
+	"modules.deniedresponse":      "What happens when someone runs a command they aren't allowed to: `public` replies in the channel, `dm` tells them privately, and `silent` ignores them. Each person is told at most once a minute. Default: public",
 	"modules.disabled":            "A list of disabled modules.",
 	"modules.channels":            "A mapping of what channels a given module can operate on. If no mapping is given, a module operates on all channels. If \"!\" is included as a channel, it switches from a whitelist to a blacklist, enabling you to exclude certain channels instead of allow certain channels.",
 	"spam.imagepressure":          "Additional pressure generated by each image, link or attachment in a message. Defaults to (MaxPressure - BasePressure) / 6, instantly silencing anyone posting 6 or more links at once.",
@@ -145,13 +381,42 @@ var ConfigHelp map[string]string = map[string]string{
 	"spam.maxchannelpressure":     "Per-channel pressure override. If a channel's pressure is specified in this map, it will override the global maxpressure setting.",
 	"spam.pressuredecay":          "The number of seconds it takes for a user to lose Spam.BasePressure from their pressure amount. Defaults to 2.5, so after sending 3 messages, it will take 7.5 seconds for their pressure to return to 0.",
 	"spam.maxremovelookback":      "Number of seconds back the bot should delete messages of a silenced user on the channel they spammed on. If set to 0, the bot will only delete the message that caused the user to be silenced. If less than 0, the bot won't delete any messages.",
+	"spam.exempt":                 "Maps channel and role IDs to the spam filters they are exempt from: images, pings, length, lines, repeat, short, or all. Use `!exempt` and `!unexempt` to manage this.",
+	"spam.shortlength":            "If set, anyone posting `spam.shortcount` messages with fewer than this many characters within `spam.shorttime` seconds is silenced. Messages with nothing but an embed or sticker count as empty. Short messages are normal in a lot of channels, so this is off by default. Default: 0",
+	"spam.shortcount":             "How many short messages have to be posted within `spam.shorttime` seconds before the poster is silenced. Default: 5",
+	"spam.shorttime":              "Number of seconds `spam.shortcount` short messages have to be posted in to count as flooding. Default: 10",
+	"spam.shortallowreplies":      "If true, replies never count as short messages.",
+	"spam.shortallowfiles":        "If true, messages with attachments never count as short messages.",
+	"spam.rolepingcount":          "If someone pings the same role this many times within `spam.rolepingtime` seconds, their message is deleted and they can't ping that role again until `spam.rolepingcooldown` seconds have passed. Moderators are exempt. If 0, role pings are only limited by `spam.pingpressure`. Default: 3",
+	"spam.rolepingtime":           "Number of seconds `spam.rolepingcount` pings of the same role have to be sent within to count as abuse. Default: 600",
+	"spam.rolepingcooldown":       "Number of seconds someone who abused a role ping is blocked from pinging that role. Default: 3600",
+	"spam.rolepingsilence":        "If true, anyone who abuses a role ping is silenced instead of just being blocked from pinging the role.",
+	"spam.reactioncount":          "If someone adds this many reactions within `spam.reactiontime` seconds, their recent reactions are removed and the moderators are alerted with links to the messages they targeted. Moderators are exempt. If 0, reactions aren't checked. Default: 10",
+	"spam.reactiontime":           "Number of seconds `spam.reactioncount` reactions have to be added within to count as spam. Default: 5",
+	"spam.reactionsilence":        "If true, anyone caught spamming reactions is also silenced.",
+	"spam.forwardcount":           "If someone posts this many forwarded messages or messages with nothing but stickers within `spam.forwardtime` seconds, the last one is deleted and the moderators are alerted. Moderators are exempt, and roles or channels can be exempted with `!exempt ... forwards`. If 0, they aren't checked. Default: 4",
+	"spam.forwardtime":            "Number of seconds `spam.forwardcount` forwarded or sticker messages have to be posted within to count as spam. Default: 10",
+	"spam.spacerlines":            "Messages with at least this many blank lines are deleted, since they're used to push the chat off the screen. Lines with nothing but spaces, invisible characters or empty formatting count as blank, and code blocks don't count. Exempt trusted roles or channels with `!exempt spacers`. If 0, blank lines are only counted by `spam.linepressure`. Default: 30",
+	"spam.spacerwarn":             "If true, anyone whose message is deleted for having too many blank lines is also warned. Default: false",
+	"spam.forwardsilence":         "If true, anyone caught spamming forwarded or sticker messages is silenced instead of just having the message deleted.",
+	"spam.actionnotify":           "If true, a message is posted in the channel a spammer was caught in explaining what happened to them. If false, only the mod channel is told. Default: true",
+	"spam.actionmessage":          "The message posted when a spammer is caught, if `spam.actionnotify` is true. {user} is replaced with a ping of the spammer, {username} with their name, {action} with what happened to them (silenced or banned), {reason} with why, and {channel} with the channel. If empty, a default message is used.",
+	"spam.silencenewchannels":     "If true, every new channel and category gets the silence role's permission overwrite as soon as it is created, so silenced members can't talk in it. New threads make sure the channel they're in has the overwrite too. Default: true",
 	"spam.ignorerole":             "If set, the bot will exclude anyone with this role from spam detection. Use with caution.",
 	"spam.silentrole":             "This should be a role with no permissions, so the bot can quarantine potential spammers without banning them.",
 	"spam.raidtime":               "In order to trigger a raid alarm, at least `spam.raidsize` people must join the chat within this many seconds of each other.",
+	"spam.fingerprintsize":        "If this many similar accounts join within `spam.fingerprinttime` seconds, the moderators are alerted with a button to ban them all, and lockdown is engaged. Two accounts are similar if at least two of these are true: their usernames are within `spam.fingerprintedits` edits of each other, they both have the default avatar, or they were created within `spam.fingerprintcreated` seconds of each other. If 0, accounts aren't fingerprinted. Default: 0",
+	"spam.fingerprinttime":        "How many seconds back the raid fingerprint compares new members. Default: 300",
+	"spam.fingerprintedits":       "How many letters two usernames can differ by and still count as similar. Default: 2",
+	"spam.fingerprintcreated":     "How many seconds apart two accounts can be created and still count as created together. Default: 3600",
 	"spam.raidsize":               "Specifies how many people must have joined the server within the `spam.raidtime` period to qualify as a raid.",
 	"spam.silencemessage":         "This message will be sent to users that have been silenced by the `!silence` command.",
 	"spam.autosilence":            "Gets the current autosilence state. Use the `!autosilence` command to set this.",
 	"spam.lockdownduration":       "Determines how long the server's verification mode will temporarily be increased to tableflip levels after a raid is detected. If set to 0, disables lockdown entirely.",
+	"spam.editgrace":              "Number of seconds after a message is posted during which edits to it are checked for spam again, so users can't sneak links in by editing them in. Only the pressure the edit added is counted. If set to 0, edits are never checked. Default: 300",
+	"spam.verifieddays":           "Members who have been on the server for this many days, have sent `spam.verifiedmessages` messages, and haven't been warned or caught spamming in that time become verified, and the spam filters in `spam.verifiedrelax` no longer apply to them. Any warning or spam offense takes it away again. Checked every hour. If 0, nobody is verified. Default: 0",
+	"spam.verifiedmessages":       "How many messages a member has to have sent to become verified. Default: 200",
+	"spam.verifiedrelax":          "The spam filters that don't apply to verified members: images, pings, length, lines, repeat, short, roleping, reactions, forwards, spacers, or all. Default: length, lines, repeat, short",
 	"bucket.maxitems":             "Determines the maximum number of items sweetiebot can carry in her bucket. If set to 0, her bucket is disabled.",
 	"bucket.maxitemlength":        "Determines the maximum length of a string that can be added to her bucket.",
 	"bucket.maxfighthp":           "Maximum HP of the randomly generated enemy for the `!fight` command.",
@@ -162,21 +427,148 @@ var ConfigHelp map[string]string = map[string]string{
 	"markov.usemembernames":       "Use member names instead of random pony names.",
 	"users.timezonelocation":      "Sets the timezone location of the server itself. When no user timezone is available, the bot will use this.",
 	"users.welcomechannel":        "If set to a channel ID, the bot will treat this channel as a \"quarantine zone\" for silenced members. If autosilence is enabled, new users will be sent to this channel.",
-	"users.welcomemessage":        "If autosilence is enabled, this message will be sent to a new user upon joining.",
+	"users.welcomemessage":        "If autosilence is enabled, this message will be sent to a new user upon joining. `{user}` is replaced with a ping of them, `{username}` with their name, and any variable set with `!setvar` with its value.",
 	"users.roles":                 "A list of all user-assignable roles. Manage it via !addrole and !removerole",
+	"users.temprolerejoin":        "If true, members who leave and rejoin before a role given with `!temprole` expires get it back. Default: false",
+	"users.migratestrategy":       "What `!migrateuser` does when both accounts have data. `merge` adds up their message activity and keeps the earliest join date. `replace` uses the old account's activity, birthday and color role instead of the new account's. `keep` leaves the new account's data alone and only fills in what it's missing. Offenses and logged moderator actions are always moved. Default: merge",
 	"bored.cooldown":              "The bored cooldown timer, in seconds. This is the length of time a channel must be inactive for sweetiebot to post a bored message in it.",
 	"bored.commands":              "This determines what commands sweetie will run when she gets bored. She will choose one command from this list at random.\n\nExample: `!setconfig bored.commands !drop \"!pick bored\"`",
 	"help.rules":                  "Contains a list of numbered rules. The numbers do not need to be contiguous, and can be negative.",
 	"help.hidenegativerules":      "If true, `!rules -1` will display a rule at index -1, but `!rules` will not. This is useful for joke rules or additional rules that newcomers don't need to know about.",
 	"log.channel":                 "This is the channel where sweetiebot logs her output.",
 	"log.cooldown":                "The cooldown time for sweetiebot to display an error message, in seconds, intended to prevent the bot from spamming itself. Default: 4",
+	"log.channels":                "Sends some kinds of log messages to their own channel instead of `log.channel`. The categories are `messages` (edited and deleted messages, which are only logged if this category has a channel), `joins` (members joining and leaving), `moderation` (silences, warnings, filtered messages, spam and role changes) and `voice`. Use `!logchannel` to change this.",
+	"log.invites":                 "If true, logs which invite each new member joined with and who created it, to the joins log channel. Sweetie Bot needs the Manage Server permission to see invites. Defaults to false.",
+	"log.roles":                   "If true, role changes made by moderators are posted to the log channel, along with who made them. Role changes made by sweetiebot herself are only recorded in the audit log. Defaults to false.",
 	"witty.responses":             "Stores the replies used by the Witty module and must be configured using `!addwit` or `!removewit`",
 	"witty.cooldown":              "The cooldown time for the witty module. At least this many seconds must have passed before the bot will make another witty reply.",
+	"witty.persona":               "If set to the name of a persona added with `!persona`, witty responses are posted under that persona's name and avatar instead of the bot's.",
 	"schedule.birthdayrole":       " This is the role given to members on their birthday.",
 	"search.maxresults":           "Maximum number of search results that can be requested at once.",
 	"spoiler.channels":            "A list of channels that are exempt from the spoiler rules.",
 	"status.cooldown":             "Number of seconds sweetiebot waits before changing her status to a string picked randomly from the `status` collection.",
 	"quote.quotes":                "This is a map of quotes, which should be managed via `!addquote` and `!removequote`.",
+	"profile.nicknames":           "If true, nickname changes are logged to the mod channel. Defaults to false.",
+	"profile.usernames":           "If true, username changes are logged to the mod channel. Defaults to false.",
+	"profile.avatars":             "If true, avatar changes are logged to the mod channel along with the new avatar. Defaults to false.",
+	"profile.debounce":            "Number of seconds to wait for a user to stop changing their profile before logging it, so rapid changes are collapsed into a single message. Default: 60",
+	"privacy.storecontent":        "If true, Sweetie Bot keeps the content of recent messages in memory and, on servers with a chat log, stores every message in the database. This lets her log edited and deleted messages and search the chat log, but it means your members' messages are stored by the bot. If false, nothing anyone says is kept, and features that need message content are disabled. Default: true",
+	"privacy.cachesize":           "Maximum number of recent messages to keep in memory. Default: 1000",
+	"privacy.cachettl":            "Number of seconds to keep a message in memory before forgetting it. Default: 86400 (1 day)",
+	"privacy.excludechannels":     "A list of channels whose messages are never cached or logged, even if `privacy.storecontent` is true. Example: `!setconfig privacy.excludechannels #venting #staff`",
+	"filter.words":                "The blocked words and phrases. A value of true means the entry only matches whole words, false means it matches anywhere. Use `!addfilter` and `!removefilter` to manage these, since they normalize the entries for you.",
+	"filter.allow":                "Exceptions to the filter, for innocent words that contain a blocked one. Use `!addexception` and `!removeexception` to manage these.",
+	"filter.warn":                 "If true, users are pinged with a warning when one of their messages is removed by the filter.",
+	"filter.severity":             "How severe each blocked entry is: mild, strong or slur. Entries that aren't in here are strong. Use `!addfilter` to change these.",
+	"filter.actions":              "What happens when a message contains an entry of each severity: `log` only reports it in the log channel, `delete` removes it, `warn` also records a warning against the user, and `silence` silences them. For example, `!setconfig filter.actions slur silence`. Default: mild and strong are deleted, slurs are warned.",
+	"filter.channels":             "Channels where only slurs are filtered, such as a venting channel. Threads in these channels are included.",
+	"filter.slursonly":            "If true, only slurs are filtered anywhere on the server.",
+	"pinboard.emoji":              "The reaction that pins a message, such as 📌 or a custom emoji. If empty, the pinboard is disabled.",
+	"pinboard.roles":              "Members with any of these roles can pin messages by reacting with `pinboard.emoji`. Moderators can always pin messages.",
+	"pinboard.channel":            "If set, pinned messages are posted to this channel instead of using discord's pins, which are limited to 50 per channel.",
+	"approval.enabled":            "If true, the first message each new member posts is held until a moderator approves it.",
+	"approval.channel":            "The channel messages are sent to for approval. If not set, the mod channel is used.",
+	"approval.tenure":             "Members who have been on the server for at least this many seconds skip the approval queue, and pending messages are approved automatically once their author reaches it. If 0, every member must be approved by a moderator. Default: 86400",
+	"warnings.decaydays":          "Number of days a user has to go without a new warning or spam silence before their offenses start expiring. If 0, offenses never expire. Default: 30",
+	"warnings.decaycurve":         "How offenses expire after each `warnings.decaydays` clean days: `linear` forgives one offense, `halving` halves the count, and `reset` forgives all of them. Default: linear",
+	"warnings.selfservice":        "If true, members can use `myrecord` to see their own warnings, silences, kicks and bans, with the reasons but not who did it. Default: true",
+	"warnings.appeal":             "The message posted under someone's record when they use `myrecord`, telling them how to appeal. {user}, {username}, {modchannel} and {prefix} are filled in, and server templates work too. If empty, a generic message is used.",
+	"autothread.channels":         "Every message posted in one of these channels gets its own thread. Example: `!setconfig autothread.channels #support #suggestions`",
+	"autothread.match":            "If set, only messages matching this regex (case insensitive) get a thread.",
+	"autothread.name":             "How threads are named. `{message}` is replaced with the first line of the message, `{user}` with the author's name and `{channel}` with the channel's name. Names are cut off at 100 characters. Default: {message}",
+	"autothread.minlength":        "Messages shorter than this many characters don't get a thread.",
+	"autothread.archive":          "Number of minutes without activity before a thread is archived. Discord only allows 60, 1440, 4320 or 10080, so other values are rounded down. Default: 1440",
+	"colors.anchor":               "Members can give themselves a personal color role with `!color`, which is placed just beneath this role. If not set, `!color` is disabled. Sweetie Bot's own role must be above this one.",
+	"colors.palette":              "If any colors are added here, members can only pick from these. Example: `!setconfig colors.palette red #FF0000`",
+	"autoreact.channels":          "Maps channels to the emoji Sweetie Bot reacts to every new message in them with, separated by spaces. Use `!autoreact` to change this, since it checks that the emoji are valid.",
+	"sticky.messages":             "Sticky messages are reposted after this many new messages, even if the channel hasn't gone quiet. If 0, they are only reposted once the channel is quiet. Default: 5",
+	"sticky.delay":                "Sticky messages are reposted once nobody has posted in the channel for this many seconds. Can't be less than 5. Default: 15",
+	"welcomecard.enabled":         "If true, the welcome message is posted with a welcome card showing the new member's avatar, username and member number. Use `!welcomecard` to preview it.",
+	"welcomecard.background":      "URL of a PNG, JPEG or GIF to draw the welcome card on, no larger than 2048x2048. The card is the same size as the background. If empty, a plain 600x200 card is used.",
+	"welcomecard.avatarx":         "Distance in pixels from the left of the card to the member's avatar. Default: 36",
+	"welcomecard.avatary":         "Distance in pixels from the top of the card to the member's avatar. Default: 36",
+	"welcomecard.avatarsize":      "Width and height of the member's avatar in pixels. If 0, no avatar is drawn. Can't be more than 512. Default: 128",
+	"welcomecard.textx":           "Distance in pixels from the left of the card to the member's username. Default: 200",
+	"welcomecard.texty":           "Distance in pixels from the top of the card to the member's username. The member number is drawn beneath it. Default: 60",
+	"welcomecard.textscale":       "How many times larger than 5x7 pixels each letter of the username is drawn. The member number is drawn at half this size. Can't be more than 16. Default: 4",
+	"welcomecard.textcolor":       "Hex code of the text color, like #FFFFFF. Default: #FFFFFF",
+	"rolemenus.menus":             "Every role menu on the server, by name. Use `!rolemenu` to change these.",
+	"tempvoice.hubs":              "Joining one of these voice channels creates a temporary voice channel in the same category, with the same permissions, and moves you into it. The channel is deleted once everyone leaves it. Sweetie Bot needs the Manage Channels and Move Members permissions.",
+	"tempvoice.name":              "The name of temporary voice channels, where {user} is replaced with the nickname of the member who made it. Default: {user}'s channel",
+	"tempvoice.userlimit":         "The most members that can be in a temporary voice channel. The member who made it can change this. If 0, there is no limit.",
+	"snipe.maxage":                "`!snipe` and `!editsnipe` only show messages deleted or edited within this many seconds. If 0, there is no limit, but messages are still forgotten once they leave the message cache. Default: 300",
+	"phishing.enabled":            "If true, messages linking to a known phishing domain, like a fake discord nitro or steam giveaway, are deleted. The domains come from a public list the bot downloads every few hours. Links hidden with tricks like `discord[.]gift` are still caught. Moderators are exempt.",
+	"phishing.silence":            "If true, anyone who posts a phishing link is also silenced, since their account has probably been compromised. Default: true",
+	"phishing.block":              "Extra domains to treat as phishing, in addition to the downloaded list. Subdomains of these domains are also blocked.",
+	"secrets.enabled":             "If true, messages containing what looks like a leaked secret, like a discord bot token, a webhook URL, or a GitHub, AWS or Google key, are deleted, the author is told to revoke it, and the moderators are alerted. Only the start of the secret is ever logged.",
+	"secrets.ignore":              "Kinds of secrets that aren't deleted: discord, webhook, github, gitlab, aws, google, slack, stripe, openai, npm or privatekey.",
+	"secrets.message":             "The message sent to someone whose secret was deleted. {user} is replaced with a ping of them, {username} with their name, {channel} with the channel, and {kind} with what kind of secret it looked like, like \"a discord bot token\". If empty, a default message is used.",
+	"phishing.allow":              "Domains that are never treated as phishing, even if they are on the downloaded list.",
+	"polls.chart":                 "If true, `!results` draws the results as a bar chart, and `!deletepoll` posts the final results as a chart before deleting the poll. The text results are always sent along with the chart.",
+	"bump.enabled":                "If true, bump confirmations from `bump.bot` schedule a reminder to bump the server again.",
+	"bump.bot":                    "The user ID of the server listing bot to watch. Default: 302050872383242240 (Disboard)",
+	"bump.channel":                "If set, only bumps in this channel are noticed. Reminders are posted in whatever channel the bump happened in.",
+	"bump.pattern":                "A case-insensitive regular expression matched against the bot's messages and embeds to tell a successful bump apart from its other messages. Change this if the bot changes its wording. Default: bump done",
+	"bump.cooldown":               "How many seconds after a bump to post the reminder. Default: 7200",
+	"bump.role":                   "The role pinged by bump reminders. If 0, nobody is pinged.",
+	"bump.message":                "The bump reminder message. Default: The server can be bumped again!",
+	"subscriptions.topics":        "Maps each topic members can `!subscribe` to onto the ID of its ping role. Use `!settopic` to change this, which checks the role is safe to hand out.",
+	"rulesgate.enabled":           "If true, members who accept the rules are given `rulesgate.role`, and members who don't are kicked after `rulesgate.timeout`.",
+	"rulesgate.channel":           "The channel the rules message is in. Set by `!rulesgate`.",
+	"rulesgate.message":           "The ID of the rules message members react to. Set by `!rulesgate`.",
+	"rulesgate.emoji":             "The reaction that accepts the rules. Either a unicode emoji, or name:id for a custom emoji. Default: ✅",
+	"rulesgate.role":              "The role given to members once they accept the rules. Usually this is the role that can see the rest of the server.",
+	"rulesgate.timeout":           "If greater than 0, members who haven't accepted the rules this many seconds after joining are kicked. They can rejoin and try again. Default: 0",
+	"nicknames.dehoist":           "If true, symbols at the start of a member's name that sort them to the top of the member list, like ! or ., are removed.",
+	"nicknames.decancer":          "If true, fancy unicode letters like 𝐛𝐨𝐥𝐝 or ｆｕｌｌｗｉｄｔｈ text are turned back into normal letters, and zalgo is stripped.",
+	"nicknames.blank":             "If true, members whose names have no letters or numbers in them are renamed to `nicknames.fallback`.",
+	"nicknames.fallback":          "The name given to members with unreadable names. Default: Moderated Nickname",
+	"nicknames.bypassrole":        "Members with this role are never renamed.",
+	"limits.quotes":               "The most quotes this server can store. If 0, or higher than the bot owner allows, the bot owner's limit is used. Use `!limits` to see how much is being used.",
+	"limits.reminders":            "The most `!remindme` reminders each member can have waiting. If 0, or higher than the bot owner allows, the bot owner's limit is used.",
+	"limits.channelreminders":     "The most `!remindchannel` reminders this server can have. If 0, or higher than the bot owner allows, the bot owner's limit is used.",
+	"limits.triggers":             "The most witty response triggers this server can have. If 0, or higher than the bot owner allows, the bot owner's limit is used.",
+	"limits.collectionitems":      "The most items this server can store across all of its collections. If 0, or higher than the bot owner allows, the bot owner's limit is used.",
+	"newchannels.announce":        "If set, new channels are posted in this channel along with who created them. Channels that @everyone can't see aren't announced.",
+	"newchannels.applytemplates":  "If true, new channels are given the permissions saved for their category with `!channeltemplate`. Default: false",
+	"newchannels.templates":       "The permission overwrites given to new channels in each category. Should be configured using `!channeltemplate`.",
+	"boosts.channel":              "If set, a thank-you message is posted in this channel whenever someone starts boosting the server.",
+	"boosts.message":              "The message posted when someone boosts the server. {user} is replaced with a ping, {username} with their name, and {count} with the server's total number of boosts. Default: Thank you {user} for boosting {server}! We're now at {count} boosts.",
+	"boosts.perkrole":             "If set, members are given this role when they start boosting the server.",
+	"boosts.revokeperk":           "If true, `boosts.perkrole` is taken away from members after they stop boosting the server. Default: false",
+	"boosts.grace":                "How many seconds someone keeps `boosts.perkrole` after they stop boosting, in case they're just switching their boost around. Default: 259200 (3 days)",
+	"boosts.milestone":            "If greater than 0, every current booster is thanked in `boosts.channel` whenever the server's total boosts reach a multiple of this. Reaching a new boost level is always celebrated. Default: 0",
+	"quiethours.enabled":          "If true, witty responses, AFK replies, bored actions, birthday wishes, bump reminders and boost celebrations aren't posted between `quiethours.start` and `quiethours.end`. Moderation carries on as usual. Use `!quiethours` to change this. Default: false",
+	"quiethours.start":            "The hour quiet hours start, from 0 to 23, in the server's timezone (`users.timezonelocation`). Default: 0",
+	"quiethours.end":              "The hour quiet hours end, from 0 to 23. If this is less than `quiethours.start`, quiet hours run past midnight. Default: 0",
+	"quiethours.queue":            "If true, messages are held back and posted once quiet hours end, instead of being dropped. Witty responses, AFK replies and bored actions are always dropped. Default: false",
+	"suggestions.channel":         "If set, every post in this channel is turned into a suggestion that can be voted on, and the original post is deleted.",
+	"suggestions.implemented":     "If set, suggestions marked as implemented are moved to this channel.",
+	"suggestions.upvote":          "The emoji used to vote for a suggestion. Can be a unicode emoji or a custom emoji's name:id. Default: 👍",
+	"suggestions.downvote":        "The emoji used to vote against a suggestion. Default: 👎",
+	"inactivity.role":             "If set, this role is taken away from members who haven't been seen for `inactivity.days`. Checked once a day.",
+	"inactivity.days":             "How many days someone has to go unseen before losing `inactivity.role`. If 0, nothing is removed. Default: 0",
+	"inactivity.dm":               "If true, members are sent `inactivity.message` when they lose the role. Default: false",
+	"inactivity.message":          "The private message sent to members who lose the role. {role} is replaced with the role's name. Default: You haven't been around on {server} in a while, so your {role} role was removed. You can get it back whenever you like!",
+	"inactivity.exempt":           "Members with any of these roles never lose `inactivity.role`. Moderators are always exempt.",
+	"ghostping.window":            "If someone pings a user or role and deletes the message within this many seconds, the moderators are alerted. If 0, ghost pings aren't detected. Default: 0",
+	"ghostping.warn":              "If true, anyone caught ghost pinging is also sent `ghostping.message`. Default: false",
+	"digest.channel":              "If set, a summary of server activity is posted in this channel every day, or every week if `digest.weekly` is true.",
+	"digest.weekly":               "If true, the digest is posted once a week on `digest.day` and covers the whole week. Default: false",
+	"digest.day":                  "The day of the week a weekly digest is posted, from 0 for Sunday to 6 for Saturday. Default: 1",
+	"digest.hour":                 "The hour the digest is posted, from 0 to 23, in the server's timezone. Default: 9",
+	"digest.sections":             "Which sections the digest has: members, messages, channels, emoji, moderation and quotes. If empty, it has all of them.",
+	"ghostping.message":           "The private message sent to someone caught ghost pinging. {user} is replaced with a ping of them, {username} with their name, and {channel} with the channel. Default: Please don't ping people on {server} and then delete the message. The moderators have been told.",
+	"chatter.messages":            "The most messages Sweetie Bot posts on her own within `chatter.period` seconds, counting witty responses, bored commands, AFK replies, replies to bare pings, birthday wishes, boost thanks and bump reminders together. Anything past that is skipped until the budget refills. Commands and moderation alerts don't count. If 0, there's no limit. Default: 30",
+	"chatter.period":              "How many seconds it takes for the whole chatter budget to refill. Default: 600",
+	"feedback.mode":               "How Sweetie Bot tells people how their commands went. `text` replies with text, like she always has. `react` reacts with `feedback.failure` instead of posting an error when a command can't be run, and with `feedback.success` when a command worked but has nothing to say. `both` does both and still posts errors, and reacts with `feedback.success` to every command that worked. Default: text",
+	"feedback.success":            "The emoji Sweetie Bot reacts with when a command worked. Custom emoji from any server she's on can be used. Default: ✅",
+	"feedback.failure":            "The emoji Sweetie Bot reacts with when a command couldn't be run. Default: ❌",
+	"feedback.working":            "The emoji Sweetie Bot reacts with while a command takes longer than a second, which is taken off once it finishes. Default: ⏳",
+	"automod.rules":               "What messages blocked by each of the server's AutoMod rules count as, by rule ID: `mild`, `strong` or `slur` are handled like the filter catching language of that tier, following `filter.actions` except that there's nothing left to delete, `spam` records a spam offense and silences them, and `ignore` does nothing. Example: `!setconfig automod.rules 1234567890 slur`",
+	"automod.default":             "What messages blocked by AutoMod rules that aren't in `automod.rules` count as. By default they're left alone, so only rules a moderator has set up count towards warnings. Default: ignore",
+	"automod.synctier":            "The least severe filter tier `!syncautomod` copies into AutoMod: mild, strong or slur. Default: slur",
+	"automod.syncrule":            "The ID of the AutoMod rule `!syncautomod` made. Messages it blocks count as the tier of the filter entry they matched.",
 }
 
 // Version represents an app version using four sections
@@ -207,6 +599,20 @@ func AssembleVersion(major byte, minor byte, revision byte, build byte) int {
 	return int(build) | (int(revision) << 8) | (int(minor) << 16) | (int(major) << 24)
 }
 
+// CommandLimits are bot-wide limits on how many commands get processed, set by the bot owner in the limits file
+type CommandLimits struct {
+	GlobalRate  float64 `json:"globalrate"`  // commands per second processed across all guilds
+	GlobalBurst float64 `json:"globalburst"` // maximum number of commands that can be processed at once across all guilds
+	GuildRate   float64 `json:"guildrate"`   // commands per second processed for a single guild
+	GuildBurst  float64 `json:"guildburst"`  // maximum number of commands that can be processed at once for a single guild
+}
+
+// DMResponse controls what happens to private messages that aren't commands, set by the bot owner in the dmresponse file
+type DMResponse struct {
+	Mode    string `json:"mode"`    // "ignore", "reply" with the message, or "forward" to the mod channel of the user's default server
+	Message string `json:"message"` // the message sent back in "reply" mode, or if a forwarded message has nowhere to go
+}
+
 // SweetieBot is the primary bot object containing the bot state
 type SweetieBot struct {
 	db                 *BotDB
@@ -222,6 +628,18 @@ type SweetieBot struct {
 	MainGuildID        uint64
 	DBGuilds           map[uint64]bool   `json:"dbguilds"`
 	DebugChannels      map[string]string `json:"debugchannels"`
+	CommandLimits      CommandLimits     `json:"commandlimits"`
+	ContentLimits      ContentLimits     `json:"contentlimits"`
+	commandbucket      TokenBucket
+	DMResponse         DMResponse `json:"dmresponse"`
+	dmbucket           TokenBucket
+	RateLimitAlert     RateLimitAlert `json:"ratelimitalert"`
+	PhishingList       PhishingList   `json:"phishinglist"`
+	ratelimits         SaturationLimit
+	lastratelimitalert int64
+	Intents            GatewayIntents `json:"intents"`
+	Scripts            ScriptConfig   `json:"scripts"`
+	cron               CronScheduler
 	quit               AtomicBool
 	guilds             map[uint64]*GuildInfo
 	guildsLock         sync.RWMutex
@@ -259,6 +677,7 @@ func (sbot *SweetieBot) IsDBGuild(info *GuildInfo) bool {
 
 // BulkDelete Performs a bulk deletion in groups of 100
 func (sbot *SweetieBot) BulkDelete(channelID string, messages []string) (err error) {
+	markSelfDeleted(messages...)
 	i := 0
 	n := len(messages)
 	for (n - i) > 99 {
@@ -317,6 +736,9 @@ func sbReady(s *discordgo.Session, r *discordgo.Ready) {
 	fmt.Println("Ready message receieved, waiting for guilds...")
 	sb.SelfID = r.User.ID
 	sb.SelfAvatar = r.User.Avatar
+	if r.Application != nil {
+		go registerApplicationCommands(r.Application.ID)
+	}
 	isuser, _ := os.ReadFile("isuser") // THIS FILE SHOULD NOT EXIST UNLESS YOU WANT TO BE IN USER MODE. If you don't know what user mode is, you don't want it.
 	if r.Guilds != nil && isuser != nil {
 		for _, G := range r.Guilds {
@@ -388,18 +810,6 @@ func AttachToGuild(g *discordgo.Guild) {
 			if perms&0x00020000 != 0 {
 				warning = "\nWARNING: You have given sweetiebot the Mention Everyone role, which means users will be able to abuse her to ping everyone on the server! Sweetie Bot does NOT attempt to filter @\u200Beveryone from her messages!" + warning
 			}
-			if perms&0x00000004 == 0 {
-				warning = "\nWARNING: Sweetiebot cannot ban members spamming the welcome channel without the Ban Members role! (If you do not use this feature, it is safe to ignore this warning)." + warning
-			}
-			if perms&0x10000000 == 0 {
-				warning = "\nWARNING: Sweetiebot cannot silence members or give birthday roles without the Manage Roles role!" + warning
-			}
-			if perms&0x00002000 == 0 {
-				warning = "\nWARNING: Sweetiebot cannot delete messages without the Manage Messages role!" + warning
-			}
-			if perms&0x00000020 == 0 {
-				warning = "\nWARNING: Sweetiebot cannot engage lockdown mode without the Manage Server role!" + warning
-			}
 			sb.dg.ChannelMessageSend(ch.ID, "You've successfully added Sweetie Bot to your server! To finish setting her up, run the `setup` command. Here is an explanation of the command and an example:\n```!setup <Mod Role> <Mod Channel> [Log Channel]```\n**> Mod Role**\nThis is a role shared by all the moderators and admins of your server. Sweetie Bot will ping this role to alert you about potential raids or silenced users, and sensitive commands will be restricted so only users with the moderator role can use them. As the server owner, you will ALWAYS be able to run any command, no matter what. This ensures that you can always fix a broken configuration. Before running `!setup`, make sure your moderator role can be pinged: Go to Server Settings -> Roles and select your mod role, then make sure \"Allow anyone to @mention this role\" is checked.\n\n**> Mod Channel**\nThis is the channel Sweetie Bot will post alerts on. Usually, this is your private moderation channel, but you can make it whatever channel you want. Just make sure you use the format `#channel`, and ensure the bot actually has permission to post messages on the channel.\n\n**> Log Channel**\nThis is an optional channel where sweetiebot will post errors and update notifications. Usually, this is only visible to server admins and the bot. Remember to give the bot permission to post messages on the log channel, or you won't get any output. Providing a log channel is highly recommended, because it's often Sweetie Bot's last resort for notifying you about potential errors.\n\nThat's it! Here is an example of the command: ```!setup @Mods #staff-chat #bot-log```\n\nNote: **Do not run `!setup` in this PM!** It won't work because Discord won't autocomplete `#channel` for you. Run `!setup` directly on your server.")
 			if len(warning) > 0 {
 				sb.dg.ChannelMessageSend(ch.ID, warning)
@@ -515,12 +925,48 @@ func AttachToGuild(g *discordgo.Guild) {
 	guild.modules = append(guild.modules, &BucketModule{})
 	guild.modules = append(guild.modules, &MiscModule{guild.emotemodule})
 	guild.modules = append(guild.modules, &ConfigModule{})
+	guild.modules = append(guild.modules, &SecretsModule{}) // Before the audit log and snipe, so they never see a leaked secret
 	guild.modules = append(guild.modules, &SpamModule{tracker: make(map[uint64]*userPressure), lastraid: 0})
 	guild.modules = append(guild.modules, wittymodule)
 	guild.modules = append(guild.modules, &StatusModule{})
 	guild.modules = append(guild.modules, &BoredModule{lastmessage: 0})
 	guild.modules = append(guild.modules, guild.emotemodule)
 	guild.modules = append(guild.modules, spoilermodule)
+	guild.modules = append(guild.modules, &AuditModule{})
+	guild.modules = append(guild.modules, &ProfileModule{pending: make(map[uint64]*profileChange)})
+	filtermodule := &FilterModule{}
+	filtermodule.UpdateRegex(guild)
+	guild.modules = append(guild.modules, filtermodule)
+	guild.modules = append(guild.modules, &AutoModModule{seen: make(map[string]int64), rules: make(map[string]string)})
+	afkmodule := &AFKModule{}
+	afkmodule.load(guild)
+	guild.modules = append(guild.modules, afkmodule)
+	guild.modules = append(guild.modules, &PinboardModule{})
+	approvalmodule := &ApprovalModule{}
+	approvalmodule.load(guild)
+	guild.modules = append(guild.modules, approvalmodule)
+	guild.modules = append(guild.modules, &AutoThreadModule{})
+	guild.modules = append(guild.modules, &ReportModule{})
+	guild.modules = append(guild.modules, &AutoReactModule{})
+	stickymodule := &StickyModule{}
+	stickymodule.load(guild)
+	guild.modules = append(guild.modules, stickymodule)
+	guild.modules = append(guild.modules, &RoleMenuModule{})
+	tempvoicemodule := &TempVoiceModule{}
+	tempvoicemodule.load(guild)
+	guild.modules = append(guild.modules, tempvoicemodule)
+	guild.modules = append(guild.modules, &SnipeModule{})
+	guild.modules = append(guild.modules, &PhishingModule{})
+	guild.modules = append(guild.modules, &BumpModule{})
+	guild.modules = append(guild.modules, &RulesGateModule{})
+	guild.modules = append(guild.modules, &NicknameModule{})
+	guild.modules = append(guild.modules, &InviteModule{})
+	guild.modules = append(guild.modules, &NewChannelModule{})
+	guild.modules = append(guild.modules, &BoostModule{})
+	guild.modules = append(guild.modules, &ScriptModule{})
+	guild.modules = append(guild.modules, &SuggestionModule{})
+	guild.modules = append(guild.modules, &GhostPingModule{})
+	guild.modules = append(guild.modules, &DigestModule{})
 
 	for _, v := range guild.modules {
 		guild.RegisterModule(v)
@@ -545,6 +991,7 @@ func AttachToGuild(g *discordgo.Guild) {
 		}
 		delete(guild.config.Modules.CommandDisabled, "setup")
 		guild.SaveConfig()
+		go sendPreflight(guild) // Missing permissions are listed separately, once the modules exist to check them against
 	}
 	if sb.IsMainGuild(guild) {
 		sb.db.log = guild
@@ -552,6 +999,9 @@ func AttachToGuild(g *discordgo.Guild) {
 	}
 
 	go func() { // Do this concurrently because we don't need this to function properly, we just need it to happen eventually
+		if !sb.Intents.Members {
+			return // Listing members requires the server members intent
+		}
 		// Discord doesn't send us all the members, so we force feed them into the state ourselves
 		members := []*discordgo.Member{}
 		lastid := ""
@@ -625,6 +1075,35 @@ func SBProcessCommand(s *discordgo.Session, m *discordgo.Message, info *GuildInf
 		prefix = info.config.Basic.CommandPrefix[0]
 	}
 
+	// A ping of the bot on its own gets a hint about how to use her. Replies can ping her too, but they never count.
+	if info != nil && m.Author.ID != sb.SelfID && !m.Author.Bot && m.Type != discordgo.MessageTypeReply {
+		if phrase, ok := stripSelfMention(m.Content); ok && len(phrase) == 0 {
+			msg := info.config.Basic.MentionResponse
+			if len(msg) == 0 {
+				msg = defaultMentionResponse
+			}
+			respondToMention(info, m, msg)
+		}
+	}
+
+	// A ping of the bot followed by a phrase is treated as if the command it describes had been typed with the prefix
+	if info != nil && info.config.Basic.MentionCommands && m.Author.ID != sb.SelfID {
+		if phrase, ok := stripSelfMention(m.Content); ok && len(phrase) > 0 {
+			cmd, ok := parseMentionCommand(phrase, func(s string) bool {
+				_, command := info.commands[s]
+				_, alias := info.config.Basic.Aliases[s]
+				return command || alias
+			})
+			if !ok {
+				respondToMention(info, m, mentionConfusedResponse)
+			} else {
+				mc := *m // Don't change the message other handlers see
+				mc.Content = string(prefix) + cmd
+				m = &mc
+			}
+		}
+	}
+
 	// Check if this is a command. If it is, process it as a command, otherwise process it with our modules.
 	if len(m.Content) > 1 && m.Content[0] == prefix && (len(m.Content) < 2 || m.Content[1] != prefix) { // We check for > 1 here because a single character can't possibly be a valid command
 		private := info == nil
@@ -689,6 +1168,11 @@ func SBProcessCommand(s *discordgo.Session, m *discordgo.Message, info *GuildInf
 				c, ok = info.commands[arg]
 			}
 		}
+		if !ok && len(info.IsModuleDisabled("scripts")) == 0 {
+			if script := findScriptCommand(arg); script != nil { // Scripts registered since this server was loaded aren't in its command list yet
+				c, ok = script, true
+			}
+		}
 		if ok {
 			if isdbguild && sb.db.status.get() && m.Author.ID != sb.SelfID {
 				sb.db.Audit(AUDIT_TYPE_COMMAND, m.Author, m.Content, SBatoi(info.ID))
@@ -711,20 +1195,35 @@ func SBProcessCommand(s *discordgo.Session, m *discordgo.Message, info *GuildInf
 					return
 				}
 			}
-			if !isdebug && !isfree && !isSelf && info.config.Modules.CommandPerDuration > 0 && !info.UserHasRole(m.Author.ID, SBitoa(info.config.Basic.AlertRole)) { // debug channels aren't limited
+			if !isdebug && !isfree && !isSelf && info.config.Modules.CommandPerDuration > 0 && !info.HasModRole(m.Author.ID) { // debug channels aren't limited
 				if len(info.commandlimit.times) < info.config.Modules.CommandPerDuration*2 { // Check if we need to re-allocate the array because the configuration changed
 					info.commandlimit.times = make([]int64, info.config.Modules.CommandPerDuration*2, info.config.Modules.CommandPerDuration*2)
 				}
 				if info.commandlimit.check(info.config.Modules.CommandPerDuration, info.config.Modules.CommandMaxDuration, t) { // if we've hit the saturation limit, post an error (which itself will only post if the error saturation limit hasn't been hit)
-					info.Error(m.ChannelID, fmt.Sprintf("You can't input more than %v commands every %s!%s", info.config.Modules.CommandPerDuration, TimeDiff(time.Duration(info.config.Modules.CommandMaxDuration)*time.Second), getAddMsg(info)))
+					info.commandFailed(m, fmt.Sprintf("You can't input more than %v commands every %s!%s", info.config.Modules.CommandPerDuration, TimeDiff(time.Duration(info.config.Modules.CommandMaxDuration)*time.Second), getAddMsg(info)))
 					return
 				}
 				info.commandlimit.append(t)
 			}
-			if !isOwner && !isSelf && !info.UserHasAnyRole(m.Author.ID, info.config.Modules.CommandRoles[cmdname]) {
-				info.Error(m.ChannelID, "You don't have permission to run this command! Allowed Roles: "+info.GetRoles(c))
+			if !isOwner && !isSelf && !info.UserCanRunCommand(m.Author.ID, cmdname) {
+				info.commandDenied(m, "You don't have permission to run this command! Allowed Roles: "+info.GetRoles(c))
 				return
 			}
+			// Protect the bot from being overwhelmed. Mod-only commands and moderators are exempt so they can still deal with whatever is causing the flood.
+			if !isOwner && !isSelf && len(info.config.Modules.CommandRoles[cmdname]) == 0 && !info.HasModRole(m.Author.ID) {
+				if sb.CommandLimits.GuildRate > 0 && !info.commandbucket.take(sb.CommandLimits.GuildRate, sb.CommandLimits.GuildBurst) {
+					metricCommandsDropped.Add("guild", 1)
+					metricCommandsDroppedByGuild.Add(info.ID, 1)
+					recordDropped("command", info.ID, "server command limit", cmdname+" from "+userIdentity(m.Author))
+					return
+				}
+				if sb.CommandLimits.GlobalRate > 0 && !sb.commandbucket.take(sb.CommandLimits.GlobalRate, sb.CommandLimits.GlobalBurst) {
+					metricCommandsDropped.Add("global", 1)
+					metricCommandsDroppedByGuild.Add(info.ID, 1)
+					recordDropped("command", info.ID, "global command limit", cmdname+" from "+userIdentity(m.Author))
+					return
+				}
+			}
 
 			cmdlimit := info.config.Modules.CommandLimits[cmdname]
 			if !isfree && cmdlimit > 0 && !isSelf {
@@ -732,7 +1231,7 @@ func SBProcessCommand(s *discordgo.Session, m *discordgo.Message, info *GuildInf
 				lastcmd := info.commandLast[m.ChannelID][cmdname]
 				info.commandLock.RUnlock()
 				if !RateLimit(&lastcmd, cmdlimit) {
-					info.Error(m.ChannelID, fmt.Sprintf("You can only run that command once every %s!%s", TimeDiff(time.Duration(cmdlimit)*time.Second), getAddMsg(info)))
+					info.commandFailed(m, fmt.Sprintf("You can only run that command once every %s!%s", TimeDiff(time.Duration(cmdlimit)*time.Second), getAddMsg(info)))
 					return
 				}
 				info.commandLock.Lock()
@@ -743,7 +1242,16 @@ func SBProcessCommand(s *discordgo.Session, m *discordgo.Message, info *GuildInf
 				info.commandLock.Unlock()
 			}
 
-			result, usepm, resultembed := c.Process(args[1:], m, indices[1:], info)
+			var result string
+			var usepm bool
+			var resultembed *discordgo.MessageEmbed
+			feedback := startFeedback(info, m)
+			if ac, ok := c.(CommandWithArgs); ok { // Commands that declare their arguments get them validated before they run
+				result, usepm, resultembed = RunWithArgs(ac, args[1:], m, indices[1:], info)
+			} else {
+				result, usepm, resultembed = c.Process(args[1:], m, indices[1:], info)
+			}
+			feedback(len(result) > 0 || resultembed != nil)
 			if len(result) > 0 || resultembed != nil {
 				targetchannel := m.ChannelID
 				if usepm && !private {
@@ -762,21 +1270,25 @@ func SBProcessCommand(s *discordgo.Session, m *discordgo.Message, info *GuildInf
 
 				if resultembed != nil {
 					info.SendEmbed(targetchannel, resultembed)
+				} else if private {
+					info.SendLong(targetchannel, result) // Nobody else sees a private reply, so there's no reason to cut it off
 				} else {
 					info.SendMessage(targetchannel, result)
 				}
 			}
 		} else {
 			if !info.config.Basic.IgnoreInvalidCommands {
-				info.Error(m.ChannelID, "Sorry, "+args[0]+" is not a valid command.\nFor a list of valid commands, type !help.")
+				info.commandFailed(m, "Sorry, "+args[0]+" is not a valid command.\nFor a list of valid commands, type !help.")
 			}
 		}
-	} else if info != nil { // If info is nil this was sent through a private message so just ignore it completely
+	} else if info != nil {
 		for _, h := range info.hooks.OnMessageCreate {
 			if info.ProcessModule(m.ChannelID, h) {
 				h.OnMessageCreate(info, m)
 			}
 		}
+	} else if m.Author.ID != sb.SelfID { // If info is nil this was sent through a private message
+		handleDirectMessage(m)
 	}
 }
 
@@ -808,17 +1320,31 @@ func sbMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 	if m.ChannelID != "heartbeat" {
 		if info != nil && isdbguild && sb.db.CheckStatus() { // Log this message if it was sent to the main guild only.
 			cid := SBatoi(m.ChannelID)
-			if cid != info.config.Log.Channel {
+			if info.StoresContent(m.ChannelID) {
 				sb.db.AddMessage(SBatoi(m.ID), SBatoi(m.Author.ID), SanitizeMentions(m.ContentWithMentionsReplaced()), cid, m.MentionEveryone, SBatoi(ch.GuildID))
 			}
 		}
 		if info != nil {
 			sb.db.SentMessage(SBatoi(m.Author.ID), SBatoi(info.ID))
+			if !m.Author.Bot && m.Author.ID != sb.SelfID {
+				sb.db.AddActivity(SBatoi(m.Author.ID), SBatoi(info.ID))
+			}
 		}
 		if m.Author.ID == sb.SelfID { // discard all our own messages (unless this is a heartbeat message)
 			return
 		}
+		if info != nil {
+			info.messagecache.Add(info, m.Message)
+		}
 		if info != nil && !info.config.Basic.ListenToBots && m.Author.Bot { // If we aren't supposed to listen to bot messages, discard them.
+			if info.config.Bump.Bot != 0 && SBatoi(m.Author.ID) == info.config.Bump.Bot && !boolXOR(sb.Debug, isdebug) {
+				// Except for bump confirmations, which always come from a bot, so only the bump module gets to see them
+				for _, h := range info.hooks.OnMessageCreate {
+					if _, ok := h.(*BumpModule); ok && info.ProcessModule(m.ChannelID, h) {
+						h.OnMessageCreate(info, m.Message)
+					}
+				}
+			}
 			return
 		}
 		if boolXOR(sb.Debug, isdebug) { // debug builds only respond to the debug channel, and release builds ignore it
@@ -860,12 +1386,15 @@ func sbMessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
 		private = typeIsPrivate(ch.Type)
 	}
 	cid := SBatoi(m.ChannelID)
-	if cid != info.config.Log.Channel && !private && sb.IsDBGuild(info) && sb.db.CheckStatus() { // Always ignore messages from the log channel
+	if info.StoresContent(m.ChannelID) && !private && sb.IsDBGuild(info) && sb.db.CheckStatus() { // Skips the log channels and anywhere privacy.storecontent or privacy.excludechannels keep content out of the database
 		sb.db.AddMessage(SBatoi(m.ID), SBatoi(m.Author.ID), SanitizeMentions(m.ContentWithMentionsReplaced()), cid, m.MentionEveryone, SBatoi(ch.GuildID))
 	}
 	if m.Author.ID == sb.SelfID {
 		return
 	}
+	if len(m.Content) > 0 { // Embed-only updates don't include the content
+		info.messagecache.Add(info, m.Message)
+	}
 	for _, h := range info.hooks.OnMessageUpdate {
 		if info.ProcessModule(m.ChannelID, h) {
 			h.OnMessageUpdate(info, m.Message)
@@ -885,6 +1414,35 @@ func sbMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
 			h.OnMessageDelete(info, m.Message)
 		}
 	}
+	info.messagecache.Remove(m.ID)
+}
+func sbMessageReactionAdd(s *discordgo.Session, m *discordgo.MessageReactionAdd) {
+	info := getGuildFromID(m.GuildID) // Reactions carry their guild, so DM reactions are dropped without looking up the channel
+	if info == nil || m.UserID == sb.SelfID {
+		return
+	}
+	if boolXOR(sb.Debug, info.IsDebug(m.ChannelID)) {
+		return
+	}
+	for _, h := range info.hooks.OnReactionAdd {
+		if info.ProcessModule(m.ChannelID, h) {
+			h.OnMessageReactionAdd(info, m.MessageReaction)
+		}
+	}
+}
+func sbMessageReactionRemove(s *discordgo.Session, m *discordgo.MessageReactionRemove) {
+	info := getGuildFromID(m.GuildID)
+	if info == nil || m.UserID == sb.SelfID {
+		return
+	}
+	if boolXOR(sb.Debug, info.IsDebug(m.ChannelID)) {
+		return
+	}
+	for _, h := range info.hooks.OnReactionRemove {
+		if info.ProcessModule(m.ChannelID, h) {
+			h.OnMessageReactionRemove(info, m.MessageReaction)
+		}
+	}
 }
 func sbUserUpdate(s *discordgo.Session, m *discordgo.UserUpdate) {
 	ProcessUser(m.User, nil)
@@ -902,6 +1460,18 @@ func sbPresenceUpdate(s *discordgo.Session, m *discordgo.PresenceUpdate) {
 		}
 	}
 }
+func sbVoiceStateUpdate(s *discordgo.Session, m *discordgo.VoiceStateUpdate) {
+	info := getGuildFromID(m.GuildID)
+	if info == nil {
+		return
+	}
+
+	for _, h := range info.hooks.OnVoiceStateUpdate {
+		if info.ProcessModule("", h) {
+			h.OnVoiceStateUpdate(info, m)
+		}
+	}
+}
 func sbGuildUpdate(s *discordgo.Session, m *discordgo.GuildUpdate) {
 	info := getChannelGuild(m.ID)
 	if info == nil {
@@ -958,7 +1528,7 @@ func sbGuildMemberUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
 
 	for _, h := range info.hooks.OnGuildMemberUpdate {
 		if info.ProcessModule("", h) {
-			h.OnGuildMemberUpdate(info, m.Member)
+			h.OnGuildMemberUpdate(info, m)
 		}
 	}
 }
@@ -998,7 +1568,34 @@ func sbGuildRoleDelete(s *discordgo.Session, m *discordgo.GuildRoleDelete) {
 		}
 	}
 }
-func sbGuildCreate(s *discordgo.Session, m *discordgo.GuildCreate) { AttachToGuild(m.Guild) }
+func sbAutoModerationActionExecution(s *discordgo.Session, m *discordgo.AutoModerationActionExecution) {
+	info := getGuildFromID(m.GuildID)
+	if info == nil {
+		return
+	}
+
+	for _, h := range info.hooks.OnAutoModAction {
+		if info.ProcessModule(m.ChannelID, h) {
+			h.OnAutoModAction(info, m)
+		}
+	}
+}
+func sbGuildCreate(s *discordgo.Session, m *discordgo.GuildCreate) {
+	AttachToGuild(m.Guild)
+	info := getGuildFromID(m.ID)
+	if info == nil {
+		return
+	}
+
+	for _, h := range info.hooks.OnGuildCreate {
+		if info.ProcessModule("", h) {
+			h.OnGuildCreate(info, m.Guild)
+		}
+	}
+	if sb.IsDBGuild(info) {
+		go reportInterruptedOperations(info)
+	}
+}
 func sbGuildDelete(s *discordgo.Session, m *discordgo.GuildDelete) {
 	fmt.Println("Sweetie was deleted from", m.Guild.Name)
 	sb.guildsLock.Lock()
@@ -1010,7 +1607,38 @@ func sbChannelCreate(s *discordgo.Session, c *discordgo.ChannelCreate) {
 	guild, ok := sb.guilds[SBatoi(c.GuildID)]
 	sb.guildsLock.RUnlock()
 	if ok {
-		setupSilenceRole(guild)
+		silenceNewChannel(guild, c.Channel)
+		for _, m := range guild.modules {
+			if w, ok := m.(*NewChannelModule); ok && guild.ProcessModule("", w) {
+				go w.channelCreated(guild, c.Channel)
+			}
+		}
+	}
+}
+func sbThreadCreate(s *discordgo.Session, c *discordgo.ThreadCreate) {
+	sb.guildsLock.RLock()
+	guild, ok := sb.guilds[SBatoi(c.GuildID)]
+	sb.guildsLock.RUnlock()
+	if ok && c.NewlyCreated {
+		silenceNewChannel(guild, c.Channel)
+	}
+}
+func sbInviteCreate(s *discordgo.Session, i *discordgo.InviteCreate) {
+	if info := getGuildFromID(i.GuildID); info != nil {
+		for _, m := range info.modules {
+			if w, ok := m.(*InviteModule); ok {
+				w.inviteCreated(i)
+			}
+		}
+	}
+}
+func sbInviteDelete(s *discordgo.Session, i *discordgo.InviteDelete) {
+	if info := getGuildFromID(i.GuildID); info != nil {
+		for _, m := range info.modules {
+			if w, ok := m.(*InviteModule); ok {
+				w.inviteDeleted(i)
+			}
+		}
 	}
 }
 func sbChannelDelete(s *discordgo.Session, c *discordgo.ChannelDelete) {
@@ -1071,6 +1699,8 @@ func idleCheckLoop() {
 				}
 			}
 
+			info.messagecache.expire(info)
+
 			if info.lockdown != -1 && time.Now().UTC().Sub(info.lastlockdown) > (time.Duration(info.config.Spam.LockdownDuration)*time.Second) {
 				DisableLockdown(info)
 			}
@@ -1179,130 +1809,22 @@ func New(token string) *SweetieBot {
 		Debug:              false,
 		Owners:             owners,
 		RestrictedCommands: map[string]bool{"search": true, "lastping": true, "setstatus": true},
-		NonServerCommands:  map[string]bool{"about": true, "roll": true, "episodegen": true, "bestpony": true, "episodequote": true, "help": true, "listguilds": true, "update": true, "announce": true, "dumptables": true, "defaultserver": true},
+		NonServerCommands:  map[string]bool{"about": true, "version": true, "roll": true, "episodegen": true, "bestpony": true, "episodequote": true, "help": true, "listguilds": true, "update": true, "announce": true, "dumptables": true, "defaultserver": true, "guildconfig": true, "leaveguild": true, "broadcastowners": true, "limiters": true, "dropped": true, "jobs": true, "setdmresponse": true, "script": true},
 		MainGuildID:        mainguildid,
 		DBGuilds:           make(map[uint64]bool),
 		DebugChannels:      make(map[string]string),
 		quit:               AtomicBool{0},
-		guilds:        make(map[uint64]*GuildInfo),
-		MaxConfigSize: 1000000,
+		CommandLimits:      CommandLimits{GlobalRate: 20, GlobalBurst: 40, GuildRate: 3, GuildBurst: 10},
+		ContentLimits:      ContentLimits{Quotes: 2000, Reminders: 25, ChannelReminders: 100, Triggers: 200, CollectionItems: 10000},
+		Intents:            GatewayIntents{Members: true, MessageContent: true},
+		RateLimitAlert:     RateLimitAlert{Threshold: 20},
+		DMResponse:         DMResponse{Mode: "ignore", Message: "I only work in servers! Use !help to see what commands you can send me."},
+		guilds:             make(map[uint64]*GuildInfo),
+		MaxConfigSize:      1000000,
 		StartTime:          time.Now().UTC().Unix(),
 		heartbeat:          4294967290,
 		MessageCount:       0,
-		changelog: map[int]string{
-			AssembleVersion(0, 9, 9, 0):  "- Modernized codebase for Go 1.25+\n- Owner ID now loaded from 'owner' file instead of being hardcoded\n- Replaced deprecated ioutil package with os package\n- Added Go modules support (go.mod)\n- Improved installation documentation with clearer MariaDB setup instructions\n- Added SQL LIKE wildcard escaping to prevent pattern injection in search queries\n- Improved string building efficiency in search command using strings.Builder",
-			AssembleVersion(0, 9, 8, 14): "- Reduce database pressure on startup",
-			AssembleVersion(0, 9, 8, 13): "- Fix crash on startup.\n- Did more code refactoring, fixed several spelling errors.",
-			AssembleVersion(0, 9, 8, 12): "- Do bulk member insertions in single batch to reduce database pressure.\n- Removed bestpony command\n- Did large internal code refactor",
-			AssembleVersion(0, 9, 8, 11): "- User left now lists username+discriminator instead of pinging them to avoid @invalid-user problems.\n- Add ToS to !about\n- Bot now detects when it's about to be rate limited and combines short messages into a single large message. Helps keep bot responsive during huge raids.\n- Fixed race condition in spam module.",
-			AssembleVersion(0, 9, 8, 10): "- !setup can now be run by any user with the administrator role.\n- Sweetie splits up embed messages if they have more than 25 fields.\n- Added !getraid and !banraid commands\n- Replaced !wipewelcome with generic !wipe command\n- Added LinePressure, which adds pressure for each newline in a message\n- Added TrackUserLeft, which will send a message when a user leaves in addition to when they join.",
-			AssembleVersion(0, 9, 8, 9):  "- Moved several options to outside files to make self-hosting simpler to set up",
-			AssembleVersion(0, 9, 8, 8):  "- !roll returns errors now.\n- You can now change the command prefix to a different ascii character - no, you can't set it to an emoji. Don't try.",
-			AssembleVersion(0, 9, 8, 7):  "- Account creation time included on join message.\n- Specifying the config category is now optional. For example, !setconfig rules 3 \"blah\" works.",
-			AssembleVersion(0, 9, 8, 6):  "- Support a lot more time formats and make time format more obvious.",
-			AssembleVersion(0, 9, 8, 5):  "- Augment discordgo with maps instead of slices, and switch to using standard discordgo functions.",
-			AssembleVersion(0, 9, 8, 4):  "- Update discordgo.",
-			AssembleVersion(0, 9, 8, 3):  "- Allow deadlock detector to respond to deadlocks in the underlying discordgo library.\n- Fixed guild user count.",
-			AssembleVersion(0, 9, 8, 2):  "- Simplify sweetiebot setup\n- Setting autosilence now resets the lockdown timer\n- Sweetiebot won't restore the verification level if it was manually changed by an administrator.",
-			AssembleVersion(0, 9, 8, 1):  "- Switch to fork of discordgo to fix serious connection error handling issues.",
-			AssembleVersion(0, 9, 8, 0):  "- Attempts to register if she is removed from a server.\n- Silencing has been redone to minimize rate-limiting problems.\n- Sweetie now tracks the first time someone posts a message, used in the \"bannewcomers\" command, which bans everyone who sent their first message in the past two minutes (configurable).\n- Sweetie now attempts to engage a lockdown when a raid is detected by temporarily increasing the server verification level. YOU MUST GIVE HER \"MANAGE SERVER\" PERMISSIONS FOR THIS TO WORK! This can be disabled by setting Spam.LockdownDuration to 0.",
-			AssembleVersion(0, 9, 7, 9):  "- Discard Group DM errors from legacy conversations.",
-			AssembleVersion(0, 9, 7, 8):  "- Correctly deal with rare edge-case on !userinfo queries.",
-			AssembleVersion(0, 9, 7, 7):  "- Sweetiebot sends an autosilence change message before she starts silencing raiders, to ensure admins get immediate feedback even if discord is being slow.",
-			AssembleVersion(0, 9, 7, 6):  "- Sweetiebot now ignores other bots by default. To revert this, run '!setconfig basic.listentobots true' and she will listen to them again, but will never attempt to silence them.\n- Removed legacy timezones\n- Spam messages are limited to 300 characters in the log.",
-			AssembleVersion(0, 9, 7, 5):  "- Compensate for discordgo being braindead and forgetting JoinedAt dates.",
-			AssembleVersion(0, 9, 7, 4):  "- Update discordgo API.",
-			AssembleVersion(0, 9, 7, 3):  "- Fix permissions issue.",
-			AssembleVersion(0, 9, 7, 2):  "- Fix ignoring admins in anti-spam.",
-			AssembleVersion(0, 9, 7, 1):  "- Fixed an issue with out-of-date guild objects not including all server members.",
-			AssembleVersion(0, 9, 7, 0):  "- Groups have been removed and replaced with user-assignable roles. All your groups have automatically been migrated to roles. If there was a name-collision with an existing role, your group name will be prefixed with 'sb-', which you can then resolve yourself. Use '!help roles' to get usage information about the new commands.",
-			AssembleVersion(0, 9, 6, 9):  "- Sweetiebot no longer logs her own actions in the audit log",
-			AssembleVersion(0, 9, 6, 8):  "- Sweetiebot now has a deadlock detector and will auto-restart if she detects that she is not responding to !about\n- Appending @ to the end of a name or server is no longer necessary. If sweetie finds an exact match to your query, she will always use that.",
-			AssembleVersion(0, 9, 6, 7):  "- Sweetiebot no longer attempts to track edited messages for spam detection. This also fixes a timestamp bug with pinned messages.",
-			AssembleVersion(0, 9, 6, 6):  "- Sweetiebot now automatically sets Silence permissions on newly created channels. If you have a channel that silenced members should be allowed to speak in, make sure you've set it as the welcome channel via !setconfig users.welcomechannel #yourchannel",
-			AssembleVersion(0, 9, 6, 5):  "- Fix spam detection error for edited messages.",
-			AssembleVersion(0, 9, 6, 4):  "- Enforce max DB connections to try to mitigate connection problems",
-			AssembleVersion(0, 9, 6, 3):  "- Extreme spam could flood SB with user updates, crashing the database. She now throttles user updates to help prevent this.\n- Anti-spam now uses discord's message timestamp, which should prevent false positives from network problems\n- Sweetie will no longer silence mods for spamming under any circumstance.",
-			AssembleVersion(0, 9, 6, 2):  "- Renamed !quickconfig to !setup, added a friendly PM to new servers to make initial setup easier.",
-			AssembleVersion(0, 9, 6, 1):  "- Fix !bestpony crash",
-			AssembleVersion(0, 9, 6, 0):  "- Sweetiebot is now self-repairing and can function without a database, although her functionality is EXTREMELY limited in this state.",
-			AssembleVersion(0, 9, 5, 9):  "- MaxRemoveLookback no longer relies on the database and can now be used in any server. However, it only deletes messages from the channel that was spammed in.",
-			AssembleVersion(0, 9, 5, 8):  "- You can now specify per-channel pressure overrides via '!setconfig spam.maxchannelpressure <channel> <pressure>'.",
-			AssembleVersion(0, 9, 5, 7):  "- You can now do '!pick collection1+collection2' to pick a random item from multiple collections.\n- !fight <monster> is now sanitized.\n- !silence now tells you when someone already silenced will be unsilenced, if ever.",
-			AssembleVersion(0, 9, 5, 6):  "- Prevent idiots from setting status.cooldown to 0 and breaking everything.",
-			AssembleVersion(0, 9, 5, 5):  "- Fix crash on invalid command limits.",
-			AssembleVersion(0, 9, 5, 4):  "- Added ignorerole for excluding certain users from spam detection.\n- Adjusted unsilence to force bot to assume user is unsilenced so it can be used to fix race conditions.",
-			AssembleVersion(0, 9, 5, 3):  "- Prevent users from aliasing existing commands.",
-			AssembleVersion(0, 9, 5, 2):  "- Show user account creation date in userinfo\n- Added !SnowflakeTime command",
-			AssembleVersion(0, 9, 5, 1):  "- Allow !setconfig to edit float values",
-			AssembleVersion(0, 9, 5, 0):  "- Completely overhauled Anti-Spam module. Sweetie now analyzes message content and tracks text pressure users exert on the chat. See !help anti-spam for details, or !getconfig spam for descriptions of the new configuration options. Your old MaxImages and MaxPings settings were migrated over to ImagePressure and PingPressure, respectively.",
-			AssembleVersion(0, 9, 4, 5):  "- Escape nicknames correctly\n- Sweetiebot no longer tracks per-server nickname changes, only username changes.\n- You can now use the format username#1234 in user arguments.",
-			AssembleVersion(0, 9, 4, 4):  "- Fix locks, update endpoint calls, improve antispam response.",
-			AssembleVersion(0, 9, 4, 3):  "- Emergency revert of last changes",
-			AssembleVersion(0, 9, 4, 2):  "- Spammer killing is now asynchronous and should have fewer duplicate alerts.",
-			AssembleVersion(0, 9, 4, 1):  "- Attempt to make sweetiebot more threadsafe.",
-			AssembleVersion(0, 9, 4, 0):  "- Reduced number of goroutines, made updating faster.",
-			AssembleVersion(0, 9, 3, 9):  "- Added !getaudit command for server admins.\n- Updated documentation for consistency.",
-			AssembleVersion(0, 9, 3, 8):  "- Removed arbitrary limit on spam message detection, replaced with sanity limit of 600.\n- Sweetiebot now automatically detects invalid spam.maxmessage settings and removes them instead of breaking your server.\n- Replaced a GuildMember call with an initial state check to eliminate lag and some race conditions.",
-			AssembleVersion(0, 9, 3, 7):  "- If a collection only has one item, just display the item.\n- If you put \"!\" into CommandRoles[<command>], it will now allow any role EXCEPT the roles specified to use <command>. This behaves the same as the channel blacklist function.",
-			AssembleVersion(0, 9, 3, 6):  "- Add log option to autosilence.\n- Ensure you actually belong to the server you set as your default.",
-			AssembleVersion(0, 9, 3, 5):  "- Improve help messages.",
-			AssembleVersion(0, 9, 3, 4):  "- Prevent cross-server message sending exploit, without destroying all private messages this time.",
-			AssembleVersion(0, 9, 3, 3):  "- Emergency revert change.",
-			AssembleVersion(0, 9, 3, 2):  "- Prevent cross-server message sending exploit.",
-			AssembleVersion(0, 9, 3, 1):  "- Allow sweetiebot to be executed as a user bot.",
-			AssembleVersion(0, 9, 3, 0):  "- Make argument parsing more consistent\n- All commands that accepted a trailing argument without quotes no longer strip quotes out. The quotes will now be included in the query, so don't put them in if you don't want them!\n- You can now escape '\"' inside an argument via '\\\"', which will work even if discord does not show the \\ character.",
-			AssembleVersion(0, 9, 2, 3):  "- Fix echoembed crash when putting in invalid parameters.",
-			AssembleVersion(0, 9, 2, 2):  "- Update help text.",
-			AssembleVersion(0, 9, 2, 1):  "- Add !joingroup warning to deal with breathtaking stupidity of zootopia users.",
-			AssembleVersion(0, 9, 2, 0):  "- Remove !lastping\n- Help now lists modules with no commands",
-			AssembleVersion(0, 9, 1, 1):  "- Fix crash in !getconfig",
-			AssembleVersion(0, 9, 1, 0):  "- Renamed config options\n- Made things more clear for new users\n- Fixed legacy importable problem\n- Fixed command saturation\n- Added botchannel notification\n- Changed getconfig behavior for maps",
-			AssembleVersion(0, 9, 0, 4):  "- To protect privacy, !listguilds no longer lists servers that do not have Basic.Importable set to true.\n- Remove some more unnecessary sanitization",
-			AssembleVersion(0, 9, 0, 3):  "- Don't sanitize links already in code blocks",
-			AssembleVersion(0, 9, 0, 2):  "- Alphabetize collections because Tawmy is OCD",
-			AssembleVersion(0, 9, 0, 1):  "- Update documentation\n- Simplify !collections output",
-			AssembleVersion(0, 9, 0, 0):  "- Completely restructured Sweetie Bot into a module-based architecture\n- Disabling/Enabling a module now disables/enables all its commands\n- Help now includes information about modules\n- Collections command is now pretty",
-			AssembleVersion(0, 8, 17, 2): "- Added ability to hide negative rules because Tawmy is weird",
-			AssembleVersion(0, 8, 17, 1): "- Added echoembed command",
-			AssembleVersion(0, 8, 17, 0): "- Sweetiebot can now send embeds\n- Made about message pretty",
-			AssembleVersion(0, 8, 16, 3): "- Update discordgo structs to account for breaking API change.",
-			AssembleVersion(0, 8, 16, 2): "- Enable sweetiebot to tell dumbasses that they are dumbasses.",
-			AssembleVersion(0, 8, 16, 1): "- !add can now add to multiple collections at the same time.",
-			AssembleVersion(0, 8, 16, 0): "- Alphabetized the command list",
-			AssembleVersion(0, 8, 15, 4): "- ReplaceMentions now breaks role pings (but does not resolve them)",
-			AssembleVersion(0, 8, 15, 3): "- Use database to resolve users to improve responsiveness",
-			AssembleVersion(0, 8, 15, 2): "- Improved !vote error messages",
-			AssembleVersion(0, 8, 15, 1): "- Quickconfig actually sets silentrole now",
-			AssembleVersion(0, 8, 15, 0): "- Use 64-bit integer conversion",
-			AssembleVersion(0, 8, 14, 6): "- Allow adding birthdays on current day\n-Update avatar change function",
-			AssembleVersion(0, 8, 14, 5): "- Allow exact string matching on !import",
-			AssembleVersion(0, 8, 14, 4): "- Added !import\n- Added Importable option\n- Make !collections more useful",
-			AssembleVersion(0, 8, 14, 3): "- Allow pinging multiple groups via group1+group2",
-			AssembleVersion(0, 8, 14, 2): "- Fix !createpoll unique option key\n- Add !addoption",
-			AssembleVersion(0, 8, 14, 1): "- Clean up !poll",
-			AssembleVersion(0, 8, 14, 0): "- Added !poll, !vote, !createpoll, !deletepoll and !results commands",
-			AssembleVersion(0, 8, 13, 1): "- Fixed !setconfig rules",
-			AssembleVersion(0, 8, 13, 0): "- Added changelog\n- Added !rules command",
-			AssembleVersion(0, 8, 12, 0): "- Added temporary silences",
-			AssembleVersion(0, 8, 11, 5): "- Added \"dumbass\" to Sweetie Bot's vocabulary",
-			AssembleVersion(0, 8, 11, 4): "- Display channels in help for commands",
-			AssembleVersion(0, 8, 11, 3): "- Make defaultserver an independent command",
-			AssembleVersion(0, 8, 11, 2): "- Add !defaultserver command",
-			AssembleVersion(0, 8, 11, 1): "- Fix !autosilence behavior",
-			AssembleVersion(0, 8, 11, 0): "- Replace mentions in !search\n- Add temporary ban to !ban command",
-			AssembleVersion(0, 8, 10, 0): "- !ping now accepts newlines\n- Added build version to make moonwolf happy",
-			AssembleVersion(0, 8, 9, 0):  "- Add silence message for Tawmy\n- Make silence message ping user\n- Fix #27 (Sweetie Bot explodes if you search nothing)\n- Make !lastseen more reliable",
-			AssembleVersion(0, 8, 8, 0):  "- Log all commands sent to SB in DB-enabled servers",
-			AssembleVersion(0, 8, 7, 0):  "- Default to main server for PMs if it exists\n- Restrict PM commands to the server you belong in (fix #26)\n- Make spam deletion lookback configurable\n- Make !quickconfig complain if permissions are wrong\n- Add giant warning label for Tawmy\n- Prevent parse time crash\n- Make readme more clear on how things work\n- Sort !listguild by user count\n- Fallback to search all users if SB can't find one in the current server",
-			AssembleVersion(0, 8, 6, 0):  "- Add full timezone support\n- Deal with discord's broken permissions\n- Improve timezone help messages",
-			AssembleVersion(0, 8, 5, 0):  "- Add !userinfo\n- Fix #15 (Lock down !removeevent)\n- Fix guildmember query\n- Use nicknames in more places",
-			AssembleVersion(0, 8, 4, 0):  "- Update readme, remove disablebored\n- Add delete command",
-			AssembleVersion(0, 8, 3, 0):  "- Actually seed random number generator because Cloud is a FUCKING IDIOT\n- Allow newlines in commands\n- Bored module is now fully programmable\n- Display user ID in !aka\n- Hopefully stop sweetie from being an emo teenager\n- Add additional stupid proofing\n- Have bored commands override all restrictions",
-			AssembleVersion(0, 8, 2, 0):  "- Enable multi-server message logging\n- Extend !searchquote\n- Attach !lastping to current server\n- Actually make aliases work with commands",
-			AssembleVersion(0, 8, 1, 0):  "- Add dynamic collections\n- Add quotes\n- Prevent !aka command from spawning evil twins\n- Add !removealias\n- Use nicknames where possible\n- Fix off by one error\n- Sanitize !search output ",
-			AssembleVersion(0, 8, 0, 0):  "- Appease the dark gods of discord's API\n- Allow sweetiebot to track nicknames\n- update help\n- Include nickname in searches",
-		},
+		changelog:          parseChangelog(changelogFile),
 	}
 
 	if debugerr == nil && len(debugchannels) > 0 {
@@ -1313,6 +1835,39 @@ func New(token string) *SweetieBot {
 		json.Unmarshal(dbguilds, sb)
 	}
 	sb.DBGuilds[sb.MainGuildID] = true
+	limits, err := os.ReadFile("limits")
+	if err == nil && len(limits) > 0 {
+		if err = json.Unmarshal(limits, sb); err != nil {
+			fmt.Println("Error parsing limits file: ", err.Error())
+		}
+	}
+	if sb.RateLimitAlert.Threshold > 0 {
+		sb.ratelimits.resize(sb.RateLimitAlert.Threshold)
+	}
+	dmresponse, err := os.ReadFile("dmresponse")
+	if err == nil && len(dmresponse) > 0 {
+		if err = json.Unmarshal(dmresponse, sb); err != nil {
+			fmt.Println("Error parsing dmresponse file: ", err.Error())
+		}
+	}
+	phishinglist, err := os.ReadFile("phishinglist")
+	if err == nil && len(phishinglist) > 0 {
+		if err = json.Unmarshal(phishinglist, sb); err != nil {
+			fmt.Println("Error parsing phishinglist file: ", err.Error())
+		}
+	}
+	intents, err := os.ReadFile("intents")
+	if err == nil && len(intents) > 0 {
+		if err = json.Unmarshal(intents, sb); err != nil {
+			fmt.Println("Error parsing intents file: ", err.Error())
+		}
+	}
+	scripts, err := os.ReadFile("scripts")
+	if err == nil && len(scripts) > 0 {
+		if err = json.Unmarshal(scripts, sb); err != nil {
+			fmt.Println("Error parsing scripts file: ", err.Error())
+		}
+	}
 
 	rand.Intn(10)
 	for i := 0; i < 20+rand.Intn(20); i++ {
@@ -1346,13 +1901,21 @@ func New(token string) *SweetieBot {
 		return nil
 	}
 	sb.dg.LogLevel = discordgo.LogWarning
+	sb.dg.Identify.Intents = sb.Intents.mask()
+	sb.Intents.warn()
 
 	sb.dg.AddHandler(sbReady)
 	sb.dg.AddHandler(sbMessageCreate)
 	sb.dg.AddHandler(sbMessageUpdate)
 	sb.dg.AddHandler(sbMessageDelete)
+	sb.dg.AddHandler(sbMessageReactionAdd)
+	sb.dg.AddHandler(sbMessageReactionRemove)
+	sb.dg.AddHandler(sbGuildMembersChunk)
+	sb.dg.AddHandler(sbRateLimit)
+	sb.dg.AddHandler(sbInteractionCreate)
 	sb.dg.AddHandler(sbUserUpdate)
 	sb.dg.AddHandler(sbPresenceUpdate)
+	sb.dg.AddHandler(sbVoiceStateUpdate)
 	sb.dg.AddHandler(sbGuildUpdate)
 	sb.dg.AddHandler(sbGuildMemberAdd)
 	sb.dg.AddHandler(sbGuildMemberRemove)
@@ -1360,9 +1923,13 @@ func New(token string) *SweetieBot {
 	sb.dg.AddHandler(sbGuildBanAdd)
 	sb.dg.AddHandler(sbGuildBanRemove)
 	sb.dg.AddHandler(sbGuildRoleDelete)
+	sb.dg.AddHandler(sbAutoModerationActionExecution)
 	sb.dg.AddHandler(sbGuildCreate)
 	sb.dg.AddHandler(sbChannelCreate)
+	sb.dg.AddHandler(sbThreadCreate)
 	sb.dg.AddHandler(sbChannelDelete)
+	sb.dg.AddHandler(sbInviteCreate)
+	sb.dg.AddHandler(sbInviteDelete)
 
 	if sb.Debug { // The server does not necessarily tie a standard input to the program
 		go func() {
@@ -1372,8 +1939,21 @@ func New(token string) *SweetieBot {
 		}()
 	}
 
+	sb.cron.Register("backupconfigs", "@daily", backupConfigs)
+	sb.cron.Register("pruneactivity", "@daily", pruneActivity)
+	sb.cron.Register("channelreminders", "@every 1m", runChannelReminders)
+	sb.cron.Register("memberresync", "@daily", resyncAllMembers)
+	sb.cron.Register("colorroles", "@daily", pruneColorRoles)
+	sb.cron.Register("webhooks", "@daily", pruneWebhooks)
+	sb.cron.Register("phishinglist", phishingRefresh, refreshPhishingJob)
+	sb.cron.Register("quiethours", "@every 1m", flushQuietHours)
+	sb.cron.Register("inactivity", "@daily", removeInactiveRoles)
+	sb.cron.Register("digest", "@hourly", postDigests)
+	sb.cron.Register("verifymembers", "@hourly", recomputeVerified)
+
 	go idleCheckLoop()
 	go deadlockDetector()
+	go cronLoop()
 
 	//BuildMarkov(1, 1)
 	return sb
@@ -1388,7 +1968,7 @@ func (sbot *SweetieBot) Connect() {
 			time.Sleep(400 * time.Millisecond)
 		}
 	} else {
-		fmt.Println("Error opening websocket connection: ", err.Error())
+		fmt.Println("Error opening websocket connection: ", err.Error()+intentError(err))
 	}
 
 	fmt.Println("Sweetiebot quitting")
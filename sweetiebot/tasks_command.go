@@ -0,0 +1,186 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Tasks are shown this many at a time
+const tasksPageSize = 15
+
+// No more than this many scheduled events are loaded at once, which is the most a server can have
+const tasksEventLimit = 5000
+
+// Something Sweetie Bot is going to do later, from any of the places pending work is kept
+type pendingTask struct {
+	id    string // #123 for a scheduled event, R12 for a channel reminder, or the name of a recurring job
+	when  time.Time
+	kind  string
+	owner uint64
+	desc  string
+}
+
+// Task types that aren't scheduled events
+const (
+	taskTypeChannelReminders = 253
+	taskTypeJobs             = 254
+	taskTypeAll              = 255
+)
+
+func getTaskType(s string) uint8 {
+	switch strings.ToLower(s) {
+	case "channelreminders", "channelreminder":
+		return taskTypeChannelReminders
+	case "jobs", "job":
+		return taskTypeJobs
+	case "all":
+		return taskTypeAll
+	}
+	return getScheduleType(s)
+}
+
+// Collects the pending tasks of the given type, soonest first. Recurring jobs run for every server at once, so only the
+// bot owner sees them.
+func getPendingTasks(info *GuildInfo, ty uint8, user *discordgo.User) []pendingTask {
+	tasks := []pendingTask{}
+	if ty != taskTypeChannelReminders && ty != taskTypeJobs {
+		var events []ScheduleEvent
+		if ty == taskTypeAll {
+			events = sb.db.GetAllEvents(SBatoi(info.ID), tasksEventLimit)
+		} else {
+			events = sb.db.GetEventsByType(SBatoi(info.ID), ty, tasksEventLimit)
+		}
+		for i := range events {
+			kind, desc := describeEvent(info, &events[i], "")
+			tasks = append(tasks, pendingTask{"#" + SBitoa(events[i].ID), events[i].Date, kind, eventOwner(&events[i]), desc})
+		}
+	}
+	if ty == taskTypeAll || ty == taskTypeChannelReminders {
+		for _, r := range sb.db.GetChannelReminders(SBatoi(info.ID)) {
+			if !r.Paused {
+				tasks = append(tasks, pendingTask{fmt.Sprintf("R%v", r.ID), r.NextRun, "CHANNEL REMINDER:#" + getChannelName(SBitoa(r.Channel)), 0, r.Message + " (" + r.Spec + ")"})
+			}
+		}
+	}
+	if (ty == taskTypeAll || ty == taskTypeJobs) && isBotOwner(user.ID) {
+		for _, j := range sb.cron.Jobs() {
+			tasks = append(tasks, pendingTask{j.Name, j.Next, "JOB", 0, j.Spec})
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].when.Before(tasks[j].when) })
+	return tasks
+}
+
+type tasksCommand struct {
+}
+
+func (c *tasksCommand) Name() string {
+	return "Tasks"
+}
+func (c *tasksCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) > 0 && strings.ToLower(args[0]) == "cancel" {
+		if len(args) < 2 {
+			return "```You must give the ID of the task to cancel, as shown by this command.```", false, nil
+		}
+		return cancelTask(info, msg, args[1]), false, nil
+	}
+
+	ty := uint8(taskTypeAll)
+	page := 1
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil {
+			page = n
+		} else if ty = getTaskType(arg); ty == 255 && strings.ToLower(arg) != "all" {
+			return "```" + arg + " isn't a type of task. Use bans, birthdays, messages, episodes, events, roles, silences, reminders, announcements, bumps, kicks, boosts, temproles, channelreminders or jobs.```", false, nil
+		}
+	}
+	if page < 1 {
+		page = 1
+	}
+	tasks := getPendingTasks(info, ty, msg.Author)
+	if len(tasks) == 0 {
+		return "```There are no pending tasks of that type.```", false, nil
+	}
+	pages := (len(tasks) + tasksPageSize - 1) / tasksPageSize
+	if page > pages {
+		return fmt.Sprintf("```There's no page %v. The last page is %v.```", page, pages), false, nil
+	}
+	tz := getTimezone(info, nil)
+	lines := make([]string, 0, tasksPageSize+2)
+	lines = append(lines, fmt.Sprintf("%s pending (page %v of %v, times in %s):", Pluralize(int64(len(tasks)), " task"), page, pages, tz.String()))
+	now := time.Now().UTC()
+	for _, t := range tasks[(page-1)*tasksPageSize : min(page*tasksPageSize, len(tasks))] {
+		line := fmt.Sprintf("%s %s (in %s) [%s] %s", t.id, t.when.In(tz).Format("Jan 2, 2006 3:04pm"), TimeDiff(t.when.Sub(now)), t.kind, truncateRunes(ReplaceAllMentions(t.desc), 100))
+		if t.owner != 0 {
+			line += " - " + getUserName(t.owner, info)
+		}
+		lines = append(lines, line)
+	}
+	if page < pages {
+		lines = append(lines, fmt.Sprintf("Add %v to see the next page.", page+1))
+	}
+	return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```", false, nil
+}
+
+// Cancels the scheduled event or channel reminder with the given ID. Admins and the bot owner can cancel anything, and
+// anyone else only what they scheduled themselves.
+func cancelTask(info *GuildInfo, msg *discordgo.Message, arg string) string {
+	admin := info.HasAdminRole(msg.Author.ID) || isBotOwner(msg.Author.ID)
+	name := getUserName(SBatoi(msg.Author.ID), info)
+	if len(arg) > 1 && (arg[0] == 'R' || arg[0] == 'r') {
+		id, err := strconv.ParseUint(arg[1:], 10, 64)
+		if err != nil {
+			return "```" + arg + " isn't a channel reminder ID.```"
+		}
+		if !admin {
+			return "```Only admins can cancel channel reminders from here.```"
+		}
+		if !sb.db.RemoveChannelReminder(id, SBatoi(info.ID)) {
+			return "```There's no channel reminder " + arg + " on this server.```"
+		}
+		info.LogTo(LogModeration, name, " cancelled channel reminder ", arg)
+		return "```Cancelled channel reminder " + arg + ".```"
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil {
+		return "```" + arg + " isn't a task ID. Recurring jobs can't be cancelled, only scheduled events and channel reminders.```"
+	}
+	e := sb.db.GetGuildEvent(id, SBatoi(info.ID))
+	if e == nil {
+		return "```There's no task #" + SBitoa(id) + " on this server.```"
+	}
+	if !admin && eventOwner(e) != SBatoi(msg.Author.ID) {
+		return "```Only admins can cancel tasks scheduled by someone else.```"
+	}
+	kind, desc := describeEvent(info, e, "")
+	sb.db.RemoveSchedule(id)
+	info.LogTo(LogModeration, name, " cancelled task #", id, " [", kind, "] ", desc)
+	s := "Cancelled task #" + SBitoa(id) + "."
+	switch e.Type {
+	case 0:
+		s += " They will stay banned until someone unbans them."
+	case 8:
+		s += " They will stay silenced until someone unsilences them."
+	case scheduleTempRole:
+		s += " They will keep the role until someone removes it."
+	}
+	return "```" + s + "```"
+}
+func (c *tasksCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Lists everything Sweetie Bot has scheduled on this server, soonest first: temporary bans and silences, temporary roles, reminders, scheduled messages and announcements, birthdays, channel reminders, bump reminders and rules kicks, with when they'll happen in the server's timezone and who scheduled them. The bot owner also sees the recurring jobs. `" + info.config.Basic.CommandPrefix + "tasks cancel <id>` cancels one. Admins can cancel anything, and anyone else only what they scheduled.",
+		Params: []CommandUsageParam{
+			{Name: "type", Desc: "Only list one type of task: bans, birthdays, messages, episodes, events, roles, silences, reminders, announcements, bumps, kicks, boosts, temproles, channelreminders or jobs.", Optional: true},
+			{Name: "page", Desc: "Which page to show. Tasks are shown 15 at a time.", Optional: true},
+		},
+	}
+}
+func (c *tasksCommand) UsageShort() string { return "Lists and cancels scheduled tasks." }
@@ -0,0 +1,93 @@
+package sweetiebot
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Matches a {name} placeholder in a configured message
+var templateregex = regexp.MustCompile(`\{([a-zA-Z0-9_]+)\}`)
+
+// Variables can refer to other variables, but only this deep, so a long chain can't blow up a message
+const maxTemplateDepth = 5
+
+// Discord rejects messages longer than this, so there's no point expanding a template any further
+const maxTemplateLength = 2000
+
+// No more than this many placeholders are filled in while rendering one message, however the variables refer to each other
+const maxTemplateWork = 500
+
+// Placeholders filled in by whatever feature is sending the message. Variables can't use these names, since they'd never be seen.
+var templateBuiltins = map[string]bool{"user": true, "username": true, "channel": true, "server": true, "action": true, "reason": true, "message": true, "count": true, "role": true, "prefix": true}
+
+// Replaces {name} placeholders in a configured message with the given built-in values and the server's variables from
+// basic.variables. Built-in values are inserted as they are, so a username containing {something} is never expanded.
+// Variables that refer to themselves, directly or through other variables, are left as they were.
+func renderTemplate(info *GuildInfo, s string, builtins map[string]string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	t := &templateRender{info: info, builtins: builtins, expanding: make(map[string]bool), expanded: make(map[string]string)}
+	return t.expand(s, 0)
+}
+
+// The state of rendering one message. Each variable is only expanded once, so variables that use each other many
+// times over can't make rendering take exponentially long.
+type templateRender struct {
+	info      *GuildInfo
+	builtins  map[string]string
+	expanding map[string]bool   // variables in the middle of being expanded, which are left alone if they come up again
+	expanded  map[string]string // variables that have already been expanded
+	work      int
+}
+
+func (t *templateRender) expand(s string, depth int) string {
+	r := templateregex.ReplaceAllStringFunc(s, func(match string) string {
+		name := strings.ToLower(match[1 : len(match)-1])
+		if v, ok := t.builtins[name]; ok {
+			return v
+		}
+		if name == "server" {
+			return t.info.Name
+		}
+		if v, ok := t.expanded[name]; ok {
+			return v
+		}
+		v, ok := t.info.config.Basic.Variables[name]
+		if !ok || t.expanding[name] || depth >= maxTemplateDepth || t.work >= maxTemplateWork {
+			return match
+		}
+		t.work++
+		t.expanding[name] = true
+		v = t.expand(v, depth+1)
+		delete(t.expanding, name)
+		t.expanded[name] = v
+		return v
+	})
+	return truncateRunes(r, maxTemplateLength)
+}
+
+// Returns the chain of variables leading from name back to itself, or nil if expanding it never loops
+func templateCycle(info *GuildInfo, name string) []string {
+	visited := make(map[string]bool)
+	var visit func(cur string, path []string) []string
+	visit = func(cur string, path []string) []string {
+		if len(path) > maxTemplateDepth || visited[cur] {
+			return nil
+		}
+		visited[cur] = true
+		for _, m := range templateregex.FindAllStringSubmatch(info.config.Basic.Variables[cur], -1) {
+			ref := strings.ToLower(m[1])
+			if ref == name {
+				return append(path, ref)
+			}
+			if _, ok := info.config.Basic.Variables[ref]; ok {
+				if cycle := visit(ref, append(path, ref)); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		return nil
+	}
+	return visit(name, []string{name})
+}
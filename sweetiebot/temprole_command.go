@@ -0,0 +1,119 @@
+package sweetiebot
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Schedule type used to take a temporary role away again. The data is the user ID and the role ID, separated by |
+const scheduleTempRole = 13
+
+// Takes away a temporary role once it expires. Members who left are ignored, since they'll lose it when they rejoin.
+func expireTempRole(info *GuildInfo, data string) {
+	dat := strings.SplitN(data, "|", 2)
+	if len(dat) != 2 {
+		return
+	}
+	user, role := dat[0], dat[1]
+	if _, err := info.GetMember(user); err != nil || !info.UserHasRole(user, role) {
+		return
+	}
+	rolename := role
+	if r, err := sb.dg.State.Role(info.ID, role); err == nil {
+		rolename = r.Name
+	}
+	err := CallAPI("GuildMemberRoleRemove", func() error { return sb.dg.GuildMemberRoleRemove(info.ID, user, role) })
+	if err != nil {
+		info.LogTo(LogModeration, "Couldn't take the temporary role ", rolename, " away from ", getUserName(SBatoi(user), info), ": ", apiErrorMessage(err))
+		return
+	}
+	info.LogTo(LogModeration, "The temporary role ", rolename, " expired for ", getUserName(SBatoi(user), info), ".")
+}
+
+// OnGuildMemberAdd gives back temporary roles that haven't expired yet to members who left and came back, if
+// users.temprolerejoin is set
+func (w *RolesModule) OnGuildMemberAdd(info *GuildInfo, m *discordgo.Member) {
+	if !info.config.Users.TempRoleRejoin || m.User == nil || !sb.db.CheckStatus() {
+		return
+	}
+	for _, e := range sb.db.GetEventsByType(SBatoi(info.ID), scheduleTempRole, 5000) {
+		dat := strings.SplitN(e.Data, "|", 2)
+		if len(dat) != 2 || dat[0] != m.User.ID {
+			continue
+		}
+		role, err := sb.dg.State.Role(info.ID, dat[1])
+		if err != nil {
+			continue
+		}
+		err = CallAPI("GuildMemberRoleAdd", func() error { return sb.dg.GuildMemberRoleAdd(info.ID, m.User.ID, role.ID) })
+		if err != nil {
+			info.LogTo(LogModeration, "Couldn't give ", role.Name, " back to ", m.User.Username, " after they rejoined: ", apiErrorMessage(err))
+			continue
+		}
+		info.LogTo(LogModeration, "Gave the temporary role ", role.Name, " back to ", m.User.Username, " after they rejoined. It expires in ", TimeDiff(e.Date.Sub(time.Now().UTC())), ".")
+	}
+}
+
+type tempRoleCommand struct {
+}
+
+func (c *tempRoleCommand) Name() string {
+	return "TempRole"
+}
+func (c *tempRoleCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "user", Desc: "A ping of the user, or their name in quotes.", Type: ArgUser},
+		{Name: "role", Desc: "The name of the role, or a ping of it. Put names with spaces in quotes.", Type: ArgRole},
+		{Name: "duration", Desc: "How long they keep the role, like 30m, 12h or 3d.", Type: ArgDuration},
+	}
+}
+func (c *tempRoleCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *tempRoleCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	user := SBitoa(args.User("user"))
+	role, e := findRole(args.String("role"), info)
+	if role == nil {
+		return e, false, nil
+	}
+	if e := checkMassRole(info, role, msg.Author); len(e) > 0 {
+		return e, false, nil
+	}
+	if _, err := info.GetMember(user); err != nil {
+		return "```" + getUserName(SBatoi(user), info) + " isn't on this server.```", false, nil
+	}
+	duration := args.Duration("duration")
+	expires := time.Now().UTC().Add(duration)
+	data := user + "|" + role.ID
+	gID := SBatoi(info.ID)
+	extended := false
+	if id := sb.db.FindEvent(data, gID, scheduleTempRole); id != nil {
+		sb.db.RemoveSchedule(*id) // Giving someone the same temporary role again just changes when it expires
+		extended = true
+	}
+	if !info.UserHasRole(user, role.ID) {
+		err := CallAPI("GuildMemberRoleAdd", func() error { return sb.dg.GuildMemberRoleAdd(info.ID, user, role.ID) })
+		if err != nil {
+			return "```Couldn't give them " + role.Name + ": " + apiErrorMessage(err) + "```", false, nil
+		}
+	} else if !extended {
+		return "```" + getUserName(SBatoi(user), info) + " already has " + role.Name + ", so it wouldn't be temporary.```", false, nil
+	}
+	if !sb.db.AddSchedule(gID, expires, scheduleTempRole, data) {
+		return "```Gave them " + role.Name + ", but couldn't schedule its removal because this server has too many events. Remove it yourself later.```", false, nil
+	}
+	info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " gave ", getUserName(SBatoi(user), info), " the temporary role ", role.Name, " for ", TimeDiff(duration), ".")
+	return "```Gave " + getUserName(SBatoi(user), info) + " " + role.Name + " for " + TimeDiff(duration) + ". It will be removed " + ApplyTimezone(expires, info, msg.Author).Format(time.RFC1123) + ".```", false, nil
+}
+func (c *tempRoleCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Gives someone a role and schedules its removal, which survives restarts and shows up in `!schedule temproles`. Using it again on someone who still has the temporary role changes when it expires. Both you and Sweetie Bot must have a higher role than the one being given. If `users.temprolerejoin` is true, members who leave and come back before it expires get the role back.",
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *tempRoleCommand) UsageShort() string { return "Gives someone a role for a while." }
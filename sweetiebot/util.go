@@ -225,6 +225,57 @@ func (info *GuildInfo) UserHasAnyRole(user string, roles map[string]bool) bool {
 	return reverse
 }
 
+// HasAdminRole returns true if the user owns the server, has the Administrator permission, or has one of the roles in basic.adminroles
+func (info *GuildInfo) HasAdminRole(user string) bool {
+	if g, err := sb.dg.State.Guild(info.ID); err == nil && g.OwnerID == user {
+		return true
+	}
+	if perms, err := getAllPerms(info, user); err == nil && perms&discordgo.PermissionAdministrator != 0 {
+		return true
+	}
+	return len(info.config.Basic.AdminRoles) > 0 && info.userHasListedRole(user, info.config.Basic.AdminRoles)
+}
+
+// HasModRole returns true if the user has basic.alertrole, one of the roles in basic.modroles, the Manage Messages
+// permission, or is an admin
+func (info *GuildInfo) HasModRole(user string) bool {
+	if info.config.Basic.AlertRole != 0 && info.UserHasRole(user, SBitoa(info.config.Basic.AlertRole)) {
+		return true
+	}
+	if len(info.config.Basic.ModRoles) > 0 && info.userHasListedRole(user, info.config.Basic.ModRoles) {
+		return true
+	}
+	if perms, err := getAllPerms(info, user); err == nil && perms&discordgo.PermissionManageMessages != 0 {
+		return true
+	}
+	return info.HasAdminRole(user)
+}
+
+// Unlike UserHasAnyRole, an empty map matches nobody and "!" has no special meaning
+func (info *GuildInfo) userHasListedRole(user string, roles map[string]bool) bool {
+	m, err := info.GetMember(user)
+	if err != nil {
+		return false
+	}
+	for _, v := range m.Roles {
+		if roles[v] {
+			return true
+		}
+	}
+	return false
+}
+
+// UserCanRunCommand returns true if the user is allowed to run the command under modules.commandroles. Admins can run
+// everything, and any moderator can run a command restricted to basic.alertrole.
+func (info *GuildInfo) UserCanRunCommand(user string, command string) bool {
+	roles := info.config.Modules.CommandRoles[command]
+	if info.UserHasAnyRole(user, roles) || info.HasAdminRole(user) {
+		return true
+	}
+	_, reverse := roles["!"]
+	return !reverse && info.config.Basic.AlertRole != 0 && roles[SBitoa(info.config.Basic.AlertRole)] && info.HasModRole(user)
+}
+
 // GetMember attempts to get a member from the guild by checking the state first before making the REST API call.
 func (info *GuildInfo) GetMember(id string) (*discordgo.Member, error) {
 	m, err := sb.dg.State.Member(info.ID, id)
@@ -958,8 +1009,294 @@ func MigrateSettings(config []byte, guild *GuildInfo) error {
 		guild.config.Spam.LinePressure = (guild.config.Spam.MaxPressure - guild.config.Spam.BasePressure) / 70.0
 	}
 
-	if guild.config.Version != 19 {
-		guild.config.Version = 19 // set version to most recent config version
+	if guild.config.Version <= 19 {
+		restrictCommand("addemoji", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("removeemoji", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("renameemoji", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("addsticker", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("removesticker", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("renamesticker", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 20 {
+		guild.config.Profile.Debounce = 60
+	}
+
+	if guild.config.Version <= 21 {
+		guild.config.Spam.EditGrace = 300
+	}
+
+	if guild.config.Version <= 22 {
+		restrictCommand("modlog", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 23 {
+		restrictCommand("modules", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 24 {
+		restrictCommand("say", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("editsay", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 25 {
+		guild.config.Privacy.StoreContent = true // Existing servers already log message content, so don't silently break their chat logs
+		guild.config.Privacy.CacheSize = 1000
+		guild.config.Privacy.CacheTTL = 86400
+	}
+
+	if guild.config.Version <= 26 {
+		for _, c := range []string{"addfilter", "removefilter", "listfilter", "addexception", "removeexception", "testfilter"} {
+			restrictCommand(c, guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		}
+	}
+
+	if guild.config.Version <= 27 {
+		for _, c := range []string{"remindchannel", "pausereminder", "resumereminder", "removechannelreminder"} {
+			restrictCommand(c, guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		}
+	}
+
+	if guild.config.Version <= 28 {
+		restrictCommand("massrole", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 29 {
+		restrictCommand("exempt", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("unexempt", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 30 {
+		guild.config.Approval.Tenure = 86400
+		restrictCommand("approve", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 31 {
+		restrictCommand("resyncmembers", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 32 {
+		guild.config.Warnings.DecayDays = 30
+		guild.config.Warnings.DecayCurve = "linear"
+		restrictCommand("warn", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("warnings", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 33 {
+		guild.config.AutoThread.Name = "{message}"
+		guild.config.AutoThread.Archive = 1440
+	}
+
+	if guild.config.Version <= 34 {
+		guild.config.Spam.ShortCount = 5
+		guild.config.Spam.ShortTime = 10
+	}
+
+	if guild.config.Version <= 35 {
+		restrictCommand("autoreact", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 36 {
+		guild.config.Sticky.Messages = 5
+		guild.config.Sticky.Delay = 15
+		restrictCommand("stickymessage", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("unstick", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 37 {
+		guild.config.Spam.RolePingCount = 3
+		guild.config.Spam.RolePingTime = 600
+		guild.config.Spam.RolePingCooldown = 3600
+	}
+
+	if guild.config.Version <= 38 {
+		guild.config.WelcomeCard.AvatarX = 36
+		guild.config.WelcomeCard.AvatarY = 36
+		guild.config.WelcomeCard.AvatarSize = 128
+		guild.config.WelcomeCard.TextX = 200
+		guild.config.WelcomeCard.TextY = 60
+		guild.config.WelcomeCard.TextScale = 4
+		guild.config.WelcomeCard.TextColor = "#FFFFFF"
+	}
+
+	if guild.config.Version <= 39 {
+		guild.config.Spam.ActionNotify = true
+	}
+
+	if guild.config.Version <= 40 {
+		restrictCommand("rolemenu", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 41 {
+		guild.config.TempVoice.Name = "{user}'s channel"
+	}
+
+	if guild.config.Version <= 42 {
+		guild.config.Filter.Actions = map[string]string{"mild": "delete", "strong": "delete", "slur": "warn"}
+	}
+
+	if guild.config.Version <= 43 {
+		guild.config.Snipe.MaxAge = 300
+		restrictCommand("snipe", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("editsnipe", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 44 {
+		restrictCommand("modroles", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("adminroles", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 45 {
+		restrictCommand("moveall", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("summon", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 46 {
+		guild.config.Phishing.Silence = true
+		restrictCommand("refreshphishing", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 47 {
+		guild.config.Bump.Bot = 302050872383242240
+		guild.config.Bump.Pattern = "bump done"
+		guild.config.Bump.Cooldown = 7200
+	}
+
+	if guild.config.Version <= 48 {
+		restrictCommand("settopic", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 49 {
+		guild.config.Spam.SilenceNewChannels = true
+	}
+
+	if guild.config.Version <= 50 {
+		restrictCommand("fixmute", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 51 {
+		restrictCommand("logchannel", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 52 {
+		restrictCommand("rulesgate", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 53 {
+		restrictCommand("spamtest", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 54 {
+		restrictCommand("guildevent", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 55 {
+		guild.config.Spam.ReactionCount = 10
+		guild.config.Spam.ReactionTime = 5
+		restrictCommand("setvar", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("delvar", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 56 {
+		guild.config.Basic.MentionCommands = true
+	}
+
+	if guild.config.Version <= 57 {
+		restrictCommand("interrupted", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 58 {
+		restrictCommand("persona", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 59 {
+		restrictCommand("channeltemplate", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 60 {
+		guild.config.Boosts.Message = defaultBoostMessage
+		guild.config.Boosts.Grace = 259200
+	}
+
+	if guild.config.Version <= 61 {
+		restrictCommand("quiethours", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 62 {
+		restrictCommand("suggestion", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 63 {
+		restrictCommand("temprole", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 64 {
+		guild.config.Spam.ForwardCount = 4
+		guild.config.Spam.ForwardTime = 10
+	}
+
+	if guild.config.Version <= 65 {
+		restrictCommand("preflight", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 66 {
+		restrictCommand("digest", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		guild.config.Digest.Day = 1
+		guild.config.Digest.Hour = 9
+	}
+
+	if guild.config.Version <= 67 {
+		guild.config.Spam.FingerprintTime = 300
+		guild.config.Spam.FingerprintEdits = 2
+		guild.config.Spam.FingerprintCreated = 3600
+	}
+
+	if guild.config.Version <= 68 {
+		restrictCommand("migrateuser", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 70 {
+		guild.config.Chatter.Messages = 30
+		guild.config.Chatter.Period = 600
+	}
+
+	if guild.config.Version <= 71 {
+		guild.config.Warnings.SelfService = true
+	}
+
+	if guild.config.Version <= 72 {
+		guild.config.AutoMod.Default = "ignore" // Only rules a moderator chose to count are tied into warnings
+		guild.config.AutoMod.SyncTier = "slur"
+		restrictCommand("syncautomod", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 73 {
+		restrictCommand("tasks", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 74 {
+		guild.config.Spam.VerifiedMessages = 200
+		guild.config.Spam.VerifiedRelax = map[string]bool{"length": true, "lines": true, "repeat": true, "short": true}
+	}
+
+	if guild.config.Version <= 75 {
+		restrictCommand("config", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 76 {
+		guild.config.Spam.SpacerLines = 30
+	}
+
+	if guild.config.Version <= 77 {
+		restrictCommand("bulkimport", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 78 {
+		restrictCommand("reactions", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole) // Each use can fetch thousands of reactors
+	}
+
+	if guild.config.Version != 79 {
+		guild.config.Version = 79 // set version to most recent config version
 		guild.SaveConfig()
 	}
 	return nil
@@ -1047,9 +1384,12 @@ func getAllPerms(info *GuildInfo, user string) (int64, error) {
 		return 0, err
 	}
 	var perms int64
+	if everyone, err := sb.dg.State.Role(info.ID, info.ID); err == nil {
+		perms |= int64(everyone.Permissions)
+	}
 	for _, r := range m.Roles {
 		role, err := sb.dg.State.Role(info.ID, r)
-		if err != nil {
+		if err == nil {
 			perms |= int64(role.Permissions)
 		}
 	}
@@ -1106,24 +1446,60 @@ func setupSilenceRole(info *GuildInfo) {
 			return
 		}
 		for _, ch := range guild.Channels {
-			if SBatoi(ch.ID) != info.config.Users.WelcomeChannel {
-				var allow int64 = 0
-				var deny int64 = 0
-				for _, v := range ch.PermissionOverwrites {
-					if v.Type == discordgo.PermissionOverwriteTypeRole && SBatoi(v.ID) == info.config.Spam.SilentRole {
-						allow = v.Allow
-						deny = v.Deny
-						break
-					}
-				}
-				allow &= (^int64(0x00000800))
-				deny |= 0x00000800
-				sb.dg.ChannelPermissionSet(ch.ID, SBitoa(info.config.Spam.SilentRole), discordgo.PermissionOverwriteTypeRole, allow, deny)
-			}
+			applySilenceOverwrite(info, ch)
 		}
 	}
 }
 
+// Permissions the silence role is denied in every channel except the welcome channel. Threads have their own send
+// permission, so without it silenced members could keep talking in any thread.
+const silenceDeny = discordgo.PermissionSendMessages | discordgo.PermissionSendMessagesInThreads |
+	discordgo.PermissionCreatePublicThreads | discordgo.PermissionCreatePrivateThreads
+
+// Adds the silence role's overwrite to a channel. Returns false if the channel already had it, or doesn't need it.
+func applySilenceOverwrite(info *GuildInfo, ch *discordgo.Channel) (bool, error) {
+	if info.config.Spam.SilentRole == 0 || SBatoi(ch.ID) == info.config.Users.WelcomeChannel || ch.IsThread() {
+		return false, nil
+	}
+	var allow int64 = 0
+	var deny int64 = 0
+	for _, v := range ch.PermissionOverwrites {
+		if v.Type == discordgo.PermissionOverwriteTypeRole && SBatoi(v.ID) == info.config.Spam.SilentRole {
+			allow = v.Allow
+			deny = v.Deny
+			break
+		}
+	}
+	if deny&silenceDeny == silenceDeny && allow&silenceDeny == 0 {
+		return false, nil
+	}
+	allow &= ^int64(silenceDeny)
+	deny |= silenceDeny
+	return true, CallAPI("ChannelPermissionSet", func() error {
+		return sb.dg.ChannelPermissionSet(ch.ID, SBitoa(info.config.Spam.SilentRole), discordgo.PermissionOverwriteTypeRole, allow, deny)
+	})
+}
+
+// Silences a newly created channel, or the channel a new thread was made in, logging whether it worked
+func silenceNewChannel(info *GuildInfo, ch *discordgo.Channel) {
+	if !info.config.Spam.SilenceNewChannels || info.config.Spam.SilentRole == 0 {
+		return
+	}
+	if ch.IsThread() {
+		parent, err := sb.dg.State.Channel(ch.ParentID)
+		if err != nil {
+			return
+		}
+		ch = parent // Threads inherit the permissions of the channel they're in
+	}
+	changed, err := applySilenceOverwrite(info, ch)
+	if err != nil {
+		info.LogTo(LogModeration, "Failed to apply the silence role's permissions to #", ch.Name, ", so silenced members can talk there: ", err.Error())
+	} else if changed {
+		info.LogTo(LogModeration, "Applied the silence role's permissions to #", ch.Name, ".")
+	}
+}
+
 // UnsilenceMember unsilences the member, if they are silenced
 func UnsilenceMember(user uint64, info *GuildInfo) error {
 	m, err := info.GetMember(SBitoa(user))
@@ -1133,5 +1509,7 @@ func UnsilenceMember(user uint64, info *GuildInfo) error {
 		sb.dg.State.Unlock()
 	}
 
-	return sb.dg.GuildMemberRoleRemove(info.ID, SBitoa(user), SBitoa(info.config.Spam.SilentRole))
+	return CallAPI("GuildMemberRoleRemove", func() error {
+		return sb.dg.GuildMemberRoleRemove(info.ID, SBitoa(user), SBitoa(info.config.Spam.SilentRole))
+	})
 }
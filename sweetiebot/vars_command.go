@@ -0,0 +1,114 @@
+package sweetiebot
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var varnameregex = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// Keeps the config file from being used as a dumping ground
+const maxGuildVariables = 100
+
+const maxVariableLength = 1000
+
+// setVarCommand edits basic.variables, the values that can be used as {name} in welcome messages, witty responses,
+// announcements and other configured messages
+type setVarCommand struct {
+}
+
+func (c *setVarCommand) Name() string {
+	return "SetVar"
+}
+func (c *setVarCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		if len(info.config.Basic.Variables) == 0 {
+			return "```No variables have been set. Use !setvar <name> <value> to add one.```", false, nil
+		}
+		names := MapStringToSlice(info.config.Basic.Variables)
+		sort.Strings(names)
+		lines := make([]string, 0, len(names))
+		for _, k := range names {
+			lines = append(lines, "{"+k+"}: "+info.config.Basic.Variables[k])
+		}
+		return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```", len(lines) > 6, nil
+	}
+	name := strings.ToLower(strings.Trim(args[0], "{}"))
+	if !varnameregex.MatchString(name) {
+		return "```Variable names can only have letters, numbers and underscores, and can't be longer than 32 characters.```", false, nil
+	}
+	if templateBuiltins[name] {
+		return "```{" + name + "} is filled in automatically, so it can't be a variable.```", false, nil
+	}
+	if len(args) < 2 {
+		if v, ok := info.config.Basic.Variables[name]; ok {
+			return "```{" + name + "}: " + PartialSanitize(v) + "```", false, nil
+		}
+		return "```You must provide a value for {" + name + "}.```", false, nil
+	}
+	value := strings.TrimSpace(msg.Content[indices[1]:])
+	if len([]rune(value)) > maxVariableLength {
+		return "```Variables can't be longer than 1000 characters.```", false, nil
+	}
+	old, existed := info.config.Basic.Variables[name]
+	if !existed && len(info.config.Basic.Variables) >= maxGuildVariables {
+		return "```This server already has 100 variables. Remove one with !delvar first.```", false, nil
+	}
+	CheckMapNilString(&info.config.Basic.Variables)
+	info.config.Basic.Variables[name] = value
+	if cycle := templateCycle(info, name); cycle != nil {
+		if existed {
+			info.config.Basic.Variables[name] = old
+		} else {
+			delete(info.config.Basic.Variables, name)
+		}
+		return "```That would make {" + name + "} refer to itself: {" + strings.Join(cycle, "} -> {") + "}```", false, nil
+	}
+	info.SaveConfig()
+	return "```{" + name + "} is now set to: " + PartialSanitize(value) + "```", false, nil
+}
+func (c *setVarCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Sets a server variable, which is filled in wherever `{name}` appears in the welcome message, witty responses, announcements, bump reminders and the anti-spam action message. Variables can use other variables, but not themselves. With only a name, shows that variable's value, and with no arguments, lists all of them.",
+		Params: []CommandUsageParam{
+			{Name: "name", Desc: "The name of the variable, like `rules_link`.", Optional: true},
+			{Name: "value", Desc: "What to replace it with.", Optional: true},
+		},
+	}
+}
+func (c *setVarCommand) UsageShort() string {
+	return "Sets a variable for configured messages."
+}
+
+type delVarCommand struct {
+}
+
+func (c *delVarCommand) Name() string {
+	return "DelVar"
+}
+func (c *delVarCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		return "```You must provide the name of the variable to remove.```", false, nil
+	}
+	name := strings.ToLower(strings.Trim(args[0], "{}"))
+	if _, ok := info.config.Basic.Variables[name]; !ok {
+		return "```There's no variable called {" + name + "}.```", false, nil
+	}
+	delete(info.config.Basic.Variables, name)
+	info.SaveConfig()
+	return "```Removed {" + name + "}. Any message that still uses it will show {" + name + "} as it is.```", false, nil
+}
+func (c *delVarCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Removes a server variable set with `!setvar`.",
+		Params: []CommandUsageParam{
+			{Name: "name", Desc: "The name of the variable.", Optional: false},
+		},
+	}
+}
+func (c *delVarCommand) UsageShort() string {
+	return "Removes a variable."
+}
@@ -0,0 +1,95 @@
+package sweetiebot
+
+import (
+	"time"
+)
+
+// Members become verified once they've been on a server for spam.verifieddays, have sent spam.verifiedmessages, and
+// haven't been warned or caught spamming in that time. The spam filters in spam.verifiedrelax don't apply to them.
+// Verification is stored in the database, recomputed by the verifymembers job, and revoked by any warning or spam offense.
+
+// Returns true if a member is verified on this server. The verified members are loaded the first time they're needed.
+func (info *GuildInfo) isVerified(user uint64) bool {
+	if info.config.Spam.VerifiedDays <= 0 {
+		return false
+	}
+	info.verifiedLock.RLock()
+	verified := info.verified
+	info.verifiedLock.RUnlock()
+	if verified == nil {
+		if !sb.db.status.get() {
+			return false
+		}
+		verified = sb.db.GetVerified(SBatoi(info.ID))
+		info.verifiedLock.Lock()
+		info.verified = verified
+		info.verifiedLock.Unlock()
+	}
+	return verified[user]
+}
+
+// AddOffense records an offense against a member. Anything worse than a message removed by the filter also revokes
+// their verification.
+func (info *GuildInfo) AddOffense(o Offense) {
+	sb.db.AddOffense(o, SBatoi(info.ID))
+	if o.Type == OFFENSE_FILTER {
+		return
+	}
+	info.verifiedLock.Lock()
+	if info.verified != nil {
+		delete(info.verified, o.User)
+	}
+	info.verifiedLock.Unlock()
+	if sb.db.RemoveVerified(SBatoi(info.ID), o.User) {
+		info.LogTo(LogModeration, getUserName(o.User, info), " is no longer verified: ", offenseNames[o.Type])
+	}
+}
+
+// Works out who should be verified on this server right now, and stores any changes
+func (info *GuildInfo) updateVerified(now time.Time) {
+	cutoff := now.Add(-time.Duration(info.config.Spam.VerifiedDays) * 24 * time.Hour)
+	guild := SBatoi(info.ID)
+	candidates := sb.db.GetVerifyCandidates(guild, cutoff, info.config.Spam.VerifiedMessages, cutoff)
+	current := sb.db.GetVerified(guild)
+	added, removed := 0, 0
+	for u := range candidates {
+		if !current[u] {
+			sb.db.AddVerified(guild, u)
+			added++
+		}
+	}
+	for u := range current {
+		if !candidates[u] {
+			sb.db.RemoveVerified(guild, u)
+			removed++
+		}
+	}
+	info.verifiedLock.Lock()
+	info.verified = candidates
+	info.verifiedLock.Unlock()
+	if added > 0 || removed > 0 {
+		info.LogTo(LogModeration, "Verified ", Pluralize(int64(added), " member"), " and unverified ", removed, " who no longer qualify. ", len(candidates), " members are verified.")
+	}
+}
+
+// Recomputes the verified members on every server that uses spam.verifieddays
+func recomputeVerified() {
+	if !sb.db.CheckStatus() {
+		return
+	}
+	sb.guildsLock.RLock()
+	guilds := make([]*GuildInfo, 0, len(sb.guilds))
+	for _, v := range sb.guilds {
+		guilds = append(guilds, v)
+	}
+	sb.guildsLock.RUnlock()
+	now := time.Now().UTC()
+	for _, info := range guilds {
+		if sb.quit.get() {
+			return
+		}
+		if info.config.Spam.VerifiedDays > 0 {
+			info.updateVerified(now)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sweetiebot
+
+import (
+	_ "embed" // needed for go:embed
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+//go:embed CHANGELOG.md
+var changelogFile string
+
+var changelogheaderregex = regexp.MustCompile(`^## ([0-9]+)\.([0-9]+)\.([0-9]+)\.([0-9]+)\s*$`)
+
+// Parses the embedded changelog, where each version starts with a "## major.minor.revision.build" header followed by its changes
+func parseChangelog(text string) map[int]string {
+	changelog := make(map[int]string)
+	version := -1
+	lines := []string{}
+	flush := func() {
+		if version >= 0 {
+			changelog[version] = strings.TrimSpace(strings.Join(lines, "\n"))
+		}
+		lines = lines[:0]
+	}
+	for _, line := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n") {
+		if m := changelogheaderregex.FindStringSubmatch(line); m != nil {
+			flush()
+			var v [4]byte
+			for i := range v {
+				n, _ := strconv.Atoi(m[i+1])
+				v[i] = byte(n)
+			}
+			version = AssembleVersion(v[0], v[1], v[2], v[3])
+		} else if version >= 0 {
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return changelog
+}
+
+// Returns the commit the binary was built from, and when, if go build was run inside a git checkout
+func buildCommit() (commit string, when string, modified bool) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", "", false
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			commit = s.Value
+		case "vcs.time":
+			when = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	return
+}
+
+type versionCommand struct {
+}
+
+func (c *versionCommand) Name() string {
+	return "Version"
+}
+func (c *versionCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	commit, when, modified := buildCommit()
+	if len(commit) == 0 {
+		commit = "unknown"
+	} else {
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if modified {
+			commit += " (modified)"
+		}
+		if t, err := time.Parse(time.RFC3339, when); err == nil {
+			commit += ", " + t.UTC().Format("2006-01-02")
+		}
+	}
+	changes, ok := sb.changelog[sb.version.Integer()]
+	if !ok {
+		changes = "No changelog for this version."
+	}
+	sb.guildsLock.RLock()
+	guilds := len(sb.guilds)
+	sb.guildsLock.RUnlock()
+	embed := &discordgo.MessageEmbed{
+		Type:  "rich",
+		Title: "Sweetie Bot v" + sb.version.String(),
+		Color: 0x3e92e5,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Commit", Value: commit, Inline: true},
+			{Name: "Go", Value: runtime.Version(), Inline: true},
+			{Name: "Uptime", Value: TimeDiff(time.Duration(time.Now().UTC().Unix()-sb.StartTime) * time.Second), Inline: true},
+			{Name: "Servers", Value: strconv.Itoa(guilds), Inline: true},
+			{Name: "Changes in this version", Value: truncateRunes(changes, 1024), Inline: false},
+		},
+	}
+	return "", false, embed
+}
+func (c *versionCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Shows which version of Sweetie Bot is running, the commit and Go version it was built with, how long it has been up, how many servers it's on, and what changed in this version. Use `!changelog` to see older versions.",
+	}
+}
+func (c *versionCommand) UsageShort() string { return "Shows the running version and its changes." }
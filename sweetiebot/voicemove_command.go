@@ -0,0 +1,196 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Voice moves are paced to stay clear of discord's limits
+const voiceMoveRate = 2
+const voiceMoveBurst = 5
+
+// Returns the voice channel a member is connected to, or an empty string if they aren't in one
+func userVoiceChannel(info *GuildInfo, user string) string {
+	g, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return ""
+	}
+	sb.dg.State.RLock()
+	defer sb.dg.State.RUnlock()
+	for _, v := range g.VoiceStates {
+		if v.UserID == user {
+			return v.ChannelID
+		}
+	}
+	return ""
+}
+
+// Returns everyone connected to a voice channel
+func voiceChannelMembers(info *GuildInfo, channel string) []string {
+	users := []string{}
+	g, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return users
+	}
+	sb.dg.State.RLock()
+	for _, v := range g.VoiceStates {
+		if v.ChannelID == channel {
+			users = append(users, v.UserID)
+		}
+	}
+	sb.dg.State.RUnlock()
+	return users
+}
+
+// Checks that a channel is a voice channel both the bot and the user can move members in and out of
+func checkVoiceMove(info *GuildInfo, channel string, user string) string {
+	ch, err := sb.dg.State.Channel(channel)
+	if err != nil || (ch.Type != discordgo.ChannelTypeGuildVoice && ch.Type != discordgo.ChannelTypeGuildStageVoice) {
+		return "```#" + getChannelName(channel) + " isn't a voice channel.```"
+	}
+	if perms, err := sb.dg.State.UserChannelPermissions(sb.SelfID, channel); err != nil || perms&discordgo.PermissionVoiceMoveMembers == 0 {
+		return "```I don't have the Move Members permission in " + ch.Name + ".```"
+	}
+	if user != info.OwnerID {
+		if perms, err := sb.dg.State.UserChannelPermissions(user, channel); err != nil || perms&discordgo.PermissionVoiceMoveMembers == 0 {
+			return "```You don't have the Move Members permission in " + ch.Name + ".```"
+		}
+	}
+	return ""
+}
+
+type moveAllCommand struct {
+}
+
+func (c *moveAllCommand) Name() string {
+	return "MoveAll"
+}
+func (c *moveAllCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "from", Desc: "The voice channel to move everyone out of.", Type: ArgChannel},
+		{Name: "to", Desc: "The voice channel to move everyone into.", Type: ArgChannel},
+	}
+}
+func (c *moveAllCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *moveAllCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	from := args.String("from")
+	to := args.String("to")
+	if from == to {
+		return "```Those are the same channel.```", false, nil
+	}
+	for _, ch := range []string{from, to} {
+		if e := checkVoiceMove(info, ch, msg.Author.ID); len(e) > 0 {
+			return e, false, nil
+		}
+	}
+	users := voiceChannelMembers(info, from)
+	if len(users) == 0 {
+		return "```Nobody is in " + getChannelName(from) + ".```", false, nil
+	}
+	if info.voicemove.test_and_set() {
+		return "```Members are already being moved. Wait for that to finish first.```", false, nil
+	}
+	go runMoveAll(info, from, to, users, msg.ChannelID, msg.Author)
+	return "```Moving " + Pluralize(int64(len(users)), " member") + " from " + getChannelName(from) + " to " + getChannelName(to) + ".```", false, nil
+}
+func (c *moveAllCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Moves everyone in one voice channel into another, such as when starting an event. Members are moved a couple at a time to avoid discord's rate limits, and anyone who disconnects before their turn is skipped. Both you and Sweetie Bot need the Move Members permission in both channels. Paste a voice channel's mention, which looks like `<#id>`, or right click it and copy its link.",
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *moveAllCommand) UsageShort() string { return "Moves everyone to another voice channel." }
+
+func runMoveAll(info *GuildInfo, from string, to string, users []string, channel string, author *discordgo.User) {
+	defer info.voicemove.clear()
+	var bucket TokenBucket
+	moved, left, failed := 0, 0, 0
+loop:
+	for _, user := range users {
+		if sb.quit.get() {
+			break
+		}
+		for !bucket.take(voiceMoveRate, voiceMoveBurst) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if userVoiceChannel(info, user) != from {
+			left++ // They disconnected or moved themselves while we were waiting
+			continue
+		}
+		err := CallAPI("GuildMemberMove", func() error { return sb.dg.GuildMemberMove(info.ID, user, &to) })
+		switch {
+		case err == nil:
+			moved++
+		case discordErrorCode(err) == discordgo.ErrCodeTargetIsNotConnectedToVoice:
+			left++
+		case ClassifyAPIError(err) == APIErrorPermission:
+			info.SendMessage(channel, "Stopped moving members: "+apiErrorMessage(err))
+			failed = len(users) - moved - left // If we lost permissions, every other move will fail too
+			break loop
+		default:
+			failed++
+		}
+	}
+	tally := fmt.Sprintf("Moved %v from %s to %s.", Pluralize(int64(moved), " member"), getChannelName(from), getChannelName(to))
+	if left > 0 {
+		tally += fmt.Sprintf(" %v left voice before they could be moved.", left)
+	}
+	if failed > 0 {
+		tally += fmt.Sprintf(" %v couldn't be moved.", failed)
+	}
+	info.LogTo(LogVoice, getUserName(SBatoi(author.ID), info), ": ", tally)
+	info.SendMessage(channel, "```"+tally+"```")
+}
+
+type summonCommand struct {
+}
+
+func (c *summonCommand) Name() string {
+	return "Summon"
+}
+func (c *summonCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "user", Desc: "The member to pull into your voice channel.", Type: ArgUser},
+	}
+}
+func (c *summonCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *summonCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	user := SBitoa(args.User("user"))
+	to := userVoiceChannel(info, msg.Author.ID)
+	if len(to) == 0 {
+		return "```You have to be in a voice channel to summon someone to it.```", false, nil
+	}
+	from := userVoiceChannel(info, user)
+	if len(from) == 0 {
+		return "```" + getUserName(SBatoi(user), info) + " isn't in a voice channel. Discord can only move members who are already connected.```", false, nil
+	}
+	if from == to {
+		return "```" + getUserName(SBatoi(user), info) + " is already in your voice channel.```", false, nil
+	}
+	for _, ch := range []string{from, to} {
+		if e := checkVoiceMove(info, ch, msg.Author.ID); len(e) > 0 {
+			return e, false, nil
+		}
+	}
+	err := CallAPI("GuildMemberMove", func() error { return sb.dg.GuildMemberMove(info.ID, user, &to) })
+	if discordErrorCode(err) == discordgo.ErrCodeTargetIsNotConnectedToVoice {
+		return "```" + getUserName(SBatoi(user), info) + " left voice before they could be moved.```", false, nil
+	}
+	if err != nil {
+		return apiErrorMessage(err), false, nil
+	}
+	return "```Moved " + getUserName(SBatoi(user), info) + " to " + getChannelName(to) + ".```", false, nil
+}
+func (c *summonCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Pulls a member who is connected to another voice channel into the one you are in. Both you and Sweetie Bot need the Move Members permission in both channels.",
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *summonCommand) UsageShort() string { return "Pulls someone into your voice channel." }
@@ -0,0 +1,257 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Offense types
+const (
+	OFFENSE_WARNING = iota
+	OFFENSE_SPAM    = iota
+	OFFENSE_FILTER  = iota // a message removed by the filter, which isn't an offense on its own
+)
+
+var offenseNames = map[uint8]string{
+	OFFENSE_WARNING: "Warned",
+	OFFENSE_SPAM:    "Silenced for spamming",
+	OFFENSE_FILTER:  "Filtered",
+}
+
+// Separates the messages removed by the filter from a user's real offenses, counting how many were removed in each tier
+func splitFilterOffenses(offenses []Offense) ([]Offense, map[string]int) {
+	r := make([]Offense, 0, len(offenses))
+	tiers := make(map[string]int)
+	for _, o := range offenses {
+		if o.Type == OFFENSE_FILTER {
+			tiers[o.Reason]++
+		} else {
+			r = append(r, o)
+		}
+	}
+	return r, tiers
+}
+
+// Applies the decay for a number of clean periods to an offense count. "linear" forgives one offense per period, "halving"
+// halves the count every period, and "reset" forgives everything after a single period.
+func decayOffenses(count int, periods int64, curve string) int {
+	if periods <= 0 || count == 0 {
+		return count
+	}
+	switch curve {
+	case "reset":
+		return 0
+	case "halving":
+		if periods >= 32 {
+			return 0
+		}
+		return count >> uint(periods)
+	}
+	if int64(count) <= periods {
+		return 0
+	}
+	return count - int(periods)
+}
+
+// Computes how many offenses still count against a user, given their offenses from oldest to newest. Every new offense
+// restarts the clean period, so offenses only drop off after a stretch of good behavior.
+func effectiveOffenses(offenses []Offense, info *GuildInfo, now time.Time) int {
+	if info.config.Warnings.DecayDays <= 0 {
+		return len(offenses)
+	}
+	period := time.Duration(info.config.Warnings.DecayDays) * 24 * time.Hour
+	count := 0
+	var last time.Time
+	for _, o := range offenses {
+		if count > 0 {
+			count = decayOffenses(count, int64(o.Timestamp.Sub(last)/period), info.config.Warnings.DecayCurve)
+		}
+		count++
+		last = o.Timestamp
+	}
+	if count > 0 {
+		count = decayOffenses(count, int64(now.Sub(last)/period), info.config.Warnings.DecayCurve)
+	}
+	return count
+}
+
+func describeOffenseDecay(info *GuildInfo) string {
+	days := info.config.Warnings.DecayDays
+	switch {
+	case days <= 0:
+		return "Offenses never expire."
+	case info.config.Warnings.DecayCurve == "reset":
+		return fmt.Sprintf("All offenses expire after %s without a new one.", Pluralize(days, " day"))
+	case info.config.Warnings.DecayCurve == "halving":
+		return fmt.Sprintf("Offenses are halved every %s without a new one.", Pluralize(days, " day"))
+	}
+	return fmt.Sprintf("One offense expires every %s without a new one.", Pluralize(days, " day"))
+}
+
+type warnCommand struct {
+}
+
+func (c *warnCommand) Name() string {
+	return "Warn"
+}
+func (c *warnCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "user", Desc: "A ping of the user, or their name in quotes.", Type: ArgUser},
+		{Name: "reason", Desc: "Why they are being warned. This is sent to them.", Optional: true, Variadic: true},
+	}
+}
+func (c *warnCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *warnCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	user := args.User("user")
+	reason := args.String("reason")
+	if len(reason) > 500 {
+		return "```The reason can't be longer than 500 characters.```", false, nil
+	}
+	now := time.Now().UTC()
+	info.AddOffense(Offense{Type: OFFENSE_WARNING, User: user, Moderator: SBatoi(msg.Author.ID), Reason: reason, Timestamp: now})
+	offenses, _ := splitFilterOffenses(sb.db.GetOffenses(user, SBatoi(info.ID)))
+	name := getUserName(user, info)
+	info.LogTo(LogModeration, getUserName(SBatoi(msg.Author.ID), info), " warned ", name, ": ", reason)
+
+	notice := "You have been warned on " + info.Name + "."
+	if len(reason) > 0 {
+		notice = "You have been warned on " + info.Name + ": " + reason
+	}
+	if channel, err := sb.dg.UserChannelCreate(SBitoa(user)); err == nil {
+		sb.dg.ChannelMessageSend(channel.ID, notice)
+	}
+	return fmt.Sprintf("```Warned %s. They have %v active and %v total offenses.```", name, effectiveOffenses(offenses, info, now), len(offenses)), false, nil
+}
+func (c *warnCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Records a warning against a user and sends them the reason in a private message. " + describeOffenseDecay(info),
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *warnCommand) UsageShort() string { return "Warns a user." }
+
+type warningsCommand struct {
+}
+
+func (c *warningsCommand) Name() string {
+	return "Warnings"
+}
+func (c *warningsCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "user", Desc: "A ping of the user, or their name.", Type: ArgUser, Variadic: true},
+	}
+}
+func (c *warningsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *warningsCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	user := args.User("user")
+	name := getUserName(user, info)
+	offenses, filtered := splitFilterOffenses(sb.db.GetOffenses(user, SBatoi(info.ID)))
+	if len(offenses) == 0 && len(filtered) == 0 {
+		return "```" + name + " has no recorded offenses.```", false, nil
+	}
+	lines := make([]string, 0, 23)
+	lines = append(lines, fmt.Sprintf("%s has %v active and %v total offenses. %s", name, effectiveOffenses(offenses, info, time.Now().UTC()), len(offenses), describeOffenseDecay(info)))
+	if len(filtered) > 0 {
+		counts := make([]string, 0, len(filterTierNames))
+		for _, tier := range filterTierNames {
+			counts = append(counts, fmt.Sprintf("%v %s", filtered[tier], tier))
+		}
+		lines = append(lines, "Messages removed by the filter: "+strings.Join(counts, ", "))
+	}
+	start := 0
+	if len(offenses) > 20 {
+		start = len(offenses) - 20
+		lines = append(lines, fmt.Sprintf("(%v older offenses not shown)", start))
+	}
+	for _, o := range offenses[start:] {
+		line := ApplyTimezone(o.Timestamp, info, msg.Author).Format("Jan 2, 2006 3:04pm") + ": " + offenseNames[o.Type]
+		if o.Type == OFFENSE_WARNING {
+			line += " by " + getUserName(o.Moderator, info)
+		}
+		if len(o.Reason) > 0 {
+			line += " (" + o.Reason + ")"
+		}
+		lines = append(lines, line)
+	}
+	return "```" + PartialSanitize(strings.Join(lines, "\n")) + "```", len(lines) > 10, nil
+}
+func (c *warningsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Lists a user's warnings and spam silences, along with how many of them still count against them, and how many of their messages the filter removed at each severity. " + describeOffenseDecay(info),
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *warningsCommand) UsageShort() string { return "Lists a user's offenses." }
+
+// The most moderation actions someone is shown when they look up their own record
+const myRecordLimit = 10
+
+// Posted under someone's record when warnings.appeal is empty
+const defaultAppealMessage = "If you think a moderator made a mistake, you can message one of them to appeal."
+
+type myRecordCommand struct {
+}
+
+func (c *myRecordCommand) Name() string {
+	return "MyRecord"
+}
+func (c *myRecordCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !info.config.Warnings.SelfService {
+		return "```This server doesn't let members look up their own moderation history. Ask a moderator instead.```", false, nil
+	}
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	user := SBatoi(msg.Author.ID)
+	gID := SBatoi(info.ID)
+	now := time.Now().UTC()
+	offenses, _ := splitFilterOffenses(sb.db.GetOffenses(user, gID))
+	lines := make([]string, 0, myRecordLimit+4)
+	lines = append(lines, fmt.Sprintf("Your record on %s: %v active and %v total offenses. %s", info.Name, effectiveOffenses(offenses, info, now), len(offenses), describeOffenseDecay(info)))
+	if m, err := info.GetMember(msg.Author.ID); err == nil && info.config.Spam.SilentRole != 0 && isSilenced(m, info) {
+		if t := sb.db.GetUnsilenceDate(gID, user); t != nil {
+			lines = append(lines, "You are silenced, and will be unsilenced in "+TimeDiff(t.Sub(now))+".")
+		} else {
+			lines = append(lines, "You are silenced until a moderator unsilences you.")
+		}
+	}
+	// Only actions taken on the user themselves are shown, and never who took them
+	actions := sb.db.SearchModlog(gID, user, now.AddDate(-1, 0, 0), joinTypes(modlogDefaultFilter.modlog), joinTypes(modlogDefaultFilter.offense), myRecordLimit, 0)
+	if len(actions) == 0 {
+		lines = append(lines, "Nothing has happened to you in the last year.")
+	} else {
+		lines = append(lines, "Your most recent moderation actions:")
+	}
+	for _, a := range actions {
+		line := "  " + ApplyTimezone(a.Timestamp, info, msg.Author).Format("Jan 2, 2006 3:04pm") + ": " + a.name()
+		if len(a.Reason) > 0 {
+			line += " (" + a.Reason + ")"
+		}
+		lines = append(lines, line)
+	}
+	appeal := info.config.Warnings.Appeal
+	if len(strings.TrimSpace(appeal)) == 0 {
+		appeal = defaultAppealMessage
+	}
+	appeal = renderTemplate(info, appeal, map[string]string{"user": "<@" + msg.Author.ID + ">", "username": msg.Author.Username, "modchannel": "<#" + SBitoa(info.config.Basic.ModChannel) + ">", "prefix": info.config.Basic.CommandPrefix})
+	return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```" + SanitizeMentions(appeal), true, nil
+}
+func (c *myRecordCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Sends you your own moderation history on this server in a private message: your most recent warnings, silences, kicks and bans with their reasons, how many offenses still count against you, and how long until you're unsilenced, if you are. Moderators aren't named. You can also PM this command to Sweetie Bot, which works even while you're silenced. Only works if the server has turned on `warnings.selfservice`.",
+	}
+}
+func (c *myRecordCommand) UsageShort() string { return "Shows why you were warned or silenced." }
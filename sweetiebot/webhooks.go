@@ -0,0 +1,237 @@
+package sweetiebot
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Persona is a name and avatar that announcements and witty responses can be posted under, using a webhook
+type Persona struct {
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
+
+// Every webhook the bot creates gets this name, so it can tell them apart from webhooks made by anyone else
+const personaWebhookName = "Sweetie Bot Personas"
+
+// Discord lets a webhook post about 5 messages every 2 seconds, so we pace ourselves to stay under that
+const personaWebhookRate = 2.0 // tokens per second
+const personaWebhookBurst = 5
+
+// Webhooks that haven't been used in this long are deleted by the daily cleanup
+const personaWebhookIdle = 7 * 24 * time.Hour
+
+type personaWebhook struct {
+	id       string
+	token    string
+	channel  string
+	bucket   TokenBucket
+	lastused int64
+}
+
+// The webhook the bot owns in each channel, by channel ID
+var personaWebhooks = make(map[string]*personaWebhook)
+var personaWebhooksLock sync.Mutex
+
+// Held while looking up or creating a channel's webhook, so two messages don't both create one. This is separate from
+// personaWebhooksLock so a slow API call in one channel doesn't hold up every other channel.
+var personaWebhookLookups = make(map[string]*sync.Mutex) // guarded by personaWebhooksLock
+
+// Returns the channel's webhook if it's already known, or else the lock for looking it up
+func cachedPersonaWebhook(channel string) (*personaWebhook, *sync.Mutex) {
+	personaWebhooksLock.Lock()
+	defer personaWebhooksLock.Unlock()
+	if hook, ok := personaWebhooks[channel]; ok {
+		return hook, nil
+	}
+	lookup, ok := personaWebhookLookups[channel]
+	if !ok {
+		lookup = &sync.Mutex{}
+		personaWebhookLookups[channel] = lookup
+	}
+	return nil, lookup
+}
+
+func cachePersonaWebhook(hook *personaWebhook) {
+	personaWebhooksLock.Lock()
+	personaWebhooks[hook.channel] = hook
+	personaWebhooksLock.Unlock()
+}
+
+// Finds the webhook the bot already owns in a channel, creating one if there isn't one and create is true. Returns nil
+// if there's no webhook and create is false. Webhooks can't be made in threads, so threads use their parent's.
+func getPersonaWebhook(channel string, create bool) (*personaWebhook, error) {
+	hook, lookup := cachedPersonaWebhook(channel)
+	if hook != nil {
+		return hook, nil
+	}
+	lookup.Lock()
+	defer lookup.Unlock()
+	if hook, _ = cachedPersonaWebhook(channel); hook != nil {
+		return hook, nil // Someone else found it while we were waiting
+	}
+	var hooks []*discordgo.Webhook
+	err := CallAPI("ChannelWebhooks", func() (e error) {
+		hooks, e = sb.dg.ChannelWebhooks(channel)
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hooks {
+		if h.User != nil && h.User.ID == sb.SelfID && h.Name == personaWebhookName && h.Token != "" {
+			hook = &personaWebhook{id: h.ID, token: h.Token, channel: channel, lastused: time.Now().UTC().Unix()}
+			cachePersonaWebhook(hook)
+			return hook, nil
+		}
+	}
+	if !create {
+		return nil, nil
+	}
+	var h *discordgo.Webhook
+	err = CallAPI("WebhookCreate", func() (e error) {
+		h, e = sb.dg.WebhookCreate(channel, personaWebhookName, "")
+		return e
+	})
+	if err != nil {
+		return nil, err
+	}
+	hook = &personaWebhook{id: h.ID, token: h.Token, channel: channel, lastused: time.Now().UTC().Unix()}
+	cachePersonaWebhook(hook)
+	return hook, nil
+}
+
+// Forgets a webhook that turned out to have been deleted, so the next message makes a new one
+func forgetPersonaWebhook(hook *personaWebhook) {
+	personaWebhooksLock.Lock()
+	if personaWebhooks[hook.channel] == hook {
+		delete(personaWebhooks, hook.channel)
+	}
+	personaWebhooksLock.Unlock()
+}
+
+// Waits until the webhook is allowed to post again, giving up after a while so a flood can't back up forever
+func (hook *personaWebhook) wait() bool {
+	for i := 0; i < 20; i++ {
+		if hook.bucket.take(personaWebhookRate, personaWebhookBurst) {
+			atomic.StoreInt64(&hook.lastused, time.Now().UTC().Unix())
+			return true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return false
+}
+
+// Returns the channel a webhook has to belong to, and the thread to post in, if the channel is a thread
+func personaWebhookChannel(channel string) (string, string) {
+	if ch, err := sb.dg.State.Channel(channel); err == nil && ch.IsThread() {
+		return ch.ParentID, channel
+	}
+	return channel, ""
+}
+
+// Posts a message in a channel under a persona's name and avatar, through a webhook the bot owns
+func sendAsPersona(info *GuildInfo, channel string, p Persona, m *discordgo.MessageSend) (*discordgo.Message, error) {
+	parent, thread := personaWebhookChannel(channel)
+	params := &discordgo.WebhookParams{Content: m.Content, Username: p.Name, AvatarURL: p.Avatar, Embeds: m.Embeds}
+	for attempt := 0; ; attempt++ {
+		hook, err := getPersonaWebhook(parent, true)
+		if err != nil {
+			return nil, err
+		}
+		if !hook.wait() {
+			recordDropped("webhook", info.ID, "webhook rate limit", "#"+getChannelName(channel)+" as "+p.Name+": "+m.Content)
+			return nil, errPersonaRateLimited
+		}
+		var posted *discordgo.Message
+		err = CallAPI("WebhookExecute", func() (e error) {
+			if thread != "" {
+				posted, e = sb.dg.WebhookThreadExecute(hook.id, hook.token, true, thread, params)
+			} else {
+				posted, e = sb.dg.WebhookExecute(hook.id, hook.token, true, params)
+			}
+			return e
+		})
+		if ClassifyAPIError(err) == APIErrorNotFound && attempt == 0 {
+			forgetPersonaWebhook(hook) // Someone deleted the webhook, so make a new one and try again
+			continue
+		}
+		return posted, err
+	}
+}
+
+// Edits a message that was posted under a persona. Returns false if the message didn't come from one of our webhooks.
+func editAsPersona(old *discordgo.Message, edit *discordgo.WebhookEdit) (bool, error) {
+	if old.WebhookID == "" {
+		return false, nil
+	}
+	parent, thread := personaWebhookChannel(old.ChannelID)
+	hook, err := getPersonaWebhook(parent, false)
+	if err != nil || hook == nil || hook.id != old.WebhookID {
+		return false, err
+	}
+	if thread != "" {
+		return true, errPersonaThreadEdit
+	}
+	return true, CallAPI("WebhookMessageEdit", func() error {
+		_, e := sb.dg.WebhookMessageEdit(hook.id, hook.token, old.ID, edit)
+		return e
+	})
+}
+
+var errPersonaRateLimited = errors.New("too many messages are being posted in this channel right now")
+var errPersonaThreadEdit = errors.New("messages posted under a persona can't be edited inside a thread")
+
+// Deletes the webhooks the bot made that haven't been used in a week, are in channels that no longer exist, or are in
+// servers that have no personas left. Webhooks found for the first time are given a week before they count as unused.
+func pruneWebhooks() {
+	sb.guildsLock.RLock()
+	guilds := make([]*GuildInfo, 0, len(sb.guilds))
+	for _, v := range sb.guilds {
+		guilds = append(guilds, v)
+	}
+	sb.guildsLock.RUnlock()
+	now := time.Now().UTC()
+	for _, info := range guilds {
+		var hooks []*discordgo.Webhook
+		err := CallAPI("GuildWebhooks", func() (e error) {
+			hooks, e = sb.dg.GuildWebhooks(info.ID)
+			return e
+		})
+		if err != nil {
+			continue // Usually we just aren't allowed to manage webhooks here, in which case we never made any
+		}
+		removed := 0
+		for _, h := range hooks {
+			if h.User == nil || h.User.ID != sb.SelfID || h.Name != personaWebhookName {
+				continue
+			}
+			personaWebhooksLock.Lock()
+			hook, ok := personaWebhooks[h.ChannelID]
+			duplicate := ok && hook.id != h.ID
+			if !ok || duplicate {
+				hook = &personaWebhook{id: h.ID, token: h.Token, channel: h.ChannelID, lastused: now.Unix()}
+				if !ok {
+					personaWebhooks[h.ChannelID] = hook
+				}
+			}
+			personaWebhooksLock.Unlock()
+			_, err := sb.dg.State.Channel(h.ChannelID)
+			orphaned := err != nil || len(info.config.Basic.Personas) == 0 || now.Sub(time.Unix(atomic.LoadInt64(&hook.lastused), 0)) > personaWebhookIdle
+			if duplicate || orphaned {
+				if CallAPI("WebhookDelete", func() error { return sb.dg.WebhookDelete(h.ID) }) == nil {
+					forgetPersonaWebhook(hook)
+					removed++
+				}
+				time.Sleep(time.Second)
+			}
+		}
+		if removed > 0 {
+			info.Log("Deleted ", Pluralize(int64(removed), " unused persona webhook"), ".")
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package sweetiebot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // Registers the decoders for backgrounds and avatars
+	_ "image/jpeg"
+	"image/png"
+	"strconv"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Size of the card when no background is set
+const welcomeCardWidth = 600
+const welcomeCardHeight = 200
+
+// Backgrounds larger than this are refused, so a huge image can't eat all the bot's memory when decoded
+const welcomeCardMaxSize = 2048
+
+// Avatars and letters are never drawn larger than this, whatever the config says, so a typo can't allocate gigabytes
+const welcomeCardMaxAvatar = 512
+const welcomeCardMaxTextScale = 16
+
+// Backgrounds are cached by URL, because every server would otherwise download its background again for every new member
+var welcomeCardBackgrounds = struct {
+	sync.Mutex
+	images map[string]image.Image
+}{images: make(map[string]image.Image)}
+
+// Color of the circle drawn when an avatar can't be downloaded
+var welcomeCardDefaultAvatar = color.RGBA{0x72, 0x89, 0xDA, 0xFF}
+
+// Downloads and decodes an image, refusing anything too large to draw onto a card
+func downloadImage(url string, maxsize int) (image.Image, error) {
+	data, _, err := downloadUpload(url, maxsize)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width > welcomeCardMaxSize || cfg.Height > welcomeCardMaxSize {
+		return nil, fmt.Errorf("image is larger than %vx%v", welcomeCardMaxSize, welcomeCardMaxSize)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+func getWelcomeCardBackground(url string) (image.Image, error) {
+	if len(url) == 0 {
+		return image.NewUniform(color.RGBA{0x2C, 0x2F, 0x33, 0xFF}), nil
+	}
+	welcomeCardBackgrounds.Lock()
+	img, ok := welcomeCardBackgrounds.images[url]
+	welcomeCardBackgrounds.Unlock()
+	if ok {
+		return img, nil
+	}
+	img, err := downloadImage(url, 8*1024*1024)
+	if err != nil {
+		return nil, err
+	}
+	welcomeCardBackgrounds.Lock()
+	if len(welcomeCardBackgrounds.images) >= 64 {
+		welcomeCardBackgrounds.images = make(map[string]image.Image) // Old backgrounds pile up as servers change them
+	}
+	welcomeCardBackgrounds.images[url] = img
+	welcomeCardBackgrounds.Unlock()
+	return img, nil
+}
+
+// A circle that can be used as a mask, so avatars are drawn round like they are in discord
+type circleMask struct {
+	size int
+}
+
+func (c *circleMask) ColorModel() color.Model { return color.AlphaModel }
+func (c *circleMask) Bounds() image.Rectangle { return image.Rect(0, 0, c.size, c.size) }
+func (c *circleMask) At(x, y int) color.Color {
+	r := float64(c.size) / 2
+	dx, dy := float64(x)+0.5-r, float64(y)+0.5-r
+	if dx*dx+dy*dy <= r*r {
+		return color.Alpha{0xFF}
+	}
+	return color.Alpha{0}
+}
+
+// Scales an image to a size by size square with nearest neighbor sampling, which avatars are already close enough to
+func scaleSquare(src image.Image, size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	b := src.Bounds()
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*b.Dx()/size, b.Min.Y+y*b.Dy()/size))
+		}
+	}
+	return dst
+}
+
+// Draws a welcome card for a member, with their avatar, username and member number on the server's background. Only the
+// background can make this fail, because a missing avatar is replaced with a plain circle.
+func renderWelcomeCard(info *GuildInfo, user *discordgo.User) ([]byte, error) {
+	cfg := &info.config.WelcomeCard
+	bg, err := getWelcomeCardBackground(cfg.Background)
+	if err != nil {
+		return nil, errors.New("couldn't load the background: " + err.Error())
+	}
+	bounds := image.Rect(0, 0, welcomeCardWidth, welcomeCardHeight)
+	if len(cfg.Background) > 0 {
+		bounds = image.Rect(0, 0, bg.Bounds().Dx(), bg.Bounds().Dy())
+	}
+	card := image.NewRGBA(bounds)
+	draw.Draw(card, bounds, bg, bg.Bounds().Min, draw.Src)
+
+	size := min(cfg.AvatarSize, welcomeCardMaxAvatar)
+	if size > 0 {
+		var avatar image.Image
+		if img, err := downloadImage(user.AvatarURL("256"), 2*1024*1024); err == nil {
+			avatar = scaleSquare(img, size)
+		} else {
+			avatar = image.NewUniform(welcomeCardDefaultAvatar)
+		}
+		r := image.Rect(cfg.AvatarX, cfg.AvatarY, cfg.AvatarX+size, cfg.AvatarY+size)
+		draw.DrawMask(card, r, avatar, image.Point{}, &circleMask{size}, image.Point{}, draw.Over)
+	}
+
+	textcolor := color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	if m := hexcolorregex.FindStringSubmatch(cfg.TextColor); m != nil {
+		c, _ := strconv.ParseUint(m[1], 16, 32)
+		textcolor = color.RGBA{uint8(c >> 16), uint8(c >> 8), uint8(c), 0xFF}
+	}
+	scale := cfg.TextScale
+	scale = max(1, min(scale, welcomeCardMaxTextScale))
+	name := user.Username
+	if fit := (bounds.Dx() - cfg.TextX) / (fontAdvance * scale); fit < len([]rune(name)) {
+		if fit < 1 {
+			fit = 1
+		}
+		name = string([]rune(name)[:fit])
+	}
+	drawText(card, cfg.TextX, cfg.TextY, scale, textcolor, name)
+	if g, err := sb.dg.State.Guild(info.ID); err == nil && g.MemberCount > 0 {
+		small := (scale + 1) / 2
+		drawText(card, cfg.TextX, cfg.TextY+10*scale, small, textcolor, "Member #"+strconv.Itoa(g.MemberCount))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, card); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sends a welcome card for a member to a channel along with a message
+func sendWelcomeCard(info *GuildInfo, channel string, user *discordgo.User, content string) error {
+	data, err := renderWelcomeCard(info, user)
+	if err != nil {
+		return err
+	}
+	_, err = sb.dg.ChannelMessageSendComplex(channel, &discordgo.MessageSend{
+		Content:         content,
+		Files:           []*discordgo.File{{Name: "welcome.png", ContentType: "image/png", Reader: bytes.NewReader(data)}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{user.ID}},
+	})
+	return err
+}
+
+// Posts the welcome message for a new member, as a welcome card if they're turned on. If the card can't be made, the
+// plain welcome message is sent instead, so a broken background never stops anyone from being welcomed. Cards are
+// drawn in the background, so a slow download doesn't hold up everything else that happens when someone joins.
+func sendWelcomeMessage(info *GuildInfo, user *discordgo.User) {
+	if info.config.WelcomeCard.Enabled {
+		go postWelcomeMessage(info, user)
+	} else {
+		postWelcomeMessage(info, user)
+	}
+}
+
+func postWelcomeMessage(info *GuildInfo, user *discordgo.User) {
+	channel := SBitoa(info.config.Users.WelcomeChannel)
+	content := "<@" + user.ID + "> " + renderTemplate(info, info.config.Users.WelcomeMessage, map[string]string{"user": "<@" + user.ID + ">", "username": user.Username})
+	if info.config.WelcomeCard.Enabled {
+		err := sendWelcomeCard(info, channel, user, content)
+		if err == nil {
+			return
+		}
+		info.Log("Failed to send a welcome card for ", user.Username, ": ", err.Error())
+	}
+	info.SendMessage(channel, content)
+}
+
+type welcomeCardCommand struct {
+	lastcard int64
+}
+
+func (c *welcomeCardCommand) Name() string {
+	return "WelcomeCard"
+}
+func (c *welcomeCardCommand) Args() []CommandArg {
+	return []CommandArg{
+		{Name: "user", Desc: "The member to draw a card for. If omitted, draws yours.", Type: ArgUser, Optional: true},
+	}
+}
+func (c *welcomeCardCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	return RunWithArgs(c, args, msg, indices, info)
+}
+func (c *welcomeCardCommand) Run(args *ParsedArgs, msg *discordgo.Message, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	user := msg.Author
+	if args.Has("user") {
+		m, err := info.GetMember(SBitoa(args.User("user")))
+		if err != nil {
+			return "```That user isn't on this server.```", false, nil
+		}
+		user = m.User
+	}
+	if !RateLimit(&c.lastcard, 5) {
+		return "```Welcome cards can only be drawn once every 5 seconds.```", false, nil
+	}
+	if err := sendWelcomeCard(info, msg.ChannelID, user, ""); err != nil {
+		return "```Couldn't draw the welcome card: " + err.Error() + "```", false, nil
+	}
+	return "", false, nil
+}
+func (c *welcomeCardCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc:   "Draws the welcome card a member would get when joining, so you can check how `welcomecard.*` looks before turning it on with `welcomecard.enabled`.",
+		Params: ArgsToParams(c.Args()),
+	}
+}
+func (c *welcomeCardCommand) UsageShort() string { return "Draws a welcome card." }
@@ -45,6 +45,12 @@ ALTER TABLE `schedule` ADD INDEX `INDEX_GUILD_DATE` (`Guild`, `Date`);
 -- Query: WHERE Guild = ? AND Type = 8 AND Data = ?
 ALTER TABLE `schedule` ADD INDEX `INDEX_GUILD_TYPE` (`Guild`, `Type`);
 
+-- modlog and offenses tables: Indexes for searching a whole server's history
+-- Used by: SearchModlog, when !modlog isn't given a user
+-- New installs already have these from sweetiebot.sql, so skip these two there
+ALTER TABLE `modlog` ADD INDEX `INDEX_GUILD_TIMESTAMP` (`Guild`, `Timestamp`);
+ALTER TABLE `offenses` ADD INDEX `INDEX_GUILD_TIMESTAMP` (`Guild`, `Timestamp`);
+
 
 -- ============================================================
 -- 2. OPTIMIZE RANDOM SELECTION QUERIES